@@ -39,7 +39,25 @@ func main() {
 	if application.Redis() != nil {
 		redisClient = application.Redis().Client()
 	}
-	r := router.New(application.Logger(), application.ProductsService, application.PriceHistoryService, application.ScrapingStatsService, application.CategoriesService, application.CitiesService, application.GetTranslator(), application.Postgres(), redisClient)
+	r := router.New(application.Logger(), router.Deps{
+		Products:         application.ProductsService,
+		PriceHistory:     application.PriceHistoryService,
+		ScrapingStats:    application.ScrapingStatsService,
+		Categories:       application.CategoriesService,
+		Cities:           application.CitiesService,
+		Attributes:       application.AttributesService,
+		Alerts:           application.AlertsService,
+		MatchReview:      application.MatchReviewService,
+		SelectorHealth:   application.SelectorHealthService,
+		SelectorVersions: application.SelectorVersionsService,
+		Candidates:       application.CandidatesService,
+		Categorizer:      application.CategorizerService,
+		Brands:           application.BrandsService,
+		AdminTokens:      cfg.Admin.Credentials(),
+		Translator:       application.GetTranslator(),
+		DB:               application.Postgres(),
+		Redis:            redisClient,
+	})
 
 	// Настройка HTTP сервера
 	srv := &http.Server{
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"time"
@@ -34,7 +35,7 @@ func main() {
 	autoconfigService := application.GetAutoconfigService()
 
 	if autoconfigService == nil {
-		log.Fatal("Autoconfig service is not available. Check OPENAI_API_KEY in .env", nil)
+		log.Fatal("Autoconfig service is not available. AUTOCONFIG_GENERATOR=openai requires OPENAI_API_KEY or OPENAI_BASE_URL in .env", nil)
 	}
 
 	ctx := context.Background()
@@ -43,6 +44,7 @@ func main() {
 	limit := flag.Int("limit", 1, "Number of candidates to process (default: 1)")
 	daemon := flag.Bool("daemon", false, "Run in daemon mode (process candidates continuously)")
 	interval := flag.Duration("interval", 5*time.Minute, "Interval between processing batches in daemon mode")
+	reconfigure := flag.Bool("reconfigure", false, "Re-configure shops with degraded selectors instead of new candidates")
 	flag.Parse()
 
 	if *daemon {
@@ -52,6 +54,8 @@ func main() {
 		})
 
 		for {
+			// Сломанные селекторы действующих магазинов важнее новых кандидатов
+			processDegradedShops(ctx, autoconfigService, *limit, log)
 			processed := processCandidates(ctx, autoconfigService, *limit, log)
 			
 			if processed == 0 {
@@ -63,6 +67,9 @@ func main() {
 			// Ждем перед следующей итерацией
 			time.Sleep(*interval)
 		}
+	} else if *reconfigure {
+		// Одноразовая повторная конфигурация деградировавших магазинов
+		processDegradedShops(ctx, autoconfigService, *limit, log)
 	} else {
 		// Одноразовая обработка
 		processCandidates(ctx, autoconfigService, *limit, log)
@@ -120,3 +127,33 @@ func processCandidates(ctx context.Context, service *autoconfig.Service, limit i
 	return processed
 }
 
+// processDegradedShops заново генерирует селекторы магазинов, помеченных детектором деградации
+func processDegradedShops(ctx context.Context, service *autoconfig.Service, limit int, log *logger.Logger) int {
+	successful := 0
+	failed := 0
+
+	for i := 0; i < limit; i++ {
+		err := service.ProcessNextDegradedShop(ctx)
+		if errors.Is(err, autoconfig.ErrNoDegradedShops) {
+			break
+		}
+		if err != nil {
+			failed++
+			log.Error("Failed to re-configure degraded shop", map[string]interface{}{
+				"error":  err.Error(),
+				"number": i + 1,
+			})
+			continue
+		}
+		successful++
+	}
+
+	if successful > 0 || failed > 0 {
+		log.Info("🔧 Re-configuration summary", map[string]interface{}{
+			"successful": successful,
+			"failed":     failed,
+		})
+	}
+
+	return successful
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/solomonczyk/izborator/internal/app"
+	"github.com/solomonczyk/izborator/internal/brands"
+	"github.com/solomonczyk/izborator/internal/config"
+)
+
+// Предлагает синонимы брендов по схожести написаний из товаров со справочником брендов.
+// С -apply принимает предложения, с -canonicalize переименовывает бренды товаров
+// в канонические названия справочника
+func main() {
+	_ = godotenv.Load()
+
+	minSimilarity := flag.Float64("min-similarity", brands.DefaultMinSimilarity, "Minimum similarity (0..1] between a brand spelling and a registry brand")
+	apply := flag.Bool("apply", false, "Save proposed aliases and rename matching products")
+	canonicalize := flag.Bool("canonicalize", false, "Rename product brands to canonical registry names")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	application, err := app.NewAPIApp(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize app: %v", err)
+	}
+	defer application.Close()
+
+	ctx := context.Background()
+	service := application.BrandsService
+
+	proposals, err := service.Proposals(ctx, *minSimilarity)
+	if err != nil {
+		log.Fatalf("Failed to propose brand aliases: %v", err)
+	}
+
+	fmt.Printf("🔍 Alias proposals (min similarity %.2f): %d\n", *minSimilarity, len(proposals))
+	for _, p := range proposals {
+		fmt.Printf("  %-30s -> %-20s similarity=%.2f products=%d\n", p.Alias, p.BrandName, p.Similarity, p.Products)
+	}
+
+	if *apply {
+		var applied int
+		var renamed int64
+		for _, p := range proposals {
+			count, err := service.AddAlias(ctx, p.BrandID, p.Alias)
+			if err != nil {
+				fmt.Printf("❌ %s -> %s: %v\n", p.Alias, p.BrandName, err)
+				continue
+			}
+			applied++
+			renamed += count
+		}
+		fmt.Printf("✅ Aliases saved: %d, products renamed: %d\n", applied, renamed)
+	}
+
+	if *canonicalize {
+		renamed, err := service.CanonicalizeProducts(ctx)
+		if err != nil {
+			log.Fatalf("Failed to canonicalize product brands: %v", err)
+		}
+		fmt.Printf("✅ Products renamed to canonical brands: %d\n", renamed)
+	}
+}
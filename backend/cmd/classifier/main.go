@@ -9,6 +9,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/solomonczyk/izborator/internal/app"
+	"github.com/solomonczyk/izborator/internal/candidates"
 	"github.com/solomonczyk/izborator/internal/classifier"
 	"github.com/solomonczyk/izborator/internal/config"
 	"github.com/solomonczyk/izborator/internal/logger"
@@ -29,7 +30,7 @@ func main() {
 	// Флаги
 	testDomain := flag.String("domain", "", "Test single domain")
 	testList := flag.Bool("test-list", false, "Test on predefined list of shops and non-shops")
-	classifyAll := flag.Bool("classify-all", false, "Classify all domains with status 'new' from database")
+	classifyAll := flag.Bool("classify-all", false, "Classify all domains with status 'discovered' from database")
 	limit := flag.Int("limit", 0, "Limit number of domains to classify (0 = no limit)")
 	flag.Parse()
 
@@ -59,7 +60,7 @@ func main() {
 	fmt.Println("Usage:")
 	fmt.Println("  -domain <domain>     Test single domain")
 	fmt.Println("  -test-list           Test on predefined list")
-	fmt.Println("  -classify-all        Classify all domains with status 'new' from database")
+	fmt.Println("  -classify-all        Classify all domains with status 'discovered' from database")
 	fmt.Println("  -limit <number>      Limit number of domains to classify (use with -classify-all)")
 }
 
@@ -172,7 +173,7 @@ func testPredefinedList(ctx context.Context, classifierService *classifier.Servi
 	}
 }
 
-// classifyAllDomains классифицирует все домены со статусом "new" из БД
+// classifyAllDomains классифицирует все домены со статусом "discovered" из БД
 func classifyAllDomains(ctx context.Context, limit int, log *logger.Logger) {
 	// Загрузка конфигурации
 	cfg, err := config.Load()
@@ -190,17 +191,17 @@ func classifyAllDomains(ctx context.Context, limit int, log *logger.Logger) {
 	storage := application.GetClassifierStorage()
 	classifierService := application.Classifier
 
-	// Получаем все домены со статусом "new"
+	// Получаем все домены со статусом "discovered"
 	if limit == 0 {
 		limit = 1000 // Большое число, чтобы получить все
 	}
 
 	log.Info("Fetching domains to classify", map[string]interface{}{
-		"status": "new",
+		"status": candidates.StatusDiscovered,
 		"limit":  limit,
 	})
 
-	domains, err := storage.ListPotentialShopsByStatus("new", limit)
+	domains, err := storage.ListPotentialShopsByStatus(string(candidates.StatusDiscovered), limit)
 	if err != nil {
 		log.Fatal("Failed to fetch domains", map[string]interface{}{"error": err.Error()})
 	}
@@ -239,22 +240,29 @@ func classifyAllDomains(ctx context.Context, limit int, log *logger.Logger) {
 		// Обновляем статус и confidence score
 		shop.ConfidenceScore = result.Score.TotalScore
 
-		// Определяем статус на основе результата
+		// Определяем статус на основе результата.
+		// Сомнительные сайты идут дальше с пометкой needs_review: магазин из них
+		// всё равно создаётся только после одобрения администратора
+		if shop.Metadata == nil {
+			shop.Metadata = make(map[string]interface{})
+		}
+		delete(shop.Metadata, candidates.MetadataNeedsReview)
 		if result.IsShop {
-			shop.Status = "classified"
+			shop.Status = string(candidates.StatusClassified)
+			shop.StatusReason = fmt.Sprintf("classified as %s (score %.2f)", result.SiteType, result.Score.TotalScore)
 			classified++
 		} else if result.Score.TotalScore >= 0.5 {
-			shop.Status = "pending_review"
+			shop.Status = string(candidates.StatusClassified)
+			shop.StatusReason = fmt.Sprintf("low classification confidence (score %.2f), needs review", result.Score.TotalScore)
+			shop.Metadata[candidates.MetadataNeedsReview] = true
 			pendingReview++
 		} else {
-			shop.Status = "rejected"
+			shop.Status = string(candidates.StatusRejected)
+			shop.StatusReason = fmt.Sprintf("not a shop (score %.2f)", result.Score.TotalScore)
 			rejected++
 		}
 
 		// Обновляем метаданные с результатами классификации
-		if shop.Metadata == nil {
-			shop.Metadata = make(map[string]interface{})
-		}
 		shop.Metadata["site_type"] = result.SiteType // Сохраняем тип сайта для AutoConfig
 		shop.Metadata["classification"] = map[string]interface{}{
 			"keywords_score":  result.Score.KeywordsScore,
@@ -288,10 +296,10 @@ func classifyAllDomains(ctx context.Context, limit int, log *logger.Logger) {
 		})
 
 		statusIcon := "✅"
-		if shop.Status == "rejected" {
-			statusIcon = "❌"
-		} else if shop.Status == "pending_review" {
+		if result.Score.TotalScore >= 0.5 && !result.IsShop {
 			statusIcon = "⚠️"
+		} else if shop.Status == string(candidates.StatusRejected) {
+			statusIcon = "❌"
 		}
 
 		log.Info("Domain classified", map[string]interface{}{
@@ -312,7 +320,7 @@ func classifyAllDomains(ctx context.Context, limit int, log *logger.Logger) {
 	fmt.Println("\n=== Classification Summary ===")
 	fmt.Printf("Total processed: %d\n", len(domains))
 	fmt.Printf("✅ Classified (shops): %d\n", classified)
-	fmt.Printf("⚠️  Pending review: %d\n", pendingReview)
+	fmt.Printf("⚠️  Low confidence (classified, needs review): %d\n", pendingReview)
 	fmt.Printf("❌ Rejected: %d\n", rejected)
 	fmt.Printf("⚠️  Errors: %d\n", errors)
 	fmt.Printf("\nSuccess rate: %.1f%%\n", float64(classified+pendingReview)/float64(len(domains))*100)
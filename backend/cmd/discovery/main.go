@@ -1,94 +1,22 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/url"
+	"os"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/solomonczyk/izborator/internal/app"
-	"github.com/solomonczyk/izborator/internal/classifier"
+	"github.com/solomonczyk/izborator/internal/candidates"
 	"github.com/solomonczyk/izborator/internal/config"
+	"github.com/solomonczyk/izborator/internal/discovery"
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/sitemap"
 )
 
-// Dorking Queries — запросы для поиска магазинов и услуг в Сербии
-var queries = []string{
-	// E-commerce магазины (существующие запросы)
-	"site:.rs \"dodaj u korpu\"",
-	"site:.rs \"kupi odmah\"",
-	"site:.rs \"cena rsd\"",
-	"site:.rs inurl:proizvod",
-	"site:.rs inurl:kategorija",
-	"site:.rs \"besplatna dostava\" cena",
-	"site:.rs \"online prodavnica\"",
-	"site:.rs \"internet prodavnica\"",
-	"site:.rs \"e-shop\"",
-	"site:.rs \"webshop\"",
-	
-	// Услуги - прайс-листы и цены
-	"site:.rs \"cenovnik usluga\"",
-	"site:.rs \"cenovnik\" cena",
-	"site:.rs \"cena usluge\"",
-	"site:.rs \"cena rada\"",
-	"site:.rs \"zakazivanje termina\"",
-	"site:.rs \"rezervacija\" cena",
-	
-	// Медицинские услуги
-	"site:.rs \"zubarska ordinacija\" cene",
-	"site:.rs \"dermatolog\" cena",
-	"site:.rs \"fizioterapija\" cena",
-	"site:.rs \"masaza\" cena",
-	
-	// Красота и уход
-	"site:.rs \"frizerski salon\" cena",
-	"site:.rs \"manikir pedikir\" cena",
-	"site:.rs \"kozmeticki salon\" cena",
-	
-	// Ремонт и обслуживание
-	"site:.rs \"servis\" cena",
-	"site:.rs \"popravka\" cena",
-	"site:.rs \"montaza\" cena",
-	
-	// Образование и курсы
-	"site:.rs \"kurs\" cena",
-	"site:.rs \"obuka\" cena",
-	"site:.rs \"skola\" cena",
-	
-	// Юридические и консультационные услуги
-	"site:.rs \"advokat\" cena",
-	"site:.rs \"notar\" cena",
-	"site:.rs \"konsultacije\" cena",
-	
-	// Транспорт и доставка
-	"site:.rs \"prevoz\" cena",
-	"site:.rs \"dostava\" cena",
-	"site:.rs \"kurirska sluzba\" cena",
-	
-	// Общие паттерны для услуг
-	"site:.rs inurl:cenovnik",
-	"site:.rs inurl:cene",
-	"site:.rs inurl:usluge",
-	"site:.rs \"tabela cena\"",
-	"site:.rs \"cena po satu\"",
-	"site:.rs \"cena po terminu\"",
-}
-
-// GoogleResult структура ответа Google Custom Search API
-type GoogleResult struct {
-	Items []struct {
-		Link  string `json:"link"`
-		Title string `json:"title"`
-	} `json:"items"`
-	SearchInformation struct {
-		TotalResults string `json:"totalResults"`
-	} `json:"searchInformation"`
-}
-
 func main() {
 	// Загрузка .env файла
 	_ = godotenv.Load()
@@ -107,20 +35,22 @@ func main() {
 	defer application.Close()
 
 	log := application.Logger()
-	storage := application.GetClassifierStorage()
-
-	// Получаем ключи из конфигурации
-	apiKey := cfg.Google.APIKey
-	cx := cfg.Google.CX
 
-	// Флаги (опциональные, для переопределения)
+	// Флаги
+	sourcesFlag := flag.String("sources", "google", "Comma-separated discovery sources: google, seeds, outbound, sitemaps")
 	apiKeyFlag := flag.String("key", "", "Google API Key (optional, overrides env)")
 	cxFlag := flag.String("cx", "", "Custom Search Engine ID (optional, overrides env)")
-	maxResults := flag.Int("max-results", 100, "Maximum results per query (default: 100, max: 100)")
-	delay := flag.Duration("delay", 1*time.Second, "Delay between requests (default: 1s)")
+	maxResults := flag.Int("max-results", 100, "Maximum Google results per query (max: 100)")
+	delay := flag.Duration("delay", 1*time.Second, "Delay between Google requests")
+	seedFile := flag.String("seeds", "", "Seed list file: one domain/URL per line or CSV with a domain column")
+	seedTag := flag.String("seed-tag", "seed_list", "Source tag for domains from the seed list (e.g. registry)")
+	tlds := flag.String("tlds", ".rs", "Comma-separated domain suffixes accepted from outbound links (empty = any)")
+	sitemapLimit := flag.Int("sitemap-limit", 200, "Number of discovered candidates whose sitemaps are inspected")
 	flag.Parse()
 
-	// Если переданы флаги, используем их (для обратной совместимости)
+	// Google ключи из конфигурации; флаги переопределяют (для обратной совместимости)
+	apiKey := cfg.Google.APIKey
+	cx := cfg.Google.CX
 	if *apiKeyFlag != "" {
 		apiKey = *apiKeyFlag
 	}
@@ -128,143 +58,47 @@ func main() {
 		cx = *cxFlag
 	}
 
-	if apiKey == "" || cx == "" {
-		log.Fatal("API Key and CX are required. Set GOOGLE_API_KEY and GOOGLE_CX in .env or use -key and -cx flags", nil)
-	}
-
-	if *maxResults > 100 {
-		*maxResults = 100 // Google ограничивает до 100 результатов на запрос
+	ctx := context.Background()
+	var sources []discovery.Source
+	for _, name := range splitList(*sourcesFlag) {
+		switch name {
+		case "google":
+			if apiKey == "" || cx == "" {
+				log.Fatal("API Key and CX are required for google source. Set GOOGLE_API_KEY and GOOGLE_CX in .env or use -key and -cx flags", nil)
+			}
+			sources = append(sources, discovery.NewGoogleSource(apiKey, cx, nil, *maxResults, *delay))
+		case "seeds":
+			if *seedFile == "" {
+				log.Fatal("-seeds file is required for seeds source", nil)
+			}
+			sources = append(sources, discovery.NewSeedListSource(*seedFile, *seedTag))
+		case "outbound":
+			sources = append(sources, discovery.NewOutboundLinksSource(application.GetKnownShops(), nil, cfg.Scraper.UserAgent, splitList(*tlds)))
+		case "sitemaps":
+			domains := sitemapDomains(application, *seedFile, *sitemapLimit, log)
+			sources = append(sources, discovery.NewSitemapSource(sitemap.New(nil, cfg.Scraper.UserAgent), domains))
+		default:
+			log.Fatal("Unknown discovery source", map[string]interface{}{"source": name})
+		}
 	}
-
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+	if len(sources) == 0 {
+		log.Fatal("No discovery sources selected, use -sources", nil)
 	}
 
-	totalDiscovered := 0
-	totalSkipped := 0
-
 	log.Info("🔍 Starting Discovery Worker", map[string]interface{}{
-		"queries":     len(queries),
-		"max_results": *maxResults,
-		"delay":       delay.String(),
+		"sources": *sourcesFlag,
 	})
 
-	for i, query := range queries {
-		log.Info("🔎 Processing query", map[string]interface{}{
-			"query":  query,
-			"number": i + 1,
-			"total":  len(queries),
-		})
-
-		// Google разрешает только 100 результатов на запрос (start=1, 11, 21, ... 91)
-		// Максимум 10 страниц по 10 результатов
-		pages := (*maxResults + 9) / 10 // Округление вверх
-		if pages > 10 {
-			pages = 10
-		}
-
-		for page := 0; page < pages; page++ {
-			start := page*10 + 1
-
-			googleURL := fmt.Sprintf(
-				"https://www.googleapis.com/customsearch/v1?key=%s&cx=%s&q=%s&start=%d&gl=rs&num=10",
-				apiKey, cx, url.QueryEscape(query), start,
-			)
-
-			resp, err := client.Get(googleURL)
-			if err != nil {
-				log.Error("Failed to request Google", map[string]interface{}{
-					"error": err.Error(),
-					"query": query,
-					"page":  page + 1,
-				})
-				continue
-			}
-
-			if resp.StatusCode != http.StatusOK {
-				resp.Body.Close()
-				log.Error("Google API returned error", map[string]interface{}{
-					"status": resp.StatusCode,
-					"query":  query,
-					"page":   page + 1,
-				})
-				continue
-			}
-
-			var result GoogleResult
-			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-				resp.Body.Close()
-				log.Error("Failed to decode Google response", map[string]interface{}{
-					"error": err.Error(),
-					"query": query,
-				})
-				continue
-			}
-			resp.Body.Close()
-
-			if len(result.Items) == 0 {
-				log.Info("No more results for query", map[string]interface{}{"query": query})
-				break
-			}
-
-			for _, item := range result.Items {
-				domain := extractDomain(item.Link)
-				if domain == "" {
-					continue
-				}
-
-				// Нормализация домена (убираем www.)
-				domain = normalizeDomain(domain)
-
-				// Проверяем, не существует ли уже этот домен
-				existing, err := storage.GetPotentialShopByDomain(domain)
-				if err == nil && existing != nil {
-					totalSkipped++
-					continue
-				}
-
-				// Создаем новый кандидат
-				shop := &classifier.PotentialShop{
-					ID:             uuid.New().String(),
-					Domain:         domain,
-					Source:         "google_search",
-					Status:         "new",
-					ConfidenceScore: 0.0,
-					DiscoveredAt:   time.Now().Format(time.RFC3339),
-					Metadata: map[string]interface{}{
-						"title":      item.Title,
-						"url":        item.Link,
-						"query":      query,
-						"page":       page + 1,
-						"discovered": time.Now().Format(time.RFC3339),
-					},
-				}
-
-				if err := storage.SavePotentialShop(shop); err != nil {
-					log.Error("Failed to save potential shop", map[string]interface{}{
-						"error":  err.Error(),
-						"domain": domain,
-					})
-					continue
-				}
-
-				totalDiscovered++
-				log.Info("🆕 Discovered candidate", map[string]interface{}{
-					"domain": domain,
-					"title":  item.Title,
-				})
-			}
-
-			// Важно! Не спамим Google, иначе забанят ключ
-			if page < pages-1 {
-				time.Sleep(*delay)
-			}
-		}
+	stats, err := application.DiscoveryService.Run(ctx, sources...)
+	if err != nil {
+		log.Fatal("Discovery failed", map[string]interface{}{"error": err.Error()})
+	}
 
-		// Задержка между запросами
-		if i < len(queries)-1 {
-			time.Sleep(*delay * 2)
-		}
+	totalDiscovered, totalSkipped := 0, 0
+	for name, s := range stats {
+		totalDiscovered += s.Saved
+		totalSkipped += s.Skipped
+		fmt.Printf("%-16s found=%d saved=%d updated=%d skipped=%d %s\n", name, s.Found, s.Saved, s.Updated, s.Skipped, s.Error)
 	}
 
 	log.Info("✅ Discovery completed", map[string]interface{}{
@@ -274,28 +108,41 @@ func main() {
 	})
 }
 
-// extractDomain извлекает домен из URL
-func extractDomain(u string) string {
-	parsed, err := url.Parse(u)
+// sitemapDomains домены для чтения sitemap: список из -seeds и ещё не классифицированные кандидаты
+func sitemapDomains(application *app.App, seedFile string, limit int, log *logger.Logger) []string {
+	var domains []string
+	if seedFile != "" {
+		file, err := os.Open(seedFile)
+		if err != nil {
+			log.Fatal("Failed to open seed list", map[string]interface{}{"error": err.Error()})
+		}
+		seeds, err := discovery.ParseSeedList(file)
+		file.Close()
+		if err != nil {
+			log.Fatal("Failed to parse seed list", map[string]interface{}{"error": err.Error()})
+		}
+		for _, seed := range seeds {
+			domains = append(domains, seed.Domain)
+		}
+	}
+
+	shops, err := application.GetClassifierStorage().ListPotentialShopsByStatus(string(candidates.StatusDiscovered), limit)
 	if err != nil {
-		return ""
+		log.Fatal("Failed to list discovered candidates", map[string]interface{}{"error": err.Error()})
 	}
-	return parsed.Host
+	for _, shop := range shops {
+		domains = append(domains, shop.Domain)
+	}
+	return domains
 }
 
-// normalizeDomain нормализует домен (убирает www., приводит к нижнему регистру)
-func normalizeDomain(domain string) string {
-	domain = strings.ToLower(domain)
-	domain = strings.TrimSpace(domain)
-	
-	// Убираем www.
-		domain = strings.TrimPrefix(domain, "www.")
-	
-	// Убираем порт, если есть
-	if idx := strings.Index(domain, ":"); idx != -1 {
-		domain = domain[:idx]
+// splitList разбирает список через запятую
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	
-	return domain
+	return items
 }
-
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,19 +15,45 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/solomonczyk/izborator/internal/config"
 	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/selectorfixtures"
+	"github.com/solomonczyk/izborator/internal/storage"
 )
 
+// defaultFixturesDir каталог фикстур относительно backend/
+const defaultFixturesDir = "testdata/selector-fixtures"
+
 func main() {
-	if len(os.Args) < 2 {
+	snapshot := flag.Bool("snapshot", false, "сохранить страницу -url магазина -shop в фикстуры с ожидаемым результатом текущих селекторов")
+	replay := flag.Bool("replay", false, "проверить селекторы на сохранённых фикстурах без сети")
+	shopID := flag.String("shop", "", "ID магазина (snapshot) или каталог/ID магазина для фильтра (replay)")
+	pageURL := flag.String("url", "", "URL страницы для snapshot")
+	kind := flag.String("kind", string(selectorfixtures.KindProduct), "тип страницы для snapshot: product | catalog")
+	name := flag.String("name", "", "имя фикстуры (по умолчанию из URL)")
+	dir := flag.String("dir", defaultFixturesDir, "каталог фикстур")
+	live := flag.Bool("live", false, "replay с актуальными селекторами магазинов из БД вместо сохранённых в shop.json")
+	flag.Parse()
+
+	switch {
+	case *snapshot:
+		runSnapshot(*dir, *shopID, *pageURL, selectorfixtures.Kind(*kind), *name)
+		return
+	case *replay:
+		runReplay(*dir, *shopID, *live)
+		return
+	}
+
+	args := flag.Args()
+	if len(args) < 1 {
 		fmt.Println("Использование: go run cmd/test-selectors/main.go <URL> [selector]")
 		fmt.Println("Пример: go run cmd/test-selectors/main.go https://gigatron.rs/mobilni-telefoni-tableti-i-oprema/mobilni-telefoni")
+		fmt.Println("Фикстуры: -snapshot -shop <id> -url <URL> [-kind catalog] | -replay [-shop <id>] [-live]")
 		os.Exit(1)
 	}
 
-	url := os.Args[1]
+	url := args[0]
 	selector := ".product-box a, .product-item a, .product-card a, .product-title a, article a, .item a"
-	if len(os.Args) > 2 {
-		selector = os.Args[2]
+	if len(args) > 1 {
+		selector = args[1]
 	}
 
 	_ = godotenv.Load()
@@ -196,3 +226,106 @@ func main() {
 		fmt.Println()
 	}
 }
+
+// runSnapshot скачивает страницу магазина и сохраняет её в фикстуры вместе с результатом
+// текущих селекторов как ожидаемым. Результат стоит проверить глазами перед коммитом
+func runSnapshot(dir, shopID, pageURL string, kind selectorfixtures.Kind, name string) {
+	if shopID == "" || pageURL == "" || !kind.Valid() {
+		fmt.Println("❌ snapshot требует -shop, -url и -kind product|catalog")
+		os.Exit(1)
+	}
+
+	log, pg := connect()
+	defer pg.Close()
+
+	shop, err := storage.NewScraperAdapter(pg).GetShopConfig(shopID)
+	if err != nil {
+		fmt.Printf("❌ Failed to load shop config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	html, err := selectorfixtures.Capture(ctx, &http.Client{Timeout: 60 * time.Second}, pageURL)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fixture := &selectorfixtures.Fixture{
+		Name:       name,
+		Kind:       kind,
+		URL:        pageURL,
+		CapturedAt: time.Now().UTC(),
+	}
+	if err := selectorfixtures.NewRunner(log).Expect(ctx, shop, fixture, html); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	shopDir, err := selectorfixtures.Save(dir, shop, fixture, html)
+	if err != nil {
+		fmt.Printf("❌ Failed to save fixture: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Fixture saved: %s/%s.json\n", shopDir, fixture.Name)
+}
+
+// runReplay применяет селекторы магазинов к сохранённым страницам и печатает расхождения
+// по полям: по умолчанию селекторы из shop.json (без сети и БД, для CI), с live - актуальные
+// из БД. Код выхода 1 при любом расхождении
+func runReplay(dir, shopFilter string, live bool) {
+	suites, err := selectorfixtures.Load(dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if shopFilter != "" {
+		filtered := suites[:0]
+		for _, suite := range suites {
+			if suite.Shop.ID == shopFilter || filepath.Base(suite.Dir) == shopFilter {
+				filtered = append(filtered, suite)
+			}
+		}
+		suites = filtered
+		if len(suites) == 0 {
+			fmt.Printf("❌ %v: %s\n", selectorfixtures.ErrShopNotFound, shopFilter)
+			os.Exit(1)
+		}
+	}
+
+	if live {
+		_, pg := connect()
+		defer pg.Close()
+		checked := selectorfixtures.UseLiveSelectors(storage.NewScraperAdapter(pg), suites)
+		if skipped := len(suites) - len(checked); skipped > 0 {
+			fmt.Printf("⏭️  Skipped %d fixture set(s) of shops missing from the database\n", skipped)
+		}
+		suites = checked
+	}
+
+	// Логи парсера заглушены: расхождения выводятся отчётом
+	report := selectorfixtures.NewRunner(logger.New("error")).Run(context.Background(), suites)
+	fmt.Print(report.Format())
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// connect загружает конфигурацию и подключается к PostgreSQL
+func connect() (*logger.Logger, *storage.Postgres) {
+	_ = godotenv.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("❌ Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	log := logger.New(cfg.LogLevel)
+
+	pg, err := storage.NewPostgres(&cfg.DB, log)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to PostgreSQL: %v\n", err)
+		os.Exit(1)
+	}
+	return log, pg
+}
@@ -7,16 +7,19 @@ import (
 	"flag"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/solomonczyk/izborator/internal/app"
 	"github.com/solomonczyk/izborator/internal/config"
+	"github.com/solomonczyk/izborator/internal/indexing"
 	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/pricehistory"
 	"github.com/solomonczyk/izborator/internal/queue"
+	"github.com/solomonczyk/izborator/internal/rescrape"
 	"github.com/solomonczyk/izborator/internal/scraper"
 )
 
@@ -46,7 +49,11 @@ func main() {
 	processRaw := flag.Bool("process", false, "Run processor once")
 	batchSize := flag.Int("batch-size", 100, "Batch size for processing")
 	reindex := flag.Bool("reindex", false, "Run full reindex once")
-	discover := flag.Bool("discover", false, "Run catalog discovery once")
+	rebuild := flag.Bool("rebuild", false, "Rebuild search index without downtime once")
+	discover := flag.Bool("discover", false, "Run catalog discovery once (found products are added to the rescrape schedule)")
+	rescrapeOnce := flag.Bool("rescrape", false, "Seed the rescrape schedule and run due rescrapes once")
+	priceCleanup := flag.Bool("price-history-cleanup", false, "Prune raw price history points older than retention once")
+	categorize := flag.Bool("categorize", false, "Assign categories and product types to uncategorized products once")
 
 	flag.Parse()
 
@@ -73,11 +80,32 @@ func main() {
 		return
 	}
 
+	if *rebuild {
+		runIndexRebuild(ctx, application, log)
+		return
+	}
+
 	if *discover {
 		runCatalogDiscovery(ctx, application, log)
 		return
 	}
 
+	if *priceCleanup {
+		runPriceHistoryCleanup(application, cfg.PriceHistory.Retention, log)
+		return
+	}
+
+	if *categorize {
+		runCategoryBackfill(ctx, application, *batchSize, log)
+		return
+	}
+
+	if *rescrapeOnce {
+		runRescrapeSeed(ctx, application, log)
+		runRescrapeInline(ctx, application, cfg.Rescrape.BatchSize, log)
+		return
+	}
+
 	// --- 2. РЕЖИМ ДЕМОНА (Автоматизация) ---
 
 	if *daemonMode {
@@ -95,7 +123,7 @@ func main() {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				runQueueConsumer(ctx, application, queueClient, cfg.Queue.Topic, log)
+				runQueueConsumer(ctx, application, queueClient, cfg.Queue.Topic, cfg.Queue.MaxWorkers, log)
 			}()
 		} else {
 			log.Info("Queue consumer disabled", map[string]interface{}{
@@ -104,25 +132,67 @@ func main() {
 			})
 		}
 
+		// Задания повторного парсинга от планировщика: их разбирают все воркеры, подписанные на топик
+		if queueClient != nil && application.RescrapeService.Queued() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runRescrapeConsumer(ctx, application, queueClient, cfg.Queue.MaxWorkers, log)
+			}()
+		}
+
+		// Обработчик событий индексации: пачками обновляет документы изменённых товаров
+		if updater := application.IndexUpdater(); updater != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := updater.Run(ctx, cfg.Queue.IndexTopic); err != nil && !errors.Is(err, context.Canceled) {
+					log.Warn("Index updater stopped", map[string]interface{}{"error": err.Error()})
+				}
+			}()
+		} else {
+			log.Info("Index updater disabled, relying on periodic index sync", map[string]interface{}{
+				"queue_type":  cfg.Queue.Type,
+				"index_topic": cfg.Queue.IndexTopic,
+			})
+		}
+
 		// Тикеры (Таймеры)
 		// Процессинг запускаем часто (каждые 30 сек), чтобы быстро подхватывать новые данные
 		processTicker := time.NewTicker(30 * time.Second)
 		defer processTicker.Stop()
 
-		// Скрапинг запускаем редко (каждые 6 часов), чтобы обновлять цены
-		// Для теста поставим 10 минут, чтобы ты увидел результат быстрее
+		// Обнаружение новых товаров в каталогах и пополнение расписания повторного парсинга
 		scrapeTicker := time.NewTicker(10 * time.Minute)
 		defer scrapeTicker.Stop()
 
+		// Планировщик повторного парсинга раздаёт созревшие адреса; частоту каждого адреса
+		// определяют волатильность цены, популярность, наличие и бюджет магазина
+		rescrapeTicker := time.NewTicker(cfg.Rescrape.TickInterval)
+		defer rescrapeTicker.Stop()
+
+		// Полная пересборка индекса раз в сутки (без простоя поиска),
+		// между ними индекс обновляется инкрементально
+		rebuildTicker := time.NewTicker(24 * time.Hour)
+		defer rebuildTicker.Stop()
+
+		// Очистка сырых точек истории цен раз в сутки (агрегаты остаются)
+		retentionTicker := time.NewTicker(24 * time.Hour)
+		defer retentionTicker.Stop()
+
+		// Доставка уведомлений о снижении цены, поставленных процессингом в очередь
+		alertTicker := time.NewTicker(30 * time.Second)
+		defer alertTicker.Stop()
+
 		// Запуск горутины планировщика
 		go func() {
 			// Сразу при старте сделаем один прогон всего
 			log.Info("Running initial startup tasks...", nil)
 			wg.Add(4)
 			go func() { defer wg.Done(); runCatalogDiscovery(ctx, application, log) }()      // Обнаружение новых товаров в каталогах
-			go func() { defer wg.Done(); runMonitoring(ctx, application, log) }()            // Скрапинг списка
+			go func() { defer wg.Done(); runRescrapeSeed(ctx, application, log) }()          // Новые адреса в расписание
 			go func() { defer wg.Done(); runProcessor(ctx, application, *batchSize, log) }() // Процессинг
-			go func() { defer wg.Done(); runReindex(ctx, application, log) }()               // Индексация
+			go func() { defer wg.Done(); runIndexSync(ctx, application, log) }()             // Индексация изменённых товаров
 
 			for {
 				select {
@@ -137,8 +207,30 @@ func main() {
 					log.Info("⏰ Scheduled scraping started", nil)
 					wg.Add(3)
 					go func() { defer wg.Done(); runCatalogDiscovery(ctx, application, log) }() // Обнаружение новых товаров
-					go func() { defer wg.Done(); runMonitoring(ctx, application, log) }()       // Обновление цен
-					go func() { defer wg.Done(); runReindex(ctx, application, log) }()          // Индексация
+					go func() { defer wg.Done(); runRescrapeSeed(ctx, application, log) }()     // Новые адреса в расписание
+					go func() { defer wg.Done(); runIndexSync(ctx, application, log) }()        // Индексация изменённых товаров
+
+				case <-rescrapeTicker.C:
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						runRescrapeScheduler(ctx, application, cfg.Rescrape.BatchSize, log)
+					}()
+
+				case <-rebuildTicker.C:
+					wg.Add(1)
+					go func() { defer wg.Done(); runIndexRebuild(ctx, application, log) }()
+
+				case <-retentionTicker.C:
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						runPriceHistoryCleanup(application, cfg.PriceHistory.Retention, log)
+					}()
+
+				case <-alertTicker.C:
+					wg.Add(1)
+					go func() { defer wg.Done(); runAlertDelivery(ctx, application, log) }()
 
 				case <-ctx.Done():
 					return
@@ -156,6 +248,10 @@ func main() {
 		// Останавливаем тикеры
 		processTicker.Stop()
 		scrapeTicker.Stop()
+		rescrapeTicker.Stop()
+		rebuildTicker.Stop()
+		retentionTicker.Stop()
+		alertTicker.Stop()
 
 		// Даем время на завершение активных задач (graceful shutdown)
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -234,11 +330,11 @@ func runProcessor(ctx context.Context, app *app.App, batchSize int, log *logger.
 }
 
 func runReindex(ctx context.Context, app *app.App, log *logger.Logger) {
-	log.Info("🔍 Reindex tick", nil)
+	log.Info("🔍 Full reindex started", nil)
 	// Используем контекст с таймаутом для реиндексации
 	reindexCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
-	
+
 	if err := app.ReindexAllWithContext(reindexCtx); err != nil {
 		log.Error("Reindex failed", map[string]interface{}{"error": err.Error()})
 	} else {
@@ -246,58 +342,264 @@ func runReindex(ctx context.Context, app *app.App, log *logger.Logger) {
 	}
 }
 
-func runMonitoring(ctx context.Context, app *app.App, log *logger.Logger) {
-	log.Info("🕵️ Checking for outdated prices...", nil)
+// runIndexRebuild пересобирает индекс в новый и переключает на него поиск
+// Тикер работает в каждом воркере, пересборку выполняет тот, кто взял блокировку
+func runIndexRebuild(ctx context.Context, app *app.App, log *logger.Logger) {
+	log.Info("🔁 Index rebuild started", nil)
+	rebuildCtx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	result, err := app.RebuildIndexWithContext(rebuildCtx)
+	if errors.Is(err, indexing.ErrRebuildInProgress) {
+		log.Info("Index rebuild skipped: running in another worker", nil)
+		return
+	}
+	if err != nil {
+		log.Error("Index rebuild failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	log.Info("✅ Index rebuild completed", map[string]interface{}{
+		"expected": result.Expected,
+		"indexed":  result.Indexed,
+		"deleted":  result.Deleted,
+		"catch_up": result.CatchUp,
+	})
+}
+
+// runPriceHistoryCleanup удаляет сырые точки истории цен старше срока хранения
+func runPriceHistoryCleanup(app *app.App, retention time.Duration, log *logger.Logger) {
+	if app.PriceHistoryService == nil {
+		log.Warn("Price history service not available, skipping cleanup", nil)
+		return
+	}
+
+	log.Info("🧹 Price history cleanup started", map[string]interface{}{
+		"retention": retention.String(),
+	})
 
-	// Получаем ссылки, которые не обновлялись более 6 часов
-	// Берем пачками по 10 штук, чтобы не заспамить магазины
-	outdatedItems, err := app.ProductsService.GetURLsForRescrape(ctx, 6*time.Hour, 10)
+	deleted, err := app.PriceHistoryService.Cleanup(retention)
+	if errors.Is(err, pricehistory.ErrCleanupInProgress) {
+		log.Info("Price history cleanup skipped: running in another worker", nil)
+		return
+	}
 	if err != nil {
-		log.Error("Failed to get urls for rescrape", map[string]interface{}{"error": err.Error()})
+		log.Error("Price history cleanup failed", map[string]interface{}{"error": err.Error()})
 		return
 	}
+	log.Info("✅ Price history cleanup completed", map[string]interface{}{
+		"deleted": deleted,
+	})
+}
+
+// runCategoryBackfill назначает категории товарам без категории и обновляет их документы в индексе
+func runCategoryBackfill(ctx context.Context, app *app.App, batchSize int, log *logger.Logger) {
+	log.Info("🗂 Category backfill started", map[string]interface{}{"batch_size": batchSize})
 
-	if len(outdatedItems) == 0 {
-		log.Info("All prices are fresh ✨", nil)
+	result, err := app.CategorizerService.Backfill(ctx, batchSize)
+	if err != nil {
+		log.Error("Category backfill failed", map[string]interface{}{"error": err.Error()})
 		return
 	}
+	log.Info("✅ Category backfill completed", map[string]interface{}{
+		"scanned":  result.Scanned,
+		"assigned": result.Assigned,
+		"skipped":  result.Skipped,
+	})
 
-	log.Info("Found outdated items", map[string]interface{}{"count": len(outdatedItems)})
+	if result.Assigned > 0 {
+		runIndexSync(ctx, app, log)
+	}
+}
 
-	// Обходим их
-	for _, item := range outdatedItems {
-		log.Info("Rescraping item", map[string]interface{}{"url": item.URL, "shop_id": item.ShopID})
+// runIndexSync отправляет в Meilisearch только товары, изменённые с прошлого запуска
+func runIndexSync(ctx context.Context, app *app.App, log *logger.Logger) {
+	log.Info("🔍 Index sync tick", nil)
+	syncCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
 
-		// Получаем конфиг магазина
-		shopConfig, err := app.GetShopConfig(item.ShopID)
-		if err != nil {
-			log.Error("Shop config not found", map[string]interface{}{
-				"shop_id": item.ShopID,
-				"error":   err.Error(),
+	result, err := app.SyncIndexWithContext(syncCtx)
+	if err != nil {
+		log.Error("Index sync failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	log.Info("✅ Index sync completed", map[string]interface{}{
+		"indexed": result.Indexed,
+		"deleted": result.Deleted,
+		"since":   result.Since,
+	})
+}
+
+// alertDeliveryRunning не даёт тикам запускать доставку уведомлений параллельно
+var alertDeliveryRunning atomic.Bool
+
+// runAlertDelivery доставляет уведомления о снижении цены из очереди
+func runAlertDelivery(ctx context.Context, app *app.App, log *logger.Logger) {
+	if app.AlertsService == nil || !alertDeliveryRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer alertDeliveryRunning.Store(false)
+
+	if _, err := app.AlertsService.DeliverPending(ctx, 0); err != nil {
+		log.Error("Alert delivery failed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// runRescrapeSeed добавляет в расписание повторного парсинга адреса новых цен
+func runRescrapeSeed(ctx context.Context, app *app.App, log *logger.Logger) {
+	if _, err := app.RescrapeService.Seed(ctx); err != nil {
+		log.Error("Rescrape seed failed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// rescrapeInlineRunning не даёт тикам планировщика без очереди запускать обходы параллельно
+var rescrapeInlineRunning atomic.Bool
+
+// runRescrapeScheduler раздаёт созревшие адреса через очередь, а без неё парсит их сам
+func runRescrapeScheduler(ctx context.Context, app *app.App, batchSize int, log *logger.Logger) {
+	if !app.RescrapeService.Queued() {
+		runRescrapeInline(ctx, app, batchSize, log)
+		return
+	}
+	if _, err := app.RescrapeService.Dispatch(ctx, batchSize); err != nil {
+		log.Error("Rescrape dispatch failed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// runRescrapeInline забирает созревшие адреса и парсит их в текущем процессе
+func runRescrapeInline(ctx context.Context, app *app.App, batchSize int, log *logger.Logger) {
+	if !rescrapeInlineRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer rescrapeInlineRunning.Store(false)
+
+	jobs, err := app.RescrapeService.Claim(ctx, batchSize)
+	if err != nil {
+		log.Error("Failed to claim due urls", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	log.Info("🕵️ Rescraping due urls", map[string]interface{}{"count": len(jobs)})
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := runRescrapeJob(ctx, app, job, log); err != nil {
+			log.Error("Failed to reschedule url", map[string]interface{}{
+				"url":   job.URL,
+				"error": err.Error(),
 			})
-			continue
 		}
+	}
+	log.Info("✅ Rescrape batch completed", map[string]interface{}{"count": len(jobs)})
+}
+
+// runRescrapeJob парсит страницу (паузы и robots.txt соблюдает слой вежливости скрапера)
+// и планирует следующее посещение. Ошибка парсинга не возвращается: её учитывает расписание
+func runRescrapeJob(ctx context.Context, app *app.App, job *rescrape.Job, log *logger.Logger) error {
+	shopConfig, err := app.GetShopConfig(job.ShopID)
+	if err == nil {
+		// ScrapeAndSave обновляет существующий товар через Processor (UPSERT)
+		_, err = app.ScraperService.ScrapeAndSave(ctx, job.URL, shopConfig)
+	}
+	if err != nil {
+		log.Warn("Rescrape failed", map[string]interface{}{
+			"url":     job.URL,
+			"shop_id": job.ShopID,
+			"error":   err.Error(),
+		})
+	}
+
+	plan, completeErr := app.RescrapeService.Complete(ctx, job, err)
+	if completeErr != nil {
+		return completeErr
+	}
+	log.Debug("Rescrape scheduled", map[string]interface{}{
+		"url":           job.URL,
+		"next_visit_at": plan.NextVisitAt,
+		"priority":      plan.Priority,
+	})
+	return nil
+}
 
-		// Парсим
-		// ScrapeAndSave обновляет existing product через Processor.ProcessRawProducts (UPSERT)
-		_, err = app.ScraperService.ScrapeAndSave(ctx, item.URL, shopConfig)
+// runRescrapeConsumer выполняет задания повторного парсинга из очереди
+func runRescrapeConsumer(ctx context.Context, app *app.App, queueClient queue.Client, maxWorkers int, log *logger.Logger) {
+	topic := app.RescrapeService.Topic()
+	log.Info("Rescrape consumer started", map[string]interface{}{
+		"topic":       topic,
+		"max_workers": maxWorkers,
+	})
+
+	handle := func(ctx context.Context, payload []byte) error {
+		var job rescrape.Job
+		if err := json.Unmarshal(payload, &job); err != nil || job.ShopID == "" || job.URL == "" {
+			if err == nil {
+				err = errors.New("rescrape job without shop_id or url")
+			}
+			return queue.Permanent(err)
+		}
+		// Задание могли отправить повторно после Policy.Lease, а первый экземпляр уже выполнен
+		pending, err := app.RescrapeService.Pending(ctx, &job)
 		if err != nil {
-			log.Error("Rescrape failed", map[string]interface{}{
-				"url":   item.URL,
-				"error": err.Error(),
+			return err
+		}
+		if !pending {
+			log.Debug("Rescrape job already completed, skipping", map[string]interface{}{
+				"shop_id": job.ShopID,
+				"url":     job.URL,
 			})
-		} else {
-			log.Info("Rescraped successfully", map[string]interface{}{"url": item.URL})
+			return nil
 		}
+		return runRescrapeJob(ctx, app, &job, log)
+	}
 
-		// Вежливая пауза
-		time.Sleep(5 * time.Second)
+	var err error
+	if reliable, ok := queueClient.(queue.Reliable); ok {
+		pool := queue.NewPool(reliable, queue.PoolOptions{
+			Workers: maxWorkers,
+			Key:     queue.JSONFieldsKey("shop_id", "url"),
+			Locker:  keyLocker(app),
+		}, log)
+		err = pool.Run(ctx, topic, handle)
+	} else {
+		err = queueClient.Consume(ctx, topic, func(payload []byte) error {
+			return handle(ctx, payload)
+		})
 	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Warn("Rescrape consumer stopped", map[string]interface{}{
+			"topic": topic,
+			"error": err.Error(),
+		})
+	}
+}
 
-	log.Info("✅ Monitoring scrape completed", map[string]interface{}{"count": len(outdatedItems)})
+// keyLocker аренда ключей очереди в Redis, общая для всех процессов воркера; nil без Redis
+func keyLocker(app *app.App) queue.KeyLocker {
+	if app.Redis() == nil {
+		return nil
+	}
+	return queue.NewRedisKeyLocker(app.Redis().Client())
 }
 
+// sitemapScheduleLimit столько изменившихся товаров из sitemap ставится в расписание за один обход
+// магазина, остальные дождутся следующего запуска
+const sitemapScheduleLimit = 500
+
+// catalogDiscoveryRunning не даёт тикам планировщика запускать обходы каталогов параллельно
+var catalogDiscoveryRunning atomic.Bool
+
+// runCatalogDiscovery находит новые и изменившиеся товары магазинов и ставит их в расписание
+// повторного парсинга: страницы парсят воркеры расписания в пределах суточного бюджета магазина
 func runCatalogDiscovery(ctx context.Context, app *app.App, log *logger.Logger) {
+	if !catalogDiscoveryRunning.CompareAndSwap(false, true) {
+		log.Info("Catalog discovery is already running, skipping", nil)
+		return
+	}
+	defer catalogDiscoveryRunning.Store(false)
+
 	log.Info("🔍 Starting catalog discovery...", nil)
 
 	// Получаем список всех активных магазинов
@@ -308,115 +610,179 @@ func runCatalogDiscovery(ctx context.Context, app *app.App, log *logger.Logger)
 	}
 
 	for _, shop := range shops {
+		if ctx.Err() != nil {
+			return
+		}
 		if !shop.Enabled {
 			continue
 		}
 
-		// Получаем URL каталога из конфига
-		catalogURL := shop.Selectors["catalog_url"]
-
-		// Если catalog_url не указан, пробуем использовать base_url как точку входа
-		if catalogURL == "" {
-			log.Info("No catalog_url configured, trying base_url", map[string]interface{}{"shop": shop.Name})
-			catalogURL = shop.BaseURL
+		// Сначала sitemap: полный список товаров с lastmod, в расписание идут только изменившиеся страницы
+		entries, fromSitemap := discoverFromSitemap(ctx, app, shop, log)
+		if !fromSitemap {
+			entries = discoverFromCatalogPages(ctx, app, shop, log)
 		}
-
-		if catalogURL == "" {
-			log.Info("No catalog URL available, skipping", map[string]interface{}{"shop": shop.Name})
+		if len(entries) == 0 {
 			continue
 		}
 
-		log.Info("Discovering products from catalog", map[string]interface{}{
-			"shop":        shop.Name,
-			"catalog_url": catalogURL,
-		})
-
-		// Парсим каталог (максимум 3 страницы за раз, чтобы не перегружать)
-		result, err := app.ScraperService.ParseCatalog(ctx, catalogURL, shop, 3)
+		urls := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			urls = append(urls, entry.URL)
+		}
+		scheduled, err := app.RescrapeService.Schedule(ctx, shop.ID, urls)
 		if err != nil {
-			log.Error("Catalog parsing failed", map[string]interface{}{
+			log.Error("Failed to schedule catalog products", map[string]interface{}{
 				"shop":  shop.Name,
 				"error": err.Error(),
 			})
 			continue
 		}
 
-		if result.TotalFound == 0 {
-			log.Info("No products found in catalog", map[string]interface{}{"shop": shop.Name})
-			continue
+		// lastmod запоминается после постановки в расписание: ошибки парсинга дальше учитывает расписание
+		if fromSitemap {
+			for _, entry := range entries {
+				if err := app.ScraperService.MarkCatalogEntrySeen(shop.ID, entry); err != nil {
+					log.Warn("Failed to save sitemap lastmod", map[string]interface{}{
+						"url":   entry.URL,
+						"error": err.Error(),
+					})
+				}
+			}
 		}
 
-		log.Info("Found products in catalog", map[string]interface{}{
-			"shop":        shop.Name,
-			"total_found": result.TotalFound,
+		log.Info("✅ Catalog discovery completed", map[string]interface{}{
+			"shop":      shop.Name,
+			"found":     len(entries),
+			"scheduled": scheduled,
+			"sitemap":   fromSitemap,
 		})
+	}
+}
 
-		// Сохраняем найденные URL в базу для последующего парсинга
-		savedCount := 0
-		for _, productURL := range result.ProductURLs {
-			// Создаем минимальный RawProduct для сохранения URL
-			rawProduct := &scraper.RawProduct{
-				ShopID:    shop.ID,
-				ShopName:  shop.Name,
-				URL:       productURL,
-				ParsedAt:  time.Now(),
-				ScrapedAt: time.Now(),
-			}
+// discoverFromSitemap возвращает новые и изменившиеся с прошлого обхода товары из sitemap магазина.
+// false - sitemap нет или в нём не нашлось товаров, нужен обход HTML каталога
+func discoverFromSitemap(ctx context.Context, app *app.App, shop *scraper.ShopConfig, log *logger.Logger) ([]scraper.CatalogEntry, bool) {
+	result, err := app.ScraperService.ChangedCatalogEntries(ctx, shop, sitemapScheduleLimit)
+	if err != nil {
+		log.Info("Sitemap unavailable, falling back to catalog pages", map[string]interface{}{
+			"shop":  shop.Name,
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	if result.TotalFound == 0 {
+		log.Info("No products in sitemap, falling back to catalog pages", map[string]interface{}{"shop": shop.Name})
+		return nil, false
+	}
 
-			// Извлекаем external_id из URL
-			parts := strings.Split(productURL, "/")
-			if len(parts) > 0 {
-				rawProduct.ExternalID = parts[len(parts)-1]
-			}
+	log.Info("Found products in sitemap", map[string]interface{}{
+		"shop":        shop.Name,
+		"total_found": result.TotalFound,
+		"unchanged":   result.Unchanged,
+		"to_schedule": len(result.Entries),
+	})
+	return result.Entries, true
+}
 
-			// Сохраняем через ScraperService (он использует ScrapeAndSave, который сохраняет в raw_products)
-			// Но нам нужно просто сохранить URL, поэтому используем прямой вызов storage
-			// Вместо этого, запустим быстрый парсинг каждого товара
-			_, err = app.ScraperService.ScrapeAndSave(ctx, productURL, shop)
-			if err != nil {
-				log.Error("Failed to scrape product from catalog", map[string]interface{}{
-					"url":   productURL,
-					"error": err.Error(),
-				})
-				continue
-			}
-			savedCount++
+// discoverFromCatalogPages обходит HTML каталога магазина по ссылкам пагинации
+func discoverFromCatalogPages(ctx context.Context, app *app.App, shop *scraper.ShopConfig, log *logger.Logger) []scraper.CatalogEntry {
+	// Получаем URL каталога из конфига
+	catalogURL := shop.Selectors["catalog_url"]
 
-			// Небольшая пауза между товарами
-			time.Sleep(2 * time.Second)
-		}
+	// Если catalog_url не указан, пробуем использовать base_url как точку входа
+	if catalogURL == "" {
+		log.Info("No catalog_url configured, trying base_url", map[string]interface{}{"shop": shop.Name})
+		catalogURL = shop.BaseURL
+	}
 
-		log.Info("✅ Catalog discovery completed", map[string]interface{}{
+	if catalogURL == "" {
+		log.Info("No catalog URL available, skipping", map[string]interface{}{"shop": shop.Name})
+		return nil
+	}
+
+	log.Info("Discovering products from catalog", map[string]interface{}{
+		"shop":        shop.Name,
+		"catalog_url": catalogURL,
+	})
+
+	// Парсим каталог (максимум 3 страницы за раз, чтобы не перегружать)
+	result, err := app.ScraperService.ParseCatalog(ctx, catalogURL, shop, 3)
+	if err != nil {
+		log.Error("Catalog parsing failed", map[string]interface{}{
 			"shop":  shop.Name,
-			"found": result.TotalFound,
-			"saved": savedCount,
+			"error": err.Error(),
 		})
+		return nil
+	}
 
-		// Пауза между магазинами
-		time.Sleep(5 * time.Second)
+	if result.TotalFound == 0 {
+		log.Info("No products found in catalog", map[string]interface{}{"shop": shop.Name})
+		return nil
 	}
-}
 
+	log.Info("Found products in catalog", map[string]interface{}{
+		"shop":        shop.Name,
+		"total_found": result.TotalFound,
+	})
 
-func runQueueConsumer(ctx context.Context, app *app.App, queueClient queue.Client, topic string, log *logger.Logger) {
+	entries := make([]scraper.CatalogEntry, 0, len(result.ProductURLs))
+	for _, productURL := range result.ProductURLs {
+		entries = append(entries, scraper.CatalogEntry{URL: productURL})
+	}
+	return entries
+}
+
+func runQueueConsumer(ctx context.Context, app *app.App, queueClient queue.Client, topic string, maxWorkers int, log *logger.Logger) {
 	log.Info("Queue consumer started", map[string]interface{}{
-		"topic": topic,
+		"topic":       topic,
+		"max_workers": maxWorkers,
 	})
 
-	err := queueClient.Consume(ctx, topic, func(payload []byte) error {
+	handle := func(ctx context.Context, payload []byte) error {
 		var raw scraper.RawProduct
 		if err := json.Unmarshal(payload, &raw); err != nil {
 			log.Error("Queue payload decode failed", map[string]interface{}{
 				"topic": topic,
 				"error": err.Error(),
 			})
-			return err
+			// Битый payload не имеет смысла повторять - сразу в dead-letter
+			return queue.Permanent(err)
 		}
 		if app.ProcessorService == nil {
 			return errors.New("processor service is not initialized")
 		}
-		return app.ProcessorService.ProcessRawProduct(ctx, &raw)
-	})
+		// Ошибка возвращается в очередь: в режиме redis_streams сообщение
+		// останется неподтверждённым и будет доставлено повторно
+		if err := app.ProcessorService.ProcessRawProduct(ctx, &raw); err != nil {
+			log.Error("Queue raw product processing failed", map[string]interface{}{
+				"topic":       topic,
+				"shop_id":     raw.ShopID,
+				"external_id": raw.ExternalID,
+				"error":       err.Error(),
+			})
+			return err
+		}
+		return nil
+	}
+
+	var err error
+	if reliable, ok := queueClient.(queue.Reliable); ok {
+		// Пул воркеров: сообщения одного товара (shop_id + external_id) обрабатываются
+		// последовательно, разные товары - параллельно. Аренда ключа в Redis не даёт другим
+		// процессам воркера и повторной доставке обработать тот же товар одновременно.
+		// При остановке пул дожидается уже полученных сообщений.
+		pool := queue.NewPool(reliable, queue.PoolOptions{
+			Workers: maxWorkers,
+			Key:     queue.JSONFieldsKey("shop_id", "external_id"),
+			Locker:  keyLocker(app),
+		}, log)
+		err = pool.Run(ctx, topic, handle)
+	} else {
+		err = queueClient.Consume(ctx, topic, func(payload []byte) error {
+			return handle(ctx, payload)
+		})
+	}
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Warn("Queue consumer stopped", map[string]interface{}{
 			"topic": topic,
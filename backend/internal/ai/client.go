@@ -16,15 +16,30 @@ type Client struct {
 
 // New создает новый AI клиент
 func New(token string, model string) *Client {
+	return NewWithBaseURL("", token, model)
+}
+
+// NewWithBaseURL создает AI клиент для OpenAI-совместимого endpoint (self-hosted модели: vLLM, Ollama, LocalAI).
+// Пустой baseURL - официальный API OpenAI; токен для локальных серверов может быть пустым
+func NewWithBaseURL(baseURL, token, model string) *Client {
 	if model == "" {
 		model = openai.GPT4oMini // Дешевая и умная модель
 	}
+	cfg := openai.DefaultConfig(token)
+	if baseURL != "" {
+		cfg.BaseURL = strings.TrimRight(baseURL, "/")
+	}
 	return &Client{
-		api:   openai.NewClient(token),
+		api:   openai.NewClientWithConfig(cfg),
 		model: model,
 	}
 }
 
+// Model возвращает модель, которой генерируются селекторы
+func (c *Client) Model() string {
+	return c.model
+}
+
 // SelectorsResult результат генерации селекторов
 type SelectorsResult struct {
 	Name        string `json:"name"`
@@ -0,0 +1,20 @@
+package alerts
+
+import "errors"
+
+var (
+	// ErrSubscriptionNotFound подписка не найдена
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+
+	// ErrInvalidSubscription невалидные параметры подписки
+	ErrInvalidSubscription = errors.New("invalid subscription")
+
+	// ErrUnsupportedChannel канал уведомлений не поддерживается
+	ErrUnsupportedChannel = errors.New("unsupported notification channel")
+
+	// ErrOwnerTokenRequired не передан токен владельца подписок
+	ErrOwnerTokenRequired = errors.New("owner token is required")
+
+	// ErrForbiddenWebhookTarget вебхук указывает на внутренний или служебный адрес
+	ErrForbiddenWebhookTarget = errors.New("forbidden webhook target")
+)
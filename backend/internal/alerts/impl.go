@@ -0,0 +1,325 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCurrency = "RSD"
+	ownerTokenBytes = 32
+
+	defaultDeliveryBatch = 20
+	deliveryTimeout      = 30 * time.Second // Предел доставки одного уведомления
+	maxDeliveryAttempts  = 5
+	baseRetryDelay       = time.Minute
+)
+
+// Subscribe создаёт подписку на снижение цены владельца ownerToken.
+// Если токен не передан, выдаётся новый: он возвращается в OwnerToken один раз
+// и нужен для просмотра и удаления подписок
+func (s *Service) Subscribe(ctx context.Context, ownerToken string, sub *Subscription) (*Subscription, error) {
+	if sub == nil {
+		return nil, ErrInvalidSubscription
+	}
+	if err := normalizeSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	ownerToken = strings.TrimSpace(ownerToken)
+	issued := ownerToken == ""
+	if issued {
+		token, err := NewOwnerToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue owner token: %w", err)
+		}
+		ownerToken = token
+	}
+	sub.OwnerKey = OwnerKey(ownerToken)
+
+	sub.IsActive = true
+	if err := s.storage.CreateSubscription(ctx, sub); err != nil {
+		s.logger.Error("alerts: failed to create subscription", map[string]interface{}{
+			"product_id": sub.ProductID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	if issued {
+		sub.OwnerToken = ownerToken
+	}
+
+	s.logger.Info("alerts: subscription created", map[string]interface{}{
+		"subscription_id": sub.ID,
+		"product_id":      sub.ProductID,
+		"target_price":    sub.TargetPrice,
+		"channel":         sub.Channel,
+	})
+
+	return sub, nil
+}
+
+// GetSubscription получает подписку владельца по ID.
+// Чужая подписка неотличима от несуществующей
+func (s *Service) GetSubscription(ctx context.Context, ownerToken, id string) (*Subscription, error) {
+	ownerKey, err := ownerKeyFromToken(ownerToken)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(id) == "" {
+		return nil, ErrSubscriptionNotFound
+	}
+	sub, err := s.storage.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(sub.OwnerKey), []byte(ownerKey)) != 1 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+// ListByOwner получает подписки владельца токена
+func (s *Service) ListByOwner(ctx context.Context, ownerToken string) ([]*Subscription, error) {
+	ownerKey, err := ownerKeyFromToken(ownerToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.ListByOwner(ctx, ownerKey)
+}
+
+// Unsubscribe удаляет подписку владельца токена
+func (s *Service) Unsubscribe(ctx context.Context, ownerToken, id string) error {
+	ownerKey, err := ownerKeyFromToken(ownerToken)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(id) == "" {
+		return ErrSubscriptionNotFound
+	}
+	return s.storage.DeleteSubscription(ctx, id, ownerKey)
+}
+
+// NewOwnerToken создаёт случайный токен владельца подписок
+func NewOwnerToken() (string, error) {
+	buf := make([]byte, ownerTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// OwnerKey ключ владельца для хранения: сам токен в БД не сохраняется
+func OwnerKey(ownerToken string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(ownerToken)))
+	return hex.EncodeToString(sum[:])
+}
+
+func ownerKeyFromToken(ownerToken string) (string, error) {
+	if strings.TrimSpace(ownerToken) == "" {
+		return "", ErrOwnerTokenRequired
+	}
+	return OwnerKey(ownerToken), nil
+}
+
+// EvaluatePrice проверяет подписки на товар после сохранения новой цены и
+// ставит в очередь уведомления, если цена опустилась до целевой.
+// Доставка идёт отдельно (DeliverPending), поэтому обработка цены не ждёт
+// вебхуков и SMTP. Повторное уведомление ставится только при дальнейшем снижении цены.
+// Возвращает количество поставленных в очередь уведомлений.
+func (s *Service) EvaluatePrice(ctx context.Context, update *PriceUpdate) (int, error) {
+	if update == nil || update.ProductID == "" || update.Price <= 0 || !update.InStock {
+		return 0, nil
+	}
+
+	subs, err := s.storage.FindTriggered(ctx, update.ProductID, update.CityID, update.Price)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find triggered subscriptions: %w", err)
+	}
+
+	currency := update.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	now := time.Now()
+	notifications := make([]*Notification, 0, len(subs))
+	for _, sub := range subs {
+		if !strings.EqualFold(sub.Currency, currency) {
+			continue
+		}
+
+		if _, ok := s.notifiers[sub.Channel]; !ok {
+			s.logger.Warn("alerts: no notifier for channel", map[string]interface{}{
+				"subscription_id": sub.ID,
+				"channel":         sub.Channel,
+			})
+			continue
+		}
+
+		notifications = append(notifications, &Notification{
+			SubscriptionID: sub.ID,
+			ProductID:      update.ProductID,
+			ShopID:         update.ShopID,
+			ShopName:       update.ShopName,
+			URL:            update.URL,
+			Price:          update.Price,
+			TargetPrice:    sub.TargetPrice,
+			Currency:       currency,
+			Channel:        sub.Channel,
+			Target:         sub.Target,
+			TriggeredAt:    now,
+		})
+	}
+
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	if err := s.storage.RecordNotifications(ctx, notifications); err != nil {
+		return 0, fmt.Errorf("failed to record notifications: %w", err)
+	}
+
+	s.logger.Info("alerts: notifications queued", map[string]interface{}{
+		"product_id": update.ProductID,
+		"shop_id":    update.ShopID,
+		"price":      update.Price,
+		"queued":     len(notifications),
+	})
+
+	return len(notifications), nil
+}
+
+// DeliverPending доставляет до limit уведомлений из очереди.
+// Неудачная доставка повторяется с растущей задержкой, после maxDeliveryAttempts попыток
+// уведомление помечается неудачным. Возвращает количество доставленных уведомлений.
+func (s *Service) DeliverPending(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		limit = defaultDeliveryBatch
+	}
+
+	// Каждая доставка ограничена deliveryTimeout, поэтому пачка гарантированно
+	// укладывается в lease и не будет захвачена повторно, пока мы её обрабатываем
+	lease := time.Duration(limit) * deliveryTimeout
+	notifications, err := s.storage.ClaimNotifications(ctx, limit, lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	delivered := 0
+	for _, n := range notifications {
+		if ctx.Err() != nil {
+			// Незавершённые уведомления заберут снова после lease
+			break
+		}
+		if err := s.deliver(ctx, n); err != nil {
+			s.failDelivery(ctx, n, err)
+			continue
+		}
+		if err := s.storage.CompleteNotification(ctx, n.ID); err != nil {
+			s.logger.Error("alerts: failed to mark notification as sent", map[string]interface{}{
+				"notification_id": n.ID,
+				"error":           err.Error(),
+			})
+			continue
+		}
+		delivered++
+	}
+
+	if len(notifications) > 0 {
+		s.logger.Info("alerts: notifications delivered", map[string]interface{}{
+			"claimed":   len(notifications),
+			"delivered": delivered,
+		})
+	}
+
+	return delivered, nil
+}
+
+// deliver отправляет уведомление через notifier его канала
+func (s *Service) deliver(ctx context.Context, n *Notification) error {
+	notifier, ok := s.notifiers[n.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedChannel, n.Channel)
+	}
+	deliverCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	return notifier.Notify(deliverCtx, n)
+}
+
+// failDelivery откладывает повтор доставки или, если попытки кончились, помечает уведомление неудачным
+func (s *Service) failDelivery(ctx context.Context, n *Notification, deliveryErr error) {
+	var retryAt *time.Time
+	if n.Attempts < maxDeliveryAttempts {
+		at := time.Now().Add(retryDelay(n.Attempts))
+		retryAt = &at
+	}
+
+	s.logger.Error("alerts: notification failed", map[string]interface{}{
+		"notification_id": n.ID,
+		"subscription_id": n.SubscriptionID,
+		"channel":         n.Channel,
+		"attempts":        n.Attempts,
+		"will_retry":      retryAt != nil,
+		"error":           deliveryErr.Error(),
+	})
+
+	if err := s.storage.FailNotification(ctx, n.ID, deliveryErr.Error(), retryAt); err != nil {
+		s.logger.Error("alerts: failed to record delivery failure", map[string]interface{}{
+			"notification_id": n.ID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// retryDelay задержка перед повтором после attempts неудачных попыток: 1, 2, 4, 8... минут
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return baseRetryDelay << (attempts - 1)
+}
+
+// normalizeSubscription проверяет и нормализует параметры подписки
+func normalizeSubscription(sub *Subscription) error {
+	sub.ProductID = strings.TrimSpace(sub.ProductID)
+	sub.Target = strings.TrimSpace(sub.Target)
+	sub.Currency = strings.ToUpper(strings.TrimSpace(sub.Currency))
+
+	if sub.ProductID == "" {
+		return fmt.Errorf("%w: product_id is required", ErrInvalidSubscription)
+	}
+	if sub.TargetPrice <= 0 {
+		return fmt.Errorf("%w: target_price must be positive", ErrInvalidSubscription)
+	}
+	if sub.Currency == "" {
+		sub.Currency = defaultCurrency
+	}
+	if sub.CityID != nil && strings.TrimSpace(*sub.CityID) == "" {
+		sub.CityID = nil
+	}
+
+	switch sub.Channel {
+	case ChannelWebhook:
+		if err := validateWebhookURL(sub.Target); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+		}
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(sub.Target)
+		if err != nil {
+			return fmt.Errorf("%w: target must be an email address", ErrInvalidSubscription)
+		}
+		sub.Target = addr.Address
+	default:
+		return ErrUnsupportedChannel
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package alerts
+
+import "time"
+
+// Channel канал доставки уведомлений
+type Channel string
+
+const (
+	ChannelWebhook Channel = "webhook" // HTTP POST на URL пользователя
+	ChannelEmail   Channel = "email"   // Письмо через SMTP
+)
+
+// Subscription подписка пользователя на снижение цены товара
+type Subscription struct {
+	ID                string     `json:"id"`
+	OwnerKey          string     `json:"-"`                     // SHA-256 токена владельца
+	OwnerToken        string     `json:"owner_token,omitempty"` // Токен владельца, возвращается один раз при создании
+	ProductID         string     `json:"product_id"`
+	CityID            *string    `json:"city_id,omitempty"` // nil = любой город
+	TargetPrice       float64    `json:"target_price"`
+	Currency          string     `json:"currency"`
+	Channel           Channel    `json:"channel"`
+	Target            string     `json:"target"` // URL вебхука или email
+	IsActive          bool       `json:"is_active"`
+	LastNotifiedPrice *float64   `json:"last_notified_price,omitempty"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// PriceUpdate новая цена товара в магазине (источник для проверки подписок)
+type PriceUpdate struct {
+	ProductID string  `json:"product_id"`
+	ShopID    string  `json:"shop_id"`
+	ShopName  string  `json:"shop_name"`
+	CityID    *string `json:"city_id,omitempty"`
+	Price     float64 `json:"price"`
+	Currency  string  `json:"currency"`
+	URL       string  `json:"url"`
+	InStock   bool    `json:"in_stock"`
+}
+
+// Notification уведомление о снижении цены
+type Notification struct {
+	ID             string    `json:"id"` // Стабилен между повторами доставки: получатель может по нему отбрасывать дубли
+	SubscriptionID string    `json:"subscription_id"`
+	ProductID      string    `json:"product_id"`
+	ShopID         string    `json:"shop_id"`
+	ShopName       string    `json:"shop_name"`
+	URL            string    `json:"url"`
+	Price          float64   `json:"price"`
+	TargetPrice    float64   `json:"target_price"`
+	Currency       string    `json:"currency"`
+	Channel        Channel   `json:"channel"`
+	Target         string    `json:"-"`
+	TriggeredAt    time.Time `json:"triggered_at"`
+	Attempts       int       `json:"-"` // Попыток доставки, включая текущую
+}
@@ -0,0 +1,74 @@
+package alerts
+
+import (
+	"context"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс для работы с хранилищем подписок
+type Storage interface {
+	// CreateSubscription сохраняет новую подписку
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+
+	// GetSubscription получает подписку по ID
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+
+	// ListByOwner получает все подписки владельца (ownerKey - SHA-256 токена владельца)
+	ListByOwner(ctx context.Context, ownerKey string) ([]*Subscription, error)
+
+	// DeleteSubscription удаляет подписку владельца
+	DeleteSubscription(ctx context.Context, id, ownerKey string) error
+
+	// FindTriggered находит активные подписки на товар, цель которых достигнута ценой price
+	// (city_id подписки пустой или совпадает с cityID)
+	FindTriggered(ctx context.Context, productID string, cityID *string, price float64) ([]*Subscription, error)
+
+	// RecordNotifications сохраняет сработавшие уведомления для доставки и в той же транзакции
+	// отмечает подписки уведомлёнными по этой цене. Заполняет ID уведомлений
+	RecordNotifications(ctx context.Context, notifications []*Notification) error
+
+	// ClaimNotifications забирает до limit уведомлений, ожидающих доставки, и откладывает
+	// их повтор на lease: если процесс упадёт посреди доставки, уведомление заберут снова.
+	// Счётчик попыток увеличивается при каждом захвате
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error)
+
+	// CompleteNotification отмечает уведомление доставленным
+	CompleteNotification(ctx context.Context, id string) error
+
+	// FailNotification фиксирует ошибку доставки: уведомление повторится в retryAt,
+	// а при retryAt == nil помечается окончательно неудачным
+	FailNotification(ctx context.Context, id, lastError string, retryAt *time.Time) error
+}
+
+// Notifier отправляет уведомление по конкретному каналу
+type Notifier interface {
+	Channel() Channel
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Service сервис подписок на снижение цены
+type Service struct {
+	storage   Storage
+	notifiers map[Channel]Notifier
+	logger    *logger.Logger
+}
+
+// New создаёт новый сервис подписок
+func New(storage Storage, notifiers []Notifier, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	registry := make(map[Channel]Notifier, len(notifiers))
+	for _, n := range notifiers {
+		if n != nil {
+			registry[n.Channel()] = n
+		}
+	}
+	return &Service{
+		storage:   storage,
+		notifiers: registry,
+		logger:    log,
+	}
+}
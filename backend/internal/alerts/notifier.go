@@ -0,0 +1,283 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+	defaultSMTPTimeout    = 15 * time.Second
+)
+
+// blockedWebhookNetworks служебные диапазоны, не покрытые методами net.IP
+// (CGNAT, "this network", бенчмарки, IETF-протоколы, зарезервированные, NAT64)
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96",
+)
+
+// WebhookNotifier отправляет уведомление POST-запросом с JSON телом.
+// Соединения с внутренними адресами (loopback, частные сети, link-local, метаданные облака)
+// запрещены: IP проверяется при подключении, поэтому смена DNS после создания подписки не помогает
+type WebhookNotifier struct {
+	client       *http.Client
+	allowPrivate bool // только для тестов с локальным сервером
+}
+
+// NewWebhookNotifier создаёт notifier для вебхуков
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	n := &WebhookNotifier{}
+	dialer := &net.Dialer{Timeout: timeout, Control: n.checkDialAddress}
+	n.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil, // прокси подключался бы вместо цели и обходил проверку адреса
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		// Редирект на другой адрес подписчик не подтверждал
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return n
+}
+
+// checkDialAddress проверяет IP, к которому реально идёт подключение (после DNS)
+func (n *WebhookNotifier) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if n.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenWebhookTarget, address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenWebhookTarget, host)
+	}
+	return nil
+}
+
+// validateWebhookURL проверяет URL вебхука при создании подписки: схема http(s),
+// без учётных данных, хост не localhost и не внутренний IP
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("target must be an http(s) URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("target must not contain credentials")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return ErrForbiddenWebhookTarget
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return ErrForbiddenWebhookTarget
+	}
+	return nil
+}
+
+// isPublicIP true для адресов, доступных из интернета
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4.Equal(net.IPv4bcast) {
+			return false
+		}
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Channel возвращает канал notifier'а
+func (n *WebhookNotifier) Channel() Channel {
+	return ChannelWebhook
+}
+
+// Notify отправляет уведомление на URL подписки
+func (n *WebhookNotifier) Notify(ctx context.Context, notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Izborator-Alerts/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPConfig параметры SMTP сервера
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration // Предел на подключение и весь обмен с сервером
+}
+
+// SMTPNotifier отправляет уведомление письмом
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier создаёт notifier для email
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPNotifier{cfg: cfg}
+}
+
+// Channel возвращает канал notifier'а
+func (n *SMTPNotifier) Channel() Channel {
+	return ChannelEmail
+}
+
+// Notify отправляет письмо на адрес подписки
+func (n *SMTPNotifier) Notify(ctx context.Context, notification *Notification) error {
+	if n.cfg.Host == "" || n.cfg.From == "" {
+		return fmt.Errorf("smtp is not configured")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	// smtp.SendMail подключается без таймаута и может висеть на недоступном сервере
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	dialer := &net.Dialer{Timeout: n.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	// Дедлайн ограничивает весь обмен, а отмена ctx прерывает его досрочно
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set smtp deadline: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := n.send(conn, notification); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send проводит SMTP-сессию по открытому соединению так же, как smtp.SendMail
+func (n *SMTPNotifier) send(conn net.Conn, notification *Notification) error {
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(notification.Target); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildEmail(n.cfg.From, notification)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail формирует текст письма о снижении цены
+func buildEmail(from string, n *Notification) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + n.Target + "\r\n")
+	b.WriteString(fmt.Sprintf("Subject: Cena je pala: %.2f %s\r\n", n.Price, n.Currency))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(fmt.Sprintf("Cena proizvoda je pala na %.2f %s (vaš cilj: %.2f %s).\r\n",
+		n.Price, n.Currency, n.TargetPrice, n.Currency))
+	if n.ShopName != "" {
+		b.WriteString("Prodavnica: " + n.ShopName + "\r\n")
+	}
+	if n.URL != "" {
+		b.WriteString("Link: " + n.URL + "\r\n")
+	}
+	return []byte(b.String())
+}
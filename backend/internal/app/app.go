@@ -2,26 +2,36 @@ package app
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/solomonczyk/izborator/internal/ai"
+	"github.com/solomonczyk/izborator/internal/alerts"
 	"github.com/solomonczyk/izborator/internal/attributes"
 	"github.com/solomonczyk/izborator/internal/autoconfig"
+	"github.com/solomonczyk/izborator/internal/brands"
+	"github.com/solomonczyk/izborator/internal/candidates"
 	"github.com/solomonczyk/izborator/internal/categories"
+	"github.com/solomonczyk/izborator/internal/categorizer"
 	"github.com/solomonczyk/izborator/internal/cities"
 	"github.com/solomonczyk/izborator/internal/classifier"
 	"github.com/solomonczyk/izborator/internal/config"
+	"github.com/solomonczyk/izborator/internal/discovery"
 	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/indexing"
 	"github.com/solomonczyk/izborator/internal/logger"
 	"github.com/solomonczyk/izborator/internal/matching"
+	"github.com/solomonczyk/izborator/internal/matchreview"
+	"github.com/solomonczyk/izborator/internal/politeness"
 	"github.com/solomonczyk/izborator/internal/pricehistory"
 	"github.com/solomonczyk/izborator/internal/processor"
 	"github.com/solomonczyk/izborator/internal/products"
 	"github.com/solomonczyk/izborator/internal/producttypes"
 	"github.com/solomonczyk/izborator/internal/queue"
+	"github.com/solomonczyk/izborator/internal/rescrape"
 	"github.com/solomonczyk/izborator/internal/scraper"
+	"github.com/solomonczyk/izborator/internal/selectorhealth"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
 	"github.com/solomonczyk/izborator/internal/scrapingstats"
 	"github.com/solomonczyk/izborator/internal/storage"
 )
@@ -48,9 +58,19 @@ type App struct {
 	categoriesStorage    categories.Storage
 	productTypesStorage  producttypes.Storage
 	attributesStorage    attributes.Storage
+	categorizerStorage   categorizer.Storage
+	brandsStorage        brands.Storage
 	citiesStorage        cities.Storage
 	classifierStorage    classifier.Storage
 	autoconfigStorage    autoconfig.Storage
+	alertsStorage        alerts.Storage
+	indexingStorage      indexing.Storage
+	matchReviewStorage   matchreview.Storage
+	selectorHealthStorage selectorhealth.Storage
+	selectorVersionsStorage selectorversions.Storage
+	candidatesStorage    candidates.Storage
+	knownShopsStorage    discovery.KnownShops
+	rescrapeStorage      rescrape.Storage
 
 	// Services (публичные - используются в cmd/*)
 	ScraperService       *scraper.Service
@@ -62,9 +82,19 @@ type App struct {
 	CategoriesService    *categories.Service
 	ProductTypesService  *producttypes.Service
 	AttributesService    *attributes.Service
+	CategorizerService   *categorizer.Service
+	BrandsService        *brands.Service
 	CitiesService        *cities.Service
 	Classifier           *classifier.Service
+	DiscoveryService     *discovery.Service
 	AutoconfigService    *autoconfig.Service
+	AlertsService        *alerts.Service
+	IndexingService      *indexing.Service
+	MatchReviewService   *matchreview.Service
+	SelectorHealthService *selectorhealth.Service
+	SelectorVersionsService *selectorversions.Service
+	CandidatesService    *candidates.Service
+	RescrapeService      *rescrape.Service
 
 	// AI
 	AIClient *ai.Client
@@ -108,6 +138,11 @@ func (a *App) GetClassifierStorage() classifier.Storage {
 	return a.classifierStorage
 }
 
+// GetKnownShops возвращает домены действующих магазинов (для источников discovery)
+func (a *App) GetKnownShops() discovery.KnownShops {
+	return a.knownShopsStorage
+}
+
 // GetAIClient возвращает AI клиент (может быть nil, если API ключ не задан)
 func (a *App) GetAIClient() *ai.Client {
 	return a.AIClient
@@ -119,7 +154,7 @@ func (a *App) GetAutoconfigService() *autoconfig.Service {
 }
 
 // ReindexAll переиндексирует все товары в Meilisearch
-// Использует те же методы, что и cmd/indexer
+// Использует тот же сервис индексации, что и cmd/indexer
 func (a *App) ReindexAll() error {
 	return a.ReindexAllWithContext(context.Background())
 }
@@ -130,99 +165,48 @@ func (a *App) ReindexAllWithContext(ctx context.Context) error {
 		return fmt.Errorf("Meilisearch is not available")
 	}
 
-	// Используем processor adapter для реиндексации
-	// Он уже имеет доступ к Meilisearch и Postgres
-	processorAdapter := storage.NewProcessorAdapter(a.pg, a.meili)
+	if _, err := a.IndexingService.Reindex(ctx); err != nil {
+		return fmt.Errorf("failed to reindex products: %w", err)
+	}
 
-	// Получаем все товары из PostgreSQL и индексируем их
-	// Используем тот же подход, что и в cmd/indexer
-	query := `
-		SELECT id, name, description, brand, category, category_id, image_url, specs, created_at, updated_at
-		FROM products
-		ORDER BY created_at DESC
-	`
+	return nil
+}
 
-	rows, err := a.pg.DB().Query(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to query products: %w", err)
-	}
-	defer rows.Close()
-
-	var productList []*products.Product
-	for rows.Next() {
-		var p products.Product
-		var specsJSON []byte
-		var description, brand, category, imageURL *string
-		var categoryID *string
-
-		if err := rows.Scan(
-			&p.ID,
-			&p.Name,
-			&description,
-			&brand,
-			&category,
-			&categoryID,
-			&imageURL,
-			&specsJSON,
-			&p.CreatedAt,
-			&p.UpdatedAt,
-		); err != nil {
-			return fmt.Errorf("failed to scan product: %w", err)
-		}
+// RebuildIndexWithContext пересобирает индекс Meilisearch без простоя поиска
+// (новый индекс, проверка числа документов, атомарное переключение)
+func (a *App) RebuildIndexWithContext(ctx context.Context) (*indexing.RebuildResult, error) {
+	if a.meili == nil {
+		return nil, fmt.Errorf("Meilisearch is not available")
+	}
 
-		if description != nil {
-			p.Description = *description
-		}
-		if brand != nil {
-			p.Brand = *brand
-		}
-		if category != nil {
-			p.Category = *category
-		}
-		if imageURL != nil {
-			p.ImageURL = *imageURL
-		}
-		p.CategoryID = categoryID
-
-		if len(specsJSON) > 0 {
-			if err := json.Unmarshal(specsJSON, &p.Specs); err != nil {
-				a.logger.Warn("Failed to unmarshal specs", map[string]interface{}{"error": err.Error()})
-			}
-		} else {
-			p.Specs = make(map[string]string)
-		}
+	return a.IndexingService.Rebuild(ctx)
+}
 
-		productList = append(productList, &p)
+// SyncIndexWithContext отправляет в Meilisearch товары, изменённые после последней синхронизации
+func (a *App) SyncIndexWithContext(ctx context.Context) (*indexing.SyncResult, error) {
+	if a.meili == nil {
+		return nil, fmt.Errorf("Meilisearch is not available")
 	}
 
-	// Удаляем все документы из индекса
-	index := a.meili.Client().Index("products")
-	if _, err := index.DeleteAllDocuments(); err != nil {
-		// Если Meilisearch недоступен или ключ неверный, просто логируем и продолжаем
-		a.logger.Warn("Failed to delete documents from Meilisearch (will continue anyway)", map[string]interface{}{
-			"error": err.Error(),
-		})
-		// Не прерываем выполнение - просто пропускаем реиндексацию
+	return a.IndexingService.SyncChanged(ctx)
+}
+
+// IndexUpdater создаёт обработчик событий индексации
+// Возвращает nil, если очередь не поддерживает подтверждения или индексация отключена
+func (a *App) IndexUpdater() *indexing.Updater {
+	reliable, ok := a.queueClient.(queue.Reliable)
+	if !ok || a.meili == nil || a.config.Queue.IndexTopic == "" {
 		return nil
 	}
+	return indexing.NewUpdater(a.IndexingService, reliable, indexing.UpdaterOptions{}, a.logger)
+}
 
-	a.logger.Info("Deleted all documents from index")
-
-	// Индексируем все товары
-	for _, p := range productList {
-		if err := processorAdapter.IndexProduct(p); err != nil {
-			a.logger.Error("Failed to index product", map[string]interface{}{
-				"product_id": p.ID,
-				"error":      err.Error(),
-			})
-		}
+// reviewIndexer возвращает индексатор для решений модераторов (nil без Meilisearch)
+func (a *App) reviewIndexer() matchreview.Indexer {
+	if a.meili == nil || a.IndexingService == nil {
+		return nil
 	}
-
-	a.logger.Info("Reindex completed", map[string]interface{}{
-		"total_products": len(productList),
-	})
-
-	return nil
+	return a.IndexingService
 }
 
 // NewApp создаёт новое приложение и инициализирует все зависимости
@@ -291,9 +275,19 @@ func (a *App) initAdapters() {
 	a.categoriesStorage = storage.NewCategoriesAdapter(a.pg)
 	a.productTypesStorage = storage.NewProductTypesAdapter(a.pg)
 	a.attributesStorage = storage.NewAttributesAdapter(a.pg)
+	a.categorizerStorage = storage.NewCategorizerAdapter(a.pg)
+	a.brandsStorage = storage.NewBrandsAdapter(a.pg)
 	a.citiesStorage = storage.NewCitiesAdapter(a.pg)
 	a.classifierStorage = storage.NewClassifierAdapter(a.pg)
 	a.autoconfigStorage = storage.NewAutoconfigAdapter(a.pg)
+	a.alertsStorage = storage.NewAlertsAdapter(a.pg)
+	a.indexingStorage = storage.NewIndexingAdapter(a.pg, a.meili)
+	a.matchReviewStorage = storage.NewMatchReviewAdapter(a.pg)
+	a.selectorHealthStorage = storage.NewSelectorHealthAdapter(a.pg)
+	a.selectorVersionsStorage = storage.NewSelectorVersionsAdapter(a.pg)
+	a.candidatesStorage = storage.NewCandidatesAdapter(a.pg)
+	a.knownShopsStorage = storage.NewDiscoveryAdapter(a.pg)
+	a.rescrapeStorage = storage.NewRescrapeAdapter(a.pg)
 }
 
 // initServices инициализирует доменные сервисы
@@ -311,67 +305,197 @@ func (a *App) initServices() {
 	}
 	a.queueClient = queueClient
 
+	// Politeness: robots.txt и лимиты на магазин, общие для Colly и браузера
+	scraperCfg := a.config.Scraper
+	politenessManager := politeness.New(politeness.Config{
+		RespectRobots:    scraperCfg.RespectRobots,
+		UserAgent:        scraperCfg.UserAgent,
+		RobotsTTL:        scraperCfg.RobotsCacheTTL,
+		DefaultRateLimit: scraperCfg.DefaultRateLimit,
+		MaxPerShop:       scraperCfg.MaxPerShop,
+		MaxBrowsers:      scraperCfg.MaxBrowsers,
+	}, nil, a.logger)
+
+	// Selector health service (детектор деградации селекторов)
+	a.SelectorHealthService = selectorhealth.New(a.selectorHealthStorage, a.selectorHealthThresholds(), a.logger)
+
+	// Selector versions service (история, откат и закрепление селекторов)
+	a.SelectorVersionsService = selectorversions.New(a.selectorVersionsStorage, a.logger)
+
+	// Candidates service (конвейер кандидатов в магазины)
+	a.CandidatesService = candidates.New(a.candidatesStorage, a.logger)
+
 	// Scraper service
 	a.ScraperService = scraper.New(
 		a.scraperStorage,
 		queueClient,
 		a.config.Queue.Topic,
 		a.ScrapingStatsService,
+		politenessManager,
+		a.SelectorHealthService,
 		a.logger,
 	)
+	a.ScraperService.SetUserAgent(politenessManager.UserAgent())
+
+	// Brands service (справочник брендов и синонимов)
+	a.BrandsService = brands.New(a.brandsStorage, a.logger)
 
 	// Products service
-	a.ProductsService = products.New(a.productsStorage, a.logger)
+	a.ProductsService = products.New(a.productsStorage, a.BrandsService, a.logger)
+
+	// Rescrape scheduler (без очереди задания выполняет сам воркер)
+	var rescrapePublisher rescrape.Publisher
+	if queueClient != nil {
+		rescrapePublisher = queueClient
+	}
+	a.RescrapeService = rescrape.New(a.rescrapeStorage, rescrapePublisher, a.config.Queue.RescrapeTopic, a.rescrapePolicy(), a.logger)
 
 	// Matching service
-	a.MatchingService = matching.New(a.matchingStorage, a.logger)
+	a.MatchingService = matching.New(a.matchingStorage, a.BrandsService, a.logger)
+
+	// Price alerts service (уведомления о снижении цены)
+	a.AlertsService = alerts.New(a.alertsStorage, a.alertNotifiers(), a.logger)
+
+	// Indexing service (инкрементальная синхронизация Meilisearch)
+	a.IndexingService = indexing.New(a.indexingStorage, a.logger)
+
+	// Match review service (ручная проверка сопоставлений)
+	a.MatchReviewService = matchreview.New(a.matchReviewStorage, a.reviewIndexer(), a.logger)
+
+	// События индексации публикуются, только если их есть кому обработать
+	var indexEvents processor.IndexEvents
+	if a.IndexUpdater() != nil {
+		indexEvents = indexing.NewQueuePublisher(queueClient, a.config.Queue.IndexTopic)
+	}
+
+	// Price history service (журнал изменений цен пишет процессор)
+	a.PriceHistoryService = pricehistory.New(a.priceHistoryStorage, a.logger)
+
+	// Attributes service
+	a.AttributesService = attributes.New(a.attributesStorage, a.logger)
+
+	// Categorizer service (категория и тип товара по категории магазина и названию)
+	a.CategorizerService = categorizer.New(a.categorizerStorage, a.logger)
 
 	// Processor service
 	a.ProcessorService = processor.New(
 		a.scraperStorage,   // как processor.RawStorage
 		a.processorStorage, // как processor.ProcessedStorage
 		a.MatchingService,  // как processor.Matching
-		a.ScrapingStatsService, // semantic validation recorder
+		processor.Deps{
+			SemanticRecorder: a.ScrapingStatsService,
+			PriceAlerts:      a.AlertsService,
+			PriceHistory:     a.PriceHistoryService,
+			IndexEvents:      indexEvents,
+			Attributes:       a.AttributesService,
+			Categorizer:      a.CategorizerService,
+			Brands:           a.BrandsService,
+		},
 		a.logger,
 	)
 
-	// Price history service
-	a.PriceHistoryService = pricehistory.New(a.priceHistoryStorage, a.logger)
-
 	// Categories service
 	a.CategoriesService = categories.New(a.categoriesStorage, a.logger)
 
 	// Product types service
 	a.ProductTypesService = producttypes.New(a.productTypesStorage, a.logger)
 
-	// Attributes service
-	a.AttributesService = attributes.New(a.attributesStorage, a.logger)
-
 	// Cities service
 	a.CitiesService = cities.New(a.citiesStorage, a.logger)
 
 	// Classifier service
 	a.Classifier = classifier.New(a.classifierStorage, a.logger)
 
-	// AI Client (опционально, если API ключ задан) - ДОЛЖЕН быть инициализирован ДО AutoconfigService
-	if a.config.OpenAI.APIKey != "" {
-		a.AIClient = ai.New(a.config.OpenAI.APIKey, a.config.OpenAI.Model)
+	// Discovery service (источники доменов-кандидатов)
+	a.DiscoveryService = discovery.New(a.classifierStorage, a.knownShopsStorage, a.logger)
+
+	// AI Client (опционально: API ключ OpenAI или OpenAI-совместимый endpoint) - ДОЛЖЕН быть инициализирован ДО AutoconfigService
+	if a.config.OpenAI.APIKey != "" || a.config.OpenAI.BaseURL != "" {
+		a.AIClient = ai.NewWithBaseURL(a.config.OpenAI.BaseURL, a.config.OpenAI.APIKey, a.config.OpenAI.Model)
 		a.logger.Info("AI client initialized", map[string]interface{}{
-			"model": a.config.OpenAI.Model,
+			"model":    a.AIClient.Model(),
+			"base_url": a.config.OpenAI.BaseURL,
 		})
 	} else {
 		a.logger.Warn("OpenAI API key not set, AI features will be unavailable", nil)
 	}
 
-	// Autoconfig service (требует AI клиент) - ПОСЛЕ инициализации AI Client
-	if a.AIClient != nil {
-		a.AutoconfigService = autoconfig.NewService(a.autoconfigStorage, a.AIClient, a.logger)
-		a.logger.Info("Autoconfig service initialized", nil)
+	// Autoconfig service - ПОСЛЕ инициализации AI Client
+	if generator := a.selectorGenerator(); generator != nil {
+		a.AutoconfigService = autoconfig.NewService(a.autoconfigStorage, generator, a.logger)
+		a.logger.Info("Autoconfig service initialized", map[string]interface{}{
+			"generator": a.config.Autoconfig.Generator,
+		})
 	} else {
-		a.logger.Warn("Autoconfig service not initialized (AI client unavailable)", nil)
+		a.logger.Warn("Autoconfig service not initialized (AI client unavailable)", map[string]interface{}{
+			"generator": a.config.Autoconfig.Generator,
+		})
 	}
 }
 
+// selectorGenerator генератор селекторов AutoConfig по AUTOCONFIG_GENERATOR.
+// В режиме auto без AI клиента работает только эвристика, поэтому авто-конфигурация доступна без ключей
+func (a *App) selectorGenerator() autoconfig.SelectorGenerator {
+	heuristic := autoconfig.NewHeuristicGenerator()
+	switch a.config.Autoconfig.Generator {
+	case "heuristic":
+		return heuristic
+	case "openai":
+		if a.AIClient == nil {
+			return nil
+		}
+		return autoconfig.NewAIGenerator(a.AIClient)
+	default:
+		if a.AIClient == nil {
+			return heuristic
+		}
+		return autoconfig.NewChainGenerator(autoconfig.NewAIGenerator(a.AIClient), heuristic)
+	}
+}
+
+// selectorHealthThresholds пороги детектора деградации селекторов из конфигурации
+func (a *App) selectorHealthThresholds() selectorhealth.Thresholds {
+	cfg := a.config.SelectorHealth
+	return selectorhealth.Thresholds{
+		Window:         cfg.Window,
+		MinSamples:     cfg.MinSamples,
+		MinSuccessRate: cfg.MinSuccessRate,
+	}
+}
+
+// rescrapePolicy параметры расписания повторного парсинга
+func (a *App) rescrapePolicy() rescrape.Policy {
+	cfg := a.config.Rescrape
+	return rescrape.Policy{
+		BaseInterval: cfg.BaseInterval,
+		MinInterval:  cfg.MinInterval,
+		MaxInterval:  cfg.MaxInterval,
+		Lease:        cfg.Lease,
+	}
+}
+
+// alertNotifiers создаёт notifier'ы для подписок на снижение цены
+// Email доступен только при заданном SMTP_HOST
+func (a *App) alertNotifiers() []alerts.Notifier {
+	cfg := a.config.Alerts
+	notifiers := []alerts.Notifier{
+		alerts.NewWebhookNotifier(cfg.WebhookTimeout),
+	}
+	if cfg.SMTPHost != "" {
+		notifiers = append(notifiers, alerts.NewSMTPNotifier(alerts.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.SMTPTimeout,
+		}))
+	} else {
+		a.logger.Warn("SMTP host not set, email alerts will be unavailable", nil)
+	}
+	return notifiers
+}
+
 // initI18n инициализирует переводчик
 func (a *App) initI18n() error {
 	// Путь к локалям относительно корня проекта
@@ -440,17 +564,36 @@ func NewAPIApp(cfg *config.Config) (*App, error) {
 	app.categoriesStorage = storage.NewCategoriesAdapter(app.pg)
 	app.productTypesStorage = storage.NewProductTypesAdapter(app.pg)
 	app.attributesStorage = storage.NewAttributesAdapter(app.pg)
+	app.categorizerStorage = storage.NewCategorizerAdapter(app.pg)
+	app.brandsStorage = storage.NewBrandsAdapter(app.pg)
 	app.citiesStorage = storage.NewCitiesAdapter(app.pg)
+	app.alertsStorage = storage.NewAlertsAdapter(app.pg)
 
 	// Инициализация сервисов (только для API)
-	app.ProductsService = products.New(app.productsStorage, app.logger)
-	app.MatchingService = matching.New(app.matchingStorage, app.logger)
+	app.BrandsService = brands.New(app.brandsStorage, app.logger)
+	app.ProductsService = products.New(app.productsStorage, app.BrandsService, app.logger)
+	app.MatchingService = matching.New(app.matchingStorage, app.BrandsService, app.logger)
 	app.PriceHistoryService = pricehistory.New(app.priceHistoryStorage, app.logger)
 	app.ScrapingStatsService = scrapingstats.New(app.scrapingStatsStorage, app.logger, app.config.QualityGates)
 	app.CategoriesService = categories.New(app.categoriesStorage, app.logger)
 	app.ProductTypesService = producttypes.New(app.productTypesStorage, app.logger)
 	app.AttributesService = attributes.New(app.attributesStorage, app.logger)
+	app.CategorizerService = categorizer.New(app.categorizerStorage, app.logger)
 	app.CitiesService = cities.New(app.citiesStorage, app.logger)
+	// API только управляет подписками, уведомления отправляет воркер
+	app.AlertsService = alerts.New(app.alertsStorage, nil, app.logger)
+	if app.meili != nil {
+		app.indexingStorage = storage.NewIndexingAdapter(app.pg, app.meili)
+		app.IndexingService = indexing.New(app.indexingStorage, app.logger)
+	}
+	app.matchReviewStorage = storage.NewMatchReviewAdapter(app.pg)
+	app.MatchReviewService = matchreview.New(app.matchReviewStorage, app.reviewIndexer(), app.logger)
+	app.selectorHealthStorage = storage.NewSelectorHealthAdapter(app.pg)
+	app.SelectorHealthService = selectorhealth.New(app.selectorHealthStorage, app.selectorHealthThresholds(), app.logger)
+	app.selectorVersionsStorage = storage.NewSelectorVersionsAdapter(app.pg)
+	app.SelectorVersionsService = selectorversions.New(app.selectorVersionsStorage, app.logger)
+	app.candidatesStorage = storage.NewCandidatesAdapter(app.pg)
+	app.CandidatesService = candidates.New(app.candidatesStorage, app.logger)
 
 	// i18n
 	if err := app.initI18n(); err != nil {
@@ -18,3 +18,30 @@ type ProductTypeAttribute struct {
 	IsRequired    bool
 	SortOrder     int
 }
+
+// Типы данных атрибутов (attributes.data_type)
+const (
+	DataTypeInt    = "int"
+	DataTypeFloat  = "float"
+	DataTypeString = "string"
+	DataTypeBool   = "bool"
+	DataTypeEnum   = "enum"
+)
+
+// Alias название характеристики у магазина, соответствующее атрибуту ("Radna memorija" -> RAM)
+type Alias struct {
+	AttributeID string
+	Alias       string // нормализованное название (NormalizeLabel)
+}
+
+// Value типизированное значение атрибута товара
+type Value struct {
+	AttributeID   string
+	AttributeCode string
+	Text          *string  // для string/enum
+	Number        *float64 // для int/float, в единицах атрибута (UnitSr)
+	Bool          *bool    // для bool
+	Unit          string   // единица атрибута, если задана
+	RawValue      string   // исходное значение из характеристик магазина
+	SourceLabel   string   // исходное название характеристики
+}
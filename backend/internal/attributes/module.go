@@ -1,6 +1,7 @@
 package attributes
 
 import (
+	"github.com/solomonczyk/izborator/internal/cache"
 	"github.com/solomonczyk/izborator/internal/logger"
 )
 
@@ -17,19 +18,31 @@ type Storage interface {
 
 	// GetByProductTypeID получает атрибуты для типа товара
 	GetByProductTypeID(productTypeID string) ([]*ProductTypeAttribute, error)
+
+	// GetAliases получает сохранённые названия характеристик магазинов
+	GetAliases() ([]*Alias, error)
+
+	// SaveProductValues сохраняет типизированные значения атрибутов товара
+	SaveProductValues(productID string, values []*Value) error
 }
 
 // Service сервис для работы с атрибутами
 type Service struct {
 	storage Storage
 	logger  *logger.Logger
+
+	normalizer *cache.TTL[*Normalizer]
 }
 
 // New создаёт новый сервис атрибутов
 func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
 	return &Service{
-		storage: storage,
-		logger:  log,
+		storage:    storage,
+		logger:     log,
+		normalizer: cache.NewTTL[*Normalizer](normalizerTTL),
 	}
 }
 
@@ -0,0 +1,245 @@
+package attributes
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultAliases названия характеристик у сербских магазинов для атрибутов справочника.
+// Дополнительные названия хранятся в attribute_aliases и имеют приоритет
+var defaultAliases = map[string][]string{
+	"BRAND":            {"brend", "brand", "proizvodjac", "marka"},
+	"COLOR":            {"boja", "color", "colour", "boja kucista"},
+	"RAM":              {"ram", "ram memorija", "radna memorija", "memorija ram", "operativna memorija", "kolicina ram memorije", "ram memory"},
+	"INTERNAL_STORAGE": {"interna memorija", "unutrasnja memorija", "ugradjena memorija", "memorija", "kapacitet memorije", "storage", "rom"},
+	"SCREEN_SIZE":      {"dijagonala", "dijagonala ekrana", "velicina ekrana", "ekran", "dijagonala displeja", "velicina displeja", "screen size"},
+	"CPU":              {"procesor", "cpu", "tip procesora", "model procesora", "cipset", "chipset"},
+	"GPU":              {"graficka kartica", "graficki procesor", "gpu"},
+	"OS":               {"operativni sistem", "os", "operating system"},
+	"BATTERY_CAPACITY": {"baterija", "kapacitet baterije", "battery", "battery capacity"},
+	"FAT_PERCENT":      {"procenat masti", "mlecna mast", "masnoca"},
+	"VOLUME_L":         {"zapremina", "neto zapremina"},
+	"SIZE_EU":          {"velicina", "broj", "eu velicina", "velicina eu"},
+	"GENDER":           {"pol", "gender"},
+	"WIDTH_CM":         {"sirina", "width"},
+	"HEIGHT_CM":        {"visina", "height"},
+	"DEPTH_CM":         {"dubina", "depth"},
+	"MATERIAL":         {"materijal", "material"},
+}
+
+// unit единица измерения: семейство и множитель к базовой единице семейства
+type unit struct {
+	family string
+	factor float64
+}
+
+// units единицы в значениях характеристик (после transliterate и приведения к нижнему регистру)
+var units = map[string]unit{
+	"mb": {"data", 1.0 / 1024}, "gb": {"data", 1}, "tb": {"data", 1024},
+	"mah": {"charge", 1}, "ah": {"charge", 1000},
+	"mm": {"length", 0.1}, "cm": {"length", 1}, "m": {"length", 100},
+	`"`: {"length", 2.54}, "″": {"length", 2.54}, "”": {"length", 2.54}, "''": {"length", 2.54},
+	"in": {"length", 2.54}, "inch": {"length", 2.54}, "inca": {"length", 2.54}, "inc": {"length", 2.54},
+	"ml": {"volume", 0.001}, "dl": {"volume", 0.1}, "l": {"volume", 1}, "lit": {"volume", 1}, "litar": {"volume", 1}, "litra": {"volume", 1},
+	"g": {"mass", 0.001}, "gr": {"mass", 0.001}, "kg": {"mass", 1},
+	"%": {"percent", 1},
+}
+
+var (
+	numberRegex    = regexp.MustCompile(`(\d+(?:[.,]\d+)*)\s*(''|[a-z%"″”]+)?`)
+	thousandsRegex = regexp.MustCompile(`^\d{1,3}([.,]\d{3})+$`)
+	bracketsRegex  = regexp.MustCompile(`\([^)]*\)`)
+	nonWordRegex   = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// transliteration латиница без диакритики и сербская кириллица
+var transliteration = strings.NewReplacer(
+	"š", "s", "đ", "dj", "č", "c", "ć", "c", "ž", "z",
+	"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "ђ", "dj", "е", "e", "ж", "z", "з", "z",
+	"и", "i", "ј", "j", "к", "k", "л", "l", "љ", "lj", "м", "m", "н", "n", "њ", "nj", "о", "o",
+	"п", "p", "р", "r", "с", "s", "т", "t", "ћ", "c", "у", "u", "ф", "f", "х", "h", "ц", "c",
+	"ч", "c", "џ", "dz", "ш", "s",
+)
+
+// Normalizer сопоставляет характеристики магазина с атрибутами справочника
+type Normalizer struct {
+	byLabel map[string]*Attribute
+}
+
+// NewNormalizer строит словарь названий: код, название атрибута, встроенные и сохранённые синонимы
+func NewNormalizer(attrs []*Attribute, aliases []*Alias) *Normalizer {
+	n := &Normalizer{byLabel: make(map[string]*Attribute)}
+	byID := make(map[string]*Attribute, len(attrs))
+	for _, attr := range attrs {
+		byID[attr.ID] = attr
+		n.register(attr.Code, attr, false)
+		n.register(attr.NameSr, attr, false)
+		for _, alias := range defaultAliases[attr.Code] {
+			n.register(alias, attr, false)
+		}
+	}
+	for _, alias := range aliases {
+		if attr, ok := byID[alias.AttributeID]; ok {
+			n.register(alias.Alias, attr, true)
+		}
+	}
+	return n
+}
+
+// register добавляет название; встроенные названия не перезаписывают друг друга
+func (n *Normalizer) register(label string, attr *Attribute, override bool) {
+	key := NormalizeLabel(label)
+	if key == "" {
+		return
+	}
+	if _, exists := n.byLabel[key]; exists && !override {
+		return
+	}
+	n.byLabel[key] = attr
+}
+
+// Normalize разбирает характеристики в типизированные значения атрибутов.
+// Второй результат - названия характеристик, которые не удалось сопоставить или разобрать
+func (n *Normalizer) Normalize(specs map[string]string) ([]*Value, []string) {
+	labels := make([]string, 0, len(specs))
+	for label := range specs {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	seen := make(map[string]bool)
+	values := make([]*Value, 0)
+	var unmatched []string
+	for _, label := range labels {
+		attr := n.byLabel[NormalizeLabel(label)]
+		if attr == nil || seen[attr.ID] {
+			unmatched = append(unmatched, label)
+			continue
+		}
+		value, ok := ParseValue(attr, specs[label])
+		if !ok {
+			unmatched = append(unmatched, label)
+			continue
+		}
+		value.SourceLabel = label
+		seen[attr.ID] = true
+		values = append(values, value)
+	}
+	return values, unmatched
+}
+
+// NormalizeLabel приводит название характеристики к ключу словаря:
+// "RAM memorija (GB):" -> "ram memorija"
+func NormalizeLabel(label string) string {
+	label = transliteration.Replace(strings.ToLower(label))
+	label = bracketsRegex.ReplaceAllString(label, " ")
+	label = strings.ReplaceAll(label, "_", " ")
+	return strings.TrimSpace(nonWordRegex.ReplaceAllString(label, " "))
+}
+
+// ParseValue разбирает значение по типу данных атрибута; числа переводятся в единицу атрибута
+func ParseValue(attr *Attribute, raw string) (*Value, bool) {
+	raw = strings.Join(strings.Fields(raw), " ")
+	if raw == "" {
+		return nil, false
+	}
+	value := &Value{
+		AttributeID:   attr.ID,
+		AttributeCode: attr.Code,
+		RawValue:      raw,
+	}
+	if attr.UnitSr != nil {
+		value.Unit = *attr.UnitSr
+	}
+
+	switch attr.DataType {
+	case DataTypeInt, DataTypeFloat:
+		number, ok := parseNumber(raw, value.Unit)
+		if !ok {
+			return nil, false
+		}
+		if attr.DataType == DataTypeInt {
+			number = math.Round(number)
+		} else {
+			number = math.Round(number*100) / 100
+		}
+		value.Number = &number
+	case DataTypeBool:
+		b, ok := parseBool(raw)
+		if !ok {
+			return nil, false
+		}
+		value.Bool = &b
+	default:
+		value.Text = &raw
+	}
+	return value, true
+}
+
+// parseNumber ищет число в единице атрибута: сначала с той же единицей ("65\" (165 cm)" для дюймов),
+// затем с единицей того же семейства с пересчётом, затем число без единицы
+func parseNumber(raw, attrUnit string) (float64, bool) {
+	text := transliteration.Replace(strings.ToLower(raw))
+	target, hasTarget := units[transliteration.Replace(strings.ToLower(strings.TrimSpace(attrUnit)))]
+
+	var (
+		converted, bare       float64
+		hasConverted, hasBare bool
+	)
+	for _, match := range numberRegex.FindAllStringSubmatch(text, -1) {
+		number, ok := parseDecimal(match[1])
+		if !ok {
+			continue
+		}
+		found, known := units[match[2]]
+		switch {
+		case !hasTarget:
+			// Атрибут без единицы ("42 EU"): берём первое число как есть
+			if !hasBare {
+				bare, hasBare = number, true
+			}
+		case match[2] == "" || !known:
+			if !hasBare && match[2] == "" {
+				bare, hasBare = number, true
+			}
+		case found == target:
+			return number, true
+		case found.family == target.family && !hasConverted:
+			converted, hasConverted = number*found.factor/target.factor, true
+		}
+	}
+
+	if hasConverted {
+		return converted, true
+	}
+	return bare, hasBare
+}
+
+// parseDecimal разбирает "6,7", "5.000" (тысячи) и "1.234,56"
+func parseDecimal(value string) (float64, bool) {
+	switch {
+	case thousandsRegex.MatchString(value):
+		value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	case strings.Count(value, ".")+strings.Count(value, ",") > 1:
+		last := strings.LastIndexAny(value, ".,")
+		value = strings.NewReplacer(".", "", ",", "").Replace(value[:last]) + "." + value[last+1:]
+	default:
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return number, err == nil
+}
+
+// parseBool разбирает да/нет на сербском и английском
+func parseBool(raw string) (bool, bool) {
+	switch NormalizeLabel(raw) {
+	case "da", "yes", "ima", "true", "1", "podrzano", "podrzava", "postoji":
+		return true, true
+	case "ne", "no", "nema", "false", "0", "nije", "nije podrzano":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,106 @@
+package attributes
+
+import "testing"
+
+func strPtr(s string) *string { return &s }
+
+func testAttributes() []*Attribute {
+	return []*Attribute{
+		{ID: "ram", Code: "RAM", NameSr: "RAM", DataType: DataTypeInt, UnitSr: strPtr("GB")},
+		{ID: "storage", Code: "INTERNAL_STORAGE", NameSr: "Unutrašnja memorija", DataType: DataTypeInt, UnitSr: strPtr("GB")},
+		{ID: "screen", Code: "SCREEN_SIZE", NameSr: "Dijagonala ekrana", DataType: DataTypeFloat, UnitSr: strPtr("inča")},
+		{ID: "battery", Code: "BATTERY_CAPACITY", NameSr: "Kapacitet baterije", DataType: DataTypeInt, UnitSr: strPtr("mAh")},
+		{ID: "os", Code: "OS", NameSr: "Operativni sistem", DataType: DataTypeString},
+		{ID: "size", Code: "SIZE_EU", NameSr: "Veličina (EU)", DataType: DataTypeFloat},
+		{ID: "nfc", Code: "NFC", NameSr: "NFC", DataType: DataTypeBool},
+	}
+}
+
+func TestNormalizeLabel(t *testing.T) {
+	cases := map[string]string{
+		"RAM memorija (GB):":  "ram memorija",
+		"Unutrašnja memorija": "unutrasnja memorija",
+		"Радна меморија":      "radna memorija",
+		"INTERNAL_STORAGE":    "internal storage",
+	}
+	for in, want := range cases {
+		if got := NormalizeLabel(in); got != want {
+			t.Errorf("NormalizeLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizer_Normalize(t *testing.T) {
+	n := NewNormalizer(testAttributes(), []*Alias{{AttributeID: "storage", Alias: "Kapacitet skladišta"}})
+
+	values, unmatched := n.Normalize(map[string]string{
+		"Radna memorija":      "8GB LPDDR5",
+		"RAM memorija":        "12 GB",
+		"Kapacitet skladišta": "0,5 TB",
+		"Dijagonala ekrana:":  `6,7" (17 cm)`,
+		"Baterija":            "5.000 mAh",
+		"Operativni sistem":   "  Android   14 ",
+		"NFC":                 "Da",
+		"Veličina":            "42 EU",
+		"Težina":              "190 g",
+	})
+
+	got := make(map[string]*Value)
+	for _, v := range values {
+		got[v.AttributeCode] = v
+	}
+	checkNumber := func(code string, want float64) {
+		t.Helper()
+		v := got[code]
+		if v == nil || v.Number == nil || *v.Number != want {
+			t.Errorf("%s: want %v, got %+v", code, want, v)
+		}
+	}
+	checkNumber("RAM", 12) // "RAM memorija" раньше "Radna memorija" по алфавиту
+	checkNumber("INTERNAL_STORAGE", 512)
+	checkNumber("SCREEN_SIZE", 6.7)
+	checkNumber("BATTERY_CAPACITY", 5000)
+	checkNumber("SIZE_EU", 42)
+
+	if v := got["OS"]; v == nil || v.Text == nil || *v.Text != "Android 14" {
+		t.Errorf("OS: unexpected value %+v", v)
+	}
+	if v := got["NFC"]; v == nil || v.Bool == nil || !*v.Bool {
+		t.Errorf("NFC: unexpected value %+v", v)
+	}
+	if v := got["SCREEN_SIZE"]; v.Unit != "inča" || v.SourceLabel != "Dijagonala ekrana:" {
+		t.Errorf("SCREEN_SIZE: unexpected unit/label %+v", v)
+	}
+
+	if len(unmatched) != 2 || unmatched[0] != "Radna memorija" || unmatched[1] != "Težina" {
+		t.Errorf("unexpected unmatched labels %v", unmatched)
+	}
+}
+
+func TestParseValue_Conversions(t *testing.T) {
+	screen := &Attribute{ID: "screen", Code: "SCREEN_SIZE", DataType: DataTypeFloat, UnitSr: strPtr("inča")}
+	ram := &Attribute{ID: "ram", Code: "RAM", DataType: DataTypeInt, UnitSr: strPtr("GB")}
+
+	cases := []struct {
+		attr *Attribute
+		raw  string
+		want float64
+	}{
+		{screen, `165 cm (65")`, 65},
+		{screen, "139 cm", 54.72},
+		{screen, "55 inča", 55},
+		{ram, "8", 8},
+		{ram, "8192 MB", 8},
+		{ram, "1.024 GB", 1024},
+	}
+	for _, c := range cases {
+		v, ok := ParseValue(c.attr, c.raw)
+		if !ok || *v.Number != c.want {
+			t.Errorf("ParseValue(%s, %q) = %+v, want %v", c.attr.Code, c.raw, v, c.want)
+		}
+	}
+
+	if _, ok := ParseValue(ram, "nije navedeno"); ok {
+		t.Error("value without number must not be parsed")
+	}
+}
@@ -0,0 +1,56 @@
+package attributes
+
+import (
+	"fmt"
+	"time"
+)
+
+// normalizerTTL через столько перечитываются атрибуты и синонимы
+const normalizerTTL = 10 * time.Minute
+
+// Normalize сопоставляет характеристики магазина с атрибутами справочника и разбирает значения.
+// Второй результат - названия характеристик, которые не удалось сопоставить или разобрать
+func (s *Service) Normalize(specs map[string]string) ([]*Value, []string, error) {
+	if len(specs) == 0 {
+		return nil, nil, nil
+	}
+
+	normalizer, err := s.getNormalizer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, unmatched := normalizer.Normalize(specs)
+	return values, unmatched, nil
+}
+
+// SaveProductValues сохраняет типизированные значения атрибутов товара
+func (s *Service) SaveProductValues(productID string, values []*Value) error {
+	if productID == "" || len(values) == 0 {
+		return nil
+	}
+	return s.storage.SaveProductValues(productID, values)
+}
+
+// getNormalizer возвращает словарь названий, перечитывая справочник раз в normalizerTTL
+func (s *Service) getNormalizer() (*Normalizer, error) {
+	return s.normalizer.Get(s.loadNormalizer)
+}
+
+// loadNormalizer читает активные атрибуты и сохранённые названия характеристик
+func (s *Service) loadNormalizer() (*Normalizer, error) {
+	attrs, err := s.storage.GetAllActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attributes: %w", err)
+	}
+	aliases, err := s.storage.GetAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attribute aliases: %w", err)
+	}
+
+	s.logger.Debug("Attribute normalizer loaded", map[string]interface{}{
+		"attributes": len(attrs),
+		"aliases":    len(aliases),
+	})
+	return NewNormalizer(attrs, aliases), nil
+}
@@ -0,0 +1,9 @@
+package autoconfig
+
+import "errors"
+
+// ErrNoDegradedShops нет магазинов, ожидающих повторной авто-конфигурации
+var ErrNoDegradedShops = errors.New("no degraded shops")
+
+// ErrNoSelectors генератор не смог предложить селекторы name и price
+var ErrNoSelectors = errors.New("no selectors generated")
@@ -0,0 +1,102 @@
+package autoconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/selectorversions"
+)
+
+// SelectorGenerator предлагает CSS селекторы по HTML страницы товара (или прайс-листа для service_provider).
+// Найденные селекторы сервис затем проверяет на живой странице
+type SelectorGenerator interface {
+	GenerateSelectors(ctx context.Context, html string, siteType string) (*GeneratedSelectors, error)
+}
+
+// GeneratedSelectors селекторы, предложенные генератором
+type GeneratedSelectors struct {
+	Selectors map[string]string
+	// Source и Author попадают в историю версий селекторов
+	Source selectorversions.Source
+	Author string
+}
+
+// LLM языковая модель, отвечающая JSON с селекторами: OpenAI или OpenAI-совместимый endpoint (*ai.Client)
+type LLM interface {
+	GenerateSelectors(ctx context.Context, htmlSnippet string, siteType string) (string, error)
+	Model() string
+}
+
+// AIGenerator генерирует селекторы языковой моделью
+type AIGenerator struct {
+	llm LLM
+}
+
+// NewAIGenerator создаёт генератор селекторов на основе языковой модели
+func NewAIGenerator(llm LLM) *AIGenerator {
+	return &AIGenerator{llm: llm}
+}
+
+// GenerateSelectors отправляет модели очищенный текст страницы и разбирает JSON ответа
+func (g *AIGenerator) GenerateSelectors(ctx context.Context, html string, siteType string) (*GeneratedSelectors, error) {
+	cleanHTML, err := CleanHTML(html)
+	if err != nil {
+		cleanHTML = html // используем сырой HTML, если очистка не удалась
+	}
+
+	selectorsJSON, err := g.llm.GenerateSelectors(ctx, cleanHTML, siteType)
+	if err != nil {
+		return nil, fmt.Errorf("AI generation failed: %w", err)
+	}
+
+	var selectors map[string]string
+	if err := json.Unmarshal([]byte(selectorsJSON), &selectors); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON from AI: %v", ErrNoSelectors, err)
+	}
+
+	return &GeneratedSelectors{
+		Selectors: selectors,
+		Source:    selectorversions.SourceAI,
+		Author:    g.llm.Model(),
+	}, nil
+}
+
+// ChainGenerator пробует генераторы по очереди и возвращает первый результат с селекторами name и price
+type ChainGenerator struct {
+	generators []SelectorGenerator
+}
+
+// NewChainGenerator создаёт цепочку генераторов, например AI с эвристикой в качестве запасного варианта
+func NewChainGenerator(generators ...SelectorGenerator) *ChainGenerator {
+	return &ChainGenerator{generators: generators}
+}
+
+// GenerateSelectors реализует SelectorGenerator
+func (g *ChainGenerator) GenerateSelectors(ctx context.Context, html string, siteType string) (*GeneratedSelectors, error) {
+	var errs []string
+	for _, generator := range g.generators {
+		result, err := generator.GenerateSelectors(ctx, html, siteType)
+		if err == nil && hasRequiredSelectors(result) {
+			return result, nil
+		}
+		if err == nil {
+			err = ErrNoSelectors
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoSelectors
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+// hasRequiredSelectors проверяет, что есть хотя бы name и price
+func hasRequiredSelectors(result *GeneratedSelectors) bool {
+	return result != nil && result.Selectors["name"] != "" && result.Selectors["price"] != ""
+}
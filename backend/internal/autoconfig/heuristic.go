@@ -0,0 +1,393 @@
+package autoconfig
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
+)
+
+// HeuristicAuthor автор версий селекторов, подобранных эвристикой
+const HeuristicAuthor = "heuristic"
+
+// maxSelectorDepth сколько предков добавляется к селектору, прежде чем перейти к пути от body
+const maxSelectorDepth = 4
+
+var (
+	// priceTextRegex число с валютой: "42.999 RSD", "1.500 din.", "€ 19,99"
+	priceTextRegex = regexp.MustCompile(`(?i)\d[\d.,\s\x{00a0}]*(?:rsd|din|дин|€|eur|usd|\$|km|kn)|(?:€|\$|eur|usd|rsd)\s*\d`)
+	// bareNumberRegex число без валюты - цена в столбце прайс-листа: "1.500", "2.000,00", "990,-"
+	bareNumberRegex = regexp.MustCompile(`^\d[\d.,\s\x{00a0}]*(?:,-)?$`)
+	numberRegex     = regexp.MustCompile(`\d[\d.,\s\x{00a0}]*\d|\d`)
+	decimalsRegex   = regexp.MustCompile(`[.,]\d{1,2}$`)
+	nonDigitRegex   = regexp.MustCompile(`\D`)
+	priceAttrRegex  = regexp.MustCompile(`(?i)price|cena|cijena|цена|amount`)
+	oldPriceRegex   = regexp.MustCompile(`(?i)old|regular|was|strike|stara|before`)
+	imageAttrRegex  = regexp.MustCompile(`(?i)gallery|product|media|image|slika`)
+	identRegex      = regexp.MustCompile(`^[a-zA-Z_][\w-]*$`)
+)
+
+// HeuristicGenerator подбирает селекторы по статистике DOM без обращения к сети и AI:
+// h1 и og:title для названия, текстовые узлы с ценой для цены, og:image для картинки,
+// для прайс-листов - столбцы таблицы с ценами
+type HeuristicGenerator struct{}
+
+// NewHeuristicGenerator создаёт эвристический генератор селекторов
+func NewHeuristicGenerator() *HeuristicGenerator {
+	return &HeuristicGenerator{}
+}
+
+// GenerateSelectors реализует SelectorGenerator
+func (g *HeuristicGenerator) GenerateSelectors(ctx context.Context, html string, siteType string) (*GeneratedSelectors, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	doc.Find("script, style, noscript, template, svg").Remove()
+
+	var selectors map[string]string
+	if siteType == "service_provider" {
+		selectors = priceListSelectors(doc)
+	} else {
+		selectors = productSelectors(doc)
+	}
+
+	result := &GeneratedSelectors{
+		Selectors: selectors,
+		Source:    selectorversions.SourceAutoconfig,
+		Author:    HeuristicAuthor,
+	}
+	if !hasRequiredSelectors(result) {
+		return nil, fmt.Errorf("%w: heuristic found name=%q price=%q", ErrNoSelectors, selectors["name"], selectors["price"])
+	}
+	return result, nil
+}
+
+// productSelectors селекторы страницы товара
+func productSelectors(doc *goquery.Document) map[string]string {
+	selectors := make(map[string]string)
+	if name := findName(doc, metaContent(doc, "og:title")); name != nil {
+		selectors["name"] = selectorFor(doc, name)
+	}
+	if price := findPrice(doc, metaContent(doc, "product:price:amount")); price != nil {
+		selectors["price"] = selectorFor(doc, price)
+	}
+	if image := findImage(doc, metaContent(doc, "og:image")); image != nil {
+		selectors["image"] = selectorFor(doc, image)
+	}
+	description := metaContent(doc, "og:description")
+	if description == "" {
+		description = metaContent(doc, "description")
+	}
+	if desc := findDescription(doc, description); desc != nil {
+		selectors["description"] = selectorFor(doc, desc)
+	}
+	return selectors
+}
+
+// findName h1 или [itemprop=name] вне шапки сайта, наиболее полно совпадающий с og:title;
+// без совпадения - первый непустой
+func findName(doc *goquery.Document, ogTitle string) *goquery.Selection {
+	title := strings.ToLower(normalizeText(ogTitle))
+	var first, match *goquery.Selection
+	matchLength := 0
+	doc.Find(`h1, [itemprop="name"]`).Each(func(_ int, s *goquery.Selection) {
+		text := strings.ToLower(normalizeText(s.Text()))
+		if text == "" || s.Closest("header, nav, footer").Length() > 0 {
+			return
+		}
+		if first == nil {
+			first = s
+		}
+		if title != "" && len(text) > matchLength && (strings.Contains(title, text) || strings.Contains(text, title)) {
+			match, matchLength = s, len(text)
+		}
+	})
+	if match != nil {
+		return match
+	}
+	return first
+}
+
+// findPrice элемент с ценой: короткий текст с числом, валюта, класс price/cena, совпадение с product:price:amount.
+// Старые (зачёркнутые) цены штрафуются
+func findPrice(doc *goquery.Document, amount string) *goquery.Selection {
+	var best *goquery.Selection
+	bestScore := 0
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		text := normalizeText(s.Text())
+		if text == "" || len([]rune(text)) > 40 || !numberRegex.MatchString(text) {
+			return
+		}
+
+		score := 0
+		if priceTextRegex.MatchString(text) {
+			score += 2
+		}
+		if priceAttrRegex.MatchString(elementAttrs(s)) {
+			score += 3
+		}
+		if s.AttrOr("itemprop", "") == "price" {
+			score += 5
+		}
+		if amount != "" && samePrice(text, amount) {
+			score += 4
+		}
+		if score < 2 {
+			return
+		}
+		if s.Closest("del, s, strike").Length() > 0 || oldPriceRegex.MatchString(elementAttrs(s)+" "+elementAttrs(s.Parent())) {
+			score -= 4
+		}
+
+		// При равном счёте вложенный элемент точнее контейнера
+		if score > bestScore || (score == bestScore && best != nil && best.HasSelection(s).Length() > 0) {
+			best = s
+			bestScore = score
+		}
+	})
+	if bestScore <= 0 {
+		return nil
+	}
+	return best
+}
+
+// findImage картинка из og:image, иначе [itemprop=image], иначе первая картинка галереи
+func findImage(doc *goquery.Document, ogImage string) *goquery.Selection {
+	images := doc.Find("img")
+	if base := urlBase(ogImage); base != "" {
+		if match := images.FilterFunction(func(_ int, s *goquery.Selection) bool {
+			src := imageSrc(s)
+			return src == ogImage || urlBase(src) == base
+		}).First(); match.Length() > 0 {
+			return match
+		}
+	}
+	if match := images.Filter(`[itemprop="image"]`).First(); match.Length() > 0 {
+		return match
+	}
+	if match := images.FilterFunction(func(_ int, s *goquery.Selection) bool {
+		if imageSrc(s) == "" {
+			return false
+		}
+		return imageAttrRegex.MatchString(elementAttrs(s)) || s.ParentsFiltered("*").FilterFunction(func(_ int, p *goquery.Selection) bool {
+			return imageAttrRegex.MatchString(elementAttrs(p))
+		}).Length() > 0
+	}).First(); match.Length() > 0 {
+		return match
+	}
+	return nil
+}
+
+// findDescription [itemprop=description], иначе самый вложенный элемент с текстом meta description
+func findDescription(doc *goquery.Document, metaDescription string) *goquery.Selection {
+	if match := doc.Find(`[itemprop="description"]`).First(); match.Length() > 0 {
+		return match
+	}
+
+	snippet := []rune(normalizeText(metaDescription))
+	if len(snippet) < 20 {
+		return nil
+	}
+	if len(snippet) > 60 {
+		snippet = snippet[:60]
+	}
+
+	var best *goquery.Selection
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		if !strings.Contains(normalizeText(s.Text()), string(snippet)) {
+			return
+		}
+		if best == nil || best.HasSelection(s).Length() > 0 {
+			best = s
+		}
+	})
+	return best
+}
+
+// priceListSelectors селекторы прайс-листа: столбцы таблицы с наибольшим числом цен,
+// иначе повторяющиеся элементы списка с ценами
+func priceListSelectors(doc *goquery.Document) map[string]string {
+	selectors := make(map[string]string)
+
+	var bestTable *goquery.Selection
+	bestCount, priceCol := 0, 0
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		counts := make(map[int]int)
+		table.Find("tr").Each(func(_ int, row *goquery.Selection) {
+			row.ChildrenFiltered("td").Each(func(i int, cell *goquery.Selection) {
+				if looksLikePrice(normalizeText(cell.Text())) {
+					counts[i+1]++
+				}
+			})
+		})
+		// При равенстве берётся правый столбец: слева обычно номера строк, справа - итоговая цена
+		for col, count := range counts {
+			if count >= 2 && (count > bestCount || (count == bestCount && table == bestTable && col > priceCol)) {
+				bestTable, bestCount, priceCol = table, count, col
+			}
+		}
+	})
+
+	if bestTable != nil {
+		nameCol := 1
+		if priceCol == 1 {
+			nameCol = 2
+		}
+		tableSelector := selectorFor(doc, bestTable)
+		selectors["name"] = fmt.Sprintf("%s tr > td:nth-child(%d)", tableSelector, nameCol)
+		selectors["price"] = fmt.Sprintf("%s tr > td:nth-child(%d)", tableSelector, priceCol)
+		return selectors
+	}
+
+	// Список на div: одинаковые элементы с ценой, название - первый текстовый потомок того же элемента списка
+	groups := make(map[string][]*goquery.Selection)
+	var order []string
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		text := normalizeText(s.Text())
+		if len([]rune(text)) > 40 || !priceTextRegex.MatchString(text) || s.AttrOr("class", "") == "" {
+			return
+		}
+		key := elementSelector(s)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], s)
+	})
+	for _, key := range order {
+		prices := groups[key]
+		if len(prices) < 2 {
+			continue
+		}
+		item := prices[0].Parent()
+		itemSelector := elementSelector(item)
+		name := item.Children().FilterFunction(func(_ int, child *goquery.Selection) bool {
+			text := normalizeText(child.Text())
+			return text != "" && !looksLikePrice(text) && child.HasSelection(prices[0]).Length() == 0
+		}).First()
+		if name.Length() == 0 {
+			continue
+		}
+		selectors["name"] = itemSelector + " > " + elementSelector(name)
+		selectors["price"] = itemSelector + " > " + key
+		return selectors
+	}
+	return selectors
+}
+
+// selectorFor CSS селектор, первым совпадением которого в документе является элемент.
+// Короткий селектор по id/классам уточняется предками, в крайнем случае - путь от body
+func selectorFor(doc *goquery.Document, target *goquery.Selection) string {
+	var parts []string
+	node := target
+	for depth := 0; depth < maxSelectorDepth && node.Length() > 0; depth++ {
+		tag := goquery.NodeName(node)
+		if tag == "body" || tag == "html" {
+			break
+		}
+		parts = append([]string{elementSelector(node)}, parts...)
+		candidate := strings.Join(parts, " > ")
+		if doc.Find(candidate).First().IsSelection(target) {
+			return candidate
+		}
+		node = node.Parent()
+	}
+	return pathSelector(target)
+}
+
+// elementSelector селектор одного элемента: tag#id, tag[itemprop], tag.class1.class2 или tag
+func elementSelector(s *goquery.Selection) string {
+	tag := goquery.NodeName(s)
+	if id := s.AttrOr("id", ""); identRegex.MatchString(id) {
+		return tag + "#" + id
+	}
+	if itemprop := s.AttrOr("itemprop", ""); identRegex.MatchString(itemprop) {
+		return fmt.Sprintf(`%s[itemprop="%s"]`, tag, itemprop)
+	}
+
+	selector := tag
+	classes := 0
+	for _, class := range strings.Fields(s.AttrOr("class", "")) {
+		if classes == 2 {
+			break
+		}
+		if identRegex.MatchString(class) {
+			selector += "." + class
+			classes++
+		}
+	}
+	return selector
+}
+
+// pathSelector путь от body через :nth-of-type, однозначно задающий элемент
+func pathSelector(target *goquery.Selection) string {
+	var parts []string
+	for node := target; node.Length() > 0; node = node.Parent() {
+		tag := goquery.NodeName(node)
+		if tag == "body" || tag == "html" {
+			break
+		}
+		index := node.PrevAllFiltered(tag).Length() + 1
+		parts = append([]string{fmt.Sprintf("%s:nth-of-type(%d)", tag, index)}, parts...)
+	}
+	return "body > " + strings.Join(parts, " > ")
+}
+
+// metaContent значение meta тега по property или name
+func metaContent(doc *goquery.Document, key string) string {
+	selector := fmt.Sprintf(`meta[property="%s"], meta[name="%s"]`, key, key)
+	return strings.TrimSpace(doc.Find(selector).First().AttrOr("content", ""))
+}
+
+// elementAttrs атрибуты элемента, по которым угадывается его роль
+func elementAttrs(s *goquery.Selection) string {
+	return s.AttrOr("class", "") + " " + s.AttrOr("id", "") + " " + s.AttrOr("itemprop", "")
+}
+
+// imageSrc адрес картинки с учётом lazy loading
+func imageSrc(s *goquery.Selection) string {
+	if src := s.AttrOr("src", ""); src != "" {
+		return src
+	}
+	return s.AttrOr("data-src", "")
+}
+
+// urlBase имя файла из URL без query
+func urlBase(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Path == "" {
+		return ""
+	}
+	base := path.Base(parsed.Path)
+	if base == "/" || base == "." {
+		return ""
+	}
+	return base
+}
+
+// looksLikePrice текст с валютой или одно число (ячейка столбца цен)
+func looksLikePrice(text string) bool {
+	return text != "" && (priceTextRegex.MatchString(text) || bareNumberRegex.MatchString(text))
+}
+
+// samePrice совпадает ли целая часть цены в тексте с product:price:amount
+func samePrice(text, amount string) bool {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil {
+		return false
+	}
+	number := decimalsRegex.ReplaceAllString(strings.TrimSpace(numberRegex.FindString(text)), "")
+	return nonDigitRegex.ReplaceAllString(number, "") == strconv.FormatFloat(math.Trunc(value), 'f', 0, 64)
+}
+
+// normalizeText схлопывает пробелы
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
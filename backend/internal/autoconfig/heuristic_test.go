@@ -0,0 +1,144 @@
+package autoconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
+)
+
+const productPageHTML = `<html><head>
+<title>Samsung Galaxy A55 128GB | Shop</title>
+<meta property="og:title" content="Samsung Galaxy A55 128GB | Shop">
+<meta property="og:image" content="https://cdn.shop.example/img/a55-front.jpg?w=800">
+<meta property="og:description" content="Samsung Galaxy A55 sa 6.6 inča Super AMOLED ekranom i trostrukom kamerom.">
+<meta property="product:price:amount" content="42999.00">
+</head><body>
+<header><h1 class="logo">Shop</h1><nav><a href="/">Početna</a></nav></header>
+<div class="product">
+  <div class="gallery"><img src="/img/logo-badge.png"><img class="main" src="https://cdn.shop.example/img/a55-front.jpg"></div>
+  <div class="info">
+    <h1 class="product-title">Samsung Galaxy A55 128GB</h1>
+    <div class="prices">
+      <del class="old-price">49.999 RSD</del>
+      <span class="price">42.999 <small>RSD</small></span>
+    </div>
+    <p class="stock">Na stanju: 5 kom</p>
+    <div class="tabs"><div class="tab"><p>Samsung Galaxy A55 sa 6.6 inča Super AMOLED ekranom i trostrukom kamerom.</p></div></div>
+  </div>
+</div>
+</body></html>`
+
+const priceListHTML = `<html><body>
+<h1>Cenovnik usluga</h1>
+<table class="cenovnik">
+  <tr><th>#</th><th>Usluga</th><th>Cena</th></tr>
+  <tr><td>1.</td><td>Servis klima uređaja</td><td>4.500 din</td></tr>
+  <tr><td>2.</td><td>Dopuna freona</td><td>3.000 din</td></tr>
+  <tr><td>3.</td><td>Montaža</td><td>9.000 din</td></tr>
+</table>
+</body></html>`
+
+func TestHeuristicGenerator_ProductPage(t *testing.T) {
+	result, err := NewHeuristicGenerator().GenerateSelectors(context.Background(), productPageHTML, "ecommerce")
+	if err != nil {
+		t.Fatalf("GenerateSelectors failed: %v", err)
+	}
+	if result.Source != selectorversions.SourceAutoconfig || result.Author != HeuristicAuthor {
+		t.Errorf("unexpected attribution %s/%s", result.Source, result.Author)
+	}
+
+	doc := mustParse(t, productPageHTML)
+	checks := map[string]string{
+		"name":        "Samsung Galaxy A55 128GB",
+		"price":       "42.999 RSD",
+		"description": "Samsung Galaxy A55 sa 6.6 inča Super AMOLED ekranom i trostrukom kamerom.",
+	}
+	for field, want := range checks {
+		got := normalizeText(doc.Find(result.Selectors[field]).First().Text())
+		if got != want {
+			t.Errorf("%s selector %q extracted %q, want %q", field, result.Selectors[field], got, want)
+		}
+	}
+	if src := doc.Find(result.Selectors["image"]).First().AttrOr("src", ""); src != "https://cdn.shop.example/img/a55-front.jpg" {
+		t.Errorf("image selector %q matched %q", result.Selectors["image"], src)
+	}
+}
+
+func TestHeuristicGenerator_PriceList(t *testing.T) {
+	result, err := NewHeuristicGenerator().GenerateSelectors(context.Background(), priceListHTML, "service_provider")
+	if err != nil {
+		t.Fatalf("GenerateSelectors failed: %v", err)
+	}
+
+	doc := mustParse(t, priceListHTML)
+	names := doc.Find(result.Selectors["name"]).Map(func(_ int, s *goquery.Selection) string { return s.Text() })
+	prices := doc.Find(result.Selectors["price"]).Map(func(_ int, s *goquery.Selection) string { return s.Text() })
+	if len(names) != 3 || len(prices) != 3 {
+		t.Fatalf("expected 3 rows, got names %v prices %v (selectors %v)", names, prices, result.Selectors)
+	}
+	if prices[0] != "4.500 din" {
+		t.Errorf("unexpected first price %q", prices[0])
+	}
+}
+
+func TestHeuristicGenerator_NoPrice(t *testing.T) {
+	_, err := NewHeuristicGenerator().GenerateSelectors(context.Background(), `<html><body><h1>O nama</h1></body></html>`, "ecommerce")
+	if !errors.Is(err, ErrNoSelectors) {
+		t.Fatalf("expected ErrNoSelectors, got %v", err)
+	}
+}
+
+func TestChainGenerator_FallsBackToHeuristic(t *testing.T) {
+	ai := NewAIGenerator(NewMockAIClient("", errors.New("rate limited")))
+	result, err := NewChainGenerator(ai, NewHeuristicGenerator()).GenerateSelectors(context.Background(), productPageHTML, "ecommerce")
+	if err != nil {
+		t.Fatalf("GenerateSelectors failed: %v", err)
+	}
+	if result.Source != selectorversions.SourceAutoconfig {
+		t.Errorf("expected heuristic result, got %s", result.Source)
+	}
+
+	ai = NewAIGenerator(NewMockAIClient(`{"name": "h1.product-title", "price": "span.price"}`, nil))
+	result, err = NewChainGenerator(ai, NewHeuristicGenerator()).GenerateSelectors(context.Background(), productPageHTML, "ecommerce")
+	if err != nil {
+		t.Fatalf("GenerateSelectors failed: %v", err)
+	}
+	if result.Source != selectorversions.SourceAI || result.Author != "mock-model" {
+		t.Errorf("expected AI result, got %s/%s", result.Source, result.Author)
+	}
+}
+
+func TestService_GenerateSelectorsOffline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(productPageHTML))
+	}))
+	defer server.Close()
+
+	service := NewService(nil, NewHeuristicGenerator(), logger.New("error"))
+	generated, reason, err := service.generateSelectors(context.Background(), server.URL+"/p/a55", "ecommerce")
+	if err != nil {
+		t.Fatalf("generateSelectors failed (%s): %v", reason, err)
+	}
+
+	config := generatedConfig(generated)
+	if config.Source != selectorversions.SourceAutoconfig || config.Author != HeuristicAuthor || config.Selectors["price"] == "" {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
+func mustParse(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+	return doc
+}
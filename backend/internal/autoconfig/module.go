@@ -1,10 +1,23 @@
 package autoconfig
 
+import "github.com/solomonczyk/izborator/internal/selectorversions"
+
 // Storage интерфейс для работы с БД (кандидаты и магазины)
 type Storage interface {
-	GetClassifiedCandidates(limit int) ([]Candidate, error)
+	// ClaimCandidates забирает кандидатов в работу (статус configuring): классифицированных
+	// и неудачных, у которых ещё остались попытки
+	ClaimCandidates(limit int) ([]Candidate, error)
+	// MarkAsConfigured сохраняет проверенные селекторы; магазин создаётся после одобрения администратором
 	MarkAsConfigured(id string, config ShopConfig) error
+	// MarkAsFailed сохраняет неудачную попытку и её причину
 	MarkAsFailed(id string, reason string) error
+
+	// GetDegradedShops возвращает магазины, помеченные детектором деградации селекторов
+	GetDegradedShops(limit int) ([]DegradedShop, error)
+	// ApplyReconfiguration сохраняет новые селекторы магазина как активную версию; прежние остаются для отката
+	ApplyReconfiguration(shopID string, config ShopConfig) error
+	// MarkReconfigurationFailed сохраняет неудачную попытку; после лимита попыток магазин требует ручной правки
+	MarkReconfigurationFailed(shopID string, reason string) error
 }
 
 // Candidate кандидат на магазин для авто-конфигурации
@@ -12,9 +25,23 @@ type Candidate struct {
 	ID       string
 	Domain   string
 	SiteType string // "ecommerce" | "service_provider" | "unknown"
+	// SampleURL страница товара из sitemap кандидата (discovery); с ней Scout не нужен
+	SampleURL string
+}
+
+// DegradedShop магазин с деградировавшими селекторами
+type DegradedShop struct {
+	ShopID         string
+	Domain         string
+	SampleURL      string // последняя спарсенная страница товара магазина
+	SiteType       string
+	DegradedFields []string
 }
 
 // ShopConfig конфигурация магазина с селекторами
 type ShopConfig struct {
 	Selectors map[string]string `json:"selectors"`
+	// Source и Author попадают в историю версий селекторов
+	Source selectorversions.Source `json:"source,omitempty"`
+	Author string                  `json:"author,omitempty"`
 }
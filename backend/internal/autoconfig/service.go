@@ -2,40 +2,41 @@ package autoconfig
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
-	"github.com/solomonczyk/izborator/internal/ai"
 	"github.com/solomonczyk/izborator/internal/logger"
 )
 
-// Service Ã‘ÂÃÂµÃ‘â‚¬ÃÂ²ÃÂ¸Ã‘Â ÃÂ´ÃÂ»Ã‘Â ÃÂ°ÃÂ²Ã‘â€šÃÂ¾ÃÂ¼ÃÂ°Ã‘â€šÃÂ¸Ã‘â€¡ÃÂµÃ‘ÂÃÂºÃÂ¾ÃÂ¹ ÃÂ³ÃÂµÃÂ½ÃÂµÃ‘â‚¬ÃÂ°Ã‘â€ ÃÂ¸ÃÂ¸ ÃÂºÃÂ¾ÃÂ½Ã‘â€žÃÂ¸ÃÂ³ÃÂ¾ÃÂ²
+// Service сервис для автоматической генерации конфигов
 type Service struct {
-	storage Storage
-	ai      *ai.Client
-	log     *logger.Logger
+	storage   Storage
+	generator SelectorGenerator
+	log       *logger.Logger
 }
 
-// NewService Ã‘ÂÃÂ¾ÃÂ·ÃÂ´ÃÂ°ÃÂµÃ‘â€š ÃÂ½ÃÂ¾ÃÂ²Ã‘â€¹ÃÂ¹ Ã‘ÂÃÂµÃ‘â‚¬ÃÂ²ÃÂ¸Ã‘Â AutoConfig
-func NewService(storage Storage, ai *ai.Client, log *logger.Logger) *Service {
+// NewService создаёт новый сервис AutoConfig.
+// generator - AI (OpenAI или совместимый endpoint), эвристика или их цепочка
+func NewService(storage Storage, generator SelectorGenerator, log *logger.Logger) *Service {
 	return &Service{
-		storage: storage,
-		ai:      ai,
-		log:     log,
+		storage:   storage,
+		generator: generator,
+		log:       log,
 	}
 }
 
-// ProcessNextCandidate ÃÂ±ÃÂµÃ‘â‚¬ÃÂµÃ‘â€š ÃÂ¾ÃÂ´ÃÂ½ÃÂ¾ÃÂ³ÃÂ¾ ÃÂºÃÂ°ÃÂ½ÃÂ´ÃÂ¸ÃÂ´ÃÂ°Ã‘â€šÃÂ° ÃÂ¸ ÃÂ¿Ã‘â€¹Ã‘â€šÃÂ°ÃÂµÃ‘â€šÃ‘ÂÃ‘Â Ã‘ÂÃÂ¾ÃÂ·ÃÂ´ÃÂ°Ã‘â€šÃ‘Å’ ÃÂºÃÂ¾ÃÂ½Ã‘â€žÃÂ¸ÃÂ³
+// ProcessNextCandidate берёт одного кандидата и пытается создать конфиг
 func (s *Service) ProcessNextCandidate(ctx context.Context) error {
-	if s.ai == nil {
-		return fmt.Errorf("AI client is not available")
+	if s.generator == nil {
+		return fmt.Errorf("selector generator is not configured")
 	}
 
-	candidates, err := s.storage.GetClassifiedCandidates(1)
+	candidates, err := s.storage.ClaimCandidates(1)
 	if err != nil {
 		return fmt.Errorf("failed to get candidates: %w", err)
 	}
@@ -49,125 +50,204 @@ func (s *Service) ProcessNextCandidate(ctx context.Context) error {
 		siteType = "ecommerce"
 	}
 
-	s.log.Info("Ã°Å¸Â¤â€“ Auto-configuring shop", map[string]interface{}{
+	s.log.Info("🤖 Auto-configuring shop", map[string]interface{}{
 		"domain": candidate.Domain,
 		"id":     candidate.ID,
 	})
 
-	// 1. Scout: ÃËœÃ‘â€°ÃÂµÃÂ¼ Ã‘ÂÃ‘â€šÃ‘â‚¬ÃÂ°ÃÂ½ÃÂ¸Ã‘â€ Ã‘Æ’ Ã‘â€šÃÂ¾ÃÂ²ÃÂ°Ã‘â‚¬ÃÂ°
-	productURL, err := s.findProductPage(candidate.Domain, siteType)
-	if err != nil {
-		s.log.Error("Scout failed", map[string]interface{}{
-			"domain": candidate.Domain,
-			"error":  err.Error(),
-		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "scout_failed: "+err.Error()); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
+	// 1. Scout: ищем страницу товара, если discovery не нашёл её в sitemap
+	productURL := s.sampleURL(candidate.SampleURL, candidate.Domain)
+	if productURL == "" {
+		found, err := s.findProductPage(candidate.Domain, siteType)
+		if err != nil {
+			s.log.Error("Scout failed", map[string]interface{}{
+				"domain": candidate.Domain,
+				"error":  err.Error(),
 			})
+			s.markCandidateFailed(candidate.ID, "scout_failed: "+err.Error())
+			return fmt.Errorf("scout failed: %w", err)
 		}
-		return fmt.Errorf("scout failed: %w", err)
+		productURL = found
 	}
 	s.log.Info("Found page", map[string]interface{}{
 		"url":       productURL,
 		"site_type": siteType,
 	})
 
-	// 2. Fetch & Clean: ÃÂ¡ÃÂºÃÂ°Ã‘â€¡ÃÂ¸ÃÂ²ÃÂ°ÃÂµÃÂ¼ HTML
-	html, err := s.fetchHTML(productURL)
+	// 2-5. Fetch, Generate, Validate
+	generated, reason, err := s.generateSelectors(ctx, productURL, siteType)
 	if err != nil {
-		s.log.Error("Failed to fetch HTML", map[string]interface{}{
-			"url":   productURL,
-			"error": err.Error(),
-		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "fetch_failed: "+err.Error()); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
-			})
+		s.markCandidateFailed(candidate.ID, reason)
+		return err
+	}
+
+	// 6. Success: сохраняем
+	s.log.Info("✨ SUCCESS! Config generated", map[string]interface{}{
+		"selectors": generated.Selectors,
+		"source":    generated.Source,
+		"domain":    candidate.Domain,
+	})
+	return s.storage.MarkAsConfigured(candidate.ID, generatedConfig(generated))
+}
+
+// ProcessNextDegradedShop заново генерирует селекторы магазина, помеченного детектором деградации.
+// Текущие селекторы сохраняются для отката
+func (s *Service) ProcessNextDegradedShop(ctx context.Context) error {
+	if s.generator == nil {
+		return fmt.Errorf("selector generator is not configured")
+	}
+
+	shops, err := s.storage.GetDegradedShops(1)
+	if err != nil {
+		return fmt.Errorf("failed to get degraded shops: %w", err)
+	}
+	if len(shops) == 0 {
+		return ErrNoDegradedShops
+	}
+	shop := shops[0]
+
+	siteType := shop.SiteType
+	if siteType == "" {
+		siteType = "ecommerce"
+	}
+
+	s.log.Info("🔧 Re-configuring degraded shop", map[string]interface{}{
+		"shop_id":         shop.ShopID,
+		"domain":          shop.Domain,
+		"degraded_fields": shop.DegradedFields,
+	})
+
+	// Последняя страница товара магазина надёжнее эвристики поиска ссылок
+	productURL := s.sampleURL(shop.SampleURL, shop.Domain)
+	if productURL == "" {
+		productURL, err = s.findProductPage(shop.Domain, siteType)
+		if err != nil {
+			s.markShopFailed(shop.ShopID, "scout_failed: "+err.Error())
+			return fmt.Errorf("scout failed: %w", err)
 		}
-		return fmt.Errorf("fetch failed: %w", err)
 	}
 
-	cleanHTML, err := CleanHTML(html)
+	generated, reason, err := s.generateSelectors(ctx, productURL, siteType)
 	if err != nil {
-		s.log.Warn("HTML cleaning failed, using raw HTML", map[string]interface{}{
-			"error": err.Error(),
-		})
-		cleanHTML = html // ÃËœÃ‘ÂÃÂ¿ÃÂ¾ÃÂ»Ã‘Å’ÃÂ·Ã‘Æ’ÃÂµÃÂ¼ Ã‘ÂÃ‘â€¹Ã‘â‚¬ÃÂ¾ÃÂ¹ HTML, ÃÂµÃ‘ÂÃÂ»ÃÂ¸ ÃÂ¾Ã‘â€¡ÃÂ¸Ã‘ÂÃ‘â€šÃÂºÃÂ° ÃÂ½ÃÂµ Ã‘Æ’ÃÂ´ÃÂ°ÃÂ»ÃÂ°Ã‘ÂÃ‘Å’
+		s.markShopFailed(shop.ShopID, reason)
+		return err
 	}
 
-	// 3. Brain: ÃÂ¡ÃÂ¿Ã‘â‚¬ÃÂ°Ã‘Ë†ÃÂ¸ÃÂ²ÃÂ°ÃÂµÃÂ¼ AI
-	s.log.Info("Asking AI for selectors", map[string]interface{}{
-		"html_length": len(cleanHTML),
-		"site_type":   siteType,
+	if err := s.storage.ApplyReconfiguration(shop.ShopID, generatedConfig(generated)); err != nil {
+		return fmt.Errorf("failed to apply reconfiguration: %w", err)
+	}
+
+	s.log.Info("✨ Degraded shop re-configured", map[string]interface{}{
+		"shop_id":   shop.ShopID,
+		"selectors": generated.Selectors,
+		"source":    generated.Source,
 	})
-	selectorsJSON, err := s.ai.GenerateSelectors(ctx, cleanHTML, siteType)
+	return nil
+}
+
+// sampleURL возвращает страницу товара, если она принадлежит домену магазина.
+// Страница с чужого хоста (CDN, маркетплейс, сеть магазинов) дала бы селекторы другого сайта
+func (s *Service) sampleURL(pageURL, domain string) string {
+	if pageURL == "" {
+		return ""
+	}
+	if !onDomain(pageURL, domain) {
+		s.log.Warn("Sample URL is on another host, falling back to scout", map[string]interface{}{
+			"url":    pageURL,
+			"domain": domain,
+		})
+		return ""
+	}
+	return pageURL
+}
+
+// onDomain проверяет, что хост URL совпадает с доменом (без учёта www. и регистра)
+func onDomain(pageURL, domain string) bool {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+	return host != "" && host == domain
+}
+
+// generatedConfig конфигурация из сгенерированных селекторов; генератор (модель или эвристика) записывается автором версии
+func generatedConfig(generated *GeneratedSelectors) ShopConfig {
+	return ShopConfig{
+		Selectors: generated.Selectors,
+		Source:    generated.Source,
+		Author:    generated.Author,
+	}
+}
+
+// generateSelectors скачивает страницу, просит генератор предложить селекторы и проверяет их на странице.
+// При ошибке возвращает причину для журнала попыток конфигурации
+func (s *Service) generateSelectors(ctx context.Context, productURL, siteType string) (*GeneratedSelectors, string, error) {
+	// Fetch & Clean: скачиваем HTML
+	html, err := s.fetchHTML(productURL)
 	if err != nil {
-		s.log.Error("AI generation failed", map[string]interface{}{
+		s.log.Error("Failed to fetch HTML", map[string]interface{}{
+			"url":   productURL,
 			"error": err.Error(),
 		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "ai_failed: "+err.Error()); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
-			})
-		}
-		return fmt.Errorf("AI generation failed: %w", err)
+		return nil, "fetch_failed: " + err.Error(), fmt.Errorf("fetch failed: %w", err)
 	}
 
-	// 4. Parse JSON
-	var selectors map[string]string
-	if err := json.Unmarshal([]byte(selectorsJSON), &selectors); err != nil {
-		s.log.Error("Invalid JSON from AI", map[string]interface{}{
-			"json":  selectorsJSON,
+	s.log.Info("Generating selectors", map[string]interface{}{
+		"html_length": len(html),
+		"site_type":   siteType,
+	})
+	generated, err := s.generator.GenerateSelectors(ctx, html, siteType)
+	if err != nil {
+		s.log.Error("Selector generation failed", map[string]interface{}{
 			"error": err.Error(),
 		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "invalid_json: "+err.Error()); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
-			})
+		if errors.Is(err, ErrNoSelectors) {
+			return nil, "missing_required_selectors: " + err.Error(), fmt.Errorf("selector generation failed: %w", err)
 		}
-		return fmt.Errorf("invalid JSON: %w", err)
+		return nil, "generation_failed: " + err.Error(), fmt.Errorf("selector generation failed: %w", err)
 	}
 
-	// ÃÅ¸Ã‘â‚¬ÃÂ¾ÃÂ²ÃÂµÃ‘â‚¬Ã‘ÂÃÂµÃÂ¼, Ã‘â€¡Ã‘â€šÃÂ¾ ÃÂµÃ‘ÂÃ‘â€šÃ‘Å’ Ã‘â€¦ÃÂ¾Ã‘â€šÃ‘Â ÃÂ±Ã‘â€¹ name ÃÂ¸ price
-	if selectors["name"] == "" || selectors["price"] == "" {
+	// Проверяем, что есть хотя бы name и price
+	if !hasRequiredSelectors(generated) {
 		s.log.Warn("Missing required selectors", map[string]interface{}{
-			"selectors": selectors,
+			"selectors": generated.Selectors,
 		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "missing_required_selectors"); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
-			})
-		}
-		return fmt.Errorf("missing required selectors (name or price)")
+		return nil, "missing_required_selectors", fmt.Errorf("missing required selectors (name or price)")
 	}
+	selectors := generated.Selectors
 
-	// 5. Validate: ÃÅ¸Ã‘â‚¬ÃÂ¾ÃÂ²ÃÂµÃ‘â‚¬Ã‘ÂÃÂµÃÂ¼, Ã‘â‚¬ÃÂ°ÃÂ±ÃÂ¾Ã‘â€šÃÂ°Ã‘Å½Ã‘â€š ÃÂ»ÃÂ¸ Ã‘ÂÃÂµÃÂ»ÃÂµÃÂºÃ‘â€šÃÂ¾Ã‘â‚¬Ã‘â€¹
+	// Validate: проверяем, работают ли селекторы
 	if err := s.validateSelectors(productURL, selectors, siteType); err != nil {
 		s.log.Warn("Validation failed", map[string]interface{}{
 			"error":     err.Error(),
 			"selectors": selectors,
 		})
-		if markErr := s.storage.MarkAsFailed(candidate.ID, "validation_failed: "+err.Error()); markErr != nil {
-			s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
-				"candidate_id": candidate.ID,
-				"error":        markErr.Error(),
-			})
-		}
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, "validation_failed: " + err.Error(), fmt.Errorf("validation failed: %w", err)
 	}
 
-	// 6. Success: ÃÂ¡ÃÂ¾Ã‘â€¦Ã‘â‚¬ÃÂ°ÃÂ½Ã‘ÂÃÂµÃÂ¼!
-	s.log.Info("Ã¢Å“Â¨ SUCCESS! Config generated", map[string]interface{}{
-		"selectors": selectors,
-		"domain":    candidate.Domain,
-	})
-	return s.storage.MarkAsConfigured(candidate.ID, ShopConfig{Selectors: selectors})
+	return generated, "", nil
+}
+
+// markCandidateFailed сохраняет неудачную попытку конфигурации кандидата
+func (s *Service) markCandidateFailed(candidateID, reason string) {
+	if err := s.storage.MarkAsFailed(candidateID, reason); err != nil {
+		s.log.Error("Failed to mark candidate as failed", map[string]interface{}{
+			"candidate_id": candidateID,
+			"error":        err.Error(),
+		})
+	}
+}
+
+// markShopFailed сохраняет неудачную попытку повторной конфигурации магазина
+func (s *Service) markShopFailed(shopID, reason string) {
+	if err := s.storage.MarkReconfigurationFailed(shopID, reason); err != nil {
+		s.log.Error("Failed to mark shop reconfiguration as failed", map[string]interface{}{
+			"shop_id": shopID,
+			"error":   err.Error(),
+		})
+	}
 }
 
 // --- Helpers ---
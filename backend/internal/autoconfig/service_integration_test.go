@@ -3,7 +3,11 @@ package autoconfig
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
 )
 
 // MockStorage для тестирования
@@ -21,7 +25,7 @@ func NewMockStorage() *MockStorage {
 	}
 }
 
-func (m *MockStorage) GetClassifiedCandidates(limit int) ([]Candidate, error) {
+func (m *MockStorage) ClaimCandidates(limit int) ([]Candidate, error) {
 	if len(m.candidates) == 0 {
 		return []Candidate{}, nil
 	}
@@ -61,6 +65,10 @@ func (m *MockAIClient) GenerateSelectors(ctx context.Context, htmlSnippet string
 	return m.selectorsJSON, nil
 }
 
+func (m *MockAIClient) Model() string {
+	return "mock-model"
+}
+
 // TestServiceProviderValidation проверяет логику валидации для service_provider
 func TestServiceProviderValidation(t *testing.T) {
 	// Это unit-тест логики, не требует реальных HTTP запросов
@@ -158,9 +166,7 @@ func TestMockFlow(t *testing.T) {
 		"description": ""
 	}`, nil)
 	
-	// Note: MockAIClient не может быть использован напрямую с NewService,
-	// так как NewService ожидает *ai.Client. Этот тест проверяет только логику моков.
-	_ = mockAI
+	// Note: этот тест проверяет только логику моков; полный flow через NewService - TestValidationWithMockHTTPServer
 	
 	// Добавляем тестового кандидата
 	mockStorage.candidates = []Candidate{
@@ -172,7 +178,7 @@ func TestMockFlow(t *testing.T) {
 	}
 	
 	t.Run("Candidate retrieval", func(t *testing.T) {
-		candidates, err := mockStorage.GetClassifiedCandidates(1)
+		candidates, err := mockStorage.ClaimCandidates(1)
 		if err != nil {
 			t.Fatalf("Failed to get candidates: %v", err)
 		}
@@ -221,23 +227,43 @@ func TestValidationWithMockHTTPServer(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 	
-	// Note: MockAIClient не может быть использован напрямую с NewService
-	// Валидация требует реального AI клиента или нужно пропустить этот тест
-	// htmlContent и server не используются, так как тест пропускается
-	// service := NewService(mockStorage, mockAI, log) // Пропускаем из-за несовместимости типов
-	
+	serve := func(html string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(html))
+		}))
+	}
+
 	t.Run("Validation for service_provider with table", func(t *testing.T) {
-		// Note: Этот тест требует реального сервиса, который нельзя создать с моками
-		// Пропускаем валидацию, так как она требует реального AI клиента
-		t.Log("⚠️  Validation test skipped - requires real AI client")
-		t.Skip("Skipping validation test - requires real AI client, not mock")
+		server := serve(`<table><tbody>
+			<tr><td>Usluga 1</td><td>1000 RSD</td></tr>
+			<tr><td>Usluga 2</td><td>2000 RSD</td></tr>
+		</tbody></table>`)
+		defer server.Close()
+
+		mockAI := NewMockAIClient(`{"name": "table tbody tr td:first-child", "price": "table tbody tr td:last-child"}`, nil)
+		service := NewService(nil, NewAIGenerator(mockAI), logger.New("error"))
+
+		generated, reason, err := service.generateSelectors(context.Background(), server.URL, "service_provider")
+		if err != nil {
+			t.Fatalf("generateSelectors failed (%s): %v", reason, err)
+		}
+		if generated.Author != "mock-model" {
+			t.Errorf("expected mock model as author, got %q", generated.Author)
+		}
 	})
-	
+
 	t.Run("Validation for ecommerce (single element)", func(t *testing.T) {
-		// Note: Этот тест требует реального сервиса
-		// ecommerceHTML и ecommerceServer не используются, так как тест пропускается
-		t.Log("⚠️  Validation test skipped - requires real AI client")
-		t.Skip("Skipping validation test - requires real AI client, not mock")
+		server := serve(`<html><body><h1>Telefon</h1><span class="price">19.999 RSD</span></body></html>`)
+		defer server.Close()
+
+		mockAI := NewMockAIClient(`{"name": "h1", "price": ".missing-price"}`, nil)
+		service := NewService(nil, NewAIGenerator(mockAI), logger.New("error"))
+
+		_, reason, err := service.generateSelectors(context.Background(), server.URL, "ecommerce")
+		if err == nil || !contains(reason, "validation_failed") {
+			t.Fatalf("expected validation failure, got reason %q, err %v", reason, err)
+		}
 	})
 }
 
@@ -31,3 +31,25 @@ func TestAIPromptForTables(t *testing.T) {
 	t.Log("4. Emphasis on MULTIPLE elements extraction")
 	t.Log("5. Support for div-based lists")
 }
+
+func TestOnDomain(t *testing.T) {
+	tests := []struct {
+		url    string
+		domain string
+		want   bool
+	}{
+		{"https://shop.rs/p/phone-x", "shop.rs", true},
+		{"https://www.shop.rs/p/phone-x", "shop.rs", true},
+		{"https://SHOP.rs:443/p/phone-x", "www.shop.rs", true},
+		{"https://cdn.marketplace.com/p/phone-x", "shop.rs", false},
+		{"https://shop.rs.evil.com/p/phone-x", "shop.rs", false},
+		{"https://m.shop.rs/p/phone-x", "shop.rs", false},
+		{"ftp://shop.rs/p/phone-x", "shop.rs", false},
+		{"/p/phone-x", "shop.rs", false},
+	}
+	for _, tt := range tests {
+		if got := onDomain(tt.url, tt.domain); got != tt.want {
+			t.Errorf("onDomain(%q, %q) = %v, want %v", tt.url, tt.domain, got, tt.want)
+		}
+	}
+}
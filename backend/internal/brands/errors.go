@@ -0,0 +1,17 @@
+package brands
+
+import "errors"
+
+var (
+	// ErrBrandNotFound бренд не найден
+	ErrBrandNotFound = errors.New("brand not found")
+
+	// ErrAliasNotFound синоним бренда не найден
+	ErrAliasNotFound = errors.New("brand alias not found")
+
+	// ErrInvalidBrand невалидный бренд: пустое название, неизвестный производитель
+	ErrInvalidBrand = errors.New("invalid brand")
+
+	// ErrBrandConflict название или синоним уже принадлежит другому бренду
+	ErrBrandConflict = errors.New("brand name or alias already used by another brand")
+)
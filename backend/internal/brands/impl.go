@@ -0,0 +1,261 @@
+package brands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+)
+
+// registryTTL через столько перечитывается справочник брендов
+const registryTTL = 10 * time.Minute
+
+// DefaultMinSimilarity порог схожести для предложений синонимов
+const DefaultMinSimilarity = 0.8
+
+// Canonicalize возвращает каноническое название бренда. Если справочник недоступен
+// или бренда в нём нет, написание оформляется FormatUnknown
+func (s *Service) Canonicalize(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	registry, err := s.getRegistry(context.Background())
+	if err != nil {
+		s.logger.Warn("brands: registry unavailable, formatting brand as is", map[string]interface{}{
+			"brand": raw,
+			"error": err.Error(),
+		})
+		return FormatUnknown(raw)
+	}
+	return registry.Canonical(raw)
+}
+
+// List возвращает бренды справочника
+func (s *Service) List(ctx context.Context) ([]*Brand, error) {
+	return s.storage.ListBrands(ctx)
+}
+
+// Save проверяет и сохраняет бренд: название и синонимы не должны принадлежать другому бренду,
+// производитель должен существовать. Slug по умолчанию строится из названия,
+// переданные синонимы добавляются к уже сохранённым
+func (s *Service) Save(ctx context.Context, brand *Brand) (*Brand, error) {
+	brand.Name = strings.Join(strings.Fields(brand.Name), " ")
+	if Key(brand.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidBrand)
+	}
+	if brand.Slug = slugify(brand.Slug); brand.Slug == "" {
+		brand.Slug = slugify(brand.Name)
+	}
+
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if brand.ID != "" {
+		if _, ok := registry.Brand(brand.ID); !ok {
+			return nil, ErrBrandNotFound
+		}
+	}
+	for _, name := range append([]string{brand.Name}, brand.Aliases...) {
+		if owner, ok := registry.Lookup(name); ok && owner.ID != brand.ID {
+			return nil, fmt.Errorf("%w: %q belongs to %s", ErrBrandConflict, name, owner.Name)
+		}
+	}
+	if brand.ManufacturerID != nil {
+		manufacturer, ok := registry.Brand(*brand.ManufacturerID)
+		if !ok || manufacturer.ID == brand.ID {
+			return nil, fmt.Errorf("%w: unknown manufacturer %s", ErrInvalidBrand, *brand.ManufacturerID)
+		}
+		brand.Manufacturer = manufacturer.Name
+	}
+
+	if err := s.storage.SaveBrand(ctx, brand); err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{Key(brand.Name): true}
+	for _, alias := range brand.Aliases {
+		if key := Key(alias); key != "" {
+			if err := s.storage.SaveAlias(ctx, brand.ID, key, strings.TrimSpace(alias)); err != nil {
+				return nil, err
+			}
+			keys[key] = true
+		}
+	}
+	s.invalidate()
+
+	// Товары с другими написаниями бренда получают каноническое название
+	renamed, err := s.renameProducts(ctx, brand.Name, func(raw string) bool { return keys[Key(raw)] })
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("brands: brand saved", map[string]interface{}{
+		"brand_id": brand.ID,
+		"name":     brand.Name,
+		"aliases":  len(brand.Aliases),
+		"products": renamed,
+	})
+	return brand, nil
+}
+
+// Delete удаляет бренд
+func (s *Service) Delete(ctx context.Context, id string) error {
+	if err := s.storage.DeleteBrand(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// AddAlias привязывает написание к бренду и переименовывает товары с этим написанием.
+// Возвращает число переименованных товаров
+func (s *Service) AddAlias(ctx context.Context, brandID, alias string) (int64, error) {
+	alias = strings.TrimSpace(alias)
+	key := Key(alias)
+	if key == "" {
+		return 0, fmt.Errorf("%w: alias is required", ErrInvalidBrand)
+	}
+
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return 0, err
+	}
+	brand, ok := registry.Brand(brandID)
+	if !ok {
+		return 0, ErrBrandNotFound
+	}
+	if owner, ok := registry.Lookup(alias); ok && owner.ID != brand.ID {
+		return 0, fmt.Errorf("%w: %q belongs to %s", ErrBrandConflict, alias, owner.Name)
+	}
+
+	if err := s.storage.SaveAlias(ctx, brand.ID, key, alias); err != nil {
+		return 0, err
+	}
+	s.invalidate()
+
+	renamed, err := s.renameProducts(ctx, brand.Name, func(raw string) bool { return Key(raw) == key })
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("brands: alias added", map[string]interface{}{
+		"brand":    brand.Name,
+		"alias":    alias,
+		"products": renamed,
+	})
+	return renamed, nil
+}
+
+// RemoveAlias удаляет синоним бренда
+func (s *Service) RemoveAlias(ctx context.Context, alias string) error {
+	key := Key(alias)
+	if key == "" {
+		return ErrAliasNotFound
+	}
+	if err := s.storage.DeleteAlias(ctx, key); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Proposals предлагает синонимы для написаний брендов из товаров, которых нет в справочнике
+func (s *Service) Proposals(ctx context.Context, minSimilarity float64) ([]*AliasProposal, error) {
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		minSimilarity = DefaultMinSimilarity
+	}
+
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.storage.ListBrandUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load brand usage: %w", err)
+	}
+	return registry.Propose(usage, minSimilarity), nil
+}
+
+// CanonicalizeProducts приводит бренды товаров к каноническим названиям справочника.
+// Возвращает число переименованных товаров
+func (s *Service) CanonicalizeProducts(ctx context.Context) (int64, error) {
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return 0, err
+	}
+	usage, err := s.storage.ListBrandUsage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load brand usage: %w", err)
+	}
+
+	byCanonical := make(map[string][]string)
+	for _, u := range usage {
+		brand, ok := registry.Lookup(u.Brand)
+		if ok && u.Brand != brand.Name {
+			byCanonical[brand.Name] = append(byCanonical[brand.Name], u.Brand)
+		}
+	}
+
+	var total int64
+	for name, from := range byCanonical {
+		renamed, err := s.storage.RenameProductBrands(ctx, from, name)
+		if err != nil {
+			return total, fmt.Errorf("failed to rename brand %s: %w", name, err)
+		}
+		total += renamed
+	}
+	return total, nil
+}
+
+// renameProducts переименовывает в canonical написания из товаров, подходящие под match
+func (s *Service) renameProducts(ctx context.Context, canonical string, match func(raw string) bool) (int64, error) {
+	usage, err := s.storage.ListBrandUsage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load brand usage: %w", err)
+	}
+
+	from := make([]string, 0)
+	for _, u := range usage {
+		if u.Brand != canonical && match(u.Brand) {
+			from = append(from, u.Brand)
+		}
+	}
+	if len(from) == 0 {
+		return 0, nil
+	}
+	return s.storage.RenameProductBrands(ctx, from, canonical)
+}
+
+// getRegistry возвращает словарь брендов, перечитывая справочник раз в registryTTL
+func (s *Service) getRegistry(ctx context.Context) (*Registry, error) {
+	return s.registry.Get(func() (*Registry, error) {
+		return s.loadRegistry(ctx)
+	})
+}
+
+// loadRegistry читает справочник брендов
+func (s *Service) loadRegistry(ctx context.Context) (*Registry, error) {
+	list, err := s.storage.ListBrands(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load brands: %w", err)
+	}
+
+	s.logger.Debug("Brand registry loaded", map[string]interface{}{
+		"brands": len(list),
+	})
+	return NewRegistry(list), nil
+}
+
+// invalidate сбрасывает словарь после изменения справочника
+func (s *Service) invalidate() {
+	s.registry.Invalidate()
+}
+
+// slugify slug из названия: "New Balance" -> "new-balance"
+func slugify(value string) string {
+	return strings.ReplaceAll(attributes.NormalizeLabel(value), " ", "-")
+}
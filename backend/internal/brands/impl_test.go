@@ -0,0 +1,134 @@
+package brands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type mockStorage struct {
+	brands  []*Brand
+	usage   []*Usage
+	aliases map[string]string
+	renamed map[string][]string
+	loads   int
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		brands:  testBrands(),
+		aliases: make(map[string]string),
+		renamed: make(map[string][]string),
+	}
+}
+
+func (m *mockStorage) ListBrands(ctx context.Context) ([]*Brand, error) {
+	m.loads++
+	return m.brands, nil
+}
+
+func (m *mockStorage) SaveBrand(ctx context.Context, brand *Brand) error {
+	if brand.ID == "" {
+		brand.ID = "brand-new"
+		m.brands = append(m.brands, brand)
+	}
+	return nil
+}
+
+func (m *mockStorage) DeleteBrand(ctx context.Context, id string) error {
+	return ErrBrandNotFound
+}
+
+func (m *mockStorage) SaveAlias(ctx context.Context, brandID, aliasKey, alias string) error {
+	m.aliases[aliasKey] = brandID
+	for _, brand := range m.brands {
+		if brand.ID == brandID {
+			brand.Aliases = append(brand.Aliases, alias)
+		}
+	}
+	return nil
+}
+
+func (m *mockStorage) DeleteAlias(ctx context.Context, aliasKey string) error {
+	return ErrAliasNotFound
+}
+
+func (m *mockStorage) ListBrandUsage(ctx context.Context) ([]*Usage, error) {
+	return m.usage, nil
+}
+
+func (m *mockStorage) RenameProductBrands(ctx context.Context, from []string, to string) (int64, error) {
+	m.renamed[to] = append(m.renamed[to], from...)
+	return int64(len(from)), nil
+}
+
+func TestService_Canonicalize(t *testing.T) {
+	storage := newMockStorage()
+	service := New(storage, nil)
+
+	if got := service.Canonicalize("hewlett-packard"); got != "HP" {
+		t.Errorf("Canonicalize(hewlett-packard) = %q, want HP", got)
+	}
+	if got := service.Canonicalize("gorenje"); got != "Gorenje" {
+		t.Errorf("Canonicalize(gorenje) = %q, want Gorenje", got)
+	}
+	if storage.loads != 1 {
+		t.Errorf("registry loaded %d times, want 1 (cached)", storage.loads)
+	}
+}
+
+func TestService_AddAlias(t *testing.T) {
+	storage := newMockStorage()
+	storage.usage = []*Usage{{Brand: "Samsumg", Products: 3}, {Brand: "Samsung", Products: 10}}
+	service := New(storage, nil)
+	ctx := context.Background()
+
+	if _, err := service.AddAlias(ctx, "brand-samsung", "HP"); !errors.Is(err, ErrBrandConflict) {
+		t.Errorf("alias of another brand: error = %v, want ErrBrandConflict", err)
+	}
+	if _, err := service.AddAlias(ctx, "brand-missing", "Acme"); !errors.Is(err, ErrBrandNotFound) {
+		t.Errorf("unknown brand: error = %v, want ErrBrandNotFound", err)
+	}
+
+	renamed, err := service.AddAlias(ctx, "brand-samsung", " Samsumg ")
+	if err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if renamed != 1 || len(storage.renamed["Samsung"]) != 1 || storage.renamed["Samsung"][0] != "Samsumg" {
+		t.Errorf("renamed %d, storage %v", renamed, storage.renamed)
+	}
+
+	// Новый синоним применяется сразу: словарь перечитывается
+	if got := service.Canonicalize("SAMSUMG"); got != "Samsung" {
+		t.Errorf("Canonicalize(SAMSUMG) = %q, want Samsung", got)
+	}
+}
+
+func TestService_Save(t *testing.T) {
+	storage := newMockStorage()
+	storage.usage = []*Usage{{Brand: "GORENJE", Products: 5}, {Brand: "Gorenje d.d.", Products: 2}}
+	service := New(storage, nil)
+	ctx := context.Background()
+
+	if _, err := service.Save(ctx, &Brand{Name: " - "}); !errors.Is(err, ErrInvalidBrand) {
+		t.Errorf("empty name: error = %v, want ErrInvalidBrand", err)
+	}
+	if _, err := service.Save(ctx, &Brand{Name: "Hewlett Packard"}); !errors.Is(err, ErrBrandConflict) {
+		t.Errorf("existing alias as name: error = %v, want ErrBrandConflict", err)
+	}
+	missing := "brand-missing"
+	if _, err := service.Save(ctx, &Brand{Name: "Gorenje", ManufacturerID: &missing}); !errors.Is(err, ErrInvalidBrand) {
+		t.Errorf("unknown manufacturer: error = %v, want ErrInvalidBrand", err)
+	}
+
+	brand, err := service.Save(ctx, &Brand{Name: "  Gorenje ", Aliases: []string{"Gorenje d.d."}})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if brand.ID != "brand-new" || brand.Name != "Gorenje" || brand.Slug != "gorenje" {
+		t.Errorf("unexpected saved brand %+v", brand)
+	}
+	if got := storage.renamed["Gorenje"]; len(got) != 2 {
+		t.Errorf("renamed spellings %v, want GORENJE and Gorenje d.d.", got)
+	}
+}
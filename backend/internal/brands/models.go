@@ -0,0 +1,31 @@
+package brands
+
+import "time"
+
+// Brand бренд справочника: каноническое написание, синонимы у магазинов и производитель-владелец
+type Brand struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"` // каноническое написание: "HP", "LG", "New Balance"
+	Slug           string    `json:"slug"`
+	LogoURL        *string   `json:"logo_url,omitempty"`
+	ManufacturerID *string   `json:"manufacturer_id,omitempty"` // владелец бренда: Redmi -> Xiaomi
+	Manufacturer   string    `json:"manufacturer,omitempty"`
+	Aliases        []string  `json:"aliases"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Usage написание бренда в товарах и число товаров с ним
+type Usage struct {
+	Brand    string
+	Products int
+}
+
+// AliasProposal предложение считать написание бренда из товаров синонимом бренда справочника
+type AliasProposal struct {
+	Alias      string  `json:"alias"`
+	BrandID    string  `json:"brand_id"`
+	BrandName  string  `json:"brand_name"`
+	Similarity float64 `json:"similarity"`
+	Products   int     `json:"products"`
+}
@@ -0,0 +1,52 @@
+package brands
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/cache"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс хранилища справочника брендов
+type Storage interface {
+	// ListBrands возвращает все бренды с синонимами и названием производителя
+	ListBrands(ctx context.Context) ([]*Brand, error)
+
+	// SaveBrand создаёт бренд (пустой ID) или обновляет существующий
+	SaveBrand(ctx context.Context, brand *Brand) error
+
+	// DeleteBrand удаляет бренд вместе с синонимами
+	DeleteBrand(ctx context.Context, id string) error
+
+	// SaveAlias привязывает написание к бренду; aliasKey - ключ написания (Key)
+	SaveAlias(ctx context.Context, brandID, aliasKey, alias string) error
+
+	// DeleteAlias удаляет синоним по ключу написания
+	DeleteAlias(ctx context.Context, aliasKey string) error
+
+	// ListBrandUsage возвращает написания брендов в товарах с числом товаров
+	ListBrandUsage(ctx context.Context) ([]*Usage, error)
+
+	// RenameProductBrands заменяет написания from на каноническое название to в товарах
+	RenameProductBrands(ctx context.Context, from []string, to string) (int64, error)
+}
+
+// Service сервис справочника брендов
+type Service struct {
+	storage Storage
+	logger  *logger.Logger
+
+	registry *cache.TTL[*Registry]
+}
+
+// New создаёт сервис справочника брендов
+func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage:  storage,
+		logger:   log,
+		registry: cache.NewTTL[*Registry](registryTTL),
+	}
+}
@@ -0,0 +1,190 @@
+package brands
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+)
+
+// Registry словарь написаний брендов: ключ написания -> бренд
+type Registry struct {
+	brands []*Brand
+	byKey  map[string]*Brand
+	byID   map[string]*Brand
+}
+
+// NewRegistry строит словарь по названиям, slug и синонимам брендов.
+// Название бренда важнее чужого синонима с тем же ключом
+func NewRegistry(list []*Brand) *Registry {
+	r := &Registry{
+		brands: list,
+		byKey:  make(map[string]*Brand),
+		byID:   make(map[string]*Brand, len(list)),
+	}
+	for _, brand := range list {
+		r.byID[brand.ID] = brand
+		if key := Key(brand.Name); key != "" {
+			r.byKey[key] = brand
+		}
+	}
+	for _, brand := range list {
+		names := append([]string{brand.Slug}, brand.Aliases...)
+		for _, name := range names {
+			key := Key(name)
+			if key == "" {
+				continue
+			}
+			if _, exists := r.byKey[key]; !exists {
+				r.byKey[key] = brand
+			}
+		}
+	}
+	return r
+}
+
+// Lookup находит бренд по любому написанию
+func (r *Registry) Lookup(raw string) (*Brand, bool) {
+	brand, ok := r.byKey[Key(raw)]
+	return brand, ok
+}
+
+// Brand возвращает бренд по ID
+func (r *Registry) Brand(id string) (*Brand, bool) {
+	brand, ok := r.byID[id]
+	return brand, ok
+}
+
+// Canonical каноническое название бренда; неизвестные бренды оформляются FormatUnknown
+func (r *Registry) Canonical(raw string) string {
+	if brand, ok := r.Lookup(raw); ok {
+		return brand.Name
+	}
+	return FormatUnknown(raw)
+}
+
+// Key ключ написания бренда: нижний регистр, латиница без диакритики, без пробелов и знаков.
+// "Hewlett-Packard" -> "hewlettpackard", "LG Electronics" -> "lgelectronics"
+func Key(raw string) string {
+	return strings.ReplaceAll(attributes.NormalizeLabel(raw), " ", "")
+}
+
+// FormatUnknown оформляет бренд, которого нет в справочнике: слова в нижнем регистре
+// и длинные слова в верхнем получают заглавную букву, аббревиатуры ("HP", "JBL")
+// и смешанный регистр ("iRobot") сохраняются
+func FormatUnknown(raw string) string {
+	words := strings.Fields(raw)
+	for i, word := range words {
+		lower, upper := strings.ToLower(word), strings.ToUpper(word)
+		switch {
+		case word == lower && word != upper:
+			words[i] = capitalize(lower)
+		case word == upper && word != lower && utf8.RuneCountInString(word) > 4:
+			words[i] = capitalize(lower)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// capitalize первая буква заглавная
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	if first == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(first)) + word[size:]
+}
+
+// Propose предлагает синонимы для написаний брендов из товаров, которых нет в справочнике:
+// ближайший бренд по схожести ключей не ниже minSimilarity. Частые написания первыми
+func (r *Registry) Propose(usage []*Usage, minSimilarity float64) []*AliasProposal {
+	proposals := make([]*AliasProposal, 0)
+	for _, u := range usage {
+		key := Key(u.Brand)
+		if key == "" {
+			continue
+		}
+		if _, known := r.byKey[key]; known {
+			continue
+		}
+
+		var (
+			best           *Brand
+			bestSimilarity float64
+		)
+		for candidateKey, brand := range r.byKey {
+			similarity := keySimilarity(key, candidateKey)
+			if similarity > bestSimilarity || (similarity == bestSimilarity && best != nil && brand.Name < best.Name) {
+				best, bestSimilarity = brand, similarity
+			}
+		}
+		if best == nil || bestSimilarity < minSimilarity {
+			continue
+		}
+
+		proposals = append(proposals, &AliasProposal{
+			Alias:      strings.TrimSpace(u.Brand),
+			BrandID:    best.ID,
+			BrandName:  best.Name,
+			Similarity: float64(int(bestSimilarity*100+0.5)) / 100,
+			Products:   u.Products,
+		})
+	}
+
+	sort.SliceStable(proposals, func(i, j int) bool {
+		if proposals[i].Products != proposals[j].Products {
+			return proposals[i].Products > proposals[j].Products
+		}
+		return proposals[i].Alias < proposals[j].Alias
+	})
+	return proposals
+}
+
+// minPrefixLength короче этого ключи не сравниваются по префиксу ("lg" не совпадает с "lgbt")
+const minPrefixLength = 3
+
+// keySimilarity схожесть ключей 0..1: расстояние Левенштейна, а для ключей,
+// начинающихся с другого ключа ("samsungelectronics" и "samsung"), не меньше 0.85
+func keySimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	similarity := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	shorter, longer := a, b
+	if len(shorter) > len(longer) {
+		shorter, longer = longer, shorter
+	}
+	if len(shorter) >= minPrefixLength && strings.HasPrefix(longer, shorter) && similarity < 0.85 {
+		similarity = 0.85
+	}
+	return similarity
+}
+
+// levenshtein расстояние редактирования между строками
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,99 @@
+package brands
+
+import "testing"
+
+func testBrands() []*Brand {
+	xiaomi := "brand-xiaomi"
+	return []*Brand{
+		{ID: "brand-hp", Name: "HP", Slug: "hp", Aliases: []string{"Hewlett-Packard"}},
+		{ID: "brand-samsung", Name: "Samsung", Slug: "samsung", Aliases: []string{"Samsung Electronics"}},
+		{ID: "brand-nb", Name: "New Balance", Slug: "new-balance", Aliases: []string{"NB"}},
+		{ID: "brand-xiaomi", Name: "Xiaomi", Slug: "xiaomi", Aliases: []string{"Mi"}},
+		{ID: "brand-redmi", Name: "Redmi", Slug: "redmi", ManufacturerID: &xiaomi},
+		{ID: "brand-lg", Name: "LG", Slug: "lg"},
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"Hewlett-Packard":  "hewlettpackard",
+		" LG Electronics ": "lgelectronics",
+		"New_Balance":      "newbalance",
+		"Škoda":            "skoda",
+		"   ":              "",
+	}
+	for in, want := range tests {
+		if got := Key(in); got != want {
+			t.Errorf("Key(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatUnknown(t *testing.T) {
+	tests := map[string]string{
+		"acme":         "Acme",
+		"ACME":         "ACME",
+		"GORENJE":      "Gorenje",
+		"iRobot":       "iRobot",
+		"JBL":          "JBL",
+		"black decker": "Black Decker",
+		"  tefal  ":    "Tefal",
+	}
+	for in, want := range tests {
+		if got := FormatUnknown(in); got != want {
+			t.Errorf("FormatUnknown(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRegistry_Canonical(t *testing.T) {
+	registry := NewRegistry(testBrands())
+
+	tests := map[string]string{
+		"hp":                  "HP",
+		"Hewlett Packard":     "HP",
+		"SAMSUNG ELECTRONICS": "Samsung",
+		"new-balance":         "New Balance",
+		"nb":                  "New Balance",
+		"REDMI":               "Redmi",
+		"gorenje":             "Gorenje",
+	}
+	for in, want := range tests {
+		if got := registry.Canonical(in); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if _, ok := registry.Lookup("lgbt"); ok {
+		t.Errorf("Lookup(lgbt) must not match LG")
+	}
+}
+
+func TestRegistry_Propose(t *testing.T) {
+	registry := NewRegistry(testBrands())
+
+	usage := []*Usage{
+		{Brand: "HP", Products: 40},
+		{Brand: "Samsumg", Products: 3},
+		{Brand: "Xiaomi Global", Products: 7},
+		{Brand: "Gorenje", Products: 12},
+		{Brand: "LGE", Products: 1},
+	}
+
+	proposals := registry.Propose(usage, DefaultMinSimilarity)
+	if len(proposals) != 2 {
+		t.Fatalf("expected 2 proposals, got %+v", proposals)
+	}
+
+	// Частые написания первыми
+	if p := proposals[0]; p.Alias != "Xiaomi Global" || p.BrandID != "brand-xiaomi" || p.Similarity != 0.85 {
+		t.Errorf("unexpected first proposal %+v", p)
+	}
+	if p := proposals[1]; p.Alias != "Samsumg" || p.BrandName != "Samsung" || p.Products != 3 {
+		t.Errorf("unexpected second proposal %+v", p)
+	}
+
+	if got := registry.Propose(usage, 0.95); len(got) != 0 {
+		t.Errorf("expected no proposals above 0.95, got %+v", got)
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTL значение, которое загружается при первом обращении и перечитывается раз в ttl.
+// Безопасно для одновременного использования из нескольких горутин
+type TTL[T any] struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	value    T
+	loaded   bool
+	loadedAt time.Time
+}
+
+// NewTTL создаёт кэш, значение которого устаревает через ttl
+func NewTTL[T any](ttl time.Duration) *TTL[T] {
+	return &TTL[T]{ttl: ttl}
+}
+
+// Get возвращает закэшированное значение или загружает его через load.
+// Ошибка загрузки не кэшируется: следующий вызов повторит загрузку
+func (c *TTL[T]) Get(load func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded && time.Since(c.loadedAt) < c.ttl {
+		return c.value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.value = value
+	c.loaded = true
+	c.loadedAt = time.Now()
+	return value, nil
+}
+
+// Invalidate сбрасывает значение; следующий Get загрузит его заново
+func (c *TTL[T]) Invalidate() {
+	c.mu.Lock()
+	var zero T
+	c.value = zero
+	c.loaded = false
+	c.mu.Unlock()
+}
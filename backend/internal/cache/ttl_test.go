@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTL_Get(t *testing.T) {
+	c := NewTTL[int](time.Minute)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if got, err := c.Get(load); err != nil || got != 1 {
+			t.Fatalf("Get() = %d, %v, want 1, nil", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, want 1 (cached)", loads)
+	}
+
+	c.Invalidate()
+	if got, _ := c.Get(load); got != 2 {
+		t.Errorf("Get() after Invalidate = %d, want 2", got)
+	}
+}
+
+func TestTTL_Expired(t *testing.T) {
+	c := NewTTL[int](0)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	c.Get(load)
+	c.Get(load)
+	if loads != 2 {
+		t.Errorf("loaded %d times, want 2 (expired)", loads)
+	}
+}
+
+func TestTTL_ErrorNotCached(t *testing.T) {
+	c := NewTTL[int](time.Minute)
+	failure := errors.New("storage unavailable")
+
+	if _, err := c.Get(func() (int, error) { return 0, failure }); !errors.Is(err, failure) {
+		t.Fatalf("Get() error = %v, want %v", err, failure)
+	}
+	if got, err := c.Get(func() (int, error) { return 7, nil }); err != nil || got != 7 {
+		t.Errorf("Get() after error = %d, %v, want 7, nil", got, err)
+	}
+}
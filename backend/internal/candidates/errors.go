@@ -0,0 +1,17 @@
+package candidates
+
+import "errors"
+
+var (
+	// ErrCandidateNotFound кандидат не найден
+	ErrCandidateNotFound = errors.New("candidate not found")
+
+	// ErrInvalidTransition переход из текущего статуса запрещён
+	ErrInvalidTransition = errors.New("invalid candidate status transition")
+
+	// ErrNoConfig у кандидата нет проверенных селекторов
+	ErrNoConfig = errors.New("candidate has no selector config")
+
+	// ErrInvalidRequest невалидные параметры запроса
+	ErrInvalidRequest = errors.New("invalid candidate request")
+)
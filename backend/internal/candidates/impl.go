@@ -0,0 +1,164 @@
+package candidates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// List возвращает страницу кандидатов, при необходимости с фильтром по статусу
+func (s *Service) List(ctx context.Context, filter ListFilter) (*CandidateList, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidRequest, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := s.storage.ListCandidates(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list candidates: %w", err)
+	}
+	if items == nil {
+		items = []*Candidate{}
+	}
+
+	return &CandidateList{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// Get возвращает кандидата с историей переходов
+func (s *Service) Get(ctx context.Context, id string) (*Details, error) {
+	candidate, err := s.candidate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.storage.ListTransitions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list candidate transitions: %w", err)
+	}
+	if history == nil {
+		history = []*Transition{}
+	}
+
+	return &Details{Candidate: candidate, Transitions: history}, nil
+}
+
+// Approve создаёт из сконфигурированного кандидата неактивный магазин.
+// Парсинг начнётся после GoLive
+func (s *Service) Approve(ctx context.Context, id, actor, note string) (*Candidate, error) {
+	candidate, err := s.prepare(ctx, id, actor, StatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Config == nil || len(candidate.Config.Selectors) == 0 {
+		return nil, ErrNoConfig
+	}
+
+	shopID, err := s.storage.Approve(ctx, id, strings.TrimSpace(actor), strings.TrimSpace(note))
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("candidates: candidate approved", map[string]interface{}{
+		"candidate_id": id,
+		"domain":       candidate.Domain,
+		"shop_id":      shopID,
+		"actor":        actor,
+	})
+	return s.candidate(ctx, id)
+}
+
+// Reject отклоняет кандидата; причина обязательна
+func (s *Service) Reject(ctx context.Context, id, actor, reason string) (*Candidate, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidRequest)
+	}
+	return s.transition(ctx, id, actor, reason, StatusRejected)
+}
+
+// Retry возвращает неудачного или отклонённого кандидата в classified со сброшенным счётчиком попыток
+func (s *Service) Retry(ctx context.Context, id, actor, note string) (*Candidate, error) {
+	return s.transition(ctx, id, actor, note, StatusClassified)
+}
+
+// GoLive активирует магазин одобренного кандидата
+func (s *Service) GoLive(ctx context.Context, id, actor, note string) (*Candidate, error) {
+	candidate, err := s.prepare(ctx, id, actor, StatusLive)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.GoLive(ctx, id, strings.TrimSpace(actor), strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("candidates: shop is live", map[string]interface{}{
+		"candidate_id": id,
+		"domain":       candidate.Domain,
+		"shop_id":      candidate.ShopID,
+		"actor":        actor,
+	})
+	return s.candidate(ctx, id)
+}
+
+// transition выполняет переход без побочных действий
+func (s *Service) transition(ctx context.Context, id, actor, reason string, to Status) (*Candidate, error) {
+	candidate, err := s.prepare(ctx, id, actor, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Transition(ctx, id, to, strings.TrimSpace(reason), strings.TrimSpace(actor)); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("candidates: status changed", map[string]interface{}{
+		"candidate_id": id,
+		"domain":       candidate.Domain,
+		"from":         candidate.Status,
+		"to":           to,
+		"actor":        actor,
+	})
+	return s.candidate(ctx, id)
+}
+
+// prepare проверяет актора и допустимость перехода из текущего статуса
+func (s *Service) prepare(ctx context.Context, id, actor string, to Status) (*Candidate, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidRequest)
+	}
+	candidate, err := s.candidate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckTransition(candidate.Status, to); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// candidate загружает кандидата по ID
+func (s *Service) candidate(ctx context.Context, id string) (*Candidate, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, ErrCandidateNotFound
+	}
+	return s.storage.GetCandidate(ctx, id)
+}
@@ -0,0 +1,190 @@
+package candidates
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	candidates  map[string]*Candidate
+	transitions []*Transition
+	approved    []string
+}
+
+func newMockStorage(candidates ...*Candidate) *mockStorage {
+	m := &mockStorage{candidates: make(map[string]*Candidate)}
+	for _, c := range candidates {
+		m.candidates[c.ID] = c
+	}
+	return m
+}
+
+func (m *mockStorage) ListCandidates(ctx context.Context, filter ListFilter) ([]*Candidate, int, error) {
+	var items []*Candidate
+	for _, c := range m.candidates {
+		if filter.Status == "" || c.Status == filter.Status {
+			items = append(items, c)
+		}
+	}
+	return items, len(items), nil
+}
+
+func (m *mockStorage) GetCandidate(ctx context.Context, id string) (*Candidate, error) {
+	c, ok := m.candidates[id]
+	if !ok {
+		return nil, ErrCandidateNotFound
+	}
+	return c, nil
+}
+
+func (m *mockStorage) ListTransitions(ctx context.Context, id string) ([]*Transition, error) {
+	return m.transitions, nil
+}
+
+func (m *mockStorage) Transition(ctx context.Context, id string, to Status, reason, actor string) error {
+	c := m.candidates[id]
+	if err := CheckTransition(c.Status, to); err != nil {
+		return err
+	}
+	from := c.Status
+	m.transitions = append(m.transitions, &Transition{From: &from, To: to, Reason: reason, Actor: actor})
+	c.Status = to
+	if to == StatusClassified {
+		c.Attempts = 0
+		c.LastError = ""
+	}
+	return nil
+}
+
+func (m *mockStorage) Approve(ctx context.Context, id, actor, note string) (string, error) {
+	if err := m.Transition(ctx, id, StatusApproved, note, actor); err != nil {
+		return "", err
+	}
+	m.candidates[id].ShopID = "shop-" + id
+	m.approved = append(m.approved, id)
+	return "shop-" + id, nil
+}
+
+func (m *mockStorage) GoLive(ctx context.Context, id, actor, note string) error {
+	return m.Transition(ctx, id, StatusLive, note, actor)
+}
+
+func configured(id string) *Candidate {
+	return &Candidate{
+		ID:     id,
+		Domain: id + ".rs",
+		Status: StatusConfigured,
+		Config: &Config{Selectors: map[string]string{"name": "h1", "price": ".price"}},
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	allowed := [][2]Status{
+		{StatusDiscovered, StatusClassified},
+		{StatusClassified, StatusConfiguring},
+		{StatusConfiguring, StatusFailed},
+		{StatusFailed, StatusConfiguring},
+		{StatusConfigured, StatusApproved},
+		{StatusApproved, StatusLive},
+		{StatusRejected, StatusClassified},
+	}
+	for _, tr := range allowed {
+		if !CanTransition(tr[0], tr[1]) {
+			t.Errorf("expected %s → %s to be allowed", tr[0], tr[1])
+		}
+	}
+
+	forbidden := [][2]Status{
+		{StatusDiscovered, StatusConfigured},
+		{StatusClassified, StatusApproved},
+		{StatusFailed, StatusApproved},
+		{StatusLive, StatusRejected},
+		{StatusApproved, StatusRejected},
+		{Status("new"), StatusClassified},
+	}
+	for _, tr := range forbidden {
+		if CanTransition(tr[0], tr[1]) {
+			t.Errorf("expected %s → %s to be forbidden", tr[0], tr[1])
+		}
+	}
+}
+
+func TestService_ApproveAndGoLive(t *testing.T) {
+	storage := newMockStorage(configured("gigatron"))
+	service := New(storage, logger.New("error"))
+	ctx := context.Background()
+
+	candidate, err := service.Approve(ctx, "gigatron", "admin", "looks good")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if candidate.Status != StatusApproved || candidate.ShopID != "shop-gigatron" {
+		t.Fatalf("unexpected candidate after approve: %+v", candidate)
+	}
+
+	candidate, err = service.GoLive(ctx, "gigatron", "admin", "")
+	if err != nil {
+		t.Fatalf("GoLive failed: %v", err)
+	}
+	if candidate.Status != StatusLive {
+		t.Errorf("expected live, got %s", candidate.Status)
+	}
+
+	if _, err := service.Reject(ctx, "gigatron", "admin", "duplicate"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition for live candidate, got %v", err)
+	}
+}
+
+func TestService_ApproveRequiresConfig(t *testing.T) {
+	candidate := configured("emmi")
+	candidate.Config = nil
+	service := New(newMockStorage(candidate), logger.New("error"))
+
+	if _, err := service.Approve(context.Background(), "emmi", "admin", ""); !errors.Is(err, ErrNoConfig) {
+		t.Fatalf("expected ErrNoConfig, got %v", err)
+	}
+
+	classified := &Candidate{ID: "winwin", Status: StatusClassified}
+	service = New(newMockStorage(classified), logger.New("error"))
+	if _, err := service.Approve(context.Background(), "winwin", "admin", ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition for unconfigured candidate, got %v", err)
+	}
+}
+
+func TestService_RejectAndRetry(t *testing.T) {
+	failed := &Candidate{ID: "ananas", Status: StatusFailed, Attempts: MaxAttempts, LastError: "scout_failed"}
+	storage := newMockStorage(failed)
+	service := New(storage, logger.New("error"))
+	ctx := context.Background()
+
+	if _, err := service.Reject(ctx, "ananas", "admin", " "); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest without reason, got %v", err)
+	}
+
+	candidate, err := service.Retry(ctx, "ananas", "admin", "site fixed")
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if candidate.Status != StatusClassified || candidate.Attempts != 0 || candidate.LastError != "" {
+		t.Errorf("expected reset classified candidate, got %+v", candidate)
+	}
+
+	details, err := service.Get(ctx, "ananas")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(details.Transitions) != 1 || details.Transitions[0].Actor != "admin" || *details.Transitions[0].From != StatusFailed {
+		t.Errorf("unexpected history %+v", details.Transitions)
+	}
+
+	if _, err := service.List(ctx, ListFilter{Status: "pending_review"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for unknown status, got %v", err)
+	}
+	list, err := service.List(ctx, ListFilter{Status: StatusClassified, Limit: 1000})
+	if err != nil || list.Total != 1 || list.Limit != maxListLimit {
+		t.Errorf("unexpected list %+v (%v)", list, err)
+	}
+}
@@ -0,0 +1,93 @@
+package candidates
+
+import "time"
+
+// Status статус кандидата в конвейере discovered → classified → configuring → configured | failed → approved | rejected → live
+type Status string
+
+const (
+	// StatusDiscovered домен найден discovery и ждёт классификации
+	StatusDiscovered Status = "discovered"
+	// StatusClassified классификатор признал сайт магазином или провайдером услуг
+	StatusClassified Status = "classified"
+	// StatusConfiguring авто-конфигурация подбирает селекторы
+	StatusConfiguring Status = "configuring"
+	// StatusConfigured селекторы найдены и проверены, кандидат ждёт решения администратора
+	StatusConfigured Status = "configured"
+	// StatusFailed авто-конфигурация не удалась; до MaxAttempts попыток кандидат берётся повторно
+	StatusFailed Status = "failed"
+	// StatusApproved администратор одобрил кандидата, магазин создан неактивным
+	StatusApproved Status = "approved"
+	// StatusRejected кандидат отклонён классификатором или администратором
+	StatusRejected Status = "rejected"
+	// StatusLive магазин активен и парсится
+	StatusLive Status = "live"
+)
+
+// MaxAttempts после стольких неудачных попыток авто-конфигурация больше не берёт кандидата
+const MaxAttempts = 3
+
+// MetadataNeedsReview ключ metadata: классификатор не уверен, что сайт - магазин,
+// администратору стоит проверить его перед одобрением
+const MetadataNeedsReview = "needs_review"
+
+// Акторы автоматических переходов; администраторы указывают своё имя
+const (
+	ActorDiscovery  = "discovery"
+	ActorClassifier = "classifier"
+	ActorAutoconfig = "autoconfig"
+)
+
+// Candidate кандидат в магазины (potential_shops)
+type Candidate struct {
+	ID              string                 `json:"id"`
+	Domain          string                 `json:"domain"`
+	Source          string                 `json:"source,omitempty"`
+	Status          Status                 `json:"status"`
+	ConfidenceScore float64                `json:"confidence_score"`
+	SiteType        string                 `json:"site_type,omitempty"`
+	Attempts        int                    `json:"attempts"`
+	LastError       string                 `json:"last_error,omitempty"`
+	Config          *Config                `json:"config,omitempty"`
+	ShopID          string                 `json:"shop_id,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	DiscoveredAt    time.Time              `json:"discovered_at"`
+	StateChangedAt  time.Time              `json:"state_changed_at"`
+}
+
+// Config селекторы, найденные авто-конфигурацией
+type Config struct {
+	Selectors map[string]string `json:"selectors"`
+	Source    string            `json:"source,omitempty"` // источник версии селекторов: ai или autoconfig
+	Author    string            `json:"author,omitempty"` // модель AI или генератор
+}
+
+// Transition запись истории переходов
+type Transition struct {
+	From      *Status   `json:"from,omitempty"` // nil для только что найденного кандидата
+	To        Status    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Details кандидат с историей переходов
+type Details struct {
+	*Candidate
+	Transitions []*Transition `json:"transitions"`
+}
+
+// ListFilter фильтр списка кандидатов
+type ListFilter struct {
+	Status Status // пусто - все статусы
+	Limit  int
+	Offset int
+}
+
+// CandidateList страница списка кандидатов
+type CandidateList struct {
+	Items  []*Candidate `json:"items"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
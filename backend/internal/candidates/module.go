@@ -0,0 +1,47 @@
+package candidates
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс хранилища кандидатов.
+// Переходы проверяются хранилищем повторно под блокировкой строки кандидата
+type Storage interface {
+	// ListCandidates возвращает страницу кандидатов и их общее число
+	ListCandidates(ctx context.Context, filter ListFilter) ([]*Candidate, int, error)
+
+	// GetCandidate возвращает кандидата по ID
+	GetCandidate(ctx context.Context, id string) (*Candidate, error)
+
+	// ListTransitions возвращает историю переходов кандидата, старые первыми
+	ListTransitions(ctx context.Context, id string) ([]*Transition, error)
+
+	// Transition переводит кандидата в статус to и записывает переход в историю.
+	// Возврат в classified сбрасывает счётчик попыток и последнюю ошибку
+	Transition(ctx context.Context, id string, to Status, reason, actor string) error
+
+	// Approve создаёт неактивный магазин с селекторами кандидата и переводит кандидата в approved
+	Approve(ctx context.Context, id, actor, note string) (string, error)
+
+	// GoLive активирует магазин кандидата и переводит кандидата в live
+	GoLive(ctx context.Context, id, actor, note string) error
+}
+
+// Service конвейер кандидатов в магазины
+type Service struct {
+	storage Storage
+	logger  *logger.Logger
+}
+
+// New создаёт сервис кандидатов
+func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage: storage,
+		logger:  log,
+	}
+}
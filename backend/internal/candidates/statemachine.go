@@ -0,0 +1,41 @@
+package candidates
+
+import "fmt"
+
+// transitions разрешённые переходы между статусами
+var transitions = map[Status][]Status{
+	StatusDiscovered:  {StatusClassified, StatusRejected},
+	StatusClassified:  {StatusConfiguring, StatusRejected},
+	StatusConfiguring: {StatusConfigured, StatusFailed},
+	StatusConfigured:  {StatusApproved, StatusRejected},
+	// configuring - повторная авто-конфигурация, classified - ручной перезапуск со сбросом попыток
+	StatusFailed:   {StatusConfiguring, StatusClassified, StatusRejected},
+	StatusApproved: {StatusLive},
+	// Администратор может вернуть отклонённого кандидата в конвейер
+	StatusRejected: {StatusClassified},
+	StatusLive:     {},
+}
+
+// Valid проверяет, что статус известен
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransition проверяет, разрешён ли переход from → to
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition возвращает ErrInvalidTransition, если переход from → to запрещён
+func CheckTransition(from, to Status) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
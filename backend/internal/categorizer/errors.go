@@ -0,0 +1,11 @@
+package categorizer
+
+import "errors"
+
+var (
+	// ErrMappingNotFound сопоставление категории магазина не найдено
+	ErrMappingNotFound = errors.New("shop category mapping not found")
+
+	// ErrInvalidMapping невалидное сопоставление: пустой путь, неизвестная категория или тип товара
+	ErrInvalidMapping = errors.New("invalid shop category mapping")
+)
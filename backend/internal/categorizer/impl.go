@@ -0,0 +1,186 @@
+package categorizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// matcherTTL через столько перечитываются справочник категорий и сопоставления магазинов
+const matcherTTL = 10 * time.Minute
+
+// defaultBackfillBatch размер пачки дозаполнения по умолчанию
+const defaultBackfillBatch = 500
+
+// Categorize определяет категорию и тип товара; nil - уверенность ниже MinConfidence
+func (s *Service) Categorize(ctx context.Context, in *Input) (*Result, error) {
+	matcher, err := s.getMatcher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matcher.Match(in), nil
+}
+
+// Assign определяет категорию товара и записывает её.
+// Возвращает решение, если категория или тип товара изменились, иначе nil
+func (s *Service) Assign(ctx context.Context, productID string, in *Input) (*Result, error) {
+	result, err := s.Categorize(ctx, in)
+	if err != nil || result == nil {
+		return nil, err
+	}
+
+	changed, err := s.storage.AssignCategory(ctx, productID, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign category: %w", err)
+	}
+	if !changed {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// Backfill назначает категории товарам без категории пачками по batchSize
+func (s *Service) Backfill(ctx context.Context, batchSize int) (*BackfillResult, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBackfillBatch
+	}
+
+	result := &BackfillResult{}
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		items, err := s.storage.ListUncategorized(ctx, afterID, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list uncategorized products: %w", err)
+		}
+		if len(items) == 0 {
+			return result, nil
+		}
+
+		for _, item := range items {
+			result.Scanned++
+			assigned, err := s.Assign(ctx, item.ID, &Input{
+				ShopID:   item.ShopID,
+				Category: item.Category,
+				Name:     item.Name,
+			})
+			if err != nil {
+				s.logger.Warn("categorizer: failed to assign category", map[string]interface{}{
+					"product_id": item.ID,
+					"error":      err.Error(),
+				})
+				result.Skipped++
+				continue
+			}
+			if assigned == nil {
+				result.Skipped++
+				continue
+			}
+			result.Assigned++
+		}
+
+		afterID = items[len(items)-1].ID
+		if len(items) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+// ListMappings возвращает сопоставления категорий магазина; пустой shopID - всех магазинов
+func (s *Service) ListMappings(ctx context.Context, shopID string) ([]*ShopMapping, error) {
+	return s.storage.ListShopMappings(ctx, strings.TrimSpace(shopID))
+}
+
+// SaveMapping проверяет и сохраняет сопоставление категории магазина.
+// Путь нормализуется, уверенность по умолчанию 1.0, тип товара должен быть привязан к категории
+func (s *Service) SaveMapping(ctx context.Context, mapping *ShopMapping) (*ShopMapping, error) {
+	mapping.ShopID = strings.TrimSpace(mapping.ShopID)
+	mapping.SourceCategory = NormalizePath(mapping.SourceCategory)
+	if mapping.ShopID == "" || mapping.SourceCategory == "" {
+		return nil, fmt.Errorf("%w: shop_id and source_category are required", ErrInvalidMapping)
+	}
+	if mapping.Confidence == 0 {
+		mapping.Confidence = 1
+	}
+	if mapping.Confidence < 0 || mapping.Confidence > 1 {
+		return nil, fmt.Errorf("%w: confidence must be between 0 and 1", ErrInvalidMapping)
+	}
+
+	matcher, err := s.getMatcher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := matcher.Category(mapping.CategoryID)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown category %s", ErrInvalidMapping, mapping.CategoryID)
+	}
+	if mapping.ProductTypeID != nil && !containsString(category.ProductTypeIDs, *mapping.ProductTypeID) {
+		return nil, fmt.Errorf("%w: product type %s is not linked to category %s", ErrInvalidMapping, *mapping.ProductTypeID, category.Code)
+	}
+
+	if err := s.storage.SaveShopMapping(ctx, mapping); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.logger.Info("categorizer: shop category mapping saved", map[string]interface{}{
+		"shop_id":         mapping.ShopID,
+		"source_category": mapping.SourceCategory,
+		"category_id":     mapping.CategoryID,
+		"confidence":      mapping.Confidence,
+	})
+	return mapping, nil
+}
+
+// DeleteMapping удаляет сопоставление категории магазина
+func (s *Service) DeleteMapping(ctx context.Context, id string) error {
+	if err := s.storage.DeleteShopMapping(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// getMatcher возвращает словари категорий, перечитывая справочник раз в matcherTTL
+func (s *Service) getMatcher(ctx context.Context) (*Matcher, error) {
+	return s.matcher.Get(func() (*Matcher, error) {
+		return s.loadMatcher(ctx)
+	})
+}
+
+// loadMatcher читает справочник категорий и сопоставления магазинов
+func (s *Service) loadMatcher(ctx context.Context) (*Matcher, error) {
+	catalog, err := s.storage.LoadCatalog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	mappings, err := s.storage.ListShopMappings(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shop category mappings: %w", err)
+	}
+
+	s.logger.Debug("Categorizer matcher loaded", map[string]interface{}{
+		"categories": len(catalog),
+		"mappings":   len(mappings),
+	})
+	return NewMatcher(catalog, mappings), nil
+}
+
+// invalidate сбрасывает словари после изменения сопоставлений
+func (s *Service) invalidate() {
+	s.matcher.Invalidate()
+}
+
+// containsString есть ли значение в списке
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package categorizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type mockStorage struct {
+	catalog   []*CatalogCategory
+	mappings  []*ShopMapping
+	products  []*Product
+	assigned  map[string]*Result
+	loads     int
+	saveCalls int
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{catalog: testCatalog(), assigned: make(map[string]*Result)}
+}
+
+func (m *mockStorage) LoadCatalog(ctx context.Context) ([]*CatalogCategory, error) {
+	m.loads++
+	return m.catalog, nil
+}
+
+func (m *mockStorage) ListShopMappings(ctx context.Context, shopID string) ([]*ShopMapping, error) {
+	return m.mappings, nil
+}
+
+func (m *mockStorage) SaveShopMapping(ctx context.Context, mapping *ShopMapping) error {
+	m.saveCalls++
+	mapping.ID = "mapping-1"
+	m.mappings = append(m.mappings, mapping)
+	return nil
+}
+
+func (m *mockStorage) DeleteShopMapping(ctx context.Context, id string) error {
+	return ErrMappingNotFound
+}
+
+func (m *mockStorage) AssignCategory(ctx context.Context, productID string, result *Result) (bool, error) {
+	if _, ok := m.assigned[productID]; ok {
+		return false, nil
+	}
+	m.assigned[productID] = result
+	return true, nil
+}
+
+func (m *mockStorage) ListUncategorized(ctx context.Context, afterID string, limit int) ([]*Product, error) {
+	items := make([]*Product, 0, limit)
+	for _, p := range m.products {
+		if p.ID > afterID && m.assigned[p.ID] == nil && len(items) < limit {
+			items = append(items, p)
+		}
+	}
+	return items, nil
+}
+
+func TestService_Backfill(t *testing.T) {
+	storage := newMockStorage()
+	storage.products = []*Product{
+		{ID: "p1", Category: "Mobilni telefoni", Name: "Samsung Galaxy"},
+		{ID: "p2", Name: "Nepoznat proizvod"},
+		{ID: "p3", ShopID: "shop-1", Category: "TV", Name: "LG 55"},
+	}
+	service := New(storage, nil)
+
+	result, err := service.Backfill(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if result.Scanned != 3 || result.Assigned != 2 || result.Skipped != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := storage.assigned["p3"]; got == nil || got.CategoryID != "cat-tvs" {
+		t.Errorf("p3 assigned %+v, want cat-tvs", got)
+	}
+	if storage.loads != 1 {
+		t.Errorf("catalog loaded %d times, want 1 (cached)", storage.loads)
+	}
+}
+
+func TestService_SaveMapping(t *testing.T) {
+	storage := newMockStorage()
+	service := New(storage, nil)
+	ctx := context.Background()
+
+	wrongType := "type-tv"
+	invalid := []*ShopMapping{
+		{ShopID: "", SourceCategory: "TV", CategoryID: "cat-tvs"},
+		{ShopID: "shop-1", SourceCategory: " > ", CategoryID: "cat-tvs"},
+		{ShopID: "shop-1", SourceCategory: "TV", CategoryID: "cat-missing"},
+		{ShopID: "shop-1", SourceCategory: "TV", CategoryID: "cat-phones", ProductTypeID: &wrongType},
+		{ShopID: "shop-1", SourceCategory: "TV", CategoryID: "cat-tvs", Confidence: 1.5},
+	}
+	for _, mapping := range invalid {
+		if _, err := service.SaveMapping(ctx, mapping); !errors.Is(err, ErrInvalidMapping) {
+			t.Errorf("SaveMapping(%+v) error = %v, want ErrInvalidMapping", mapping, err)
+		}
+	}
+	if storage.saveCalls != 0 {
+		t.Fatalf("invalid mappings must not be saved")
+	}
+
+	saved, err := service.SaveMapping(ctx, &ShopMapping{ShopID: "shop-1", SourceCategory: "Bela Tehnika » Ostalo", CategoryID: "cat-tvs"})
+	if err != nil {
+		t.Fatalf("SaveMapping failed: %v", err)
+	}
+	if saved.SourceCategory != "bela tehnika > ostalo" || saved.Confidence != 1 {
+		t.Errorf("unexpected saved mapping %+v", saved)
+	}
+
+	// Новое сопоставление применяется сразу: словари перечитываются
+	result, err := service.Categorize(ctx, &Input{ShopID: "shop-1", Category: "Bela tehnika > Ostalo"})
+	if err != nil {
+		t.Fatalf("Categorize failed: %v", err)
+	}
+	if result == nil || result.Source != SourceShopMapping || result.CategoryID != "cat-tvs" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,333 @@
+package categorizer
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+)
+
+// MinConfidence ниже этой уверенности категория товару не назначается
+const MinConfidence = 0.5
+
+// Уверенность решения по способу совпадения
+const (
+	confidenceExactSegment   = 0.9  // сегмент совпал с названием, slug или кодом категории
+	confidencePartialSegment = 0.8  // название категории целиком входит в сегмент
+	confidenceSegmentKeyword = 0.75 // сегмент содержит ключевое слово категории
+	confidenceNameKeyword    = 0.6  // ключевое слово в названии товара
+	confidenceAgreement      = 0.05 // категория магазина и название товара указывают на одну категорию
+	confidenceLevelPenalty   = 0.1  // за каждый уровень хлебных крошек выше самого глубокого
+	// confidenceParentLimit потолок совпадения выше самого глубокого уровня: раздел магазина
+	// ("TV, audio i video") сам по себе категорию не назначает, только вместе с названием товара
+	confidenceParentLimit = MinConfidence - confidenceAgreement
+)
+
+// categoryKeywords синонимы категорий справочника по коду для категорий магазинов
+var categoryKeywords = map[string][]string{
+	"PHONES":     {"mobilni telefoni", "mobilni", "telefoni", "smartfoni", "pametni telefoni", "smartphones", "mobile phones"},
+	"LAPTOPS":    {"laptop", "laptopovi", "notebook", "notebooks", "prenosni racunari", "laptop racunari"},
+	"TVS":        {"televizori", "televizor", "tv", "smart tv", "led tv", "oled tv"},
+	"MILK_DAIRY": {"mleko", "mlecni proizvodi", "jogurt", "jogurti", "kefir", "pavlaka", "sirevi"},
+	"SNEAKERS":   {"patike", "sneakers", "tenisice", "sportska obuca"},
+	"FURNITURE":  {"namestaj", "stolice", "ormari", "kreveti", "sofe", "komode", "garniture"},
+}
+
+// nameKeywords однозначные ключевые слова в названиях товаров по коду категории
+var nameKeywords = map[string][]string{
+	"PHONES":     {"mobilni telefon", "smartphone", "smartfon", "iphone"},
+	"LAPTOPS":    {"laptop", "notebook", "macbook", "ultrabook"},
+	"TVS":        {"televizor", "smart tv", "led tv", "oled tv", "qled"},
+	"MILK_DAIRY": {"mleko", "jogurt", "kefir"},
+	"SNEAKERS":   {"patike", "sneakers"},
+	"FURNITURE":  {"stolica", "ormar", "krevet", "sofa", "komoda"},
+}
+
+// accessoryKeywords в названии или категории магазина означают аксессуар ("Maska za iPhone 15",
+// "Mobilni telefoni > Maske i futrole"): категорию по ключевым словам не определяем
+var accessoryKeywords = []string{
+	"maska", "futrola", "zastitno staklo", "folija", "punjac", "kabl", "adapter",
+	"nosac", "drzac", "torba", "ranac", "postolje", "daljinski", "ulozak", "pertle",
+	"maske", "futrole", "zastitna stakla", "folije", "punjaci", "kablovi", "adapteri",
+	"nosaci", "drzaci", "torbe", "rancevi", "postolja", "ulosci",
+}
+
+// breadcrumbSeparators разделители уровней в категории магазина
+var breadcrumbSeparators = strings.NewReplacer(">", "\n", "/", "\n", "\\", "\n", "»", "\n", "›", "\n", "|", "\n")
+
+// term фраза словаря: название, slug или код категории (exact) либо ключевое слово
+type term struct {
+	category *CatalogCategory
+	exact    bool
+}
+
+// Matcher определяет категорию товара по сопоставлениям магазинов, категории магазина и названию
+type Matcher struct {
+	byID        map[string]*CatalogCategory
+	terms       map[string]term
+	phrases     []string // фразы terms, длинные первыми
+	nameTerms   map[string]*CatalogCategory
+	namePhrases []string
+	mappings    map[string]map[string]*ShopMapping // shop_id -> нормализованный путь -> сопоставление
+}
+
+// NewMatcher строит словари по справочнику категорий и сопоставлениям магазинов
+func NewMatcher(catalog []*CatalogCategory, mappings []*ShopMapping) *Matcher {
+	m := &Matcher{
+		byID:      make(map[string]*CatalogCategory, len(catalog)),
+		terms:     make(map[string]term),
+		nameTerms: make(map[string]*CatalogCategory),
+		mappings:  make(map[string]map[string]*ShopMapping),
+	}
+
+	// Сначала названия, slug и коды, затем синонимы: синоним не перекрывает название другой категории
+	for _, category := range catalog {
+		m.byID[category.ID] = category
+		for _, phrase := range []string{category.NameSr, category.Slug, category.Code} {
+			m.addTerm(phrase, term{category: category, exact: true})
+		}
+	}
+	for _, category := range catalog {
+		for _, keyword := range categoryKeywords[category.Code] {
+			m.addTerm(keyword, term{category: category})
+		}
+		for _, keyword := range nameKeywords[category.Code] {
+			if key := attributes.NormalizeLabel(keyword); key != "" {
+				if _, exists := m.nameTerms[key]; !exists {
+					m.nameTerms[key] = category
+					m.namePhrases = append(m.namePhrases, key)
+				}
+			}
+		}
+	}
+	sortLongestFirst(m.phrases)
+	sortLongestFirst(m.namePhrases)
+
+	for _, mapping := range mappings {
+		if _, ok := m.byID[mapping.CategoryID]; !ok {
+			continue
+		}
+		byPath, ok := m.mappings[mapping.ShopID]
+		if !ok {
+			byPath = make(map[string]*ShopMapping)
+			m.mappings[mapping.ShopID] = byPath
+		}
+		byPath[NormalizePath(mapping.SourceCategory)] = mapping
+	}
+
+	return m
+}
+
+// addTerm добавляет фразу словаря; первая зарегистрированная фраза не перезаписывается
+func (m *Matcher) addTerm(phrase string, t term) {
+	key := attributes.NormalizeLabel(phrase)
+	if key == "" {
+		return
+	}
+	if _, exists := m.terms[key]; exists {
+		return
+	}
+	m.terms[key] = t
+	m.phrases = append(m.phrases, key)
+}
+
+// Category возвращает категорию справочника по ID
+func (m *Matcher) Category(id string) (*CatalogCategory, bool) {
+	category, ok := m.byID[id]
+	return category, ok
+}
+
+// Match определяет категорию товара; nil - уверенность ниже MinConfidence.
+// Порядок: сопоставление магазина (полный путь, затем последний сегмент),
+// категория магазина от самого глубокого сегмента, ключевые слова в названии.
+// Аксессуары без сопоставления магазина не категоризируются
+func (m *Matcher) Match(in *Input) *Result {
+	segments := SplitPath(in.Category)
+
+	if result := m.matchShopMapping(in.ShopID, segments); result != nil {
+		return result
+	}
+
+	// Раздел аксессуаров: ни хлебные крошки, ни название ("Maska za iPhone 15") категорию не определяют
+	for _, segment := range segments {
+		if isAccessory(segment) {
+			return nil
+		}
+	}
+
+	textResult := m.matchSegments(segments)
+	nameResult := m.matchName(in.Name)
+
+	result := textResult
+	if textResult == nil || (nameResult != nil && nameResult.Confidence > textResult.Confidence) {
+		result = nameResult
+	}
+	if textResult != nil && nameResult != nil && textResult.CategoryID == nameResult.CategoryID {
+		result.Confidence = roundConfidence(result.Confidence + confidenceAgreement)
+	}
+
+	if result == nil || result.Confidence < MinConfidence {
+		return nil
+	}
+	return result
+}
+
+// matchShopMapping ищет сопоставление магазина по полному пути и по последнему сегменту
+func (m *Matcher) matchShopMapping(shopID string, segments []string) *Result {
+	byPath := m.mappings[shopID]
+	if len(byPath) == 0 || len(segments) == 0 {
+		return nil
+	}
+
+	path := strings.Join(segments, pathSeparator)
+	mapping, ok := byPath[path]
+	if !ok {
+		mapping, ok = byPath[segments[len(segments)-1]]
+	}
+	if !ok {
+		return nil
+	}
+
+	productTypeID := mapping.ProductTypeID
+	if productTypeID == nil {
+		productTypeID = m.defaultProductType(mapping.CategoryID)
+	}
+	return &Result{
+		CategoryID:    mapping.CategoryID,
+		ProductTypeID: productTypeID,
+		Confidence:    mapping.Confidence,
+		Source:        SourceShopMapping,
+		MatchedBy:     mapping.SourceCategory,
+	}
+}
+
+// matchSegments ищет категорию в хлебных крошках начиная с самого глубокого уровня.
+// Совпадение уровнем выше ограничено confidenceParentLimit: "TV, audio i video > Slušalice" -
+// не телевизор
+func (m *Matcher) matchSegments(segments []string) *Result {
+	for i := len(segments) - 1; i >= 0; i-- {
+		category, confidence, ok := m.matchSegment(segments[i])
+		if !ok {
+			continue
+		}
+		if depth := len(segments) - 1 - i; depth > 0 {
+			confidence = math.Min(confidence-float64(depth)*confidenceLevelPenalty, confidenceParentLimit)
+		}
+		return m.result(category, confidence, SourceCategoryText, segments[i])
+	}
+	return nil
+}
+
+// matchSegment сопоставляет один сегмент: точное совпадение фразы, затем вхождение самой длинной фразы
+func (m *Matcher) matchSegment(segment string) (*CatalogCategory, float64, bool) {
+	if t, ok := m.terms[segment]; ok {
+		if t.exact {
+			return t.category, confidenceExactSegment, true
+		}
+		return t.category, confidenceSegmentKeyword, true
+	}
+	for _, phrase := range m.phrases {
+		if !containsPhrase(segment, phrase) {
+			continue
+		}
+		t := m.terms[phrase]
+		if t.exact {
+			return t.category, confidencePartialSegment, true
+		}
+		return t.category, confidenceSegmentKeyword, true
+	}
+	return nil, 0, false
+}
+
+// matchName ищет однозначное ключевое слово в названии товара; аксессуары пропускаются
+func (m *Matcher) matchName(name string) *Result {
+	text := attributes.NormalizeLabel(name)
+	if text == "" || isAccessory(text) {
+		return nil
+	}
+	for _, phrase := range m.namePhrases {
+		if containsPhrase(text, phrase) {
+			return m.result(m.nameTerms[phrase], confidenceNameKeyword, SourceNameKeywords, phrase)
+		}
+	}
+	return nil
+}
+
+// isAccessory содержит ли нормализованный текст ключевое слово аксессуара
+func isAccessory(text string) bool {
+	for _, keyword := range accessoryKeywords {
+		if containsPhrase(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// result решение с типом товара по умолчанию для категории
+func (m *Matcher) result(category *CatalogCategory, confidence float64, source Source, matchedBy string) *Result {
+	return &Result{
+		CategoryID:    category.ID,
+		ProductTypeID: m.defaultProductType(category.ID),
+		Confidence:    roundConfidence(confidence),
+		Source:        source,
+		MatchedBy:     matchedBy,
+	}
+}
+
+// defaultProductType тип товара категории, если он единственный
+func (m *Matcher) defaultProductType(categoryID string) *string {
+	category, ok := m.byID[categoryID]
+	if !ok || len(category.ProductTypeIDs) != 1 {
+		return nil
+	}
+	productTypeID := category.ProductTypeIDs[0]
+	return &productTypeID
+}
+
+// pathSeparator разделитель уровней в нормализованном пути категории магазина
+const pathSeparator = " > "
+
+// SplitPath разбивает категорию магазина на нормализованные сегменты:
+// "TV, audio i video » Televizori" -> ["tv audio i video", "televizori"]
+func SplitPath(category string) []string {
+	parts := strings.Split(breadcrumbSeparators.Replace(category), "\n")
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if segment := attributes.NormalizeLabel(part); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// NormalizePath приводит категорию магазина к ключу сопоставления: "tv audio i video > televizori"
+func NormalizePath(category string) string {
+	return strings.Join(SplitPath(category), pathSeparator)
+}
+
+// containsPhrase входит ли фраза в текст целыми словами
+func containsPhrase(text, phrase string) bool {
+	return strings.Contains(" "+text+" ", " "+phrase+" ")
+}
+
+// sortLongestFirst длинные фразы первыми, чтобы "smart tv" побеждал "tv"
+func sortLongestFirst(phrases []string) {
+	sort.SliceStable(phrases, func(i, j int) bool {
+		if len(phrases[i]) != len(phrases[j]) {
+			return len(phrases[i]) > len(phrases[j])
+		}
+		return phrases[i] < phrases[j]
+	})
+}
+
+// roundConfidence округляет уверенность до сотых и ограничивает диапазоном 0..1
+func roundConfidence(confidence float64) float64 {
+	if confidence > 1 {
+		confidence = 1
+	}
+	if confidence < 0 {
+		confidence = 0
+	}
+	return float64(int(confidence*100+0.5)) / 100
+}
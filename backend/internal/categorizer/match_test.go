@@ -0,0 +1,156 @@
+package categorizer
+
+import "testing"
+
+func testCatalog() []*CatalogCategory {
+	electronics := "cat-electronics"
+	return []*CatalogCategory{
+		{ID: "cat-phones", ParentID: &electronics, Code: "PHONES", Slug: "mobilni-telefoni", NameSr: "Mobilni telefoni", Level: 2, ProductTypeIDs: []string{"type-smartphone"}},
+		{ID: "cat-tvs", ParentID: &electronics, Code: "TVS", Slug: "televizori", NameSr: "Televizori", Level: 2, ProductTypeIDs: []string{"type-tv"}},
+		{ID: "cat-sneakers", Code: "SNEAKERS", Slug: "patike", NameSr: "Patike", Level: 2, ProductTypeIDs: []string{"type-sneakers", "type-running"}},
+		{ID: "cat-electronics", Code: "ELEKTRONIKA", Slug: "elektronika", NameSr: "Elektronika", Level: 1},
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	got := SplitPath(" Elektronika » TV, audio i video / Televizori > ")
+	want := []string{"elektronika", "tv audio i video", "televizori"}
+	if len(got) != len(want) {
+		t.Fatalf("SplitPath = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("segment %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if path := NormalizePath("Telefoni | Mobilni telefoni"); path != "telefoni > mobilni telefoni" {
+		t.Errorf("NormalizePath = %q", path)
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	matcher := NewMatcher(testCatalog(), []*ShopMapping{
+		{ShopID: "shop-1", SourceCategory: "Bela tehnika > Ostalo", CategoryID: "cat-tvs", Confidence: 0.95},
+		{ShopID: "shop-1", SourceCategory: "Sportska oprema", CategoryID: "cat-sneakers", Confidence: 1},
+		{ShopID: "shop-1", SourceCategory: "Nepoznato", CategoryID: "cat-missing", Confidence: 1},
+	})
+
+	tests := []struct {
+		name       string
+		in         *Input
+		category   string
+		confidence float64
+		source     Source
+		typeID     string
+	}{
+		{
+			name:       "shop mapping by full path",
+			in:         &Input{ShopID: "shop-1", Category: "Bela tehnika › Ostalo", Name: "Nešto"},
+			category:   "cat-tvs",
+			confidence: 0.95,
+			source:     SourceShopMapping,
+			typeID:     "type-tv",
+		},
+		{
+			name:       "shop mapping by deepest segment",
+			in:         &Input{ShopID: "shop-1", Category: "Akcija > Sportska oprema", Name: "Nike Air"},
+			category:   "cat-sneakers",
+			confidence: 1,
+			source:     SourceShopMapping,
+		},
+		{
+			name:       "exact name in deepest segment",
+			in:         &Input{Category: "Elektronika > Mobilni telefoni", Name: "Samsung Galaxy A55"},
+			category:   "cat-phones",
+			confidence: 0.9,
+			source:     SourceCategoryText,
+			typeID:     "type-smartphone",
+		},
+		{
+			name: "keyword one level up alone is below threshold",
+			in:   &Input{Category: "TV > Samsung", Name: "Samsung QE55"},
+		},
+		{
+			name:       "keyword one level up agrees with name",
+			in:         &Input{Category: "TV > Samsung", Name: "Samsung QE55 QLED"},
+			category:   "cat-tvs",
+			confidence: 0.65,
+			source:     SourceNameKeywords,
+			typeID:     "type-tv",
+		},
+		{
+			name: "unmatched subsection of a category section",
+			in:   &Input{Category: "TV, audio i video > Slušalice", Name: "Sony WH-1000XM5"},
+		},
+		{
+			name: "accessory subsection of a category",
+			in:   &Input{Category: "Mobilni telefoni > Maske i futrole", Name: "Spigen Ultra Hybrid za iPhone 15"},
+		},
+		{
+			name:       "category text agrees with name",
+			in:         &Input{Category: "Smart TV", Name: "LG OLED TV 55"},
+			category:   "cat-tvs",
+			confidence: 0.8,
+			source:     SourceCategoryText,
+			typeID:     "type-tv",
+		},
+		{
+			name:       "name keywords without category",
+			in:         &Input{Name: "Apple iPhone 15 128GB"},
+			category:   "cat-phones",
+			confidence: 0.6,
+			source:     SourceNameKeywords,
+			typeID:     "type-smartphone",
+		},
+		{
+			name:       "section only",
+			in:         &Input{Category: "Elektronika", Name: "Nešto"},
+			category:   "cat-electronics",
+			confidence: 0.9,
+			source:     SourceCategoryText,
+		},
+		{
+			name: "section above an unknown subsection",
+			in:   &Input{Category: "Elektronika > Razno", Name: "Nešto"},
+		},
+		{
+			name: "accessory name is not categorized by keywords",
+			in:   &Input{Name: "Maska za iPhone 15"},
+		},
+		{
+			name: "mapping to unknown category is ignored",
+			in:   &Input{ShopID: "shop-1", Category: "Nepoznato"},
+		},
+		{
+			name: "deep unmatched breadcrumbs fall below threshold",
+			in:   &Input{Category: "Patike > A > B > C > D > E", Name: "Model X"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matcher.Match(tt.in)
+			if tt.category == "" {
+				if result != nil {
+					t.Fatalf("expected no result, got %+v", result)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatalf("expected %s, got nil", tt.category)
+			}
+			if result.CategoryID != tt.category || result.Source != tt.source || result.Confidence != tt.confidence {
+				t.Errorf("got %s/%s/%.2f, want %s/%s/%.2f",
+					result.CategoryID, result.Source, result.Confidence, tt.category, tt.source, tt.confidence)
+			}
+			gotType := ""
+			if result.ProductTypeID != nil {
+				gotType = *result.ProductTypeID
+			}
+			if gotType != tt.typeID {
+				t.Errorf("product type = %q, want %q", gotType, tt.typeID)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package categorizer
+
+import "time"
+
+// Source откуда взято решение о категории товара
+type Source string
+
+const (
+	SourceShopMapping  Source = "shop_mapping"  // сопоставление категории магазина, заданное администратором
+	SourceCategoryText Source = "category_text" // хлебные крошки / категория магазина совпали с категорией справочника
+	SourceNameKeywords Source = "name_keywords" // ключевые слова в названии товара
+)
+
+// Input данные товара для определения категории
+type Input struct {
+	ShopID   string
+	Category string // категория магазина, в том числе хлебные крошки "Elektronika > TV > Televizori"
+	Name     string
+}
+
+// Result категория и тип товара с уверенностью 0..1
+type Result struct {
+	CategoryID    string  `json:"category_id"`
+	ProductTypeID *string `json:"product_type_id,omitempty"`
+	Confidence    float64 `json:"confidence"`
+	Source        Source  `json:"source"`
+	MatchedBy     string  `json:"matched_by,omitempty"` // сегмент категории, ключевое слово или путь сопоставления
+}
+
+// ShopMapping сопоставление категории магазина с категорией справочника
+type ShopMapping struct {
+	ID             string    `json:"id"`
+	ShopID         string    `json:"shop_id"`
+	SourceCategory string    `json:"source_category"` // нормализованный путь: "tv audio video > televizori"
+	CategoryID     string    `json:"category_id"`
+	ProductTypeID  *string   `json:"product_type_id,omitempty"`
+	Confidence     float64   `json:"confidence"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CatalogCategory активная категория справочника с привязанными типами товаров
+type CatalogCategory struct {
+	ID             string
+	ParentID       *string
+	Code           string
+	Slug           string
+	NameSr         string
+	Level          int
+	ProductTypeIDs []string
+}
+
+// Product товар без категории для дозаполнения
+type Product struct {
+	ID       string
+	ShopID   string // магазин последнего привязанного сырого товара, может быть пустым
+	Category string
+	Name     string
+}
+
+// BackfillResult итог дозаполнения категорий
+type BackfillResult struct {
+	Scanned  int `json:"scanned"`
+	Assigned int `json:"assigned"`
+	Skipped  int `json:"skipped"` // уверенность ниже порога или категория уже задана
+}
@@ -0,0 +1,50 @@
+package categorizer
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/cache"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс хранилища справочника категорий, сопоставлений магазинов и категорий товаров
+type Storage interface {
+	// LoadCatalog возвращает активные категории с типами товаров (category_product_types)
+	LoadCatalog(ctx context.Context) ([]*CatalogCategory, error)
+
+	// ListShopMappings возвращает сопоставления магазина; пустой shopID - всех магазинов
+	ListShopMappings(ctx context.Context, shopID string) ([]*ShopMapping, error)
+
+	// SaveShopMapping создаёт или обновляет сопоставление по (shop_id, source_category)
+	SaveShopMapping(ctx context.Context, mapping *ShopMapping) error
+
+	// DeleteShopMapping удаляет сопоставление
+	DeleteShopMapping(ctx context.Context, id string) error
+
+	// AssignCategory записывает категорию товара, если её нет или она назначена автоматически
+	// с меньшей уверенностью; категории, заданные вручную, не перезаписываются
+	AssignCategory(ctx context.Context, productID string, result *Result) (bool, error)
+
+	// ListUncategorized возвращает товары без категории с ID больше afterID
+	ListUncategorized(ctx context.Context, afterID string, limit int) ([]*Product, error)
+}
+
+// Service сервис автоматического определения категорий товаров
+type Service struct {
+	storage Storage
+	logger  *logger.Logger
+
+	matcher *cache.TTL[*Matcher]
+}
+
+// New создаёт сервис определения категорий
+func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage: storage,
+		logger:  log,
+		matcher: cache.NewTTL[*Matcher](matcherTTL),
+	}
+}
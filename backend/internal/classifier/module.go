@@ -31,6 +31,8 @@ type PotentialShop struct {
 	ConfidenceScore float64
 	DiscoveredAt    string
 	Metadata        map[string]interface{}
+	// StatusReason причина смены статуса, попадает в историю переходов кандидата
+	StatusReason string
 }
 
 // Storage интерфейс для работы с хранилищем
@@ -44,7 +46,7 @@ type Storage interface {
 	// ListPotentialShopsByStatus получает список кандидатов по статусу
 	ListPotentialShopsByStatus(status string, limit int) ([]*PotentialShop, error)
 
-	// UpdatePotentialShop обновляет кандидата
+	// UpdatePotentialShop обновляет кандидата; смена статуса проверяется конвейером кандидатов (candidates)
 	UpdatePotentialShop(shop *PotentialShop) error
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,7 +20,14 @@ type Config struct {
 	Queue  QueueConfig
 	Google GoogleConfig
 	OpenAI OpenAIConfig
+	Autoconfig AutoconfigConfig
+	Alerts AlertsConfig
+	Scraper ScraperConfig
+	Admin   AdminConfig
+	PriceHistory PriceHistoryConfig
+	SelectorHealth SelectorHealthConfig
 	QualityGates QualityGatesConfig
+	Rescrape RescrapeConfig
 }
 
 // ServerConfig конфигурация HTTP сервера
@@ -60,11 +68,22 @@ type MeilisearchConfig struct {
 
 // QueueConfig конфигурация очереди сообщений
 type QueueConfig struct {
-	Type       string // "kafka", "rabbitmq"
+	Type       string // "redis" (список, без подтверждений), "redis_streams" (надёжный режим)
 	Brokers    []string
 	Topic      string
 	GroupID    string
 	MaxWorkers int
+
+	// Надёжный режим (redis_streams)
+	MaxAttempts       int           // попыток доставки до перемещения в dead-letter
+	VisibilityTimeout time.Duration // через сколько неподтверждённое сообщение доставляется повторно
+	DeadLetterTopic   string        // пусто = "<topic>.dead"
+
+	// События изменения товаров для инкрементальной индексации (пусто = отключено)
+	IndexTopic string
+
+	// Задания повторного парсинга от планировщика (пусто = воркер выполняет их сам)
+	RescrapeTopic string
 }
 
 // GoogleConfig конфигурация Google API
@@ -75,8 +94,77 @@ type GoogleConfig struct {
 
 // OpenAIConfig конфигурация OpenAI API
 type OpenAIConfig struct {
-	APIKey string // OpenAI API Key
-	Model  string // Модель для использования (по умолчанию gpt-4o-mini)
+	APIKey  string // OpenAI API Key
+	Model   string // Модель для использования (по умолчанию gpt-4o-mini)
+	BaseURL string // OpenAI-совместимый endpoint self-hosted модели (пусто - api.openai.com)
+}
+
+// AutoconfigConfig настройки авто-конфигурации магазинов
+type AutoconfigConfig struct {
+	// Generator генератор селекторов: auto (AI с эвристикой как запасным вариантом, без AI - только эвристика),
+	// openai (только AI) или heuristic (только эвристика, без сети и ключей)
+	Generator string
+}
+
+// AlertsConfig конфигурация уведомлений о снижении цены
+type AlertsConfig struct {
+	WebhookTimeout time.Duration
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPFrom       string
+	SMTPTimeout    time.Duration
+}
+
+// ScraperConfig настройки вежливого обхода магазинов (robots.txt, лимиты)
+type ScraperConfig struct {
+	RespectRobots    bool          // проверять robots.txt перед запросом
+	UserAgent        string        // имя краулера: группа robots.txt и начало заголовка User-Agent
+	RobotsCacheTTL   time.Duration // время жизни кэша robots.txt
+	DefaultRateLimit int           // запросов в секунду, если у магазина не задан rate_limit
+	MaxPerShop       int           // одновременных запросов к одному магазину
+	MaxBrowsers      int           // одновременно запущенных headless-браузеров
+}
+
+// AdminConfig настройки административного API
+type AdminConfig struct {
+	APIToken string            // общий Bearer-токен; решения по нему записываются на "admin"
+	Tokens   map[string]string // именные токены: имя администратора -> токен
+}
+
+// Credentials возвращает токены для AdminAuth (токен -> имя администратора); пусто - API отключён
+func (c AdminConfig) Credentials() map[string]string {
+	credentials := make(map[string]string, len(c.Tokens)+1)
+	if c.APIToken != "" {
+		credentials[c.APIToken] = "admin"
+	}
+	for name, token := range c.Tokens {
+		credentials[token] = name
+	}
+	return credentials
+}
+
+// PriceHistoryConfig настройки журнала истории цен
+type PriceHistoryConfig struct {
+	Retention time.Duration // сколько хранить сырые точки; дневные/недельные агрегаты не удаляются
+}
+
+// SelectorHealthConfig настройки детектора деградации селекторов магазинов
+type SelectorHealthConfig struct {
+	Window         int     // сколько последних парсингов магазина учитывать
+	MinSamples     int     // минимум парсингов в окне для вывода о деградации
+	MinSuccessRate float64 // доля успешных срабатываний селекторов name/price, ниже - магазин degraded
+}
+
+// RescrapeConfig настройки планировщика повторного парсинга
+type RescrapeConfig struct {
+	BaseInterval time.Duration // интервал для обычного товара; волатильные и популярные посещаются чаще
+	MinInterval  time.Duration // минимальный интервал между посещениями адреса
+	MaxInterval  time.Duration // максимальный интервал (товары без наличия, падающие страницы)
+	Lease        time.Duration // через сколько невыполненное задание раздаётся снова
+	TickInterval time.Duration // как часто планировщик раздаёт созревшие адреса
+	BatchSize    int           // сколько адресов раздаётся за один тик
 }
 
 type QualityGateThresholds struct {
@@ -133,6 +221,12 @@ func Load() (*Config, error) {
 			Topic:      getEnv("QUEUE_TOPIC", "scraping_tasks"),
 			GroupID:    getEnv("QUEUE_GROUP_ID", "izborator_workers"),
 			MaxWorkers: getEnvAsInt("QUEUE_MAX_WORKERS", 10),
+
+			MaxAttempts:       getEnvAsInt("QUEUE_MAX_ATTEMPTS", 5),
+			VisibilityTimeout: getEnvAsDuration("QUEUE_VISIBILITY_TIMEOUT", 5*time.Minute),
+			DeadLetterTopic:   getEnv("QUEUE_DEAD_LETTER_TOPIC", ""),
+			IndexTopic:        getEnv("QUEUE_INDEX_TOPIC", "product_index_events"),
+			RescrapeTopic:     getEnv("QUEUE_RESCRAPE_TOPIC", "rescrape_jobs"),
 		},
 
 		Google: GoogleConfig{
@@ -141,8 +235,53 @@ func Load() (*Config, error) {
 		},
 
 		OpenAI: OpenAIConfig{
-			APIKey: getEnv("OPENAI_API_KEY", ""),
-			Model:  getEnv("OPENAI_MODEL", ""), // Пустое = gpt-4o-mini по умолчанию
+			APIKey:  getEnv("OPENAI_API_KEY", ""),
+			Model:   getEnv("OPENAI_MODEL", ""), // Пустое = gpt-4o-mini по умолчанию
+			BaseURL: getEnv("OPENAI_BASE_URL", ""),
+		},
+
+		Autoconfig: AutoconfigConfig{
+			Generator: getEnv("AUTOCONFIG_GENERATOR", "auto"),
+		},
+
+		Alerts: AlertsConfig{
+			WebhookTimeout: getEnvAsDuration("ALERTS_WEBHOOK_TIMEOUT", 10*time.Second),
+			SMTPHost:       getEnv("SMTP_HOST", ""),
+			SMTPPort:       getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:       getEnv("SMTP_FROM", ""),
+			SMTPTimeout:    getEnvAsDuration("SMTP_TIMEOUT", 15*time.Second),
+		},
+
+		Scraper: ScraperConfig{
+			RespectRobots:    getEnvAsBool("SCRAPER_RESPECT_ROBOTS", true),
+			UserAgent:        getEnv("SCRAPER_USER_AGENT", "IzboratorBot"),
+			RobotsCacheTTL:   getEnvAsDuration("SCRAPER_ROBOTS_CACHE_TTL", 6*time.Hour),
+			DefaultRateLimit: getEnvAsInt("SCRAPER_DEFAULT_RATE_LIMIT", 1),
+			MaxPerShop:       getEnvAsInt("SCRAPER_MAX_CONCURRENCY_PER_SHOP", 2),
+			MaxBrowsers:      getEnvAsInt("SCRAPER_MAX_BROWSERS", 2),
+		},
+
+		Admin: AdminConfig{
+			APIToken: getEnv("ADMIN_API_TOKEN", ""),
+			Tokens:   getEnvAsNamedTokens("ADMIN_API_TOKENS"),
+		},
+		PriceHistory: PriceHistoryConfig{
+			Retention: getEnvAsDuration("PRICE_HISTORY_RETENTION", 90*24*time.Hour),
+		},
+		SelectorHealth: SelectorHealthConfig{
+			Window:         getEnvAsInt("SELECTOR_HEALTH_WINDOW", 20),
+			MinSamples:     getEnvAsInt("SELECTOR_HEALTH_MIN_SAMPLES", 10),
+			MinSuccessRate: getEnvAsFloat("SELECTOR_HEALTH_MIN_SUCCESS_RATE", 0.5),
+		},
+		Rescrape: RescrapeConfig{
+			BaseInterval: getEnvAsDuration("RESCRAPE_BASE_INTERVAL", 6*time.Hour),
+			MinInterval:  getEnvAsDuration("RESCRAPE_MIN_INTERVAL", 30*time.Minute),
+			MaxInterval:  getEnvAsDuration("RESCRAPE_MAX_INTERVAL", 7*24*time.Hour),
+			Lease:        getEnvAsDuration("RESCRAPE_LEASE", time.Hour),
+			TickInterval: getEnvAsDuration("RESCRAPE_TICK_INTERVAL", time.Minute),
+			BatchSize:    getEnvAsInt("RESCRAPE_BATCH_SIZE", 50),
 		},
 		QualityGates: QualityGatesConfig{
 			Goods: QualityGateThresholds{
@@ -182,6 +321,34 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -207,6 +374,19 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	return []string{valueStr}
 }
 
+// getEnvAsNamedTokens читает пары "имя:токен" через запятую; пары без имени или токена пропускаются
+func getEnvAsNamedTokens(key string) map[string]string {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		name, token, ok := strings.Cut(pair, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if ok && name != "" && token != "" {
+			tokens[name] = token
+		}
+	}
+	return tokens
+}
+
 // DSN возвращает строку подключения к PostgreSQL
 func (c *DBConfig) DSN() string {
 	return fmt.Sprintf(
@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// NormalizeDomain приводит домен к виду potential_shops.domain: нижний регистр, без www. и порта
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimSuffix(domain, ".")
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	domain = strings.TrimPrefix(domain, "www.")
+
+	// Домен должен содержать точку и не быть IP-адресом
+	if !strings.Contains(domain, ".") || net.ParseIP(domain) != nil || strings.ContainsAny(domain, "/ @") {
+		return ""
+	}
+	return domain
+}
+
+// DomainFromURL извлекает нормализованный домен из URL; URL без схемы считается https
+func DomainFromURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return NormalizeDomain(parsed.Host)
+}
+
+// sameSite проверяет, что domain совпадает с site или является его поддоменом
+func sameSite(domain, site string) bool {
+	return domain == site || strings.HasSuffix(domain, "."+site)
+}
+
+// hasSuffix проверяет доменную зону; пустой список пропускает любые домены
+func hasSuffix(domain string, suffixes []string) bool {
+	if len(suffixes) == 0 {
+		return true
+	}
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(domain, strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultQueries dorking-запросы для поиска магазинов и услуг в Сербии
+var DefaultQueries = []string{
+	// E-commerce магазины (существующие запросы)
+	"site:.rs \"dodaj u korpu\"",
+	"site:.rs \"kupi odmah\"",
+	"site:.rs \"cena rsd\"",
+	"site:.rs inurl:proizvod",
+	"site:.rs inurl:kategorija",
+	"site:.rs \"besplatna dostava\" cena",
+	"site:.rs \"online prodavnica\"",
+	"site:.rs \"internet prodavnica\"",
+	"site:.rs \"e-shop\"",
+	"site:.rs \"webshop\"",
+
+	// Услуги - прайс-листы и цены
+	"site:.rs \"cenovnik usluga\"",
+	"site:.rs \"cenovnik\" cena",
+	"site:.rs \"cena usluge\"",
+	"site:.rs \"cena rada\"",
+	"site:.rs \"zakazivanje termina\"",
+	"site:.rs \"rezervacija\" cena",
+
+	// Медицинские услуги
+	"site:.rs \"zubarska ordinacija\" cene",
+	"site:.rs \"dermatolog\" cena",
+	"site:.rs \"fizioterapija\" cena",
+	"site:.rs \"masaza\" cena",
+
+	// Красота и уход
+	"site:.rs \"frizerski salon\" cena",
+	"site:.rs \"manikir pedikir\" cena",
+	"site:.rs \"kozmeticki salon\" cena",
+
+	// Ремонт и обслуживание
+	"site:.rs \"servis\" cena",
+	"site:.rs \"popravka\" cena",
+	"site:.rs \"montaza\" cena",
+
+	// Образование и курсы
+	"site:.rs \"kurs\" cena",
+	"site:.rs \"obuka\" cena",
+	"site:.rs \"skola\" cena",
+
+	// Юридические и консультационные услуги
+	"site:.rs \"advokat\" cena",
+	"site:.rs \"notar\" cena",
+	"site:.rs \"konsultacije\" cena",
+
+	// Транспорт и доставка
+	"site:.rs \"prevoz\" cena",
+	"site:.rs \"dostava\" cena",
+	"site:.rs \"kurirska sluzba\" cena",
+
+	// Общие паттерны для услуг
+	"site:.rs inurl:cenovnik",
+	"site:.rs inurl:cene",
+	"site:.rs inurl:usluge",
+	"site:.rs \"tabela cena\"",
+	"site:.rs \"cena po satu\"",
+	"site:.rs \"cena po terminu\"",
+}
+
+// googleResult структура ответа Google Custom Search API
+type googleResult struct {
+	Items []struct {
+		Link  string `json:"link"`
+		Title string `json:"title"`
+	} `json:"items"`
+}
+
+// GoogleSource ищет домены запросами Google Custom Search
+type GoogleSource struct {
+	apiKey     string
+	cx         string
+	queries    []string
+	maxResults int
+	delay      time.Duration
+	client     *http.Client
+	endpoint   string
+}
+
+// NewGoogleSource создаёт источник Google CSE; queries = nil - DefaultQueries.
+// Google отдаёт не больше 100 результатов на запрос
+func NewGoogleSource(apiKey, cx string, queries []string, maxResults int, delay time.Duration) *GoogleSource {
+	if queries == nil {
+		queries = DefaultQueries
+	}
+	if maxResults <= 0 || maxResults > 100 {
+		maxResults = 100
+	}
+	return &GoogleSource{
+		apiKey:     apiKey,
+		cx:         cx,
+		queries:    queries,
+		maxResults: maxResults,
+		delay:      delay,
+		client:     &http.Client{Timeout: 15 * time.Second},
+		endpoint:   "https://www.googleapis.com/customsearch/v1",
+	}
+}
+
+// Name реализует Source
+func (g *GoogleSource) Name() string {
+	return "google_search"
+}
+
+// Discover реализует Source. Ошибка запроса пропускает оставшиеся страницы запроса;
+// она возвращается, только если Google не вернул ни одного результата
+func (g *GoogleSource) Discover(ctx context.Context, emit func(Finding) error) error {
+	if g.apiKey == "" || g.cx == "" {
+		return fmt.Errorf("google source requires API key and CX")
+	}
+
+	var lastErr error
+	emitted := 0
+
+	// Максимум 10 страниц по 10 результатов (start=1, 11, ... 91)
+	pages := (g.maxResults + 9) / 10
+	for i, query := range g.queries {
+		for page := 0; page < pages; page++ {
+			items, err := g.search(ctx, query, page*10+1)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				lastErr = fmt.Errorf("query %q: %w", query, err)
+				break
+			}
+			if len(items.Items) == 0 {
+				break
+			}
+
+			for _, item := range items.Items {
+				err := emit(Finding{
+					URL:   item.Link,
+					Title: item.Title,
+					Metadata: map[string]interface{}{
+						"query": query,
+						"page":  page + 1,
+					},
+				})
+				if err != nil {
+					return err
+				}
+				emitted++
+			}
+
+			// Не спамим Google, иначе ключ заблокируют
+			if err := sleep(ctx, g.delay); err != nil {
+				return err
+			}
+		}
+
+		if i < len(g.queries)-1 {
+			if err := sleep(ctx, g.delay*2); err != nil {
+				return err
+			}
+		}
+	}
+
+	if emitted == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// search запрашивает одну страницу результатов
+func (g *GoogleSource) search(ctx context.Context, query string, start int) (*googleResult, error) {
+	params := url.Values{}
+	params.Set("key", g.apiKey)
+	params.Set("cx", g.cx)
+	params.Set("q", query)
+	params.Set("start", fmt.Sprint(start))
+	params.Set("gl", "rs")
+	params.Set("num", "10")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request Google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google API returned status %d", resp.StatusCode)
+	}
+
+	var result googleResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Google response: %w", err)
+	}
+	return &result, nil
+}
+
+// sleep ждёт d или отмены контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/solomonczyk/izborator/internal/candidates"
+	"github.com/solomonczyk/izborator/internal/classifier"
+)
+
+// SourceStats итоги одного источника
+type SourceStats struct {
+	Found   int    `json:"found"`
+	Saved   int    `json:"saved"`
+	Updated int    `json:"updated"`
+	Skipped int    `json:"skipped"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Run опрашивает источники по очереди. Ошибка источника не останавливает остальные.
+// Домены действующих магазинов и уже известные кандидаты пропускаются
+func (s *Service) Run(ctx context.Context, sources ...Source) (map[string]*SourceStats, error) {
+	known, err := s.knownShopDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*SourceStats, len(sources))
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		sourceStats := &SourceStats{}
+		stats[source.Name()] = sourceStats
+
+		s.logger.Info("discovery: source started", map[string]interface{}{
+			"source": source.Name(),
+		})
+
+		err := source.Discover(ctx, func(f Finding) error {
+			sourceStats.Found++
+			return s.save(source.Name(), f, known, seen, sourceStats)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			sourceStats.Error = err.Error()
+			s.logger.Error("discovery: source failed", map[string]interface{}{
+				"source": source.Name(),
+				"error":  err.Error(),
+			})
+		}
+
+		s.logger.Info("discovery: source finished", map[string]interface{}{
+			"source":  source.Name(),
+			"found":   sourceStats.Found,
+			"saved":   sourceStats.Saved,
+			"updated": sourceStats.Updated,
+			"skipped": sourceStats.Skipped,
+		})
+	}
+
+	return stats, nil
+}
+
+// save сохраняет находку как нового кандидата или дополняет метаданные известного
+func (s *Service) save(sourceName string, f Finding, known, seen map[string]bool, stats *SourceStats) error {
+	domain := NormalizeDomain(f.Domain)
+	if domain == "" {
+		domain = DomainFromURL(f.URL)
+	}
+	if domain == "" || known[domain] || (seen[domain] && !f.Update) {
+		stats.Skipped++
+		return nil
+	}
+	seen[domain] = true
+
+	existing, err := s.storage.GetPotentialShopByDomain(domain)
+	if err != nil {
+		return fmt.Errorf("failed to check domain %s: %w", domain, err)
+	}
+	if existing != nil && !f.Update {
+		stats.Skipped++
+		return nil
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	metadata := map[string]interface{}{"discovered": now}
+	for key, value := range f.Metadata {
+		metadata[key] = value
+	}
+	if f.URL != "" {
+		metadata["url"] = f.URL
+	}
+	if f.Title != "" {
+		metadata["title"] = f.Title
+	}
+
+	shop := &classifier.PotentialShop{
+		ID:           uuid.New().String(),
+		Domain:       domain,
+		Source:       sourceName,
+		Status:       string(candidates.StatusDiscovered),
+		DiscoveredAt: now,
+		Metadata:     metadata,
+	}
+	if existing != nil {
+		// Метаданные дополняются, источник и статус кандидата не меняются
+		shop.ID = existing.ID
+		delete(metadata, "discovered")
+	}
+
+	if err := s.storage.SavePotentialShop(shop); err != nil {
+		s.logger.Error("discovery: failed to save potential shop", map[string]interface{}{
+			"source": sourceName,
+			"domain": domain,
+			"error":  err.Error(),
+		})
+		stats.Skipped++
+		return nil
+	}
+
+	if existing != nil {
+		stats.Updated++
+		return nil
+	}
+	stats.Saved++
+	s.logger.Info("discovery: new candidate", map[string]interface{}{
+		"source": sourceName,
+		"domain": domain,
+	})
+	return nil
+}
+
+// knownShopDomains домены действующих магазинов: они уже в shops и кандидатами не становятся
+func (s *Service) knownShopDomains(ctx context.Context) (map[string]bool, error) {
+	known := make(map[string]bool)
+	if s.shops == nil {
+		return known, nil
+	}
+	urls, err := s.shops.ListShopURLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list known shops: %w", err)
+	}
+	for _, u := range urls {
+		if domain := DomainFromURL(u); domain != "" {
+			known[domain] = true
+		}
+	}
+	return known, nil
+}
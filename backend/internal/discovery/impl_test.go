@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/classifier"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	shops map[string]*classifier.PotentialShop
+	saved []*classifier.PotentialShop
+}
+
+func newMockStorage(existing ...string) *mockStorage {
+	m := &mockStorage{shops: make(map[string]*classifier.PotentialShop)}
+	for _, domain := range existing {
+		m.shops[domain] = &classifier.PotentialShop{ID: "id-" + domain, Domain: domain, Source: "google_search"}
+	}
+	return m
+}
+
+func (m *mockStorage) SavePotentialShop(shop *classifier.PotentialShop) error {
+	m.saved = append(m.saved, shop)
+	if _, ok := m.shops[shop.Domain]; !ok {
+		m.shops[shop.Domain] = shop
+	}
+	return nil
+}
+
+func (m *mockStorage) GetPotentialShopByDomain(domain string) (*classifier.PotentialShop, error) {
+	return m.shops[domain], nil
+}
+
+func (m *mockStorage) ListPotentialShopsByStatus(status string, limit int) ([]*classifier.PotentialShop, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) UpdatePotentialShop(shop *classifier.PotentialShop) error {
+	return nil
+}
+
+type knownShops []string
+
+func (k knownShops) ListShopURLs(ctx context.Context) ([]string, error) {
+	return k, nil
+}
+
+type staticSource struct {
+	name     string
+	findings []Finding
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Discover(ctx context.Context, emit func(Finding) error) error {
+	for _, f := range s.findings {
+		if err := emit(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestParseSeedList(t *testing.T) {
+	text := "# shops from the chamber of commerce\nwww.Gigatron.rs\nhttps://shop.emmi.rs/akcije\n\nlocalhost\n"
+	seeds, err := ParseSeedList(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseSeedList failed: %v", err)
+	}
+	if len(seeds) != 3 || seeds[0].Domain != "gigatron.rs" || seeds[1].Domain != "shop.emmi.rs" || seeds[1].URL == "" {
+		t.Fatalf("unexpected seeds %+v", seeds)
+	}
+	if seeds[2].Domain != "" {
+		t.Errorf("localhost must not produce a domain, got %q", seeds[2].Domain)
+	}
+
+	csv := "\ufeffNaziv;Sajt;Grad\nTehnomanija;tehnomanija.rs;Beograd\nWinWin;https://www.winwin.rs/;Niš\n"
+	seeds, err = ParseSeedList(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseSeedList failed: %v", err)
+	}
+	if len(seeds) != 2 || seeds[0].Domain != "tehnomanija.rs" || seeds[0].Title != "Tehnomanija" || seeds[1].Domain != "winwin.rs" {
+		t.Errorf("unexpected CSV seeds %+v", seeds)
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	storage := newMockStorage("ananas.rs")
+	service := New(storage, knownShops{"https://www.gigatron.rs"}, logger.New("error"))
+
+	seeds := staticSource{name: "registry", findings: []Finding{
+		{Domain: "emmi.rs", Title: "Emmi"},
+		{URL: "https://www.gigatron.rs/tv"},
+		{Domain: "ananas.rs"},
+		{URL: "https://EMMI.rs/akcije"},
+		{Domain: "not a domain"},
+	}}
+	sitemaps := staticSource{name: "sitemap", findings: []Finding{
+		{Domain: "ananas.rs", Update: true, Metadata: map[string]interface{}{"product_urls": []string{"https://ananas.rs/p/1"}}},
+	}}
+
+	stats, err := service.Run(context.Background(), seeds, sitemaps)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if s := stats["registry"]; s.Found != 5 || s.Saved != 1 || s.Skipped != 4 {
+		t.Errorf("unexpected registry stats %+v", s)
+	}
+	if s := stats["sitemap"]; s.Updated != 1 {
+		t.Errorf("unexpected sitemap stats %+v", s)
+	}
+
+	if len(storage.saved) != 2 {
+		t.Fatalf("expected 2 saves, got %d", len(storage.saved))
+	}
+	created := storage.saved[0]
+	if created.Domain != "emmi.rs" || created.Source != "registry" || created.Status != "discovered" || created.Metadata["title"] != "Emmi" {
+		t.Errorf("unexpected new candidate %+v", created)
+	}
+	updated := storage.saved[1]
+	if updated.ID != "id-ananas.rs" || updated.Metadata["product_urls"] == nil || updated.Metadata["discovered"] != nil {
+		t.Errorf("unexpected metadata update %+v", updated)
+	}
+}
@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/classifier"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Source источник доменов-кандидатов: Google CSE, списки доменов, внешние ссылки и sitemap известных магазинов
+type Source interface {
+	// Name метка источника, сохраняется в potential_shops.source
+	Name() string
+	// Discover передаёт найденные домены в emit; ошибка emit прекращает обход
+	Discover(ctx context.Context, emit func(Finding) error) error
+}
+
+// Finding найденный домен
+type Finding struct {
+	Domain   string // если пусто, берётся из URL
+	URL      string
+	Title    string
+	Metadata map[string]interface{}
+	// Update дополняет метаданные уже известного кандидата вместо пропуска
+	Update bool
+}
+
+// KnownShops домены действующих магазинов
+type KnownShops interface {
+	// ListShopURLs возвращает base_url всех магазинов
+	ListShopURLs(ctx context.Context) ([]string, error)
+}
+
+// Service сохраняет найденные источниками домены как кандидатов (статус discovered)
+type Service struct {
+	storage classifier.Storage
+	shops   KnownShops
+	logger  *logger.Logger
+}
+
+// New создаёт сервис discovery; shops может быть nil
+func New(storage classifier.Storage, shops KnownShops, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage: storage,
+		shops:   shops,
+		logger:  log,
+	}
+}
@@ -0,0 +1,140 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ignoredHosts соцсети, мессенджеры и платформы, на которые ссылаются почти все магазины
+var ignoredHosts = []string{
+	"facebook.com", "instagram.com", "twitter.com", "x.com", "youtube.com", "youtu.be",
+	"linkedin.com", "tiktok.com", "pinterest.com", "google.com", "google.rs", "goo.gl",
+	"apple.com", "wa.me", "whatsapp.com", "viber.com", "t.me", "telegram.me",
+	"wordpress.org", "woocommerce.com", "shopify.com", "mailchimp.com", "cloudflare.com",
+}
+
+// OutboundLinksSource ищет домены по внешним ссылкам с главных страниц действующих магазинов:
+// партнёры, поставщики и соседние магазины сети
+type OutboundLinksSource struct {
+	shops     KnownShops
+	client    *http.Client
+	userAgent string
+	suffixes  []string
+}
+
+// NewOutboundLinksSource создаёт источник внешних ссылок; suffixes ограничивает доменные зоны (например .rs)
+func NewOutboundLinksSource(shops KnownShops, client *http.Client, userAgent string, suffixes []string) *OutboundLinksSource {
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &OutboundLinksSource{
+		shops:     shops,
+		client:    client,
+		userAgent: userAgent,
+		suffixes:  suffixes,
+	}
+}
+
+// Name реализует Source
+func (o *OutboundLinksSource) Name() string {
+	return "outbound_links"
+}
+
+// Discover реализует Source. Недоступные магазины пропускаются
+func (o *OutboundLinksSource) Discover(ctx context.Context, emit func(Finding) error) error {
+	shopURLs, err := o.shops.ListShopURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shops: %w", err)
+	}
+
+	for _, shopURL := range shopURLs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		links, err := o.externalLinks(ctx, shopURL)
+		if err != nil {
+			continue
+		}
+		for _, link := range links {
+			link.Metadata = map[string]interface{}{"referrer": shopURL}
+			if err := emit(link); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// externalLinks возвращает по одной ссылке на каждый внешний домен главной страницы магазина
+func (o *OutboundLinksSource) externalLinks(ctx context.Context, shopURL string) ([]Finding, error) {
+	base, err := url.Parse(shopURL)
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme == "" {
+		base, err = url.Parse("https://" + shopURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	site := NormalizeDomain(base.Host)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.userAgent != "" {
+		req.Header.Set("User-Agent", o.userAgent)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var links []Finding
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		target, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			return
+		}
+		domain := NormalizeDomain(target.Host)
+		if domain == "" || seen[domain] || sameSite(domain, site) || isIgnoredHost(domain) || !hasSuffix(domain, o.suffixes) {
+			return
+		}
+		seen[domain] = true
+		links = append(links, Finding{
+			Domain: domain,
+			URL:    target.String(),
+			Title:  strings.Join(strings.Fields(a.Text()), " "),
+		})
+	})
+	return links, nil
+}
+
+// isIgnoredHost проверяет домен по списку соцсетей и платформ
+func isIgnoredHost(domain string) bool {
+	for _, host := range ignoredHosts {
+		if sameSite(domain, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package discovery
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// seedDomainColumns названия столбца с доменом в заголовке CSV
+var seedDomainColumns = []string{"domain", "url", "website", "site", "sajt", "domen"}
+
+// seedTitleColumns названия столбца с названием магазина
+var seedTitleColumns = []string{"title", "name", "naziv", "shop"}
+
+// SeedListSource импортирует домены из файла: текст (один домен или URL в строке) или CSV с заголовком
+type SeedListSource struct {
+	path string
+	tag  string
+}
+
+// NewSeedListSource создаёт источник из файла; tag - метка источника (по умолчанию seed_list),
+// например registry для выгрузки реестра
+func NewSeedListSource(path, tag string) *SeedListSource {
+	if tag == "" {
+		tag = "seed_list"
+	}
+	return &SeedListSource{path: path, tag: tag}
+}
+
+// Name реализует Source
+func (s *SeedListSource) Name() string {
+	return s.tag
+}
+
+// Discover реализует Source
+func (s *SeedListSource) Discover(ctx context.Context, emit func(Finding) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open seed list: %w", err)
+	}
+	defer file.Close()
+
+	seeds, err := ParseSeedList(file)
+	if err != nil {
+		return err
+	}
+	for _, seed := range seeds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		seed.Metadata = map[string]interface{}{"seed_file": s.path}
+		if err := emit(seed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseSeedList разбирает список доменов. Разделитель CSV (запятая, точка с запятой или табуляция)
+// определяется по первой строке; строки, начинающиеся с #, пропускаются
+func ParseSeedList(r io.Reader) ([]Finding, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed list: %w", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = detectDelimiter(content)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse seed list: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	domainCol, titleCol := 0, -1
+	if header := records[0]; isSeedHeader(header) {
+		domainCol = columnIndex(header, seedDomainColumns)
+		titleCol = columnIndex(header, seedTitleColumns)
+		records = records[1:]
+	}
+
+	var seeds []Finding
+	for _, record := range records {
+		if domainCol >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[domainCol])
+		if value == "" {
+			continue
+		}
+		seed := Finding{Domain: DomainFromURL(value)}
+		if strings.Contains(value, "/") {
+			seed.URL = value
+		}
+		if titleCol >= 0 && titleCol < len(record) {
+			seed.Title = strings.TrimSpace(record[titleCol])
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, nil
+}
+
+// detectDelimiter выбирает разделитель CSV по первой значимой строке
+func detectDelimiter(content string) rune {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, delimiter := range []rune{'\t', ';', ','} {
+			if strings.ContainsRune(line, delimiter) {
+				return delimiter
+			}
+		}
+		break
+	}
+	return ','
+}
+
+// isSeedHeader проверяет, что первая строка - заголовок со столбцом домена
+func isSeedHeader(record []string) bool {
+	return columnIndex(record, seedDomainColumns) >= 0
+}
+
+// columnIndex ищет столбец по одному из названий
+func columnIndex(header []string, names []string) int {
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		for _, name := range names {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
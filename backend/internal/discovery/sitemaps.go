@@ -0,0 +1,140 @@
+package discovery
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/sitemap"
+)
+
+const (
+	// maxSitemapEntries столько адресов sitemap просматривается на домен
+	maxSitemapEntries = 5000
+	// maxSampleURLs столько примеров страниц товаров и каталога сохраняется в метаданных
+	maxSampleURLs = 5
+)
+
+var (
+	productPathRegex = regexp.MustCompile(`(?i)/(proizvod[a-z]*|product[s]?|artik[a-z]*|item[s]?|p)/[^/]+`)
+	catalogPathRegex = regexp.MustCompile(`(?i)/(kategorij[a-z]*|categor[a-z]*|katalog|catalog|collections?|c)(/|$)`)
+)
+
+// SitemapSource читает sitemap.xml кандидатов (через robots.txt) и сохраняет примеры страниц товаров и каталога
+// в метаданных: по ним классификатор и авто-конфигурация не ищут товар на главной.
+// Домены без страниц товаров и каталога пропускаются
+type SitemapSource struct {
+	client  *sitemap.Client
+	domains []string
+}
+
+// NewSitemapSource создаёт источник sitemap для списка доменов
+func NewSitemapSource(client *sitemap.Client, domains []string) *SitemapSource {
+	return &SitemapSource{client: client, domains: domains}
+}
+
+// Name реализует Source
+func (s *SitemapSource) Name() string {
+	return "sitemap"
+}
+
+// Discover реализует Source. Домены без sitemap пропускаются
+func (s *SitemapSource) Discover(ctx context.Context, emit func(Finding) error) error {
+	for _, domain := range s.domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		finding, ok := s.inspect(ctx, domain)
+		if !ok {
+			continue
+		}
+		if err := emit(finding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inspect собирает примеры страниц товаров и каталога домена
+func (s *SitemapSource) inspect(ctx context.Context, domain string) (Finding, bool) {
+	domain = DomainFromURL(domain)
+	if domain == "" {
+		return Finding{}, false
+	}
+	sitemaps, err := s.client.Locate(ctx, "https://"+domain)
+	if err != nil {
+		return Finding{}, false
+	}
+
+	var products, catalog []string
+	total := 0
+	_ = s.client.Walk(ctx, sitemaps, func(entry sitemap.Entry) error {
+		total++
+		// Адреса с других доменов (CDN, сети магазинов) к кандидату не относятся
+		if DomainFromURL(entry.Loc) != domain {
+			return nil
+		}
+		switch classifyURL(entry.Loc, entry.Sitemap) {
+		case urlProduct:
+			if len(products) < maxSampleURLs {
+				products = append(products, entry.Loc)
+			}
+		case urlCatalog:
+			if len(catalog) < maxSampleURLs {
+				catalog = append(catalog, entry.Loc)
+			}
+		}
+		if total >= maxSitemapEntries || (len(products) == maxSampleURLs && len(catalog) == maxSampleURLs) {
+			return sitemap.ErrStop
+		}
+		return nil
+	})
+
+	if len(products) == 0 && len(catalog) == 0 {
+		return Finding{}, false
+	}
+
+	metadata := map[string]interface{}{"sitemaps": sitemaps}
+	if len(products) > 0 {
+		metadata["product_urls"] = products
+	}
+	if len(catalog) > 0 {
+		metadata["catalog_urls"] = catalog
+	}
+	return Finding{Domain: domain, Metadata: metadata, Update: true}, true
+}
+
+type urlKind int
+
+const (
+	urlOther urlKind = iota
+	urlProduct
+	urlCatalog
+)
+
+// classifyURL относит адрес к товарам или каталогу по пути и по имени файла sitemap (sitemap-products.xml)
+func classifyURL(loc, sitemapURL string) urlKind {
+	parsed, err := url.Parse(loc)
+	if err != nil {
+		return urlOther
+	}
+	path := parsed.Path
+
+	source := strings.ToLower(sitemapURL)
+	switch {
+	case productPathRegex.MatchString(path):
+		return urlProduct
+	case catalogPathRegex.MatchString(path):
+		return urlCatalog
+	case strings.Contains(source, "product") || strings.Contains(source, "proizvod"):
+		if strings.Trim(path, "/") != "" {
+			return urlProduct
+		}
+	case strings.Contains(source, "categor") || strings.Contains(source, "kategor"):
+		if strings.Trim(path, "/") != "" {
+			return urlCatalog
+		}
+	}
+	return urlOther
+}
@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/sitemap"
+)
+
+func TestOutboundLinksSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>
+			<a href="/kategorija/tv">TV</a>
+			<a href="https://www.facebook.com/shop">Facebook</a>
+			<a href="https://partner-shop.rs/?utm=1">Partner   shop</a>
+			<a href="https://partner-shop.rs/akcije">Partner again</a>
+			<a href="https://supplier.com/">Supplier</a>
+			<a href="mailto:info@shop.rs">Mail</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	source := NewOutboundLinksSource(knownShops{server.URL}, server.Client(), "IzboratorBot", []string{".rs"})
+	var found []Finding
+	err := source.Discover(context.Background(), func(f Finding) error {
+		found = append(found, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected only partner-shop.rs, got %+v", found)
+	}
+	if found[0].Domain != "partner-shop.rs" || found[0].Title != "Partner shop" || found[0].Metadata["referrer"] != server.URL {
+		t.Errorf("unexpected finding %+v", found[0])
+	}
+}
+
+func TestSitemapSource(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("Sitemap: https://example.com/sitemap-products.xml\n"))
+		case "/sitemap-products.xml":
+			_, _ = w.Write([]byte(`<urlset>
+				<url><loc>https://example.com/</loc></url>
+				<url><loc>https://example.com/kategorija/televizori</loc></url>
+				<url><loc>https://example.com/samsung-qe55-tv</loc></url>
+				<url><loc>https://cdn.example.net/feed.xml</loc></url>
+			</urlset>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Все домены ведут на тестовый сервер; сертификат httptest выдан на example.com
+	client := server.Client()
+	transport := client.Transport.(*http.Transport)
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+
+	source := NewSitemapSource(sitemap.New(client, "IzboratorBot"), []string{"www.example.com", "example.org"})
+	var found []Finding
+	err := source.Discover(context.Background(), func(f Finding) error {
+		found = append(found, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(found) != 1 || found[0].Domain != "example.com" || !found[0].Update {
+		t.Fatalf("unexpected findings %+v", found)
+	}
+
+	products, _ := found[0].Metadata["product_urls"].([]string)
+	catalog, _ := found[0].Metadata["catalog_urls"].([]string)
+	if len(products) != 1 || products[0] != "https://example.com/samsung-qe55-tv" {
+		t.Errorf("unexpected product urls %v", products)
+	}
+	if len(catalog) != 1 || catalog[0] != "https://example.com/kategorija/televizori" {
+		t.Errorf("unexpected catalog urls %v", catalog)
+	}
+}
@@ -57,6 +57,29 @@ const (
 
 	// Ошибки городов
 	CodeCityNotFound = "CITY_NOT_FOUND"
+
+	// Ошибки проверки сопоставлений
+	CodeMatchNotFound        = "MATCH_NOT_FOUND"
+	CodeMatchAlreadyReviewed = "MATCH_ALREADY_REVIEWED"
+
+	// Ошибки селекторов магазинов
+	CodeShopNotFound           = "SHOP_NOT_FOUND"
+	CodeNoPreviousSelectors    = "NO_PREVIOUS_SELECTORS"
+	CodeSelectorVersionMissing = "SELECTOR_VERSION_NOT_FOUND"
+	CodeSelectorsUnchanged     = "SELECTORS_UNCHANGED"
+
+	// Ошибки конвейера кандидатов в магазины
+	CodeCandidateNotFound      = "CANDIDATE_NOT_FOUND"
+	CodeInvalidTransition      = "INVALID_CANDIDATE_TRANSITION"
+	CodeCandidateNotConfigured = "CANDIDATE_NOT_CONFIGURED"
+
+	// Ошибки сопоставлений категорий магазинов
+	CodeCategoryMappingNotFound = "CATEGORY_MAPPING_NOT_FOUND"
+
+	// Ошибки справочника брендов
+	CodeBrandNotFound      = "BRAND_NOT_FOUND"
+	CodeBrandAliasNotFound = "BRAND_ALIAS_NOT_FOUND"
+	CodeBrandConflict      = "BRAND_CONFLICT"
 )
 
 // NewAppError создает новую ошибку приложения
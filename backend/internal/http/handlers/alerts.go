@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/alerts"
+	"github.com/solomonczyk/izborator/internal/cities"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// alertOwnerTokenHeader заголовок с токеном владельца подписок
+const alertOwnerTokenHeader = "X-Alert-Token"
+
+// CreateAlertRequest тело запроса на создание подписки
+type CreateAlertRequest struct {
+	ProductID   string  `json:"product_id" validate:"required,uuid"`
+	City        string  `json:"city" validate:"omitempty,max=100"` // slug города (опционально)
+	TargetPrice float64 `json:"target_price" validate:"gt=0"`
+	Currency    string  `json:"currency" validate:"omitempty,len=3"`
+	Channel     string  `json:"channel" validate:"required,oneof=webhook email"`
+	Target      string  `json:"target" validate:"required,max=500"`
+}
+
+// AlertsHandler обработчик для подписок на снижение цены
+type AlertsHandler struct {
+	*BaseHandler
+	service   *alerts.Service
+	citiesSvc *cities.Service
+}
+
+// NewAlertsHandler создаёт новый обработчик подписок
+func NewAlertsHandler(service *alerts.Service, citiesSvc *cities.Service, log *logger.Logger, translator *i18n.Translator) *AlertsHandler {
+	return &AlertsHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+		citiesSvc:   citiesSvc,
+	}
+}
+
+// Create создаёт подписку на снижение цены
+// POST /api/v1/alerts
+// Без заголовка X-Alert-Token выдаётся новый токен владельца (owner_token в ответе)
+func (h *AlertsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+	req.City = validation.SanitizeString(req.City)
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	sub := &alerts.Subscription{
+		ProductID:   req.ProductID,
+		TargetPrice: req.TargetPrice,
+		Currency:    req.Currency,
+		Channel:     alerts.Channel(req.Channel),
+		Target:      req.Target,
+	}
+
+	if req.City != "" {
+		city, err := h.citiesSvc.GetBySlug(req.City)
+		if err != nil {
+			appErr := appErrors.NewAppError(appErrors.CodeCityNotFound, "City not found", http.StatusNotFound, err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		sub.CityID = &city.ID
+	}
+
+	created, err := h.service.Subscribe(r.Context(), alertOwnerToken(r), sub)
+	if err != nil {
+		if errors.Is(err, alerts.ErrInvalidSubscription) || errors.Is(err, alerts.ErrUnsupportedChannel) {
+			appErr := appErrors.NewValidationError(err.Error(), err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		appErr := appErrors.NewInternalError("Failed to create alert", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	h.RespondJSON(w, http.StatusCreated, created)
+}
+
+// List возвращает подписки владельца токена
+// GET /api/v1/alerts (X-Alert-Token: <token>)
+func (h *AlertsHandler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListByOwner(r.Context(), alertOwnerToken(r))
+	if err != nil {
+		h.respondAlertError(w, r, err, "Failed to list alerts")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"items": subs,
+	})
+}
+
+// Get возвращает подписку по ID
+// GET /api/v1/alerts/{id} (X-Alert-Token: <token>)
+func (h *AlertsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid alert ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	sub, err := h.service.GetSubscription(r.Context(), alertOwnerToken(r), id)
+	if err != nil {
+		h.respondAlertError(w, r, err, "Failed to load alert")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, sub)
+}
+
+// Delete удаляет подписку
+// DELETE /api/v1/alerts/{id} (X-Alert-Token: <token>)
+func (h *AlertsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid alert ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	if err := h.service.Unsubscribe(r.Context(), alertOwnerToken(r), id); err != nil {
+		h.respondAlertError(w, r, err, "Failed to delete alert")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondAlertError отдаёт ошибку сервиса подписок с подходящим HTTP статусом
+func (h *AlertsHandler) respondAlertError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, alerts.ErrOwnerTokenRequired):
+		h.RespondAppError(w, r, appErrors.NewAppError(appErrors.CodeUnauthorized, "X-Alert-Token header is required", http.StatusUnauthorized, err))
+	case errors.Is(err, alerts.ErrSubscriptionNotFound):
+		h.RespondAppError(w, r, appErrors.NewNotFound("Alert not found"))
+	default:
+		h.RespondAppError(w, r, appErrors.NewInternalError(message, err))
+	}
+}
+
+// alertOwnerToken токен владельца подписок из заголовка запроса
+func alertOwnerToken(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(alertOwnerTokenHeader))
+}
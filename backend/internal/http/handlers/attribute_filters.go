@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+	"github.com/solomonczyk/izborator/internal/products"
+)
+
+// attributeParamPrefix префикс параметров фильтра и сортировки по атрибутам: attr.ram=8..16, sort=attr.ram_desc
+const attributeParamPrefix = "attr."
+
+// hasAttributeParams есть ли в запросе фильтры или сортировка по атрибутам
+func hasAttributeParams(q url.Values, sortParam string) bool {
+	if strings.HasPrefix(sortParam, attributeParamPrefix) {
+		return true
+	}
+	for key := range q {
+		if strings.HasPrefix(key, attributeParamPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttributeFilters разбирает параметры attr.<code>=... для фильтруемых атрибутов справочника:
+// числа - значение или диапазон "min..max" ("8..", "..16"), bool - true/false, строки - значения через запятую
+func parseAttributeFilters(q url.Values, attrs []*attributes.Attribute) ([]products.AttributeFilter, error) {
+	byCode := attributesByCode(attrs)
+
+	keys := make([]string, 0)
+	for key := range q {
+		if strings.HasPrefix(key, attributeParamPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	filters := make([]products.AttributeFilter, 0, len(keys))
+	for _, key := range keys {
+		code := strings.ToLower(strings.TrimPrefix(key, attributeParamPrefix))
+		attr, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute filter: %s", key)
+		}
+		if !attr.IsFilterable {
+			return nil, fmt.Errorf("attribute %s is not filterable", code)
+		}
+
+		raw := strings.TrimSpace(q.Get(key))
+		if raw == "" {
+			continue
+		}
+
+		filter := products.AttributeFilter{AttributeID: attr.ID, Code: code}
+		switch attr.DataType {
+		case attributes.DataTypeInt, attributes.DataTypeFloat:
+			low, high, err := parseAttributeRange(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number or range min..max", key)
+			}
+			filter.Min, filter.Max = low, high
+		case attributes.DataTypeBool:
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", key)
+			}
+			filter.Bool = &value
+		default:
+			for _, value := range strings.Split(raw, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					filter.Values = append(filter.Values, value)
+				}
+			}
+			if len(filter.Values) == 0 {
+				continue
+			}
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// parseAttributeRange разбирает "8", "8..16", "8.." и "..16"; границы меняются местами, если нижняя больше верхней
+func parseAttributeRange(raw string) (*float64, *float64, error) {
+	parse := func(value string) (*float64, error) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, nil
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &number, nil
+	}
+
+	from, to, isRange := strings.Cut(raw, "..")
+	low, err := parse(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isRange {
+		if low == nil {
+			return nil, nil, fmt.Errorf("empty value")
+		}
+		return low, low, nil
+	}
+	high, err := parse(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if low == nil && high == nil {
+		return nil, nil, fmt.Errorf("empty range")
+	}
+	if low != nil && high != nil && *low > *high {
+		low, high = high, low
+	}
+	return low, high, nil
+}
+
+// parseAttributeSort разбирает sort=attr.<code>_asc|_desc (без суффикса - по возрастанию)
+func parseAttributeSort(sortParam string, attrs []*attributes.Attribute) (*products.AttributeSort, error) {
+	value := strings.ToLower(strings.TrimPrefix(sortParam, attributeParamPrefix))
+	desc := false
+	switch {
+	case strings.HasSuffix(value, "_desc"):
+		value, desc = strings.TrimSuffix(value, "_desc"), true
+	case strings.HasSuffix(value, "_asc"):
+		value = strings.TrimSuffix(value, "_asc")
+	}
+
+	attr, ok := attributesByCode(attrs)[value]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort attribute: %s", value)
+	}
+	if !attr.IsSortable {
+		return nil, fmt.Errorf("attribute %s is not sortable", value)
+	}
+	return &products.AttributeSort{AttributeID: attr.ID, Code: value, Desc: desc}, nil
+}
+
+// attributesByCode атрибуты по коду в нижнем регистре
+func attributesByCode(attrs []*attributes.Attribute) map[string]*attributes.Attribute {
+	byCode := make(map[string]*attributes.Attribute, len(attrs))
+	for _, attr := range attrs {
+		byCode[strings.ToLower(attr.Code)] = attr
+	}
+	return byCode
+}
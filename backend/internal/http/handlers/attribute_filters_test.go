@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+)
+
+func testFilterAttributes() []*attributes.Attribute {
+	return []*attributes.Attribute{
+		{ID: "ram-id", Code: "RAM", DataType: attributes.DataTypeInt, IsFilterable: true, IsSortable: true},
+		{ID: "color-id", Code: "COLOR", DataType: attributes.DataTypeString, IsFilterable: true},
+		{ID: "nfc-id", Code: "NFC", DataType: attributes.DataTypeBool, IsFilterable: true},
+		{ID: "cpu-id", Code: "CPU", DataType: attributes.DataTypeString},
+	}
+}
+
+func TestParseAttributeFilters(t *testing.T) {
+	q := url.Values{
+		"attr.ram":   {"16..8"},
+		"attr.color": {"Black, white,"},
+		"attr.nfc":   {"true"},
+		"query":      {"samsung"},
+	}
+
+	filters, err := parseAttributeFilters(q, testFilterAttributes())
+	if err != nil {
+		t.Fatalf("parseAttributeFilters failed: %v", err)
+	}
+	if len(filters) != 3 {
+		t.Fatalf("expected 3 filters, got %+v", filters)
+	}
+
+	// Ключи разбираются по алфавиту: color, nfc, ram
+	if filters[0].Code != "color" || len(filters[0].Values) != 2 || filters[0].Values[1] != "white" {
+		t.Errorf("unexpected color filter %+v", filters[0])
+	}
+	if filters[1].Bool == nil || !*filters[1].Bool {
+		t.Errorf("unexpected nfc filter %+v", filters[1])
+	}
+	ram := filters[2]
+	if ram.AttributeID != "ram-id" || *ram.Min != 8 || *ram.Max != 16 {
+		t.Errorf("range bounds must be swapped, got %+v", ram)
+	}
+}
+
+func TestParseAttributeFilters_Errors(t *testing.T) {
+	cases := map[string]url.Values{
+		"unknown attribute":  {"attr.weight": {"1"}},
+		"not filterable":     {"attr.cpu": {"snapdragon"}},
+		"not a number":       {"attr.ram": {"eight"}},
+		"empty range":        {"attr.ram": {".."}},
+		"bool not parseable": {"attr.nfc": {"maybe"}},
+	}
+	for name, q := range cases {
+		if _, err := parseAttributeFilters(q, testFilterAttributes()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseAttributeRange_OpenBounds(t *testing.T) {
+	low, high, err := parseAttributeRange("8..")
+	if err != nil || *low != 8 || high != nil {
+		t.Errorf("8..: got %v %v %v", low, high, err)
+	}
+	low, high, err = parseAttributeRange("..16")
+	if err != nil || low != nil || *high != 16 {
+		t.Errorf("..16: got %v %v %v", low, high, err)
+	}
+}
+
+func TestParseAttributeSort(t *testing.T) {
+	sortAttr, err := parseAttributeSort("attr.ram_desc", testFilterAttributes())
+	if err != nil || sortAttr.AttributeID != "ram-id" || sortAttr.Code != "ram" || !sortAttr.Desc {
+		t.Errorf("unexpected sort %+v (%v)", sortAttr, err)
+	}
+	if sortAttr, err := parseAttributeSort("attr.ram", testFilterAttributes()); err != nil || sortAttr.Desc {
+		t.Errorf("sort without suffix must be ascending, got %+v (%v)", sortAttr, err)
+	}
+	if _, err := parseAttributeSort("attr.color_asc", testFilterAttributes()); err == nil {
+		t.Error("non-sortable attribute must be rejected")
+	}
+}
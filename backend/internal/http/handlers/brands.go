@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/brands"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// SaveBrandRequest тело запроса на создание или изменение бренда
+type SaveBrandRequest struct {
+	Name           string   `json:"name" validate:"required,max=255"`
+	Slug           string   `json:"slug" validate:"omitempty,max=255"`
+	LogoURL        *string  `json:"logo_url" validate:"omitempty,url,max=1000"`
+	ManufacturerID *string  `json:"manufacturer_id" validate:"omitempty,uuid"`
+	Aliases        []string `json:"aliases" validate:"omitempty,max=50,dive,required,max=255"`
+}
+
+// AddBrandAliasRequest тело запроса на добавление синонима бренда
+type AddBrandAliasRequest struct {
+	Alias string `json:"alias" validate:"required,max=255"`
+}
+
+// BrandsHandler обработчик административного API справочника брендов
+type BrandsHandler struct {
+	*BaseHandler
+	service *brands.Service
+}
+
+// NewBrandsHandler создаёт новый обработчик справочника брендов
+func NewBrandsHandler(service *brands.Service, log *logger.Logger, translator *i18n.Translator) *BrandsHandler {
+	return &BrandsHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// List возвращает бренды справочника с синонимами
+// GET /api/admin/brands
+func (h *BrandsHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.List(r.Context())
+	if err != nil {
+		h.respondBrandError(w, r, err, "Failed to list brands")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"items": list,
+	})
+}
+
+// Create добавляет бренд в справочник
+// POST /api/admin/brands
+func (h *BrandsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	h.save(w, r, "")
+}
+
+// Update изменяет бренд; переданные синонимы добавляются к сохранённым
+// PUT /api/admin/brands/{id}
+func (h *BrandsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.brandID(w, r)
+	if !ok {
+		return
+	}
+	h.save(w, r, id)
+}
+
+// Delete удаляет бренд вместе с синонимами
+// DELETE /api/admin/brands/{id}
+func (h *BrandsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.brandID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		h.respondBrandError(w, r, err, "Failed to delete brand")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddAlias привязывает написание к бренду и переименовывает товары с этим написанием
+// POST /api/admin/brands/{id}/aliases
+func (h *BrandsHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.brandID(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddBrandAliasRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	renamed, err := h.service.AddAlias(r.Context(), id, req.Alias)
+	if err != nil {
+		h.respondBrandError(w, r, err, "Failed to add brand alias")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"alias":            req.Alias,
+		"renamed_products": renamed,
+	})
+}
+
+// RemoveAlias удаляет синоним бренда
+// DELETE /api/admin/brands/{id}/aliases/{alias}
+func (h *BrandsHandler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	alias := validation.SanitizeString(chi.URLParam(r, "alias"))
+	if err := h.service.RemoveAlias(r.Context(), alias); err != nil {
+		h.respondBrandError(w, r, err, "Failed to remove brand alias")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Proposals предлагает синонимы для написаний брендов из товаров по схожести
+// GET /api/admin/brands/proposals?min_similarity=0.8
+func (h *BrandsHandler) Proposals(w http.ResponseWriter, r *http.Request) {
+	minSimilarity := brands.DefaultMinSimilarity
+	if raw := r.URL.Query().Get("min_similarity"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 || value > 1 {
+			appErr := appErrors.NewValidationError("min_similarity must be in (0, 1]", err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		minSimilarity = value
+	}
+
+	proposals, err := h.service.Proposals(r.Context(), minSimilarity)
+	if err != nil {
+		h.respondBrandError(w, r, err, "Failed to propose brand aliases")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"items": proposals,
+	})
+}
+
+// save разбирает тело запроса и сохраняет бренд (пустой id - новый бренд)
+func (h *BrandsHandler) save(w http.ResponseWriter, r *http.Request, id string) {
+	var req SaveBrandRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	brand, err := h.service.Save(r.Context(), &brands.Brand{
+		ID:             id,
+		Name:           req.Name,
+		Slug:           req.Slug,
+		LogoURL:        req.LogoURL,
+		ManufacturerID: req.ManufacturerID,
+		Aliases:        req.Aliases,
+	})
+	if err != nil {
+		h.respondBrandError(w, r, err, "Failed to save brand")
+		return
+	}
+
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+	}
+	h.RespondJSON(w, status, brand)
+}
+
+// brandID разбирает ID бренда из пути
+func (h *BrandsHandler) brandID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := validation.SanitizeString(chi.URLParam(r, "id"))
+	if id == "" {
+		appErr := appErrors.NewValidationError("Brand ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return "", false
+	}
+	return id, true
+}
+
+// decodeBody разбирает и валидирует JSON тело запроса
+func (h *BrandsHandler) decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+	return true
+}
+
+// respondBrandError переводит ошибки сервиса в HTTP ответы
+func (h *BrandsHandler) respondBrandError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, brands.ErrBrandNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeBrandNotFound, "Brand not found", http.StatusNotFound, err)
+	case errors.Is(err, brands.ErrAliasNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeBrandAliasNotFound, "Brand alias not found", http.StatusNotFound, err)
+	case errors.Is(err, brands.ErrBrandConflict):
+		appErr = appErrors.NewAppError(appErrors.CodeBrandConflict, err.Error(), http.StatusConflict, err)
+	case errors.Is(err, brands.ErrInvalidBrand):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/candidates"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	httpMiddleware "github.com/solomonczyk/izborator/internal/http/middleware"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// CandidateDecisionRequest тело запроса на одобрение, перезапуск или запуск кандидата.
+// Автор решения берётся из токена администратора (AdminAuth), а не из тела запроса
+type CandidateDecisionRequest struct {
+	Note string `json:"note" validate:"omitempty,max=2000"`
+}
+
+// RejectCandidateRequest тело запроса на отклонение кандидата
+type RejectCandidateRequest struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// CandidatesHandler обработчик внутреннего API конвейера кандидатов в магазины
+type CandidatesHandler struct {
+	*BaseHandler
+	service *candidates.Service
+}
+
+// NewCandidatesHandler создаёт новый обработчик кандидатов
+func NewCandidatesHandler(service *candidates.Service, log *logger.Logger, translator *i18n.Translator) *CandidatesHandler {
+	return &CandidatesHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// List возвращает кандидатов, при необходимости с фильтром по статусу
+// GET /api/internal/candidates?status=configured&limit=50&offset=0
+func (h *CandidatesHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := candidates.ListFilter{
+		Status: candidates.Status(validation.SanitizeString(query.Get("status"))),
+		Limit:  h.ParseIntParam(query.Get("limit"), 0),
+		Offset: h.ParseIntParamUnsigned(query.Get("offset"), 0),
+	}
+
+	list, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to list candidates")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, list)
+}
+
+// Get возвращает кандидата с историей переходов
+// GET /api/internal/candidates/{id}
+func (h *CandidatesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.candidateID(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to load candidate")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, details)
+}
+
+// Approve создаёт из сконфигурированного кандидата неактивный магазин
+// POST /api/internal/candidates/{id}/approve
+func (h *CandidatesHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	candidate, err := h.service.Approve(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Note)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to approve candidate")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, candidate)
+}
+
+// Reject отклоняет кандидата
+// POST /api/internal/candidates/{id}/reject
+func (h *CandidatesHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.candidateID(w, r)
+	if !ok {
+		return
+	}
+
+	var req RejectCandidateRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	candidate, err := h.service.Reject(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Reason)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to reject candidate")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, candidate)
+}
+
+// Retry возвращает неудачного или отклонённого кандидата в очередь авто-конфигурации
+// POST /api/internal/candidates/{id}/retry
+func (h *CandidatesHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	candidate, err := h.service.Retry(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Note)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to retry candidate")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, candidate)
+}
+
+// GoLive активирует магазин одобренного кандидата
+// POST /api/internal/candidates/{id}/live
+func (h *CandidatesHandler) GoLive(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	candidate, err := h.service.GoLive(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Note)
+	if err != nil {
+		h.respondCandidateError(w, r, err, "Failed to activate candidate shop")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, candidate)
+}
+
+// candidateID разбирает ID кандидата из пути
+func (h *CandidatesHandler) candidateID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := validation.SanitizeString(chi.URLParam(r, "id"))
+	if id == "" {
+		appErr := appErrors.NewValidationError("Candidate ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return "", false
+	}
+	return id, true
+}
+
+// decodeDecision разбирает ID кандидата и тело решения администратора
+func (h *CandidatesHandler) decodeDecision(w http.ResponseWriter, r *http.Request) (string, *CandidateDecisionRequest, bool) {
+	id, ok := h.candidateID(w, r)
+	if !ok {
+		return "", nil, false
+	}
+
+	var req CandidateDecisionRequest
+	if !h.decodeBody(w, r, &req) {
+		return "", nil, false
+	}
+	return id, &req, true
+}
+
+// decodeBody разбирает и валидирует JSON тело запроса
+func (h *CandidatesHandler) decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+	return true
+}
+
+// respondCandidateError переводит ошибки сервиса в HTTP ответы
+func (h *CandidatesHandler) respondCandidateError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, candidates.ErrCandidateNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeCandidateNotFound, "Candidate not found", http.StatusNotFound, err)
+	case errors.Is(err, candidates.ErrInvalidTransition):
+		appErr = appErrors.NewAppError(appErrors.CodeInvalidTransition, err.Error(), http.StatusConflict, err)
+	case errors.Is(err, candidates.ErrNoConfig):
+		appErr = appErrors.NewAppError(appErrors.CodeCandidateNotConfigured, "Candidate has no validated selectors", http.StatusConflict, err)
+	case errors.Is(err, candidates.ErrInvalidRequest):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/categorizer"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// SaveCategoryMappingRequest тело запроса на сохранение сопоставления категории магазина
+type SaveCategoryMappingRequest struct {
+	SourceCategory string  `json:"source_category" validate:"required,max=500"`
+	CategoryID     string  `json:"category_id" validate:"required,uuid"`
+	ProductTypeID  *string `json:"product_type_id" validate:"omitempty,uuid"`
+	Confidence     float64 `json:"confidence" validate:"omitempty,gte=0,lte=1"`
+}
+
+// PreviewCategoryRequest тело запроса на проверку определения категории
+type PreviewCategoryRequest struct {
+	ShopID   string `json:"shop_id" validate:"omitempty,max=255"`
+	Category string `json:"category" validate:"omitempty,max=500"`
+	Name     string `json:"name" validate:"omitempty,max=500"`
+}
+
+// CategoryMappingsHandler обработчик административного API сопоставлений категорий магазинов
+type CategoryMappingsHandler struct {
+	*BaseHandler
+	service *categorizer.Service
+}
+
+// NewCategoryMappingsHandler создаёт новый обработчик сопоставлений категорий
+func NewCategoryMappingsHandler(service *categorizer.Service, log *logger.Logger, translator *i18n.Translator) *CategoryMappingsHandler {
+	return &CategoryMappingsHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// List возвращает сопоставления категорий магазина
+// GET /api/admin/shops/{id}/category-mappings
+func (h *CategoryMappingsHandler) List(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	mappings, err := h.service.ListMappings(r.Context(), shopID)
+	if err != nil {
+		h.respondMappingError(w, r, err, "Failed to list category mappings")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"items": mappings,
+	})
+}
+
+// Save создаёт или обновляет сопоставление категории магазина с категорией справочника
+// POST /api/admin/shops/{id}/category-mappings
+func (h *CategoryMappingsHandler) Save(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	var req SaveCategoryMappingRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	mapping, err := h.service.SaveMapping(r.Context(), &categorizer.ShopMapping{
+		ShopID:         shopID,
+		SourceCategory: req.SourceCategory,
+		CategoryID:     req.CategoryID,
+		ProductTypeID:  req.ProductTypeID,
+		Confidence:     req.Confidence,
+	})
+	if err != nil {
+		h.respondMappingError(w, r, err, "Failed to save category mapping")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, mapping)
+}
+
+// Delete удаляет сопоставление категории магазина
+// DELETE /api/admin/shops/{id}/category-mappings/{mappingID}
+func (h *CategoryMappingsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	mappingID := validation.SanitizeString(chi.URLParam(r, "mappingID"))
+	if mappingID == "" {
+		appErr := appErrors.NewValidationError("Mapping ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	if err := h.service.DeleteMapping(r.Context(), mappingID); err != nil {
+		h.respondMappingError(w, r, err, "Failed to delete category mapping")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Preview показывает, какую категорию получит товар, без записи
+// POST /api/admin/categorizer/preview
+func (h *CategoryMappingsHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req PreviewCategoryRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := h.service.Categorize(r.Context(), &categorizer.Input{
+		ShopID:   req.ShopID,
+		Category: req.Category,
+		Name:     req.Name,
+	})
+	if err != nil {
+		h.respondMappingError(w, r, err, "Failed to categorize product")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"source_category": categorizer.NormalizePath(req.Category),
+		"result":          result,
+	})
+}
+
+// shopID разбирает ID магазина из пути
+func (h *CategoryMappingsHandler) shopID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	shopID := validation.SanitizeString(chi.URLParam(r, "id"))
+	if shopID == "" {
+		appErr := appErrors.NewValidationError("Shop ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return "", false
+	}
+	return shopID, true
+}
+
+// decodeBody разбирает и валидирует JSON тело запроса
+func (h *CategoryMappingsHandler) decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+	return true
+}
+
+// respondMappingError переводит ошибки сервиса в HTTP ответы
+func (h *CategoryMappingsHandler) respondMappingError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, categorizer.ErrMappingNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeCategoryMappingNotFound, "Category mapping not found", http.StatusNotFound, err)
+	case errors.Is(err, categorizer.ErrInvalidMapping):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/attributes"
 	"github.com/solomonczyk/izborator/internal/categories"
 	"github.com/solomonczyk/izborator/internal/cities"
 	"github.com/solomonczyk/izborator/internal/i18n"
@@ -41,12 +42,14 @@ func setupTestServer(t *testing.T) (*httptest.Server, *storage.Postgres, func())
 	priceHistoryStorage := storage.NewPriceHistoryAdapter(pg)
 	categoriesStorage := storage.NewCategoriesAdapter(pg)
 	citiesStorage := storage.NewCitiesAdapter(pg)
+	attributesStorage := storage.NewAttributesAdapter(pg)
 
 	// Сервисы
-	productsService := products.New(productsStorage, log)
+	productsService := products.New(productsStorage, nil, log)
 	priceHistoryService := pricehistory.New(priceHistoryStorage, log)
 	categoriesService := categories.New(categoriesStorage, log)
 	citiesService := cities.New(citiesStorage, log)
+	attributesService := attributes.New(attributesStorage, log)
 
 	// Handler
 	handler := NewProductsHandler(
@@ -54,6 +57,7 @@ func setupTestServer(t *testing.T) (*httptest.Server, *storage.Postgres, func())
 		priceHistoryService,
 		categoriesService,
 		citiesService,
+		attributesService,
 		log,
 		translator,
 	)
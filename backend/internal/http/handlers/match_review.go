@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	httpMiddleware "github.com/solomonczyk/izborator/internal/http/middleware"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/matchreview"
+)
+
+// ReviewDecisionRequest тело запроса на одобрение/отклонение сопоставления.
+// Автор решения берётся из токена администратора (AdminAuth), а не из тела запроса
+type ReviewDecisionRequest struct {
+	Note string `json:"note" validate:"omitempty,max=2000"`
+}
+
+// MergeProductsRequest тело запроса на слияние товаров
+type MergeProductsRequest struct {
+	SourceID string `json:"source_id" validate:"required,uuid"`
+	TargetID string `json:"target_id" validate:"required,uuid,nefield=SourceID"`
+	Note     string `json:"note" validate:"omitempty,max=2000"`
+}
+
+// SplitProductRequest тело запроса на разделение товара
+type SplitProductRequest struct {
+	ShopIDs []string `json:"shop_ids" validate:"required,min=1,dive,required,max=255"`
+	Name    string   `json:"name" validate:"omitempty,max=500"`
+	Note    string   `json:"note" validate:"omitempty,max=2000"`
+}
+
+// MatchReviewHandler обработчик административного API проверки сопоставлений
+type MatchReviewHandler struct {
+	*BaseHandler
+	service *matchreview.Service
+}
+
+// NewMatchReviewHandler создаёт новый обработчик проверки сопоставлений
+func NewMatchReviewHandler(service *matchreview.Service, log *logger.Logger, translator *i18n.Translator) *MatchReviewHandler {
+	return &MatchReviewHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// ListPending возвращает очередь непроверенных сопоставлений
+// GET /api/admin/matches?confidence=medium|low&limit=50&offset=0
+func (h *MatchReviewHandler) ListPending(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := matchreview.ListFilter{
+		Confidence: validation.SanitizeString(query.Get("confidence")),
+		Limit:      h.ParseIntParam(query.Get("limit"), 0),
+		Offset:     h.ParseIntParamUnsigned(query.Get("offset"), 0),
+	}
+
+	list, err := h.service.ListPending(r.Context(), filter)
+	if err != nil {
+		h.respondReviewError(w, r, err, "Failed to list pending matches")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, list)
+}
+
+// Approve одобряет сопоставление и сливает дубликат с найденным товаром
+// POST /api/admin/matches/{id}/approve
+func (h *MatchReviewHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Approve(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Note)
+	if err != nil {
+		h.respondReviewError(w, r, err, "Failed to approve match")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, result)
+}
+
+// Reject отклоняет сопоставление
+// POST /api/admin/matches/{id}/reject
+func (h *MatchReviewHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeDecision(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Reject(r.Context(), id, httpMiddleware.AdminActor(r.Context()), req.Note); err != nil {
+		h.respondReviewError(w, r, err, "Failed to reject match")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Merge сливает два канонических товара
+// POST /api/admin/products/merge
+func (h *MatchReviewHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	var req MergeProductsRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := h.service.Merge(r.Context(), req.SourceID, req.TargetID, httpMiddleware.AdminActor(r.Context()), req.Note)
+	if err != nil {
+		h.respondReviewError(w, r, err, "Failed to merge products")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, result)
+}
+
+// Split выделяет предложения магазинов в новый товар
+// POST /api/admin/products/{id}/split
+func (h *MatchReviewHandler) Split(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid product ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	var req SplitProductRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	result, err := h.service.Split(r.Context(), &matchreview.SplitRequest{
+		ProductID: id,
+		ShopIDs:   req.ShopIDs,
+		Name:      req.Name,
+		Actor:     httpMiddleware.AdminActor(r.Context()),
+		Note:      req.Note,
+	})
+	if err != nil {
+		h.respondReviewError(w, r, err, "Failed to split product")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusCreated, result)
+}
+
+// Audit возвращает журнал решений по товару
+// GET /api/admin/products/{id}/audit
+func (h *MatchReviewHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid product ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	entries, err := h.service.ListAudit(r.Context(), id)
+	if err != nil {
+		h.respondReviewError(w, r, err, "Failed to load audit log")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id": id,
+		"items":      entries,
+	})
+}
+
+// decodeDecision разбирает ID сопоставления и тело решения
+func (h *MatchReviewHandler) decodeDecision(w http.ResponseWriter, r *http.Request) (string, *ReviewDecisionRequest, bool) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid match ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return "", nil, false
+	}
+
+	var req ReviewDecisionRequest
+	if !h.decodeBody(w, r, &req) {
+		return "", nil, false
+	}
+	return id, &req, true
+}
+
+// decodeBody разбирает и валидирует JSON тело запроса
+func (h *MatchReviewHandler) decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+	return true
+}
+
+// respondReviewError переводит ошибки сервиса в HTTP ответы
+func (h *MatchReviewHandler) respondReviewError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, matchreview.ErrMatchNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeMatchNotFound, "Match not found", http.StatusNotFound, err)
+	case errors.Is(err, matchreview.ErrMatchNotPending):
+		appErr = appErrors.NewAppError(appErrors.CodeMatchAlreadyReviewed, "Match already reviewed", http.StatusConflict, err)
+	case errors.Is(err, matchreview.ErrProductNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeProductNotFound, "Product not found", http.StatusNotFound, err)
+	case errors.Is(err, matchreview.ErrInvalidRequest), errors.Is(err, matchreview.ErrInvalidSplit):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/solomonczyk/izborator/internal/attributes"
 	"github.com/solomonczyk/izborator/internal/categories"
 	"github.com/solomonczyk/izborator/internal/cities"
 	"github.com/solomonczyk/izborator/internal/domainpack"
@@ -37,13 +38,15 @@ type BrowseRequest struct {
 	MaxPrice *float64 `json:"max_price" validate:"omitempty,gte=0"`
 	Page     int      `json:"page" validate:"gte=1"`
 	PerPage  int      `json:"per_page" validate:"gte=1,lte=100"`
-	Sort     string   `json:"sort" validate:"omitempty,oneof=price_asc price_desc name_asc name_desc newest"`
+	Sort     string   `json:"sort" validate:"omitempty,oneof=price_asc price_desc unit_price_asc unit_price_desc name_asc name_desc newest"`
 }
 
 type FacetSchemaResponse struct {
 	Domain   string                      `json:"domain"`
 	TenantID string                      `json:"tenant_id"`
 	Facets   []domainpack.FacetDefinition `json:"facets"`
+	// Attributes значения атрибутов типов товаров категории (только при category=<slug>)
+	Attributes []*products.AttributeFacet `json:"attributes,omitempty"`
 }
 
 type TenantHealthResponse struct {
@@ -171,16 +174,18 @@ type ProductsHandler struct {
 	priceHistorySvc *pricehistory.Service
 	categoriesSvc   *categories.Service
 	citiesSvc       *cities.Service
+	attributesSvc   *attributes.Service
 }
 
 // NewProductsHandler создаёт новый обработчик товаров
-func NewProductsHandler(service *products.Service, priceHistorySvc *pricehistory.Service, categoriesSvc *categories.Service, citiesSvc *cities.Service, log *logger.Logger, translator *i18n.Translator) *ProductsHandler {
+func NewProductsHandler(service *products.Service, priceHistorySvc *pricehistory.Service, categoriesSvc *categories.Service, citiesSvc *cities.Service, attributesSvc *attributes.Service, log *logger.Logger, translator *i18n.Translator) *ProductsHandler {
 	return &ProductsHandler{
 		BaseHandler:     NewBaseHandler(log, translator),
 		service:         service,
 		priceHistorySvc: priceHistorySvc,
 		categoriesSvc:   categoriesSvc,
 		citiesSvc:       citiesSvc,
+		attributesSvc:   attributesSvc,
 	}
 }
 
@@ -349,6 +354,42 @@ func (h *ProductsHandler) GetPrices(w http.ResponseWriter, r *http.Request) {
 	h.RespondJSON(w, http.StatusOK, result)
 }
 
+// GetOffers возвращает предложения товара, ранжированные по итоговой цене с доставкой
+// GET /api/v1/products/{id}/offers?city=beograd
+func (h *ProductsHandler) GetOffers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateUUID(id); err != nil {
+		appErr := appErrors.NewValidationError("Invalid product ID format", err)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	var cityID *string
+	if city := validation.SanitizeString(r.URL.Query().Get("city")); city != "" {
+		cityObj, err := h.citiesSvc.GetBySlug(city)
+		if err != nil {
+			appErr := appErrors.NewAppError(appErrors.CodeCityNotFound, "City not found", http.StatusNotFound, err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		cityID = &cityObj.ID
+	}
+
+	offers, err := h.service.GetOffers(r.Context(), id, cityID)
+	if err != nil {
+		var appErr *appErrors.AppError
+		if err == products.ErrInvalidProductID {
+			appErr = appErrors.NewValidationError("Invalid product ID", err)
+		} else {
+			appErr = appErrors.NewInternalError("Failed to get offers", err)
+		}
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, offers)
+}
+
 // GetPriceHistory обрабатывает получение истории цен товара
 // GET /api/v1/products/{id}/price-history?period=month&shops=shop1,shop2
 func (h *ProductsHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
@@ -399,13 +440,14 @@ func (h *ProductsHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request
 	stats := calculatePriceStats(chart)
 
 	result := map[string]interface{}{
-		"product_id": id,
-		"period":     period,
-		"from":       chart.From.Format(time.RFC3339),
-		"to":         chart.To.Format(time.RFC3339),
-		"shops":      chart.Shops,
-		"shop_names": chart.ShopNames,
-		"stats":      stats,
+		"product_id":  id,
+		"period":      period,
+		"granularity": chart.Granularity,
+		"from":        chart.From.Format(time.RFC3339),
+		"to":          chart.To.Format(time.RFC3339),
+		"shops":       chart.Shops,
+		"shop_names":  chart.ShopNames,
+		"stats":       stats,
 	}
 
 	h.RespondJSON(w, http.StatusOK, result)
@@ -432,12 +474,28 @@ func calculatePriceStats(chart *pricehistory.PriceChart) PriceStats {
 	var allPrices []float64
 	var firstPrice, lastPrice float64
 	var firstDate, lastDate time.Time
+	minPrice, maxPrice := 0.0, 0.0
 
 	// Собираем все цены из всех магазинов
+	// Точки агрегатов несут min/max за интервал, которые шире цены закрытия
 	for _, points := range chart.Shops {
 		for _, point := range points {
 			allPrices = append(allPrices, point.Price)
 
+			low, high := point.Price, point.Price
+			if point.MinPrice > 0 && point.MinPrice < low {
+				low = point.MinPrice
+			}
+			if point.MaxPrice > high {
+				high = point.MaxPrice
+			}
+			if len(allPrices) == 1 || low < minPrice {
+				minPrice = low
+			}
+			if len(allPrices) == 1 || high > maxPrice {
+				maxPrice = high
+			}
+
 			if firstDate.IsZero() || point.Timestamp.Before(firstDate) {
 				firstDate = point.Timestamp
 				firstPrice = point.Price
@@ -453,18 +511,8 @@ func calculatePriceStats(chart *pricehistory.PriceChart) PriceStats {
 		return PriceStats{}
 	}
 
-	// Находим мин/макс
-	minPrice := allPrices[0]
-	maxPrice := allPrices[0]
 	sum := 0.0
-
 	for _, price := range allPrices {
-		if price < minPrice {
-			minPrice = price
-		}
-		if price > maxPrice {
-			maxPrice = price
-		}
 		sum += price
 	}
 
@@ -491,7 +539,8 @@ func calculatePriceStats(chart *pricehistory.PriceChart) PriceStats {
 // Browse обрабатывает каталог товаров с фильтрами
 // GET /api/v1/products/browse?query=motorola&category=phones&min_price=10000&max_price=30000&shop_id=...&page=1&per_page=20&sort=price_asc
 // Facets returns facet schema for a domain.
-// GET /api/v1/products/facets?type=<domain>&tenant_id=<tenant>
+// GET /api/v1/products/facets?type=<domain>&tenant_id=<tenant>[&category=<slug>]
+// With category, also returns value counts of the filterable attributes of the category's product types.
 func (h *ProductsHandler) Facets(w http.ResponseWriter, r *http.Request) {
 	domain := validation.SanitizeString(r.URL.Query().Get("type"))
 	if domain == "" {
@@ -562,6 +611,23 @@ func (h *ProductsHandler) Facets(w http.ResponseWriter, r *http.Request) {
 		TenantID: tenantID,
 		Facets:   facets,
 	}
+
+	if category := validation.SanitizeString(r.URL.Query().Get("category")); category != "" {
+		_, categoryIDs := h.resolveCategoryIDs(category)
+		if len(categoryIDs) == 0 {
+			appErr := appErrors.NewValidationError("category not found", nil)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		attrFacets, err := h.service.AttributeFacets(r.Context(), categoryIDs)
+		if err != nil {
+			appErr := appErrors.NewInternalError("failed to load attribute facets", err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		resp.Attributes = attrFacets
+	}
+
 	h.RespondJSON(w, http.StatusOK, resp)
 }
 
@@ -766,6 +832,40 @@ func (h *ProductsHandler) Browse(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Фильтры и сортировка по атрибутам справочника: attr.ram=8..16, sort=attr.ram_desc
+	var (
+		attrFilters []products.AttributeFilter
+		attrSort    *products.AttributeSort
+	)
+	if hasAttributeParams(q, sort) {
+		if h.attributesSvc == nil {
+			appErr := appErrors.NewValidationError("attribute filters are not available", nil)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		attrs, err := h.attributesSvc.GetAllActive()
+		if err != nil {
+			appErr := appErrors.NewInternalError("failed to load attributes", err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		attrFilters, err = parseAttributeFilters(q, attrs)
+		if err != nil {
+			appErr := appErrors.NewValidationError(err.Error(), err)
+			h.RespondAppError(w, r, appErr)
+			return
+		}
+		if strings.HasPrefix(sort, attributeParamPrefix) {
+			attrSort, err = parseAttributeSort(sort, attrs)
+			if err != nil {
+				appErr := appErrors.NewValidationError(err.Error(), err)
+				h.RespondAppError(w, r, appErr)
+				return
+			}
+			sort = ""
+		}
+	}
+
 	req := BrowseRequest{
 		Query:    query,
 		TenantID: tenantID,
@@ -793,28 +893,7 @@ func (h *ProductsHandler) Browse(w http.ResponseWriter, r *http.Request) {
 	var categoryID *string
 	var categoryIDs []string
 	if category != "" {
-		cat, err := h.categoriesSvc.GetBySlug(category)
-		if err == nil {
-			categoryID = &cat.ID
-			// Получаем все дочерние категории для включения в фильтр
-			childCats, err := h.categoriesSvc.GetByParentID(cat.ID)
-			if err == nil {
-				// Добавляем родительскую категорию и все дочерние
-				categoryIDs = append(categoryIDs, cat.ID)
-				for _, childCat := range childCats {
-					categoryIDs = append(categoryIDs, childCat.ID)
-				}
-			} else {
-				// Если не удалось получить дочерние, используем только родительскую
-				categoryIDs = []string{cat.ID}
-			}
-		} else {
-			// Если категория не найдена по slug, оставляем category как строку (для обратной совместимости)
-			h.logger.Warn("Category not found by slug", map[string]interface{}{
-				"slug":  category,
-				"error": err.Error(),
-			})
-		}
+		categoryID, categoryIDs = h.resolveCategoryIDs(category)
 	}
 
 	// Преобразуем city slug в city_id, если указан
@@ -848,6 +927,9 @@ func (h *ProductsHandler) Browse(w http.ResponseWriter, r *http.Request) {
 		Page:        page,
 		PerPage:     perPage,
 		Sort:        sort,
+
+		Attributes:    attrFilters,
+		SortAttribute: attrSort,
 	})
 	if err != nil {
 		appErr := appErrors.NewInternalError("Browse failed", err)
@@ -872,3 +954,30 @@ func (h *ProductsHandler) Browse(w http.ResponseWriter, r *http.Request) {
 
 	h.RespondJSON(w, http.StatusOK, res)
 }
+
+// resolveCategoryIDs находит категорию по slug и возвращает её ID и ID категории вместе с дочерними
+func (h *ProductsHandler) resolveCategoryIDs(slug string) (*string, []string) {
+	cat, err := h.categoriesSvc.GetBySlug(slug)
+	if err != nil {
+		// Если категория не найдена по slug, оставляем category как строку (для обратной совместимости)
+		h.logger.Warn("Category not found by slug", map[string]interface{}{
+			"slug":  slug,
+			"error": err.Error(),
+		})
+		return nil, nil
+	}
+
+	// Получаем все дочерние категории для включения в фильтр
+	childCats, err := h.categoriesSvc.GetByParentID(cat.ID)
+	if err != nil {
+		// Если не удалось получить дочерние, используем только родительскую
+		return &cat.ID, []string{cat.ID}
+	}
+
+	// Добавляем родительскую категорию и все дочерние
+	categoryIDs := []string{cat.ID}
+	for _, childCat := range childCats {
+		categoryIDs = append(categoryIDs, childCat.ID)
+	}
+	return &cat.ID, categoryIDs
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/selectorhealth"
+)
+
+// SelectorHealthHandler обработчик административного API состояния селекторов магазинов
+type SelectorHealthHandler struct {
+	*BaseHandler
+	service *selectorhealth.Service
+}
+
+// NewSelectorHealthHandler создаёт новый обработчик состояния селекторов
+func NewSelectorHealthHandler(service *selectorhealth.Service, log *logger.Logger, translator *i18n.Translator) *SelectorHealthHandler {
+	return &SelectorHealthHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// List возвращает состояние селекторов магазинов, деградировавшие первыми
+// GET /api/admin/shops/selector-health?status=healthy|degraded|failed
+func (h *SelectorHealthHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := selectorhealth.Status(validation.SanitizeString(r.URL.Query().Get("status")))
+
+	items, err := h.service.ListHealth(r.Context(), status)
+	if err != nil {
+		h.respondSelectorError(w, r, err, "Failed to list selector health")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// Rollback возвращает магазину версию селекторов, действовавшую до текущей
+// POST /api/admin/shops/{id}/selectors/rollback
+func (h *SelectorHealthHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	shopID := validation.SanitizeString(chi.URLParam(r, "id"))
+	if shopID == "" {
+		appErr := appErrors.NewValidationError("Shop ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return
+	}
+
+	if err := h.service.Rollback(r.Context(), shopID); err != nil {
+		h.respondSelectorError(w, r, err, "Failed to roll back selectors")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondSelectorError переводит ошибки сервиса в HTTP ответы
+func (h *SelectorHealthHandler) respondSelectorError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, selectorhealth.ErrShopNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeShopNotFound, "Shop not found", http.StatusNotFound, err)
+	case errors.Is(err, selectorhealth.ErrNoPreviousConfig):
+		appErr = appErrors.NewAppError(appErrors.CodeNoPreviousSelectors, "Shop has no previous selector config", http.StatusConflict, err)
+	case errors.Is(err, selectorhealth.ErrInvalidStatus):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
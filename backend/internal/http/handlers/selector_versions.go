@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+	"github.com/solomonczyk/izborator/internal/http/validation"
+	"github.com/solomonczyk/izborator/internal/i18n"
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
+)
+
+// CreateSelectorVersionRequest тело запроса на ручное изменение селекторов
+type CreateSelectorVersionRequest struct {
+	Selectors map[string]string `json:"selectors" validate:"required,min=1"`
+	Author    string            `json:"author" validate:"required,max=255"`
+	Note      string            `json:"note" validate:"omitempty,max=2000"`
+}
+
+// PinSelectorVersionRequest тело запроса на закрепление версии; без версии закрепляется активная
+type PinSelectorVersionRequest struct {
+	Version int `json:"version" validate:"omitempty,min=1"`
+}
+
+// SelectorVersionsHandler обработчик административного API версий селекторов магазинов
+type SelectorVersionsHandler struct {
+	*BaseHandler
+	service *selectorversions.Service
+}
+
+// NewSelectorVersionsHandler создаёт новый обработчик версий селекторов
+func NewSelectorVersionsHandler(service *selectorversions.Service, log *logger.Logger, translator *i18n.Translator) *SelectorVersionsHandler {
+	return &SelectorVersionsHandler{
+		BaseHandler: NewBaseHandler(log, translator),
+		service:     service,
+	}
+}
+
+// History возвращает активную версию и историю версий селекторов магазина
+// GET /api/admin/shops/{id}/selectors/versions
+func (h *SelectorVersionsHandler) History(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.History(r.Context(), shopID)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to load selector versions")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, history)
+}
+
+// Create сохраняет ручное изменение селекторов новой версией и активирует её
+// POST /api/admin/shops/{id}/selectors/versions
+func (h *SelectorVersionsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateSelectorVersionRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	version, err := h.service.CreateVersion(r.Context(), selectorversions.NewVersion{
+		ShopID:    shopID,
+		Selectors: req.Selectors,
+		Source:    selectorversions.SourceManual,
+		Author:    req.Author,
+		Note:      req.Note,
+	})
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to save selector version")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusCreated, version)
+}
+
+// Get возвращает версию селекторов магазина
+// GET /api/admin/shops/{id}/selectors/versions/{version}
+func (h *SelectorVersionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetVersion(r.Context(), shopID, version)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to load selector version")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, result)
+}
+
+// Diff сравнивает две версии селекторов магазина
+// GET /api/admin/shops/{id}/selectors/diff?from=1&to=2 (по умолчанию - активная версия и предыдущая)
+func (h *SelectorVersionsHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from := h.ParseIntParam(query.Get("from"), 0)
+	to := h.ParseIntParam(query.Get("to"), 0)
+
+	diff, err := h.service.Diff(r.Context(), shopID, from, to)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to compare selector versions")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, diff)
+}
+
+// Activate откатывает магазин на указанную версию селекторов
+// POST /api/admin/shops/{id}/selectors/versions/{version}/activate
+func (h *SelectorVersionsHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.service.Rollback(r.Context(), shopID, version)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to activate selector version")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, state)
+}
+
+// Pin закрепляет магазин на версии селекторов: авто-конфигурация не будет её заменять
+// POST /api/admin/shops/{id}/selectors/pin
+func (h *SelectorVersionsHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	var req PinSelectorVersionRequest
+	if r.ContentLength != 0 && !h.decodeBody(w, r, &req) {
+		return
+	}
+
+	state, err := h.service.Pin(r.Context(), shopID, req.Version)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to pin selector version")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, state)
+}
+
+// Unpin снимает закрепление версии селекторов
+// DELETE /api/admin/shops/{id}/selectors/pin
+func (h *SelectorVersionsHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	shopID, ok := h.shopID(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.service.Unpin(r.Context(), shopID)
+	if err != nil {
+		h.respondVersionError(w, r, err, "Failed to unpin selector version")
+		return
+	}
+
+	h.RespondJSON(w, http.StatusOK, state)
+}
+
+// shopID разбирает ID магазина из пути
+func (h *SelectorVersionsHandler) shopID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	shopID := validation.SanitizeString(chi.URLParam(r, "id"))
+	if shopID == "" {
+		appErr := appErrors.NewValidationError("Shop ID is required", nil)
+		h.RespondAppError(w, r, appErr)
+		return "", false
+	}
+	return shopID, true
+}
+
+// versionParam разбирает номер версии из пути
+func (h *SelectorVersionsHandler) versionParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		appErr := appErrors.NewValidationError("Invalid selector version", err)
+		h.RespondAppError(w, r, appErr)
+		return 0, false
+	}
+	return version, true
+}
+
+// decodeBody разбирает и валидирует JSON тело запроса
+func (h *SelectorVersionsHandler) decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		appErr := appErrors.NewBadRequest("Invalid JSON body", err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+
+	if err := validation.ValidateStruct(req); err != nil {
+		message := validation.FormatValidationErrors(err)
+		appErr := appErrors.NewValidationError(message, err)
+		h.RespondAppError(w, r, appErr)
+		return false
+	}
+	return true
+}
+
+// respondVersionError переводит ошибки сервиса в HTTP ответы
+func (h *SelectorVersionsHandler) respondVersionError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var appErr *appErrors.AppError
+	switch {
+	case errors.Is(err, selectorversions.ErrShopNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeShopNotFound, "Shop not found", http.StatusNotFound, err)
+	case errors.Is(err, selectorversions.ErrVersionNotFound):
+		appErr = appErrors.NewAppError(appErrors.CodeSelectorVersionMissing, "Selector version not found", http.StatusNotFound, err)
+	case errors.Is(err, selectorversions.ErrNoPreviousVersion):
+		appErr = appErrors.NewAppError(appErrors.CodeNoPreviousSelectors, "Shop has no previous selector version", http.StatusConflict, err)
+	case errors.Is(err, selectorversions.ErrNoChanges):
+		appErr = appErrors.NewAppError(appErrors.CodeSelectorsUnchanged, "Selectors match the active version", http.StatusConflict, err)
+	case errors.Is(err, selectorversions.ErrInvalidVersion):
+		appErr = appErrors.NewValidationError(err.Error(), err)
+	default:
+		appErr = appErrors.NewInternalError(message, err)
+	}
+	h.RespondAppError(w, r, appErr)
+}
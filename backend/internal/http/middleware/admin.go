@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	appErrors "github.com/solomonczyk/izborator/internal/errors"
+)
+
+const adminActorKey contextKey = "admin_actor"
+
+// AdminAuth пропускает только запросы с заголовком "Authorization: Bearer <token>".
+// tokens - токен -> имя администратора; имя попадает в контекст (AdminActor) и в журналы решений.
+// Если токенов нет, административные роуты отключены.
+func AdminAuth(tokens map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(tokens) == 0 {
+				writeAdminError(w, http.StatusForbidden, appErrors.CodeForbidden, "admin API is disabled")
+				return
+			}
+
+			provided := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			actor := ""
+			if provided != "" {
+				// Сравниваются все токены: время ответа не выдаёт, какой из них совпал
+				for token, name := range tokens {
+					if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+						actor = name
+					}
+				}
+			}
+			if actor == "" {
+				writeAdminError(w, http.StatusUnauthorized, appErrors.CodeUnauthorized, "invalid admin token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), adminActorKey, actor)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AdminActor возвращает имя администратора, прошедшего AdminAuth
+func AdminActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(adminActorKey).(string); ok {
+		return actor
+	}
+	return ""
+}
+
+func writeAdminError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(appErrors.NewErrorResponse(code, message, nil))
+}
@@ -10,7 +10,7 @@ func CORS(next http.Handler) http.Handler {
 		// Устанавливаем CORS заголовки
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Alert-Token")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Обрабатываем preflight запросы
@@ -9,16 +9,24 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/solomonczyk/izborator/internal/alerts"
+	"github.com/solomonczyk/izborator/internal/attributes"
+	"github.com/solomonczyk/izborator/internal/brands"
+	"github.com/solomonczyk/izborator/internal/candidates"
 	"github.com/solomonczyk/izborator/internal/categories"
+	"github.com/solomonczyk/izborator/internal/categorizer"
 	"github.com/solomonczyk/izborator/internal/cities"
 	appErrors "github.com/solomonczyk/izborator/internal/errors"
 	"github.com/solomonczyk/izborator/internal/http/handlers"
 	httpMiddleware "github.com/solomonczyk/izborator/internal/http/middleware"
 	"github.com/solomonczyk/izborator/internal/i18n"
 	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/matchreview"
 	"github.com/solomonczyk/izborator/internal/pricehistory"
 	"github.com/solomonczyk/izborator/internal/products"
 	"github.com/solomonczyk/izborator/internal/scrapingstats"
+	"github.com/solomonczyk/izborator/internal/selectorhealth"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
 	"github.com/solomonczyk/izborator/internal/storage"
 )
 
@@ -37,10 +45,39 @@ type Handlers struct {
 	Stats      *handlers.StatsHandler
 	Categories *handlers.CategoriesHandler
 	Cities     *handlers.CitiesHandler
+	Alerts     *handlers.AlertsHandler
+	Review     *handlers.MatchReviewHandler
+	Selectors  *handlers.SelectorHealthHandler
+	Versions   *handlers.SelectorVersionsHandler
+	Candidates *handlers.CandidatesHandler
+	Mappings   *handlers.CategoryMappingsHandler
+	Brands     *handlers.BrandsHandler
+}
+
+// Deps сервисы и инфраструктура, которые роутер передаёт обработчикам
+type Deps struct {
+	Products         *products.Service
+	PriceHistory     *pricehistory.Service
+	ScrapingStats    *scrapingstats.Service
+	Categories       *categories.Service
+	Cities           *cities.Service
+	Attributes       *attributes.Service
+	Alerts           *alerts.Service
+	MatchReview      *matchreview.Service
+	SelectorHealth   *selectorhealth.Service
+	SelectorVersions *selectorversions.Service
+	Candidates       *candidates.Service
+	Categorizer      *categorizer.Service
+	Brands           *brands.Service
+
+	AdminTokens map[string]string // токен -> имя администратора; пусто - административное API отключено
+	Translator  *i18n.Translator
+	DB          *storage.Postgres // nil - health check без PostgreSQL
+	Redis       *redis.Client     // nil - без кэша ответов и health check Redis
 }
 
 // New создаёт новый роутер
-func New(log *logger.Logger, productsService *products.Service, priceHistoryService *pricehistory.Service, scrapingStatsService *scrapingstats.Service, categoriesService *categories.Service, citiesService *cities.Service, translator *i18n.Translator, db *storage.Postgres, redisClient *redis.Client) *Router {
+func New(log *logger.Logger, deps Deps) *Router {
 	r := chi.NewRouter()
 
 	// Базовые middleware
@@ -54,26 +91,29 @@ func New(log *logger.Logger, productsService *products.Service, priceHistoryServ
 
 	// Инициализация handlers
 	var pgPool *pgxpool.Pool
-	if db != nil {
-		pgPool = db.DB()
-	}
-
-	var redisPool *redis.Client
-	if redisClient != nil {
-		redisPool = redisClient
+	if deps.DB != nil {
+		pgPool = deps.DB.DB()
 	}
 
+	translator := deps.Translator
 	handlers := &Handlers{
-		Health:     handlers.NewHealthHandler(pgPool, redisPool, log),
+		Health:     handlers.NewHealthHandler(pgPool, deps.Redis, log),
 		Home:       handlers.NewHomeHandler(log, translator),
-		Products:   handlers.NewProductsHandler(productsService, priceHistoryService, categoriesService, citiesService, log, translator),
-		Stats:      handlers.NewStatsHandler(scrapingStatsService, log, translator),
-		Categories: handlers.NewCategoriesHandler(categoriesService, log, translator),
-		Cities:     handlers.NewCitiesHandler(citiesService, log, translator),
+		Products:   handlers.NewProductsHandler(deps.Products, deps.PriceHistory, deps.Categories, deps.Cities, deps.Attributes, log, translator),
+		Stats:      handlers.NewStatsHandler(deps.ScrapingStats, log, translator),
+		Categories: handlers.NewCategoriesHandler(deps.Categories, log, translator),
+		Cities:     handlers.NewCitiesHandler(deps.Cities, log, translator),
+		Alerts:     handlers.NewAlertsHandler(deps.Alerts, deps.Cities, log, translator),
+		Review:     handlers.NewMatchReviewHandler(deps.MatchReview, log, translator),
+		Selectors:  handlers.NewSelectorHealthHandler(deps.SelectorHealth, log, translator),
+		Versions:   handlers.NewSelectorVersionsHandler(deps.SelectorVersions, log, translator),
+		Candidates: handlers.NewCandidatesHandler(deps.Candidates, log, translator),
+		Mappings:   handlers.NewCategoryMappingsHandler(deps.Categorizer, log, translator),
+		Brands:     handlers.NewBrandsHandler(deps.Brands, log, translator),
 	}
 
 	// Настройка роутов
-	setupRoutes(r, handlers, translator, redisClient, log)
+	setupRoutes(r, handlers, deps.AdminTokens, translator, deps.Redis, log)
 
 	return &Router{
 		chi:      r,
@@ -83,7 +123,7 @@ func New(log *logger.Logger, productsService *products.Service, priceHistoryServ
 }
 
 // setupRoutes настраивает все роуты приложения
-func setupRoutes(r *chi.Mux, h *Handlers, translator *i18n.Translator, redisClient *redis.Client, log *logger.Logger) {
+func setupRoutes(r *chi.Mux, h *Handlers, adminTokens map[string]string, translator *i18n.Translator, redisClient *redis.Client, log *logger.Logger) {
 	// Health check endpoints
 	r.Get("/api/health", h.Health.Check)
 	r.Get("/api/health/live", h.Health.Alive)
@@ -93,6 +133,65 @@ func setupRoutes(r *chi.Mux, h *Handlers, translator *i18n.Translator, redisClie
 	// Internal tenant health snapshot
 	r.Route("/api/internal", func(ir chi.Router) {
 		ir.Get("/tenant/health", h.Products.TenantHealth)
+
+		// Конвейер кандидатов в магазины: discovered → classified → configuring → configured | failed → approved | rejected → live
+		ir.Route("/candidates", func(cr chi.Router) {
+			cr.Use(httpMiddleware.AdminAuth(adminTokens))
+			cr.Get("/", h.Candidates.List)
+			cr.Get("/{id}", h.Candidates.Get)
+			cr.Post("/{id}/approve", h.Candidates.Approve)
+			cr.Post("/{id}/reject", h.Candidates.Reject)
+			cr.Post("/{id}/retry", h.Candidates.Retry)
+			cr.Post("/{id}/live", h.Candidates.GoLive)
+		})
+	})
+
+	// Административное API: проверка сопоставлений, слияние и разделение товаров, селекторы,
+	// сопоставления категорий магазинов и справочник брендов
+	r.Route("/api/admin", func(ar chi.Router) {
+		ar.Use(httpMiddleware.AdminAuth(adminTokens))
+
+		ar.Route("/matches", func(mr chi.Router) {
+			mr.Get("/", h.Review.ListPending)
+			mr.Post("/{id}/approve", h.Review.Approve)
+			mr.Post("/{id}/reject", h.Review.Reject)
+		})
+
+		ar.Route("/products", func(pr chi.Router) {
+			pr.Post("/merge", h.Review.Merge)
+			pr.Post("/{id}/split", h.Review.Split)
+			pr.Get("/{id}/audit", h.Review.Audit)
+		})
+
+		// Состояние селекторов магазинов, история версий, откат и закрепление; сопоставления категорий магазинов
+		ar.Route("/shops", func(sr chi.Router) {
+			sr.Get("/selector-health", h.Selectors.List)
+			sr.Post("/{id}/selectors/rollback", h.Selectors.Rollback)
+			sr.Get("/{id}/selectors/versions", h.Versions.History)
+			sr.Post("/{id}/selectors/versions", h.Versions.Create)
+			sr.Get("/{id}/selectors/versions/{version}", h.Versions.Get)
+			sr.Post("/{id}/selectors/versions/{version}/activate", h.Versions.Activate)
+			sr.Get("/{id}/selectors/diff", h.Versions.Diff)
+			sr.Post("/{id}/selectors/pin", h.Versions.Pin)
+			sr.Delete("/{id}/selectors/pin", h.Versions.Unpin)
+			sr.Get("/{id}/category-mappings", h.Mappings.List)
+			sr.Post("/{id}/category-mappings", h.Mappings.Save)
+			sr.Delete("/{id}/category-mappings/{mappingID}", h.Mappings.Delete)
+		})
+
+		// Проверка автоматического определения категории без записи
+		ar.Post("/categorizer/preview", h.Mappings.Preview)
+
+		// Справочник брендов: синонимы и предложения синонимов по написаниям из товаров
+		ar.Route("/brands", func(br chi.Router) {
+			br.Get("/", h.Brands.List)
+			br.Post("/", h.Brands.Create)
+			br.Get("/proposals", h.Brands.Proposals)
+			br.Put("/{id}", h.Brands.Update)
+			br.Delete("/{id}", h.Brands.Delete)
+			br.Post("/{id}/aliases", h.Brands.AddAlias)
+			br.Delete("/{id}/aliases/{alias}", h.Brands.RemoveAlias)
+		})
 	})
 
 	// API v1 роуты
@@ -118,6 +217,14 @@ func setupRoutes(r *chi.Mux, h *Handlers, translator *i18n.Translator, redisClie
 			cr.With(httpMiddleware.CacheMiddleware(redisClient, log, 30*time.Minute)).Get("/", h.Cities.GetAllActive)
 		})
 
+		// Подписки на снижение цены (без кэширования)
+		api.Route("/alerts", func(ar chi.Router) {
+			ar.Post("/", h.Alerts.Create)
+			ar.Get("/", h.Alerts.List)
+			ar.Get("/{id}", h.Alerts.Get)
+			ar.Delete("/{id}", h.Alerts.Delete)
+		})
+
 		// Товары
 		api.Route("/products", func(pr chi.Router) {
 			// Кэширование для популярных endpoints
@@ -130,6 +237,8 @@ func setupRoutes(r *chi.Mux, h *Handlers, translator *i18n.Translator, redisClie
 			pr.With(httpMiddleware.CacheMiddleware(redisClient, log, 10*time.Minute)).Get("/{id}", h.Products.GetByID)
 			// Prices - 2 минуты (цены обновляются часто)
 			pr.With(httpMiddleware.CacheMiddleware(redisClient, log, 2*time.Minute)).Get("/{id}/prices", h.Products.GetPrices)
+			// Offers - 2 минуты (ранжирование по текущим ценам)
+			pr.With(httpMiddleware.CacheMiddleware(redisClient, log, 2*time.Minute)).Get("/{id}/offers", h.Products.GetOffers)
 			// Price history - 15 минут (история меняется редко)
 			pr.With(httpMiddleware.CacheMiddleware(redisClient, log, 15*time.Minute)).Get("/{id}/price-history", h.Products.GetPriceHistory)
 		})
@@ -0,0 +1,11 @@
+package indexing
+
+import "errors"
+
+var (
+	// ErrRebuildValidation новый индекс не прошёл проверку и не был подключён
+	ErrRebuildValidation = errors.New("rebuilt index validation failed")
+
+	// ErrRebuildInProgress пересборку уже выполняет другой процесс
+	ErrRebuildInProgress = errors.New("index rebuild is already in progress")
+)
@@ -0,0 +1,318 @@
+package indexing
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultSyncBatchSize = 1000
+
+	// syncOverlap на сколько раньше watermark перечитываются изменения: транзакции,
+	// начатые до watermark и зафиксированные после него, пишут updated_at в прошлое
+	syncOverlap = 10 * time.Minute
+
+	// rebuildTolerance допустимая доля товаров, которых нет в новом индексе перед переключением
+	// (добавлены во время заливки; дозаливаются сразу после переключения)
+	rebuildTolerance = 0.01
+)
+
+// SyncChanged отправляет в индекс только товары, изменённые после последней синхронизации
+func (s *Service) SyncChanged(ctx context.Context) (*SyncResult, error) {
+	since, err := s.storage.GetCheckpoint(ctx, ProductsCheckpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync checkpoint: %w", err)
+	}
+	return s.sync(ctx, since)
+}
+
+// SyncAll отправляет в индекс все товары и сдвигает checkpoint
+func (s *Service) SyncAll(ctx context.Context) (*SyncResult, error) {
+	return s.sync(ctx, time.Time{})
+}
+
+// sync индексирует товары, изменённые после since, батчами по batchSize, и убирает из индекса
+// товары из журнала удалений (удалённых строк нет среди изменённых).
+// Watermark - время БД на начало синхронизации (часы приложения могут расходиться с БД):
+// изменения, пришедшие во время неё, попадут в следующий запуск. Изменения читаются
+// с запасом syncOverlap до since (повторная индексация идемпотентна)
+func (s *Service) sync(ctx context.Context, since time.Time) (*SyncResult, error) {
+	watermark, err := s.storage.CurrentTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database time: %w", err)
+	}
+	result := &SyncResult{
+		Since:     since,
+		Watermark: watermark,
+	}
+
+	from := since
+	if !from.IsZero() {
+		from = from.Add(-syncOverlap)
+	}
+
+	indexed, err := s.forEachChangedBatch(ctx, from, func(docs []*Document) error {
+		return s.storage.UpsertDocuments(ctx, docs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Indexed = indexed
+
+	// Удаления применяются после заливки: товар, удалённый после чтения батча, не вернётся в индекс.
+	// Полная синхронизация журнал не читает, журнал очищает пересборка
+	if !since.IsZero() {
+		ids, err := s.storage.ListDeletedProducts(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deleted products: %w", err)
+		}
+		if len(ids) > 0 {
+			if err := s.storage.DeleteDocuments(ctx, ids); err != nil {
+				return nil, fmt.Errorf("failed to delete products from index: %w", err)
+			}
+		}
+		result.Deleted = len(ids)
+	}
+
+	if err := s.storage.SaveCheckpoint(ctx, ProductsCheckpoint, result.Watermark); err != nil {
+		return nil, fmt.Errorf("failed to save sync checkpoint: %w", err)
+	}
+
+	s.logger.Info("Index sync completed", map[string]interface{}{
+		"since":     since,
+		"watermark": result.Watermark,
+		"indexed":   result.Indexed,
+		"deleted":   result.Deleted,
+	})
+
+	return result, nil
+}
+
+// forEachChangedBatch постранично читает товары, изменённые после since, и передаёт их в write
+func (s *Service) forEachChangedBatch(ctx context.Context, since time.Time, write func(docs []*Document) error) (int, error) {
+	total := 0
+	afterID := ""
+	for {
+		docs, err := s.storage.LoadChangedDocuments(ctx, since, afterID, s.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to load changed products: %w", err)
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		if err := write(docs); err != nil {
+			return total, fmt.Errorf("failed to index products: %w", err)
+		}
+
+		total += len(docs)
+		afterID = docs[len(docs)-1].ID
+
+		s.logger.Info("Indexed batch", map[string]interface{}{
+			"count": len(docs),
+			"total": total,
+		})
+
+		if len(docs) < s.batchSize {
+			return total, nil
+		}
+	}
+}
+
+// IndexProducts переиндексирует указанные товары
+// Товары, которых больше нет в БД, удаляются из индекса
+func (s *Service) IndexProducts(ctx context.Context, productIDs []string) error {
+	ids := uniqueIDs(productIDs)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	docs, err := s.storage.LoadDocuments(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load products: %w", err)
+	}
+
+	if len(docs) > 0 {
+		if err := s.storage.UpsertDocuments(ctx, docs); err != nil {
+			return fmt.Errorf("failed to index products: %w", err)
+		}
+	}
+
+	if len(docs) < len(ids) {
+		found := make(map[string]bool, len(docs))
+		for _, doc := range docs {
+			found[doc.ID] = true
+		}
+		var missing []string
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		if err := s.storage.DeleteDocuments(ctx, missing); err != nil {
+			return fmt.Errorf("failed to delete missing products from index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Reindex очищает индекс и заново индексирует все товары
+func (s *Service) Reindex(ctx context.Context) (*SyncResult, error) {
+	if err := s.storage.DeleteAllDocuments(ctx); err != nil {
+		return nil, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	s.logger.Info("Deleted all documents from index", nil)
+	return s.SyncAll(ctx)
+}
+
+// uniqueIDs убирает пустые и повторяющиеся ID, сохраняя порядок
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// Rebuild пересобирает индекс без простоя поиска (blue/green):
+// все товары заливаются в новый индекс products_<timestamp>, число документов
+// сверяется с PostgreSQL, затем индексы атомарно меняются местами и старый удаляется.
+// Удаления и изменения, пришедшие во время пересборки, применяются после переключения.
+// Одновременно пересборку выполняет только один процесс (ErrRebuildInProgress)
+func (s *Service) Rebuild(ctx context.Context) (*RebuildResult, error) {
+	unlock, ok, err := s.storage.TryLockRebuild(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock index rebuild: %w", err)
+	}
+	if !ok {
+		return nil, ErrRebuildInProgress
+	}
+	defer unlock()
+
+	startedAt, err := s.storage.CurrentTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database time: %w", err)
+	}
+	result := &RebuildResult{
+		StartedAt: startedAt,
+	}
+	result.IndexName = fmt.Sprintf("products_%s", result.StartedAt.UTC().Format("20060102150405"))
+
+	if err := s.storage.CreateIndex(ctx, result.IndexName); err != nil {
+		return nil, fmt.Errorf("failed to create index %s: %w", result.IndexName, err)
+	}
+
+	s.logger.Info("Rebuilding index", map[string]interface{}{
+		"index": result.IndexName,
+	})
+
+	if err := s.fillAndValidate(ctx, result); err != nil {
+		s.dropIndex(ctx, result.IndexName)
+		return nil, err
+	}
+
+	if err := s.storage.SwapWithLive(ctx, result.IndexName); err != nil {
+		s.dropIndex(ctx, result.IndexName)
+		return nil, fmt.Errorf("failed to swap indexes: %w", err)
+	}
+
+	// После swap под именем result.IndexName лежит старый рабочий индекс
+	s.dropIndex(ctx, result.IndexName)
+
+	// Индекс уже переключён: при ошибках дальше недостающие изменения
+	// подхватят события индексации и следующая синхронизация
+	deleted, err := s.replayDeletions(ctx, result.StartedAt)
+	if err != nil {
+		s.logger.Warn("Index deletions replay failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		result.Deleted = deleted
+	}
+
+	catchUp, err := s.sync(ctx, result.StartedAt)
+	if err != nil {
+		s.logger.Warn("Index catch-up sync failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		result.CatchUp = catchUp.Indexed
+	}
+
+	s.logger.Info("Index rebuild completed", map[string]interface{}{
+		"index":    result.IndexName,
+		"expected": result.Expected,
+		"indexed":  result.Indexed,
+		"deleted":  result.Deleted,
+		"catch_up": result.CatchUp,
+	})
+
+	return result, nil
+}
+
+// fillAndValidate заливает все товары в новый индекс и сверяет число документов с БД.
+// Число товаров берётся после заливки: удаления и слияния во время пересборки не должны
+// проваливать проверку, а небольшое расхождение (rebuildTolerance) догоняется после переключения
+func (s *Service) fillAndValidate(ctx context.Context, result *RebuildResult) error {
+	_, err := s.forEachChangedBatch(ctx, time.Time{}, func(docs []*Document) error {
+		return s.storage.UpsertDocumentsTo(ctx, result.IndexName, docs)
+	})
+	if err != nil {
+		return err
+	}
+
+	indexed, err := s.storage.CountDocuments(ctx, result.IndexName)
+	if err != nil {
+		return err
+	}
+	result.Indexed = indexed
+
+	expected, err := s.storage.CountProducts(ctx)
+	if err != nil {
+		return err
+	}
+	result.Expected = expected
+
+	allowed := int64(float64(expected) * rebuildTolerance)
+	if indexed < expected-allowed {
+		return fmt.Errorf("%w: index %s has %d documents, expected at least %d of %d",
+			ErrRebuildValidation, result.IndexName, indexed, expected-allowed, expected)
+	}
+
+	return nil
+}
+
+// replayDeletions убирает из рабочего индекса товары, удалённые во время пересборки
+// (в новый индекс они могли попасть до удаления), и очищает отработанный журнал удалений
+func (s *Service) replayDeletions(ctx context.Context, startedAt time.Time) (int, error) {
+	ids, err := s.storage.ListDeletedProducts(ctx, startedAt.Add(-syncOverlap))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list deleted products: %w", err)
+	}
+	if len(ids) > 0 {
+		if err := s.storage.DeleteDocuments(ctx, ids); err != nil {
+			return 0, fmt.Errorf("failed to delete products from index: %w", err)
+		}
+	}
+	if err := s.storage.PruneDeletedProducts(ctx, startedAt.Add(-syncOverlap)); err != nil {
+		return len(ids), fmt.Errorf("failed to prune deletions log: %w", err)
+	}
+	return len(ids), nil
+}
+
+// dropIndex удаляет временный индекс, ошибка только логируется
+func (s *Service) dropIndex(ctx context.Context, indexName string) {
+	if err := s.storage.DeleteIndex(ctx, indexName); err != nil {
+		s.logger.Warn("Failed to delete index", map[string]interface{}{
+			"index": indexName,
+			"error": err.Error(),
+		})
+	}
+}
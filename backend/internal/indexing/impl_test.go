@@ -0,0 +1,385 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+// mockStorage in-memory хранилище товаров и индекса
+type mockStorage struct {
+	products   map[string]time.Time // id -> updated_at
+	index      map[string]*Document // рабочий индекс
+	others     map[string]map[string]*Document
+	checkpoint time.Time
+	now        time.Time // время БД, нулевое - time.Now()
+	upserts    int
+	upsertErr  error
+	dropOnFill bool // теряет документы при заливке в новый индекс
+	onFill     func()
+	deleted    map[string]time.Time // журнал удалений
+	locked     bool                 // пересборку держит другой процесс
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		products: make(map[string]time.Time),
+		index:    make(map[string]*Document),
+		others:   make(map[string]map[string]*Document),
+		deleted:  make(map[string]time.Time),
+	}
+}
+
+func (m *mockStorage) LoadDocuments(ctx context.Context, productIDs []string) ([]*Document, error) {
+	var docs []*Document
+	for _, id := range productIDs {
+		if _, ok := m.products[id]; ok {
+			docs = append(docs, &Document{ID: id})
+		}
+	}
+	return docs, nil
+}
+
+func (m *mockStorage) LoadChangedDocuments(ctx context.Context, since time.Time, afterID string, limit int) ([]*Document, error) {
+	var ids []string
+	for id, updatedAt := range m.products {
+		if updatedAt.After(since) && id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return m.LoadDocuments(ctx, ids)
+}
+
+func (m *mockStorage) UpsertDocuments(ctx context.Context, docs []*Document) error {
+	if m.upsertErr != nil {
+		return m.upsertErr
+	}
+	m.upserts++
+	for _, doc := range docs {
+		m.index[doc.ID] = doc
+	}
+	return nil
+}
+
+func (m *mockStorage) DeleteDocuments(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		delete(m.index, id)
+	}
+	return nil
+}
+
+func (m *mockStorage) DeleteAllDocuments(ctx context.Context) error {
+	m.index = make(map[string]*Document)
+	return nil
+}
+
+func (m *mockStorage) UpsertDocumentsTo(ctx context.Context, indexName string, docs []*Document) error {
+	target, ok := m.others[indexName]
+	if !ok {
+		return fmt.Errorf("index %s not found", indexName)
+	}
+	for _, doc := range docs {
+		if m.dropOnFill && len(target) > 0 {
+			break
+		}
+		target[doc.ID] = doc
+	}
+	if m.onFill != nil {
+		m.onFill()
+		m.onFill = nil
+	}
+	return nil
+}
+
+func (m *mockStorage) CountProducts(ctx context.Context) (int64, error) {
+	return int64(len(m.products)), nil
+}
+
+func (m *mockStorage) CreateIndex(ctx context.Context, indexName string) error {
+	m.others[indexName] = make(map[string]*Document)
+	return nil
+}
+
+func (m *mockStorage) CountDocuments(ctx context.Context, indexName string) (int64, error) {
+	return int64(len(m.others[indexName])), nil
+}
+
+func (m *mockStorage) SwapWithLive(ctx context.Context, indexName string) error {
+	m.index, m.others[indexName] = m.others[indexName], m.index
+	return nil
+}
+
+func (m *mockStorage) DeleteIndex(ctx context.Context, indexName string) error {
+	delete(m.others, indexName)
+	return nil
+}
+
+func (m *mockStorage) ListDeletedProducts(ctx context.Context, since time.Time) ([]string, error) {
+	var ids []string
+	for id, at := range m.deleted {
+		if at.After(since) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockStorage) PruneDeletedProducts(ctx context.Context, before time.Time) error {
+	for id, at := range m.deleted {
+		if !at.After(before) {
+			delete(m.deleted, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockStorage) TryLockRebuild(ctx context.Context) (func(), bool, error) {
+	if m.locked {
+		return nil, false, nil
+	}
+	m.locked = true
+	return func() { m.locked = false }, true, nil
+}
+
+func (m *mockStorage) CurrentTime(ctx context.Context) (time.Time, error) {
+	if m.now.IsZero() {
+		return time.Now(), nil
+	}
+	return m.now, nil
+}
+
+func (m *mockStorage) GetCheckpoint(ctx context.Context, name string) (time.Time, error) {
+	return m.checkpoint, nil
+}
+
+func (m *mockStorage) SaveCheckpoint(ctx context.Context, name string, watermark time.Time) error {
+	m.checkpoint = watermark
+	return nil
+}
+
+func TestSyncChanged_OnlyChangedSinceCheckpoint(t *testing.T) {
+	storage := newMockStorage()
+	now := time.Now()
+	storage.checkpoint = now.Add(-time.Hour)
+	storage.now = now
+	storage.products["a"] = now.Add(-2 * time.Hour) // не изменился
+	storage.products["b"] = now.Add(-30 * time.Minute)
+	storage.products["c"] = now.Add(-time.Second)
+
+	service := New(storage, nil)
+	result, err := service.SyncChanged(context.Background())
+	if err != nil {
+		t.Fatalf("SyncChanged failed: %v", err)
+	}
+
+	if result.Indexed != 2 {
+		t.Errorf("Indexed = %d, want 2", result.Indexed)
+	}
+	if _, ok := storage.index["a"]; ok {
+		t.Error("unchanged product should not be indexed")
+	}
+	// Watermark берётся из времени БД, а не из часов приложения
+	if !storage.checkpoint.Equal(now) || !result.Watermark.Equal(now) {
+		t.Errorf("checkpoint = %v, want database time %v", storage.checkpoint, now)
+	}
+
+	// Повторная синхронизация перечитывает только окно syncOverlap до watermark
+	storage.now = now.Add(time.Minute)
+	result, err = service.SyncChanged(context.Background())
+	if err != nil {
+		t.Fatalf("second SyncChanged failed: %v", err)
+	}
+	if result.Indexed != 1 {
+		t.Errorf("expected only the overlap window to be re-read, got indexed=%d", result.Indexed)
+	}
+}
+
+func TestSyncChanged_ReplaysDeletions(t *testing.T) {
+	storage := newMockStorage()
+	now := time.Now()
+	storage.checkpoint = now.Add(-time.Hour)
+	storage.now = now
+	storage.index["gone"] = &Document{ID: "gone"}
+	storage.index["old"] = &Document{ID: "old"}
+	storage.deleted["gone"] = now.Add(-time.Minute)
+	storage.deleted["old"] = now.Add(-2 * time.Hour) // удалён до прошлой синхронизации
+
+	service := New(storage, nil)
+	result, err := service.SyncChanged(context.Background())
+	if err != nil {
+		t.Fatalf("SyncChanged failed: %v", err)
+	}
+
+	if result.Deleted != 1 {
+		t.Errorf("Deleted = %d, want 1", result.Deleted)
+	}
+	if _, ok := storage.index["gone"]; ok {
+		t.Error("product deleted since the watermark must be removed from the index")
+	}
+	if _, ok := storage.index["old"]; !ok {
+		t.Error("deletions before the watermark are not replayed again")
+	}
+}
+
+func TestSyncAll_Paginates(t *testing.T) {
+	storage := newMockStorage()
+	for i := 0; i < 25; i++ {
+		storage.products[fmt.Sprintf("p%02d", i)] = time.Now().Add(-24 * time.Hour)
+	}
+	storage.checkpoint = time.Now()
+
+	service := New(storage, nil)
+	service.batchSize = 10
+
+	result, err := service.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if result.Indexed != 25 || len(storage.index) != 25 {
+		t.Errorf("Indexed = %d (index size %d), want 25", result.Indexed, len(storage.index))
+	}
+	if storage.upserts != 3 {
+		t.Errorf("upserts = %d, want 3 batches", storage.upserts)
+	}
+}
+
+func TestSyncChanged_KeepsCheckpointOnError(t *testing.T) {
+	storage := newMockStorage()
+	storage.products["a"] = time.Now()
+	storage.upsertErr = fmt.Errorf("meilisearch unavailable")
+
+	service := New(storage, nil)
+	if _, err := service.SyncChanged(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if !storage.checkpoint.IsZero() {
+		t.Errorf("checkpoint must not move on failure, got %v", storage.checkpoint)
+	}
+}
+
+func TestIndexProducts_DeduplicatesAndDeletesMissing(t *testing.T) {
+	storage := newMockStorage()
+	storage.products["a"] = time.Now()
+	storage.index["gone"] = &Document{ID: "gone"}
+
+	service := New(storage, nil)
+	if err := service.IndexProducts(context.Background(), []string{"a", "a", "", "gone"}); err != nil {
+		t.Fatalf("IndexProducts failed: %v", err)
+	}
+
+	if storage.upserts != 1 {
+		t.Errorf("upserts = %d, want 1", storage.upserts)
+	}
+	if _, ok := storage.index["a"]; !ok {
+		t.Error("product a should be indexed")
+	}
+	if _, ok := storage.index["gone"]; ok {
+		t.Error("deleted product should be removed from index")
+	}
+}
+
+func TestRebuild_SwapsValidatedIndex(t *testing.T) {
+	storage := newMockStorage()
+	for i := 0; i < 5; i++ {
+		storage.products[fmt.Sprintf("p%d", i)] = time.Now().Add(-time.Hour)
+	}
+	storage.index["stale"] = &Document{ID: "stale"}
+
+	service := New(storage, nil)
+	result, err := service.Rebuild(context.Background())
+	if err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	if result.Expected != 5 || result.Indexed != 5 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(storage.index) != 5 {
+		t.Errorf("live index size = %d, want 5", len(storage.index))
+	}
+	if _, ok := storage.index["stale"]; ok {
+		t.Error("stale document should be gone after swap")
+	}
+	if len(storage.others) != 0 {
+		t.Errorf("old index should be deleted, left: %v", storage.others)
+	}
+	if storage.checkpoint.IsZero() {
+		t.Error("checkpoint should be advanced by catch-up sync")
+	}
+}
+
+func TestRebuild_ValidationFailureKeepsLiveIndex(t *testing.T) {
+	storage := newMockStorage()
+	for i := 0; i < 3; i++ {
+		storage.products[fmt.Sprintf("p%d", i)] = time.Now()
+	}
+	storage.index["live"] = &Document{ID: "live"}
+	storage.dropOnFill = true
+
+	service := New(storage, nil)
+	_, err := service.Rebuild(context.Background())
+	if !errors.Is(err, ErrRebuildValidation) {
+		t.Fatalf("expected ErrRebuildValidation, got %v", err)
+	}
+
+	if _, ok := storage.index["live"]; !ok || len(storage.index) != 1 {
+		t.Errorf("live index must stay untouched, got %v", storage.index)
+	}
+	if len(storage.others) != 0 {
+		t.Errorf("temporary index should be deleted, left: %v", storage.others)
+	}
+}
+
+func TestRebuild_ReplaysDeletionsDuringFill(t *testing.T) {
+	storage := newMockStorage()
+	for i := 0; i < 5; i++ {
+		storage.products[fmt.Sprintf("p%d", i)] = time.Now().Add(-time.Hour)
+	}
+	storage.deleted["old"] = time.Now().Add(-48 * time.Hour)
+	// Товар удалён (слит) после того, как попал в новый индекс
+	storage.onFill = func() {
+		delete(storage.products, "p0")
+		storage.deleted["p0"] = time.Now()
+	}
+
+	service := New(storage, nil)
+	result, err := service.Rebuild(context.Background())
+	if err != nil {
+		t.Fatalf("Rebuild must tolerate deletions during fill: %v", err)
+	}
+
+	if result.Expected != 4 || result.Deleted != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, ok := storage.index["p0"]; ok {
+		t.Error("product deleted during rebuild must be removed from the new index")
+	}
+	if _, ok := storage.deleted["old"]; ok {
+		t.Error("deletions log before the rebuild should be pruned")
+	}
+	if storage.locked {
+		t.Error("rebuild lock must be released")
+	}
+}
+
+func TestRebuild_SkipsWhenLocked(t *testing.T) {
+	storage := newMockStorage()
+	storage.products["p0"] = time.Now()
+	storage.index["live"] = &Document{ID: "live"}
+	storage.locked = true
+
+	service := New(storage, nil)
+	if _, err := service.Rebuild(context.Background()); !errors.Is(err, ErrRebuildInProgress) {
+		t.Fatalf("expected ErrRebuildInProgress, got %v", err)
+	}
+	if len(storage.others) != 0 || len(storage.index) != 1 {
+		t.Errorf("locked rebuild must not touch indexes: others=%v index=%v", storage.others, storage.index)
+	}
+}
@@ -0,0 +1,64 @@
+package indexing
+
+import "time"
+
+// EventType тип события изменения товара
+type EventType string
+
+const (
+	// EventProductUpserted товар создан или обновлён
+	EventProductUpserted EventType = "product_upserted"
+	// EventPriceChanged записана цена товара в магазине
+	EventPriceChanged EventType = "price_changed"
+)
+
+// Event событие изменения товара, по которому обновляется поисковый индекс
+type Event struct {
+	Type       EventType `json:"type"`
+	ProductID  string    `json:"product_id"`
+	ShopID     string    `json:"shop_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Document документ товара в поисковом индексе
+// Агрегаты по ценам (min/max, цена за единицу, shops_count) нужны для фильтров и сортировки каталога,
+// Attrs - типизированные атрибуты справочника по коду в нижнем регистре (фильтры attrs.<code>)
+type Document struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Brand       string                 `json:"brand,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	CategoryID  *string                `json:"category_id,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	ImageURL    string                 `json:"image_url,omitempty"`
+	Specs       map[string]interface{} `json:"specs,omitempty"`
+	Attrs       map[string]interface{} `json:"attrs,omitempty"`
+	Type        string                 `json:"type"` // "good" | "service"
+	ShopNames   []string               `json:"shop_names,omitempty"`
+	ShopsCount  int                    `json:"shops_count"`
+	MinPrice    *float64               `json:"min_price,omitempty"`
+	MaxPrice    *float64               `json:"max_price,omitempty"`
+	Currency    string                 `json:"currency,omitempty"`
+	UnitPrice   *float64               `json:"min_unit_price,omitempty"` // минимальная цена за 1 кг, 1 л или 1 шт
+	Unit        string                 `json:"unit,omitempty"`           // единица цены за единицу: kg, l, pcs
+	CreatedAt   string                 `json:"created_at"`               // RFC3339
+	UpdatedAt   string                 `json:"updated_at"`               // RFC3339
+}
+
+// SyncResult итог синхронизации индекса
+type SyncResult struct {
+	Since     time.Time `json:"since"`
+	Watermark time.Time `json:"watermark"`
+	Indexed   int       `json:"indexed"`
+	Deleted   int       `json:"deleted"` // удалённых из БД товаров, убранных из индекса
+}
+
+// RebuildResult итог пересборки индекса с переключением
+type RebuildResult struct {
+	IndexName string    `json:"index_name"` // временный индекс, который стал рабочим
+	Expected  int64     `json:"expected"`   // товаров в PostgreSQL перед переключением
+	Indexed   int64     `json:"indexed"`    // документов в новом индексе
+	Deleted   int       `json:"deleted"`    // удалённых во время пересборки, убранных после переключения
+	CatchUp   int       `json:"catch_up"`   // изменений, дозалитых после переключения
+	StartedAt time.Time `json:"started_at"` // время БД на начало пересборки
+}
@@ -0,0 +1,87 @@
+package indexing
+
+import (
+	"context"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// ProductsCheckpoint имя checkpoint'а синхронизации индекса товаров
+const ProductsCheckpoint = "products"
+
+// Storage интерфейс для чтения товаров и записи в поисковый индекс
+type Storage interface {
+	// LoadDocuments собирает документы для указанных товаров одним запросом
+	// Отсутствующие в БД товары не возвращаются
+	LoadDocuments(ctx context.Context, productIDs []string) ([]*Document, error)
+
+	// LoadChangedDocuments собирает документы товаров, изменённых (сам товар или его цены)
+	// после since, постранично по id: afterID - последний id предыдущей страницы
+	LoadChangedDocuments(ctx context.Context, since time.Time, afterID string, limit int) ([]*Document, error)
+
+	// UpsertDocuments добавляет или заменяет документы в индексе и дожидается применения
+	UpsertDocuments(ctx context.Context, docs []*Document) error
+
+	// DeleteDocuments удаляет документы из индекса
+	DeleteDocuments(ctx context.Context, ids []string) error
+
+	// DeleteAllDocuments очищает индекс
+	DeleteAllDocuments(ctx context.Context) error
+
+	// UpsertDocumentsTo добавляет или заменяет документы в указанном индексе
+	UpsertDocumentsTo(ctx context.Context, indexName string, docs []*Document) error
+
+	// CountProducts возвращает число товаров в БД
+	CountProducts(ctx context.Context) (int64, error)
+
+	// CreateIndex создаёт пустой индекс товаров с настройками поиска
+	CreateIndex(ctx context.Context, indexName string) error
+
+	// CountDocuments возвращает число документов в индексе
+	CountDocuments(ctx context.Context, indexName string) (int64, error)
+
+	// SwapWithLive атомарно меняет местами indexName и рабочий индекс
+	SwapWithLive(ctx context.Context, indexName string) error
+
+	// DeleteIndex удаляет индекс целиком
+	DeleteIndex(ctx context.Context, indexName string) error
+
+	// ListDeletedProducts возвращает товары, удалённые из БД после since (журнал удалений)
+	ListDeletedProducts(ctx context.Context, since time.Time) ([]string, error)
+
+	// PruneDeletedProducts очищает журнал удалений до before
+	PruneDeletedProducts(ctx context.Context, before time.Time) error
+
+	// TryLockRebuild берёт межпроцессную блокировку пересборки индекса.
+	// ok=false - блокировку держит другой процесс; unlock нужно вызвать при ok=true
+	TryLockRebuild(ctx context.Context) (unlock func(), ok bool, err error)
+
+	// CurrentTime возвращает текущее время БД (источник watermark)
+	CurrentTime(ctx context.Context) (time.Time, error)
+
+	// GetCheckpoint возвращает watermark синхронизации (нулевое время, если её не было)
+	GetCheckpoint(ctx context.Context, name string) (time.Time, error)
+
+	// SaveCheckpoint сохраняет watermark синхронизации
+	SaveCheckpoint(ctx context.Context, name string, watermark time.Time) error
+}
+
+// Service сервис инкрементальной индексации товаров
+type Service struct {
+	storage   Storage
+	batchSize int
+	logger    *logger.Logger
+}
+
+// New создаёт новый сервис индексации
+func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage:   storage,
+		batchSize: defaultSyncBatchSize,
+		logger:    log,
+	}
+}
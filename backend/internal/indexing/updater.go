@@ -0,0 +1,196 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/queue"
+)
+
+const (
+	defaultUpdaterBatchSize     = 200
+	defaultUpdaterFlushInterval = 2 * time.Second
+	updaterRetryDelay           = time.Second
+)
+
+// QueuePublisher публикует события изменения товаров в очередь
+type QueuePublisher struct {
+	client queue.Client
+	topic  string
+}
+
+// NewQueuePublisher создаёт публикатор событий индексации
+func NewQueuePublisher(client queue.Client, topic string) *QueuePublisher {
+	return &QueuePublisher{
+		client: client,
+		topic:  topic,
+	}
+}
+
+// Publish отправляет событие в очередь
+func (p *QueuePublisher) Publish(ctx context.Context, event *Event) error {
+	if event == nil || event.ProductID == "" {
+		return fmt.Errorf("index event product_id is required")
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	return p.client.Publish(p.topic, event)
+}
+
+// UpdaterOptions настройки Updater
+type UpdaterOptions struct {
+	BatchSize     int           // максимум событий в одной пачке
+	FlushInterval time.Duration // сколько ждать добора пачки после первого события
+}
+
+// Updater читает события из очереди и пачками обновляет документы в индексе
+// Несколько событий одного товара в пачке дают одну запись в индекс
+type Updater struct {
+	service *Service
+	source  queue.Reliable
+	opts    UpdaterOptions
+	logger  *logger.Logger
+}
+
+// NewUpdater создаёт обработчик событий индексации
+func NewUpdater(service *Service, source queue.Reliable, opts UpdaterOptions, log *logger.Logger) *Updater {
+	if log == nil {
+		log = logger.New("info")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultUpdaterBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultUpdaterFlushInterval
+	}
+	return &Updater{
+		service: service,
+		source:  source,
+		opts:    opts,
+		logger:  log,
+	}
+}
+
+// Run обрабатывает события до отмены ctx
+// Перед выходом уже полученная пачка записывается в индекс
+func (u *Updater) Run(ctx context.Context, topic string) error {
+	u.logger.Info("Index updater started", map[string]interface{}{
+		"topic":          topic,
+		"batch_size":     u.opts.BatchSize,
+		"flush_interval": u.opts.FlushInterval.String(),
+	})
+
+	for {
+		batch := u.collect(ctx, topic)
+		if len(batch) > 0 {
+			u.flush(context.WithoutCancel(ctx), batch)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// collect набирает пачку: до BatchSize сообщений или FlushInterval после первого
+func (u *Updater) collect(ctx context.Context, topic string) []*queue.Message {
+	var (
+		batch    []*queue.Message
+		deadline time.Time
+	)
+	for len(batch) < u.opts.BatchSize {
+		if ctx.Err() != nil {
+			return batch
+		}
+		if len(batch) > 0 && time.Now().After(deadline) {
+			return batch
+		}
+
+		msgs, err := u.source.Fetch(ctx, topic, u.opts.BatchSize-len(batch))
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return batch
+			}
+			u.logger.Warn("Index updater fetch failed", map[string]interface{}{
+				"topic": topic,
+				"error": err.Error(),
+			})
+			if len(batch) > 0 {
+				return batch
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(updaterRetryDelay):
+			}
+			continue
+		}
+
+		if len(batch) == 0 && len(msgs) > 0 {
+			deadline = time.Now().Add(u.opts.FlushInterval)
+		}
+		batch = append(batch, msgs...)
+	}
+	return batch
+}
+
+// flush индексирует товары из пачки и подтверждает сообщения
+// При ошибке индексации сообщения остаются неподтверждёнными для повторной доставки
+func (u *Updater) flush(ctx context.Context, batch []*queue.Message) {
+	var (
+		productIDs []string
+		valid      []*queue.Message
+	)
+	for _, msg := range batch {
+		var event Event
+		if err := json.Unmarshal(msg.Payload, &event); err != nil || event.ProductID == "" {
+			if err == nil {
+				err = fmt.Errorf("index event product_id is required")
+			}
+			u.fail(ctx, msg, queue.Permanent(err))
+			continue
+		}
+		productIDs = append(productIDs, event.ProductID)
+		valid = append(valid, msg)
+	}
+	if len(valid) == 0 {
+		return
+	}
+
+	if err := u.service.IndexProducts(ctx, productIDs); err != nil {
+		u.logger.Error("Index updater batch failed", map[string]interface{}{
+			"events": len(valid),
+			"error":  err.Error(),
+		})
+		for _, msg := range valid {
+			u.fail(ctx, msg, err)
+		}
+		return
+	}
+
+	for _, msg := range valid {
+		if err := u.source.Ack(ctx, msg); err != nil {
+			u.logger.Warn("Index updater ack failed", map[string]interface{}{
+				"message_id": msg.ID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	u.logger.Debug("Index updater batch flushed", map[string]interface{}{
+		"events":   len(valid),
+		"products": len(uniqueIDs(productIDs)),
+	})
+}
+
+func (u *Updater) fail(ctx context.Context, msg *queue.Message, err error) {
+	if failErr := u.source.Fail(ctx, msg, err); failErr != nil {
+		u.logger.Warn("Index updater failed to return message to queue", map[string]interface{}{
+			"message_id": msg.ID,
+			"error":      failErr.Error(),
+		})
+	}
+}
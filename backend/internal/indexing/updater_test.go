@@ -0,0 +1,154 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/queue"
+)
+
+// memorySource in-memory queue.Reliable для тестов Updater
+type memorySource struct {
+	mu     sync.Mutex
+	queue  []*queue.Message
+	acked  int
+	failed []error
+}
+
+func (s *memorySource) push(payload []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, &queue.Message{Payload: payload})
+}
+
+func (s *memorySource) Fetch(ctx context.Context, topic string, count int) ([]*queue.Message, error) {
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Millisecond):
+		}
+		return nil, nil
+	}
+	if count > len(s.queue) {
+		count = len(s.queue)
+	}
+	batch := s.queue[:count]
+	s.queue = s.queue[count:]
+	s.mu.Unlock()
+	return batch, nil
+}
+
+func (s *memorySource) Ack(ctx context.Context, msg *queue.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked++
+	return nil
+}
+
+func (s *memorySource) Fail(ctx context.Context, msg *queue.Message, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, err)
+	return nil
+}
+
+func (s *memorySource) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked, len(s.failed)
+}
+
+func eventPayload(t *testing.T, eventType EventType, productID string) []byte {
+	t.Helper()
+	payload, err := json.Marshal(&Event{Type: eventType, ProductID: productID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestUpdater_BatchesEvents(t *testing.T) {
+	storage := newMockStorage()
+	storage.products["a"] = time.Now()
+	storage.products["b"] = time.Now()
+
+	source := &memorySource{}
+	source.push(eventPayload(t, EventProductUpserted, "a"))
+	source.push(eventPayload(t, EventPriceChanged, "a"))
+	source.push(eventPayload(t, EventPriceChanged, "b"))
+	source.push([]byte(`not json`))
+
+	updater := NewUpdater(New(storage, nil), source, UpdaterOptions{BatchSize: 10, FlushInterval: 20 * time.Millisecond}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- updater.Run(ctx, "index") }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		acked, failed := source.counts()
+		if acked+failed == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("events not handled in time: acked=%d failed=%d", acked, failed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	acked, _ := source.counts()
+	if acked != 3 {
+		t.Errorf("acked = %d, want 3", acked)
+	}
+	if len(source.failed) != 1 || !queue.IsPermanent(source.failed[0]) {
+		t.Errorf("invalid payload should fail permanently, got %v", source.failed)
+	}
+	if storage.upserts != 1 {
+		t.Errorf("upserts = %d, want a single batched upsert", storage.upserts)
+	}
+	if len(storage.index) != 2 {
+		t.Errorf("index size = %d, want 2", len(storage.index))
+	}
+}
+
+func TestUpdater_FailsBatchOnIndexError(t *testing.T) {
+	storage := newMockStorage()
+	storage.products["a"] = time.Now()
+	storage.upsertErr = context.DeadlineExceeded
+
+	source := &memorySource{}
+	source.push(eventPayload(t, EventPriceChanged, "a"))
+
+	updater := NewUpdater(New(storage, nil), source, UpdaterOptions{FlushInterval: time.Millisecond}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- updater.Run(ctx, "index") }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, failed := source.counts(); failed == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("batch was not failed in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if acked, _ := source.counts(); acked != 0 {
+		t.Errorf("acked = %d, want 0", acked)
+	}
+	if queue.IsPermanent(source.failed[0]) {
+		t.Error("index error should be retried, not dead-lettered")
+	}
+}
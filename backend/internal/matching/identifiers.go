@@ -0,0 +1,305 @@
+package matching
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/structured"
+)
+
+// specIdentifierKeys названия характеристик, в которых магазины публикуют идентификаторы
+var specIdentifierKeys = map[string]IdentifierType{
+	"gtin":                     IdentifierGTIN,
+	"gtin8":                    IdentifierGTIN,
+	"gtin12":                   IdentifierGTIN,
+	"gtin13":                   IdentifierGTIN,
+	"gtin14":                   IdentifierGTIN,
+	"ean":                      IdentifierGTIN,
+	"ean13":                    IdentifierGTIN,
+	"ean kod":                  IdentifierGTIN,
+	"ean code":                 IdentifierGTIN,
+	"upc":                      IdentifierGTIN,
+	"barcode":                  IdentifierGTIN,
+	"bar kod":                  IdentifierGTIN,
+	"barkod":                   IdentifierGTIN,
+	"mpn":                      IdentifierMPN,
+	"part number":              IdentifierMPN,
+	"partnumber":               IdentifierMPN,
+	"manufacturer part number": IdentifierMPN,
+	"šifra proizvođača":        IdentifierMPN,
+	"sku":                      IdentifierSKU,
+	"šifra artikla":            IdentifierSKU,
+}
+
+// minCodeLength минимальная длина MPN/SKU: короткие коды неуникальны
+const minCodeLength = 3
+
+// normalizeIdentifiers нормализует идентификаторы запроса и дополняет их из характеристик
+func (s *Service) normalizeIdentifiers(req *MatchRequest) []Identifier {
+	candidates := append([]Identifier{}, req.Identifiers...)
+	for key, value := range req.Specs {
+		specKey := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(key, "-", ""))), " ")
+		if idType, ok := specIdentifierKeys[specKey]; ok {
+			candidates = append(candidates, Identifier{Type: idType, Value: value})
+		}
+	}
+
+	seen := make(map[Identifier]bool, len(candidates))
+	result := make([]Identifier, 0, len(candidates))
+	for _, id := range candidates {
+		normalized, ok := s.normalizeIdentifier(id, req.Brand, req.ShopID)
+		if !ok || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		result = append(result, normalized)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		pi, pj := identifierRank(result[i].Type), identifierRank(result[j].Type)
+		if pi != pj {
+			return pi < pj
+		}
+		if result[i].Scope != result[j].Scope {
+			return result[i].Scope < result[j].Scope
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+// normalizeIdentifier приводит идентификатор к виду, в котором он хранится:
+// GTIN - 14 цифр с проверенной контрольной цифрой, MPN - в пределах бренда,
+// SKU - в пределах магазина
+func (s *Service) normalizeIdentifier(id Identifier, brand, shopID string) (Identifier, bool) {
+	switch IdentifierType(strings.ToLower(string(id.Type))) {
+	case IdentifierGTIN, "ean", "upc":
+		gtin := structured.NormalizeGTIN(id.Value)
+		if gtin == "" {
+			return Identifier{}, false
+		}
+		return Identifier{Type: IdentifierGTIN, Value: strings.Repeat("0", 14-len(gtin)) + gtin}, true
+
+	case IdentifierMPN:
+		scope := id.Scope
+		if scope == "" {
+			scope = brand
+		}
+		scope = s.normalizeBrand(scope)
+		value := normalizeCode(id.Value)
+		if scope == "" || len(value) < minCodeLength {
+			return Identifier{}, false
+		}
+		return Identifier{Type: IdentifierMPN, Scope: scope, Value: value}, true
+
+	case IdentifierSKU:
+		scope := id.Scope
+		if scope == "" {
+			scope = shopID
+		}
+		value := normalizeCode(id.Value)
+		if scope == "" || len(value) < minCodeLength {
+			return Identifier{}, false
+		}
+		return Identifier{Type: IdentifierSKU, Scope: scope, Value: value}, true
+	}
+	return Identifier{}, false
+}
+
+// normalizeCode оставляет в артикуле только буквы и цифры в верхнем регистре:
+// "SM-A556B/DS" и "sm a556b ds" совпадают
+func normalizeCode(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func identifierRank(t IdentifierType) int {
+	for i, candidate := range identifierPriority {
+		if candidate == t {
+			return i
+		}
+	}
+	return len(identifierPriority)
+}
+
+// matchByIdentifiers быстрый путь: точное совпадение идентификатора важнее
+// любой нечёткой схожести названий. Идентификатор, принадлежащий нескольким
+// товарам, считается конфликтом и для сопоставления не используется.
+func (s *Service) matchByIdentifiers(req *MatchRequest, ids []Identifier) (*ProductMatch, []*IdentifierConflict, error) {
+	owners, err := s.storage.FindProductsByIdentifiers(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byIdentifier := make(map[Identifier][]string)
+	for _, owner := range owners {
+		if owner.ProductID == req.ProductID {
+			continue
+		}
+		byIdentifier[owner.Identifier] = appendUnique(byIdentifier[owner.Identifier], owner.ProductID)
+	}
+
+	var conflicts []*IdentifierConflict
+	for _, idType := range identifierPriority {
+		var candidates []string
+		for _, id := range ids {
+			if id.Type != idType {
+				continue
+			}
+			productIDs := byIdentifier[id]
+			if len(productIDs) > 1 {
+				conflicts = append(conflicts, s.recordConflict(id, productIDs))
+				continue
+			}
+			for _, productID := range productIDs {
+				candidates = appendUnique(candidates, productID)
+			}
+		}
+
+		// Разные идентификаторы одного типа указывают на разные товары - не угадываем
+		if len(candidates) != 1 {
+			continue
+		}
+		return &ProductMatch{
+			ProductID:  req.ProductID,
+			MatchedID:  candidates[0],
+			Similarity: 1.0,
+			MatchedAt:  time.Now(),
+			Confidence: "high",
+			MatchedBy:  string(idType),
+		}, conflicts, nil
+	}
+
+	return nil, conflicts, nil
+}
+
+// AttachIdentifiers привязывает идентификаторы запроса к товару и сообщает о конфликтах,
+// если такой же идентификатор уже есть у другого товара
+func (s *Service) AttachIdentifiers(productID string, req *MatchRequest) ([]*IdentifierConflict, error) {
+	ids, others, err := s.identifierOwners(productID, req)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var conflicts []*IdentifierConflict
+	for _, id := range ids {
+		if productIDs := others[id]; len(productIDs) > 0 {
+			conflicts = append(conflicts, s.recordConflict(id, appendUnique(productIDs, productID)))
+		}
+	}
+
+	if err := s.storage.SaveIdentifiers(productID, ids); err != nil {
+		return conflicts, err
+	}
+	return conflicts, nil
+}
+
+// CheckIdentifiers сообщает, какие идентификаторы запроса уже принадлежат другим товарам,
+// ничего не сохраняя: для нечётких совпадений идентификаторы не привязываются
+func (s *Service) CheckIdentifiers(productID string, req *MatchRequest) ([]*IdentifierConflict, error) {
+	ids, others, err := s.identifierOwners(productID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []*IdentifierConflict
+	for _, id := range ids {
+		if productIDs := others[id]; len(productIDs) > 0 {
+			conflicts = append(conflicts, &IdentifierConflict{
+				Identifier: id,
+				ProductIDs: appendUnique(productIDs, productID),
+				DetectedAt: time.Now(),
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// identifierOwners нормализует идентификаторы запроса и находит товары, кроме productID, которым они принадлежат
+func (s *Service) identifierOwners(productID string, req *MatchRequest) ([]Identifier, map[Identifier][]string, error) {
+	if productID == "" || req == nil {
+		return nil, nil, nil
+	}
+	ids := s.normalizeIdentifiers(req)
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	owners, err := s.storage.FindProductsByIdentifiers(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	others := make(map[Identifier][]string)
+	for _, owner := range owners {
+		if owner.ProductID != productID {
+			others[owner.Identifier] = appendUnique(others[owner.Identifier], owner.ProductID)
+		}
+	}
+	return ids, others, nil
+}
+
+// recordConflict сохраняет конфликт идентификатора; ошибка сохранения только логируется
+func (s *Service) recordConflict(id Identifier, productIDs []string) *IdentifierConflict {
+	conflict := &IdentifierConflict{
+		Identifier: id,
+		ProductIDs: productIDs,
+		DetectedAt: time.Now(),
+	}
+	s.logger.Warn("Identifier shared by several products", map[string]interface{}{
+		"type":        id.Type,
+		"scope":       id.Scope,
+		"value":       id.Value,
+		"product_ids": productIDs,
+	})
+	if err := s.storage.SaveIdentifierConflict(conflict); err != nil {
+		s.logger.Error("Failed to save identifier conflict", map[string]interface{}{
+			"value": id.Value,
+			"error": err.Error(),
+		})
+	}
+	return conflict
+}
+
+// identifiersDisagree у обоих товаров есть GTIN (или MPN одного бренда), но ни один не совпадает:
+// это разные товары, даже если названия почти одинаковы (например, разный объём памяти)
+func identifiersDisagree(a, b []Identifier) bool {
+	for _, idType := range []IdentifierType{IdentifierGTIN, IdentifierMPN} {
+		left := make(map[Identifier]bool)
+		scopes := make(map[string]bool)
+		for _, id := range a {
+			if id.Type == idType {
+				left[id] = true
+				scopes[id.Scope] = true
+			}
+		}
+		compared, matched := false, false
+		for _, id := range b {
+			if id.Type != idType || !scopes[id.Scope] {
+				continue
+			}
+			compared = true
+			if left[id] {
+				matched = true
+			}
+		}
+		if compared && !matched {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,212 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	similar     []*Product
+	owners      []*IdentifierOwner
+	identifiers map[string][]Identifier
+	saved       map[string][]Identifier
+	conflicts   []*IdentifierConflict
+}
+
+func (m *mockStorage) FindSimilarProducts(name, brand string, productType string, limit int) ([]*Product, error) {
+	return m.similar, nil
+}
+
+func (m *mockStorage) GetProductByID(id string) (*Product, error) {
+	return nil, ErrMatchNotFound
+}
+
+func (m *mockStorage) SaveMatch(match *ProductMatch) error {
+	return nil
+}
+
+func (m *mockStorage) GetMatches(productID string) ([]*ProductMatch, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) FindProductsByIdentifiers(ids []Identifier) ([]*IdentifierOwner, error) {
+	wanted := make(map[Identifier]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var result []*IdentifierOwner
+	for _, owner := range m.owners {
+		if wanted[owner.Identifier] {
+			result = append(result, owner)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockStorage) GetIdentifiers(productIDs []string) (map[string][]Identifier, error) {
+	result := make(map[string][]Identifier)
+	for _, id := range productIDs {
+		if ids, ok := m.identifiers[id]; ok {
+			result[id] = ids
+		}
+	}
+	return result, nil
+}
+
+func (m *mockStorage) SaveIdentifiers(productID string, ids []Identifier) error {
+	if m.saved == nil {
+		m.saved = make(map[string][]Identifier)
+	}
+	m.saved[productID] = append(m.saved[productID], ids...)
+	return nil
+}
+
+func (m *mockStorage) SaveIdentifierConflict(conflict *IdentifierConflict) error {
+	m.conflicts = append(m.conflicts, conflict)
+	return nil
+}
+
+var gtinA = Identifier{Type: IdentifierGTIN, Value: "04006381333931"}
+
+func TestMatchProduct_IdentifierBeatsFuzzy(t *testing.T) {
+	storage := &mockStorage{
+		similar: []*Product{{ID: "fuzzy-id", Name: "Samsung Galaxy A55 128GB", Brand: "Samsung"}},
+		owners:  []*IdentifierOwner{{ProductID: "gtin-id", Identifier: gtinA}},
+	}
+	service := New(storage, nil, logger.New("error"))
+
+	result, err := service.MatchProduct(&MatchRequest{
+		Name:        "Samsung Galaxy A55 128GB",
+		Brand:       "Samsung",
+		Identifiers: []Identifier{{Type: "ean", Value: "4006381333931"}},
+	})
+	if err != nil {
+		t.Fatalf("MatchProduct failed: %v", err)
+	}
+	if result.Count != 1 || result.Matches[0].MatchedID != "gtin-id" {
+		t.Fatalf("expected exact identifier match, got %+v", result.Matches)
+	}
+	match := result.Matches[0]
+	if match.Similarity != 1.0 || match.MatchedBy != "gtin" || match.Confidence != "high" {
+		t.Errorf("unexpected match: %+v", match)
+	}
+}
+
+func TestMatchProduct_IdentifiersFromSpecsWithoutName(t *testing.T) {
+	storage := &mockStorage{
+		owners: []*IdentifierOwner{{
+			ProductID:  "mpn-id",
+			Identifier: Identifier{Type: IdentifierMPN, Scope: "samsung", Value: "SMA556B"},
+		}},
+	}
+	service := New(storage, nil, logger.New("error"))
+
+	result, err := service.MatchProduct(&MatchRequest{
+		Brand: "Samsung",
+		Specs: map[string]string{"Part Number": "sm-a556b"},
+	})
+	if err != nil {
+		t.Fatalf("MatchProduct failed: %v", err)
+	}
+	if result.Count != 1 || result.Matches[0].MatchedID != "mpn-id" || result.Matches[0].MatchedBy != "mpn" {
+		t.Fatalf("expected mpn match, got %+v", result.Matches)
+	}
+}
+
+func TestMatchProduct_SharedGTINIsConflict(t *testing.T) {
+	storage := &mockStorage{
+		similar: []*Product{{ID: "a", Name: "Samsung Galaxy A55", Brand: "Samsung"}},
+		owners: []*IdentifierOwner{
+			{ProductID: "a", Identifier: gtinA},
+			{ProductID: "b", Identifier: gtinA},
+		},
+	}
+	service := New(storage, nil, logger.New("error"))
+
+	result, err := service.MatchProduct(&MatchRequest{
+		Name:        "Samsung Galaxy A55",
+		Brand:       "Samsung",
+		Identifiers: []Identifier{{Type: IdentifierGTIN, Value: "4006381333931"}},
+	})
+	if err != nil {
+		t.Fatalf("MatchProduct failed: %v", err)
+	}
+	if len(result.Conflicts) != 1 || len(storage.conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %d (stored %d)", len(result.Conflicts), len(storage.conflicts))
+	}
+	if got := storage.conflicts[0].ProductIDs; len(got) != 2 {
+		t.Errorf("expected both products in conflict, got %v", got)
+	}
+	// Конфликтный GTIN не используется: решение принимает нечёткое сопоставление
+	if result.Count != 1 || result.Matches[0].MatchedBy != "" {
+		t.Errorf("expected fuzzy match, got %+v", result.Matches)
+	}
+}
+
+func TestMatchProduct_DifferentGTINRejectsFuzzyCandidate(t *testing.T) {
+	storage := &mockStorage{
+		similar: []*Product{{ID: "256gb", Name: "Samsung Galaxy A55", Brand: "Samsung"}},
+		identifiers: map[string][]Identifier{
+			"256gb": {{Type: IdentifierGTIN, Value: "05901234123457"}},
+		},
+	}
+	service := New(storage, nil, logger.New("error"))
+
+	result, err := service.MatchProduct(&MatchRequest{
+		Name:        "Samsung Galaxy A55",
+		Brand:       "Samsung",
+		Identifiers: []Identifier{{Type: IdentifierGTIN, Value: "4006381333931"}},
+	})
+	if err != nil {
+		t.Fatalf("MatchProduct failed: %v", err)
+	}
+	if result.Count != 0 {
+		t.Errorf("candidate with another GTIN must be rejected, got %+v", result.Matches)
+	}
+}
+
+func TestAttachIdentifiers_DetectsConflict(t *testing.T) {
+	storage := &mockStorage{
+		owners: []*IdentifierOwner{{ProductID: "other", Identifier: gtinA}},
+	}
+	service := New(storage, nil, logger.New("error"))
+
+	conflicts, err := service.AttachIdentifiers("new", &MatchRequest{
+		Brand:  "Samsung",
+		ShopID: "shop-1",
+		Identifiers: []Identifier{
+			{Type: IdentifierGTIN, Value: "4006381333931"},
+			{Type: IdentifierSKU, Value: "a-556"},
+			{Type: IdentifierGTIN, Value: "4006381333932"}, // неверная контрольная цифра
+		},
+	})
+	if err != nil {
+		t.Fatalf("AttachIdentifiers failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Identifier != gtinA {
+		t.Fatalf("expected gtin conflict, got %+v", conflicts)
+	}
+	saved := storage.saved["new"]
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 saved identifiers, got %+v", saved)
+	}
+	if saved[1] != (Identifier{Type: IdentifierSKU, Scope: "shop-1", Value: "A556"}) {
+		t.Errorf("unexpected sku identifier %+v", saved[1])
+	}
+}
+
+func TestNormalizeIdentifier_RequiresScope(t *testing.T) {
+	service := &Service{}
+
+	if _, ok := service.normalizeIdentifier(Identifier{Type: IdentifierMPN, Value: "A556B"}, "", ""); ok {
+		t.Error("mpn without brand must be ignored")
+	}
+	if _, ok := service.normalizeIdentifier(Identifier{Type: IdentifierSKU, Value: "A556B"}, "Samsung", ""); ok {
+		t.Error("sku without shop must be ignored")
+	}
+	id, ok := service.normalizeIdentifier(Identifier{Type: IdentifierGTIN, Value: "96385074"}, "", "")
+	if !ok || id.Value != "00000096385074" {
+		t.Errorf("expected gtin-8 padded to 14 digits, got %+v", id)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/solomonczyk/izborator/internal/brands"
 )
 
 // MatchProduct сопоставляет товар с существующими
@@ -12,6 +14,26 @@ func (s *Service) MatchProduct(req *MatchRequest) (*MatchResult, error) {
 		return nil, ErrInsufficientData
 	}
 
+	// Быстрый путь: точное совпадение GTIN/MPN/SKU
+	ids := s.normalizeIdentifiers(req)
+	var conflicts []*IdentifierConflict
+	if len(ids) > 0 {
+		match, found, err := s.matchByIdentifiers(req, ids)
+		if err != nil {
+			s.logger.Warn("Identifier lookup failed, falling back to fuzzy matching", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		conflicts = found
+		if match != nil {
+			return &MatchResult{
+				Matches:   []*ProductMatch{match},
+				Count:     1,
+				Conflicts: conflicts,
+			}, nil
+		}
+	}
+
 	if req.Name == "" {
 		return nil, ErrInsufficientData
 	}
@@ -24,10 +46,10 @@ func (s *Service) MatchProduct(req *MatchRequest) (*MatchResult, error) {
 
 	// Нормализуем данные для поиска
 	normalizedName := s.normalizeName(req.Name, productType)
-	normalizedBrand := s.normalizeBrand(req.Brand)
+	canonicalBrand := s.canonicalBrand(req.Brand)
 
-	// Ищем похожие товары или услуги
-	similar, err := s.storage.FindSimilarProducts(normalizedName, normalizedBrand, productType, 10)
+	// Ищем похожие товары или услуги (бренды товаров хранятся в каноническом написании)
+	similar, err := s.storage.FindSimilarProducts(normalizedName, canonicalBrand, productType, 10)
 	if err != nil {
 		s.logger.Error("Failed to find similar products", map[string]interface{}{
 			"error": err,
@@ -35,9 +57,20 @@ func (s *Service) MatchProduct(req *MatchRequest) (*MatchResult, error) {
 		return nil, fmt.Errorf("failed to find similar: %w", err)
 	}
 
+	// Идентификаторы кандидатов: товар с другим GTIN/MPN не может быть тем же товаром
+	candidateIDs := s.candidateIdentifiers(ids, similar)
+
 	// Рассчитываем схожесть для каждого найденного товара или услуги
 	matches := make([]*ProductMatch, 0, len(similar))
 	for _, product := range similar {
+		if identifiersDisagree(ids, candidateIDs[product.ID]) {
+			s.logger.Debug("Candidate rejected by identifiers", map[string]interface{}{
+				"candidate_id": product.ID,
+				"name":         product.Name,
+			})
+			continue
+		}
+
 		// Для услуг используем более мягкий порог
 		threshold := 0.5
 		if productType == "service" {
@@ -56,11 +89,44 @@ func (s *Service) MatchProduct(req *MatchRequest) (*MatchResult, error) {
 	}
 
 	return &MatchResult{
-		Matches: matches,
-		Count:   len(matches),
+		Matches:   matches,
+		Count:     len(matches),
+		Conflicts: conflicts,
 	}, nil
 }
 
+// candidateIdentifiers загружает идентификаторы кандидатов, если они есть у запроса
+func (s *Service) candidateIdentifiers(ids []Identifier, candidates []*Product) map[string][]Identifier {
+	result := make(map[string][]Identifier, len(candidates))
+	if len(ids) == 0 || len(candidates) == 0 {
+		return result
+	}
+
+	missing := make([]string, 0, len(candidates))
+	for _, product := range candidates {
+		if len(product.Identifiers) > 0 {
+			result[product.ID] = product.Identifiers
+		} else {
+			missing = append(missing, product.ID)
+		}
+	}
+	if len(missing) == 0 {
+		return result
+	}
+
+	loaded, err := s.storage.GetIdentifiers(missing)
+	if err != nil {
+		s.logger.Warn("Failed to load candidate identifiers", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return result
+	}
+	for productID, productIDs := range loaded {
+		result[productID] = productIDs
+	}
+	return result
+}
+
 // normalizeUnits нормализует единицы измерения
 func (s *Service) normalizeUnits(text string) string {
 	// Нормализация единиц веса
@@ -211,40 +277,19 @@ func allNumericWords(words []string) bool {
 }
 
 
-// normalizeBrand нормализует бренд
+// normalizeBrand ключ бренда для сравнения: каноническое название из справочника брендов
+// без регистра, пробелов и знаков ("Hewlett-Packard" и "HP" -> "hp")
 func (s *Service) normalizeBrand(brand string) string {
-	if brand == "" {
-		return ""
-	}
-
-	brand = strings.ToLower(strings.TrimSpace(brand))
-
-	// Нормализуем тире и дефисы
-	brand = strings.ReplaceAll(brand, "-", "")
-	brand = strings.ReplaceAll(brand, "_", "")
-	brand = strings.ReplaceAll(brand, " ", "")
-
-	// Известные варианты написания брендов
-	brandAliases := map[string]string{
-		"samsung":  "samsung",
-		"apple":    "apple",
-		"xiaomi":   "xiaomi",
-		"huawei":   "huawei",
-		"motorola": "motorola",
-		"lg":       "lg",
-		"sony":     "sony",
-		"nokia":    "nokia",
-		"oneplus":  "oneplus",
-		"oppo":     "oppo",
-		"vivo":     "vivo",
-		"realme":   "realme",
-	}
+	return brands.Key(s.canonicalBrand(brand))
+}
 
-	if normalized, ok := brandAliases[brand]; ok {
-		return normalized
+// canonicalBrand каноническое название бренда; без справочника - написание как есть
+func (s *Service) canonicalBrand(brand string) string {
+	brand = strings.TrimSpace(brand)
+	if brand == "" || s.brands == nil {
+		return brand
 	}
-
-	return brand
+	return s.brands.Canonicalize(brand)
 }
 
 // calculateSimilarity рассчитывает схожесть между товарами или услугами
@@ -8,7 +8,9 @@ type ProductMatch struct {
 	MatchedID  string    `json:"matched_id"`
 	Similarity float64   `json:"similarity"` // 0.0 - 1.0
 	MatchedAt  time.Time `json:"matched_at"`
-	Confidence string    `json:"confidence"` // "high", "medium", "low"
+	Confidence string    `json:"confidence"`           // "high", "medium", "low"
+	MatchedBy  string    `json:"matched_by,omitempty"` // тип идентификатора при точном совпадении
+	ShopID     string    `json:"shop_id,omitempty"`    // предложение магазина привязано к MatchedID до проверки
 }
 
 // MatchRequest запрос на сопоставление товара или услуги
@@ -18,10 +20,47 @@ type MatchRequest struct {
 	Brand     string            `json:"brand"`
 	Specs     map[string]string `json:"specs"`
 	Type      string            `json:"type,omitempty"` // "good" | "service"
+	// ShopID магазин-источник: область видимости SKU
+	ShopID string `json:"shop_id,omitempty"`
+	// Identifiers GTIN/EAN, MPN, SKU из структурированных данных (дополняются из Specs)
+	Identifiers []Identifier `json:"identifiers,omitempty"`
 }
 
 // MatchResult результат поиска похожих товаров
 type MatchResult struct {
-	Matches []*ProductMatch `json:"matches"`
-	Count   int             `json:"count"`
+	Matches   []*ProductMatch       `json:"matches"`
+	Count     int                   `json:"count"`
+	Conflicts []*IdentifierConflict `json:"conflicts,omitempty"`
+}
+
+// IdentifierType тип идентификатора товара
+type IdentifierType string
+
+const (
+	IdentifierGTIN IdentifierType = "gtin" // GTIN-8/12/13/14 (EAN, UPC), хранится как GTIN-14
+	IdentifierMPN  IdentifierType = "mpn"  // артикул производителя, уникален в пределах бренда
+	IdentifierSKU  IdentifierType = "sku"  // артикул магазина, уникален в пределах магазина
+)
+
+// identifierPriority порядок проверки идентификаторов: от глобального к локальному
+var identifierPriority = []IdentifierType{IdentifierGTIN, IdentifierMPN, IdentifierSKU}
+
+// Identifier нормализованный идентификатор товара
+type Identifier struct {
+	Type  IdentifierType `json:"type"`
+	Scope string         `json:"scope,omitempty"` // "" для GTIN, бренд для MPN, shop_id для SKU
+	Value string         `json:"value"`
+}
+
+// IdentifierOwner товар, которому принадлежит идентификатор
+type IdentifierOwner struct {
+	ProductID  string
+	Identifier Identifier
+}
+
+// IdentifierConflict один идентификатор принадлежит нескольким товарам
+type IdentifierConflict struct {
+	Identifier Identifier `json:"identifier"`
+	ProductIDs []string   `json:"product_ids"`
+	DetectedAt time.Time  `json:"detected_at"`
 }
@@ -17,28 +17,49 @@ type Storage interface {
 
 	// GetMatches получает все сопоставления для товара
 	GetMatches(productID string) ([]*ProductMatch, error)
+
+	// FindProductsByIdentifiers ищет товары с любым из идентификаторов
+	FindProductsByIdentifiers(ids []Identifier) ([]*IdentifierOwner, error)
+
+	// GetIdentifiers возвращает идентификаторы товаров (product_id -> идентификаторы)
+	GetIdentifiers(productIDs []string) (map[string][]Identifier, error)
+
+	// SaveIdentifiers привязывает идентификаторы к товару (повторная привязка не ошибка)
+	SaveIdentifiers(productID string, ids []Identifier) error
+
+	// SaveIdentifierConflict сохраняет конфликт, объединяя товары с уже известными
+	SaveIdentifierConflict(conflict *IdentifierConflict) error
 }
 
 // Product используется из пакета products
 // Импортируется через интерфейс или копируется структура
 type Product struct {
-	ID    string
-	Name  string
-	Brand string
-	Specs map[string]string
-	Type  string // "good" | "service"
+	ID          string
+	Name        string
+	Brand       string
+	Specs       map[string]string
+	Type        string // "good" | "service"
+	Identifiers []Identifier
+}
+
+// Brands справочник брендов: каноническое название по любому написанию
+type Brands interface {
+	Canonicalize(raw string) string
 }
 
 // Service сервис для сопоставления товаров между магазинами
 type Service struct {
 	storage Storage
+	brands  Brands
 	logger  *logger.Logger
 }
 
-// New создаёт новый сервис сопоставления
-func New(storage Storage, log *logger.Logger) *Service {
+// New создаёт новый сервис сопоставления; brandNames может быть nil,
+// тогда бренды сравниваются по написанию без регистра и знаков
+func New(storage Storage, brandNames Brands, log *logger.Logger) *Service {
 	return &Service{
 		storage: storage,
+		brands:  brandNames,
 		logger:  log,
 	}
 }
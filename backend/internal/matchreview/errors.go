@@ -0,0 +1,20 @@
+package matchreview
+
+import "errors"
+
+var (
+	// ErrMatchNotFound сопоставление не найдено
+	ErrMatchNotFound = errors.New("match not found")
+
+	// ErrMatchNotPending сопоставление уже проверено
+	ErrMatchNotPending = errors.New("match already reviewed")
+
+	// ErrProductNotFound товар не найден
+	ErrProductNotFound = errors.New("product not found")
+
+	// ErrInvalidRequest невалидные параметры операции
+	ErrInvalidRequest = errors.New("invalid review request")
+
+	// ErrInvalidSplit разделение должно перенести часть предложений, но не все
+	ErrInvalidSplit = errors.New("split must move some but not all offers")
+)
@@ -0,0 +1,271 @@
+package matchreview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultListLimit  = 50
+	maxListLimit      = 200
+	defaultAuditLimit = 100
+)
+
+// ListPending возвращает очередь непроверенных сопоставлений medium/low, самые похожие первыми
+func (s *Service) ListPending(ctx context.Context, filter ListFilter) (*MatchList, error) {
+	switch filter.Confidence {
+	case "", ConfidenceMedium, ConfidenceLow:
+	default:
+		return nil, fmt.Errorf("%w: confidence must be medium or low", ErrInvalidRequest)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := s.storage.ListPending(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending matches: %w", err)
+	}
+
+	return &MatchList{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// Approve подтверждает сопоставление: товар из нового предложения сливается с кандидатом,
+// предварительная привязка предложения к кандидату закрепляется
+func (s *Service) Approve(ctx context.Context, matchID, actor, note string) (*MergeResult, error) {
+	match, err := s.pendingMatch(ctx, matchID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := s.newEntry(ActionApprove, match.ProductID, match.MatchedID, actor, note)
+	entry.MatchID = &match.ID
+	entry.Details["similarity"] = match.Similarity
+	entry.Details["confidence"] = match.Confidence
+
+	if match.ShopID != "" {
+		entry.Details["shop_id"] = match.ShopID
+		if err := s.storage.ConfirmMatch(ctx, match.ID, entry); err != nil {
+			return nil, fmt.Errorf("failed to confirm match: %w", err)
+		}
+		s.logger.Info("matchreview: provisional match confirmed", map[string]interface{}{
+			"match_id":   match.ID,
+			"product_id": match.MatchedID,
+			"shop_id":    match.ShopID,
+			"actor":      actor,
+		})
+		return &MergeResult{TargetID: match.MatchedID}, nil
+	}
+
+	result, err := s.storage.MergeProducts(ctx, match.ProductID, match.MatchedID, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge matched products: %w", err)
+	}
+
+	s.logger.Info("matchreview: match approved", map[string]interface{}{
+		"match_id":   match.ID,
+		"source_id":  match.ProductID,
+		"target_id":  match.MatchedID,
+		"actor":      actor,
+		"moved":      result.MovedPrices,
+		"raw_linked": result.MovedRawProducts,
+	})
+	s.reindex(ctx, match.ProductID, match.MatchedID)
+
+	return result, nil
+}
+
+// Reject отклоняет сопоставление: товары остаются разными, повторно в очередь пара не попадёт.
+// Предварительно привязанное предложение выделяется в отдельный товар
+func (s *Service) Reject(ctx context.Context, matchID, actor, note string) error {
+	match, err := s.pendingMatch(ctx, matchID, actor)
+	if err != nil {
+		return err
+	}
+
+	entry := s.newEntry(ActionReject, match.ProductID, match.MatchedID, actor, note)
+	entry.MatchID = &match.ID
+	entry.Details["similarity"] = match.Similarity
+	entry.Details["confidence"] = match.Confidence
+
+	if match.ShopID != "" {
+		entry.Details["shop_id"] = match.ShopID
+		result, err := s.storage.DetachOffer(ctx, match.ID, entry)
+		if err != nil {
+			return fmt.Errorf("failed to detach offer: %w", err)
+		}
+		s.logger.Info("matchreview: provisional match rejected", map[string]interface{}{
+			"match_id":       match.ID,
+			"product_id":     match.MatchedID,
+			"new_product_id": result.NewProductID,
+			"shop_id":        match.ShopID,
+			"actor":          actor,
+		})
+		if result.NewProductID != "" {
+			s.reindex(ctx, result.ProductID, result.NewProductID)
+		}
+		return nil
+	}
+
+	if err := s.storage.RejectMatch(ctx, match.ID, entry); err != nil {
+		return fmt.Errorf("failed to reject match: %w", err)
+	}
+
+	s.logger.Info("matchreview: match rejected", map[string]interface{}{
+		"match_id": match.ID,
+		"actor":    actor,
+	})
+
+	return nil
+}
+
+// Merge вручную сливает sourceID в targetID; sourceID удаляется
+func (s *Service) Merge(ctx context.Context, sourceID, targetID, actor, note string) (*MergeResult, error) {
+	sourceID = strings.TrimSpace(sourceID)
+	targetID = strings.TrimSpace(targetID)
+	if sourceID == "" || targetID == "" {
+		return nil, fmt.Errorf("%w: source and target are required", ErrInvalidRequest)
+	}
+	if sourceID == targetID {
+		return nil, fmt.Errorf("%w: cannot merge product into itself", ErrInvalidRequest)
+	}
+	if err := requireActor(actor); err != nil {
+		return nil, err
+	}
+
+	entry := s.newEntry(ActionMerge, targetID, sourceID, actor, note)
+	result, err := s.storage.MergeProducts(ctx, sourceID, targetID, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge products: %w", err)
+	}
+
+	s.logger.Info("matchreview: products merged", map[string]interface{}{
+		"source_id": sourceID,
+		"target_id": targetID,
+		"actor":     actor,
+		"moved":     result.MovedPrices,
+		"dropped":   result.DroppedPrices,
+	})
+	s.reindex(ctx, sourceID, targetID)
+
+	return result, nil
+}
+
+// Split выделяет предложения указанных магазинов в новый товар
+func (s *Service) Split(ctx context.Context, req *SplitRequest) (*SplitResult, error) {
+	if req == nil || strings.TrimSpace(req.ProductID) == "" {
+		return nil, fmt.Errorf("%w: product is required", ErrInvalidRequest)
+	}
+	if err := requireActor(req.Actor); err != nil {
+		return nil, err
+	}
+
+	shopIDs := make([]string, 0, len(req.ShopIDs))
+	seen := make(map[string]bool, len(req.ShopIDs))
+	for _, id := range req.ShopIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			shopIDs = append(shopIDs, id)
+		}
+	}
+	if len(shopIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one shop is required", ErrInvalidRequest)
+	}
+	req.ProductID = strings.TrimSpace(req.ProductID)
+	req.ShopIDs = shopIDs
+	req.Name = strings.TrimSpace(req.Name)
+
+	entry := s.newEntry(ActionSplit, req.ProductID, "", req.Actor, req.Note)
+	entry.Details["shop_ids"] = shopIDs
+
+	result, err := s.storage.SplitProduct(ctx, req, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split product: %w", err)
+	}
+
+	s.logger.Info("matchreview: product split", map[string]interface{}{
+		"product_id":     result.ProductID,
+		"new_product_id": result.NewProductID,
+		"shop_ids":       shopIDs,
+		"actor":          req.Actor,
+	})
+	s.reindex(ctx, result.ProductID, result.NewProductID)
+
+	return result, nil
+}
+
+// ListAudit возвращает журнал решений по товару, новые записи первыми
+func (s *Service) ListAudit(ctx context.Context, productID string) ([]*AuditEntry, error) {
+	productID = strings.TrimSpace(productID)
+	if productID == "" {
+		return nil, fmt.Errorf("%w: product is required", ErrInvalidRequest)
+	}
+	return s.storage.ListAudit(ctx, productID, defaultAuditLimit)
+}
+
+// pendingMatch загружает сопоставление и проверяет, что решение по нему ещё не принято
+func (s *Service) pendingMatch(ctx context.Context, matchID, actor string) (*Match, error) {
+	if strings.TrimSpace(matchID) == "" {
+		return nil, ErrMatchNotFound
+	}
+	if err := requireActor(actor); err != nil {
+		return nil, err
+	}
+
+	match, err := s.storage.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if match.Status != StatusPending {
+		return nil, ErrMatchNotPending
+	}
+	return match, nil
+}
+
+func (s *Service) newEntry(action Action, productID, relatedID, actor, note string) *AuditEntry {
+	entry := &AuditEntry{
+		Action:    action,
+		ProductID: productID,
+		Actor:     strings.TrimSpace(actor),
+		Note:      strings.TrimSpace(note),
+		Details:   make(map[string]interface{}),
+	}
+	if relatedID != "" {
+		entry.RelatedProductID = &relatedID
+	}
+	return entry
+}
+
+func requireActor(actor string) error {
+	if strings.TrimSpace(actor) == "" {
+		return fmt.Errorf("%w: actor is required", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// reindex обновляет затронутые товары в поиске; удалённые товары уходят из индекса.
+// Ошибка только логируется: решение уже сохранено в БД
+func (s *Service) reindex(ctx context.Context, productIDs ...string) {
+	if s.indexer == nil {
+		return
+	}
+	if err := s.indexer.IndexProducts(ctx, productIDs); err != nil {
+		s.logger.Warn("matchreview: failed to reindex products", map[string]interface{}{
+			"product_ids": productIDs,
+			"error":       err.Error(),
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package matchreview
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	matches   map[string]*Match
+	filter    ListFilter
+	merged    [][2]string
+	rejected  []string
+	confirmed []string
+	detached  []string
+	split     *SplitRequest
+	entries   []*AuditEntry
+}
+
+func (m *mockStorage) ListPending(ctx context.Context, filter ListFilter) ([]*Match, int, error) {
+	m.filter = filter
+	return nil, 0, nil
+}
+
+func (m *mockStorage) GetMatch(ctx context.Context, id string) (*Match, error) {
+	match, ok := m.matches[id]
+	if !ok {
+		return nil, ErrMatchNotFound
+	}
+	return match, nil
+}
+
+func (m *mockStorage) RejectMatch(ctx context.Context, id string, entry *AuditEntry) error {
+	m.rejected = append(m.rejected, id)
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *mockStorage) ConfirmMatch(ctx context.Context, id string, entry *AuditEntry) error {
+	m.confirmed = append(m.confirmed, id)
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *mockStorage) DetachOffer(ctx context.Context, id string, entry *AuditEntry) (*SplitResult, error) {
+	m.detached = append(m.detached, id)
+	m.entries = append(m.entries, entry)
+	match := m.matches[id]
+	return &SplitResult{ProductID: match.MatchedID, NewProductID: "detached-id", MovedPrices: 1}, nil
+}
+
+func (m *mockStorage) MergeProducts(ctx context.Context, sourceID, targetID string, entry *AuditEntry) (*MergeResult, error) {
+	m.merged = append(m.merged, [2]string{sourceID, targetID})
+	m.entries = append(m.entries, entry)
+	return &MergeResult{SourceID: sourceID, TargetID: targetID, MovedPrices: 1}, nil
+}
+
+func (m *mockStorage) SplitProduct(ctx context.Context, req *SplitRequest, entry *AuditEntry) (*SplitResult, error) {
+	m.split = req
+	m.entries = append(m.entries, entry)
+	return &SplitResult{ProductID: req.ProductID, NewProductID: "new-id", MovedPrices: 1}, nil
+}
+
+func (m *mockStorage) ListAudit(ctx context.Context, productID string, limit int) ([]*AuditEntry, error) {
+	return m.entries, nil
+}
+
+type mockIndexer struct {
+	indexed [][]string
+}
+
+func (m *mockIndexer) IndexProducts(ctx context.Context, productIDs []string) error {
+	m.indexed = append(m.indexed, productIDs)
+	return nil
+}
+
+func newTestService(storage *mockStorage, indexer Indexer) *Service {
+	return New(storage, indexer, logger.New("error"))
+}
+
+func TestApprove_MergesDuplicateIntoCandidate(t *testing.T) {
+	storage := &mockStorage{matches: map[string]*Match{
+		"m1": {ID: "m1", ProductID: "dup", MatchedID: "canonical", Similarity: 0.8, Confidence: ConfidenceMedium, Status: StatusPending},
+	}}
+	indexer := &mockIndexer{}
+	service := newTestService(storage, indexer)
+
+	result, err := service.Approve(context.Background(), "m1", "editor@example.com", "same phone")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if result.TargetID != "canonical" || len(storage.merged) != 1 || storage.merged[0] != [2]string{"dup", "canonical"} {
+		t.Fatalf("expected dup merged into canonical, got %+v", storage.merged)
+	}
+
+	entry := storage.entries[0]
+	if entry.Action != ActionApprove || entry.MatchID == nil || *entry.MatchID != "m1" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+	if entry.Actor != "editor@example.com" || entry.Note != "same phone" {
+		t.Errorf("actor and note must be recorded, got %+v", entry)
+	}
+	if len(indexer.indexed) != 1 || len(indexer.indexed[0]) != 2 {
+		t.Errorf("both products must be reindexed, got %v", indexer.indexed)
+	}
+}
+
+func TestApprove_ConfirmsProvisionalMatch(t *testing.T) {
+	storage := &mockStorage{matches: map[string]*Match{
+		"m1": {ID: "m1", ProductID: "canonical", MatchedID: "canonical", ShopID: "shop-1", Similarity: 0.8, Confidence: ConfidenceMedium, Status: StatusPending},
+	}}
+	indexer := &mockIndexer{}
+	service := newTestService(storage, indexer)
+
+	result, err := service.Approve(context.Background(), "m1", "editor", "")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	// Предложение уже привязано к товару - сливать нечего
+	if len(storage.merged) != 0 || len(storage.confirmed) != 1 || result.TargetID != "canonical" {
+		t.Fatalf("expected provisional match confirmed without merge, got merged=%v confirmed=%v", storage.merged, storage.confirmed)
+	}
+	if entry := storage.entries[0]; entry.Action != ActionApprove || entry.Details["shop_id"] != "shop-1" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+	if len(indexer.indexed) != 0 {
+		t.Errorf("nothing changed, no reindex expected, got %v", indexer.indexed)
+	}
+}
+
+func TestReject_DetachesProvisionalOffer(t *testing.T) {
+	storage := &mockStorage{matches: map[string]*Match{
+		"m1": {ID: "m1", ProductID: "canonical", MatchedID: "canonical", ShopID: "shop-1", Similarity: 0.8, Confidence: ConfidenceMedium, Status: StatusPending},
+	}}
+	indexer := &mockIndexer{}
+	service := newTestService(storage, indexer)
+
+	if err := service.Reject(context.Background(), "m1", "editor", "different storage"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if len(storage.detached) != 1 || len(storage.rejected) != 0 {
+		t.Fatalf("expected offer detached, got detached=%v rejected=%v", storage.detached, storage.rejected)
+	}
+	if entry := storage.entries[0]; entry.Action != ActionReject || entry.Actor != "editor" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+	if len(indexer.indexed) != 1 || indexer.indexed[0][0] != "canonical" || indexer.indexed[0][1] != "detached-id" {
+		t.Errorf("both products must be reindexed, got %v", indexer.indexed)
+	}
+}
+
+func TestApprove_AlreadyReviewed(t *testing.T) {
+	storage := &mockStorage{matches: map[string]*Match{
+		"m1": {ID: "m1", ProductID: "a", MatchedID: "b", Status: StatusRejected},
+	}}
+	service := newTestService(storage, nil)
+
+	if _, err := service.Approve(context.Background(), "m1", "editor", ""); !errors.Is(err, ErrMatchNotPending) {
+		t.Fatalf("expected ErrMatchNotPending, got %v", err)
+	}
+	if len(storage.merged) != 0 {
+		t.Error("reviewed match must not be merged")
+	}
+}
+
+func TestReject_RequiresActor(t *testing.T) {
+	storage := &mockStorage{matches: map[string]*Match{
+		"m1": {ID: "m1", ProductID: "a", MatchedID: "b", Status: StatusPending},
+	}}
+	service := newTestService(storage, nil)
+
+	if err := service.Reject(context.Background(), "m1", " ", ""); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest, got %v", err)
+	}
+	if err := service.Reject(context.Background(), "m1", "editor", "different storage"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if len(storage.rejected) != 1 || storage.entries[0].Action != ActionReject {
+		t.Errorf("expected rejected match with audit entry, got %v %+v", storage.rejected, storage.entries)
+	}
+}
+
+func TestMerge_Validation(t *testing.T) {
+	service := newTestService(&mockStorage{}, nil)
+
+	cases := []struct{ source, target, actor string }{
+		{"", "b", "editor"},
+		{"a", "a", "editor"},
+		{"a", "b", ""},
+	}
+	for _, tc := range cases {
+		if _, err := service.Merge(context.Background(), tc.source, tc.target, tc.actor, ""); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Merge(%q, %q, %q): expected ErrInvalidRequest, got %v", tc.source, tc.target, tc.actor, err)
+		}
+	}
+}
+
+func TestSplit_NormalizesShops(t *testing.T) {
+	storage := &mockStorage{}
+	service := newTestService(storage, nil)
+
+	result, err := service.Split(context.Background(), &SplitRequest{
+		ProductID: "p1",
+		ShopIDs:   []string{" shop-a ", "shop-a", "", "shop-b"},
+		Actor:     "editor",
+	})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if result.NewProductID != "new-id" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := storage.split.ShopIDs; len(got) != 2 || got[0] != "shop-a" || got[1] != "shop-b" {
+		t.Errorf("expected deduplicated shops, got %v", got)
+	}
+	if storage.entries[0].Action != ActionSplit || storage.entries[0].Details["shop_ids"] == nil {
+		t.Errorf("unexpected audit entry %+v", storage.entries[0])
+	}
+
+	if _, err := service.Split(context.Background(), &SplitRequest{ProductID: "p1", ShopIDs: []string{" "}, Actor: "editor"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest without shops, got %v", err)
+	}
+}
+
+func TestListPending_Filter(t *testing.T) {
+	storage := &mockStorage{}
+	service := newTestService(storage, nil)
+
+	list, err := service.ListPending(context.Background(), ListFilter{Confidence: ConfidenceLow, Limit: 1000, Offset: -5})
+	if err != nil {
+		t.Fatalf("ListPending failed: %v", err)
+	}
+	if storage.filter.Limit != maxListLimit || storage.filter.Offset != 0 || list.Limit != maxListLimit {
+		t.Errorf("expected clamped paging, got %+v", storage.filter)
+	}
+
+	if _, err := service.ListPending(context.Background(), ListFilter{Confidence: "high"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for high confidence, got %v", err)
+	}
+}
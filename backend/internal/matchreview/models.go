@@ -0,0 +1,102 @@
+package matchreview
+
+import "time"
+
+// Confidence уровень уверенности сопоставления
+const (
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Status статус проверки сопоставления
+type Status string
+
+const (
+	StatusPending  Status = "pending"  // ждёт решения модератора
+	StatusRejected Status = "rejected" // товары признаны разными
+)
+
+// Action тип решения в журнале
+type Action string
+
+const (
+	ActionApprove Action = "approve" // сопоставление подтверждено, дубликат слит
+	ActionReject  Action = "reject"  // сопоставление отклонено
+	ActionMerge   Action = "merge"   // ручное слияние двух товаров
+	ActionSplit   Action = "split"   // выделение предложений в новый товар
+)
+
+// Match сопоставление из очереди проверки:
+// ProductID - товар, созданный из нового предложения, MatchedID - найденный кандидат.
+// Предварительная привязка (ShopID заполнен): предложение магазина уже привязано к MatchedID
+// и отдельного товара нет, ProductID = MatchedID
+type Match struct {
+	ID          string     `json:"id"`
+	ProductID   string     `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	MatchedID   string     `json:"matched_id"`
+	MatchedName string     `json:"matched_name"`
+	Similarity  float64    `json:"similarity"`
+	Confidence  string     `json:"confidence"`
+	ShopID      string     `json:"shop_id,omitempty"`
+	Status      Status     `json:"status"`
+	MatchedAt   time.Time  `json:"matched_at"`
+	ReviewedBy  *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote  *string    `json:"review_note,omitempty"`
+}
+
+// ListFilter фильтр очереди проверки
+type ListFilter struct {
+	Confidence string // medium | low; пусто - обе
+	Limit      int
+	Offset     int
+}
+
+// MatchList страница очереди проверки
+type MatchList struct {
+	Items  []*Match `json:"items"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+// AuditEntry запись журнала решений
+type AuditEntry struct {
+	ID               string                 `json:"id"`
+	Action           Action                 `json:"action"`
+	MatchID          *string                `json:"match_id,omitempty"`
+	ProductID        string                 `json:"product_id"`
+	RelatedProductID *string                `json:"related_product_id,omitempty"`
+	Actor            string                 `json:"actor"`
+	Note             string                 `json:"note,omitempty"`
+	Details          map[string]interface{} `json:"details,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// MergeResult итог слияния: SourceID удалён, его данные перенесены в TargetID.
+// При подтверждении предварительной привязки SourceID пуст: переносить нечего
+type MergeResult struct {
+	SourceID         string `json:"source_id"`
+	TargetID         string `json:"target_id"`
+	MovedPrices      int    `json:"moved_prices"`
+	DroppedPrices    int    `json:"dropped_prices"` // цены того же магазина, более старые, чем у целевого товара
+	MovedRawProducts int    `json:"moved_raw_products"`
+}
+
+// SplitRequest выделение предложений указанных магазинов в новый товар
+type SplitRequest struct {
+	ProductID string
+	ShopIDs   []string
+	Name      string // название нового товара; пусто - копия исходного
+	Actor     string
+	Note      string
+}
+
+// SplitResult итог разделения
+type SplitResult struct {
+	ProductID        string `json:"product_id"`
+	NewProductID     string `json:"new_product_id"`
+	MovedPrices      int    `json:"moved_prices"`
+	MovedRawProducts int    `json:"moved_raw_products"`
+}
@@ -0,0 +1,62 @@
+package matchreview
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс хранилища очереди проверки.
+// Операции изменения пишут запись журнала в той же транзакции.
+type Storage interface {
+	// ListPending возвращает непроверенные сопоставления и их общее число
+	ListPending(ctx context.Context, filter ListFilter) ([]*Match, int, error)
+
+	// GetMatch получает сопоставление по ID
+	GetMatch(ctx context.Context, id string) (*Match, error)
+
+	// RejectMatch помечает сопоставление отклонённым (только из статуса pending)
+	RejectMatch(ctx context.Context, id string, entry *AuditEntry) error
+
+	// ConfirmMatch закрепляет предварительную привязку: сопоставление удаляется (только из статуса pending)
+	ConfirmMatch(ctx context.Context, id string, entry *AuditEntry) error
+
+	// DetachOffer отклоняет предварительную привязку: предложение магазина выделяется
+	// в новый товар (если у товара есть другие предложения) и сопоставление помечается отклонённым
+	DetachOffer(ctx context.Context, id string, entry *AuditEntry) (*SplitResult, error)
+
+	// MergeProducts переносит цены, сырые данные, идентификаторы и подписки
+	// из sourceID в targetID и удаляет sourceID
+	MergeProducts(ctx context.Context, sourceID, targetID string, entry *AuditEntry) (*MergeResult, error)
+
+	// SplitProduct создаёт копию товара и переносит в неё предложения магазинов req.ShopIDs
+	SplitProduct(ctx context.Context, req *SplitRequest, entry *AuditEntry) (*SplitResult, error)
+
+	// ListAudit возвращает журнал решений по товару (как основному или связанному)
+	ListAudit(ctx context.Context, productID string, limit int) ([]*AuditEntry, error)
+}
+
+// Indexer обновляет документы товаров в поисковом индексе
+// Удалённые товары должны удаляться из индекса
+type Indexer interface {
+	IndexProducts(ctx context.Context, productIDs []string) error
+}
+
+// Service сервис ручной проверки сопоставлений
+type Service struct {
+	storage Storage
+	indexer Indexer
+	logger  *logger.Logger
+}
+
+// New создаёт сервис проверки сопоставлений; indexer может быть nil
+func New(storage Storage, indexer Indexer, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage: storage,
+		indexer: indexer,
+		logger:  log,
+	}
+}
@@ -0,0 +1,8 @@
+package politeness
+
+import "errors"
+
+var (
+	// ErrDisallowedByRobots URL запрещён robots.txt для нашего краулера
+	ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+)
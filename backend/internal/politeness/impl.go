@@ -0,0 +1,113 @@
+package politeness
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Acquire ждёт разрешения на запрос к странице магазина:
+// проверяет robots.txt, занимает слот магазина (и браузера для rod),
+// затем ждёт токен с учётом RateLimit магазина и crawl-delay хоста.
+// release нужно вызвать после завершения запроса
+func (m *Manager) Acquire(ctx context.Context, req Request) (release func(), err error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", req.URL)
+	}
+
+	crawlDelay := time.Duration(0)
+	if m.cfg.RespectRobots {
+		rules := m.robots.rules(ctx, u)
+		if !rules.allowed(u.RequestURI(), m.cfg.UserAgent) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, req.URL)
+		}
+		crawlDelay = rules.crawlDelay
+	}
+
+	key := req.ShopID
+	if key == "" {
+		key = u.Host
+	}
+
+	shopSlots := m.shopSlots(key)
+	if err := acquireSlot(ctx, shopSlots); err != nil {
+		return nil, err
+	}
+	releaseShop := func() { <-shopSlots }
+
+	releaseBrowser := func() {}
+	if req.Browser {
+		if err := acquireSlot(ctx, m.browsers); err != nil {
+			releaseShop()
+			return nil, err
+		}
+		releaseBrowser = func() { <-m.browsers }
+	}
+
+	release = func() {
+		releaseBrowser()
+		releaseShop()
+	}
+
+	if err := m.bucket(key, m.interval(req.RateLimit, crawlDelay)).wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// Allowed проверяет URL по robots.txt (без ожидания лимитов)
+func (m *Manager) Allowed(ctx context.Context, rawURL string) (bool, error) {
+	if !m.cfg.RespectRobots {
+		return true, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false, fmt.Errorf("invalid url %q", rawURL)
+	}
+	return m.robots.rules(ctx, u).allowed(u.RequestURI(), m.cfg.UserAgent), nil
+}
+
+// interval минимальный интервал между запросами: RateLimit магазина,
+// но не чаще, чем разрешает crawl-delay из robots.txt
+func (m *Manager) interval(rateLimit int, crawlDelay time.Duration) time.Duration {
+	if rateLimit <= 0 {
+		rateLimit = m.cfg.DefaultRateLimit
+	}
+	interval := time.Second / time.Duration(rateLimit)
+	if crawlDelay > interval {
+		interval = crawlDelay
+	}
+	return interval
+}
+
+func (m *Manager) bucket(key string, interval time.Duration) *tokenBucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = newTokenBucket(interval, 1)
+		m.buckets[key] = b
+		return b
+	}
+	if b.interval != interval {
+		b.setInterval(interval)
+	}
+	return b
+}
+
+func (m *Manager) shopSlots(key string) chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	slots, ok := m.shops[key]
+	if !ok {
+		slots = make(chan struct{}, m.cfg.MaxPerShop)
+		m.shops[key] = slots
+	}
+	return slots
+}
@@ -0,0 +1,201 @@
+package politeness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRobotsServer(t *testing.T, robots string, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fetches.Add(1)
+			w.WriteHeader(status)
+			fmt.Fprint(w, robots)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestAcquire_RespectsRobotsDisallow(t *testing.T) {
+	server, fetches := newRobotsServer(t, "User-agent: *\nDisallow: /private\n", http.StatusOK)
+	m := New(Config{RespectRobots: true, DefaultRateLimit: 1000}, server.Client(), nil)
+
+	release, err := m.Acquire(context.Background(), Request{ShopID: "s", URL: server.URL + "/product/1"})
+	if err != nil {
+		t.Fatalf("allowed URL rejected: %v", err)
+	}
+	release()
+
+	_, err = m.Acquire(context.Background(), Request{ShopID: "s", URL: server.URL + "/private/2"})
+	if !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("expected ErrDisallowedByRobots, got %v", err)
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1 (cached)", n)
+	}
+}
+
+func TestAcquire_RobotsGroupMatchesSentUserAgent(t *testing.T) {
+	var robotsAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		robotsAgent = r.UserAgent()
+		fmt.Fprint(w, "User-agent: IzboratorBot\nDisallow: /\n\nUser-agent: *\nAllow: /\n")
+	}))
+	defer server.Close()
+	m := New(Config{RespectRobots: true, DefaultRateLimit: 1000}, server.Client(), nil)
+
+	allowed, err := m.Allowed(context.Background(), server.URL+"/product/1")
+	if err != nil || allowed {
+		t.Errorf("Allowed = %v, %v; the IzboratorBot group must apply", allowed, err)
+	}
+	if robotsAgent != m.UserAgent() || m.UserAgent() != "IzboratorBot/1.0" {
+		t.Errorf("robots.txt fetched as %q, parsers send %q", robotsAgent, m.UserAgent())
+	}
+}
+
+func TestAcquire_IgnoresRobotsWhenDisabled(t *testing.T) {
+	server, fetches := newRobotsServer(t, "User-agent: *\nDisallow: /\n", http.StatusOK)
+	m := New(Config{RespectRobots: false, DefaultRateLimit: 1000}, server.Client(), nil)
+
+	release, err := m.Acquire(context.Background(), Request{URL: server.URL + "/product/1"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	release()
+	if fetches.Load() != 0 {
+		t.Error("robots.txt should not be fetched when disabled")
+	}
+}
+
+func TestAcquire_MissingRobotsAllowsAll(t *testing.T) {
+	server, _ := newRobotsServer(t, "", http.StatusNotFound)
+	m := New(Config{RespectRobots: true, DefaultRateLimit: 1000}, server.Client(), nil)
+
+	allowed, err := m.Allowed(context.Background(), server.URL+"/anything")
+	if err != nil || !allowed {
+		t.Errorf("Allowed = %v, %v; want true", allowed, err)
+	}
+}
+
+func TestAcquire_CrawlDelayAndRateLimit(t *testing.T) {
+	server, _ := newRobotsServer(t, "User-agent: *\nCrawl-delay: 0.1\n", http.StatusOK)
+	m := New(Config{RespectRobots: true}, server.Client(), nil)
+
+	// RateLimit 100 req/s, но crawl-delay 100ms - побеждает более медленный
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := m.Acquire(context.Background(), Request{ShopID: "s", RateLimit: 100, URL: server.URL + "/p"})
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("3 requests took %v, crawl-delay not honoured", elapsed)
+	}
+}
+
+func TestAcquire_ShopsAreIndependent(t *testing.T) {
+	m := New(Config{DefaultRateLimit: 2}, nil, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, shop := range []string{"a", "b", "c"} {
+		release, err := m.Acquire(ctx, Request{ShopID: shop, URL: "http://example.test/p"})
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("first request per shop should not wait, took %v", elapsed)
+	}
+}
+
+func TestAcquire_LimitsConcurrencyPerShopAndBrowser(t *testing.T) {
+	m := New(Config{DefaultRateLimit: 1000, MaxPerShop: 2, MaxBrowsers: 1}, nil, nil)
+
+	var (
+		mu             sync.Mutex
+		current, peak  int
+		browserCurrent int
+		browserPeak    int
+		wg             sync.WaitGroup
+	)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			browser := i%2 == 0
+			release, err := m.Acquire(context.Background(), Request{ShopID: "s", URL: "http://example.test/p", Browser: browser})
+			if err != nil {
+				t.Errorf("Acquire failed: %v", err)
+				return
+			}
+			mu.Lock()
+			current++
+			if current > peak {
+				peak = current
+			}
+			if browser {
+				browserCurrent++
+				if browserCurrent > browserPeak {
+					browserPeak = browserCurrent
+				}
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			current--
+			if browser {
+				browserCurrent--
+			}
+			mu.Unlock()
+			release()
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("peak concurrency per shop = %d, want <= 2", peak)
+	}
+	if browserPeak > 1 {
+		t.Errorf("peak browsers = %d, want <= 1", browserPeak)
+	}
+}
+
+func TestAcquire_CanceledWhileWaiting(t *testing.T) {
+	m := New(Config{DefaultRateLimit: 1}, nil, nil)
+
+	release, err := m.Acquire(context.Background(), Request{ShopID: "s", URL: "http://example.test/p"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := m.Acquire(ctx, Request{ShopID: "s", URL: "http://example.test/p"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// Слот магазина освобождён после отмены
+	if n := len(m.shopSlots("s")); n != 0 {
+		t.Errorf("shop slots in use = %d, want 0", n)
+	}
+}
@@ -0,0 +1,98 @@
+package politeness
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket ограничитель скорости запросов к одному магазину
+type tokenBucket struct {
+	mu       sync.Mutex
+	interval time.Duration // время восстановления одного токена
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(interval time.Duration, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		interval: interval,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// setInterval меняет скорость (например, после загрузки crawl-delay)
+func (b *tokenBucket) setInterval(interval time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.interval = interval
+}
+
+// reserve забирает токен и возвращает, сколько нужно подождать до запроса
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 || b.interval <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.interval))
+}
+
+// cancel возвращает токен, если запрос так и не был выполнен
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// wait ждёт своей очереди в bucket с учётом отмены ctx
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// acquireSlot занимает место в семафоре с учётом отмены ctx
+func acquireSlot(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package politeness
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// DefaultUserAgent имя краулера по умолчанию
+const DefaultUserAgent = "IzboratorBot"
+
+const (
+	defaultRobotsTTL      = 6 * time.Hour
+	defaultRobotsRetryTTL = 10 * time.Minute
+	defaultRobotsTimeout  = 10 * time.Second
+	defaultRateLimit      = 1
+	defaultShopParallel   = 2
+	defaultBrowserLimit   = 2
+)
+
+// Config настройки вежливого обхода магазинов
+type Config struct {
+	RespectRobots    bool          // проверять robots.txt перед запросом
+	UserAgent        string        // имя краулера: группа robots.txt и начало заголовка User-Agent
+	RobotsTTL        time.Duration // сколько хранить robots.txt в кэше
+	DefaultRateLimit int           // запросов в секунду, если у магазина не задан RateLimit
+	MaxPerShop       int           // одновременных запросов к одному магазину (Colly + браузер)
+	MaxBrowsers      int           // одновременно открытых headless браузеров
+}
+
+// Request описывает запрос к странице магазина
+type Request struct {
+	ShopID    string
+	RateLimit int // ShopConfig.RateLimit, запросов в секунду (0 = DefaultRateLimit)
+	URL       string
+	Browser   bool // запрос через headless браузер (rod)
+}
+
+// Manager общий слой вежливости для всех парсеров: robots.txt с crawl-delay,
+// token bucket на магазин и ограничение одновременных запросов
+type Manager struct {
+	cfg      Config
+	robots   *robotsCache
+	browsers chan struct{}
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	shops   map[string]chan struct{}
+}
+
+// New создаёт менеджер вежливости
+// client используется для загрузки robots.txt (nil = http.Client с таймаутом)
+func New(cfg Config, client *http.Client, log *logger.Logger) *Manager {
+	if log == nil {
+		log = logger.New("info")
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	if cfg.RobotsTTL <= 0 {
+		cfg.RobotsTTL = defaultRobotsTTL
+	}
+	if cfg.DefaultRateLimit <= 0 {
+		cfg.DefaultRateLimit = defaultRateLimit
+	}
+	if cfg.MaxPerShop <= 0 {
+		cfg.MaxPerShop = defaultShopParallel
+	}
+	if cfg.MaxBrowsers <= 0 {
+		cfg.MaxBrowsers = defaultBrowserLimit
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultRobotsTimeout}
+	}
+
+	return &Manager{
+		cfg:      cfg,
+		robots:   newRobotsCache(client, cfg.UserAgent, cfg.RobotsTTL, log),
+		browsers: make(chan struct{}, cfg.MaxBrowsers),
+		logger:   log,
+		buckets:  make(map[string]*tokenBucket),
+		shops:    make(map[string]chan struct{}),
+	}
+}
+
+// UserAgentHeader заголовок User-Agent краулера. Начинается с имени краулера, поэтому
+// группа robots.txt, по которой проверяются запросы, совпадает с той, что видит магазин
+func UserAgentHeader(name string) string {
+	if name == "" {
+		name = DefaultUserAgent
+	}
+	return name + "/1.0"
+}
+
+// UserAgent заголовок User-Agent, с которым парсеры должны ходить к магазинам
+func (m *Manager) UserAgent() string {
+	return UserAgentHeader(m.cfg.UserAgent)
+}
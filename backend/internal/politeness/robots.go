@@ -0,0 +1,126 @@
+package politeness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/temoto/robotstxt"
+)
+
+// robotsRules правила robots.txt для нашего краулера на одном хосте
+type robotsRules struct {
+	data       *robotstxt.RobotsData // nil = ограничений нет
+	crawlDelay time.Duration
+	expiresAt  time.Time
+}
+
+// allowed проверяет путь (с query) по правилам
+func (r *robotsRules) allowed(path, agent string) bool {
+	if r.data == nil {
+		return true
+	}
+	return r.data.TestAgent(path, agent)
+}
+
+// robotsCache загружает и кэширует robots.txt по хостам
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+	ttl       time.Duration
+	logger    *logger.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// robotsHost запись кэша; mu сериализует загрузку, чтобы robots.txt
+// одного хоста не скачивался параллельно
+type robotsHost struct {
+	mu    sync.Mutex
+	rules *robotsRules
+}
+
+func newRobotsCache(client *http.Client, userAgent string, ttl time.Duration, log *logger.Logger) *robotsCache {
+	return &robotsCache{
+		client:    client,
+		userAgent: userAgent,
+		ttl:       ttl,
+		logger:    log,
+		hosts:     make(map[string]*robotsHost),
+	}
+}
+
+// rules возвращает правила для хоста URL, загружая robots.txt при необходимости
+func (c *robotsCache) rules(ctx context.Context, u *url.URL) *robotsRules {
+	key := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	host, ok := c.hosts[key]
+	if !ok {
+		host = &robotsHost{}
+		c.hosts[key] = host
+	}
+	c.mu.Unlock()
+
+	host.mu.Lock()
+	defer host.mu.Unlock()
+
+	if host.rules != nil && time.Now().Before(host.rules.expiresAt) {
+		return host.rules
+	}
+	host.rules = c.fetch(ctx, key)
+	return host.rules
+}
+
+// fetch скачивает robots.txt
+// Недоступный robots.txt не блокирует обход, но перепроверяется раньше обычного
+func (c *robotsCache) fetch(ctx context.Context, origin string) *robotsRules {
+	robotsURL := origin + "/robots.txt"
+	rules := &robotsRules{expiresAt: time.Now().Add(c.ttl)}
+
+	data, err := c.download(ctx, robotsURL)
+	if err != nil {
+		c.logger.Warn("robots.txt fetch failed, allowing crawl", map[string]interface{}{
+			"url":   robotsURL,
+			"error": err.Error(),
+		})
+		rules.expiresAt = time.Now().Add(defaultRobotsRetryTTL)
+		return rules
+	}
+
+	rules.data = data
+	rules.crawlDelay = data.FindGroup(c.userAgent).CrawlDelay
+
+	c.logger.Debug("robots.txt loaded", map[string]interface{}{
+		"url":         robotsURL,
+		"crawl_delay": rules.crawlDelay.String(),
+	})
+
+	return rules
+}
+
+func (c *robotsCache) download(ctx context.Context, robotsURL string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgentHeader(c.userAgent))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 4xx - ограничений нет, 5xx - временно запрещено всё (правила Google)
+	data, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse robots.txt: %w", err)
+	}
+	return data, nil
+}
@@ -11,4 +11,10 @@ var (
 
 	// ErrInvalidTimeRange невалидный временной диапазон
 	ErrInvalidTimeRange = errors.New("invalid time range")
+
+	// ErrInvalidRetention невалидный срок хранения истории
+	ErrInvalidRetention = errors.New("invalid retention period")
+
+	// ErrCleanupInProgress очистку истории уже выполняет другой процесс
+	ErrCleanupInProgress = errors.New("price history cleanup already in progress")
 )
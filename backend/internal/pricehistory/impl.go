@@ -5,8 +5,11 @@ import (
 	"time"
 )
 
-// SavePrice сохраняет цену товара
-func (s *Service) SavePrice(productID, shopID string, price float64, currency string) error {
+// SavePrice записывает цену товара в историю
+// Точка сохраняется только если цена, валюта или наличие изменились с предыдущей записи.
+// Подписки на снижение цены здесь не проверяются: у точки истории нет города, магазина и ссылки
+// на предложение, а единственный вызывающий - processor - проверяет их сам после записи в product_prices
+func (s *Service) SavePrice(productID, shopID string, price float64, currency string, inStock bool) error {
 	if productID == "" {
 		return fmt.Errorf("product ID is required")
 	}
@@ -22,10 +25,11 @@ func (s *Service) SavePrice(productID, shopID string, price float64, currency st
 		ShopID:    shopID,
 		Price:     price,
 		Currency:  currency,
+		InStock:   inStock,
 		Timestamp: time.Now(),
 	}
 
-	if err := s.storage.SavePrice(point); err != nil {
+	if _, err := s.storage.SavePrice(point); err != nil {
 		s.logger.Error("Failed to save price", map[string]interface{}{
 			"error":      err,
 			"product_id": productID,
@@ -80,7 +84,7 @@ func (s *Service) GetPriceChart(productID string, period string, shopIDs []strin
 		return nil, ErrInvalidPeriod
 	}
 
-	chart, err := s.storage.GetPriceChart(productID, period, shopIDs)
+	chart, err := s.storage.GetPriceChart(productID, period, ChartGranularity(period), shopIDs)
 	if err != nil {
 		s.logger.Error("Failed to get price chart", map[string]interface{}{
 			"error":      err,
@@ -93,6 +97,53 @@ func (s *Service) GetPriceChart(productID string, period string, shopIDs []strin
 	return chart, nil
 }
 
+// Cleanup удаляет сырые точки старше срока хранения; дневные и недельные агрегаты остаются.
+// Одновременно очистку выполняет только один процесс (ErrCleanupInProgress)
+func (s *Service) Cleanup(retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, ErrInvalidRetention
+	}
+
+	unlock, ok, err := s.storage.TryLockCleanup()
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock price history cleanup: %w", err)
+	}
+	if !ok {
+		return 0, ErrCleanupInProgress
+	}
+	defer unlock()
+
+	before := time.Now().Add(-retention)
+	deleted, err := s.storage.CleanupOldData(before)
+	if err != nil {
+		s.logger.Error("Failed to cleanup price history", map[string]interface{}{
+			"error":  err,
+			"before": before,
+		})
+		return 0, fmt.Errorf("failed to cleanup price history: %w", err)
+	}
+
+	s.logger.Info("Price history cleanup completed", map[string]interface{}{
+		"before":  before,
+		"deleted": deleted,
+	})
+
+	return deleted, nil
+}
+
+// ChartGranularity выбирает детализацию графика по периоду:
+// короткие периоды строятся по сырым точкам, месяц - по дням, год - по неделям
+func ChartGranularity(period string) Granularity {
+	switch period {
+	case "month":
+		return GranularityDay
+	case "year":
+		return GranularityWeek
+	default:
+		return GranularityRaw
+	}
+}
+
 // calculatePeriod определяет период на основе временного диапазона
 func calculatePeriod(from, to time.Time) string {
 	duration := to.Sub(from)
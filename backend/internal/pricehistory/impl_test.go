@@ -0,0 +1,121 @@
+package pricehistory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	saved       []*PricePoint
+	granularity Granularity
+	before      time.Time
+	locked      bool
+}
+
+func (m *mockStorage) SavePrice(point *PricePoint) (bool, error) {
+	m.saved = append(m.saved, point)
+	return true, nil
+}
+
+func (m *mockStorage) GetHistory(productID string, from, to time.Time) ([]*PricePoint, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) GetPriceChart(productID string, period string, granularity Granularity, shopIDs []string) (*PriceChart, error) {
+	m.granularity = granularity
+	return &PriceChart{ProductID: productID, Period: period, Granularity: granularity}, nil
+}
+
+func (m *mockStorage) CleanupOldData(before time.Time) (int64, error) {
+	m.before = before
+	return 3, nil
+}
+
+func (m *mockStorage) TryLockCleanup() (func(), bool, error) {
+	if m.locked {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func TestChartGranularity(t *testing.T) {
+	cases := map[string]Granularity{
+		"day":   GranularityRaw,
+		"week":  GranularityRaw,
+		"month": GranularityDay,
+		"year":  GranularityWeek,
+	}
+	for period, want := range cases {
+		if got := ChartGranularity(period); got != want {
+			t.Errorf("ChartGranularity(%q) = %q, want %q", period, got, want)
+		}
+	}
+}
+
+func TestGetPriceChart_UsesRollupsForLongPeriods(t *testing.T) {
+	storage := &mockStorage{}
+	service := New(storage, logger.New("error"))
+
+	chart, err := service.GetPriceChart("p1", "year", nil)
+	if err != nil {
+		t.Fatalf("GetPriceChart failed: %v", err)
+	}
+	if storage.granularity != GranularityWeek || chart.Granularity != GranularityWeek {
+		t.Errorf("expected weekly rollups, got %q", storage.granularity)
+	}
+
+	if _, err := service.GetPriceChart("p1", "decade", nil); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("expected ErrInvalidPeriod, got %v", err)
+	}
+}
+
+func TestSavePrice_PassesStock(t *testing.T) {
+	storage := &mockStorage{}
+	service := New(storage, logger.New("error"))
+
+	if err := service.SavePrice("p1", "shop-1", 1999, "RSD", false); err != nil {
+		t.Fatalf("SavePrice failed: %v", err)
+	}
+	if len(storage.saved) != 1 || storage.saved[0].InStock || storage.saved[0].Price != 1999 {
+		t.Errorf("unexpected saved point %+v", storage.saved)
+	}
+
+	if err := service.SavePrice("p1", "shop-1", -1, "RSD", true); err == nil {
+		t.Error("expected error for negative price")
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	storage := &mockStorage{}
+	service := New(storage, logger.New("error"))
+
+	if _, err := service.Cleanup(0); !errors.Is(err, ErrInvalidRetention) {
+		t.Fatalf("expected ErrInvalidRetention, got %v", err)
+	}
+
+	deleted, err := service.Cleanup(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("expected 3 deleted points, got %d", deleted)
+	}
+	if age := time.Since(storage.before); age < 23*time.Hour || age > 25*time.Hour {
+		t.Errorf("unexpected cleanup cutoff %v", storage.before)
+	}
+}
+
+func TestCleanup_SkipsWhenLocked(t *testing.T) {
+	storage := &mockStorage{locked: true}
+	service := New(storage, logger.New("error"))
+
+	if _, err := service.Cleanup(24 * time.Hour); !errors.Is(err, ErrCleanupInProgress) {
+		t.Fatalf("expected ErrCleanupInProgress, got %v", err)
+	}
+	if !storage.before.IsZero() {
+		t.Error("cleanup must not run while another worker holds the lock")
+	}
+}
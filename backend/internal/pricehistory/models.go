@@ -2,12 +2,28 @@ package pricehistory
 
 import "time"
 
+// Granularity детализация точек графика
+type Granularity string
+
+const (
+	// GranularityRaw сырые точки изменений цены
+	GranularityRaw Granularity = "raw"
+	// GranularityDay дневные агрегаты
+	GranularityDay Granularity = "day"
+	// GranularityWeek недельные агрегаты
+	GranularityWeek Granularity = "week"
+)
+
 // PricePoint точка цены во времени
+// Для агрегатов Price - последняя цена интервала, MinPrice/MaxPrice - границы за интервал
 type PricePoint struct {
 	ProductID string    `json:"product_id"`
 	ShopID    string    `json:"shop_id"`
 	Price     float64   `json:"price"`
+	MinPrice  float64   `json:"min_price,omitempty"`
+	MaxPrice  float64   `json:"max_price,omitempty"`
 	Currency  string    `json:"currency"`
+	InStock   bool      `json:"in_stock"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -20,10 +36,11 @@ type PriceHistory struct {
 
 // PriceChart данные для графика цен
 type PriceChart struct {
-	ProductID string                   `json:"product_id"`
-	Shops     map[string][]*PricePoint `json:"shops"`      // shop_id -> points
-	ShopNames map[string]string        `json:"shop_names"` // shop_id -> shop_name
-	Period    string                   `json:"period"`
-	From      time.Time                `json:"from"`
-	To        time.Time                `json:"to"`
+	ProductID   string                   `json:"product_id"`
+	Shops       map[string][]*PricePoint `json:"shops"`      // shop_id -> points
+	ShopNames   map[string]string        `json:"shop_names"` // shop_id -> shop_name
+	Period      string                   `json:"period"`
+	Granularity Granularity              `json:"granularity"`
+	From        time.Time                `json:"from"`
+	To          time.Time                `json:"to"`
 }
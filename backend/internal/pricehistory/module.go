@@ -8,17 +8,23 @@ import (
 
 // Storage интерфейс для работы с time-series хранилищем цен
 type Storage interface {
-	// SavePrice сохраняет точку цены
-	SavePrice(point *PricePoint) error
+	// SavePrice сохраняет точку цены, если цена, валюта или наличие изменились с последней точки.
+	// Возвращает true, если точка записана
+	SavePrice(point *PricePoint) (bool, error)
 
 	// GetHistory получает историю цен за период
 	GetHistory(productID string, from, to time.Time) ([]*PricePoint, error)
 
-	// GetPriceChart получает данные для графика цен
-	GetPriceChart(productID string, period string, shopIDs []string) (*PriceChart, error)
+	// GetPriceChart получает данные для графика цен с заданной детализацией
+	GetPriceChart(productID string, period string, granularity Granularity, shopIDs []string) (*PriceChart, error)
 
-	// CleanupOldData удаляет старые данные
-	CleanupOldData(before time.Time) error
+	// CleanupOldData удаляет сырые точки старше before, сохраняя агрегаты и последнюю точку магазина.
+	// Возвращает количество удалённых точек
+	CleanupOldData(before time.Time) (int64, error)
+
+	// TryLockCleanup берёт блокировку очистки: среди всех воркеров очистку выполняет один.
+	// ok=false - очистка уже идёт в другом процессе
+	TryLockCleanup() (unlock func(), ok bool, err error)
 }
 
 // Service сервис для работы с историей цен
@@ -4,13 +4,25 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/solomonczyk/izborator/internal/alerts"
+	"github.com/solomonczyk/izborator/internal/brands"
+	"github.com/solomonczyk/izborator/internal/categorizer"
+	"github.com/solomonczyk/izborator/internal/indexing"
 	"github.com/solomonczyk/izborator/internal/matching"
 	"github.com/solomonczyk/izborator/internal/products"
 	"github.com/solomonczyk/izborator/internal/scraper"
 	"github.com/solomonczyk/izborator/internal/semantic"
 )
 
+const (
+	// exactMatchSimilarity порог, начиная с которого предложение привязывается к товару без проверки
+	exactMatchSimilarity = 0.95
+	// mediumMatchSimilarity нижняя граница средней уверенности в очереди проверки
+	mediumMatchSimilarity = 0.7
+)
+
 // ProcessRawProducts обрабатывает необработанные сырые данные
 func (s *Service) ProcessRawProducts(ctx context.Context, batchSize int) (int, error) {
 	// Проверка и установка значения по умолчанию
@@ -197,11 +209,20 @@ func (s *Service) processRawProduct(ctx context.Context, raw *scraper.RawProduct
 		s.semanticRecorder.RecordSemanticValidation(validationResult)
 	}
 
+	// Предложение уже привязано к товару (прошлой обработкой или решением модератора при
+	// слиянии/разделении) - повторно не сопоставляем, иначе решения проверки отменялись бы
+	// при каждом парсинге
+	if productID := s.linkedProductID(raw); productID != "" {
+		return s.updateLinkedProduct(ctx, productID, raw, normalized, cityID)
+	}
+
 	// 1. Ищем кандидатов через matching
 	matchReq := &matching.MatchRequest{
-		Name:  normalized.Name,
-		Brand: normalized.Brand,
-		Specs: normalized.Specs,
+		Name:        normalized.Name,
+		Brand:       normalized.Brand,
+		Specs:       normalized.Specs,
+		ShopID:      raw.ShopID,
+		Identifiers: rawIdentifiers(raw),
 	}
 
 	matchResult, err := s.matching.MatchProduct(matchReq)
@@ -216,8 +237,14 @@ func (s *Service) processRawProduct(ctx context.Context, raw *scraper.RawProduct
 		if err := s.createNewProduct(ctx, raw, normalized); err != nil {
 			return err
 		}
+		s.attachIdentifiers(normalized.ID, matchReq)
 		// Сохраняем цену для нового товара
-		return s.savePriceForProduct(normalized.ID, raw)
+		if err := s.savePriceForProduct(ctx, normalized.ID, raw, cityID); err != nil {
+			return err
+		}
+		s.saveAttributeValues(normalized.ID, raw)
+		s.linkRawProduct(raw, normalized.ID)
+		return nil
 	}
 
 	s.logger.Info("processor: matching result", map[string]interface{}{
@@ -229,6 +256,8 @@ func (s *Service) processRawProduct(ctx context.Context, raw *scraper.RawProduct
 	var (
 		targetProductID string
 		isNewProduct    bool
+		pendingMatch    *matching.ProductMatch
+		fuzzyMatch      *matching.ProductMatch
 	)
 
 	// 2. Выбираем лучший кандидат или создаём новый товар
@@ -242,29 +271,44 @@ func (s *Service) processRawProduct(ctx context.Context, raw *scraper.RawProduct
 		// Есть кандидаты - выбираем лучший по similarity
 		best := matchResult.Matches[0]
 
-		// Проверяем точное совпадение (similarity >= 0.95) - это почти 100% уверенность
-		if best.Similarity >= 0.95 {
+		// Совпадение идентификатора или точное совпадение (similarity >= 0.95) - это почти 100% уверенность
+		if best.MatchedBy != "" || best.Similarity >= exactMatchSimilarity {
 			// Точное совпадение - используем существующий товар
 			targetProductID = best.MatchedID
 			isNewProduct = false
+			if best.MatchedBy == "" {
+				fuzzyMatch = best
+			}
 			s.logger.Info("processor: found exact match", map[string]interface{}{
 				"matched_id": targetProductID,
 				"similarity": best.Similarity,
+				"matched_by": best.MatchedBy,
 				"name":       normalized.Name,
 			})
-		} else if best.Similarity >= 0.7 {
-			// Высокая уверенность - используем существующий товар
+		} else if best.Similarity >= mediumMatchSimilarity && !s.hasShopOffer(best.MatchedID, raw.ShopID) {
+			// Средняя уверенность - предложение сразу привязывается к найденному товару (без дубликата),
+			// пара "товар - магазин" уходит на проверку: отклонение выделит предложение в отдельный товар.
+			// Если у товара уже есть предложение этого магазина, привязка перезаписала бы его цену
 			targetProductID = best.MatchedID
 			isNewProduct = false
-			s.logger.Debug("processor: found matching product", map[string]interface{}{
-				"matched_id": targetProductID,
+			fuzzyMatch = best
+			pendingMatch = &matching.ProductMatch{
+				MatchedID:  best.MatchedID,
+				Similarity: best.Similarity,
+				ShopID:     raw.ShopID,
+			}
+			s.logger.Debug("processor: medium match, attaching offer provisionally", map[string]interface{}{
+				"matched_id": best.MatchedID,
 				"similarity": best.Similarity,
 				"name":       normalized.Name,
 			})
 		} else {
-			// Низкая уверенность - создаём новый товар
+			// Низкая уверенность - создаём отдельный товар,
+			// а пару отправляем на ручную проверку (одобрение сливает товары)
 			isNewProduct = true
-			s.logger.Debug("processor: similarity too low, creating new product", map[string]interface{}{
+			pendingMatch = best
+			s.logger.Debug("processor: uncertain match, creating new product for review", map[string]interface{}{
+				"matched_id": best.MatchedID,
 				"similarity": best.Similarity,
 				"name":       normalized.Name,
 			})
@@ -279,14 +323,248 @@ func (s *Service) processRawProduct(ctx context.Context, raw *scraper.RawProduct
 		targetProductID = normalized.ID
 	}
 
+	if pendingMatch != nil {
+		s.queueMatchForReview(targetProductID, pendingMatch)
+	}
+
+	// Запоминаем GTIN/MPN/SKU товара для точного сопоставления следующих предложений.
+	// Нечёткое совпадение может быть ошибочным: его идентификаторы не привязываются,
+	// чтобы ошибка не закрепилась для всех следующих предложений
+	if fuzzyMatch != nil {
+		s.reviewIdentifierConflicts(targetProductID, matchReq, fuzzyMatch)
+	} else {
+		s.attachIdentifiers(targetProductID, matchReq)
+	}
+
 	// 4. Сохраняем цену для товара (нового или существующего)
-	if err := s.savePriceForProduct(targetProductID, raw); err != nil {
+	if err := s.savePriceForProduct(ctx, targetProductID, raw, cityID); err != nil {
 		return fmt.Errorf("failed to save price: %w", err)
 	}
+	s.saveAttributeValues(targetProductID, raw)
+	if !isNewProduct && s.assignCategory(ctx, targetProductID, raw, normalized) != nil {
+		// Категория существующего товара уточнена - обновляем документ в индексе
+		s.publishIndexEvent(ctx, indexing.EventProductUpserted, targetProductID, raw.ShopID)
+	}
+	s.linkRawProduct(raw, targetProductID)
+
+	return nil
+}
+
+// saveAttributeValues сопоставляет характеристики магазина с атрибутами справочника
+// и сохраняет типизированные значения товара. Ошибки не прерывают обработку
+func (s *Service) saveAttributeValues(productID string, raw *scraper.RawProduct) {
+	if s.attributes == nil || len(raw.Specs) == 0 {
+		return
+	}
 
+	values, unmatched, err := s.attributes.Normalize(raw.Specs)
+	if err != nil {
+		s.logger.Warn("processor: failed to normalize attributes", map[string]interface{}{
+			"product_id": productID,
+			"shop_id":    raw.ShopID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	if len(unmatched) > 0 {
+		s.logger.Debug("processor: unmatched spec labels", map[string]interface{}{
+			"shop_id":   raw.ShopID,
+			"unmatched": unmatched,
+		})
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	if err := s.attributes.SaveProductValues(productID, values); err != nil {
+		s.logger.Warn("processor: failed to save attribute values", map[string]interface{}{
+			"product_id": productID,
+			"shop_id":    raw.ShopID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// assignCategory определяет категорию и тип товара по категории магазина и названию.
+// Возвращает решение, если категория товара изменилась; ошибки не прерывают обработку
+func (s *Service) assignCategory(ctx context.Context, productID string, raw *scraper.RawProduct, normalized *products.Product) *categorizer.Result {
+	if s.categorizer == nil {
+		return nil
+	}
+
+	result, err := s.categorizer.Assign(ctx, productID, &categorizer.Input{
+		ShopID:   raw.ShopID,
+		Category: normalized.Category,
+		Name:     normalized.Name,
+	})
+	if err != nil {
+		s.logger.Warn("processor: failed to assign category", map[string]interface{}{
+			"product_id": productID,
+			"shop_id":    raw.ShopID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	if result != nil {
+		s.logger.Debug("processor: category assigned", map[string]interface{}{
+			"product_id":  productID,
+			"category_id": result.CategoryID,
+			"confidence":  result.Confidence,
+			"source":      string(result.Source),
+		})
+	}
+	return result
+}
+
+// queueMatchForReview сохраняет неуверенное сопоставление в очередь ручной проверки:
+// productID - только что созданный товар, match.MatchedID - найденный кандидат.
+// С match.ShopID предложение магазина уже привязано к кандидату (productID = match.MatchedID)
+func (s *Service) queueMatchForReview(productID string, match *matching.ProductMatch) {
+	confidence := "low"
+	if match.Similarity >= mediumMatchSimilarity {
+		confidence = "medium"
+	}
+	err := s.matching.SaveMatch(&matching.ProductMatch{
+		ProductID:  productID,
+		MatchedID:  match.MatchedID,
+		Similarity: match.Similarity,
+		MatchedAt:  time.Now(),
+		Confidence: confidence,
+		ShopID:     match.ShopID,
+	})
+	if err != nil {
+		s.logger.Warn("processor: failed to queue match for review", map[string]interface{}{
+			"product_id": productID,
+			"matched_id": match.MatchedID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// linkedProductID товар, к которому уже привязан сырой товар; ошибка только логируется
+func (s *Service) linkedProductID(raw *scraper.RawProduct) string {
+	if raw.ExternalID == "" {
+		return ""
+	}
+	productID, err := s.rawStorage.GetLinkedProductID(raw.ShopID, raw.ExternalID)
+	if err != nil {
+		s.logger.Warn("processor: failed to get raw product link", map[string]interface{}{
+			"shop_id":     raw.ShopID,
+			"external_id": raw.ExternalID,
+			"error":       err.Error(),
+		})
+		return ""
+	}
+	return productID
+}
+
+// updateLinkedProduct обновляет цену и характеристики предложения, уже привязанного к товару
+func (s *Service) updateLinkedProduct(ctx context.Context, productID string, raw *scraper.RawProduct, normalized *products.Product, cityID *string) error {
+	if err := s.savePriceForProduct(ctx, productID, raw, cityID); err != nil {
+		return fmt.Errorf("failed to save price: %w", err)
+	}
+	s.saveAttributeValues(productID, raw)
+	if s.assignCategory(ctx, productID, raw, normalized) != nil {
+		s.publishIndexEvent(ctx, indexing.EventProductUpserted, productID, raw.ShopID)
+	}
 	return nil
 }
 
+// hasShopOffer есть ли у товара предложение магазина; при ошибке считается, что есть
+func (s *Service) hasShopOffer(productID, shopID string) bool {
+	exists, err := s.processedStorage.HasShopOffer(productID, shopID)
+	if err != nil {
+		s.logger.Warn("processor: failed to check shop offer", map[string]interface{}{
+			"product_id": productID,
+			"shop_id":    shopID,
+			"error":      err.Error(),
+		})
+		return true
+	}
+	return exists
+}
+
+// linkRawProduct связывает сырой товар с каноническим (нужно для слияния и разделения товаров)
+func (s *Service) linkRawProduct(raw *scraper.RawProduct, productID string) {
+	if raw.ExternalID == "" {
+		return
+	}
+	if err := s.rawStorage.LinkRawProduct(raw.ShopID, raw.ExternalID, productID); err != nil {
+		s.logger.Warn("processor: failed to link raw product", map[string]interface{}{
+			"shop_id":     raw.ShopID,
+			"external_id": raw.ExternalID,
+			"product_id":  productID,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// rawIdentifiers идентификаторы из структурированных данных страницы (raw_payload)
+func rawIdentifiers(raw *scraper.RawProduct) []matching.Identifier {
+	var ids []matching.Identifier
+	for key, idType := range map[string]matching.IdentifierType{
+		"gtin": matching.IdentifierGTIN,
+		"mpn":  matching.IdentifierMPN,
+		"sku":  matching.IdentifierSKU,
+	} {
+		if value, ok := raw.RawPayload[key].(string); ok && value != "" {
+			ids = append(ids, matching.Identifier{Type: idType, Value: value})
+		}
+	}
+	return ids
+}
+
+// attachIdentifiers привязывает идентификаторы к товару; ошибки не прерывают обработку
+func (s *Service) attachIdentifiers(productID string, req *matching.MatchRequest) {
+	conflicts, err := s.matching.AttachIdentifiers(productID, req)
+	if err != nil {
+		s.logger.Warn("processor: failed to save product identifiers", map[string]interface{}{
+			"product_id": productID,
+			"error":      err.Error(),
+		})
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warn("processor: identifier conflicts detected", map[string]interface{}{
+			"product_id": productID,
+			"conflicts":  len(conflicts),
+		})
+	}
+}
+
+// reviewIdentifierConflicts отправляет на ручную проверку товары, которым уже принадлежат
+// идентификаторы предложения, сопоставленного по названию: идентификатор указывает на другой товар
+func (s *Service) reviewIdentifierConflicts(productID string, req *matching.MatchRequest, match *matching.ProductMatch) {
+	conflicts, err := s.matching.CheckIdentifiers(productID, req)
+	if err != nil {
+		s.logger.Warn("processor: failed to check product identifiers", map[string]interface{}{
+			"product_id": productID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	queued := make(map[string]bool)
+	for _, conflict := range conflicts {
+		for _, otherID := range conflict.ProductIDs {
+			if otherID == productID || queued[otherID] {
+				continue
+			}
+			queued[otherID] = true
+			s.queueMatchForReview(productID, &matching.ProductMatch{
+				MatchedID:  otherID,
+				Similarity: match.Similarity,
+			})
+		}
+	}
+	if len(queued) > 0 {
+		s.logger.Warn("processor: fuzzy match conflicts with identifiers, queued for review", map[string]interface{}{
+			"product_id": productID,
+			"similarity": match.Similarity,
+			"conflicts":  len(queued),
+		})
+	}
+}
+
 // normalizeRawProduct нормализует сырые данные товара
 func (s *Service) normalizeRawProduct(raw *scraper.RawProduct) *products.Product {
 	normalized := &products.Product{
@@ -307,18 +585,27 @@ func (s *Service) normalizeRawProduct(raw *scraper.RawProduct) *products.Product
 		normalized.Brand = s.normalizeBrand(normalized.Brand)
 	}
 
+	// Позиции прайс-листа (scraper.ParseServiceList) - услуги с длительностью из raw_payload
+	if productType, _ := raw.RawPayload[scraper.PayloadProductType].(string); productType == scraper.ProductTypeService {
+		normalized.Type = products.ProductTypeService
+		duration, _ := raw.RawPayload[scraper.PayloadDuration].(string)
+		normalized.ServiceMetadata = &products.ServiceMetadata{Duration: duration}
+	}
+
 	return normalized
 }
 
-// normalizeBrand нормализует название бренда
-// normalizeBrand нормализует название бренда (первая буква заглавная, остальные строчные)
-// Защита от паники: проверяет пустую строку перед доступом к символам
+// normalizeBrand приводит бренд к каноническому названию справочника ("hewlett-packard" -> "HP").
+// Без справочника или для неизвестного бренда сохраняет аббревиатуры и смешанный регистр
 func (s *Service) normalizeBrand(brand string) string {
 	brand = strings.TrimSpace(brand)
 	if brand == "" {
 		return ""
 	}
-	return strings.ToUpper(brand[:1]) + strings.ToLower(brand[1:])
+	if s.brands != nil {
+		return s.brands.Canonicalize(brand)
+	}
+	return brands.FormatUnknown(brand)
 }
 
 // createNewProduct создаёт новый товар из сырых данных
@@ -328,9 +615,14 @@ func (s *Service) createNewProduct(ctx context.Context, raw *scraper.RawProduct,
 	if err := s.processedStorage.SaveProduct(normalized); err != nil {
 		return fmt.Errorf("failed to save product: %w", err)
 	}
+	if result := s.assignCategory(ctx, normalized.ID, raw, normalized); result != nil {
+		normalized.CategoryID = &result.CategoryID
+	}
 
-	// Индексируем товар в Meilisearch
-	if err := s.processedStorage.IndexProduct(normalized); err != nil {
+	// Индексируем товар: через событие (пачками в index updater) или напрямую в Meilisearch
+	if s.indexEvents != nil {
+		s.publishIndexEvent(ctx, indexing.EventProductUpserted, normalized.ID, raw.ShopID)
+	} else if err := s.processedStorage.IndexProduct(normalized); err != nil {
 		s.logger.Error("processor: failed to index product", map[string]interface{}{
 			"product_id": normalized.ID,
 			"error":      err.Error(),
@@ -348,8 +640,8 @@ func (s *Service) createNewProduct(ctx context.Context, raw *scraper.RawProduct,
 	return nil
 }
 
-// savePriceForProduct сохраняет цену товара
-func (s *Service) savePriceForProduct(productID string, raw *scraper.RawProduct) error {
+// savePriceForProduct сохраняет цену товара с ценой за единицу и проверяет подписки на снижение цены
+func (s *Service) savePriceForProduct(ctx context.Context, productID string, raw *scraper.RawProduct, cityID *string) error {
 	price := &products.ProductPrice{
 		ProductID: productID,
 		ShopID:    raw.ShopID,
@@ -359,6 +651,12 @@ func (s *Service) savePriceForProduct(productID string, raw *scraper.RawProduct)
 		URL:       raw.URL,
 		InStock:   raw.InStock,
 	}
+	// Фасовка из названия или характеристик магазина: цена за 1 кг / 1 л / 1 шт для сравнения
+	// разных упаковок (1 л и 6x0.5 л). Для услуг и техники фасовки нет
+	if productType, _ := raw.RawPayload[scraper.PayloadProductType].(string); productType != scraper.ProductTypeService {
+		price.Pack = products.ParsePackSize(raw.Name, strings.TrimSpace(raw.Category), raw.Specs)
+		price.UnitPrice = price.Pack.UnitPrice(raw.Price)
+	}
 
 	if err := s.processedStorage.SavePrice(price); err != nil {
 		return fmt.Errorf("failed to save price: %w", err)
@@ -371,5 +669,73 @@ func (s *Service) savePriceForProduct(productID string, raw *scraper.RawProduct)
 		"currency":   raw.Currency,
 	})
 
+	s.recordPriceHistory(price)
+	s.publishIndexEvent(ctx, indexing.EventPriceChanged, productID, raw.ShopID)
+	s.evaluatePriceAlerts(ctx, price, cityID)
+
 	return nil
 }
+
+// recordPriceHistory добавляет точку в историю цен (журнал сам отбрасывает неизменившиеся цены)
+// Ошибка не прерывает обработку - текущая цена уже сохранена
+func (s *Service) recordPriceHistory(price *products.ProductPrice) {
+	if s.priceHistory == nil {
+		return
+	}
+
+	if err := s.priceHistory.SavePrice(price.ProductID, price.ShopID, price.Price, price.Currency, price.InStock); err != nil {
+		s.logger.Warn("processor: failed to record price history", map[string]interface{}{
+			"product_id": price.ProductID,
+			"shop_id":    price.ShopID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// publishIndexEvent публикует событие для обновления документа товара в индексе
+// Ошибка не прерывает обработку - изменение подхватит синхронизация по watermark
+func (s *Service) publishIndexEvent(ctx context.Context, eventType indexing.EventType, productID, shopID string) {
+	if s.indexEvents == nil {
+		return
+	}
+
+	err := s.indexEvents.Publish(ctx, &indexing.Event{
+		Type:       eventType,
+		ProductID:  productID,
+		ShopID:     shopID,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		s.logger.Warn("processor: failed to publish index event", map[string]interface{}{
+			"type":       string(eventType),
+			"product_id": productID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// evaluatePriceAlerts проверяет подписки на снижение цены и ставит уведомления в очередь,
+// доставляет их воркер. Ошибки не прерывают обработку - цена уже сохранена
+func (s *Service) evaluatePriceAlerts(ctx context.Context, price *products.ProductPrice, cityID *string) {
+	if s.priceAlerts == nil {
+		return
+	}
+
+	_, err := s.priceAlerts.EvaluatePrice(ctx, &alerts.PriceUpdate{
+		ProductID: price.ProductID,
+		ShopID:    price.ShopID,
+		ShopName:  price.ShopName,
+		CityID:    cityID,
+		Price:     price.Price,
+		Currency:  price.Currency,
+		URL:       price.URL,
+		InStock:   price.InStock,
+	})
+	if err != nil {
+		s.logger.Warn("processor: price alerts evaluation failed", map[string]interface{}{
+			"product_id": price.ProductID,
+			"shop_id":    price.ShopID,
+			"error":      err.Error(),
+		})
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/solomonczyk/izborator/internal/alerts"
+	"github.com/solomonczyk/izborator/internal/attributes"
+	"github.com/solomonczyk/izborator/internal/brands"
+	"github.com/solomonczyk/izborator/internal/categorizer"
+	"github.com/solomonczyk/izborator/internal/indexing"
 	"github.com/solomonczyk/izborator/internal/matching"
 	"github.com/solomonczyk/izborator/internal/products"
 	"github.com/solomonczyk/izborator/internal/scraper"
@@ -13,6 +18,7 @@ import (
 // MockStorage мок для тестирования
 type mockRawStorage struct {
 	rawProducts []*scraper.RawProduct
+	links       map[string]string
 }
 
 func (m *mockRawStorage) GetUnprocessedRawProducts(limit int) ([]*scraper.RawProduct, error) {
@@ -30,29 +36,79 @@ func (m *mockRawStorage) GetShopDefaultCityID(shopID string) (*string, error) {
 	return nil, nil
 }
 
+func (m *mockRawStorage) GetLinkedProductID(shopID, externalID string) (string, error) {
+	return m.links[shopID+"/"+externalID], nil
+}
+
+func (m *mockRawStorage) LinkRawProduct(shopID, externalID, productID string) error {
+	if m.links == nil {
+		m.links = make(map[string]string)
+	}
+	m.links[shopID+"/"+externalID] = productID
+	return nil
+}
+
 type mockProcessedStorage struct {
 	products []*products.Product
+	prices   []*products.ProductPrice
+	indexed  int
 }
 
 func (m *mockProcessedStorage) SaveProduct(product *products.Product) error {
+	if product.ID == "" {
+		product.ID = fmt.Sprintf("new-%d", len(m.products)+1)
+	}
 	m.products = append(m.products, product)
 	return nil
 }
 
 func (m *mockProcessedStorage) SavePrice(price *products.ProductPrice) error {
+	m.prices = append(m.prices, price)
 	return nil
 }
 
+func (m *mockProcessedStorage) HasShopOffer(productID, shopID string) (bool, error) {
+	for _, price := range m.prices {
+		if price.ProductID == productID && price.ShopID == shopID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (m *mockProcessedStorage) IndexProduct(product *products.Product) error {
+	m.indexed++
 	return nil
 }
 
 type mockMatching struct {
 	matchResult *matching.MatchResult
 	matchError  error
+	requests    []*matching.MatchRequest
+	attached    map[string]*matching.MatchRequest
+	conflicts   []*matching.IdentifierConflict
+	saved       []*matching.ProductMatch
+}
+
+func (m *mockMatching) SaveMatch(match *matching.ProductMatch) error {
+	m.saved = append(m.saved, match)
+	return nil
+}
+
+func (m *mockMatching) AttachIdentifiers(productID string, req *matching.MatchRequest) ([]*matching.IdentifierConflict, error) {
+	if m.attached == nil {
+		m.attached = make(map[string]*matching.MatchRequest)
+	}
+	m.attached[productID] = req
+	return nil, nil
+}
+
+func (m *mockMatching) CheckIdentifiers(productID string, req *matching.MatchRequest) ([]*matching.IdentifierConflict, error) {
+	return m.conflicts, nil
 }
 
 func (m *mockMatching) MatchProduct(req *matching.MatchRequest) (*matching.MatchResult, error) {
+	m.requests = append(m.requests, req)
 	if m.matchError != nil {
 		return nil, m.matchError
 	}
@@ -65,6 +121,83 @@ func (m *mockMatching) MatchProduct(req *matching.MatchRequest) (*matching.Match
 	}, nil
 }
 
+type mockPriceAlerts struct {
+	updates []*alerts.PriceUpdate
+	err     error
+}
+
+func (m *mockPriceAlerts) EvaluatePrice(ctx context.Context, update *alerts.PriceUpdate) (int, error) {
+	m.updates = append(m.updates, update)
+	return 0, m.err
+}
+
+type mockPriceHistory struct {
+	points []*products.ProductPrice
+	err    error
+}
+
+func (m *mockPriceHistory) SavePrice(productID, shopID string, price float64, currency string, inStock bool) error {
+	m.points = append(m.points, &products.ProductPrice{
+		ProductID: productID,
+		ShopID:    shopID,
+		Price:     price,
+		Currency:  currency,
+		InStock:   inStock,
+	})
+	return m.err
+}
+
+type mockIndexEvents struct {
+	events []*indexing.Event
+}
+
+func (m *mockIndexEvents) Publish(ctx context.Context, event *indexing.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+type mockCategorizer struct {
+	inputs map[string]*categorizer.Input
+	result *categorizer.Result
+}
+
+func (m *mockCategorizer) Assign(ctx context.Context, productID string, in *categorizer.Input) (*categorizer.Result, error) {
+	if m.inputs == nil {
+		m.inputs = make(map[string]*categorizer.Input)
+	}
+	m.inputs[productID] = in
+	return m.result, nil
+}
+
+type mockAttributes struct {
+	specs  map[string]string
+	saved  map[string][]*attributes.Value
+	normFn func(specs map[string]string) []*attributes.Value
+}
+
+func (m *mockAttributes) Normalize(specs map[string]string) ([]*attributes.Value, []string, error) {
+	m.specs = specs
+	return m.normFn(specs), nil, nil
+}
+
+func (m *mockAttributes) SaveProductValues(productID string, values []*attributes.Value) error {
+	if m.saved == nil {
+		m.saved = make(map[string][]*attributes.Value)
+	}
+	m.saved[productID] = values
+	return nil
+}
+
+// mockBrands справочник брендов: ключ написания -> каноническое название
+type mockBrands map[string]string
+
+func (m mockBrands) Canonicalize(raw string) string {
+	if name, ok := m[brands.Key(raw)]; ok {
+		return name
+	}
+	return brands.FormatUnknown(raw)
+}
+
 func TestNormalizeBrand(t *testing.T) {
 	service := &Service{}
 
@@ -75,7 +208,9 @@ func TestNormalizeBrand(t *testing.T) {
 	}{
 		{"normal brand", "apple", "Apple"},
 		{"uppercase brand", "APPLE", "Apple"},
-		{"mixed case brand", "ApPlE", "Apple"},
+		{"mixed case brand", "iRobot", "iRobot"},
+		{"abbreviation", "HP", "HP"},
+		{"multi-word brand", "new balance", "New Balance"},
 		{"brand with spaces", "  apple  ", "Apple"},
 		{"empty string", "", ""},
 		{"only spaces", "   ", ""},
@@ -93,6 +228,17 @@ func TestNormalizeBrand(t *testing.T) {
 	}
 }
 
+func TestNormalizeBrand_Registry(t *testing.T) {
+	service := &Service{brands: mockBrands{"hewlettpackard": "HP", "hp": "HP"}}
+
+	if got := service.normalizeBrand(" Hewlett-Packard "); got != "HP" {
+		t.Errorf("normalizeBrand(Hewlett-Packard) = %q, want HP", got)
+	}
+	if got := service.normalizeBrand("hp"); got != "HP" {
+		t.Errorf("normalizeBrand(hp) = %q, want HP", got)
+	}
+}
+
 func TestNormalizeRawProduct(t *testing.T) {
 	service := &Service{}
 
@@ -125,6 +271,31 @@ func TestNormalizeRawProduct(t *testing.T) {
 	}
 }
 
+func TestNormalizeRawProduct_ServiceListItem(t *testing.T) {
+	service := &Service{}
+
+	raw := &scraper.RawProduct{
+		Name:  "Muško šišanje",
+		Price: 1200,
+		RawPayload: map[string]interface{}{
+			scraper.PayloadProductType: scraper.ProductTypeService,
+			scraper.PayloadDuration:    "30 min",
+		},
+	}
+
+	normalized := service.normalizeRawProduct(raw)
+	if normalized.Type != products.ProductTypeService {
+		t.Errorf("Type = %q, want %q", normalized.Type, products.ProductTypeService)
+	}
+	if normalized.ServiceMetadata == nil || normalized.ServiceMetadata.Duration != "30 min" {
+		t.Errorf("unexpected service metadata %+v", normalized.ServiceMetadata)
+	}
+
+	if goods := service.normalizeRawProduct(&scraper.RawProduct{Name: "Telefon"}); goods.ServiceMetadata != nil {
+		t.Errorf("goods must not get service metadata: %+v", goods.ServiceMetadata)
+	}
+}
+
 func TestProcessRawProducts_NoMatches(t *testing.T) {
 	rawStorage := &mockRawStorage{
 		rawProducts: []*scraper.RawProduct{
@@ -145,7 +316,7 @@ func TestProcessRawProducts_NoMatches(t *testing.T) {
 		},
 	}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	count, err := service.ProcessRawProducts(ctx, 10)
@@ -193,7 +364,7 @@ func TestProcessRawProducts_WithMatches(t *testing.T) {
 		},
 	}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	count, err := service.ProcessRawProducts(ctx, 10)
@@ -207,6 +378,217 @@ func TestProcessRawProducts_WithMatches(t *testing.T) {
 	}
 }
 
+func TestProcessRawProducts_EvaluatesPriceAlerts(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{
+				ShopID:   "shop-1",
+				ShopName: "Test Shop",
+				Name:     "iPhone 15",
+				Price:    999.0,
+				Currency: "RSD",
+				InStock:  true,
+			},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.95}},
+			Count:   1,
+		},
+	}
+	// Ошибка проверки подписок не должна ломать обработку
+	priceAlerts := &mockPriceAlerts{err: fmt.Errorf("alerts storage unavailable")}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{PriceAlerts: priceAlerts}, nil)
+
+	count, err := service.ProcessRawProducts(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 product processed, got %d", count)
+	}
+	if len(priceAlerts.updates) != 1 {
+		t.Fatalf("Expected 1 price alert evaluation, got %d", len(priceAlerts.updates))
+	}
+	update := priceAlerts.updates[0]
+	if update.ProductID != "existing-id" || update.Price != 999.0 || !update.InStock {
+		t.Errorf("unexpected price update: %+v", update)
+	}
+}
+
+func TestProcessRawProducts_RecordsPriceHistory(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", Name: "iPhone 15", Price: 999.0, Currency: "RSD", InStock: false},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.95}},
+			Count:   1,
+		},
+	}
+	// Ошибка записи истории не должна ломать обработку
+	history := &mockPriceHistory{err: fmt.Errorf("history storage unavailable")}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{PriceHistory: history}, nil)
+
+	count, err := service.ProcessRawProducts(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 product processed, got %d", count)
+	}
+	if len(history.points) != 1 {
+		t.Fatalf("Expected 1 price history point, got %d", len(history.points))
+	}
+	point := history.points[0]
+	if point.ProductID != "existing-id" || point.ShopID != "shop-1" || point.Price != 999.0 || point.InStock {
+		t.Errorf("unexpected price history point: %+v", point)
+	}
+}
+
+func TestProcessRawProducts_SavesUnitPrice(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", ExternalID: "milk-6", Name: "Mleko 6x0,5l", Category: "Hrana i piće > Mleko", Price: 420, Currency: "RSD"},
+			{ShopID: "shop-1", ExternalID: "phone", Name: "Samsung Galaxy A55 5G 128GB", Price: 999, Currency: "RSD"},
+			{ShopID: "shop-1", ExternalID: "laptop", Name: "Lenovo IdeaPad 3 1,6 kg", Category: "Laptopovi", Price: 59999, Currency: "RSD"},
+		},
+	}
+	processedStorage := &mockProcessedStorage{}
+	service := New(rawStorage, processedStorage, &mockMatching{}, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if len(processedStorage.prices) != 3 {
+		t.Fatalf("Expected 3 saved prices, got %d", len(processedStorage.prices))
+	}
+
+	milk := processedStorage.prices[0]
+	if milk.Pack == nil || milk.Pack.Count != 6 || milk.Pack.Amount != 0.5 || milk.Pack.Unit != products.UnitLiter {
+		t.Errorf("unexpected milk pack: %+v", milk.Pack)
+	}
+	if milk.UnitPrice == nil || *milk.UnitPrice != 140 {
+		t.Errorf("expected unit price 140 RSD/l, got %v", milk.UnitPrice)
+	}
+	if phone := processedStorage.prices[1]; phone.Pack != nil || phone.UnitPrice != nil {
+		t.Errorf("phone must have no pack, got %+v / %v", phone.Pack, phone.UnitPrice)
+	}
+	if laptop := processedStorage.prices[2]; laptop.Pack != nil || laptop.UnitPrice != nil {
+		t.Errorf("laptop must have no pack, got %+v / %v", laptop.Pack, laptop.UnitPrice)
+	}
+}
+
+func TestProcessRawProducts_PassesIdentifiersToMatching(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{
+				ShopID:     "shop-1",
+				Name:       "Samsung Galaxy A55",
+				Price:      42999.0,
+				Currency:   "RSD",
+				RawPayload: map[string]interface{}{"gtin": "4006381333931", "sku": "SM-A556"},
+			},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 1.0, MatchedBy: "gtin"}},
+			Count:   1,
+		},
+	}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if len(matching.requests) != 1 {
+		t.Fatalf("Expected 1 match request, got %d", len(matching.requests))
+	}
+	req := matching.requests[0]
+	if req.ShopID != "shop-1" || len(req.Identifiers) != 2 {
+		t.Errorf("unexpected match request: %+v", req)
+	}
+	if matching.attached["existing-id"] != req {
+		t.Errorf("expected identifiers attached to matched product, got %v", matching.attached)
+	}
+}
+
+func TestProcessRawProducts_FuzzyMatchDoesNotBindIdentifiers(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{
+				ShopID:     "shop-1",
+				Name:       "Samsung Galaxy A55 128GB",
+				Price:      42999.0,
+				Currency:   "RSD",
+				RawPayload: map[string]interface{}{"gtin": "4006381333931"},
+			},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.96}},
+			Count:   1,
+		},
+		conflicts: []*matching.IdentifierConflict{
+			{ProductIDs: []string{"gtin-owner-id", "existing-id"}},
+		},
+	}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if len(matching.attached) != 0 {
+		t.Errorf("expected no identifiers attached after fuzzy match, got %v", matching.attached)
+	}
+	if len(matching.saved) != 1 {
+		t.Fatalf("expected identifier conflict queued for review, got %d", len(matching.saved))
+	}
+	if got := matching.saved[0]; got.ProductID != "existing-id" || got.MatchedID != "gtin-owner-id" {
+		t.Errorf("unexpected review pair: %+v", got)
+	}
+}
+
+func TestProcessRawProducts_PublishesIndexEvents(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", Name: "New Product", Price: 100.0, Currency: "RSD"},
+		},
+	}
+	processedStorage := &mockProcessedStorage{}
+	indexEvents := &mockIndexEvents{}
+
+	service := New(rawStorage, processedStorage, &mockMatching{}, Deps{IndexEvents: indexEvents}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+
+	// При публикации событий прямая индексация не выполняется
+	if processedStorage.indexed != 0 {
+		t.Errorf("Expected no direct indexing, got %d", processedStorage.indexed)
+	}
+	if len(indexEvents.events) != 2 {
+		t.Fatalf("Expected 2 index events, got %d", len(indexEvents.events))
+	}
+	productID := processedStorage.products[0].ID
+	if e := indexEvents.events[0]; e.Type != indexing.EventProductUpserted || e.ProductID != productID {
+		t.Errorf("unexpected first event: %+v", e)
+	}
+	if e := indexEvents.events[1]; e.Type != indexing.EventPriceChanged || e.ProductID != productID || e.ShopID != "shop-1" {
+		t.Errorf("unexpected second event: %+v", e)
+	}
+}
+
 func TestProcessRawProducts_EmptyBatch(t *testing.T) {
 	rawStorage := &mockRawStorage{
 		rawProducts: []*scraper.RawProduct{},
@@ -214,7 +596,7 @@ func TestProcessRawProducts_EmptyBatch(t *testing.T) {
 	processedStorage := &mockProcessedStorage{}
 	matching := &mockMatching{}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	count, err := service.ProcessRawProducts(ctx, 10)
@@ -244,7 +626,7 @@ func TestProcessRawProducts_BatchSizeLimit(t *testing.T) {
 	processedStorage := &mockProcessedStorage{}
 	matching := &mockMatching{}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	// Запрос с batchSize > 100 должен быть ограничен до 100
@@ -275,7 +657,7 @@ func TestProcessRawProducts_InvalidBatchSize(t *testing.T) {
 	processedStorage := &mockProcessedStorage{}
 	matching := &mockMatching{}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	// Отрицательный batchSize должен быть заменен на 10
@@ -308,7 +690,7 @@ func TestProcessRawProducts_MatchingError(t *testing.T) {
 		matchError: fmt.Errorf("matching service unavailable"),
 	}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	// Должен создать новый товар при ошибке matching
@@ -350,7 +732,7 @@ func TestProcessRawProducts_LowSimilarity(t *testing.T) {
 		},
 	}
 
-	service := New(rawStorage, processedStorage, matching, nil, nil)
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
 
 	ctx := context.Background()
 	count, err := service.ProcessRawProducts(ctx, 10)
@@ -368,3 +750,213 @@ func TestProcessRawProducts_LowSimilarity(t *testing.T) {
 		t.Errorf("Expected 1 new product created, got %d", len(processedStorage.products))
 	}
 }
+
+func TestProcessRawProducts_MediumSimilarityAttachedProvisionally(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", ExternalID: "ext-1", Name: "Galaxy A55 5G", Brand: "Samsung", Price: 40000, Currency: "RSD"},
+		},
+	}
+	processedStorage := &mockProcessedStorage{}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.8}},
+			Count:   1,
+		},
+	}
+
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+
+	// Дубликат не создаётся: предложение привязано к найденному товару до проверки
+	if len(processedStorage.products) != 0 {
+		t.Fatalf("expected no new product for medium match, got %d", len(processedStorage.products))
+	}
+	if len(processedStorage.prices) != 1 || processedStorage.prices[0].ProductID != "existing-id" {
+		t.Fatalf("expected price attached to existing-id, got %+v", processedStorage.prices)
+	}
+
+	if len(matching.saved) != 1 {
+		t.Fatalf("expected match queued for review, got %d", len(matching.saved))
+	}
+	queued := matching.saved[0]
+	if queued.ProductID != "existing-id" || queued.MatchedID != "existing-id" || queued.ShopID != "shop-1" || queued.Confidence != "medium" {
+		t.Errorf("unexpected queued match %+v", queued)
+	}
+	if len(matching.attached) != 0 {
+		t.Errorf("identifiers must not be bound by a provisional match, got %v", matching.attached)
+	}
+
+	if got := rawStorage.links["shop-1/ext-1"]; got != "existing-id" {
+		t.Errorf("expected raw product linked to existing-id, got %q", got)
+	}
+}
+
+func TestProcessRawProducts_MediumSimilaritySameShopCreatesProduct(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", ExternalID: "ext-2", Name: "Galaxy A55 5G 256GB", Brand: "Samsung", Price: 45000, Currency: "RSD"},
+		},
+	}
+	// У товара уже есть предложение этого магазина - привязка перезаписала бы его цену
+	processedStorage := &mockProcessedStorage{
+		prices: []*products.ProductPrice{{ProductID: "existing-id", ShopID: "shop-1", Price: 40000}},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.8}},
+			Count:   1,
+		},
+	}
+
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+
+	if len(processedStorage.products) != 1 {
+		t.Fatalf("expected new product, got %d", len(processedStorage.products))
+	}
+	newID := processedStorage.products[0].ID
+	if len(matching.saved) != 1 {
+		t.Fatalf("expected match queued for review, got %d", len(matching.saved))
+	}
+	if queued := matching.saved[0]; queued.ProductID != newID || queued.MatchedID != "existing-id" || queued.ShopID != "" {
+		t.Errorf("unexpected queued match %+v", queued)
+	}
+}
+
+func TestProcessRawProducts_LinkedRawProductSkipsMatching(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", ExternalID: "ext-1", Name: "Galaxy A55 5G", Price: 39000, Currency: "RSD"},
+		},
+		// Предложение выделено модератором в отдельный товар
+		links: map[string]string{"shop-1/ext-1": "split-id"},
+	}
+	processedStorage := &mockProcessedStorage{}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.8}},
+			Count:   1,
+		},
+	}
+
+	service := New(rawStorage, processedStorage, matching, Deps{}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+
+	if len(matching.requests) != 0 {
+		t.Errorf("linked raw product must not be matched again, got %d requests", len(matching.requests))
+	}
+	if len(processedStorage.prices) != 1 || processedStorage.prices[0].ProductID != "split-id" {
+		t.Fatalf("expected price saved for split-id, got %+v", processedStorage.prices)
+	}
+	if len(matching.saved) != 0 {
+		t.Errorf("expected nothing queued for review, got %+v", matching.saved)
+	}
+}
+
+func TestProcessRawProducts_SavesAttributeValues(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{
+				ShopID:   "shop-1",
+				Name:     "Samsung Galaxy A55",
+				Price:    42999.0,
+				Currency: "RSD",
+				Specs:    map[string]string{"Radna memorija": "8 GB"},
+			},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.97}},
+			Count:   1,
+		},
+	}
+	ram := 8.0
+	attrs := &mockAttributes{normFn: func(specs map[string]string) []*attributes.Value {
+		return []*attributes.Value{{AttributeID: "ram", AttributeCode: "RAM", Number: &ram, RawValue: specs["Radna memorija"]}}
+	}}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{Attributes: attrs}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if attrs.specs["Radna memorija"] != "8 GB" {
+		t.Errorf("raw specs must be passed to attribute normalization, got %v", attrs.specs)
+	}
+	values := attrs.saved["existing-id"]
+	if len(values) != 1 || values[0].AttributeCode != "RAM" || *values[0].Number != 8 {
+		t.Errorf("unexpected saved attribute values %+v", attrs.saved)
+	}
+}
+
+func TestProcessRawProducts_AssignsCategory(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-1", Name: "Samsung Galaxy A55", Category: " Telefoni > Mobilni telefoni ", Price: 42999.0, Currency: "RSD"},
+		},
+	}
+	processedStorage := &mockProcessedStorage{}
+	categories := &mockCategorizer{result: &categorizer.Result{CategoryID: "cat-phones", Confidence: 0.9, Source: categorizer.SourceCategoryText}}
+
+	service := New(rawStorage, processedStorage, &mockMatching{}, Deps{Categorizer: categories}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+
+	// Категория известна до прямой индексации нового товара
+	created := processedStorage.products[0]
+	if created.CategoryID == nil || *created.CategoryID != "cat-phones" {
+		t.Errorf("new product category = %v, want cat-phones", created.CategoryID)
+	}
+	if in := categories.inputs[created.ID]; in == nil || in.ShopID != "shop-1" || in.Category != "Telefoni > Mobilni telefoni" {
+		t.Errorf("unexpected categorizer input %+v", in)
+	}
+}
+
+func TestProcessRawProducts_RecategorizesMatchedProduct(t *testing.T) {
+	rawStorage := &mockRawStorage{
+		rawProducts: []*scraper.RawProduct{
+			{ShopID: "shop-2", Name: "Samsung Galaxy A55 8/256", Category: "Smartfoni", Price: 41999.0, Currency: "RSD"},
+		},
+	}
+	matching := &mockMatching{
+		matchResult: &matching.MatchResult{
+			Matches: []*matching.ProductMatch{{MatchedID: "existing-id", Similarity: 0.97}},
+			Count:   1,
+		},
+	}
+	categories := &mockCategorizer{result: &categorizer.Result{CategoryID: "cat-phones", Confidence: 0.75, Source: categorizer.SourceCategoryText}}
+	indexEvents := &mockIndexEvents{}
+
+	service := New(rawStorage, &mockProcessedStorage{}, matching, Deps{IndexEvents: indexEvents, Categorizer: categories}, nil)
+
+	if _, err := service.ProcessRawProducts(context.Background(), 10); err != nil {
+		t.Fatalf("ProcessRawProducts failed: %v", err)
+	}
+	if categories.inputs["existing-id"] == nil {
+		t.Fatalf("expected matched product to be categorized, got %v", categories.inputs)
+	}
+
+	// Уточнённая категория существующего товара обновляет его документ в индексе
+	upserted := false
+	for _, e := range indexEvents.events {
+		if e.Type == indexing.EventProductUpserted && e.ProductID == "existing-id" {
+			upserted = true
+		}
+	}
+	if !upserted {
+		t.Errorf("expected upsert event for existing-id, got %+v", indexEvents.events)
+	}
+}
@@ -1,6 +1,12 @@
 package processor
 
 import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/alerts"
+	"github.com/solomonczyk/izborator/internal/attributes"
+	"github.com/solomonczyk/izborator/internal/categorizer"
+	"github.com/solomonczyk/izborator/internal/indexing"
 	"github.com/solomonczyk/izborator/internal/logger"
 	"github.com/solomonczyk/izborator/internal/matching"
 	"github.com/solomonczyk/izborator/internal/products"
@@ -13,6 +19,10 @@ type RawStorage interface {
 	GetUnprocessedRawProducts(limit int) ([]*scraper.RawProduct, error)
 	MarkRawProductAsProcessed(shopID, externalID string) error
 	GetShopDefaultCityID(shopID string) (*string, error)
+	// LinkRawProduct запоминает, к какому товару привязан сырой товар
+	LinkRawProduct(shopID, externalID, productID string) error
+	// GetLinkedProductID возвращает товар, к которому привязан сырой товар; пусто - не привязан
+	GetLinkedProductID(shopID, externalID string) (string, error)
 }
 
 // ProcessedStorage интерфейс для записи обработанных данных
@@ -20,34 +30,83 @@ type ProcessedStorage interface {
 	SaveProduct(product *products.Product) error
 	SavePrice(price *products.ProductPrice) error
 	IndexProduct(product *products.Product) error // Индексация в Meilisearch
+	// HasShopOffer проверяет, есть ли у товара предложение магазина
+	HasShopOffer(productID, shopID string) (bool, error)
 }
 
 // Matching интерфейс для сопоставления товаров
 type Matching interface {
 	MatchProduct(req *matching.MatchRequest) (*matching.MatchResult, error)
+	AttachIdentifiers(productID string, req *matching.MatchRequest) ([]*matching.IdentifierConflict, error)
+	// CheckIdentifiers находит другие товары с идентификаторами запроса, ничего не сохраняя
+	CheckIdentifiers(productID string, req *matching.MatchRequest) ([]*matching.IdentifierConflict, error)
+	// SaveMatch сохраняет неуверенное сопоставление для ручной проверки
+	SaveMatch(match *matching.ProductMatch) error
 }
 
 type SemanticValidationRecorder interface {
 	RecordSemanticValidation(result semantic.SemanticValidationResult)
 }
 
+// PriceAlerts интерфейс для проверки подписок на снижение цены
+type PriceAlerts interface {
+	EvaluatePrice(ctx context.Context, update *alerts.PriceUpdate) (int, error)
+}
+
+// PriceHistory записывает изменения цены в журнал истории цен
+type PriceHistory interface {
+	SavePrice(productID, shopID string, price float64, currency string, inStock bool) error
+}
+
+// IndexEvents публикует события изменения товаров для инкрементальной индексации
+type IndexEvents interface {
+	Publish(ctx context.Context, event *indexing.Event) error
+}
+
+// Attributes сопоставляет характеристики магазина с атрибутами справочника и сохраняет типизированные значения
+type Attributes interface {
+	Normalize(specs map[string]string) ([]*attributes.Value, []string, error)
+	SaveProductValues(productID string, values []*attributes.Value) error
+}
+
+// Categorizer определяет категорию и тип товара и записывает их, если решение увереннее текущего
+type Categorizer interface {
+	Assign(ctx context.Context, productID string, in *categorizer.Input) (*categorizer.Result, error)
+}
+
+// Brands приводит написание бренда к каноническому названию справочника брендов
+type Brands interface {
+	Canonicalize(raw string) string
+}
+
 // Service сервис для обработки сырых данных
 type Service struct {
 	rawStorage       RawStorage
 	processedStorage ProcessedStorage
 	matching         Matching
 	semanticRecorder SemanticValidationRecorder
+	priceAlerts      PriceAlerts
+	priceHistory     PriceHistory
+	indexEvents      IndexEvents
+	attributes       Attributes
+	categorizer      Categorizer
+	brands           Brands
 	logger           *logger.Logger
 }
 
+// Deps необязательные зависимости Service; nil отключает соответствующий шаг обработки
+type Deps struct {
+	SemanticRecorder SemanticValidationRecorder // результаты семантической валидации
+	PriceAlerts      PriceAlerts                // уведомления о снижении цены
+	PriceHistory     PriceHistory               // история цен
+	IndexEvents      IndexEvents                // события инкрементальной индексации
+	Attributes       Attributes                 // типизированные значения характеристик
+	Categorizer      Categorizer                // категория и тип товара
+	Brands           Brands                     // каноническое написание брендов
+}
+
 // New создаёт новый сервис обработки
-func New(
-	rawStorage RawStorage,
-	processedStorage ProcessedStorage,
-	matching Matching,
-	semanticRecorder SemanticValidationRecorder,
-	log *logger.Logger,
-) *Service {
+func New(rawStorage RawStorage, processedStorage ProcessedStorage, matching Matching, deps Deps, log *logger.Logger) *Service {
 	if log == nil {
 		log = logger.New("info")
 	}
@@ -55,7 +114,13 @@ func New(
 		rawStorage:       rawStorage,
 		processedStorage: processedStorage,
 		matching:         matching,
-		semanticRecorder: semanticRecorder,
+		semanticRecorder: deps.SemanticRecorder,
+		priceAlerts:      deps.PriceAlerts,
+		priceHistory:     deps.PriceHistory,
+		indexEvents:      deps.IndexEvents,
+		attributes:       deps.Attributes,
+		categorizer:      deps.Categorizer,
+		brands:           deps.Brands,
 		logger:           log,
 	}
 }
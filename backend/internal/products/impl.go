@@ -3,6 +3,8 @@ package products
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Search ищет товары по запросу (простой поиск без пагинации для /api/v1/products/search)
@@ -89,7 +91,43 @@ func (s *Service) ListBrands(ctx context.Context, productType string) ([]string,
 		})
 		return nil, fmt.Errorf("list brands failed: %w", err)
 	}
-	return brands, nil
+	if s.brands == nil {
+		return brands, nil
+	}
+
+	// Разные написания одного бренда ("Hewlett-Packard", "HP") сводятся к каноническому названию
+	seen := make(map[string]bool, len(brands))
+	canonical := make([]string, 0, len(brands))
+	for _, brand := range brands {
+		name := s.brands.Canonicalize(brand)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		canonical = append(canonical, name)
+	}
+	sort.Slice(canonical, func(i, j int) bool {
+		return strings.ToLower(canonical[i]) < strings.ToLower(canonical[j])
+	})
+	return canonical, nil
+}
+
+// AttributeFacets возвращает значения фильтруемых атрибутов категории (родитель + дочерние) с количеством товаров
+func (s *Service) AttributeFacets(ctx context.Context, categoryIDs []string) ([]*AttributeFacet, error) {
+	if len(categoryIDs) == 0 {
+		return []*AttributeFacet{}, nil
+	}
+
+	facets, err := s.storage.AttributeFacets(ctx, categoryIDs)
+	if err != nil {
+		s.logger.Error("Failed to load attribute facets", map[string]interface{}{
+			"error":        err,
+			"category_ids": categoryIDs,
+		})
+		return nil, fmt.Errorf("attribute facets failed: %w", err)
+	}
+	return facets, nil
 }
 
 
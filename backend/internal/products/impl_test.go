@@ -3,7 +3,6 @@ package products
 import (
 	"context"
 	"testing"
-	"time"
 
 	"github.com/solomonczyk/izborator/internal/logger"
 )
@@ -14,7 +13,9 @@ type mockStorage struct {
 	getProductByIDFunc func(id string) (*Product, error)
 	browseProductsFunc func(params BrowseParams) (*BrowseResult, error)
 	listBrandsFunc     func(ctx context.Context, productType string) ([]string, error)
+	attrFacetsFunc     func(categoryIDs []string) ([]*AttributeFacet, error)
 	saveProductFunc    func(product *Product) error
+	getOffersFunc      func(productID string, cityID *string) ([]*Offer, error)
 	savePriceFunc      func(productID string, price float64, currency string) error //nolint:unused
 }
 
@@ -52,6 +53,13 @@ func (m *mockStorage) ListBrands(ctx context.Context, productType string) ([]str
 	return []string{}, nil
 }
 
+func (m *mockStorage) AttributeFacets(ctx context.Context, categoryIDs []string) ([]*AttributeFacet, error) {
+	if m.attrFacetsFunc != nil {
+		return m.attrFacetsFunc(categoryIDs)
+	}
+	return []*AttributeFacet{}, nil
+}
+
 func (m *mockStorage) SaveProduct(product *Product) error {
 	if m.saveProductFunc != nil {
 		return m.saveProductFunc(product)
@@ -63,12 +71,15 @@ func (m *mockStorage) GetProductPrices(productID string) ([]*ProductPrice, error
 	return nil, nil
 }
 
-func (m *mockStorage) SaveProductPrice(price *ProductPrice) error {
-	return nil
+func (m *mockStorage) GetOffers(ctx context.Context, productID string, cityID *string) ([]*Offer, error) {
+	if m.getOffersFunc != nil {
+		return m.getOffersFunc(productID, cityID)
+	}
+	return nil, nil
 }
 
-func (m *mockStorage) GetURLsForRescrape(ctx context.Context, olderThan time.Duration, limit int) ([]RescrapeItem, error) {
-	return nil, nil
+func (m *mockStorage) SaveProductPrice(price *ProductPrice) error {
+	return nil
 }
 
 // mockLogger мок для logger - используем реальный logger
@@ -367,3 +378,41 @@ func TestBrowseDefaultParams(t *testing.T) {
 		t.Fatal("expected non-nil result")
 	}
 }
+
+// mockBrandNames мок справочника брендов
+type mockBrandNames map[string]string
+
+func (m mockBrandNames) Canonicalize(raw string) string {
+	if name, ok := m[raw]; ok {
+		return name
+	}
+	return raw
+}
+
+// TestListBrandsCanonical тестирует сведение написаний бренда к каноническому названию
+func TestListBrandsCanonical(t *testing.T) {
+	service := &Service{
+		storage: &mockStorage{
+			listBrandsFunc: func(ctx context.Context, productType string) ([]string, error) {
+				return []string{"Hewlett-Packard", "HP", "lg", "Samsung"}, nil
+			},
+		},
+		brands: mockBrandNames{"Hewlett-Packard": "HP", "lg": "LG"},
+		logger: createMockLogger(),
+	}
+
+	got, err := service.ListBrands(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"HP", "LG", "Samsung"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("brand %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
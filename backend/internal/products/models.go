@@ -35,6 +35,20 @@ type Product struct {
 	UpdatedAt        time.Time         `json:"updated_at"`
 }
 
+// Базовые единицы фасовки: цена за единицу считается за 1 кг, 1 л или 1 штуку
+const (
+	UnitKilogram = "kg"
+	UnitLiter    = "l"
+	UnitPiece    = "pcs"
+)
+
+// PackSize фасовка предложения: "6x0.5 l" -> 6 упаковок по 0.5 л
+type PackSize struct {
+	Count  int     `json:"count"`  // число упаковок в мультипаке (1 - одна упаковка)
+	Amount float64 `json:"amount"` // количество в одной упаковке в базовой единице
+	Unit   string  `json:"unit"`   // базовая единица: kg, l, pcs
+}
+
 // ProductPrice цена товара в конкретном магазине
 type ProductPrice struct {
 	ProductID string    `json:"product_id"`
@@ -45,6 +59,40 @@ type ProductPrice struct {
 	URL       string    `json:"url"`
 	InStock   bool      `json:"in_stock"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Pack      *PackSize `json:"pack,omitempty"`       // фасовка из названия или характеристик магазина
+	UnitPrice *float64  `json:"unit_price,omitempty"` // цена за базовую единицу фасовки
+}
+
+// Offer предложение магазина с итоговой ценой и позицией в рейтинге
+type Offer struct {
+	ProductID        string    `json:"product_id"`
+	ShopID           string    `json:"shop_id"`
+	ShopName         string    `json:"shop_name"`
+	Price            float64   `json:"price"`
+	Currency         string    `json:"currency"`
+	DeliveryFee      float64   `json:"delivery_fee"`
+	FreeDeliveryFrom *float64  `json:"free_delivery_from,omitempty"`
+	TotalPrice       float64   `json:"total_price"` // цена + доставка
+	URL              string    `json:"url"`
+	InStock          bool      `json:"in_stock"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	CityID           *string   `json:"city_id,omitempty"` // nil - предложение без привязки к городу
+	IsLocal          bool      `json:"is_local"`          // предложение в запрошенном городе
+	IsStale          bool      `json:"is_stale"`          // цена давно не обновлялась
+	Rank             int       `json:"rank"`              // 1 - лучшее предложение
+	IsBest           bool      `json:"is_best"`
+	Pack             *PackSize `json:"pack,omitempty"`
+	UnitPrice        *float64  `json:"unit_price,omitempty"` // цена за базовую единицу фасовки (без доставки)
+}
+
+// OfferList предложения товара, отсортированные по рейтингу
+type OfferList struct {
+	ProductID string   `json:"product_id"`
+	CityID    *string  `json:"city_id,omitempty"`
+	Currency  string   `json:"currency,omitempty"` // валюта, в которой выбирается лучшее предложение
+	Best      *Offer   `json:"best,omitempty"`
+	Items     []*Offer `json:"items"`
+	Count     int      `json:"count"`
 }
 
 // SearchResult результат поиска товаров
@@ -73,6 +121,8 @@ type BrowseProduct struct {
 	ServiceMetadata *ServiceMetadata  `json:"service_metadata,omitempty"` // Метаданные для услуг
 	IsDeliverable   bool              `json:"is_deliverable"`        // Товар можно доставить
 	IsOnsite         bool              `json:"is_onsite"`            // Услуга с выездом мастера
+	UnitPrice       *float64          `json:"unit_price,omitempty"` // Минимальная цена за базовую единицу фасовки
+	Unit            string            `json:"unit,omitempty"`       // Базовая единица цены за единицу: kg, l, pcs
 }
 
 // BrowseParams параметры для каталога
@@ -92,7 +142,46 @@ type BrowseParams struct {
 	MaxDuration *int
 	Page        int
 	PerPage     int
-	Sort        string
+	Sort        string // price_asc, price_desc, unit_price_asc, unit_price_desc, name_asc, name_desc, newest
+	// Attributes фильтры по атрибутам справочника (attr.<code>=...)
+	Attributes []AttributeFilter
+	// SortAttribute сортировка по атрибуту (sort=attr.<code>_asc), имеет приоритет над Sort
+	SortAttribute *AttributeSort
+}
+
+// AttributeFilter фильтр каталога по атрибуту справочника (attr.ram=8..16, attr.color=black,white)
+type AttributeFilter struct {
+	AttributeID string
+	Code        string   // код атрибута в нижнем регистре, в индексе поле attrs.<code>
+	Min         *float64 // диапазон для int/float
+	Max         *float64
+	Values      []string // допустимые значения для string/enum (без учёта регистра)
+	Bool        *bool
+}
+
+// AttributeSort сортировка каталога по атрибуту; товары без значения - в конце
+type AttributeSort struct {
+	AttributeID string
+	Code        string
+	Desc        bool
+}
+
+// AttributeFacet значения атрибута среди товаров категории с количеством товаров
+type AttributeFacet struct {
+	Code       string                `json:"code"`
+	Name       string                `json:"name"`
+	DataType   string                `json:"data_type"`
+	Unit       string                `json:"unit,omitempty"`
+	IsSortable bool                  `json:"is_sortable"`
+	Min        *float64              `json:"min,omitempty"` // для int/float
+	Max        *float64              `json:"max,omitempty"`
+	Values     []AttributeFacetValue `json:"values"`
+}
+
+// AttributeFacetValue значение атрибута и число товаров с ним
+type AttributeFacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
 }
 
 // BrowseResult результат каталога
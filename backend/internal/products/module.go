@@ -3,7 +3,6 @@ package products
 import (
 	"context"
 	"github.com/solomonczyk/izborator/internal/logger"
-	"time"
 )
 
 // Storage интерфейс для работы с хранилищем товаров
@@ -19,41 +18,42 @@ type Storage interface {
 
 	ListBrands(ctx context.Context, productType string) ([]string, error)
 
+	// AttributeFacets считает значения фильтруемых атрибутов типов товаров категорий
+	// среди товаров этих категорий
+	AttributeFacets(ctx context.Context, categoryIDs []string) ([]*AttributeFacet, error)
+
 	// SaveProduct сохраняет товар
 	SaveProduct(product *Product) error
 
 	// GetProductPrices получает цены товара из разных магазинов
 	GetProductPrices(productID string) ([]*ProductPrice, error)
 
+	// GetOffers получает предложения товара с условиями доставки магазинов
+	// Если cityID задан - только предложения этого города и без привязки к городу
+	GetOffers(ctx context.Context, productID string, cityID *string) ([]*Offer, error)
+
 	// SaveProductPrice сохраняет цену товара
 	SaveProductPrice(price *ProductPrice) error
-
-	// GetURLsForRescrape возвращает список URL и ID магазинов для товаров,
-	// цена которых не обновлялась дольше указанного времени
-	GetURLsForRescrape(ctx context.Context, olderThan time.Duration, limit int) ([]RescrapeItem, error)
 }
 
-// RescrapeItem элемент для перескрапинга
-type RescrapeItem struct {
-	URL    string
-	ShopID string
+// BrandNames справочник брендов: каноническое название по любому написанию
+type BrandNames interface {
+	Canonicalize(raw string) string
 }
 
 // Service сервис для работы с товарами
 type Service struct {
 	storage Storage
+	brands  BrandNames
 	logger  *logger.Logger
 }
 
-// New создаёт новый сервис товаров
-func New(storage Storage, log *logger.Logger) *Service {
+// New создаёт новый сервис товаров; brandNames может быть nil,
+// тогда бренды отдаются в написании из товаров
+func New(storage Storage, brandNames BrandNames, log *logger.Logger) *Service {
 	return &Service{
 		storage: storage,
+		brands:  brandNames,
 		logger:  log,
 	}
 }
-
-// GetURLsForRescrape возвращает список URL для перескрапинга
-func (s *Service) GetURLsForRescrape(ctx context.Context, olderThan time.Duration, limit int) ([]RescrapeItem, error) {
-	return s.storage.GetURLsForRescrape(ctx, olderThan, limit)
-}
@@ -0,0 +1,115 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// offerStaleAfter цена старше этого срока считается устаревшей и опускается в рейтинге
+const offerStaleAfter = 72 * time.Hour
+
+// GetOffers возвращает предложения товара, отсортированные по рейтингу, с отмеченным лучшим
+func (s *Service) GetOffers(ctx context.Context, productID string, cityID *string) (*OfferList, error) {
+	if productID == "" {
+		return nil, ErrInvalidProductID
+	}
+
+	offers, err := s.storage.GetOffers(ctx, productID, cityID)
+	if err != nil {
+		s.logger.Error("Failed to get product offers", map[string]interface{}{
+			"error":      err,
+			"product_id": productID,
+		})
+		return nil, fmt.Errorf("failed to get offers: %w", err)
+	}
+
+	currency := rankOffers(offers, cityID, time.Now())
+
+	list := &OfferList{
+		ProductID: productID,
+		CityID:    cityID,
+		Currency:  currency,
+		Items:     offers,
+		Count:     len(offers),
+	}
+	if len(offers) > 0 && offers[0].IsBest {
+		list.Best = offers[0]
+	}
+	return list, nil
+}
+
+// rankOffers считает итоговую цену и сортирует предложения:
+// основная валюта -> в наличии -> свежая цена -> итоговая цена -> предложение в городе -> более свежее.
+// Цены в разных валютах не сравниваются: предложения в других валютах идут после основной,
+// сгруппированные по валюте. Лучшим считается первое предложение, если товар есть в наличии.
+// Возвращает основную валюту
+func rankOffers(offers []*Offer, cityID *string, now time.Time) string {
+	for _, offer := range offers {
+		if offer.FreeDeliveryFrom != nil && offer.Price >= *offer.FreeDeliveryFrom {
+			offer.DeliveryFee = 0
+		}
+		offer.TotalPrice = offer.Price + offer.DeliveryFee
+		offer.IsLocal = cityID != nil && offer.CityID != nil && *offer.CityID == *cityID
+		offer.IsStale = now.Sub(offer.UpdatedAt) > offerStaleAfter
+		offer.IsBest = false
+	}
+
+	primary := primaryCurrency(offers)
+
+	sort.SliceStable(offers, func(i, j int) bool {
+		a, b := offers[i], offers[j]
+		if a.Currency != b.Currency {
+			if a.Currency == primary || b.Currency == primary {
+				return a.Currency == primary
+			}
+			return a.Currency < b.Currency
+		}
+		if a.InStock != b.InStock {
+			return a.InStock
+		}
+		if a.IsStale != b.IsStale {
+			return !a.IsStale
+		}
+		if a.TotalPrice != b.TotalPrice {
+			return a.TotalPrice < b.TotalPrice
+		}
+		if a.IsLocal != b.IsLocal {
+			return a.IsLocal
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+
+	for i, offer := range offers {
+		offer.Rank = i + 1
+	}
+	if len(offers) > 0 && offers[0].InStock {
+		offers[0].IsBest = true
+	}
+	return primary
+}
+
+// primaryCurrency валюта, в которой больше всего предложений в наличии (если в наличии
+// ничего нет - всех предложений); при равенстве выбирается меньший код валюты
+func primaryCurrency(offers []*Offer) string {
+	counts := make(map[string]int)
+	for _, offer := range offers {
+		if offer.InStock {
+			counts[offer.Currency]++
+		}
+	}
+	if len(counts) == 0 {
+		for _, offer := range offers {
+			counts[offer.Currency]++
+		}
+	}
+
+	primary, best := "", 0
+	for currency, count := range counts {
+		if count > best || (count == best && currency < primary) {
+			primary, best = currency, count
+		}
+	}
+	return primary
+}
@@ -0,0 +1,107 @@
+package products
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRankOffers_TotalPriceIncludesDelivery(t *testing.T) {
+	now := time.Now()
+	freeFrom := 1000.0
+	offers := []*Offer{
+		{ShopID: "cheap-with-delivery", Price: 900, DeliveryFee: 300, InStock: true, UpdatedAt: now},
+		{ShopID: "free-delivery", Price: 1050, DeliveryFee: 300, FreeDeliveryFrom: &freeFrom, InStock: true, UpdatedAt: now},
+		{ShopID: "no-fee", Price: 1100, InStock: true, UpdatedAt: now},
+	}
+
+	rankOffers(offers, nil, now)
+
+	want := []string{"free-delivery", "no-fee", "cheap-with-delivery"}
+	for i, shopID := range want {
+		if offers[i].ShopID != shopID || offers[i].Rank != i+1 {
+			t.Fatalf("position %d: expected %s, got %s (rank %d)", i, shopID, offers[i].ShopID, offers[i].Rank)
+		}
+	}
+	if offers[0].DeliveryFee != 0 || offers[0].TotalPrice != 1050 {
+		t.Errorf("free delivery threshold not applied: %+v", offers[0])
+	}
+	if offers[2].TotalPrice != 1200 {
+		t.Errorf("expected total 1200, got %v", offers[2].TotalPrice)
+	}
+	if !offers[0].IsBest || offers[1].IsBest {
+		t.Error("only the first offer must be marked best")
+	}
+}
+
+func TestRankOffers_StockFreshnessAndCity(t *testing.T) {
+	now := time.Now()
+	city := "city-1"
+	offers := []*Offer{
+		{ShopID: "out-of-stock", Price: 100, InStock: false, UpdatedAt: now},
+		{ShopID: "stale", Price: 200, InStock: true, UpdatedAt: now.Add(-2 * offerStaleAfter)},
+		{ShopID: "online", Price: 300, InStock: true, UpdatedAt: now},
+		{ShopID: "local", Price: 300, InStock: true, UpdatedAt: now.Add(-time.Hour), CityID: &city},
+	}
+
+	rankOffers(offers, &city, now)
+
+	want := []string{"local", "online", "stale", "out-of-stock"}
+	for i, shopID := range want {
+		if offers[i].ShopID != shopID {
+			t.Fatalf("position %d: expected %s, got %s", i, shopID, offers[i].ShopID)
+		}
+	}
+	if !offers[0].IsLocal || offers[1].IsLocal {
+		t.Error("only the city offer must be local")
+	}
+	if !offers[2].IsStale {
+		t.Error("expected stale offer to be flagged")
+	}
+}
+
+func TestRankOffers_DoesNotCompareCurrencies(t *testing.T) {
+	now := time.Now()
+	offers := []*Offer{
+		{ShopID: "eur", Price: 100, Currency: "EUR", InStock: true, UpdatedAt: now},
+		{ShopID: "rsd-expensive", Price: 12500, Currency: "RSD", InStock: true, UpdatedAt: now},
+		{ShopID: "rsd-cheap", Price: 11900, Currency: "RSD", InStock: true, UpdatedAt: now},
+	}
+
+	currency := rankOffers(offers, nil, now)
+
+	// 100 EUR не дешевле 11 900 RSD: лучшее выбирается в валюте большинства предложений
+	if currency != "RSD" {
+		t.Fatalf("expected primary currency RSD, got %q", currency)
+	}
+	want := []string{"rsd-cheap", "rsd-expensive", "eur"}
+	for i, shopID := range want {
+		if offers[i].ShopID != shopID {
+			t.Fatalf("position %d: expected %s, got %s", i, shopID, offers[i].ShopID)
+		}
+	}
+	if !offers[0].IsBest || offers[2].IsBest {
+		t.Error("best offer must be chosen within the primary currency")
+	}
+}
+
+func TestGetOffers_NoBestWhenNothingInStock(t *testing.T) {
+	storage := &mockStorage{
+		getOffersFunc: func(productID string, cityID *string) ([]*Offer, error) {
+			return []*Offer{{ShopID: "a", Price: 100, UpdatedAt: time.Now()}}, nil
+		},
+	}
+	service := New(storage, nil, createMockLogger())
+
+	list, err := service.GetOffers(context.Background(), "product-1", nil)
+	if err != nil {
+		t.Fatalf("GetOffers failed: %v", err)
+	}
+	if list.Count != 1 || list.Best != nil || list.Items[0].IsBest {
+		t.Errorf("out of stock offer must not be best: %+v", list)
+	}
+
+	if _, err := service.GetOffers(context.Background(), "", nil); err != ErrInvalidProductID {
+		t.Errorf("expected ErrInvalidProductID, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package products
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/attributes"
+)
+
+// packUnit единица фасовки: базовая единица и множитель к ней
+type packUnit struct {
+	base   string
+	factor float64
+}
+
+// packUnits единицы фасовки в названиях и характеристиках (латиница и кириллица, нижний регистр)
+var packUnits = map[string]packUnit{
+	"kg": {UnitKilogram, 1}, "g": {UnitKilogram, 0.001}, "gr": {UnitKilogram, 0.001},
+	"l": {UnitLiter, 1}, "lit": {UnitLiter, 1}, "litar": {UnitLiter, 1}, "litra": {UnitLiter, 1},
+	"dl": {UnitLiter, 0.1}, "cl": {UnitLiter, 0.01}, "ml": {UnitLiter, 0.001},
+	"kom": {UnitPiece, 1}, "komada": {UnitPiece, 1}, "kos": {UnitPiece, 1}, "pcs": {UnitPiece, 1}, "pc": {UnitPiece, 1},
+	"кг": {UnitKilogram, 1}, "г": {UnitKilogram, 0.001}, "гр": {UnitKilogram, 0.001},
+	"л": {UnitLiter, 1}, "дл": {UnitLiter, 0.1}, "мл": {UnitLiter, 0.001},
+	"ком": {UnitPiece, 1}, "комада": {UnitPiece, 1},
+}
+
+// Части выражений фасовки: число отделено от букв и цифр слева ("q80l" - модель, не 80 л),
+// единица - от букв справа
+const (
+	packStartPattern  = `(?:^|[^\p{L}\d.,])`
+	packNumberPattern = `(\d+(?:[.,]\d+)?)`
+	packUnitPattern   = `(komada|kom|kos|pcs|pc|litar|litra|lit|kg|gr|g|ml|cl|dl|l|комада|ком|кг|гр|г|мл|дл|л)`
+	packEndPattern    = `(?:[^\p{L}\d]|$)`
+)
+
+var (
+	// multipackRegex "6x0.5l", "6 x 1,5 l"
+	multipackRegex = regexp.MustCompile(packStartPattern + `(\d+)\s*[x×*]\s*` + packNumberPattern + `\s*` + packUnitPattern + packEndPattern)
+	// multipackReverseRegex "0.5l x 6", "500 ml x6"
+	multipackReverseRegex = regexp.MustCompile(packStartPattern + packNumberPattern + `\s*` + packUnitPattern + `\s*[x×*]\s*(\d+)` + packEndPattern)
+	// quantityRegex "1l", "200 g", "10 kom"
+	quantityRegex = regexp.MustCompile(packStartPattern + packNumberPattern + `\s*` + packUnitPattern + packEndPattern)
+	// thousandsPackRegex "1.000 g" - разделитель тысяч, а не дробная часть
+	thousandsPackRegex = regexp.MustCompile(`^\d{1,3}[.,]\d{3}$`)
+)
+
+// packSpecLabels характеристики магазина с фасовкой (после attributes.NormalizeLabel), по приоритету.
+// Масса и объём самого устройства ("tezina", "zapremina") не учитываются
+var packSpecLabels = []string{
+	"neto kolicina", "neto masa", "neto tezina", "neto zapremina", "neto sadrzaj",
+	"sadrzaj pakovanja", "kolicina u pakovanju", "pakovanje", "kolicina", "gramaza",
+}
+
+// consumableCategoryWords слова категории магазина (после attributes.NormalizeLabel),
+// в которой цена за единицу имеет смысл: продукты, напитки, бытовая химия, косметика
+var consumableCategoryWords = map[string]bool{
+	"hrana": true, "hrane": true, "pice": true, "pica": true, "namirnice": true, "napici": true,
+	"voda": true, "vode": true, "sok": true, "sokovi": true, "kafa": true, "caj": true, "cajevi": true,
+	"meso": true, "mesa": true, "voce": true, "povrce": true, "mleko": true, "mlecni": true,
+	"pivo": true, "piva": true, "vino": true, "vina": true, "alkohol": true, "slatkisi": true,
+	"grickalice": true, "konzerve": true, "zacini": true, "pekara": true, "hemija": true,
+	"deterdzenti": true, "higijena": true, "kozmetika": true, "pelene": true,
+	"food": true, "grocery": true, "drinks": true, "beverages": true, "household": true,
+}
+
+// deviceCategoryWords слова категории техники: "Aparati za sok" - не напитки
+var deviceCategoryWords = map[string]bool{
+	"aparat": true, "aparati": true, "uredjaji": true, "tehnika": true, "elektronika": true,
+	"masine": true, "oprema": true,
+}
+
+const (
+	maxPackCount  = 100   // больше упаковок в мультипаке - скорее артикул, чем фасовка
+	maxPackTotal  = 1000  // кг или л в одном предложении
+	maxPackPieces = 10000 // штук в одном предложении
+)
+
+// ParsePackSize разбирает фасовку из названия ("Mleko 6x0,5l", "Kafa 200g", "Jaja 10 kom"),
+// а если в названии её нет - из характеристик магазина ("Neto količina: 1 kg").
+// Фасовка ищется только в категориях продуктов и расходных товаров (IsConsumableCategory);
+// без категории магазина "5G" в названии не считается массой. Возвращает nil, если фасовка не найдена
+func ParsePackSize(name, category string, specs map[string]string) *PackSize {
+	consumable := IsConsumableCategory(category)
+	if category != "" && !consumable {
+		return nil
+	}
+
+	if pack := parsePackText(name, consumable); pack != nil {
+		return pack
+	}
+
+	byLabel := make(map[string]string, len(specs))
+	for label, value := range specs {
+		byLabel[attributes.NormalizeLabel(label)] = value
+	}
+	for _, label := range packSpecLabels {
+		if value, ok := byLabel[label]; ok {
+			if pack := parsePackText(value, true); pack != nil {
+				return pack
+			}
+		}
+	}
+	return nil
+}
+
+// IsConsumableCategory относится ли категория магазина ("Hrana i piće > Mlečni proizvodi")
+// к продуктам или расходным товарам, для которых сравнивается цена за единицу
+func IsConsumableCategory(category string) bool {
+	consumable := false
+	for _, word := range strings.Fields(attributes.NormalizeLabel(category)) {
+		if deviceCategoryWords[word] {
+			return false
+		}
+		if consumableCategoryWords[word] {
+			consumable = true
+		}
+	}
+	return consumable
+}
+
+// parsePackText ищет мультипак, затем массу или объём (число штук становится числом упаковок),
+// затем только число штук. Без grams однозначные "5g" и "4G" (сеть в названиях телефонов) - не масса
+func parsePackText(text string, grams bool) *PackSize {
+	text = strings.ToLower(text)
+
+	if match := multipackRegex.FindStringSubmatch(text); match != nil {
+		if pack := newPackSize(match[1], match[2], match[3]); pack != nil {
+			return pack
+		}
+	}
+	if match := multipackReverseRegex.FindStringSubmatch(text); match != nil {
+		if pack := newPackSize(match[3], match[1], match[2]); pack != nil {
+			return pack
+		}
+	}
+
+	var measure, pieces *PackSize
+	for _, match := range quantityRegex.FindAllStringSubmatch(text, -1) {
+		if !grams && isNetworkGeneration(match[1], match[2]) {
+			continue
+		}
+		pack := newPackSize("1", match[1], match[2])
+		switch {
+		case pack == nil:
+		case pack.Unit == UnitPiece && pieces == nil:
+			pieces = pack
+		case pack.Unit != UnitPiece && measure == nil:
+			measure = pack
+		}
+	}
+
+	switch {
+	case measure != nil && pieces != nil && pieces.Amount == math.Trunc(pieces.Amount):
+		// "Voda 0,5l pakovanje 6 kom" - 6 упаковок по 0.5 л
+		return validPack(&PackSize{Count: int(pieces.Amount), Amount: measure.Amount, Unit: measure.Unit})
+	case measure != nil:
+		return measure
+	default:
+		return pieces
+	}
+}
+
+// isNetworkGeneration "5g" - поколение мобильной сети, а не 5 граммов
+func isNetworkGeneration(amount, unit string) bool {
+	return (unit == "g" || unit == "г") && len(amount) == 1
+}
+
+// newPackSize собирает фасовку из найденных числа упаковок, количества и единицы
+func newPackSize(count, amount, unit string) *PackSize {
+	u, ok := packUnits[unit]
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return nil
+	}
+	value, ok := parsePackAmount(amount, u.factor < 1)
+	if !ok {
+		return nil
+	}
+	// Округление убирает погрешность пересчёта: 200 g -> 0.2 kg
+	return validPack(&PackSize{Count: n, Amount: math.Round(value*u.factor*1e4) / 1e4, Unit: u.base})
+}
+
+// parsePackAmount разбирает "0,5" и "1.5"; для мелких единиц ("1.000 g") точка отделяет тысячи
+func parsePackAmount(value string, smallUnit bool) (float64, bool) {
+	if smallUnit && thousandsPackRegex.MatchString(value) {
+		value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	}
+	number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	return number, err == nil
+}
+
+// validPack отбрасывает нулевые и неправдоподобно большие фасовки
+func validPack(pack *PackSize) *PackSize {
+	if pack.Count < 1 || pack.Count > maxPackCount || pack.Amount <= 0 {
+		return nil
+	}
+	limit := float64(maxPackTotal)
+	if pack.Unit == UnitPiece {
+		limit = maxPackPieces
+	}
+	if pack.Total() > limit {
+		return nil
+	}
+	return pack
+}
+
+// Total общее количество в базовой единице: 6x0.5 л -> 3 л
+func (p *PackSize) Total() float64 {
+	return float64(p.Count) * p.Amount
+}
+
+// UnitPrice цена за базовую единицу (за 1 кг, 1 л или 1 штуку), округлённая до сотых
+func (p *PackSize) UnitPrice(price float64) *float64 {
+	if p == nil || price <= 0 || p.Total() <= 0 {
+		return nil
+	}
+	value := math.Round(price/p.Total()*100) / 100
+	return &value
+}
+
+// MinUnitPrice минимальная цена за единицу среди предложений и её единица
+func MinUnitPrice(prices []*ProductPrice) (*float64, string) {
+	var (
+		best *float64
+		unit string
+	)
+	for _, price := range prices {
+		if price.UnitPrice == nil || price.Pack == nil {
+			continue
+		}
+		if best == nil || *price.UnitPrice < *best {
+			best, unit = price.UnitPrice, price.Pack.Unit
+		}
+	}
+	return best, unit
+}
+
+// SortByUnitPrice сортирует каталог по цене за единицу внутри групп kg, l, pcs:
+// цены за 1 кг и за 1 шт не сравниваются между собой. Товары без фасовки - в конце
+func SortByUnitPrice(items []BrowseProduct, desc bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].UnitPrice, items[j].UnitPrice
+		switch {
+		case a == nil || b == nil:
+			return a != nil && b == nil
+		case items[i].Unit != items[j].Unit:
+			return items[i].Unit < items[j].Unit
+		case desc:
+			return *a > *b
+		default:
+			return *a < *b
+		}
+	})
+}
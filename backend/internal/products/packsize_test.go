@@ -0,0 +1,106 @@
+package products
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePackSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		category string
+		specs    map[string]string
+		want     *PackSize
+	}{
+		{"liter", "Mleko Imlek 2,8% mm 1l", "", nil, &PackSize{Count: 1, Amount: 1, Unit: UnitLiter}},
+		{"multipack", "Voda Rosa 6x0,5l", "", nil, &PackSize{Count: 6, Amount: 0.5, Unit: UnitLiter}},
+		{"multipack with spaces", "Coca-Cola 4 x 330 ml limenka", "", nil, &PackSize{Count: 4, Amount: 0.33, Unit: UnitLiter}},
+		{"reverse multipack", "Pivo Jelen 0.5 l x 24", "", nil, &PackSize{Count: 24, Amount: 0.5, Unit: UnitLiter}},
+		{"grams", "Kafa Grand 200g", "", nil, &PackSize{Count: 1, Amount: 0.2, Unit: UnitKilogram}},
+		{"thousands separator", "Brašno T-400 1.000 g", "", nil, &PackSize{Count: 1, Amount: 1, Unit: UnitKilogram}},
+		{"measure with pieces", "Jogurt 180 g pakovanje 4 kom", "", nil, &PackSize{Count: 4, Amount: 0.18, Unit: UnitKilogram}},
+		{"pieces", "Jaja M klasa 10 kom", "", nil, &PackSize{Count: 1, Amount: 10, Unit: UnitPiece}},
+		{"cyrillic", "Млеко 1,5 л", "", nil, &PackSize{Count: 1, Amount: 1.5, Unit: UnitLiter}},
+		{"specs fallback", "Deterdžent Ariel", "", map[string]string{"Neto količina": "2,2 kg", "Težina": "2,4 kg"}, &PackSize{Count: 1, Amount: 2.2, Unit: UnitKilogram}},
+		{"device weight is ignored", "Usisivač X200", "", map[string]string{"Težina": "5 kg"}, nil},
+		{"model number is not a volume", "Samsung QE55Q80L", "", nil, nil},
+		{"no pack", "Apple iPhone 15 128GB", "", nil, nil},
+		{"implausible multipack", "Kabl 500x2 l", "", nil, nil},
+		{"grocery category", "Kvasac suvi 7g", "Namirnice > Pekarski proizvodi", nil, &PackSize{Count: 1, Amount: 0.007, Unit: UnitKilogram}},
+		{"network generation", "Samsung Galaxy A55 5G 128GB", "", nil, nil},
+		{"tech category", "Lenovo IdeaPad 3 15 1,6 kg", "Računari > Laptopovi", nil, nil},
+		{"appliance for drinks", "Sokovnik Philips 1,5 l", "Kućni aparati > Aparati za sok", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePackSize(tt.title, tt.category, tt.specs)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("expected no pack, got %+v", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Fatalf("ParsePackSize(%q) = %+v, want %+v", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackSize_UnitPrice(t *testing.T) {
+	single := &PackSize{Count: 1, Amount: 1, Unit: UnitLiter}
+	multi := &PackSize{Count: 6, Amount: 0.5, Unit: UnitLiter}
+
+	if got := single.UnitPrice(149.99); got == nil || *got != 149.99 {
+		t.Errorf("1 l: unit price %v, want 149.99", got)
+	}
+	if got := multi.UnitPrice(420); got == nil || *got != 140 {
+		t.Errorf("6x0.5 l: unit price %v, want 140", got)
+	}
+	if got := multi.UnitPrice(0); got != nil {
+		t.Errorf("zero price must have no unit price, got %v", *got)
+	}
+	var none *PackSize
+	if got := none.UnitPrice(100); got != nil {
+		t.Errorf("nil pack must have no unit price, got %v", *got)
+	}
+}
+
+func TestSortByUnitPrice(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	items := []BrowseProduct{
+		{ID: "no-pack"},
+		{ID: "eggs", UnitPrice: price(25), Unit: UnitPiece},
+		{ID: "expensive", UnitPrice: price(180), Unit: UnitLiter},
+		{ID: "cheap", UnitPrice: price(140), Unit: UnitLiter},
+		{ID: "coffee", UnitPrice: price(2400), Unit: UnitKilogram},
+	}
+	order := func() string {
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		return strings.Join(ids, ",")
+	}
+
+	SortByUnitPrice(items, false)
+	if got := order(); got != "coffee,cheap,expensive,eggs,no-pack" {
+		t.Errorf("ascending order %s", got)
+	}
+
+	SortByUnitPrice(items, true)
+	if got := order(); got != "coffee,expensive,cheap,eggs,no-pack" {
+		t.Errorf("descending order %s", got)
+	}
+
+	unitPrice, unit := MinUnitPrice([]*ProductPrice{
+		{Price: 100},
+		{Price: 420, Pack: &PackSize{Count: 6, Amount: 0.5, Unit: UnitLiter}, UnitPrice: price(140)},
+		{Price: 150, Pack: &PackSize{Count: 1, Amount: 1, Unit: UnitLiter}, UnitPrice: price(150)},
+	})
+	if unitPrice == nil || *unitPrice != 140 || unit != UnitLiter {
+		t.Errorf("MinUnitPrice = %v %q, want 140 l", unitPrice, unit)
+	}
+}
@@ -0,0 +1,113 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLeaseLost is returned by Lease.Refresh when the lease expired or was
+// taken over by another holder.
+var ErrLeaseLost = errors.New("key lease lost")
+
+// KeyLocker grants exclusive, expiring leases on ordering keys. It is shared by
+// all consumer processes, so a key is handled by at most one of them at a time.
+type KeyLocker interface {
+	// Acquire takes the lease on key for ttl. It returns a nil Lease and no
+	// error when another holder owns the key.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+}
+
+// Lease is an acquired key lease.
+type Lease interface {
+	// Refresh extends the lease by ttl from now.
+	Refresh(ctx context.Context, ttl time.Duration) error
+	// Release frees the key if the lease is still held.
+	Release(ctx context.Context) error
+}
+
+var (
+	// refreshLeaseScript extends the lease only while it still holds our token.
+	refreshLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseLeaseScript deletes the lease only while it still holds our token.
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisKeyLocker implements KeyLocker with SET NX PX leases.
+type RedisKeyLocker struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisKeyLocker returns a Redis-backed KeyLocker.
+func NewRedisKeyLocker(client *redis.Client) *RedisKeyLocker {
+	return &RedisKeyLocker{
+		client:    client,
+		keyPrefix: "lease:",
+	}
+}
+
+// Acquire takes the lease on key unless another holder owns it.
+func (l *RedisKeyLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	if l.client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	token, err := leaseToken()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := l.client.SetNX(ctx, l.keyPrefix+key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire key lease: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &redisLease{client: l.client, key: l.keyPrefix + key, token: token}, nil
+}
+
+type redisLease struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLease) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := refreshLeaseScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to refresh key lease: %w", err)
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (l *redisLease) Release(ctx context.Context) error {
+	if err := releaseLeaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("failed to release key lease: %w", err)
+	}
+	return nil
+}
+
+func leaseToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
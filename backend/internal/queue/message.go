@@ -0,0 +1,44 @@
+package queue
+
+import "errors"
+
+// Message is a delivered queue message awaiting acknowledgement.
+type Message struct {
+	ID       string
+	Topic    string
+	Payload  []byte
+	Attempts int // delivery attempt number, starting at 1
+}
+
+// ErrPermanent marks a handler failure that must not be retried
+// (e.g. an undecodable payload). Wrap it with Permanent.
+var ErrPermanent = errors.New("permanent failure")
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() []error { return []error{e.err, ErrPermanent} }
+
+// Permanent wraps err so reliable consumers dead-letter the message immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrPermanent)
+}
+
+// shouldDeadLetter decides whether a failed message is moved to the dead-letter topic.
+func shouldDeadLetter(attempts, maxAttempts int, err error) bool {
+	if IsPermanent(err) {
+		return true
+	}
+	return maxAttempts > 0 && attempts >= maxAttempts
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("bad json")
+	err := Permanent(base)
+
+	if !IsPermanent(err) {
+		t.Error("Permanent error should be detected")
+	}
+	if !errors.Is(err, base) {
+		t.Error("Permanent error should unwrap to the original error")
+	}
+	if !IsPermanent(fmt.Errorf("wrapped: %w", err)) {
+		t.Error("wrapped Permanent error should be detected")
+	}
+	if IsPermanent(base) {
+		t.Error("plain error must not be permanent")
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+}
+
+func TestShouldDeadLetter(t *testing.T) {
+	transient := errors.New("db timeout")
+
+	tests := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		err         error
+		want        bool
+	}{
+		{"first failure", 1, 5, transient, false},
+		{"attempts left", 4, 5, transient, false},
+		{"attempts exhausted", 5, 5, transient, true},
+		{"permanent on first attempt", 1, 5, Permanent(transient), true},
+		{"unlimited attempts", 100, 0, transient, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldDeadLetter(tt.attempts, tt.maxAttempts, tt.err); got != tt.want {
+				t.Errorf("shouldDeadLetter(%d, %d) = %v, want %v", tt.attempts, tt.maxAttempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallHandler(t *testing.T) {
+	if err := callHandler(func([]byte) error { return nil }, nil); !IsPermanent(err) {
+		t.Errorf("empty payload should be a permanent error, got %v", err)
+	}
+
+	err := callHandler(func([]byte) error { panic("boom") }, []byte("{}"))
+	if err == nil || IsPermanent(err) {
+		t.Errorf("panic should be converted to a retryable error, got %v", err)
+	}
+
+	if err := callHandler(func([]byte) error { return nil }, []byte("{}")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRedisStreamQueue_Options(t *testing.T) {
+	q := NewRedisStreamQueue(nil, StreamOptions{}, nil)
+	if q.opts.Group != defaultStreamGroup || q.opts.MaxAttempts != defaultMaxAttempts || q.opts.VisibilityTimeout != defaultVisibilityTimeout {
+		t.Errorf("defaults not applied: %+v", q.opts)
+	}
+	if q.opts.Consumer == "" {
+		t.Error("consumer name should be generated")
+	}
+	if got := q.DeadLetterTopic("scraping_tasks"); got != "scraping_tasks.dead" {
+		t.Errorf("DeadLetterTopic = %q", got)
+	}
+
+	q = NewRedisStreamQueue(nil, StreamOptions{DeadLetterTopic: "poison"}, nil)
+	if got := q.DeadLetterTopic("scraping_tasks"); got != "poison" {
+		t.Errorf("DeadLetterTopic = %q, want configured topic", got)
+	}
+	if err := q.Publish("topic", map[string]string{}); err == nil {
+		t.Error("Publish without client should fail")
+	}
+}
+
+func TestRedisStreamQueue_ToMessage(t *testing.T) {
+	q := NewRedisStreamQueue(nil, StreamOptions{}, nil)
+	msg := q.toMessage("raw", redis.XMessage{ID: "1-0", Values: map[string]interface{}{"data": `{"a":1}`}}, 3)
+	if msg.ID != "1-0" || msg.Topic != "raw" || msg.Attempts != 3 || string(msg.Payload) != `{"a":1}` {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	msg = q.toMessage("raw", redis.XMessage{ID: "2-0", Values: map[string]interface{}{}}, 1)
+	if len(msg.Payload) != 0 {
+		t.Errorf("missing data field should yield empty payload")
+	}
+}
@@ -0,0 +1,393 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+const (
+	defaultPoolWorkers     = 1
+	defaultStatsInterval   = time.Minute
+	defaultLeaseTTL        = 30 * time.Second
+	defaultDispatchTimeout = 10 * time.Second
+	defaultBusyKeyDelay    = 5 * time.Second
+	fetchRetryDelay        = time.Second
+	leaseReleaseTimeout    = 5 * time.Second
+)
+
+// KeyFunc extracts an ordering key from a payload. Messages with the same key
+// are never handled concurrently: within one Pool they go to the same worker in
+// fetch order, and with PoolOptions.Locker a key lease keeps other consumer
+// processes (and redeliveries after VisibilityTimeout) off the key while the
+// handler runs. An empty key means the message has no ordering constraint.
+type KeyFunc func(payload []byte) string
+
+// JSONFieldsKey builds a KeyFunc from top-level JSON fields,
+// e.g. JSONFieldsKey("shop_id", "external_id"). The key is empty if any
+// field is missing or null.
+func JSONFieldsKey(fields ...string) KeyFunc {
+	return func(payload []byte) string {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(payload, &doc); err != nil {
+			return ""
+		}
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			value, ok := doc[field]
+			if !ok || value == nil {
+				return ""
+			}
+			parts = append(parts, fmt.Sprint(value))
+		}
+		return strings.Join(parts, "/")
+	}
+}
+
+// PoolOptions configures a consumer Pool.
+type PoolOptions struct {
+	Workers         int           // parallel handlers (config.QueueConfig.MaxWorkers)
+	FetchCount      int           // messages fetched per round trip
+	Key             KeyFunc       // ordering key, nil = no ordering
+	Locker          KeyLocker     // cross-process key leases, nil = ordering within this pool only
+	LeaseTTL        time.Duration // key lease lifetime, renewed while the handler runs
+	DispatchTimeout time.Duration // max wait for a busy worker before a message is handed back
+	BusyKeyDelay    time.Duration // redelivery delay for a message whose key is leased elsewhere
+	StatsInterval   time.Duration // how often stats are logged, <0 disables
+}
+
+// PoolStats is a snapshot of pool counters.
+type PoolStats struct {
+	Workers   int   `json:"workers"`
+	InFlight  int64 `json:"in_flight"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+}
+
+// Pool runs queue handlers on a fixed number of workers. Messages are sharded
+// to workers by ordering key, so messages with the same key are processed
+// sequentially while different keys run in parallel (see KeyFunc).
+//
+// A message waits at most DispatchTimeout for its worker. If the worker is
+// still busy, or the key is leased by another process, the message is handed
+// back to the queue (Releaser) instead of blocking the fetch loop, so fetched
+// messages do not sit in memory past the queue's VisibilityTimeout. A handed
+// back message is redelivered later, possibly after newer messages of its key.
+type Pool struct {
+	source Reliable
+	opts   PoolOptions
+	log    *logger.Logger
+
+	inFlight  atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+	next      atomic.Uint64
+}
+
+// NewPool creates a consumer pool over a reliable queue.
+func NewPool(source Reliable, opts PoolOptions, log *logger.Logger) *Pool {
+	if log == nil {
+		log = logger.New("info")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultPoolWorkers
+	}
+	if opts.FetchCount <= 0 {
+		opts.FetchCount = opts.Workers
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = defaultLeaseTTL
+	}
+	if opts.DispatchTimeout <= 0 {
+		opts.DispatchTimeout = defaultDispatchTimeout
+	}
+	if opts.BusyKeyDelay <= 0 {
+		opts.BusyKeyDelay = defaultBusyKeyDelay
+	}
+	if opts.StatsInterval == 0 {
+		opts.StatsInterval = defaultStatsInterval
+	}
+	return &Pool{
+		source: source,
+		opts:   opts,
+		log:    log,
+	}
+}
+
+// Stats returns current pool counters.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Workers:   p.opts.Workers,
+		InFlight:  p.inFlight.Load(),
+		Processed: p.processed.Load(),
+		Failed:    p.failed.Load(),
+	}
+}
+
+// Run consumes topic until ctx is cancelled. On cancellation it stops
+// fetching, lets workers drain already fetched messages and returns once
+// all of them are handled (or failed). Handlers receive a context that is not
+// cancelled by shutdown so in-flight work can complete.
+func (p *Pool) Run(ctx context.Context, topic string, handler func(ctx context.Context, payload []byte) error) error {
+	if topic == "" {
+		return errors.New("topic is required")
+	}
+	if handler == nil {
+		return errors.New("handler is required")
+	}
+	if p.source == nil {
+		return errors.New("queue source is nil")
+	}
+
+	workCtx := context.WithoutCancel(ctx)
+	shards := make([]chan dispatched, p.opts.Workers)
+	var wg sync.WaitGroup
+	for i := range shards {
+		shards[i] = make(chan dispatched, 1)
+		wg.Add(1)
+		go func(ch <-chan dispatched) {
+			defer wg.Done()
+			for d := range ch {
+				p.handle(workCtx, d, handler)
+			}
+		}(shards[i])
+	}
+
+	stopStats := p.startStatsLogger(topic)
+
+	p.log.Info("queue pool started", map[string]interface{}{
+		"topic":   topic,
+		"workers": p.opts.Workers,
+	})
+
+	for ctx.Err() == nil {
+		messages, err := p.source.Fetch(ctx, topic, p.opts.FetchCount)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			p.log.Warn("queue pool fetch failed", map[string]interface{}{
+				"topic": topic,
+				"error": err.Error(),
+			})
+			sleepCtx(ctx, fetchRetryDelay)
+			continue
+		}
+		for _, msg := range messages {
+			// Fetched messages are dispatched even during shutdown, so that they
+			// are handled (and acked) during drain or handed back to the queue.
+			d := dispatched{msg: msg, key: p.key(msg)}
+			p.inFlight.Add(1)
+			if !p.dispatch(shards[p.shard(d.key)], d) {
+				p.inFlight.Add(-1)
+				p.release(workCtx, msg, 0, "worker busy")
+			}
+		}
+	}
+
+	p.log.Info("queue pool draining", map[string]interface{}{
+		"topic":     topic,
+		"in_flight": p.inFlight.Load(),
+	})
+	for _, ch := range shards {
+		close(ch)
+	}
+	wg.Wait()
+	stopStats()
+
+	stats := p.Stats()
+	p.log.Info("queue pool stopped", map[string]interface{}{
+		"topic":     topic,
+		"processed": stats.Processed,
+		"failed":    stats.Failed,
+	})
+	return ctx.Err()
+}
+
+// dispatched is a fetched message with its ordering key.
+type dispatched struct {
+	msg *Message
+	key string
+}
+
+// dispatch hands d to a worker, waiting at most DispatchTimeout.
+func (p *Pool) dispatch(ch chan<- dispatched, d dispatched) bool {
+	timer := time.NewTimer(p.opts.DispatchTimeout)
+	defer timer.Stop()
+	select {
+	case ch <- d:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (p *Pool) handle(ctx context.Context, d dispatched, handler func(ctx context.Context, payload []byte) error) {
+	defer p.inFlight.Add(-1)
+	msg := d.msg
+
+	if d.key != "" && p.opts.Locker != nil {
+		lease, err := p.opts.Locker.Acquire(ctx, msg.Topic+":"+d.key, p.opts.LeaseTTL)
+		if err != nil {
+			p.fail(ctx, msg, err)
+			return
+		}
+		if lease == nil {
+			p.release(ctx, msg, p.opts.BusyKeyDelay, "key leased by another consumer")
+			return
+		}
+		defer p.keepLease(ctx, msg, lease)()
+	}
+
+	err := callHandler(func(payload []byte) error { return handler(ctx, payload) }, msg.Payload)
+	if err != nil {
+		p.fail(ctx, msg, err)
+		return
+	}
+
+	p.processed.Add(1)
+	if err := p.source.Ack(ctx, msg); err != nil {
+		p.log.Error("queue pool ack failed", map[string]interface{}{
+			"topic": msg.Topic,
+			"id":    msg.ID,
+			"error": err.Error(),
+		})
+	}
+}
+
+func (p *Pool) fail(ctx context.Context, msg *Message, err error) {
+	p.failed.Add(1)
+	if failErr := p.source.Fail(ctx, msg, err); failErr != nil {
+		p.log.Error("queue pool fail handling failed", map[string]interface{}{
+			"topic": msg.Topic,
+			"id":    msg.ID,
+			"error": failErr.Error(),
+		})
+	}
+}
+
+// release hands msg back to the queue for redelivery after delay. Without a
+// Releaser the message stays pending and is redelivered after VisibilityTimeout.
+func (p *Pool) release(ctx context.Context, msg *Message, delay time.Duration, reason string) {
+	fields := map[string]interface{}{
+		"topic":  msg.Topic,
+		"id":     msg.ID,
+		"reason": reason,
+	}
+	releaser, ok := p.source.(Releaser)
+	if !ok {
+		p.log.Debug("queue pool skipped message, it will be redelivered", fields)
+		return
+	}
+	if err := releaser.Release(ctx, msg, delay); err != nil {
+		fields["error"] = err.Error()
+		p.log.Warn("queue pool release failed", fields)
+		return
+	}
+	p.log.Debug("queue pool released message", fields)
+}
+
+// keepLease renews lease while the handler runs; the returned func stops
+// renewal and releases the lease.
+func (p *Pool) keepLease(ctx context.Context, msg *Message, lease Lease) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(p.opts.LeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := lease.Refresh(ctx, p.opts.LeaseTTL); err != nil {
+					p.log.Warn("queue pool key lease refresh failed", map[string]interface{}{
+						"topic": msg.Topic,
+						"id":    msg.ID,
+						"error": err.Error(),
+					})
+					if errors.Is(err, ErrLeaseLost) {
+						return
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		releaseCtx, cancel := context.WithTimeout(ctx, leaseReleaseTimeout)
+		defer cancel()
+		if err := lease.Release(releaseCtx); err != nil {
+			p.log.Warn("queue pool key lease release failed", map[string]interface{}{
+				"topic": msg.Topic,
+				"id":    msg.ID,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// key returns the ordering key of msg, empty without a KeyFunc.
+func (p *Pool) key(msg *Message) string {
+	if p.opts.Key == nil {
+		return ""
+	}
+	return p.opts.Key(msg.Payload)
+}
+
+// shard picks the worker for key: by key hash, or round-robin without a key.
+func (p *Pool) shard(key string) int {
+	n := uint64(p.opts.Workers)
+	if key != "" {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return int(h.Sum64() % n)
+	}
+	return int(p.next.Add(1) % n)
+}
+
+func (p *Pool) startStatsLogger(topic string) func() {
+	if p.opts.StatsInterval < 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	ticker := time.NewTicker(p.opts.StatsInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				stats := p.Stats()
+				p.log.Info("queue pool stats", map[string]interface{}{
+					"topic":     topic,
+					"workers":   stats.Workers,
+					"in_flight": stats.InFlight,
+					"processed": stats.Processed,
+					"failed":    stats.Failed,
+				})
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,367 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memorySource is an in-memory Reliable (and Releaser) for pool tests.
+type memorySource struct {
+	mu       sync.Mutex
+	queue    []*Message
+	acked    []*Message
+	failed   []*Message
+	released []*Message
+}
+
+func newMemorySource(payloads ...string) *memorySource {
+	s := &memorySource{}
+	for i, p := range payloads {
+		s.queue = append(s.queue, &Message{ID: fmt.Sprint(i), Topic: "t", Payload: []byte(p), Attempts: 1})
+	}
+	return s
+}
+
+func (s *memorySource) Fetch(ctx context.Context, topic string, count int) ([]*Message, error) {
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		s.mu.Unlock()
+		sleepCtx(ctx, 5*time.Millisecond)
+		return nil, nil
+	}
+	if count > len(s.queue) {
+		count = len(s.queue)
+	}
+	batch := s.queue[:count]
+	s.queue = s.queue[count:]
+	s.mu.Unlock()
+	return batch, nil
+}
+
+func (s *memorySource) Ack(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked = append(s.acked, msg)
+	return nil
+}
+
+func (s *memorySource) Fail(ctx context.Context, msg *Message, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, msg)
+	return nil
+}
+
+// Release puts msg back at the end of the queue.
+func (s *memorySource) Release(ctx context.Context, msg *Message, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = append(s.released, msg)
+	s.queue = append(s.queue, msg)
+	return nil
+}
+
+func (s *memorySource) releasedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.released)
+}
+
+func (s *memorySource) counts() (int, int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue), len(s.acked), len(s.failed)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
+
+func TestJSONFieldsKey(t *testing.T) {
+	key := JSONFieldsKey("shop_id", "external_id")
+	if got := key([]byte(`{"shop_id":"s1","external_id":"42","name":"x"}`)); got != "s1/42" {
+		t.Errorf("key = %q, want s1/42", got)
+	}
+	if got := key([]byte(`not json`)); got != "" {
+		t.Errorf("invalid payload should have empty key, got %q", got)
+	}
+	if got := key([]byte(`{"shop_id":"s1"}`)); got != "" {
+		t.Errorf("missing field should give empty key, got %q", got)
+	}
+	if got := key([]byte(`{"shop_id":"s1","external_id":null}`)); got != "" {
+		t.Errorf("null field should give empty key, got %q", got)
+	}
+}
+
+// memoryLocker is an in-process KeyLocker shared by pools that stand in for
+// separate consumer processes.
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memoryLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return nil, nil
+	}
+	l.held[key] = true
+	return &memoryLease{locker: l, key: key}, nil
+}
+
+type memoryLease struct {
+	locker *memoryLocker
+	key    string
+}
+
+func (l *memoryLease) Refresh(ctx context.Context, ttl time.Duration) error { return nil }
+
+func (l *memoryLease) Release(ctx context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	delete(l.locker.held, l.key)
+	return nil
+}
+
+func TestPool_RunsInParallel(t *testing.T) {
+	payloads := make([]string, 8)
+	for i := range payloads {
+		payloads[i] = fmt.Sprintf(`{"shop_id":"s%d","external_id":"1"}`, i)
+	}
+	source := newMemorySource(payloads...)
+	pool := NewPool(source, PoolOptions{Workers: 4, Key: JSONFieldsKey("shop_id", "external_id"), StatsInterval: -1}, nil)
+
+	var current, peak atomic.Int64
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, "t", func(ctx context.Context, payload []byte) error {
+			n := current.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			current.Add(-1)
+			return nil
+		})
+	}()
+
+	waitFor(t, func() bool { _, acked, _ := source.counts(); return acked == len(payloads) })
+	cancel()
+	<-done
+
+	if peak.Load() < 2 {
+		t.Errorf("expected parallel handling, peak concurrency = %d", peak.Load())
+	}
+	if stats := pool.Stats(); stats.Processed != int64(len(payloads)) || stats.InFlight != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestPool_SameKeyNeverConcurrent(t *testing.T) {
+	payloads := make([]string, 20)
+	for i := range payloads {
+		// two alternating keys
+		payloads[i] = fmt.Sprintf(`{"shop_id":"s%d","external_id":"e","seq":%d}`, i%2, i)
+	}
+	source := newMemorySource(payloads...)
+	pool := NewPool(source, PoolOptions{Workers: 8, FetchCount: 20, Key: JSONFieldsKey("shop_id", "external_id"), StatsInterval: -1}, nil)
+
+	var mu sync.Mutex
+	active := map[string]bool{}
+	order := map[string][]string{}
+	violation := atomic.Bool{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, "t", func(ctx context.Context, payload []byte) error {
+			key := JSONFieldsKey("shop_id", "external_id")(payload)
+			mu.Lock()
+			if active[key] {
+				violation.Store(true)
+			}
+			active[key] = true
+			order[key] = append(order[key], JSONFieldsKey("seq")(payload))
+			mu.Unlock()
+
+			time.Sleep(2 * time.Millisecond)
+
+			mu.Lock()
+			active[key] = false
+			mu.Unlock()
+			return nil
+		})
+	}()
+
+	waitFor(t, func() bool { _, acked, _ := source.counts(); return acked == len(payloads) })
+	cancel()
+	<-done
+
+	if violation.Load() {
+		t.Error("messages with the same key were processed concurrently")
+	}
+	for key, seqs := range order {
+		for i := 1; i < len(seqs); i++ {
+			var prev, cur int
+			fmt.Sscan(seqs[i-1], &prev)
+			fmt.Sscan(seqs[i], &cur)
+			if cur < prev {
+				t.Errorf("key %s processed out of order: %v", key, seqs)
+				break
+			}
+		}
+	}
+}
+
+func TestPool_DrainsOnShutdown(t *testing.T) {
+	source := newMemorySource(`{"a":1}`, `{"a":2}`, `{"a":3}`)
+	pool := NewPool(source, PoolOptions{Workers: 1, FetchCount: 3, StatsInterval: -1}, nil)
+
+	started := make(chan struct{})
+	var once sync.Once
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, "t", func(hctx context.Context, payload []byte) error {
+			once.Do(func() { close(started) })
+			time.Sleep(20 * time.Millisecond)
+			// the handler context is not cancelled by shutdown
+			return hctx.Err()
+		})
+	}()
+
+	<-started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+
+	queued, acked, failed := source.counts()
+	if queued != 0 || acked != 3 || failed != 0 {
+		t.Errorf("expected all fetched messages acked during drain, got queued=%d acked=%d failed=%d", queued, acked, failed)
+	}
+}
+
+func TestPool_FailedMessages(t *testing.T) {
+	source := newMemorySource(`{"ok":true}`, `{"ok":false}`, ``)
+	pool := NewPool(source, PoolOptions{Workers: 2, StatsInterval: -1}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, "t", func(ctx context.Context, payload []byte) error {
+			if string(payload) == `{"ok":false}` {
+				return errors.New("processing failed")
+			}
+			return nil
+		})
+	}()
+
+	waitFor(t, func() bool { _, acked, failed := source.counts(); return acked+failed == 3 })
+	cancel()
+	<-done
+
+	_, acked, failed := source.counts()
+	if acked != 1 || failed != 2 {
+		t.Errorf("acked=%d failed=%d, want 1 and 2", acked, failed)
+	}
+	if stats := pool.Stats(); stats.Failed != 2 {
+		t.Errorf("stats.Failed = %d, want 2", stats.Failed)
+	}
+}
+
+func TestPool_KeyLeaseAcrossPools(t *testing.T) {
+	// Two pools over one source stand in for two worker processes: every
+	// message has the same key, so the lease must keep handling sequential.
+	payloads := make([]string, 10)
+	for i := range payloads {
+		payloads[i] = fmt.Sprintf(`{"shop_id":"s1","external_id":"e","seq":%d}`, i)
+	}
+	source := newMemorySource(payloads...)
+	locker := &memoryLocker{held: map[string]bool{}}
+	opts := PoolOptions{
+		Workers:       2,
+		FetchCount:    1,
+		Key:           JSONFieldsKey("shop_id", "external_id"),
+		Locker:        locker,
+		BusyKeyDelay:  time.Millisecond,
+		StatsInterval: -1,
+	}
+
+	var active atomic.Int64
+	violation := atomic.Bool{}
+	handler := func(ctx context.Context, payload []byte) error {
+		if active.Add(1) > 1 {
+			violation.Store(true)
+		}
+		time.Sleep(5 * time.Millisecond)
+		active.Add(-1)
+		return nil
+	}
+
+	pools := []*Pool{NewPool(source, opts, nil), NewPool(source, opts, nil)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, len(pools))
+	for _, pool := range pools {
+		go func() { done <- pool.Run(ctx, "t", handler) }()
+	}
+
+	waitFor(t, func() bool { _, acked, _ := source.counts(); return acked == len(payloads) })
+	cancel()
+	<-done
+	<-done
+
+	if violation.Load() {
+		t.Error("messages with the same key were processed concurrently by two pools")
+	}
+	if _, _, failed := source.counts(); failed != 0 {
+		t.Errorf("busy key must be released, not failed: failed=%d", failed)
+	}
+}
+
+func TestPool_ReleasesWhenWorkerBusy(t *testing.T) {
+	// One slow key must not block dispatch of the rest of the batch.
+	source := newMemorySource(`{"k":"slow"}`, `{"k":"slow"}`, `{"k":"slow"}`, `{"k":"slow"}`)
+	pool := NewPool(source, PoolOptions{
+		Workers:         1,
+		FetchCount:      4,
+		Key:             JSONFieldsKey("k"),
+		DispatchTimeout: 5 * time.Millisecond,
+		StatsInterval:   -1,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, "t", func(ctx context.Context, payload []byte) error {
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		})
+	}()
+
+	waitFor(t, func() bool { _, acked, _ := source.counts(); return acked == 4 })
+	cancel()
+	<-done
+
+	if source.releasedCount() == 0 {
+		t.Error("expected messages waiting for a busy worker to be released")
+	}
+}
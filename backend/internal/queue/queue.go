@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/solomonczyk/izborator/internal/config"
@@ -31,7 +32,32 @@ func New(cfg *config.QueueConfig, redisClient *redis.Client, log *logger.Logger)
 			return nil, fmt.Errorf("redis client is required for queue type redis")
 		}
 		return NewRedisQueue(redisClient, log), nil
+	case "redis_streams", "redis-streams":
+		if redisClient == nil {
+			return nil, fmt.Errorf("redis client is required for queue type %s", queueType)
+		}
+		return NewRedisStreamQueue(redisClient, StreamOptions{
+			Group:             cfg.GroupID,
+			MaxAttempts:       cfg.MaxAttempts,
+			VisibilityTimeout: cfg.VisibilityTimeout,
+			DeadLetterTopic:   cfg.DeadLetterTopic,
+		}, log), nil
 	default:
 		return nil, fmt.Errorf("unsupported queue type: %s", queueType)
 	}
 }
+
+// Reliable is implemented by queues that expose explicit fetch/ack semantics.
+// It lets consumers (e.g. Pool) process messages concurrently and acknowledge
+// them individually once handled.
+type Reliable interface {
+	Fetch(ctx context.Context, topic string, count int) ([]*Message, error)
+	Ack(ctx context.Context, msg *Message) error
+	Fail(ctx context.Context, msg *Message, handlerErr error) error
+}
+
+// Releaser is implemented by reliable queues that can hand a fetched message
+// back for redelivery after delay without counting it as a delivery attempt.
+type Releaser interface {
+	Release(ctx context.Context, msg *Message, delay time.Duration) error
+}
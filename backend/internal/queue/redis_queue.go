@@ -94,6 +94,31 @@ func (q *RedisQueue) Consume(ctx context.Context, topic string, handler func([]b
 	}
 }
 
+// Fetch pops at most one message. Messages of the list queue are removed on
+// delivery, so Ack and Fail cannot redeliver them.
+func (q *RedisQueue) Fetch(ctx context.Context, topic string, count int) ([]*Message, error) {
+	payload, err := q.pop(ctx, topic, defaultBlockTimeout)
+	if err != nil || len(payload) == 0 {
+		return nil, err
+	}
+	return []*Message{{Topic: topic, Payload: payload, Attempts: 1}}, nil
+}
+
+// Ack is a no-op for the list queue.
+func (q *RedisQueue) Ack(ctx context.Context, msg *Message) error {
+	return nil
+}
+
+// Fail logs the failure; the message is already removed from the list.
+func (q *RedisQueue) Fail(ctx context.Context, msg *Message, handlerErr error) error {
+	fields := map[string]interface{}{"topic": msg.Topic}
+	if handlerErr != nil {
+		fields["error"] = handlerErr.Error()
+	}
+	q.log.Error("queue handler failed", fields)
+	return nil
+}
+
 func (q *RedisQueue) pop(ctx context.Context, topic string, timeout time.Duration) ([]byte, error) {
 	if timeout <= 0 {
 		timeout = defaultBlockTimeout
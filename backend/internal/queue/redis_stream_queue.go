@@ -0,0 +1,375 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+const (
+	defaultStreamGroup       = "izborator_workers"
+	defaultMaxAttempts       = 5
+	defaultVisibilityTimeout = 5 * time.Minute
+	defaultFetchCount        = 10
+	deadLetterSuffix         = ".dead"
+	streamPayloadField       = "data"
+)
+
+// StreamOptions configures the reliable Redis Streams queue.
+type StreamOptions struct {
+	Group             string        // consumer group shared by all workers
+	Consumer          string        // unique consumer name of this process
+	MaxAttempts       int           // deliveries before a message is dead-lettered
+	VisibilityTimeout time.Duration // unacked messages older than this are redelivered
+	DeadLetterTopic   string        // defaults to "<topic>.dead"
+}
+
+// RedisStreamQueue implements an at-least-once queue on Redis Streams with
+// consumer groups. Messages are acked only after the handler succeeds; messages
+// left pending (handler error, crashed worker) are reclaimed after
+// VisibilityTimeout, and moved to the dead-letter topic after MaxAttempts.
+type RedisStreamQueue struct {
+	client    *redis.Client
+	log       *logger.Logger
+	keyPrefix string
+	opts      StreamOptions
+
+	groupsMu sync.Mutex
+	groups   map[string]bool
+}
+
+// NewRedisStreamQueue returns a Redis Streams-backed reliable queue client.
+func NewRedisStreamQueue(client *redis.Client, opts StreamOptions, log *logger.Logger) *RedisStreamQueue {
+	if log == nil {
+		log = logger.New("info")
+	}
+	if opts.Group == "" {
+		opts.Group = defaultStreamGroup
+	}
+	if opts.Consumer == "" {
+		opts.Consumer = defaultConsumerName()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	return &RedisStreamQueue{
+		client:    client,
+		log:       log,
+		keyPrefix: "stream:",
+		opts:      opts,
+		groups:    make(map[string]bool),
+	}
+}
+
+// Publish appends a message to the topic stream.
+func (q *RedisStreamQueue) Publish(topic string, data interface{}) error {
+	if topic == "" {
+		return errors.New("topic is required")
+	}
+	if q.client == nil {
+		return errors.New("redis client is nil")
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	return q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.key(topic),
+		Values: map[string]interface{}{streamPayloadField: payload},
+	}).Err()
+}
+
+// Consume reads messages in a loop, acking each one after handler succeeds.
+// Failed messages stay pending and are redelivered after VisibilityTimeout.
+func (q *RedisStreamQueue) Consume(ctx context.Context, topic string, handler func([]byte) error) error {
+	if topic == "" {
+		return errors.New("topic is required")
+	}
+	if handler == nil {
+		return errors.New("handler is required")
+	}
+	if q.client == nil {
+		return errors.New("redis client is nil")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		messages, err := q.Fetch(ctx, topic, defaultFetchCount)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			q.log.Warn("queue fetch failed", map[string]interface{}{
+				"topic": topic,
+				"error": err.Error(),
+			})
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, msg := range messages {
+			if err := callHandler(handler, msg.Payload); err != nil {
+				if failErr := q.Fail(ctx, msg, err); failErr != nil {
+					q.log.Error("queue fail handling failed", map[string]interface{}{
+						"topic": topic,
+						"id":    msg.ID,
+						"error": failErr.Error(),
+					})
+				}
+				continue
+			}
+			if err := q.Ack(ctx, msg); err != nil {
+				q.log.Error("queue ack failed", map[string]interface{}{
+					"topic": topic,
+					"id":    msg.ID,
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// Fetch returns up to count messages for this consumer: stale pending messages
+// reclaimed from other consumers first, then new ones (blocking briefly).
+func (q *RedisStreamQueue) Fetch(ctx context.Context, topic string, count int) ([]*Message, error) {
+	if count <= 0 {
+		count = defaultFetchCount
+	}
+	if err := q.ensureGroup(ctx, topic); err != nil {
+		return nil, err
+	}
+
+	messages, err := q.reclaim(ctx, topic, count)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > 0 {
+		return messages, nil
+	}
+
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		Streams:  []string{q.key(topic), ">"},
+		Count:    int64(count),
+		Block:    defaultBlockTimeout,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, stream := range streams {
+		for _, xmsg := range stream.Messages {
+			messages = append(messages, q.toMessage(topic, xmsg, 1))
+		}
+	}
+	return messages, nil
+}
+
+// Ack acknowledges and removes a successfully handled message.
+func (q *RedisStreamQueue) Ack(ctx context.Context, msg *Message) error {
+	key := q.key(msg.Topic)
+	if err := q.client.XAck(ctx, key, q.opts.Group, msg.ID).Err(); err != nil {
+		return err
+	}
+	return q.client.XDel(ctx, key, msg.ID).Err()
+}
+
+// Fail records a handler failure. The message is moved to the dead-letter
+// topic if it is poison or out of attempts; otherwise it stays pending and is
+// redelivered after VisibilityTimeout.
+func (q *RedisStreamQueue) Fail(ctx context.Context, msg *Message, handlerErr error) error {
+	fields := map[string]interface{}{
+		"topic":        msg.Topic,
+		"id":           msg.ID,
+		"attempts":     msg.Attempts,
+		"max_attempts": q.opts.MaxAttempts,
+	}
+	if handlerErr != nil {
+		fields["error"] = handlerErr.Error()
+	}
+
+	if !shouldDeadLetter(msg.Attempts, q.opts.MaxAttempts, handlerErr) {
+		q.log.Warn("queue handler failed, message will be redelivered", fields)
+		return nil
+	}
+
+	q.log.Error("queue message moved to dead-letter topic", fields)
+	return q.deadLetter(ctx, msg, handlerErr)
+}
+
+// Release hands msg back without counting the delivery: its idle time is set
+// so that it is reclaimed after delay, and its delivery counter is rolled back.
+func (q *RedisStreamQueue) Release(ctx context.Context, msg *Message, delay time.Duration) error {
+	idle := q.opts.VisibilityTimeout - delay
+	if idle < 0 {
+		idle = 0
+	}
+	retries := msg.Attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+	err := q.client.Do(ctx, "XCLAIM", q.key(msg.Topic), q.opts.Group, q.opts.Consumer, 0, msg.ID,
+		"IDLE", idle.Milliseconds(), "RETRYCOUNT", retries, "JUSTID").Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to release message: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterTopic returns the dead-letter topic for topic.
+func (q *RedisStreamQueue) DeadLetterTopic(topic string) string {
+	if q.opts.DeadLetterTopic != "" {
+		return q.opts.DeadLetterTopic
+	}
+	return topic + deadLetterSuffix
+}
+
+func (q *RedisStreamQueue) deadLetter(ctx context.Context, msg *Message, handlerErr error) error {
+	reason := ""
+	if handlerErr != nil {
+		reason = handlerErr.Error()
+	}
+
+	err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.key(q.DeadLetterTopic(msg.Topic)),
+		Values: map[string]interface{}{
+			streamPayloadField: msg.Payload,
+			"source_topic":     msg.Topic,
+			"source_id":        msg.ID,
+			"attempts":         msg.Attempts,
+			"error":            reason,
+			"failed_at":        time.Now().UTC().Format(time.RFC3339),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish to dead-letter topic: %w", err)
+	}
+
+	return q.Ack(ctx, msg)
+}
+
+// reclaim takes over messages pending longer than VisibilityTimeout.
+func (q *RedisStreamQueue) reclaim(ctx context.Context, topic string, count int) ([]*Message, error) {
+	key := q.key(topic)
+	claimed, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   key,
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		MinIdle:  q.opts.VisibilityTimeout,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to reclaim pending messages: %w", err)
+	}
+
+	messages := make([]*Message, 0, len(claimed))
+	for _, xmsg := range claimed {
+		attempts := 1
+		pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: key,
+			Group:  q.opts.Group,
+			Start:  xmsg.ID,
+			End:    xmsg.ID,
+			Count:  1,
+		}).Result()
+		if err == nil && len(pending) == 1 {
+			attempts = int(pending[0].RetryCount)
+		}
+		messages = append(messages, q.toMessage(topic, xmsg, attempts))
+	}
+
+	if len(messages) > 0 {
+		q.log.Info("queue reclaimed pending messages", map[string]interface{}{
+			"topic": topic,
+			"count": len(messages),
+		})
+	}
+	return messages, nil
+}
+
+func (q *RedisStreamQueue) ensureGroup(ctx context.Context, topic string) error {
+	q.groupsMu.Lock()
+	defer q.groupsMu.Unlock()
+
+	if q.groups[topic] {
+		return nil
+	}
+	// "0" so that messages published before the group existed are consumed too
+	err := q.client.XGroupCreateMkStream(ctx, q.key(topic), q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	q.groups[topic] = true
+	return nil
+}
+
+func (q *RedisStreamQueue) toMessage(topic string, xmsg redis.XMessage, attempts int) *Message {
+	msg := &Message{
+		ID:       xmsg.ID,
+		Topic:    topic,
+		Attempts: attempts,
+	}
+	switch v := xmsg.Values[streamPayloadField].(type) {
+	case string:
+		msg.Payload = []byte(v)
+	case []byte:
+		msg.Payload = v
+	}
+	return msg
+}
+
+func (q *RedisStreamQueue) key(topic string) string {
+	return q.keyPrefix + topic
+}
+
+// callHandler runs handler. An empty payload is a permanent error; a panic becomes a
+// retryable error, so the message is redelivered and dead-lettered after MaxAttempts.
+func callHandler(handler func([]byte) error, payload []byte) (err error) {
+	if len(payload) == 0 {
+		return Permanent(errors.New("empty payload"))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(payload)
+}
+
+func defaultConsumerName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
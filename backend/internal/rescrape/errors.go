@@ -0,0 +1,11 @@
+package rescrape
+
+import "errors"
+
+var (
+	// ErrShopNotFound магазин адреса не найден
+	ErrShopNotFound = errors.New("shop not found")
+
+	// ErrNoQueue очередь не настроена: задания нужно выполнять в том же процессе (Claim + Complete)
+	ErrNoQueue = errors.New("rescrape queue is not configured")
+)
@@ -0,0 +1,129 @@
+package rescrape
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxErrorLength столько символов ошибки парсинга сохраняется в расписании
+const maxErrorLength = 500
+
+// Seed добавляет в расписание новые адреса товаров
+func (s *Service) Seed(ctx context.Context) (int, error) {
+	added, err := s.storage.SeedURLs(ctx, s.policy.BaseInterval)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seed rescrape schedule: %w", err)
+	}
+	if added > 0 {
+		s.logger.Info("Rescrape schedule seeded", map[string]interface{}{"added": added})
+	}
+	return added, nil
+}
+
+// Schedule ставит найденные в каталоге адреса магазина в расписание на ближайшую раздачу.
+// Парсятся они воркерами расписания в пределах суточного бюджета магазина
+func (s *Service) Schedule(ctx context.Context, shopID string, urls []string) (int, error) {
+	if len(urls) == 0 {
+		return 0, nil
+	}
+	scheduled, err := s.storage.ScheduleURLs(ctx, shopID, urls)
+	if err != nil {
+		return 0, fmt.Errorf("failed to schedule urls: %w", err)
+	}
+	return scheduled, nil
+}
+
+// Claim забирает до limit созревших адресов для выполнения в текущем процессе
+func (s *Service) Claim(ctx context.Context, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	jobs, err := s.storage.ClaimDue(ctx, limit, s.policy.Lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due urls: %w", err)
+	}
+	return jobs, nil
+}
+
+// Dispatch забирает до limit созревших адресов и публикует задания в очередь, откуда их разбирают воркеры.
+// Неопубликованное задание вернётся в раздачу после Policy.Lease
+func (s *Service) Dispatch(ctx context.Context, limit int) (int, error) {
+	if !s.Queued() {
+		return 0, ErrNoQueue
+	}
+	jobs, err := s.Claim(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, job := range jobs {
+		if err := s.publisher.Publish(s.topic, job); err != nil {
+			s.logger.Warn("Failed to publish rescrape job", map[string]interface{}{
+				"shop_id": job.ShopID,
+				"url":     job.URL,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		published++
+	}
+
+	if len(jobs) > 0 {
+		s.logger.Info("Rescrape jobs dispatched", map[string]interface{}{
+			"claimed":   len(jobs),
+			"published": published,
+			"topic":     s.topic,
+		})
+	}
+	return published, nil
+}
+
+// Pending сообщает, ждёт ли задание выполнения. Адрес, не выполненный за Policy.Lease,
+// раздаётся снова с новой отметкой DispatchedAt: выполняется только задание последней раздачи,
+// а устаревшие экземпляры и дубли из очереди страницу не запрашивают
+func (s *Service) Pending(ctx context.Context, job *Job) (bool, error) {
+	pending, err := s.storage.IsDispatched(ctx, job)
+	if err != nil {
+		return false, fmt.Errorf("failed to check rescrape job: %w", err)
+	}
+	return pending, nil
+}
+
+// Complete сохраняет результат парсинга (scrapeErr == nil - успех) и планирует следующее посещение
+func (s *Service) Complete(ctx context.Context, job *Job, scrapeErr error) (*Plan, error) {
+	signals, err := s.storage.GetSignals(ctx, job.ShopID, job.URL, s.policy.VolatilityWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rescrape signals: %w", err)
+	}
+	shopURLs, err := s.shopURLs.Get(func() (map[string]int, error) {
+		return s.storage.CountShopURLs(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count shop urls: %w", err)
+	}
+	signals.ShopURLs = shopURLs[job.ShopID]
+
+	failures, lastError := 0, ""
+	if scrapeErr != nil {
+		failures = signals.Failures + 1
+		lastError = truncate(scrapeErr.Error(), maxErrorLength)
+	}
+	signals.Failures = failures
+
+	plan := s.policy.Plan(*signals, time.Now())
+	if err := s.storage.Reschedule(ctx, job, plan, failures, lastError); err != nil {
+		return nil, fmt.Errorf("failed to reschedule url: %w", err)
+	}
+	return &plan, nil
+}
+
+// truncate обрезает строку до limit символов
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
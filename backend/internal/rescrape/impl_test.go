@@ -0,0 +1,256 @@
+package rescrape
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// mockStorage хранит расписание в памяти
+type mockStorage struct {
+	due         []*Job
+	signals     Signals
+	claimLimit  int
+	claimLease  time.Duration
+	rescheduled map[string]Plan
+	failures    map[string]int
+	lastErrors  map[string]string
+	scheduled   map[string][]string
+	dispatched  map[string]time.Time // отметка текущей раздачи адреса
+	shopURLs    map[string]int
+	urlCounts   int // вызовов CountShopURLs
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		rescheduled: make(map[string]Plan),
+		failures:    make(map[string]int),
+		lastErrors:  make(map[string]string),
+		scheduled:   make(map[string][]string),
+		dispatched:  make(map[string]time.Time),
+	}
+}
+
+func (m *mockStorage) SeedURLs(ctx context.Context, firstVisit time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (m *mockStorage) ScheduleURLs(ctx context.Context, shopID string, urls []string) (int, error) {
+	m.scheduled[shopID] = append(m.scheduled[shopID], urls...)
+	return len(urls), nil
+}
+
+func (m *mockStorage) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Job, error) {
+	m.claimLimit, m.claimLease = limit, lease
+	jobs := m.due
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	claimed := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		// Каждая раздача получает новую отметку, как dispatched_at = NOW() в БД
+		at := time.Now()
+		if prev, ok := m.dispatched[job.URL]; ok && !at.After(prev) {
+			at = prev.Add(time.Microsecond)
+		}
+		m.dispatched[job.URL] = at
+		copied := *job
+		copied.DispatchedAt = at
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (m *mockStorage) IsDispatched(ctx context.Context, job *Job) (bool, error) {
+	at, ok := m.dispatched[job.URL]
+	return ok && at.Equal(job.DispatchedAt), nil
+}
+
+func (m *mockStorage) CountShopURLs(ctx context.Context) (map[string]int, error) {
+	m.urlCounts++
+	return m.shopURLs, nil
+}
+
+func (m *mockStorage) GetSignals(ctx context.Context, shopID, url string, volatilityWindow time.Duration) (*Signals, error) {
+	signals := m.signals
+	signals.Failures = m.failures[url]
+	return &signals, nil
+}
+
+func (m *mockStorage) Reschedule(ctx context.Context, job *Job, plan Plan, failures int, lastError string) error {
+	if at, ok := m.dispatched[job.URL]; ok && !at.Equal(job.DispatchedAt) {
+		return nil
+	}
+	m.rescheduled[job.URL] = plan
+	m.failures[job.URL] = failures
+	m.lastErrors[job.URL] = lastError
+	delete(m.dispatched, job.URL)
+	return nil
+}
+
+// mockPublisher запоминает опубликованные задания
+type mockPublisher struct {
+	topics []string
+	jobs   []*Job
+	fail   string // URL, публикация которого падает
+}
+
+func (m *mockPublisher) Publish(topic string, data interface{}) error {
+	job := data.(*Job)
+	if job.URL == m.fail {
+		return errors.New("queue unavailable")
+	}
+	m.topics = append(m.topics, topic)
+	m.jobs = append(m.jobs, job)
+	return nil
+}
+
+func TestService_Dispatch(t *testing.T) {
+	storage := newMockStorage()
+	storage.due = []*Job{
+		{ShopID: "shop-1", URL: "https://shop.example/a"},
+		{ShopID: "shop-1", URL: "https://shop.example/b"},
+		{ShopID: "shop-2", URL: "https://other.example/c"},
+	}
+	publisher := &mockPublisher{fail: "https://shop.example/b"}
+	service := New(storage, publisher, "rescrape_jobs", Policy{Lease: 15 * time.Minute}, logger.New("error"))
+
+	published, err := service.Dispatch(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if published != 2 || len(publisher.jobs) != 2 || publisher.topics[0] != "rescrape_jobs" {
+		t.Fatalf("expected 2 published jobs, got %d (%+v)", published, publisher.jobs)
+	}
+	if storage.claimLimit != 10 || storage.claimLease != 15*time.Minute {
+		t.Errorf("unexpected claim params limit=%d lease=%v", storage.claimLimit, storage.claimLease)
+	}
+
+	inline := New(storage, nil, "rescrape_jobs", Policy{}, logger.New("error"))
+	if _, err := inline.Dispatch(context.Background(), 10); !errors.Is(err, ErrNoQueue) {
+		t.Errorf("expected ErrNoQueue without publisher, got %v", err)
+	}
+}
+
+func TestService_Complete(t *testing.T) {
+	storage := newMockStorage()
+	storage.signals = Signals{InStock: true, Offers: 1, ShopBudget: 2000}
+	storage.shopURLs = map[string]int{"shop-1": 10}
+	service := New(storage, nil, "", Policy{}, logger.New("error"))
+	job := &Job{ShopID: "shop-1", URL: "https://shop.example/a"}
+
+	plan, err := service.Complete(context.Background(), job, nil)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if plan.Interval != defaultBaseInterval || storage.failures[job.URL] != 0 {
+		t.Errorf("unexpected plan %+v failures=%d", plan, storage.failures[job.URL])
+	}
+
+	for i := 1; i <= 2; i++ {
+		plan, err = service.Complete(context.Background(), job, errors.New(strings.Repeat("x", 600)))
+		if err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if storage.failures[job.URL] != 2 || plan.Interval != 4*defaultBaseInterval {
+		t.Errorf("expected backoff after 2 failures, got failures=%d interval=%v", storage.failures[job.URL], plan.Interval)
+	}
+	if len(storage.lastErrors[job.URL]) != maxErrorLength {
+		t.Errorf("error must be truncated to %d chars, got %d", maxErrorLength, len(storage.lastErrors[job.URL]))
+	}
+
+	if _, err := service.Complete(context.Background(), job, nil); err != nil || storage.failures[job.URL] != 0 {
+		t.Errorf("success must reset failures, got %d (%v)", storage.failures[job.URL], err)
+	}
+
+	// Число адресов магазина считается один раз за shopURLsTTL, а не на каждое задание
+	if storage.urlCounts != 1 {
+		t.Errorf("shop url counts must be cached, got %d loads", storage.urlCounts)
+	}
+}
+
+func TestService_CompleteUsesShopURLCount(t *testing.T) {
+	storage := newMockStorage()
+	storage.signals = Signals{InStock: true, Offers: 1, ShopBudget: 100}
+	storage.shopURLs = map[string]int{"shop-1": 400}
+	service := New(storage, nil, "", Policy{}, logger.New("error"))
+
+	plan, err := service.Complete(context.Background(), &Job{ShopID: "shop-1", URL: "https://shop.example/a"}, nil)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	// 400 адресов при бюджете 100 в сутки: каждый посещается не чаще раза в 4 дня
+	if plan.Interval < 4*24*time.Hour {
+		t.Errorf("expected interval stretched by shop budget, got %v", plan.Interval)
+	}
+}
+
+func TestService_Schedule(t *testing.T) {
+	storage := newMockStorage()
+	service := New(storage, nil, "", Policy{}, logger.New("error"))
+
+	scheduled, err := service.Schedule(context.Background(), "shop-1", []string{"https://shop.example/a", "https://shop.example/b"})
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if scheduled != 2 || len(storage.scheduled["shop-1"]) != 2 {
+		t.Errorf("expected 2 scheduled urls, got %d (%v)", scheduled, storage.scheduled)
+	}
+
+	if scheduled, err := service.Schedule(context.Background(), "shop-2", nil); err != nil || scheduled != 0 || storage.scheduled["shop-2"] != nil {
+		t.Errorf("empty list must not reach storage, got %d (%v)", scheduled, err)
+	}
+}
+
+func TestService_PendingAfterComplete(t *testing.T) {
+	storage := newMockStorage()
+	storage.due = []*Job{{ShopID: "shop-1", URL: "https://shop.example/a"}}
+	service := New(storage, nil, "", Policy{}, logger.New("error"))
+
+	jobs, err := service.Claim(context.Background(), 10)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("Claim failed: %v (%d jobs)", err, len(jobs))
+	}
+	if pending, err := service.Pending(context.Background(), jobs[0]); err != nil || !pending {
+		t.Fatalf("claimed job must be pending, got %v (%v)", pending, err)
+	}
+
+	if _, err := service.Complete(context.Background(), jobs[0], nil); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	// Повторно отправленный экземпляр того же задания после выполнения не нужен
+	if pending, err := service.Pending(context.Background(), jobs[0]); err != nil || pending {
+		t.Errorf("completed job must not be pending, got %v (%v)", pending, err)
+	}
+}
+
+func TestService_PendingOnlyForCurrentDispatch(t *testing.T) {
+	storage := newMockStorage()
+	storage.due = []*Job{{ShopID: "shop-1", URL: "https://shop.example/a"}}
+	service := New(storage, nil, "", Policy{}, logger.New("error"))
+	ctx := context.Background()
+
+	first, _ := service.Claim(ctx, 10)
+	// Lease истёк, адрес раздан снова, а первое задание ещё в очереди
+	second, _ := service.Claim(ctx, 10)
+
+	if pending, _ := service.Pending(ctx, first[0]); pending {
+		t.Error("job from an expired dispatch must not run")
+	}
+	if pending, _ := service.Pending(ctx, second[0]); !pending {
+		t.Fatal("job from the current dispatch must run")
+	}
+
+	// Запоздавший результат прежней раздачи не снимает текущую
+	if _, err := service.Complete(ctx, first[0], nil); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if pending, _ := service.Pending(ctx, second[0]); !pending {
+		t.Error("stale completion must not clear the current dispatch")
+	}
+}
@@ -0,0 +1,39 @@
+package rescrape
+
+import "time"
+
+// Job задание на повторный парсинг страницы товара, публикуемое в очередь
+type Job struct {
+	ShopID       string    `json:"shop_id"`
+	URL          string    `json:"url"`
+	Priority     float64   `json:"priority"`
+	DispatchedAt time.Time `json:"dispatched_at"` // время БД при раздаче: отличает текущую раздачу адреса от прошлых
+}
+
+// Policy параметры расписания повторного парсинга
+type Policy struct {
+	BaseInterval time.Duration // интервал для обычного товара без изменений цены и подписок
+	MinInterval  time.Duration // чаще не посещаем даже самые волатильные товары
+	MaxInterval  time.Duration // реже не посещаем даже мёртвые и падающие страницы
+	Lease        time.Duration // через сколько отправленное, но не выполненное задание отправляется снова
+	// VolatilityWindow за какой период считаются изменения цены
+	VolatilityWindow time.Duration
+}
+
+// Signals признаки адреса, от которых зависит частота его посещения
+type Signals struct {
+	PriceChanges int  // изменений цены или наличия в магазине за VolatilityWindow
+	Alerts       int  // активных подписок на снижение цены товара
+	Offers       int  // магазинов, продающих товар
+	InStock      bool // товар в наличии у магазина
+	Failures     int  // неудачных парсингов подряд до текущего
+	ShopBudget   int  // страниц магазина в сутки (0 - магазин не парсится)
+	ShopURLs     int  // адресов магазина в расписании
+}
+
+// Plan рассчитанное время следующего посещения адреса
+type Plan struct {
+	Interval    time.Duration `json:"interval"`
+	Priority    float64       `json:"priority"` // порядок отправки при нехватке бюджета магазина
+	NextVisitAt time.Time     `json:"next_visit_at"`
+}
@@ -0,0 +1,87 @@
+// Package rescrape планирует повторный парсинг страниц товаров: время следующего посещения
+// каждого адреса зависит от волатильности цены, популярности товара, наличия и суточного бюджета магазина
+package rescrape
+
+import (
+	"context"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/cache"
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// shopURLsTTL как долго используется подсчёт адресов магазинов: он меняется медленно,
+// а считать его на каждое выполненное задание дорого
+const shopURLsTTL = 10 * time.Minute
+
+// Storage интерфейс хранилища расписания
+type Storage interface {
+	// SeedURLs добавляет в расписание адреса цен, которых там ещё нет; первое посещение - через firstVisit
+	// после последнего обновления цены. Возвращает число добавленных адресов
+	SeedURLs(ctx context.Context, firstVisit time.Duration) (int, error)
+
+	// ScheduleURLs ставит адреса магазина на ближайшую раздачу: новые добавляются, известные
+	// переносятся на сейчас. Возвращает число добавленных или перенесённых адресов
+	ScheduleURLs(ctx context.Context, shopID string, urls []string) (int, error)
+
+	// ClaimDue забирает до limit созревших адресов активных магазинов в пределах их суточного бюджета
+	// и откладывает их на lease. Отправленные и не выполненные адреса раздаются снова только через lease,
+	// без повторного расхода бюджета. Одновременно адреса раздаёт только один процесс
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Job, error)
+
+	// IsDispatched проверяет, что адрес отправлен на парсинг именно этим заданием
+	// (отметка об отправке совпадает с Job.DispatchedAt) и Reschedule ещё не вызывался
+	IsDispatched(ctx context.Context, job *Job) (bool, error)
+
+	// GetSignals собирает признаки адреса, кроме ShopURLs; volatilityWindow - период подсчёта изменений цены
+	GetSignals(ctx context.Context, shopID, url string, volatilityWindow time.Duration) (*Signals, error)
+
+	// CountShopURLs возвращает число адресов в расписании по магазинам
+	CountShopURLs(ctx context.Context) (map[string]int, error)
+
+	// Reschedule сохраняет время следующего посещения и результат текущего и снимает отметку об отправке.
+	// Если адрес уже отправлен заново другим заданием, расписание не меняется
+	Reschedule(ctx context.Context, job *Job, plan Plan, failures int, lastError string) error
+}
+
+// Publisher публикует задания в очередь (queue.Client)
+type Publisher interface {
+	Publish(topic string, data interface{}) error
+}
+
+// Service планировщик повторного парсинга
+type Service struct {
+	storage   Storage
+	publisher Publisher
+	topic     string
+	policy    Policy
+	logger    *logger.Logger
+
+	shopURLs *cache.TTL[map[string]int]
+}
+
+// New создаёт планировщик; publisher может быть nil - тогда задания выполняет сам процесс (Claim + Complete).
+// Нулевые параметры policy заменяются значениями по умолчанию
+func New(storage Storage, publisher Publisher, topic string, policy Policy, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage:   storage,
+		publisher: publisher,
+		topic:     topic,
+		policy:    policy.withDefaults(),
+		logger:    log,
+		shopURLs:  cache.NewTTL[map[string]int](shopURLsTTL),
+	}
+}
+
+// Queued сообщает, раздаются ли задания через очередь
+func (s *Service) Queued() bool {
+	return s.publisher != nil && s.topic != ""
+}
+
+// Topic топик очереди заданий
+func (s *Service) Topic() string {
+	return s.topic
+}
@@ -0,0 +1,98 @@
+package rescrape
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultBaseInterval     = 6 * time.Hour
+	defaultMinInterval      = 30 * time.Minute
+	defaultMaxInterval      = 7 * 24 * time.Hour
+	defaultLease            = time.Hour
+	defaultVolatilityWindow = 30 * 24 * time.Hour
+
+	// maxFailureBackoff после стольких неудач подряд интервал больше не удваивается
+	maxFailureBackoff = 8
+)
+
+// withDefaults заменяет нулевые параметры значениями по умолчанию
+func (p Policy) withDefaults() Policy {
+	if p.BaseInterval <= 0 {
+		p.BaseInterval = defaultBaseInterval
+	}
+	if p.MinInterval <= 0 {
+		p.MinInterval = defaultMinInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = defaultMaxInterval
+	}
+	if p.MinInterval > p.BaseInterval {
+		p.MinInterval = p.BaseInterval
+	}
+	if p.MaxInterval < p.BaseInterval {
+		p.MaxInterval = p.BaseInterval
+	}
+	if p.Lease <= 0 {
+		p.Lease = defaultLease
+	}
+	if p.VolatilityWindow <= 0 {
+		p.VolatilityWindow = defaultVolatilityWindow
+	}
+	return p
+}
+
+// Priority во сколько раз адрес важнее обычного: волатильная цена, подписки,
+// конкуренция между магазинами ускоряют посещение, отсутствие в наличии - замедляет
+func (p Policy) Priority(sig Signals) float64 {
+	priority := 1.0
+	// 5 изменений цены за окно - вдвое чаще, не больше чем в 7 раз
+	priority *= 1 + math.Min(float64(sig.PriceChanges), 30)/5
+	// 1 подписка - в 1.5 раза чаще, 3 - вдвое, дальше медленнее
+	priority *= 1 + math.Log2(1+float64(sig.Alerts))/2
+	// Товар, который продают несколько магазинов, сравнивают чаще
+	if sig.Offers > 1 {
+		priority *= 1 + math.Min(float64(sig.Offers-1), 10)/10
+	}
+	if !sig.InStock {
+		priority /= 2
+	}
+	return priority
+}
+
+// Plan рассчитывает следующее посещение адреса после визита в now
+func (p Policy) Plan(sig Signals, now time.Time) Plan {
+	priority := p.Priority(sig)
+	interval := time.Duration(float64(p.BaseInterval) / priority)
+
+	// Бюджет магазина: адресов больше, чем страниц в сутки, - интервалы растягиваются,
+	// сохраняя соотношение между важными и обычными адресами
+	switch {
+	case sig.ShopBudget <= 0:
+		interval = p.MaxInterval
+	case sig.ShopURLs > sig.ShopBudget:
+		average := time.Duration(float64(24*time.Hour) * float64(sig.ShopURLs) / float64(sig.ShopBudget))
+		if stretched := time.Duration(float64(average) / priority); stretched > interval {
+			interval = stretched
+		}
+	}
+
+	if interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+
+	// Неудачи подряд: экспоненциальная пауза, чтобы не тратить бюджет на удалённые страницы
+	if sig.Failures > 0 {
+		interval <<= min(sig.Failures, maxFailureBackoff)
+	}
+
+	if interval > p.MaxInterval || interval <= 0 {
+		interval = p.MaxInterval
+	}
+
+	return Plan{
+		Interval:    interval,
+		Priority:    priority,
+		NextVisitAt: now.Add(interval),
+	}
+}
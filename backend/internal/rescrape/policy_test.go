@@ -0,0 +1,58 @@
+package rescrape
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Plan(t *testing.T) {
+	policy := Policy{}.withDefaults()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	plain := Signals{InStock: true, Offers: 1, ShopBudget: 2000, ShopURLs: 100}
+
+	tests := []struct {
+		name    string
+		signals func(Signals) Signals
+		want    time.Duration
+	}{
+		{"plain product", func(s Signals) Signals { return s }, 6 * time.Hour},
+		{"volatile price", func(s Signals) Signals { s.PriceChanges = 5; return s }, 3 * time.Hour},
+		{"popular with alerts", func(s Signals) Signals { s.Alerts = 3; return s }, 3 * time.Hour},
+		{"out of stock", func(s Signals) Signals { s.InStock = false; return s }, 12 * time.Hour},
+		{"capped by min interval", func(s Signals) Signals { s.PriceChanges = 30; s.Alerts = 15; return s }, 30 * time.Minute},
+		{"failures back off", func(s Signals) Signals { s.Failures = 2; return s }, 24 * time.Hour},
+		{"failures capped by max interval", func(s Signals) Signals { s.Failures = 20; return s }, 7 * 24 * time.Hour},
+		{"shop over budget", func(s Signals) Signals { s.ShopURLs = 8000; return s }, 96 * time.Hour},
+		{"paused shop", func(s Signals) Signals { s.ShopBudget = 0; return s }, 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := policy.Plan(tt.signals(plain), now)
+			if plan.Interval != tt.want {
+				t.Errorf("interval = %v, want %v", plan.Interval, tt.want)
+			}
+			if !plan.NextVisitAt.Equal(now.Add(plan.Interval)) {
+				t.Errorf("next visit %v does not match interval", plan.NextVisitAt)
+			}
+		})
+	}
+}
+
+func TestPolicy_OverBudgetKeepsPriorityOrder(t *testing.T) {
+	policy := Policy{}.withDefaults()
+	now := time.Now()
+	base := Signals{InStock: true, ShopBudget: 1000, ShopURLs: 4000}
+
+	volatile := base
+	volatile.PriceChanges = 10
+
+	plainPlan := policy.Plan(base, now)
+	volatilePlan := policy.Plan(volatile, now)
+	if plainPlan.Interval != 96*time.Hour || volatilePlan.Interval != 32*time.Hour {
+		t.Errorf("unexpected intervals plain=%v volatile=%v", plainPlan.Interval, volatilePlan.Interval)
+	}
+	if volatilePlan.Priority <= plainPlan.Priority {
+		t.Errorf("volatile url must be dispatched first: %v <= %v", volatilePlan.Priority, plainPlan.Priority)
+	}
+}
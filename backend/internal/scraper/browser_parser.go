@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +23,7 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 	product.URL = url
 	product.ShopID = shopConfig.ID
 	product.ShopName = shopConfig.Name
+	product.InStock = true // по умолчанию в наличии, если разметка не говорит иного
 
 	// Извлекаем external_id из URL
 	parts := strings.Split(url, "/")
@@ -31,6 +31,13 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 		product.ExternalID = parts[len(parts)-1]
 	}
 
+	// Ждём разрешения: robots.txt, лимиты магазина и свободный слот браузера
+	release, err := s.acquire(ctx, url, shopConfig, true)
+	if err != nil {
+		return nil, fmt.Errorf("politeness: %w", err)
+	}
+	defer release()
+
 	// Запускаем браузер с таймаутом
 	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
 	defer cancel()
@@ -106,9 +113,9 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 		}
 	}()
 
-	// Устанавливаем User-Agent
+	// Устанавливаем User-Agent краулера (тот же, по которому проверяется robots.txt)
 	_ = page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
-		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		UserAgent: s.crawlerUserAgent(),
 	})
 
 	// Переходим на страницу
@@ -127,6 +134,12 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 	// Дополнительная задержка для загрузки JS-контента
 	time.Sleep(3 * time.Second)
 
+	// HTML после выполнения JS: разметка товара часто добавляется скриптами
+	var pageHTML []byte
+	if html, err := page.HTML(); err == nil {
+		pageHTML = []byte(html)
+	}
+
 	// Получаем селекторы
 	nameSelector := shopConfig.Selectors["name"]
 	priceSelector := shopConfig.Selectors["price"]
@@ -136,6 +149,7 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 	brandSelector := shopConfig.Selectors["brand"]
 
 	// Парсинг названия
+	nameFromSelector := false
 	if nameSelector != "" {
 		selectors := strings.Split(nameSelector, ",")
 		for _, sel := range selectors {
@@ -148,6 +162,7 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 				text, err := elem.Text()
 				if err == nil && text != "" && product.Name == "" {
 					product.Name = strings.TrimSpace(text)
+					nameFromSelector = true
 					s.logger.Debug("Found name from browser", map[string]interface{}{
 						"name":     product.Name,
 						"selector": sel,
@@ -176,38 +191,6 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 		}
 	}
 
-	// Парсинг цены из JSON-LD
-	jsonLDScripts, err := page.Elements("script[type='application/ld+json']")
-	if err == nil {
-		for _, script := range jsonLDScripts {
-			text, err := script.Text()
-			if err == nil && strings.Contains(text, `"@type":"Product"`) {
-				// Ищем цену в JSON-LD
-				offersIdx := strings.Index(text, `"offers"`)
-				if offersIdx >= 0 {
-					afterOffers := text[offersIdx:]
-					priceIdx := strings.Index(afterOffers, `"price":`)
-					if priceIdx > 0 {
-						afterPrice := afterOffers[priceIdx+8:]
-						endIdx := strings.IndexAny(afterPrice, ",}\n\r\t ")
-						if endIdx > 0 {
-							priceStr := strings.TrimSpace(afterPrice[:endIdx])
-							if price, err := strconv.ParseFloat(priceStr, 64); err == nil && price > 0 && price < 10000000 {
-								product.Price = price
-								product.Currency = "RSD"
-								s.logger.Debug("Found price from JSON-LD (browser)", map[string]interface{}{
-									"price":    price,
-									"currency": product.Currency,
-								})
-								break
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
 	// Парсинг цены из селекторов
 	if product.Price == 0 && priceSelector != "" {
 		selectors := strings.Split(priceSelector, ",")
@@ -330,6 +313,11 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 		}
 	}
 
+	s.recordSelectorOutcomes(ctx, url, shopConfig, selectorOutcomes(shopConfig.Selectors, &product, nameFromSelector))
+
+	// Структурированные данные имеют приоритет, селекторы - запасной вариант
+	applyStructuredData(&product, s.extractStructuredData(pageHTML, url))
+
 	// Валидация результата
 	if product.Name == "" || product.Price == 0 {
 		return nil, fmt.Errorf("failed to extract essential data from %s: name='%s', price=%.2f", url, product.Name, product.Price)
@@ -337,7 +325,6 @@ func (s *Service) ParseProductWithBrowser(ctx context.Context, url string, shopC
 
 	product.ParsedAt = time.Now()
 	product.ScrapedAt = product.ParsedAt
-	product.InStock = true
 
 	s.logger.Info("Browser parsing completed", map[string]interface{}{
 		"name":        product.Name,
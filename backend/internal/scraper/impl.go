@@ -2,7 +2,7 @@ package scraper
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
+	"github.com/solomonczyk/izborator/internal/politeness"
 	"github.com/solomonczyk/izborator/internal/scrapingstats"
 )
 
@@ -25,6 +26,7 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 	product.URL = url
 	product.ShopID = shopConfig.ID
 	product.ShopName = shopConfig.Name
+	product.InStock = true // по умолчанию в наличии, если разметка не говорит иного
 
 	// Извлекаем external_id из URL (последняя часть после последнего слеша)
 	parts := strings.Split(url, "/")
@@ -33,16 +35,16 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 	}
 
 	// Инициализация Colly
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
+	// robots.txt Colly не проверяет: это делает общий слой вежливости (s.acquire)
+	// с кэшем на хост, а не отдельной загрузкой на каждый коллектор
+	c := s.newCollector(
 		colly.IgnoreRobotsTxt(),
 	)
 
 	// Настройка тайм-аутов (увеличено для медленных соединений)
 	c.SetRequestTimeout(60 * time.Second)
 
-	// Рандомизация User-Agent и Referer (защита от бана)
-	extensions.RandomUserAgent(c)
+	// User-Agent краулера задаёт newCollector (тот же, по которому проверяется robots.txt)
 	extensions.Referer(c)
 
 	// Получаем селекторы из конфига
@@ -72,99 +74,9 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 		"brand":       brandSelector,
 	})
 
-	// 0. Парсинг цены из JSON-LD (schema.org) - приоритетный метод, выполняется первым
-	// На странице может быть несколько JSON-LD блоков в одном script теге
-	c.OnHTML("script[type='application/ld+json']", func(e *colly.HTMLElement) {
-		s.logger.Debug("Found JSON-LD script tag", map[string]interface{}{
-			"text_length": len(e.Text),
-		})
-		if product.Price == 0 {
-			jsonText := strings.TrimSpace(e.Text)
-
-			// Пробуем распарсить как объект
-			var schemaData map[string]interface{}
-			if err := json.Unmarshal([]byte(jsonText), &schemaData); err == nil {
-				// Проверяем, что это Product
-				if schemaType, ok := schemaData["@type"].(string); ok && schemaType == "Product" {
-					// Ищем offers.price в schema.org данных
-					if offers, ok := schemaData["offers"].(map[string]interface{}); ok {
-						// price может быть float64 или string
-						var price float64
-						if priceFloat, ok := offers["price"].(float64); ok {
-							price = priceFloat
-						} else if priceStr, ok := offers["price"].(string); ok {
-							if parsedPrice, err := strconv.ParseFloat(priceStr, 64); err == nil {
-								price = parsedPrice
-							}
-						}
-						if price > 0 && price < 10000000 {
-							product.Price = price
-							if currency, ok := offers["priceCurrency"].(string); ok {
-								product.Currency = currency
-							} else {
-								product.Currency = "RSD"
-							}
-							s.logger.Debug("Found price from JSON-LD", map[string]interface{}{
-								"price":    price,
-								"currency": product.Currency,
-							})
-						}
-					}
-					// Если offers это массив, берем первый элемент
-					if offersArr, ok := schemaData["offers"].([]interface{}); ok && len(offersArr) > 0 {
-						if offer, ok := offersArr[0].(map[string]interface{}); ok {
-							var price float64
-							if priceFloat, ok := offer["price"].(float64); ok {
-								price = priceFloat
-							} else if priceStr, ok := offer["price"].(string); ok {
-								if parsedPrice, err := strconv.ParseFloat(priceStr, 64); err == nil {
-									price = parsedPrice
-								}
-							}
-							if price > 0 && price < 10000000 {
-								product.Price = price
-								if currency, ok := offer["priceCurrency"].(string); ok {
-									product.Currency = currency
-								} else {
-									product.Currency = "RSD"
-								}
-								s.logger.Debug("Found price from JSON-LD (array)", map[string]interface{}{
-									"price":    price,
-									"currency": product.Currency,
-								})
-							}
-						}
-					}
-				}
-			} else {
-				// Fallback: ищем цену в тексте JSON напрямую
-				if product.Price == 0 {
-					offersIdx := strings.Index(jsonText, `"offers"`)
-					if offersIdx >= 0 {
-						afterOffers := jsonText[offersIdx:]
-						priceIdx := strings.Index(afterOffers, `"price":`)
-						if priceIdx > 0 {
-							afterPrice := afterOffers[priceIdx+8:]
-							endIdx := strings.IndexAny(afterPrice, ",}\n\r\t ")
-							if endIdx > 0 {
-								priceStr := strings.TrimSpace(afterPrice[:endIdx])
-								if price, err := strconv.ParseFloat(priceStr, 64); err == nil && price > 0 && price < 10000000 {
-									product.Price = price
-									product.Currency = "RSD"
-									s.logger.Debug("Found price from JSON-LD (fallback)", map[string]interface{}{
-										"price":    price,
-										"currency": product.Currency,
-									})
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	})
-
 	// 1. Парсинг Названия
+	// nameFromSelector отличает срабатывание селектора от fallback по title
+	nameFromSelector := false
 	if nameSelector != "" {
 		// Пробуем каждый селектор из списка (разделены запятыми)
 		selectors := strings.Split(nameSelector, ",")
@@ -174,6 +86,9 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 				continue
 			}
 			c.OnHTML(sel, func(e *colly.HTMLElement) {
+				if strings.TrimSpace(e.Text) != "" {
+					nameFromSelector = true
+				}
 				if product.Name == "" {
 					product.Name = strings.TrimSpace(e.Text)
 					s.logger.Debug("Found name", map[string]interface{}{
@@ -295,85 +210,11 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 		})
 	}
 
-	// Парсинг цены из JSON-LD (schema.org) - приоритетный метод
-	// На странице может быть несколько JSON-LD блоков в одном script теге
-	c.OnHTML("script[type='application/ld+json']", func(e *colly.HTMLElement) {
-		s.logger.Debug("Found JSON-LD script tag", map[string]interface{}{
-			"text_length": len(e.Text),
-		})
-		if product.Price == 0 {
-			jsonText := strings.TrimSpace(e.Text)
-
-			// Сначала пробуем найти "price":число в offers напрямую (fallback метод)
-			// Ищем паттерн "offers" -> "price":число
-			offersIdx := strings.Index(jsonText, `"offers"`)
-			s.logger.Debug("Checking JSON-LD for price", map[string]interface{}{
-				"has_offers": offersIdx >= 0,
-				"offers_idx": offersIdx,
-				"text_preview": func() string {
-					if len(jsonText) > 200 {
-						return jsonText[:200] + "..."
-					}
-					return jsonText
-				}(),
-			})
-			if offersIdx >= 0 {
-				afterOffers := jsonText[offersIdx:]
-				priceIdx := strings.Index(afterOffers, `"price":`)
-				if priceIdx > 0 {
-					// Ищем число после "price":
-					afterPrice := afterOffers[priceIdx+8:]
-					// Ищем конец числа (запятая, скобка, пробел, новая строка)
-					endIdx := strings.IndexAny(afterPrice, ",}\n\r\t ")
-					if endIdx > 0 {
-						priceStr := strings.TrimSpace(afterPrice[:endIdx])
-						s.logger.Debug("Trying to parse price from JSON-LD", map[string]interface{}{
-							"price_str":  priceStr,
-							"offers_idx": offersIdx,
-							"price_idx":  priceIdx,
-						})
-						if price, err := strconv.ParseFloat(priceStr, 64); err == nil && price > 0 && price < 10000000 {
-							// Проверяем, что цена разумная (меньше 10 миллионов RSD)
-							product.Price = price
-							product.Currency = "RSD"
-							s.logger.Debug("Found price from JSON-LD (fallback)", map[string]interface{}{
-								"price":    price,
-								"currency": product.Currency,
-							})
-							return
-						}
-					}
-				}
-			}
-
-			// Пробуем распарсить как объект
-			var schemaData map[string]interface{}
-			if err := json.Unmarshal([]byte(jsonText), &schemaData); err == nil {
-				// Проверяем, что это Product
-				if schemaType, ok := schemaData["@type"].(string); ok && schemaType == "Product" {
-					// Ищем offers.price в schema.org данных
-					if offers, ok := schemaData["offers"].(map[string]interface{}); ok {
-						// price может быть float64
-						var price float64
-						if priceFloat, ok := offers["price"].(float64); ok {
-							price = priceFloat
-						}
-						if price > 0 && price < 10000000 {
-							product.Price = price
-							if currency, ok := offers["priceCurrency"].(string); ok {
-								product.Currency = currency
-							} else {
-								product.Currency = "RSD"
-							}
-							s.logger.Debug("Found price from JSON-LD", map[string]interface{}{
-								"price":    price,
-								"currency": product.Currency,
-							})
-						}
-					}
-				}
-			}
-		}
+	// Структурированные данные (JSON-LD, Microdata, RDFa, OpenGraph) разбираются
+	// после загрузки страницы и имеют приоритет над селекторами
+	var pageHTML []byte
+	c.OnResponse(func(r *colly.Response) {
+		pageHTML = r.Body
 	})
 
 	// Логирование запроса и настройка заголовков
@@ -477,8 +318,15 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 		})
 	}
 
+	// Ждём разрешения: robots.txt, лимит скорости и параллельности магазина
+	release, err := s.acquire(ctx, url, shopConfig, false)
+	if err != nil {
+		return nil, fmt.Errorf("politeness: %w", err)
+	}
+	defer release()
+
 	// Запуск
-	err := c.Visit(url)
+	err = c.Visit(url)
 	if err != nil {
 		return nil, fmt.Errorf("colly visit error: %w", err)
 	}
@@ -503,31 +351,12 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 				}
 			}
 		}
-		// Ищем цену в JSON-LD в HTML
-		if product.Price == 0 {
-			jsonLDIdx := strings.Index(htmlStr, `"@type":"Product"`)
-			if jsonLDIdx >= 0 {
-				// Ищем цену после "@type":"Product"
-				priceIdx := strings.Index(htmlStr[jsonLDIdx:], `"price":`)
-				if priceIdx > 0 {
-					afterPrice := htmlStr[jsonLDIdx+priceIdx+8:]
-					endIdx := strings.IndexAny(afterPrice, ",}\n\r\t ")
-					if endIdx > 0 {
-						priceStr := strings.TrimSpace(afterPrice[:endIdx])
-						if price, err := strconv.ParseFloat(priceStr, 64); err == nil && price > 0 && price < 10000000 {
-							product.Price = price
-							product.Currency = "RSD"
-							s.logger.Debug("Extracted price from HTML JSON-LD", map[string]interface{}{
-								"price":    price,
-								"currency": product.Currency,
-							})
-						}
-					}
-				}
-			}
-		}
 	}
 
+	s.recordSelectorOutcomes(ctx, url, shopConfig, selectorOutcomes(shopConfig.Selectors, &product, nameFromSelector))
+
+	applyStructuredData(&product, s.extractStructuredData(pageHTML, url))
+
 	// Логируем что было найдено
 	s.logger.Debug("Parsing completed", map[string]interface{}{
 		"name":        product.Name,
@@ -546,7 +375,6 @@ func (s *Service) ParseProduct(ctx context.Context, url string, shopConfig *Shop
 
 	product.ParsedAt = time.Now()
 	product.ScrapedAt = product.ParsedAt // для обратной совместимости
-	product.InStock = true               // По умолчанию считаем, что товар в наличии
 
 	return &product, nil
 }
@@ -749,8 +577,18 @@ func (s *Service) SaveRawProduct(ctx context.Context, product *RawProduct) error
 }
 
 // ScrapeAndSave выполняет полный цикл парсинга и сохранения товара с записью статистики
-// Автоматически выбирает между обычным парсером (Colly) и browser парсером (rod) в зависимости от магазина
+// Автоматически выбирает между обычным парсером (Colly) и browser парсером (rod) в зависимости от магазина,
+// страницы прайс-листов (ShopConfig.ListMode) разбираются построчно через ScrapeAndSaveList
 func (s *Service) ScrapeAndSave(ctx context.Context, url string, shopConfig *ShopConfig) (*RawProduct, error) {
+	// Прайс-лист услуг: каждая строка сохраняется отдельно, возвращается первая
+	if shopConfig.ListMode() {
+		items, err := s.ScrapeAndSaveList(ctx, url, shopConfig)
+		if err != nil {
+			return nil, err
+		}
+		return items[0], nil
+	}
+
 	// Магазины, требующие JS-рендеринг (headless браузер)
 	jsRenderingShops := map[string]bool{
 		"b0eebc99-9c0b-4ef8-bb6d-6bb9bd380b22": true, // Tehnomanija
@@ -800,6 +638,12 @@ func (s *Service) scrapeAndSaveWithColly(ctx context.Context, url string, shopCo
 		})
 
 		rawProduct, lastErr = s.ParseProduct(ctx, url, shopConfig)
+		if errors.Is(lastErr, politeness.ErrDisallowedByRobots) {
+			// Повторять запрещённый robots.txt URL бессмысленно
+			stat.ErrorsCount++
+			stat.ErrorMessage = truncateError(lastErr)
+			break
+		}
 		if lastErr == nil {
 			stat.ProductsFound = 1
 			if err := s.SaveRawProduct(ctx, rawProduct); err == nil {
@@ -881,6 +725,12 @@ func (s *Service) scrapeAndSaveWithBrowser(ctx context.Context, url string, shop
 		})
 
 		rawProduct, lastErr = s.ParseProductWithBrowser(ctx, url, shopConfig)
+		if errors.Is(lastErr, politeness.ErrDisallowedByRobots) {
+			// Повторять запрещённый robots.txt URL бессмысленно
+			stat.ErrorsCount++
+			stat.ErrorMessage = truncateError(lastErr)
+			break
+		}
 		if lastErr == nil {
 			stat.ProductsFound = 1
 			if err := s.SaveRawProduct(ctx, rawProduct); err == nil {
@@ -995,24 +845,15 @@ func (s *Service) ParseCatalog(ctx context.Context, catalogURL string, shopConfi
 	}
 
 	// Инициализация Colly
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
+	// robots.txt и ограничение скорости (ShopConfig.RateLimit) обеспечивает общий
+	// слой вежливости перед каждой страницей каталога (s.acquire)
+	c := s.newCollector(
 		colly.IgnoreRobotsTxt(),
 	)
 
 	c.SetRequestTimeout(60 * time.Second)
-	extensions.RandomUserAgent(c)
 	extensions.Referer(c)
 
-	// Ограничение скорости (rate limiting)
-	if shopConfig.RateLimit > 0 {
-		_ = c.Limit(&colly.LimitRule{
-			DomainGlob:  "*",
-			Parallelism: 1,
-			Delay:       time.Duration(1000/shopConfig.RateLimit) * time.Millisecond,
-		})
-	}
-
 	visitedPages := make(map[string]bool)
 	pageCount := 0
 	productURLsMap := make(map[string]bool) // Для дедупликации
@@ -1111,7 +952,17 @@ func (s *Service) ParseCatalog(ctx context.Context, catalogURL string, shopConfi
 			"found_so_far": linksBeforePage,
 		})
 
-		err := c.Visit(currentURL)
+		release, err := s.acquire(ctx, currentURL, shopConfig, false)
+		if err != nil {
+			s.logger.Warn("Catalog page skipped by politeness layer", map[string]interface{}{
+				"url":   currentURL,
+				"error": err.Error(),
+			})
+			break
+		}
+
+		err = c.Visit(currentURL)
+		release()
 		if err != nil {
 			s.logger.Error("Failed to visit catalog page", map[string]interface{}{
 				"url":   currentURL,
@@ -1136,9 +987,6 @@ func (s *Service) ParseCatalog(ctx context.Context, catalogURL string, shopConfi
 		} else {
 			break
 		}
-
-		// Пауза между страницами
-		time.Sleep(2 * time.Second)
 	}
 
 	result.TotalFound = len(result.ProductURLs)
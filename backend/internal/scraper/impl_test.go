@@ -1,7 +1,14 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/politeness"
 )
 
 func TestCleanPrice(t *testing.T) {
@@ -175,3 +182,99 @@ func TestIsProductURL(t *testing.T) {
 		})
 	}
 }
+
+type denyPoliteness struct {
+	requests []politeness.Request
+}
+
+func (d *denyPoliteness) Acquire(ctx context.Context, req politeness.Request) (func(), error) {
+	d.requests = append(d.requests, req)
+	return nil, politeness.ErrDisallowedByRobots
+}
+
+func TestParseProduct_DisallowedByRobots(t *testing.T) {
+	gate := &denyPoliteness{}
+	s := New(nil, nil, "", nil, gate, nil, logger.New("error"))
+	shop := &ShopConfig{ID: "shop-1", Name: "Shop", RateLimit: 3}
+
+	_, err := s.ParseProduct(context.Background(), "https://shop.example/p/1", shop)
+	if !errors.Is(err, politeness.ErrDisallowedByRobots) {
+		t.Fatalf("expected ErrDisallowedByRobots, got %v", err)
+	}
+	if len(gate.requests) != 1 {
+		t.Fatalf("expected one acquire call, got %d", len(gate.requests))
+	}
+	req := gate.requests[0]
+	if req.ShopID != "shop-1" || req.RateLimit != 3 || req.Browser {
+		t.Errorf("unexpected politeness request: %+v", req)
+	}
+}
+
+type recordingSelectorHealth struct {
+	shopID   string
+	outcomes map[string]bool
+}
+
+func (r *recordingSelectorHealth) RecordOutcomes(ctx context.Context, shopID, url string, outcomes map[string]bool) {
+	r.shopID = shopID
+	r.outcomes = outcomes
+}
+
+func TestParseProduct_RecordsSelectorOutcomes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Phone X</title></head>
+			<body><div class="new-title">Phone X</div><span class="price">12.999 RSD</span></body></html>`))
+	}))
+	defer server.Close()
+
+	health := &recordingSelectorHealth{}
+	s := New(nil, nil, "", nil, nil, health, logger.New("error"))
+	shop := &ShopConfig{
+		ID:      "shop-1",
+		BaseURL: server.URL,
+		Selectors: map[string]string{
+			"name":  "h1.product-title", // разметка сменилась, имя берётся из title
+			"price": ".price",
+			"brand": ".brand",
+		},
+	}
+
+	product, err := s.ParseProduct(context.Background(), server.URL+"/p/1", shop)
+	if err != nil {
+		t.Fatalf("ParseProduct failed: %v", err)
+	}
+	if product.Name != "Phone X" {
+		t.Fatalf("expected title fallback name, got %q", product.Name)
+	}
+
+	want := map[string]bool{"name": false, "price": true, "brand": false}
+	if health.shopID != "shop-1" || len(health.outcomes) != len(want) {
+		t.Fatalf("unexpected outcomes %v for shop %q", health.outcomes, health.shopID)
+	}
+	for field, matched := range want {
+		if health.outcomes[field] != matched {
+			t.Errorf("selector %s: expected matched=%v, got %v", field, matched, health.outcomes[field])
+		}
+	}
+}
+
+func TestParseProduct_SendsCrawlerUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		_, _ = w.Write([]byte(`<html><body><h1>Phone X</h1><span class="price">12.999 RSD</span></body></html>`))
+	}))
+	defer server.Close()
+
+	s := New(nil, nil, "", nil, nil, nil, logger.New("error"))
+	s.SetUserAgent("TestBot/1.0")
+	shop := &ShopConfig{ID: "shop-1", BaseURL: server.URL, Selectors: map[string]string{"name": "h1", "price": ".price"}}
+
+	if _, err := s.ParseProduct(context.Background(), server.URL+"/p/1", shop); err != nil {
+		t.Fatalf("ParseProduct failed: %v", err)
+	}
+	// robots.txt проверяется для того же краулера, которым парсер представляется магазину
+	if userAgent != "TestBot/1.0" {
+		t.Errorf("User-Agent = %q, want TestBot/1.0", userAgent)
+	}
+}
@@ -0,0 +1,435 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/gocolly/colly/v2/extensions"
+	"github.com/solomonczyk/izborator/internal/politeness"
+	"github.com/solomonczyk/izborator/internal/scrapingstats"
+	"golang.org/x/net/html"
+)
+
+// Ключи RawPayload позиций прайс-листа; processor переносит их в products.ServiceMetadata
+const (
+	PayloadProductType  = "product_type"
+	PayloadDuration     = "duration"
+	PayloadListPosition = "list_position"
+
+	// ProductTypeService значение PayloadProductType для услуг
+	ProductTypeService = "service"
+)
+
+var (
+	// durationRegex длительность услуги: "45 min", "1h 30min", "1 sat", "2 sata", "90 мин", "1 час"
+	durationRegex = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(h|sat[ai]?|час(?:а|ов)?|min(?:uta|ute)?|мин(?:ута|ут)?)(?:[^\p{L}]|$)`)
+	// priceRangeRegex разделитель диапазона цен "1.500 - 2.000 din", "od 1.500 do 2.000"
+	priceRangeRegex = regexp.MustCompile(`(?i)\s*[–—]\s*|\s+-\s*|\s+do\s+`)
+	// listNumberRegex число с разделителями в строке цены
+	listNumberRegex = regexp.MustCompile(`\d[\d.,]*`)
+	// thousandsRegex число, где один вид разделителя отделяет группы по три цифры: "4.500", "1,234,567"
+	thousandsRegex = []*regexp.Regexp{
+		regexp.MustCompile(`^\d{1,3}(?:\.\d{3})+$`),
+		regexp.MustCompile(`^\d{1,3}(?:,\d{3})+$`),
+	}
+)
+
+// ParseServiceList парсит страницу прайс-листа (cenovnik): каждая строка таблицы или карточка списка
+// становится отдельным RawProduct. Строки задаются селектором list_item; без него строкой считается
+// ближайший предок названия, содержащий цену. Селекторы name, price, duration, description, image
+// применяются внутри строки
+func (s *Service) ParseServiceList(ctx context.Context, url string, shopConfig *ShopConfig) ([]*RawProduct, error) {
+	if url == "" {
+		return nil, ErrInvalidURL
+	}
+	if strings.TrimSpace(shopConfig.Selectors["name"]) == "" {
+		return nil, fmt.Errorf("name selector is required for list pages")
+	}
+
+	s.logger.Info("Starting price list scraping", map[string]interface{}{
+		"url":     url,
+		"shop_id": shopConfig.ID,
+	})
+
+	c := s.newCollector(
+		colly.IgnoreRobotsTxt(),
+	)
+	c.SetRequestTimeout(60 * time.Second)
+	extensions.Referer(c)
+
+	var pageHTML []byte
+	var visitErr error
+	c.OnResponse(func(r *colly.Response) {
+		pageHTML = r.Body
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		visitErr = err
+	})
+
+	release, err := s.acquire(ctx, url, shopConfig, false)
+	if err != nil {
+		return nil, fmt.Errorf("politeness: %w", err)
+	}
+	defer release()
+
+	if err := c.Visit(url); err != nil {
+		return nil, fmt.Errorf("colly visit error: %w", err)
+	}
+	if visitErr != nil {
+		return nil, fmt.Errorf("colly visit error: %w", visitErr)
+	}
+
+	items, err := extractServiceList(pageHTML, url, shopConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.recordSelectorOutcomes(ctx, url, shopConfig, listSelectorOutcomes(shopConfig.Selectors, items))
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("failed to extract services from %s: no rows with name and price or duration", url)
+	}
+
+	s.logger.Debug("Price list parsing completed", map[string]interface{}{
+		"url":   url,
+		"items": len(items),
+	})
+	return items, nil
+}
+
+// ScrapeAndSaveList парсит прайс-лист с повторами и сохраняет каждую услугу отдельным сырым товаром.
+// Статистика записывается одна на страницу: найдено строк, сохранено строк
+func (s *Service) ScrapeAndSaveList(ctx context.Context, url string, shopConfig *ShopConfig) ([]*RawProduct, error) {
+	start := time.Now()
+	stat := &scrapingstats.ScrapingStat{
+		ShopID:    shopConfig.ID,
+		ShopName:  shopConfig.Name,
+		ScrapedAt: start,
+		Status:    "error",
+	}
+	finish := func() {
+		stat.DurationMs = int(time.Since(start) / time.Millisecond)
+		s.recordScrapingStat(stat)
+	}
+
+	maxAttempts, backoff := s.getRetryConfig(shopConfig)
+	var items []*RawProduct
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			lastErr = ctx.Err()
+			break
+		}
+		items, lastErr = s.ParseServiceList(ctx, url, shopConfig)
+		if lastErr == nil {
+			break
+		}
+		stat.ErrorsCount++
+		// Повторять запрещённый robots.txt URL бессмысленно
+		if errors.Is(lastErr, politeness.ErrDisallowedByRobots) || attempt == maxAttempts {
+			break
+		}
+		s.logger.Warn("Price list scraping attempt failed, retrying", map[string]interface{}{
+			"attempt":      attempt,
+			"max_attempts": maxAttempts,
+			"shop_id":      shopConfig.ID,
+			"error":        lastErr.Error(),
+			"backoff_ms":   backoff.Milliseconds(),
+		})
+		if !sleepWithContext(ctx, backoff) {
+			lastErr = ctx.Err()
+			break
+		}
+		backoff = nextBackoff(backoff)
+	}
+	if lastErr != nil {
+		stat.ErrorMessage = truncateError(lastErr)
+		finish()
+		return nil, lastErr
+	}
+
+	stat.ProductsFound = len(items)
+	saved := make([]*RawProduct, 0, len(items))
+	for _, item := range items {
+		if err := s.SaveRawProduct(ctx, item); err != nil {
+			stat.ErrorsCount++
+			stat.ErrorMessage = truncateError(err)
+			lastErr = err
+			continue
+		}
+		saved = append(saved, item)
+	}
+	stat.ProductsSaved = len(saved)
+
+	switch {
+	case len(saved) == len(items):
+		stat.Status = "success"
+	case len(saved) > 0:
+		stat.Status = "partial"
+	}
+	finish()
+
+	if len(saved) == 0 {
+		return nil, lastErr
+	}
+	return saved, nil
+}
+
+// extractServiceList разбирает строки прайс-листа из HTML страницы
+func extractServiceList(page []byte, pageURL string, shopConfig *ShopConfig) ([]*RawProduct, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	selectors := shopConfig.Selectors
+	pageID := pageExternalID(pageURL)
+	parsedAt := time.Now()
+	seen := make(map[string]int)
+
+	var items []*RawProduct
+	for position, row := range serviceRows(doc, selectors) {
+		name := cleanText(row.Find(selectors["name"]).First().Text())
+		if name == "" {
+			continue
+		}
+
+		item := &RawProduct{
+			ShopID:   shopConfig.ID,
+			ShopName: shopConfig.Name,
+			Name:     name,
+			URL:      pageURL,
+			InStock:  true,
+			RawPayload: map[string]interface{}{
+				PayloadProductType:  ProductTypeService,
+				PayloadListPosition: position + 1,
+			},
+			ParsedAt: parsedAt,
+		}
+		item.ScrapedAt = item.ParsedAt
+
+		if sel := selectors["price"]; sel != "" {
+			item.Price, item.Currency = rowPrice(row.Find(sel).First().Text())
+		}
+
+		duration := ""
+		if sel := selectors["duration"]; sel != "" {
+			duration = parseDuration(row.Find(sel).First().Text())
+		}
+		if duration == "" {
+			duration = parseDuration(spacedText(row.Nodes...))
+		}
+		if duration != "" {
+			item.RawPayload[PayloadDuration] = duration
+		}
+
+		// Строки разделов и заголовки таблицы без цены и длительности не являются услугами
+		if item.Price <= 0 && duration == "" {
+			continue
+		}
+
+		if sel := selectors["description"]; sel != "" {
+			item.Description = cleanText(row.Find(sel).First().Text())
+		}
+		if sel := selectors["image"]; sel != "" {
+			if img := row.Find(sel).First(); img.Length() > 0 {
+				src := img.AttrOr("src", img.AttrOr("data-src", ""))
+				if src != "" {
+					item.ImageURLs = []string{absoluteURL(pageURL, src)}
+				}
+			}
+		}
+		if sel := selectors["category"]; sel != "" {
+			item.Category = cleanText(doc.Find(sel).First().Text())
+		}
+
+		// Стабильный ExternalID: страница + название; одноимённые услуги нумеруются по порядку
+		key := serviceSlug(name)
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s-%d", key, seen[key])
+		}
+		item.ExternalID = pageID + "#" + key
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// serviceRows строки прайс-листа: по селектору list_item или ближайшие предки названий, содержащие цену
+func serviceRows(doc *goquery.Document, selectors map[string]string) []*goquery.Selection {
+	var rows []*goquery.Selection
+	if sel := strings.TrimSpace(selectors["list_item"]); sel != "" {
+		doc.Find(sel).Each(func(_ int, row *goquery.Selection) {
+			rows = append(rows, row)
+		})
+		return rows
+	}
+
+	priceSel := strings.TrimSpace(selectors["price"])
+	seen := make(map[*html.Node]bool)
+	doc.Find(selectors["name"]).Each(func(_ int, name *goquery.Selection) {
+		row := name
+		for parent := name.Parent(); parent.Length() > 0; parent = parent.Parent() {
+			if priceSel != "" && parent.Find(priceSel).Length() > 0 {
+				row = parent
+				break
+			}
+			if goquery.NodeName(parent) == "body" {
+				break
+			}
+		}
+		// Предок с несколькими названиями - это вся таблица, а не строка
+		if row != name && row.Find(selectors["name"]).Length() > 1 {
+			row = name
+		}
+		if node := row.Get(0); !seen[node] {
+			seen[node] = true
+			rows = append(rows, row)
+		}
+	})
+	return rows
+}
+
+// rowPrice цена строки через cleanListPrice; для диапазона берётся нижняя граница ("od 1.500 - 2.000 din")
+func rowPrice(text string) (float64, string) {
+	text = cleanText(text)
+	if text == "" {
+		return 0, ""
+	}
+	_, currency, err := cleanListPrice(text)
+	if err != nil {
+		return 0, ""
+	}
+	parts := priceRangeRegex.Split(text, 2)
+	price, _, err := cleanListPrice(parts[0])
+	if err != nil {
+		return 0, ""
+	}
+	return price, currency
+}
+
+// cleanListPrice разбирает цену строки прайс-листа. Цены услуг указываются целыми
+// динарами без копеек, поэтому группы по три цифры после точки или запятой - тысячи:
+// "4.500 din" -> 4500. Для товаров действует общий cleanPrice, где "123.450" - дробь
+func cleanListPrice(raw string) (float64, string, error) {
+	text := listNumberRegex.ReplaceAllStringFunc(raw, func(number string) string {
+		for _, re := range thousandsRegex {
+			if re.MatchString(number) {
+				return strings.NewReplacer(".", "", ",", "").Replace(number)
+			}
+		}
+		return number
+	})
+	return cleanPrice(text)
+}
+
+// parseDuration находит длительность в тексте и приводит её к виду "90 min"
+func parseDuration(text string) string {
+	minutes := 0.0
+	for _, match := range durationRegex.FindAllStringSubmatch(text, -1) {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", "."), 64)
+		if err != nil {
+			continue
+		}
+		unit := strings.ToLower(match[2])
+		if strings.HasPrefix(unit, "min") || strings.HasPrefix(unit, "мин") {
+			minutes += value
+		} else {
+			minutes += value * 60
+		}
+	}
+	if minutes <= 0 {
+		return ""
+	}
+	return strconv.Itoa(int(minutes+0.5)) + " min"
+}
+
+// pageExternalID последняя часть пути страницы прайс-листа (как ExternalID в ParseProduct)
+func pageExternalID(pageURL string) string {
+	trimmed := strings.TrimRight(strings.SplitN(pageURL, "?", 2)[0], "/")
+	parts := strings.Split(trimmed, "/")
+	return parts[len(parts)-1]
+}
+
+// serviceSlug название услуги в нижнем регистре, буквы и цифры через дефис
+func serviceSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
+
+// listSelectorOutcomes результаты селекторов прайс-листа для детектора деградации
+func listSelectorOutcomes(selectors map[string]string, items []*RawProduct) map[string]bool {
+	outcomes := make(map[string]bool)
+	if strings.TrimSpace(selectors["name"]) != "" {
+		outcomes["name"] = len(items) > 0
+	}
+	if strings.TrimSpace(selectors["price"]) != "" {
+		outcomes["price"] = false
+		for _, item := range items {
+			if item.Price > 0 {
+				outcomes["price"] = true
+				break
+			}
+		}
+	}
+	return outcomes
+}
+
+// spacedText текст узлов через пробел: Text() склеивает соседние ячейки ("45 minPo dogovoru")
+func spacedText(nodes ...*html.Node) string {
+	var parts []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			parts = append(parts, n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return cleanText(strings.Join(parts, " "))
+}
+
+// cleanText схлопывает пробелы
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// absoluteURL разрешает относительную ссылку относительно страницы
+func absoluteURL(pageURL, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return resolved.String()
+}
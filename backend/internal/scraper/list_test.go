@@ -0,0 +1,183 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+const salonPriceList = `<html><body>
+<h1>Cenovnik</h1>
+<table class="cenovnik">
+  <tr><th>Usluga</th><th>Trajanje</th><th>Cena</th></tr>
+  <tr class="section"><td colspan="3">Frizerske usluge</td></tr>
+  <tr><td>Muško šišanje</td><td>30 min</td><td>1.200 din</td></tr>
+  <tr><td>Žensko šišanje</td><td>1h 15min</td><td>od 2.500 - 3.500 din</td></tr>
+  <tr><td>Feniranje</td><td>45 min</td><td>Po dogovoru</td></tr>
+  <tr><td>Muško šišanje</td><td>20 min</td><td>900 din</td></tr>
+</table>
+</body></html>`
+
+func TestExtractServiceList_TableRows(t *testing.T) {
+	shop := &ShopConfig{
+		ID:       "salon-1",
+		Name:     "Salon",
+		SiteType: SiteTypeServiceProvider,
+		Selectors: map[string]string{
+			"name":  "table.cenovnik tr td:first-child",
+			"price": "table.cenovnik tr td:last-child",
+		},
+	}
+
+	items, err := extractServiceList([]byte(salonPriceList), "https://salon.example/cenovnik/", shop)
+	if err != nil {
+		t.Fatalf("extractServiceList failed: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("expected 4 services, got %d: %+v", len(items), items)
+	}
+
+	want := []struct {
+		externalID string
+		price      float64
+		duration   string
+	}{
+		{"cenovnik#muško-šišanje", 1200, "30 min"},
+		{"cenovnik#žensko-šišanje", 2500, "75 min"},
+		{"cenovnik#feniranje", 0, "45 min"},
+		{"cenovnik#muško-šišanje-2", 900, "20 min"},
+	}
+	for i, w := range want {
+		item := items[i]
+		if item.ExternalID != w.externalID || item.Price != w.price || item.RawPayload[PayloadDuration] != w.duration {
+			t.Errorf("item %d: got %s price=%v duration=%v, want %+v", i, item.ExternalID, item.Price, item.RawPayload[PayloadDuration], w)
+		}
+		if item.RawPayload[PayloadProductType] != ProductTypeService || item.URL != "https://salon.example/cenovnik/" || item.ShopID != "salon-1" {
+			t.Errorf("item %d: unexpected metadata %+v", i, item)
+		}
+	}
+}
+
+func TestExtractServiceList_ListItemCards(t *testing.T) {
+	page := `<div class="services">
+		<div class="card"><h3>Masaža leđa</h3><p class="desc">Relaks masaža</p><span class="price">2.000 RSD</span><span class="time">60 min</span></div>
+		<div class="card"><h3>Piling</h3><span class="price">1.500 RSD</span></div>
+		<div class="card promo"><h3>Poklon vaučer</h3></div>
+	</div>`
+	shop := &ShopConfig{
+		ID: "spa-1",
+		Selectors: map[string]string{
+			"list_item":   ".services .card",
+			"name":        "h3",
+			"price":       ".price",
+			"duration":    ".time",
+			"description": ".desc",
+		},
+	}
+	if !shop.ListMode() {
+		t.Fatal("list_item selector must enable list mode")
+	}
+
+	items, err := extractServiceList([]byte(page), "https://spa.example/usluge?tab=1", shop)
+	if err != nil {
+		t.Fatalf("extractServiceList failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 services (card without price or duration skipped), got %d", len(items))
+	}
+	if items[0].ExternalID != "usluge#masaža-leđa" || items[0].Description != "Relaks masaža" || items[0].RawPayload[PayloadDuration] != "60 min" {
+		t.Errorf("unexpected first item %+v", items[0])
+	}
+	if items[1].Price != 1500 || items[1].Currency != "RSD" {
+		t.Errorf("unexpected second item price %v %s", items[1].Price, items[1].Currency)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]string{
+		"30 min":        "30 min",
+		"1h 30min":      "90 min",
+		"1,5 sat":       "90 min",
+		"2 sata":        "120 min",
+		"45 минута":     "45 min",
+		"1 час":         "60 min",
+		"1.200 din":     "",
+		"Hidratantna 3": "",
+	}
+	for input, want := range tests {
+		if got := parseDuration(input); got != want {
+			t.Errorf("parseDuration(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCleanListPrice(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantPrice float64
+		wantCurr  string
+	}{
+		{"4.500 din", 4500, "RSD"},
+		{"1.234.567 RSD", 1234567, "RSD"},
+		{"2,500 RSD", 2500, "RSD"},
+		{"1.234,56 RSD", 1234.56, "RSD"},
+		{"12,5 EUR", 12.5, "EUR"},
+		{"800 din", 800, "RSD"},
+	}
+	for _, tt := range tests {
+		price, currency, err := cleanListPrice(tt.input)
+		if err != nil {
+			t.Errorf("cleanListPrice(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if price != tt.wantPrice || currency != tt.wantCurr {
+			t.Errorf("cleanListPrice(%q) = %v %s, want %v %s", tt.input, price, currency, tt.wantPrice, tt.wantCurr)
+		}
+	}
+}
+
+func TestScrapeAndSave_ListModeSavesEachRow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(salonPriceList))
+	}))
+	defer server.Close()
+
+	storage := &recordingStorage{}
+	health := &recordingSelectorHealth{}
+	s := New(storage, nil, "", nil, nil, health, logger.New("error"))
+	shop := &ShopConfig{
+		ID:         "salon-1",
+		BaseURL:    server.URL,
+		SiteType:   SiteTypeServiceProvider,
+		RetryLimit: 1,
+		Selectors: map[string]string{
+			"name":  "table.cenovnik tr td:first-child",
+			"price": "table.cenovnik tr td:last-child",
+		},
+	}
+
+	first, err := s.ScrapeAndSave(context.Background(), server.URL+"/cenovnik", shop)
+	if err != nil {
+		t.Fatalf("ScrapeAndSave failed: %v", err)
+	}
+	if first.Name != "Muško šišanje" || len(storage.saved) != 4 {
+		t.Fatalf("expected 4 saved rows starting with first service, got %d (first %q)", len(storage.saved), first.Name)
+	}
+	if !health.outcomes["name"] || !health.outcomes["price"] {
+		t.Errorf("unexpected selector outcomes %v", health.outcomes)
+	}
+}
+
+// recordingStorage запоминает сохранённые сырые товары
+type recordingStorage struct {
+	Storage
+	saved []*RawProduct
+}
+
+func (r *recordingStorage) SaveRawProduct(data *RawProduct) error {
+	r.saved = append(r.saved, data)
+	return nil
+}
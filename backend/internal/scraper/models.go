@@ -1,6 +1,9 @@
 package scraper
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // RawProduct сырые данные товара с сайта магазина
 type RawProduct struct {
@@ -23,6 +26,12 @@ type RawProduct struct {
 	ScrapedAt time.Time `json:"scraped_at"` // deprecated, используй ParsedAt
 }
 
+// Типы сайтов магазинов
+const (
+	SiteTypeEcommerce       = "ecommerce"        // страница - один товар
+	SiteTypeServiceProvider = "service_provider" // страница - прайс-лист (cenovnik), строка - отдельная услуга
+)
+
 // ShopConfig конфигурация магазина для парсинга
 type ShopConfig struct {
 	ID             string            `json:"id"`
@@ -33,4 +42,10 @@ type ShopConfig struct {
 	Enabled        bool              `json:"enabled"`
 	RetryLimit     int               `json:"retry_limit"`
 	RetryBackoffMs int               `json:"retry_backoff_ms"`
+	SiteType       string            `json:"site_type,omitempty"` // ecommerce | service_provider
+}
+
+// ListMode страница магазина содержит список позиций (прайс-лист услуг), а не один товар
+func (c *ShopConfig) ListMode() bool {
+	return c.SiteType == SiteTypeServiceProvider || strings.TrimSpace(c.Selectors["list_item"]) != ""
 }
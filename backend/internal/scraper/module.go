@@ -1,7 +1,14 @@
 package scraper
 
 import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
 	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/politeness"
 	"github.com/solomonczyk/izborator/internal/scrapingstats"
 )
 
@@ -18,7 +25,7 @@ type Storage interface {
 	// SaveRawProduct сохраняет сырые данные товара
 	SaveRawProduct(data *RawProduct) error
 
-	// GetShopConfig получает конфигурацию магазина
+	// GetShopConfig получает конфигурацию магазина с селекторами активной версии
 	GetShopConfig(shopID string) (*ShopConfig, error)
 
 	// ListShops получает список всех магазинов
@@ -32,6 +39,18 @@ type Storage interface {
 
 	// MarkRawProductAsProcessed помечает сырой товар как обработанный
 	MarkRawProductAsProcessed(shopID, externalID string) error
+
+	// LinkRawProduct связывает сырой товар с каноническим товаром
+	LinkRawProduct(shopID, externalID, productID string) error
+
+	// GetLinkedProductID возвращает товар, к которому привязан сырой товар; пусто - не привязан
+	GetLinkedProductID(shopID, externalID string) (string, error)
+
+	// GetCatalogLastMods возвращает lastmod уже разобранных адресов sitemap магазина
+	GetCatalogLastMods(shopID string) (map[string]time.Time, error)
+
+	// SaveCatalogLastMod запоминает lastmod разобранного адреса sitemap
+	SaveCatalogLastMod(shopID, url string, lastMod time.Time) error
 }
 
 // Queue интерфейс для отправки данных в очередь
@@ -40,13 +59,28 @@ type Queue interface {
 	Publish(topic string, data interface{}) error
 }
 
+// Politeness ограничивает запросы к магазинам: robots.txt, скорость и параллельность
+// Общий для Colly и браузерного (rod) парсеров
+type Politeness interface {
+	Acquire(ctx context.Context, req politeness.Request) (release func(), err error)
+}
+
+// SelectorHealth принимает результаты срабатывания селекторов для детектора деградации
+type SelectorHealth interface {
+	RecordOutcomes(ctx context.Context, shopID, url string, outcomes map[string]bool)
+}
+
 // Service сервис для парсинга данных с сайтов магазинов
 type Service struct {
-	storage Storage
-	queue   Queue
+	storage    Storage
+	queue      Queue
 	queueTopic string
-	logger  *logger.Logger
-	stats   *scrapingstats.Service
+	logger     *logger.Logger
+	stats      *scrapingstats.Service
+	politeness Politeness
+	selectors  SelectorHealth
+	transport  http.RoundTripper
+	userAgent  string
 }
 
 // CatalogResult результат парсинга каталога
@@ -57,15 +91,100 @@ type CatalogResult struct {
 }
 
 // New создаёт новый сервис парсеров
-func New(storage Storage, queue Queue, queueTopic string, stats *scrapingstats.Service, log *logger.Logger) *Service {
+// politeness может быть nil - тогда запросы не ограничиваются,
+// selectors может быть nil - тогда срабатывание селекторов не отслеживается
+func New(storage Storage, queue Queue, queueTopic string, stats *scrapingstats.Service, politeness Politeness, selectors SelectorHealth, log *logger.Logger) *Service {
 	if queueTopic == "" {
 		queueTopic = defaultQueueTopic
 	}
 	return &Service{
-		storage: storage,
-		queue:   queue,
+		storage:    storage,
+		queue:      queue,
 		queueTopic: queueTopic,
-		logger:  log,
-		stats:   stats,
+		logger:     log,
+		stats:      stats,
+		politeness: politeness,
+		selectors:  selectors,
+	}
+}
+
+// SetTransport подменяет HTTP транспорт Colly-парсеров.
+// Используется для воспроизведения сохранённых страниц без сети (selectorfixtures)
+func (s *Service) SetTransport(transport http.RoundTripper) {
+	s.transport = transport
+}
+
+// SetUserAgent задаёт заголовок User-Agent парсеров (politeness.Manager.UserAgent):
+// robots.txt проверяется для того же краулера, которым парсер представляется магазину
+func (s *Service) SetUserAgent(userAgent string) {
+	s.userAgent = userAgent
+}
+
+// crawlerUserAgent заголовок User-Agent для запросов к магазинам
+func (s *Service) crawlerUserAgent() string {
+	if s.userAgent == "" {
+		return politeness.UserAgentHeader(politeness.DefaultUserAgent)
+	}
+	return s.userAgent
+}
+
+// newCollector создаёт коллектор Colly с User-Agent краулера и транспортом сервиса, если он задан
+func (s *Service) newCollector(options ...colly.CollectorOption) *colly.Collector {
+	options = append([]colly.CollectorOption{colly.UserAgent(s.crawlerUserAgent())}, options...)
+	c := colly.NewCollector(options...)
+	if s.transport != nil {
+		c.WithTransport(s.transport)
+	}
+	return c
+}
+
+// acquire ждёт разрешения слоя вежливости на запрос к странице магазина
+func (s *Service) acquire(ctx context.Context, url string, shopConfig *ShopConfig, browser bool) (func(), error) {
+	if s.politeness == nil {
+		return func() {}, nil
+	}
+	return s.politeness.Acquire(ctx, politeness.Request{
+		ShopID:    shopConfig.ID,
+		RateLimit: shopConfig.RateLimit,
+		URL:       url,
+		Browser:   browser,
+	})
+}
+
+// selectorFields поля ShopConfig.Selectors, срабатывание которых отслеживается
+var selectorFields = []string{"name", "price", "image", "description", "category", "brand"}
+
+// selectorOutcomes определяет, какие из настроенных селекторов нашли данные.
+// Вызывается до применения структурированных данных и fallback по title,
+// иначе сломанный селектор будет скрыт ими
+func selectorOutcomes(selectors map[string]string, product *RawProduct, nameFromSelector bool) map[string]bool {
+	outcomes := make(map[string]bool)
+	for _, field := range selectorFields {
+		if strings.TrimSpace(selectors[field]) == "" {
+			continue
+		}
+		switch field {
+		case "name":
+			outcomes[field] = nameFromSelector
+		case "price":
+			outcomes[field] = product.Price > 0
+		case "image":
+			outcomes[field] = len(product.ImageURLs) > 0
+		case "description":
+			outcomes[field] = product.Description != ""
+		case "category":
+			outcomes[field] = product.Category != ""
+		case "brand":
+			outcomes[field] = product.Brand != ""
+		}
+	}
+	return outcomes
+}
+
+// recordSelectorOutcomes передаёт результаты селекторов детектору деградации
+func (s *Service) recordSelectorOutcomes(ctx context.Context, url string, shopConfig *ShopConfig, outcomes map[string]bool) {
+	if s.selectors == nil || len(outcomes) == 0 {
+		return
 	}
+	s.selectors.RecordOutcomes(ctx, shopConfig.ID, url, outcomes)
 }
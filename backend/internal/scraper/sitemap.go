@@ -0,0 +1,232 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/sitemap"
+)
+
+const (
+	// maxSitemapProductURLs столько адресов товаров читается из sitemap магазина за один обход
+	maxSitemapProductURLs = 100000
+)
+
+// CatalogEntry адрес товара из sitemap магазина
+type CatalogEntry struct {
+	URL     string    `json:"url"`
+	LastMod time.Time `json:"lastmod,omitempty"` // нулевое, если sitemap не указывает lastmod
+}
+
+// SitemapCatalogResult результат перечисления каталога по sitemap
+type SitemapCatalogResult struct {
+	// Entries изменившиеся с прошлого обхода адреса, сначала самые свежие
+	Entries    []CatalogEntry `json:"entries"`
+	TotalFound int            `json:"total_found"`
+	Unchanged  int            `json:"unchanged"`
+}
+
+// nonProductSitemapHints дочерние sitemap индекса с такими именами не содержат товаров
+var nonProductSitemapHints = []string{
+	"categor", "kategor", "blog", "post", "page", "stranic", "tag", "brand", "brend",
+	"author", "news", "vesti", "novosti", "manufacturer", "proizvodjac",
+}
+
+// productSitemapHints в sitemap с такими именами все адреса, кроме главной, - товары
+var productSitemapHints = []string{"product", "proizvod", "artik"}
+
+// EnumerateSitemap перечисляет адреса товаров магазина по sitemap: Selectors["sitemap_url"]
+// (через запятую) или sitemap из robots.txt. Индексы, gzip и lastmod поддерживаются
+func (s *Service) EnumerateSitemap(ctx context.Context, shopConfig *ShopConfig) ([]CatalogEntry, error) {
+	client := sitemap.New(&http.Client{
+		Timeout:   60 * time.Second,
+		Transport: &politeTransport{service: s, shop: shopConfig},
+	}, s.crawlerUserAgent())
+	client.SkipSitemap = isNonProductSitemap
+
+	sitemaps := splitSitemapURLs(shopConfig.Selectors["sitemap_url"])
+	if len(sitemaps) == 0 {
+		located, err := client.Locate(ctx, shopConfig.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate sitemap: %w", err)
+		}
+		sitemaps = located
+	}
+
+	host := siteHost(shopConfig.BaseURL)
+	seen := make(map[string]bool)
+	entries := make([]CatalogEntry, 0)
+	err := client.Walk(ctx, sitemaps, func(entry sitemap.Entry) error {
+		if seen[entry.Loc] || (host != "" && siteHost(entry.Loc) != host) {
+			return nil
+		}
+		if !s.isSitemapProductURL(entry, shopConfig) {
+			return nil
+		}
+		seen[entry.Loc] = true
+		entries = append(entries, CatalogEntry{URL: entry.Loc, LastMod: entry.LastMod})
+		if len(entries) >= maxSitemapProductURLs {
+			return sitemap.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap: %w", err)
+	}
+
+	s.logger.Info("Sitemap enumeration completed", map[string]interface{}{
+		"shop_id":      shopConfig.ID,
+		"sitemaps":     sitemaps,
+		"product_urls": len(entries),
+	})
+	return entries, nil
+}
+
+// ChangedCatalogEntries перечисляет sitemap магазина и оставляет адреса, которые ещё не парсились
+// или изменились (lastmod новее сохранённого). limit ограничивает число возвращаемых адресов (0 - без ограничений)
+func (s *Service) ChangedCatalogEntries(ctx context.Context, shopConfig *ShopConfig, limit int) (*SitemapCatalogResult, error) {
+	entries, err := s.EnumerateSitemap(ctx, shopConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := s.storage.GetCatalogLastMods(shopConfig.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog lastmods: %w", err)
+	}
+
+	changed := changedEntries(entries, known)
+	result := &SitemapCatalogResult{
+		Entries:    changed,
+		TotalFound: len(entries),
+		Unchanged:  len(entries) - len(changed),
+	}
+	if limit > 0 && len(result.Entries) > limit {
+		result.Entries = result.Entries[:limit]
+	}
+	return result, nil
+}
+
+// MarkCatalogEntrySeen запоминает lastmod адреса из sitemap, поставленного на парсинг
+func (s *Service) MarkCatalogEntrySeen(shopID string, entry CatalogEntry) error {
+	return s.storage.SaveCatalogLastMod(shopID, entry.URL, entry.LastMod)
+}
+
+// changedEntries отбирает новые адреса и адреса с более свежим lastmod.
+// Без lastmod изменения не видны: такой адрес берётся только при первом обходе
+func changedEntries(entries []CatalogEntry, known map[string]time.Time) []CatalogEntry {
+	changed := make([]CatalogEntry, 0)
+	for _, entry := range entries {
+		lastMod, ok := known[entry.URL]
+		if ok && !entry.LastMod.After(lastMod) {
+			continue
+		}
+		changed = append(changed, entry)
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].LastMod.After(changed[j].LastMod)
+	})
+	return changed
+}
+
+// isSitemapProductURL в sitemap товаров (sitemap-products.xml) доверяем имени файла,
+// в общих sitemap проверяем адрес так же, как ссылки каталога
+func (s *Service) isSitemapProductURL(entry sitemap.Entry, shopConfig *ShopConfig) bool {
+	if hasSitemapHint(entry.Sitemap, productSitemapHints) {
+		parsed, err := url.Parse(entry.Loc)
+		return err == nil && strings.Trim(parsed.Path, "/") != ""
+	}
+	return s.isProductURL(entry.Loc, shopConfig)
+}
+
+// isNonProductSitemap отсеивает sitemap категорий, блога и страниц
+func isNonProductSitemap(loc string) bool {
+	return !hasSitemapHint(loc, productSitemapHints) && hasSitemapHint(loc, nonProductSitemapHints)
+}
+
+// hasSitemapHint проверяет имя файла sitemap (без домена и каталогов) на подсказки
+func hasSitemapHint(loc string, hints []string) bool {
+	name := strings.ToLower(loc)
+	if parsed, err := url.Parse(loc); err == nil {
+		name = strings.ToLower(parsed.Path)
+	}
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	for _, hint := range hints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSitemapURLs разбирает список sitemap из конфигурации
+func splitSitemapURLs(value string) []string {
+	var urls []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			urls = append(urls, part)
+		}
+	}
+	return urls
+}
+
+// siteHost хост адреса без www
+func siteHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// politeTransport пропускает запросы к sitemap через слой вежливости магазина
+// и использует транспорт сервиса, если он подменён
+type politeTransport struct {
+	service *Service
+	shop    *ShopConfig
+}
+
+// RoundTrip реализует http.RoundTripper.
+// Слот вежливости держится до закрытия тела ответа: sitemap скачивается после RoundTrip,
+// и иначе следующий запрос к магазину шёл бы параллельно с загрузкой
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.service.acquire(req.Context(), req.URL.String(), t.shop, false)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := t.service.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody освобождает слот вежливости при закрытии тела ответа (один раз)
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close закрывает тело и освобождает слот
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,156 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/politeness"
+)
+
+func TestChangedCatalogEntries_SitemapIndex(t *testing.T) {
+	categoriesRequested := false
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("Sitemap: " + server.URL + "/sitemap_index.xml\n"))
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(`<sitemapindex>
+  <sitemap><loc>` + server.URL + `/sitemap-products-1.xml.gz</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap-categories.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap-misc.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/sitemap-products-1.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			_, _ = gz.Write([]byte(`<urlset>
+  <url><loc>` + server.URL + `/samsung-galaxy-a55</loc><lastmod>2026-10-01</lastmod></url>
+  <url><loc>` + server.URL + `/iphone-15</loc><lastmod>2026-10-05</lastmod></url>
+  <url><loc>` + server.URL + `/xiaomi-14</loc><lastmod>2026-09-01</lastmod></url>
+  <url><loc>https://other.example/tv-55</loc></url>
+  <url><loc>` + server.URL + `/</loc></url>
+</urlset>`))
+			_ = gz.Close()
+			_, _ = w.Write(buf.Bytes())
+		case "/sitemap-categories.xml":
+			categoriesRequested = true
+			http.NotFound(w, r)
+		case "/sitemap-misc.xml":
+			_, _ = w.Write([]byte(`<urlset>
+  <url><loc>` + server.URL + `/kontakt</loc></url>
+  <url><loc>` + server.URL + `/proizvod/lg-oled-55</loc></url>
+</urlset>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	storage := &catalogStorage{lastMods: map[string]time.Time{
+		server.URL + "/samsung-galaxy-a55": time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), // не изменился
+		server.URL + "/xiaomi-14":          time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),  // обновлён
+	}}
+	s := New(storage, nil, "", nil, nil, nil, logger.New("error"))
+	shop := &ShopConfig{ID: "shop-1", BaseURL: server.URL, Selectors: map[string]string{}}
+
+	result, err := s.ChangedCatalogEntries(context.Background(), shop, 0)
+	if err != nil {
+		t.Fatalf("ChangedCatalogEntries failed: %v", err)
+	}
+	if categoriesRequested {
+		t.Error("category sitemap must be skipped")
+	}
+	if result.TotalFound != 4 || result.Unchanged != 1 {
+		t.Fatalf("unexpected totals %+v", result)
+	}
+
+	want := []string{"/iphone-15", "/xiaomi-14", "/proizvod/lg-oled-55"}
+	if len(result.Entries) != len(want) {
+		t.Fatalf("expected %d changed entries, got %+v", len(want), result.Entries)
+	}
+	for i, path := range want {
+		if result.Entries[i].URL != server.URL+path {
+			t.Errorf("entry %d: got %s, want %s", i, result.Entries[i].URL, path)
+		}
+	}
+
+	limited, err := s.ChangedCatalogEntries(context.Background(), shop, 1)
+	if err != nil || len(limited.Entries) != 1 || limited.TotalFound != 4 {
+		t.Fatalf("limit must cap entries only, got %+v (%v)", limited, err)
+	}
+
+	if err := s.MarkCatalogEntrySeen(shop.ID, result.Entries[0]); err != nil {
+		t.Fatalf("MarkCatalogEntrySeen failed: %v", err)
+	}
+	if !storage.lastMods[server.URL+"/iphone-15"].Equal(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("lastmod was not saved: %v", storage.lastMods)
+	}
+}
+
+func TestChangedEntries_WithoutLastMod(t *testing.T) {
+	known := map[string]time.Time{"https://shop.example/a": {}}
+	entries := []CatalogEntry{{URL: "https://shop.example/a"}, {URL: "https://shop.example/b"}}
+
+	changed := changedEntries(entries, known)
+	if len(changed) != 1 || changed[0].URL != "https://shop.example/b" {
+		t.Errorf("known URL without lastmod must not be rescheduled, got %+v", changed)
+	}
+}
+
+// catalogStorage хранит lastmod адресов sitemap в памяти
+type catalogStorage struct {
+	Storage
+	lastMods map[string]time.Time
+}
+
+func (c *catalogStorage) GetCatalogLastMods(shopID string) (map[string]time.Time, error) {
+	return c.lastMods, nil
+}
+
+func (c *catalogStorage) SaveCatalogLastMod(shopID, url string, lastMod time.Time) error {
+	c.lastMods[url] = lastMod
+	return nil
+}
+
+// countingPoliteness считает выданные и освобождённые слоты
+type countingPoliteness struct {
+	acquired int
+	released int
+}
+
+func (c *countingPoliteness) Acquire(ctx context.Context, req politeness.Request) (func(), error) {
+	c.acquired++
+	return func() { c.released++ }, nil
+}
+
+func TestPoliteTransport_ReleasesOnBodyClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<urlset></urlset>"))
+	}))
+	defer server.Close()
+
+	gate := &countingPoliteness{}
+	s := New(nil, nil, "", nil, gate, nil, logger.New("error"))
+	client := &http.Client{Transport: &politeTransport{service: s, shop: &ShopConfig{ID: "shop-1"}}}
+
+	resp, err := client.Get(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if gate.acquired != 1 || gate.released != 0 {
+		t.Fatalf("slot must be held while the body is read, got acquired=%d released=%d", gate.acquired, gate.released)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	_ = resp.Body.Close()
+	if gate.released != 1 {
+		t.Errorf("slot must be released once on close, got %d", gate.released)
+	}
+}
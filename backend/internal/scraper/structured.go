@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"github.com/solomonczyk/izborator/internal/structured"
+)
+
+// extractStructuredData разбирает семантическую разметку страницы товара.
+// Ошибки разбора не фатальны: тогда работают только селекторы магазина.
+func (s *Service) extractStructuredData(html []byte, url string) *structured.Product {
+	if len(html) == 0 {
+		return nil
+	}
+	data, err := structured.Extract(html, url)
+	if err != nil {
+		s.logger.Warn("Failed to extract structured data", map[string]interface{}{
+			"url":   url,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if data != nil {
+		s.logger.Debug("Found structured data", map[string]interface{}{
+			"url":    url,
+			"origin": data.Origin,
+			"price":  data.Price,
+		})
+	}
+	return data
+}
+
+// applyStructuredData переносит структурированные данные в товар.
+// Разметка страницы - основной источник, значения селекторов остаются
+// только для полей, которых в ней нет. Исключение - og:title и og:description:
+// это часто заголовок вкладки и SEO-текст, поэтому они лишь заполняют пустые поля.
+func applyStructuredData(product *RawProduct, data *structured.Product) {
+	if data == nil {
+		return
+	}
+
+	setText := func(dst *string, value, field string) {
+		if value == "" {
+			return
+		}
+		if *dst != "" && data.Origin[field] == structured.SourceOpenGraph {
+			return
+		}
+		*dst = value
+	}
+	setText(&product.Name, data.Name, structured.FieldName)
+	setText(&product.Description, data.Description, structured.FieldDescription)
+	setText(&product.Brand, data.Brand, structured.FieldBrand)
+	setText(&product.Category, data.Category, structured.FieldCategory)
+
+	if data.HasPrice() {
+		product.Price = data.Price
+		product.Currency = data.Currency
+		if product.Currency == "" {
+			product.Currency = "RSD"
+		}
+	}
+
+	if len(data.Images) > 0 {
+		images := append([]string{}, data.Images...)
+		for _, img := range product.ImageURLs {
+			if !containsString(images, img) {
+				images = append(images, img)
+			}
+		}
+		product.ImageURLs = images
+	}
+
+	if data.Availability != structured.AvailabilityUnknown {
+		product.InStock = data.Availability != structured.AvailabilityOutOfStock
+	}
+
+	// Идентификаторы и диапазон цен сохраняются в сыром объекте для сопоставления
+	if product.RawPayload == nil {
+		product.RawPayload = make(map[string]interface{})
+	}
+	setPayload := func(key string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				return
+			}
+		case float64:
+			if v == 0 {
+				return
+			}
+		}
+		product.RawPayload[key] = value
+	}
+	setPayload("sku", data.SKU)
+	setPayload("gtin", data.GTIN)
+	setPayload("mpn", data.MPN)
+	setPayload("availability", string(data.Availability))
+	setPayload("price_low", data.LowPrice)
+	setPayload("price_high", data.HighPrice)
+
+	sources := make(map[string]string, len(data.Origin))
+	for field, source := range data.Origin {
+		sources[field] = string(source)
+	}
+	product.RawPayload["structured_sources"] = sources
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
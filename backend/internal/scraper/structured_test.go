@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/structured"
+)
+
+func TestApplyStructuredData(t *testing.T) {
+	product := &RawProduct{
+		Name:      "Selector name",
+		Price:     100,
+		Currency:  "RSD",
+		ImageURLs: []string{"https://shop.example/selector.jpg"},
+		InStock:   true,
+	}
+	data := &structured.Product{
+		Name:         "Markup name",
+		Description:  "OG description",
+		Brand:        "Bosch",
+		GTIN:         "4006381333931",
+		Images:       []string{"https://shop.example/markup.jpg"},
+		Price:        90,
+		LowPrice:     90,
+		HighPrice:    120,
+		Currency:     "EUR",
+		Availability: structured.AvailabilityOutOfStock,
+		Origin: map[string]structured.Source{
+			structured.FieldName:        structured.SourceJSONLD,
+			structured.FieldDescription: structured.SourceOpenGraph,
+			structured.FieldPrice:       structured.SourceJSONLD,
+		},
+	}
+
+	applyStructuredData(product, data)
+
+	if product.Name != "Markup name" || product.Price != 90 || product.Currency != "EUR" {
+		t.Errorf("structured values must win over selectors: %+v", product)
+	}
+	if product.Description != "OG description" || product.Brand != "Bosch" {
+		t.Errorf("empty fields must be filled: %+v", product)
+	}
+	if product.InStock {
+		t.Error("expected product out of stock")
+	}
+	if len(product.ImageURLs) != 2 || product.ImageURLs[0] != "https://shop.example/markup.jpg" {
+		t.Errorf("unexpected images %v", product.ImageURLs)
+	}
+	if product.RawPayload["gtin"] != "4006381333931" || product.RawPayload["price_high"] != 120.0 {
+		t.Errorf("unexpected raw payload %v", product.RawPayload)
+	}
+}
+
+func TestApplyStructuredData_OpenGraphTitleDoesNotOverrideSelector(t *testing.T) {
+	product := &RawProduct{Name: "Selector name"}
+	data := &structured.Product{
+		Name:   "Page title | Shop",
+		Origin: map[string]structured.Source{structured.FieldName: structured.SourceOpenGraph},
+	}
+
+	applyStructuredData(product, data)
+
+	if product.Name != "Selector name" {
+		t.Errorf("og:title must not override selector name, got %q", product.Name)
+	}
+}
@@ -0,0 +1,17 @@
+package selectorfixtures
+
+import "errors"
+
+var (
+	// ErrInvalidFixture фикстура без URL, HTML или с неизвестным типом страницы
+	ErrInvalidFixture = errors.New("invalid selector fixture")
+
+	// ErrShopNotFound в каталоге фикстур нет магазина
+	ErrShopNotFound = errors.New("shop fixtures not found")
+
+	// ErrNoFixtures в каталоге нет ни одной фикстуры
+	ErrNoFixtures = errors.New("no selector fixtures")
+
+	// errNoCatalogSelector у магазина не задан catalog_product_link
+	errNoCatalogSelector = errors.New("catalog_product_link selector is not configured")
+)
@@ -0,0 +1,94 @@
+package selectorfixtures
+
+import (
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/scraper"
+)
+
+// Kind тип сохранённой страницы
+type Kind string
+
+const (
+	// KindProduct страница товара, проверяется ParseProduct
+	KindProduct Kind = "product"
+	// KindCatalog страница каталога, проверяется ParseCatalog
+	KindCatalog Kind = "catalog"
+)
+
+// Valid проверяет, что тип страницы известен
+func (k Kind) Valid() bool {
+	return k == KindProduct || k == KindCatalog
+}
+
+// ExpectedProduct поля RawProduct, которые задают селекторы магазина
+type ExpectedProduct struct {
+	ExternalID  string   `json:"external_id,omitempty"`
+	Name        string   `json:"name"`
+	Price       float64  `json:"price"`
+	Currency    string   `json:"currency,omitempty"`
+	Brand       string   `json:"brand,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ImageURLs   []string `json:"image_urls,omitempty"`
+	InStock     bool     `json:"in_stock"`
+}
+
+// ExpectedCatalog ссылки на товары, найденные на странице каталога
+type ExpectedCatalog struct {
+	ProductURLs []string `json:"product_urls"`
+}
+
+// Fixture сохранённая страница магазина и ожидаемый результат парсинга
+type Fixture struct {
+	Name       string           `json:"name"`
+	Kind       Kind             `json:"kind"`
+	URL        string           `json:"url"`
+	HTMLFile   string           `json:"html_file"`
+	CapturedAt time.Time        `json:"captured_at"`
+	Product    *ExpectedProduct `json:"product,omitempty"`
+	Catalog    *ExpectedCatalog `json:"catalog,omitempty"`
+
+	// html содержимое HTMLFile, загружается вместе с манифестом
+	html []byte
+}
+
+// Suite фикстуры одного магазина и конфигурация, с которой они проверяются
+type Suite struct {
+	Dir      string              `json:"-"`
+	Shop     *scraper.ShopConfig `json:"shop"`
+	Fixtures []*Fixture          `json:"-"`
+
+	// err ошибка получения актуальной конфигурации магазина (UseLiveSelectors)
+	err error
+}
+
+// FieldDiff расхождение одного поля с ожидаемым значением
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Result результат проверки одной фикстуры
+type Result struct {
+	Shop    string      `json:"shop"`
+	Fixture string      `json:"fixture"`
+	Kind    Kind        `json:"kind"`
+	URL     string      `json:"url"`
+	Passed  bool        `json:"passed"`
+	Error   string      `json:"error,omitempty"`
+	Diffs   []FieldDiff `json:"diffs,omitempty"`
+}
+
+// Report результат прогона всех фикстур
+type Report struct {
+	Results []*Result `json:"results"`
+	Passed  int       `json:"passed"`
+	Failed  int       `json:"failed"`
+}
+
+// OK все фикстуры прошли
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
@@ -0,0 +1,327 @@
+package selectorfixtures
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/scraper"
+)
+
+// Runner применяет селекторы магазинов к сохранённым страницам без обращения к сети
+type Runner struct {
+	logger *logger.Logger
+}
+
+// NewRunner создаёт раннер фикстур
+func NewRunner(log *logger.Logger) *Runner {
+	if log == nil {
+		log = logger.New("error")
+	}
+	return &Runner{logger: log}
+}
+
+// ShopSource источник актуальной конфигурации магазинов (scraper.Storage)
+type ShopSource interface {
+	GetShopConfig(shopID string) (*scraper.ShopConfig, error)
+}
+
+// UseLiveSelectors заменяет селекторы, сохранённые в shop.json при снятии страниц,
+// актуальными селекторами магазинов и возвращает наборы для проверки. Магазины, которых
+// нет в источнике (синтетический example-shop), пропускаются; фикстуры магазина,
+// конфигурацию которого не удалось загрузить по другой причине, считаются упавшими
+func UseLiveSelectors(source ShopSource, suites []*Suite) []*Suite {
+	checked := make([]*Suite, 0, len(suites))
+	for _, suite := range suites {
+		shop, err := source.GetShopConfig(suite.Shop.ID)
+		switch {
+		case errors.Is(err, scraper.ErrShopNotFound):
+			continue
+		case err != nil:
+			suite.err = fmt.Errorf("failed to load live shop config: %w", err)
+		default:
+			suite.Shop.Selectors = shop.Selectors
+		}
+		checked = append(checked, suite)
+	}
+	return checked
+}
+
+// Run проверяет все фикстуры с селекторами Suite.Shop и сравнивает результат с ожидаемым
+func (r *Runner) Run(ctx context.Context, suites []*Suite) *Report {
+	report := &Report{}
+	for _, suite := range suites {
+		for _, fixture := range suite.Fixtures {
+			var result *Result
+			if suite.err != nil {
+				result = &Result{
+					Shop:    suite.Shop.Name,
+					Fixture: fixture.Name,
+					Kind:    fixture.Kind,
+					URL:     fixture.URL,
+					Error:   suite.err.Error(),
+				}
+			} else {
+				result = r.check(ctx, suite.Shop, fixture)
+			}
+			if result.Passed {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report
+}
+
+// Expect разбирает страницу текущими селекторами магазина и записывает результат как ожидаемый
+func (r *Runner) Expect(ctx context.Context, shop *scraper.ShopConfig, fixture *Fixture, html []byte) error {
+	fixture.html = html
+	switch fixture.Kind {
+	case KindProduct:
+		parser, recorder := r.parser(fixture)
+		product, err := parser.ParseProduct(ctx, fixture.URL, shop)
+		if err != nil {
+			return fmt.Errorf("current selectors do not extract the product: %w", err)
+		}
+		fixture.Product = expectedProduct(product)
+		// Ожидаемым становится только то, что нашли селекторы, а не структурированные данные
+		if diffs := selectorDiffs(shop.Selectors, recorder.outcomes, fixture.Product); len(diffs) > 0 {
+			return fmt.Errorf("current selector for %s does not match, the value comes from a fallback", diffs[0].Field)
+		}
+	case KindCatalog:
+		if strings.TrimSpace(shop.Selectors["catalog_product_link"]) == "" {
+			return errNoCatalogSelector
+		}
+		parser, _ := r.parser(fixture)
+		catalog, err := parser.ParseCatalog(ctx, fixture.URL, shop, 1)
+		if err != nil {
+			return fmt.Errorf("current selectors do not extract the catalog: %w", err)
+		}
+		fixture.Catalog = &ExpectedCatalog{ProductURLs: catalog.ProductURLs}
+	default:
+		return ErrInvalidFixture
+	}
+	return nil
+}
+
+// check проверяет одну фикстуру
+func (r *Runner) check(ctx context.Context, shop *scraper.ShopConfig, fixture *Fixture) *Result {
+	result := &Result{
+		Shop:    shop.Name,
+		Fixture: fixture.Name,
+		Kind:    fixture.Kind,
+		URL:     fixture.URL,
+	}
+
+	switch fixture.Kind {
+	case KindProduct:
+		expected := fixture.Product
+		if expected == nil {
+			expected = &ExpectedProduct{}
+		}
+		// Ошибка парсинга не прерывает проверку: расхождения по полям показывают, какой селектор сломался
+		parser, recorder := r.parser(fixture)
+		product, err := parser.ParseProduct(ctx, fixture.URL, shop)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Diffs = diffProduct(expected, expectedProduct(product))
+		// Совпадение значений недостаточно: поле могли заполнить структурированные данные
+		// или title при сломанном селекторе
+		result.Diffs = append(result.Diffs, selectorDiffs(shop.Selectors, recorder.outcomes, expected)...)
+	case KindCatalog:
+		expected := fixture.Catalog
+		if expected == nil {
+			expected = &ExpectedCatalog{}
+		}
+		// Без catalog_product_link ссылки находят универсальные селекторы парсера
+		if strings.TrimSpace(shop.Selectors["catalog_product_link"]) == "" {
+			result.Error = errNoCatalogSelector.Error()
+			break
+		}
+		parser, _ := r.parser(fixture)
+		catalog, err := parser.ParseCatalog(ctx, fixture.URL, shop, 1)
+		if err != nil {
+			result.Error = err.Error()
+			catalog = &scraper.CatalogResult{}
+		}
+		result.Diffs = diffList("product_urls", expected.ProductURLs, catalog.ProductURLs)
+	default:
+		result.Error = ErrInvalidFixture.Error()
+	}
+
+	result.Passed = result.Error == "" && len(result.Diffs) == 0
+	return result
+}
+
+// parser парсер магазина, которому вместо сети отдаётся страница фикстуры.
+// recorder получает результаты селекторов до применения fallback
+func (r *Runner) parser(fixture *Fixture) (*scraper.Service, *selectorRecorder) {
+	recorder := &selectorRecorder{}
+	service := scraper.New(nil, nil, "", nil, nil, recorder, r.logger)
+	service.SetTransport(&replayTransport{url: fixture.URL, html: fixture.html})
+	return service, recorder
+}
+
+// selectorRecorder запоминает, какие селекторы магазина нашли данные (scraper.SelectorHealth)
+type selectorRecorder struct {
+	outcomes map[string]bool
+}
+
+// RecordOutcomes реализует scraper.SelectorHealth
+func (r *selectorRecorder) RecordOutcomes(ctx context.Context, shopID, url string, outcomes map[string]bool) {
+	r.outcomes = outcomes
+}
+
+// selectorDiffs возвращает поля, которые ожидаются непустыми, но настроенный для них
+// селектор ничего не нашёл
+func selectorDiffs(selectors map[string]string, outcomes map[string]bool, expected *ExpectedProduct) []FieldDiff {
+	present := map[string]bool{
+		"name":        expected.Name != "",
+		"price":       expected.Price > 0,
+		"image":       len(expected.ImageURLs) > 0,
+		"description": expected.Description != "",
+		"category":    expected.Category != "",
+		"brand":       expected.Brand != "",
+	}
+
+	var diffs []FieldDiff
+	for _, field := range []string{"name", "price", "image", "description", "category", "brand"} {
+		selector := strings.TrimSpace(selectors[field])
+		if selector == "" || !present[field] || outcomes[field] {
+			continue
+		}
+		diffs = append(diffs, FieldDiff{
+			Field:    "selector:" + field,
+			Expected: selector,
+			Actual:   "no match",
+		})
+	}
+	return diffs
+}
+
+// replayTransport отвечает сохранённой страницей на запрос её URL и 404 на остальные
+type replayTransport struct {
+	url  string
+	html []byte
+}
+
+// RoundTrip реализует http.RoundTripper
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := http.StatusNotFound
+	body := []byte{}
+	if req.URL.String() == t.url {
+		status = http.StatusOK
+		body = t.html
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        http.StatusText(status),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// expectedProduct оставляет поля RawProduct, которые задают селекторы
+func expectedProduct(product *scraper.RawProduct) *ExpectedProduct {
+	if product == nil {
+		return &ExpectedProduct{}
+	}
+	return &ExpectedProduct{
+		ExternalID:  product.ExternalID,
+		Name:        product.Name,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Brand:       product.Brand,
+		Category:    product.Category,
+		Description: product.Description,
+		ImageURLs:   product.ImageURLs,
+		InStock:     product.InStock,
+	}
+}
+
+// diffProduct сравнивает товар с ожидаемым по полям
+func diffProduct(expected, actual *ExpectedProduct) []FieldDiff {
+	var diffs []FieldDiff
+	addString := func(field, want, got string) {
+		if want != got {
+			diffs = append(diffs, FieldDiff{Field: field, Expected: want, Actual: got})
+		}
+	}
+
+	addString("external_id", expected.ExternalID, actual.ExternalID)
+	addString("name", expected.Name, actual.Name)
+	if math.Abs(expected.Price-actual.Price) >= 0.005 {
+		diffs = append(diffs, FieldDiff{
+			Field:    "price",
+			Expected: strconv.FormatFloat(expected.Price, 'f', 2, 64),
+			Actual:   strconv.FormatFloat(actual.Price, 'f', 2, 64),
+		})
+	}
+	addString("currency", expected.Currency, actual.Currency)
+	addString("brand", expected.Brand, actual.Brand)
+	addString("category", expected.Category, actual.Category)
+	addString("description", expected.Description, actual.Description)
+	addString("in_stock", strconv.FormatBool(expected.InStock), strconv.FormatBool(actual.InStock))
+	diffs = append(diffs, diffList("image_urls", expected.ImageURLs, actual.ImageURLs)...)
+	return diffs
+}
+
+// diffList возвращает пропавшие (actual пусто) и лишние (expected пусто) элементы списка
+func diffList(field string, expected, actual []string) []FieldDiff {
+	expectedSet := make(map[string]bool, len(expected))
+	for _, value := range expected {
+		expectedSet[value] = true
+	}
+	actualSet := make(map[string]bool, len(actual))
+	for _, value := range actual {
+		actualSet[value] = true
+	}
+
+	var diffs []FieldDiff
+	for _, value := range expected {
+		if !actualSet[value] {
+			diffs = append(diffs, FieldDiff{Field: field, Expected: value})
+		}
+	}
+	for _, value := range actual {
+		if !expectedSet[value] {
+			diffs = append(diffs, FieldDiff{Field: field, Actual: value})
+		}
+	}
+	return diffs
+}
+
+// Format выводит отчёт: расхождения по полям для упавших фикстур и итог
+func (r *Report) Format() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s  %s/%s (%s)\n", status, result.Shop, result.Fixture, result.Kind)
+		if result.Error != "" {
+			fmt.Fprintf(&b, "      error: %s\n", result.Error)
+		}
+		for _, diff := range result.Diffs {
+			fmt.Fprintf(&b, "      %-12s expected %q, got %q\n", diff.Field, diff.Expected, diff.Actual)
+		}
+	}
+	fmt.Fprintf(&b, "\n%d passed, %d failed\n", r.Passed, r.Failed)
+	return b.String()
+}
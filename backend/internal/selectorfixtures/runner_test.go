@@ -0,0 +1,199 @@
+package selectorfixtures
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+	"github.com/solomonczyk/izborator/internal/scraper"
+)
+
+// fixturesDir фикстуры, которые проверяет test-selectors -replay в CI
+const fixturesDir = "../../testdata/selector-fixtures"
+
+func TestFixtures(t *testing.T) {
+	suites, err := Load(fixturesDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(suites) == 0 {
+		t.Fatal("expected at least one fixture suite")
+	}
+
+	report := NewRunner(logger.New("error")).Run(context.Background(), suites)
+	if !report.OK() {
+		t.Fatalf("selector fixtures failed:\n%s", report.Format())
+	}
+}
+
+func TestRun_ReportsFieldDiffs(t *testing.T) {
+	suite, err := LoadSuite(filepath.Join(fixturesDir, "example-shop"))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	shop := *suite.Shop
+	shop.Selectors = map[string]string{}
+	for field, selector := range suite.Shop.Selectors {
+		shop.Selectors[field] = selector
+	}
+	shop.Selectors["brand"] = ".missing-brand"
+	shop.Selectors["catalog_product_link"] = ".product-card:first-child a"
+	suite.Shop = &shop
+
+	report := NewRunner(logger.New("error")).Run(context.Background(), []*Suite{suite})
+	if report.OK() || report.Failed != 2 {
+		t.Fatalf("expected both fixtures to fail, got:\n%s", report.Format())
+	}
+
+	for _, result := range report.Results {
+		switch result.Kind {
+		case KindProduct:
+			if len(result.Diffs) != 2 || result.Diffs[0].Field != "brand" || result.Diffs[0].Expected != "Samsung" ||
+				result.Diffs[1].Field != "selector:brand" {
+				t.Errorf("expected brand value and selector diffs, got %+v", result.Diffs)
+			}
+		case KindCatalog:
+			if len(result.Diffs) != 2 || result.Diffs[0].Actual != "" {
+				t.Errorf("expected two missing product URLs, got %+v", result.Diffs)
+			}
+		}
+	}
+}
+
+// structuredHTML страница, где бренд есть и в разметке, и в JSON-LD
+const structuredHTML = `<html><head>
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"Product","name":"Phone X","brand":{"@type":"Brand","name":"Acme"},
+"offers":{"@type":"Offer","price":"12999","priceCurrency":"RSD","availability":"https://schema.org/InStock"}}</script>
+</head><body><h1 class="title">Phone X</h1><div class="maker">Acme</div><span class="price">12.999 RSD</span></body></html>`
+
+func TestRun_FailsWhenFallbackHidesBrokenSelector(t *testing.T) {
+	shop := &scraper.ShopConfig{
+		ID:        "acme",
+		Name:      "Acme",
+		BaseURL:   "https://acme.example",
+		Selectors: map[string]string{"name": "h1.title", "price": ".price", "brand": ".maker"},
+	}
+	fixture := &Fixture{Name: "product-phone-x", Kind: KindProduct, URL: "https://acme.example/p/phone-x"}
+	runner := NewRunner(logger.New("error"))
+	if err := runner.Expect(context.Background(), shop, fixture, []byte(structuredHTML)); err != nil {
+		t.Fatalf("Expect failed: %v", err)
+	}
+	if fixture.Product.Brand != "Acme" {
+		t.Fatalf("unexpected expected product %+v", fixture.Product)
+	}
+
+	// Разметка бренда сменилась, но JSON-LD по-прежнему отдаёт то же значение
+	broken := *shop
+	broken.Selectors = map[string]string{"name": "h1.title", "price": ".price", "brand": ".brand-name"}
+	report := runner.Run(context.Background(), []*Suite{{Shop: &broken, Fixtures: []*Fixture{fixture}}})
+	if report.OK() {
+		t.Fatalf("expected the broken brand selector to fail, got:\n%s", report.Format())
+	}
+	diffs := report.Results[0].Diffs
+	if len(diffs) != 1 || diffs[0].Field != "selector:brand" || diffs[0].Expected != ".brand-name" {
+		t.Errorf("expected only selector:brand diff, got %+v", diffs)
+	}
+
+	if err := runner.Expect(context.Background(), &broken, &Fixture{Kind: KindProduct, URL: fixture.URL}, []byte(structuredHTML)); err == nil {
+		t.Error("expected Expect to refuse values that come from structured data")
+	}
+}
+
+type shopSource map[string]*scraper.ShopConfig
+
+func (s shopSource) GetShopConfig(shopID string) (*scraper.ShopConfig, error) {
+	if shop, ok := s[shopID]; ok {
+		return shop, nil
+	}
+	if shopID == "broken-shop" {
+		return nil, errors.New("connection refused")
+	}
+	return nil, scraper.ErrShopNotFound
+}
+
+func TestUseLiveSelectors(t *testing.T) {
+	suite, err := LoadSuite(filepath.Join(fixturesDir, "example-shop"))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	live := map[string]string{}
+	for field, selector := range suite.Shop.Selectors {
+		live[field] = selector
+	}
+	live["price"] = ".price-new"
+
+	suites := UseLiveSelectors(shopSource{"example-shop": {ID: "example-shop", Selectors: live}}, []*Suite{suite})
+	report := NewRunner(logger.New("error")).Run(context.Background(), suites)
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Fatalf("expected the product fixture to fail with live selectors, got:\n%s", report.Format())
+	}
+
+	missing, err := LoadSuite(filepath.Join(fixturesDir, "example-shop"))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	if suites := UseLiveSelectors(shopSource{}, []*Suite{missing}); len(suites) != 0 {
+		t.Errorf("expected a shop missing from the source to be skipped, got %d suites", len(suites))
+	}
+
+	broken, err := LoadSuite(filepath.Join(fixturesDir, "example-shop"))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	broken.Shop.ID = "broken-shop"
+	suites = UseLiveSelectors(shopSource{}, []*Suite{broken})
+	report = NewRunner(logger.New("error")).Run(context.Background(), suites)
+	if report.Failed != len(broken.Fixtures) || report.Results[0].Error == "" {
+		t.Errorf("expected fixtures of a shop whose config failed to load to fail, got:\n%s", report.Format())
+	}
+}
+
+func TestLoad_NoFixtures(t *testing.T) {
+	if _, err := Load(t.TempDir()); !errors.Is(err, ErrNoFixtures) {
+		t.Errorf("expected ErrNoFixtures, got %v", err)
+	}
+}
+
+func TestSaveAndExpect(t *testing.T) {
+	source, err := LoadSuite(filepath.Join(fixturesDir, "example-shop"))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	html, err := os.ReadFile(filepath.Join(source.Dir, "product-samsung-galaxy-a55-128gb.html"))
+	if err != nil {
+		t.Fatalf("failed to read html: %v", err)
+	}
+
+	runner := NewRunner(logger.New("error"))
+	fixture := &Fixture{Kind: KindProduct, URL: "https://shop.example/p/samsung-galaxy-a55-128gb?ref=home"}
+	if err := runner.Expect(context.Background(), source.Shop, fixture, html); err != nil {
+		t.Fatalf("Expect failed: %v", err)
+	}
+	if fixture.Product.Price != 42999 || fixture.Product.Brand != "Samsung" {
+		t.Errorf("unexpected expected product %+v", fixture.Product)
+	}
+
+	dir := t.TempDir()
+	shopDir, err := Save(dir, source.Shop, fixture, html)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if filepath.Base(shopDir) != "example-shop" || fixture.Name != "product-samsung-galaxy-a55-128gb" {
+		t.Errorf("unexpected layout %s/%s", shopDir, fixture.Name)
+	}
+
+	suites, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if report := runner.Run(context.Background(), suites); !report.OK() || report.Passed != 1 {
+		t.Errorf("saved fixture must pass:\n%s", report.Format())
+	}
+
+	if _, err := Save(dir, &scraper.ShopConfig{ID: "x"}, &Fixture{Kind: "page", URL: "https://x"}, html); err == nil {
+		t.Error("expected error for unknown fixture kind")
+	}
+}
@@ -0,0 +1,159 @@
+package selectorfixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/scraper"
+)
+
+// Раскладка каталога фикстур:
+//
+//	<dir>/<магазин>/shop.json      - scraper.ShopConfig, селекторы которого проверяются
+//	<dir>/<магазин>/<name>.html    - сохранённая страница
+//	<dir>/<магазин>/<name>.json    - манифест Fixture с ожидаемым результатом
+const shopFile = "shop.json"
+
+var nonSlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Load загружает фикстуры всех магазинов каталога
+func Load(dir string) ([]*Suite, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures dir: %w", err)
+	}
+
+	var suites []*Suite
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		suite, err := LoadSuite(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, suite)
+	}
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoFixtures, dir)
+	}
+	return suites, nil
+}
+
+// LoadSuite загружает фикстуры одного магазина
+func LoadSuite(dir string) (*Suite, error) {
+	suite := &Suite{Dir: dir}
+	if err := readJSON(filepath.Join(dir, shopFile), &suite.Shop); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrShopNotFound, dir)
+		}
+		return nil, err
+	}
+
+	manifests, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	sort.Strings(manifests)
+
+	for _, path := range manifests {
+		if filepath.Base(path) == shopFile {
+			continue
+		}
+		var fixture Fixture
+		if err := readJSON(path, &fixture); err != nil {
+			return nil, err
+		}
+		if !fixture.Kind.Valid() || fixture.URL == "" || fixture.HTMLFile == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFixture, path)
+		}
+		fixture.html, err = os.ReadFile(filepath.Join(dir, fixture.HTMLFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture html: %w", err)
+		}
+		suite.Fixtures = append(suite.Fixtures, &fixture)
+	}
+	return suite, nil
+}
+
+// Save сохраняет страницу и манифест фикстуры вместе с текущей конфигурацией магазина.
+// Возвращает каталог магазина
+func Save(dir string, shop *scraper.ShopConfig, fixture *Fixture, html []byte) (string, error) {
+	if !fixture.Kind.Valid() || fixture.URL == "" || len(html) == 0 {
+		return "", ErrInvalidFixture
+	}
+	if fixture.Name == "" {
+		fixture.Name = FixtureName(fixture.Kind, fixture.URL)
+	}
+	fixture.HTMLFile = fixture.Name + ".html"
+
+	shopDir := filepath.Join(dir, ShopDirName(shop))
+	if err := os.MkdirAll(shopDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create fixtures dir: %w", err)
+	}
+
+	if err := writeJSON(filepath.Join(shopDir, shopFile), shop); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(shopDir, fixture.HTMLFile), html, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write fixture html: %w", err)
+	}
+	if err := writeJSON(filepath.Join(shopDir, fixture.Name+".json"), fixture); err != nil {
+		return "", err
+	}
+	return shopDir, nil
+}
+
+// ShopDirName имя каталога магазина: название в виде slug, иначе ID
+func ShopDirName(shop *scraper.ShopConfig) string {
+	if name := slug(shop.Name); name != "" {
+		return name
+	}
+	return slug(shop.ID)
+}
+
+// FixtureName имя фикстуры по типу и последнему сегменту пути страницы
+func FixtureName(kind Kind, pageURL string) string {
+	name := ""
+	if parsed, err := url.Parse(pageURL); err == nil {
+		segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		name = slug(segments[len(segments)-1])
+	}
+	if name == "" {
+		name = "index"
+	}
+	return string(kind) + "-" + name
+}
+
+// slug приводит строку к виду для имени файла
+func slug(value string) string {
+	return strings.Trim(nonSlugRegex.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package selectorhealth
+
+import "errors"
+
+var (
+	// ErrShopNotFound магазин не найден
+	ErrShopNotFound = errors.New("shop not found")
+
+	// ErrNoPreviousConfig у магазина нет сохранённой конфигурации для отката
+	ErrNoPreviousConfig = errors.New("no previous selector config to roll back to")
+
+	// ErrInvalidStatus неизвестный статус селекторов
+	ErrInvalidStatus = errors.New("invalid selector health status")
+)
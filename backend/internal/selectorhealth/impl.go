@@ -0,0 +1,155 @@
+package selectorhealth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecordOutcomes сохраняет результат селекторов за один парсинг и пересчитывает состояние магазина.
+// Ошибки только логируются: мониторинг не должен ломать парсинг
+func (s *Service) RecordOutcomes(ctx context.Context, shopID, url string, outcomes map[string]bool) {
+	if shopID == "" || len(outcomes) == 0 {
+		return
+	}
+
+	obs := &Observation{
+		ShopID:     shopID,
+		URL:        url,
+		Outcomes:   outcomes,
+		ObservedAt: time.Now(),
+	}
+	if err := s.storage.SaveObservation(ctx, obs, s.thresholds.Window); err != nil {
+		s.logger.Warn("selectorhealth: failed to save observation", map[string]interface{}{
+			"shop_id": shopID,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := s.evaluate(ctx, shopID); err != nil {
+		s.logger.Warn("selectorhealth: failed to evaluate shop", map[string]interface{}{
+			"shop_id": shopID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// evaluate пересчитывает статистику селекторов в окне и помечает магазин degraded,
+// если обязательный селектор срабатывает реже порога
+func (s *Service) evaluate(ctx context.Context, shopID string) error {
+	health, err := s.storage.GetHealth(ctx, shopID)
+	if err != nil {
+		return fmt.Errorf("failed to get health: %w", err)
+	}
+	// Магазин уже в очереди авто-конфигурации или ждёт ручной правки
+	if health.Status != StatusHealthy {
+		return nil
+	}
+
+	// После авто-конфигурации окно начинается заново, старые промахи не учитываются
+	observations, err := s.storage.RecentObservations(ctx, shopID, health.ReconfiguredAt, s.thresholds.Window)
+	if err != nil {
+		return fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	fields, degraded := evaluateFields(observations, s.thresholds)
+	health.Fields = fields
+	health.DegradedFields = degraded
+	health.Reason = ""
+	if len(degraded) > 0 {
+		health.Status = StatusDegraded
+		health.Reason = degradedReason(fields, degraded, s.thresholds)
+
+		s.logger.Warn("selectorhealth: shop selectors degraded", map[string]interface{}{
+			"shop_id":         shopID,
+			"degraded_fields": degraded,
+			"reason":          health.Reason,
+		})
+	}
+
+	if err := s.storage.SaveHealth(ctx, health); err != nil {
+		return fmt.Errorf("failed to save health: %w", err)
+	}
+	return nil
+}
+
+// ListHealth возвращает состояние селекторов магазинов
+func (s *Service) ListHealth(ctx context.Context, status Status) ([]*ShopHealth, error) {
+	switch status {
+	case "", StatusHealthy, StatusDegraded, StatusFailed:
+	default:
+		return nil, ErrInvalidStatus
+	}
+
+	items, err := s.storage.ListHealth(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list selector health: %w", err)
+	}
+	return items, nil
+}
+
+// Rollback возвращает магазину версию селекторов, действовавшую до текущей
+func (s *Service) Rollback(ctx context.Context, shopID string) error {
+	if strings.TrimSpace(shopID) == "" {
+		return ErrShopNotFound
+	}
+
+	if err := s.storage.RollbackSelectors(ctx, shopID); err != nil {
+		return err
+	}
+
+	s.logger.Info("selectorhealth: shop selectors rolled back", map[string]interface{}{
+		"shop_id": shopID,
+	})
+	return nil
+}
+
+// evaluateFields считает долю срабатываний каждого селектора в окне.
+// Деградировавшими считаются обязательные поля ниже порога при достаточном числе парсингов
+func evaluateFields(observations []*Observation, thresholds Thresholds) (map[string]*FieldStats, []string) {
+	fields := make(map[string]*FieldStats)
+	for _, obs := range observations {
+		for field, matched := range obs.Outcomes {
+			stats := fields[field]
+			if stats == nil {
+				stats = &FieldStats{}
+				fields[field] = stats
+			}
+			stats.Samples++
+			if matched {
+				stats.Successes++
+			}
+		}
+	}
+
+	for _, stats := range fields {
+		stats.SuccessRate = float64(stats.Successes) / float64(stats.Samples)
+	}
+
+	degraded := make([]string, 0)
+	for _, field := range requiredFields {
+		stats := fields[field]
+		if stats == nil || stats.Samples < thresholds.MinSamples {
+			continue
+		}
+		if stats.SuccessRate < thresholds.MinSuccessRate {
+			degraded = append(degraded, field)
+		}
+	}
+	sort.Strings(degraded)
+
+	return fields, degraded
+}
+
+// degradedReason формирует описание деградации для журнала и админки
+func degradedReason(fields map[string]*FieldStats, degraded []string, thresholds Thresholds) string {
+	parts := make([]string, 0, len(degraded))
+	for _, field := range degraded {
+		stats := fields[field]
+		parts = append(parts, fmt.Sprintf("%s %d/%d", field, stats.Successes, stats.Samples))
+	}
+	return fmt.Sprintf("selector success below %.0f%%: %s", thresholds.MinSuccessRate*100, strings.Join(parts, ", "))
+}
@@ -0,0 +1,123 @@
+package selectorhealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	observations []*Observation
+	health       *ShopHealth
+	saved        []*ShopHealth
+	since        *time.Time
+}
+
+func (m *mockStorage) SaveObservation(ctx context.Context, obs *Observation, keep int) error {
+	m.observations = append(m.observations, obs)
+	if len(m.observations) > keep {
+		m.observations = m.observations[len(m.observations)-keep:]
+	}
+	return nil
+}
+
+func (m *mockStorage) RecentObservations(ctx context.Context, shopID string, since *time.Time, limit int) ([]*Observation, error) {
+	m.since = since
+	return m.observations, nil
+}
+
+func (m *mockStorage) GetHealth(ctx context.Context, shopID string) (*ShopHealth, error) {
+	if m.health == nil {
+		return &ShopHealth{ShopID: shopID, Status: StatusHealthy}, nil
+	}
+	copied := *m.health
+	return &copied, nil
+}
+
+func (m *mockStorage) SaveHealth(ctx context.Context, health *ShopHealth) error {
+	m.saved = append(m.saved, health)
+	m.health = health
+	return nil
+}
+
+func (m *mockStorage) ListHealth(ctx context.Context, status Status) ([]*ShopHealth, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) RollbackSelectors(ctx context.Context, shopID string) error {
+	return nil
+}
+
+func TestEvaluateFields(t *testing.T) {
+	thresholds := Thresholds{Window: 10, MinSamples: 4, MinSuccessRate: 0.5}
+	var observations []*Observation
+	for i := 0; i < 4; i++ {
+		observations = append(observations, &Observation{Outcomes: map[string]bool{
+			"name":  true,
+			"price": i == 0,
+			"brand": false,
+		}})
+	}
+
+	fields, degraded := evaluateFields(observations, thresholds)
+
+	if len(degraded) != 1 || degraded[0] != "price" {
+		t.Fatalf("expected only price degraded, got %v", degraded)
+	}
+	if fields["price"].Successes != 1 || fields["price"].SuccessRate != 0.25 {
+		t.Errorf("unexpected price stats %+v", fields["price"])
+	}
+	// Необязательный селектор отслеживается, но не запускает авто-конфигурацию
+	if fields["brand"].SuccessRate != 0 {
+		t.Errorf("unexpected brand stats %+v", fields["brand"])
+	}
+
+	if _, degraded := evaluateFields(observations[:3], thresholds); len(degraded) != 0 {
+		t.Errorf("too few samples must not degrade, got %v", degraded)
+	}
+}
+
+func TestRecordOutcomes_FlagsDegradedShopOnce(t *testing.T) {
+	storage := &mockStorage{}
+	service := New(storage, Thresholds{Window: 5, MinSamples: 3, MinSuccessRate: 0.6}, logger.New("error"))
+	ctx := context.Background()
+
+	service.RecordOutcomes(ctx, "shop-1", "https://shop/p/1", map[string]bool{"name": true, "price": true})
+	if storage.health.Status != StatusHealthy {
+		t.Fatalf("expected healthy shop, got %s", storage.health.Status)
+	}
+
+	for i := 0; i < 3; i++ {
+		service.RecordOutcomes(ctx, "shop-1", "https://shop/p/2", map[string]bool{"name": false, "price": true})
+	}
+	if storage.health.Status != StatusDegraded || storage.health.DegradedFields[0] != "name" {
+		t.Fatalf("expected degraded name selector, got %+v", storage.health)
+	}
+	if storage.health.Reason == "" {
+		t.Error("degraded shop must have a reason")
+	}
+
+	// Магазин уже в очереди авто-конфигурации: состояние не перезаписывается
+	saves := len(storage.saved)
+	service.RecordOutcomes(ctx, "shop-1", "https://shop/p/3", map[string]bool{"name": true, "price": true})
+	if len(storage.saved) != saves {
+		t.Error("degraded shop must not be re-evaluated")
+	}
+}
+
+func TestRecordOutcomes_WindowRestartsAfterReconfiguration(t *testing.T) {
+	reconfiguredAt := time.Now().Add(-time.Hour)
+	storage := &mockStorage{health: &ShopHealth{ShopID: "shop-1", Status: StatusHealthy, ReconfiguredAt: &reconfiguredAt}}
+	service := New(storage, Thresholds{}, logger.New("error"))
+
+	service.RecordOutcomes(context.Background(), "shop-1", "https://shop/p/1", map[string]bool{"name": true})
+
+	if storage.since == nil || !storage.since.Equal(reconfiguredAt) {
+		t.Errorf("expected observations since reconfiguration, got %v", storage.since)
+	}
+	if service.thresholds.Window != defaultWindow || service.thresholds.MinSamples != defaultMinSamples {
+		t.Errorf("expected default thresholds, got %+v", service.thresholds)
+	}
+}
@@ -0,0 +1,53 @@
+package selectorhealth
+
+import "time"
+
+// Status состояние селекторов магазина
+type Status string
+
+const (
+	// StatusHealthy селекторы работают
+	StatusHealthy Status = "healthy"
+	// StatusDegraded селекторы перестали находить данные, магазин ждёт авто-конфигурации
+	StatusDegraded Status = "degraded"
+	// StatusFailed авто-конфигурация не справилась, нужна ручная правка
+	StatusFailed Status = "failed"
+)
+
+// Thresholds параметры детектора
+type Thresholds struct {
+	Window         int     // сколько последних парсингов учитывать
+	MinSamples     int     // минимум парсингов в окне для вывода о деградации
+	MinSuccessRate float64 // доля успешных срабатываний обязательного селектора
+}
+
+// Observation результат селекторов магазина за один парсинг страницы
+type Observation struct {
+	ShopID     string          `json:"shop_id"`
+	URL        string          `json:"url"`
+	Outcomes   map[string]bool `json:"outcomes"` // поле -> селектор нашёл данные
+	ObservedAt time.Time       `json:"observed_at"`
+}
+
+// FieldStats статистика срабатывания селектора поля в окне
+type FieldStats struct {
+	Samples     int     `json:"samples"`
+	Successes   int     `json:"successes"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// ShopHealth состояние селекторов магазина
+type ShopHealth struct {
+	ShopID            string                 `json:"shop_id"`
+	ShopName          string                 `json:"shop_name,omitempty"`
+	Status            Status                 `json:"status"`
+	Fields            map[string]*FieldStats `json:"fields"`
+	DegradedFields    []string               `json:"degraded_fields"`
+	Reason            string                 `json:"reason,omitempty"`
+	HasPreviousConfig bool                   `json:"has_previous_config"`
+	ReconfigAttempts  int                    `json:"reconfig_attempts"`
+	LastError         string                 `json:"last_error,omitempty"`
+	DegradedAt        *time.Time             `json:"degraded_at,omitempty"`
+	ReconfiguredAt    *time.Time             `json:"reconfigured_at,omitempty"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
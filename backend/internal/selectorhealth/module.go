@@ -0,0 +1,69 @@
+package selectorhealth
+
+import (
+	"context"
+	"time"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+const (
+	defaultWindow         = 20
+	defaultMinSamples     = 10
+	defaultMinSuccessRate = 0.5
+)
+
+// requiredFields селекторы, без которых товар не сохраняется; их деградация запускает авто-конфигурацию
+var requiredFields = []string{"name", "price"}
+
+// Storage интерфейс хранилища результатов селекторов
+type Storage interface {
+	// SaveObservation сохраняет результат парсинга и оставляет не больше keep последних записей магазина
+	SaveObservation(ctx context.Context, obs *Observation, keep int) error
+
+	// RecentObservations возвращает до limit последних результатов магазина после since (если задан)
+	RecentObservations(ctx context.Context, shopID string, since *time.Time, limit int) ([]*Observation, error)
+
+	// GetHealth получает состояние селекторов магазина; для магазина без записей - healthy
+	GetHealth(ctx context.Context, shopID string) (*ShopHealth, error)
+
+	// SaveHealth сохраняет статистику и статус; degraded_at выставляется при переходе в degraded
+	SaveHealth(ctx context.Context, health *ShopHealth) error
+
+	// ListHealth возвращает состояние селекторов магазинов (status пустой - все)
+	ListHealth(ctx context.Context, status Status) ([]*ShopHealth, error)
+
+	// RollbackSelectors активирует версию селекторов, действовавшую до текущей
+	RollbackSelectors(ctx context.Context, shopID string) error
+}
+
+// Service детектор деградации селекторов магазинов
+type Service struct {
+	storage    Storage
+	thresholds Thresholds
+	logger     *logger.Logger
+}
+
+// New создаёт детектор; нулевые пороги заменяются значениями по умолчанию
+func New(storage Storage, thresholds Thresholds, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	if thresholds.Window <= 0 {
+		thresholds.Window = defaultWindow
+	}
+	if thresholds.MinSamples <= 0 {
+		thresholds.MinSamples = defaultMinSamples
+	}
+	if thresholds.MinSamples > thresholds.Window {
+		thresholds.MinSamples = thresholds.Window
+	}
+	if thresholds.MinSuccessRate <= 0 || thresholds.MinSuccessRate > 1 {
+		thresholds.MinSuccessRate = defaultMinSuccessRate
+	}
+	return &Service{
+		storage:    storage,
+		thresholds: thresholds,
+		logger:     log,
+	}
+}
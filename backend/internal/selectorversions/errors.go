@@ -0,0 +1,20 @@
+package selectorversions
+
+import "errors"
+
+var (
+	// ErrShopNotFound магазин не найден
+	ErrShopNotFound = errors.New("shop not found")
+
+	// ErrVersionNotFound версия селекторов не найдена
+	ErrVersionNotFound = errors.New("selector version not found")
+
+	// ErrNoPreviousVersion у магазина нет версии, действовавшей до текущей
+	ErrNoPreviousVersion = errors.New("no previous selector version to roll back to")
+
+	// ErrInvalidVersion невалидные параметры версии
+	ErrInvalidVersion = errors.New("invalid selector version")
+
+	// ErrNoChanges селекторы совпадают с активной версией
+	ErrNoChanges = errors.New("selectors match the active version")
+)
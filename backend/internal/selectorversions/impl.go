@@ -0,0 +1,237 @@
+package selectorversions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// History возвращает активную версию и все версии магазина
+func (s *Service) History(ctx context.Context, shopID string) (*History, error) {
+	if strings.TrimSpace(shopID) == "" {
+		return nil, ErrShopNotFound
+	}
+
+	state, err := s.storage.GetState(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.storage.ListVersions(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list selector versions: %w", err)
+	}
+	if versions == nil {
+		versions = []*Version{}
+	}
+
+	return &History{State: *state, Versions: versions}, nil
+}
+
+// GetVersion возвращает версию селекторов магазина
+func (s *Service) GetVersion(ctx context.Context, shopID string, version int) (*Version, error) {
+	if strings.TrimSpace(shopID) == "" {
+		return nil, ErrShopNotFound
+	}
+	if version <= 0 {
+		return nil, ErrVersionNotFound
+	}
+	return s.storage.GetVersion(ctx, shopID, version)
+}
+
+// CreateVersion сохраняет новую версию селекторов и делает её активной.
+// Возвращает ErrNoChanges, если селекторы совпадают с активной версией
+func (s *Service) CreateVersion(ctx context.Context, nv NewVersion) (*Version, error) {
+	selectors, err := normalizeSelectors(nv.Selectors)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(nv.ShopID) == "" {
+		return nil, ErrShopNotFound
+	}
+	if !nv.Source.Valid() {
+		return nil, fmt.Errorf("%w: unknown source %q", ErrInvalidVersion, nv.Source)
+	}
+	author := strings.TrimSpace(nv.Author)
+	if nv.Source == SourceManual && author == "" {
+		return nil, fmt.Errorf("%w: author is required for manual changes", ErrInvalidVersion)
+	}
+
+	version := &Version{
+		ShopID:    nv.ShopID,
+		Selectors: selectors,
+		Source:    nv.Source,
+		Author:    author,
+		Note:      strings.TrimSpace(nv.Note),
+	}
+	created, err := s.storage.CreateVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return version, ErrNoChanges
+	}
+
+	s.logger.Info("selectorversions: new selector version", map[string]interface{}{
+		"shop_id": version.ShopID,
+		"version": version.Version,
+		"source":  version.Source,
+		"author":  version.Author,
+		"active":  version.Active,
+	})
+	return version, nil
+}
+
+// Diff сравнивает две версии магазина.
+// to = 0 - активная версия, from = 0 - версия перед to
+func (s *Service) Diff(ctx context.Context, shopID string, from, to int) (*Diff, error) {
+	if from < 0 || to < 0 {
+		return nil, ErrVersionNotFound
+	}
+	if to == 0 {
+		state, err := s.storage.GetState(ctx, shopID)
+		if err != nil {
+			return nil, err
+		}
+		to = state.ActiveVersion
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	newer, err := s.GetVersion(ctx, shopID, to)
+	if err != nil {
+		return nil, err
+	}
+	older, err := s.GetVersion(ctx, shopID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Diff{
+		ShopID:  shopID,
+		From:    from,
+		To:      to,
+		Changes: diffSelectors(older.Selectors, newer.Selectors),
+	}, nil
+}
+
+// Rollback делает активной прежнюю версию магазина; version = 0 - версия, активная до текущей.
+// Повторный откат без номера возвращает отменённую версию
+func (s *Service) Rollback(ctx context.Context, shopID string, version int) (*State, error) {
+	if strings.TrimSpace(shopID) == "" {
+		return nil, ErrShopNotFound
+	}
+	if version < 0 {
+		return nil, ErrVersionNotFound
+	}
+	if version == 0 {
+		previous, err := s.storage.PreviousVersion(ctx, shopID)
+		if err != nil {
+			return nil, err
+		}
+		version = previous
+	}
+
+	if err := s.storage.ActivateVersion(ctx, shopID, version, false); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("selectorversions: selector version activated", map[string]interface{}{
+		"shop_id": shopID,
+		"version": version,
+	})
+	return s.storage.GetState(ctx, shopID)
+}
+
+// Pin закрепляет магазин на версии (version = 0 - на активной).
+// Авто-конфигурация продолжает сохранять версии, но не активирует их
+func (s *Service) Pin(ctx context.Context, shopID string, version int) (*State, error) {
+	if strings.TrimSpace(shopID) == "" {
+		return nil, ErrShopNotFound
+	}
+	if version < 0 {
+		return nil, ErrVersionNotFound
+	}
+
+	var err error
+	if version == 0 {
+		err = s.storage.SetPinned(ctx, shopID, true)
+	} else {
+		err = s.storage.ActivateVersion(ctx, shopID, version, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.storage.GetState(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("selectorversions: shop selectors pinned", map[string]interface{}{
+		"shop_id": shopID,
+		"version": state.ActiveVersion,
+	})
+	return state, nil
+}
+
+// Unpin снимает закрепление версии
+func (s *Service) Unpin(ctx context.Context, shopID string) (*State, error) {
+	if strings.TrimSpace(shopID) == "" {
+		return nil, ErrShopNotFound
+	}
+	if err := s.storage.SetPinned(ctx, shopID, false); err != nil {
+		return nil, err
+	}
+	return s.storage.GetState(ctx, shopID)
+}
+
+// normalizeSelectors обрезает пробелы и отбрасывает пустые селекторы
+func normalizeSelectors(selectors map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(selectors))
+	for field, selector := range selectors {
+		field = strings.TrimSpace(field)
+		selector = strings.TrimSpace(selector)
+		if field == "" || selector == "" {
+			continue
+		}
+		normalized[field] = selector
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: selectors are empty", ErrInvalidVersion)
+	}
+	return normalized, nil
+}
+
+// diffSelectors возвращает изменения селекторов по полям в алфавитном порядке
+func diffSelectors(older, newer map[string]string) []FieldChange {
+	fields := make(map[string]struct{}, len(older)+len(newer))
+	for field := range older {
+		fields[field] = struct{}{}
+	}
+	for field := range newer {
+		fields[field] = struct{}{}
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	changes := make([]FieldChange, 0)
+	for _, field := range names {
+		oldSelector, hadOld := older[field]
+		newSelector, hasNew := newer[field]
+		switch {
+		case !hadOld:
+			changes = append(changes, FieldChange{Field: field, Change: ChangeAdded, New: newSelector})
+		case !hasNew:
+			changes = append(changes, FieldChange{Field: field, Change: ChangeRemoved, Old: oldSelector})
+		case oldSelector != newSelector:
+			changes = append(changes, FieldChange{Field: field, Change: ChangeModified, Old: oldSelector, New: newSelector})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,166 @@
+package selectorversions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+type mockStorage struct {
+	state     State
+	versions  map[int]*Version
+	activated []int
+	previous  int
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{
+		state: State{ShopID: "shop-1", ActiveVersion: 2},
+		versions: map[int]*Version{
+			1: {ShopID: "shop-1", Version: 1, Selectors: map[string]string{"name": "h1", "price": ".price"}},
+			2: {ShopID: "shop-1", Version: 2, Selectors: map[string]string{"name": "h1.title", "image": "img"}},
+		},
+		previous: 1,
+	}
+}
+
+func (m *mockStorage) GetState(ctx context.Context, shopID string) (*State, error) {
+	state := m.state
+	return &state, nil
+}
+
+func (m *mockStorage) ListVersions(ctx context.Context, shopID string) ([]*Version, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) GetVersion(ctx context.Context, shopID string, version int) (*Version, error) {
+	v, ok := m.versions[version]
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+func (m *mockStorage) CreateVersion(ctx context.Context, version *Version) (bool, error) {
+	version.Version = len(m.versions) + 1
+	version.Active = version.Source == SourceManual || !m.state.Pinned
+	m.versions[version.Version] = version
+	return true, nil
+}
+
+func (m *mockStorage) ActivateVersion(ctx context.Context, shopID string, version int, pin bool) error {
+	if _, ok := m.versions[version]; !ok {
+		return ErrVersionNotFound
+	}
+	m.activated = append(m.activated, version)
+	m.state.ActiveVersion = version
+	m.state.Pinned = m.state.Pinned || pin
+	return nil
+}
+
+func (m *mockStorage) SetPinned(ctx context.Context, shopID string, pinned bool) error {
+	m.state.Pinned = pinned
+	return nil
+}
+
+func (m *mockStorage) PreviousVersion(ctx context.Context, shopID string) (int, error) {
+	if m.previous == 0 {
+		return 0, ErrNoPreviousVersion
+	}
+	return m.previous, nil
+}
+
+func TestDiffSelectors(t *testing.T) {
+	changes := diffSelectors(
+		map[string]string{"name": "h1", "price": ".price", "brand": ".brand"},
+		map[string]string{"name": "h1.title", "price": ".price", "image": "img"},
+	)
+
+	want := []FieldChange{
+		{Field: "brand", Change: ChangeRemoved, Old: ".brand"},
+		{Field: "image", Change: ChangeAdded, New: "img"},
+		{Field: "name", Change: ChangeModified, Old: "h1", New: "h1.title"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i], changes[i])
+		}
+	}
+}
+
+func TestDiff_DefaultsToActiveAndPrevious(t *testing.T) {
+	service := New(newMockStorage(), logger.New("error"))
+
+	diff, err := service.Diff(context.Background(), "shop-1", 0, 0)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.From != 1 || diff.To != 2 || len(diff.Changes) != 3 {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+
+	if _, err := service.Diff(context.Background(), "shop-1", 7, 0); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestCreateVersion_Validation(t *testing.T) {
+	service := New(newMockStorage(), logger.New("error"))
+	ctx := context.Background()
+
+	cases := []NewVersion{
+		{ShopID: "shop-1", Selectors: map[string]string{"name": " "}, Source: SourceManual, Author: "editor"},
+		{ShopID: "shop-1", Selectors: map[string]string{"name": "h1"}, Source: "robot", Author: "editor"},
+		{ShopID: "shop-1", Selectors: map[string]string{"name": "h1"}, Source: SourceManual},
+	}
+	for _, nv := range cases {
+		if _, err := service.CreateVersion(ctx, nv); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("CreateVersion(%+v): expected ErrInvalidVersion, got %v", nv, err)
+		}
+	}
+
+	version, err := service.CreateVersion(ctx, NewVersion{
+		ShopID:    "shop-1",
+		Selectors: map[string]string{" name ": " h1 ", "brand": ""},
+		Source:    SourceAI,
+		Author:    "gpt-4o-mini",
+	})
+	if err != nil {
+		t.Fatalf("CreateVersion failed: %v", err)
+	}
+	if version.Version != 3 || len(version.Selectors) != 1 || version.Selectors["name"] != "h1" {
+		t.Errorf("unexpected version %+v", version)
+	}
+}
+
+func TestRollbackAndPin(t *testing.T) {
+	storage := newMockStorage()
+	service := New(storage, logger.New("error"))
+	ctx := context.Background()
+
+	state, err := service.Rollback(ctx, "shop-1", 0)
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if state.ActiveVersion != 1 || state.Pinned {
+		t.Errorf("expected version 1 active and not pinned, got %+v", state)
+	}
+
+	state, err = service.Pin(ctx, "shop-1", 2)
+	if err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+	if state.ActiveVersion != 2 || !state.Pinned {
+		t.Errorf("expected pinned version 2, got %+v", state)
+	}
+
+	storage.previous = 0
+	if _, err := service.Rollback(ctx, "shop-1", 0); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Errorf("expected ErrNoPreviousVersion, got %v", err)
+	}
+}
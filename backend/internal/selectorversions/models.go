@@ -0,0 +1,88 @@
+package selectorversions
+
+import "time"
+
+// Source источник версии селекторов
+type Source string
+
+const (
+	// SourceManual селекторы заданы вручную (админка, cmd/update-selectors)
+	SourceManual Source = "manual"
+	// SourceAutoconfig селекторы подобраны авто-конфигурацией без AI
+	SourceAutoconfig Source = "autoconfig"
+	// SourceAI селекторы сгенерированы AI
+	SourceAI Source = "ai"
+)
+
+// Valid проверяет, что источник известен
+func (s Source) Valid() bool {
+	switch s {
+	case SourceManual, SourceAutoconfig, SourceAI:
+		return true
+	}
+	return false
+}
+
+// Version версия селекторов магазина
+type Version struct {
+	ShopID      string            `json:"shop_id"`
+	Version     int               `json:"version"`
+	Selectors   map[string]string `json:"selectors"`
+	Source      Source            `json:"source"`
+	Author      string            `json:"author,omitempty"` // пользователь, генератор или модель AI
+	Note        string            `json:"note,omitempty"`
+	Active      bool              `json:"active"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ActivatedAt *time.Time        `json:"activated_at,omitempty"`
+}
+
+// State активная версия магазина
+type State struct {
+	ShopID        string `json:"shop_id"`
+	ActiveVersion int    `json:"active_version"`
+	// Pinned версии от авто-конфигурации сохраняются, но не активируются
+	Pinned bool `json:"pinned"`
+}
+
+// History история версий магазина, новые первыми
+type History struct {
+	State
+	Versions []*Version `json:"versions"`
+}
+
+// NewVersion параметры новой версии
+type NewVersion struct {
+	ShopID    string
+	Selectors map[string]string
+	Source    Source
+	Author    string
+	Note      string
+}
+
+// ChangeType вид изменения селектора поля
+type ChangeType string
+
+const (
+	// ChangeAdded селектор поля добавлен
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved селектор поля удалён
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified селектор поля изменён
+	ChangeModified ChangeType = "changed"
+)
+
+// FieldChange изменение селектора одного поля
+type FieldChange struct {
+	Field  string     `json:"field"`
+	Change ChangeType `json:"change"`
+	Old    string     `json:"old,omitempty"`
+	New    string     `json:"new,omitempty"`
+}
+
+// Diff различия между двумя версиями
+type Diff struct {
+	ShopID  string        `json:"shop_id"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
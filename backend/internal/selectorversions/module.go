@@ -0,0 +1,50 @@
+package selectorversions
+
+import (
+	"context"
+
+	"github.com/solomonczyk/izborator/internal/logger"
+)
+
+// Storage интерфейс хранилища версий селекторов
+type Storage interface {
+	// GetState возвращает активную версию и признак закрепления
+	GetState(ctx context.Context, shopID string) (*State, error)
+
+	// ListVersions возвращает версии магазина, новые первыми
+	ListVersions(ctx context.Context, shopID string) ([]*Version, error)
+
+	// GetVersion возвращает версию магазина по номеру
+	GetVersion(ctx context.Context, shopID string, version int) (*Version, error)
+
+	// CreateVersion сохраняет версию со следующим номером и активирует её.
+	// Если селекторы совпадают с активной версией, новая не создаётся и возвращается false.
+	// Версии не от SourceManual у закреплённого магазина сохраняются без активации
+	CreateVersion(ctx context.Context, version *Version) (bool, error)
+
+	// ActivateVersion делает версию активной; pin закрепляет магазин на ней
+	ActivateVersion(ctx context.Context, shopID string, version int, pin bool) error
+
+	// SetPinned закрепляет магазин на активной версии или снимает закрепление
+	SetPinned(ctx context.Context, shopID string, pinned bool) error
+
+	// PreviousVersion возвращает версию, активную до текущей
+	PreviousVersion(ctx context.Context, shopID string) (int, error)
+}
+
+// Service версии селекторов магазинов
+type Service struct {
+	storage Storage
+	logger  *logger.Logger
+}
+
+// New создаёт сервис версий селекторов
+func New(storage Storage, log *logger.Logger) *Service {
+	if log == nil {
+		log = logger.New("info")
+	}
+	return &Service{
+		storage: storage,
+		logger:  log,
+	}
+}
@@ -0,0 +1,263 @@
+// Package sitemap читает sitemap.xml сайтов: robots.txt, индексы sitemap, gzip и lastmod
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxSitemaps = 50
+	defaultMaxBytes    = 50 << 20 // лимит протокола sitemaps.org на несжатый файл
+)
+
+// ErrStop возвращается из обработчика Walk, чтобы прекратить обход без ошибки
+var ErrStop = errors.New("stop sitemap walk")
+
+// Entry адрес из sitemap
+type Entry struct {
+	Loc     string
+	LastMod time.Time // нулевое, если lastmod не указан или не разобран
+	Sitemap string    // файл sitemap, в котором найден адрес
+}
+
+// Client читает sitemap по HTTP
+type Client struct {
+	http      *http.Client
+	userAgent string
+
+	// MaxSitemaps ограничивает число файлов, читаемых за один Walk (индексы + дочерние sitemap)
+	MaxSitemaps int
+	// MaxBytes ограничивает размер одного файла после распаковки
+	MaxBytes int64
+	// SkipSitemap, если задан, отсеивает дочерние sitemap индекса (например, sitemap-blog.xml)
+	SkipSitemap func(loc string) bool
+}
+
+// New создаёт клиент sitemap; httpClient = nil - клиент с таймаутом 30 секунд
+func New(httpClient *http.Client, userAgent string) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		http:        httpClient,
+		userAgent:   userAgent,
+		MaxSitemaps: defaultMaxSitemaps,
+		MaxBytes:    defaultMaxBytes,
+	}
+}
+
+// Locate возвращает sitemap сайта: строки Sitemap: из robots.txt, иначе /sitemap.xml
+func (c *Client) Locate(ctx context.Context, baseURL string) ([]string, error) {
+	root, err := url.Parse(baseURL)
+	if err != nil || root.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	if root.Scheme == "" {
+		root.Scheme = "https"
+	}
+	root.Path, root.RawQuery, root.Fragment = "", "", ""
+
+	robots := root.String() + "/robots.txt"
+	body, err := c.fetch(ctx, robots)
+	if err == nil {
+		if sitemaps := parseRobots(body); len(sitemaps) > 0 {
+			return sitemaps, nil
+		}
+	} else if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return []string{root.String() + "/sitemap.xml"}, nil
+}
+
+// Walk обходит sitemap и вложенные индексы в ширину и вызывает fn для каждого адреса.
+// Повторные файлы пропускаются; fn может вернуть ErrStop
+func (c *Client) Walk(ctx context.Context, sitemapURLs []string, fn func(Entry) error) error {
+	queue := append([]string(nil), sitemapURLs...)
+	visited := make(map[string]bool)
+	read, parsed := 0, 0
+	var firstErr error
+
+	for len(queue) > 0 && read < c.MaxSitemaps {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		read++
+
+		body, err := c.fetch(ctx, current)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Недоступный дочерний файл не мешает читать остальные
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		children, err := parse(body, current, fn)
+		if errors.Is(err, ErrStop) {
+			return nil
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		parsed++
+		for _, child := range children {
+			if c.SkipSitemap == nil || !c.SkipSitemap(child) {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	// Ошибка важна, только если не прочитан ни один файл
+	if parsed > 0 {
+		return nil
+	}
+	return firstErr
+}
+
+// fetch скачивает файл и распаковывает gzip (по сигнатуре, а не по расширению)
+func (c *Client) fetch(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", target, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.MaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip %s: %w", target, err)
+		}
+		defer gz.Close()
+		body, err = io.ReadAll(io.LimitReader(gz, c.MaxBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", target, err)
+		}
+	}
+	return body, nil
+}
+
+// parseRobots извлекает адреса sitemap из robots.txt
+func parseRobots(body []byte) []string {
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			sitemaps = append(sitemaps, value)
+		}
+	}
+	return sitemaps
+}
+
+// xmlLocation элемент <url> или <sitemap>
+type xmlLocation struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// parse читает urlset или sitemapindex: адреса передаются в fn, дочерние sitemap возвращаются
+func parse(body []byte, source string, fn func(Entry) error) ([]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+
+	var children []string
+	found := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return children, fmt.Errorf("failed to parse sitemap %s: %w", source, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || (start.Name.Local != "url" && start.Name.Local != "sitemap") {
+			continue
+		}
+		var loc xmlLocation
+		if err := decoder.DecodeElement(&loc, &start); err != nil {
+			return children, fmt.Errorf("failed to parse sitemap %s: %w", source, err)
+		}
+		loc.Loc = strings.TrimSpace(loc.Loc)
+		if loc.Loc == "" {
+			continue
+		}
+		found = true
+
+		if start.Name.Local == "sitemap" {
+			children = append(children, loc.Loc)
+			continue
+		}
+		if err := fn(Entry{Loc: loc.Loc, LastMod: parseLastMod(loc.LastMod), Sitemap: source}); err != nil {
+			return children, err
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no sitemap entries in %s", source)
+	}
+	return children, nil
+}
+
+// lastModLayouts форматы W3C Datetime, встречающиеся в sitemap
+var lastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseLastMod разбирает lastmod; неразобранное значение даёт нулевое время
+func parseLastMod(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,97 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWalk_IndexGzipAndLastMod(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /cart\nSitemap: " + server.URL + "/sitemap_index.xml\n"))
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>` + server.URL + `/sitemap-products.xml.gz</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap-missing.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap_index.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/sitemap-products.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			_, _ = gz.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> ` + server.URL + `/proizvod/tv-55 </loc><lastmod>2026-09-30T10:00:00+02:00</lastmod></url>
+  <url><loc>` + server.URL + `/proizvod/frizider</loc><lastmod>2026-10-01</lastmod></url>
+  <url><loc>` + server.URL + `/proizvod/bez-datuma</loc></url>
+</urlset>`))
+			_ = gz.Close()
+			_, _ = w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.Client(), "IzboratorBot")
+	sitemaps, err := client.Locate(context.Background(), server.URL+"/some/page")
+	if err != nil {
+		t.Fatalf("Locate failed: %v", err)
+	}
+	if len(sitemaps) != 1 || sitemaps[0] != server.URL+"/sitemap_index.xml" {
+		t.Fatalf("unexpected sitemaps %v", sitemaps)
+	}
+
+	var entries []Entry
+	err = client.Walk(context.Background(), sitemaps, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if entries[0].Loc != server.URL+"/proizvod/tv-55" || entries[0].Sitemap != server.URL+"/sitemap-products.xml.gz" {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+	if !entries[0].LastMod.Equal(time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected lastmod %v", entries[0].LastMod)
+	}
+	if !entries[1].LastMod.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) || !entries[2].LastMod.IsZero() {
+		t.Errorf("unexpected lastmods %v / %v", entries[1].LastMod, entries[2].LastMod)
+	}
+
+	count := 0
+	err = client.Walk(context.Background(), sitemaps, func(Entry) error {
+		count++
+		return ErrStop
+	})
+	if err != nil || count != 1 {
+		t.Errorf("ErrStop must end the walk quietly, got count=%d err=%v", count, err)
+	}
+}
+
+func TestLocate_FallsBackToSitemapXML(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	sitemaps, err := New(server.Client(), "").Locate(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Locate failed: %v", err)
+	}
+	if len(sitemaps) != 1 || sitemaps[0] != server.URL+"/sitemap.xml" {
+		t.Errorf("unexpected fallback %v", sitemaps)
+	}
+
+	if err := New(server.Client(), "").Walk(context.Background(), sitemaps, func(Entry) error { return nil }); err == nil {
+		t.Error("expected error when no sitemap could be read")
+	}
+}
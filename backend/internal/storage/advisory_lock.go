@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ключи advisory lock фоновых задач, которые среди всех воркеров выполняет один процесс
+const (
+	indexRebuildLock        = 72410030 // пересборка поискового индекса
+	priceHistoryCleanupLock = 72410031 // очистка истории цен по сроку хранения
+)
+
+// priceHistoryWriteLock пространство ключей advisory lock записи истории цен (вторая часть ключа - пара товар-магазин)
+const priceHistoryWriteLock = 72410040
+
+// tryAdvisoryLock берёт сессионный advisory lock на отдельном соединении пула и держит
+// соединение до unlock. ok=false - блокировку держит другой процесс
+func (p *Postgres) tryAdvisoryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := p.DB().Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Контекст задачи к этому моменту может быть отменён
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// Без снятия блокировки соединение нельзя возвращать в пул: закрываем его
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
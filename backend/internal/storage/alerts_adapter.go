@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/alerts"
+)
+
+// AlertsAdapter адаптер для работы с подписками на снижение цены
+type AlertsAdapter struct {
+	*BaseAdapter
+}
+
+// NewAlertsAdapter создаёт новый адаптер для подписок
+func NewAlertsAdapter(pg *Postgres) alerts.Storage {
+	return &AlertsAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+const alertSubscriptionColumns = `
+	id, owner_key, product_id, city_id, target_price, currency, channel, target,
+	is_active, last_notified_price, last_notified_at, created_at, updated_at
+`
+
+// CreateSubscription сохраняет новую подписку
+func (a *AlertsAdapter) CreateSubscription(ctx context.Context, sub *alerts.Subscription) error {
+	productUUID, err := a.ParseUUID(sub.ProductID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	query := `
+		INSERT INTO price_alert_subscriptions (owner_key, product_id, city_id, target_price, currency, channel, target, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = a.pg.DB().QueryRow(ctx, query,
+		sub.OwnerKey,
+		productUUID,
+		sub.CityID,
+		sub.TargetPrice,
+		sub.Currency,
+		string(sub.Channel),
+		sub.Target,
+		sub.IsActive,
+	).Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription получает подписку по ID
+func (a *AlertsAdapter) GetSubscription(ctx context.Context, id string) (*alerts.Subscription, error) {
+	subUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return nil, alerts.ErrSubscriptionNotFound
+	}
+
+	query := `SELECT ` + alertSubscriptionColumns + ` FROM price_alert_subscriptions WHERE id = $1`
+
+	sub, err := scanAlertSubscription(a.pg.DB().QueryRow(ctx, query, subUUID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, alerts.ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListByOwner получает все подписки владельца
+func (a *AlertsAdapter) ListByOwner(ctx context.Context, ownerKey string) ([]*alerts.Subscription, error) {
+	query := `
+		SELECT ` + alertSubscriptionColumns + `
+		FROM price_alert_subscriptions
+		WHERE owner_key = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, ownerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	return collectAlertSubscriptions(rows)
+}
+
+// DeleteSubscription удаляет подписку владельца
+func (a *AlertsAdapter) DeleteSubscription(ctx context.Context, id, ownerKey string) error {
+	subUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return alerts.ErrSubscriptionNotFound
+	}
+
+	tag, err := a.pg.DB().Exec(ctx, `DELETE FROM price_alert_subscriptions WHERE id = $1 AND owner_key = $2`, subUUID, ownerKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return alerts.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// FindTriggered находит активные подписки, цель которых достигнута
+// Подписки, по которым уже отправлено уведомление с такой же или более низкой ценой, пропускаются
+func (a *AlertsAdapter) FindTriggered(ctx context.Context, productID string, cityID *string, price float64) ([]*alerts.Subscription, error) {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	query := `
+		SELECT ` + alertSubscriptionColumns + `
+		FROM price_alert_subscriptions
+		WHERE product_id = $1
+		  AND is_active = TRUE
+		  AND target_price >= $2
+		  AND (last_notified_price IS NULL OR last_notified_price > $2)
+		  AND (city_id IS NULL OR city_id = $3)
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, productUUID, price, cityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find triggered subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	return collectAlertSubscriptions(rows)
+}
+
+// RecordNotifications сохраняет сработавшие уведомления и отмечает подписки уведомлёнными
+// в одной транзакции: повторная обработка той же цены не поставит уведомление дважды
+func (a *AlertsAdapter) RecordNotifications(ctx context.Context, notifications []*alerts.Notification) error {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, n := range notifications {
+		subUUID, err := a.ParseUUID(n.SubscriptionID)
+		if err != nil {
+			return fmt.Errorf("invalid subscription ID: %w", err)
+		}
+		productUUID, err := a.ParseUUID(n.ProductID)
+		if err != nil {
+			return fmt.Errorf("invalid product ID: %w", err)
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO price_alert_notifications
+				(subscription_id, product_id, shop_id, shop_name, url, price, target_price, currency, channel, target, triggered_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id
+		`,
+			subUUID,
+			productUUID,
+			n.ShopID,
+			n.ShopName,
+			n.URL,
+			n.Price,
+			n.TargetPrice,
+			n.Currency,
+			string(n.Channel),
+			n.Target,
+			n.TriggeredAt,
+		).Scan(&n.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert notification: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE price_alert_subscriptions
+			SET last_notified_price = $2, last_notified_at = $3
+			WHERE id = $1
+		`, subUUID, n.Price, n.TriggeredAt)
+		if err != nil {
+			return fmt.Errorf("failed to mark subscription as notified: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit notifications: %w", err)
+	}
+	return nil
+}
+
+// ClaimNotifications забирает уведомления, ожидающие доставки.
+// SKIP LOCKED не даёт параллельным воркерам взять одно уведомление, а сдвиг
+// next_attempt_at на lease - забрать его снова, пока идёт доставка
+func (a *AlertsAdapter) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*alerts.Notification, error) {
+	query := `
+		UPDATE price_alert_notifications
+		SET attempts = attempts + 1,
+		    next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM price_alert_notifications
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, subscription_id, product_id, shop_id, shop_name, url, price, target_price,
+		          currency, channel, target, triggered_at, attempts
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*alerts.Notification, 0)
+	for rows.Next() {
+		var n alerts.Notification
+		var channel string
+		if err := rows.Scan(
+			&n.ID,
+			&n.SubscriptionID,
+			&n.ProductID,
+			&n.ShopID,
+			&n.ShopName,
+			&n.URL,
+			&n.Price,
+			&n.TargetPrice,
+			&n.Currency,
+			&channel,
+			&n.Target,
+			&n.TriggeredAt,
+			&n.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		n.Channel = alerts.Channel(channel)
+		result = append(result, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+	return result, nil
+}
+
+// CompleteNotification отмечает уведомление доставленным
+func (a *AlertsAdapter) CompleteNotification(ctx context.Context, id string) error {
+	notificationUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+
+	query := `
+		UPDATE price_alert_notifications
+		SET status = 'sent', sent_at = NOW(), last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := a.pg.DB().Exec(ctx, query, notificationUUID); err != nil {
+		return fmt.Errorf("failed to mark notification as sent: %w", err)
+	}
+	return nil
+}
+
+// FailNotification фиксирует ошибку доставки и время повтора (nil - попытки исчерпаны)
+func (a *AlertsAdapter) FailNotification(ctx context.Context, id, lastError string, retryAt *time.Time) error {
+	notificationUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+
+	query := `
+		UPDATE price_alert_notifications
+		SET status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		    next_attempt_at = COALESCE($3, next_attempt_at),
+		    last_error = $2
+		WHERE id = $1
+	`
+
+	if _, err := a.pg.DB().Exec(ctx, query, notificationUUID, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to record notification failure: %w", err)
+	}
+	return nil
+}
+
+func scanAlertSubscription(row pgx.Row) (*alerts.Subscription, error) {
+	var sub alerts.Subscription
+	var channel string
+	if err := row.Scan(
+		&sub.ID,
+		&sub.OwnerKey,
+		&sub.ProductID,
+		&sub.CityID,
+		&sub.TargetPrice,
+		&sub.Currency,
+		&channel,
+		&sub.Target,
+		&sub.IsActive,
+		&sub.LastNotifiedPrice,
+		&sub.LastNotifiedAt,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	sub.Channel = alerts.Channel(channel)
+	return &sub, nil
+}
+
+func collectAlertSubscriptions(rows pgx.Rows) ([]*alerts.Subscription, error) {
+	result := make([]*alerts.Subscription, 0)
+	for rows.Next() {
+		sub, err := scanAlertSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		result = append(result, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscriptions: %w", err)
+	}
+	return result, nil
+}
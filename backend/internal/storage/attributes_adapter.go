@@ -153,3 +153,81 @@ func (a *AttributesAdapter) GetByProductTypeID(productTypeID string) ([]*attribu
 	return result, nil
 }
 
+// GetAliases получает сохранённые названия характеристик магазинов
+func (a *AttributesAdapter) GetAliases() ([]*attributes.Alias, error) {
+	query := `
+		SELECT attribute_id, alias
+		FROM attribute_aliases
+		ORDER BY alias
+	`
+
+	rows, err := a.pg.DB().Query(a.GetContext(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*attributes.Alias
+	for rows.Next() {
+		var alias attributes.Alias
+		if err := rows.Scan(&alias.AttributeID, &alias.Alias); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute alias: %w", err)
+		}
+		result = append(result, &alias)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveProductValues сохраняет типизированные значения атрибутов товара (одно значение на атрибут)
+func (a *AttributesAdapter) SaveProductValues(productID string, values []*attributes.Value) error {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	query := `
+		INSERT INTO product_attribute_values (
+			product_id, attribute_id, value_text, value_number, value_bool,
+			unit, raw_value, source_label, updated_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, ''), NOW())
+		ON CONFLICT (product_id, attribute_id)
+		DO UPDATE SET
+			value_text   = EXCLUDED.value_text,
+			value_number = EXCLUDED.value_number,
+			value_bool   = EXCLUDED.value_bool,
+			unit         = EXCLUDED.unit,
+			raw_value    = EXCLUDED.raw_value,
+			source_label = EXCLUDED.source_label,
+			updated_at   = EXCLUDED.updated_at
+	`
+
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, value := range values {
+		_, err := tx.Exec(ctx, query,
+			productUUID,
+			value.AttributeID,
+			value.Text,
+			value.Number,
+			value.Bool,
+			value.Unit,
+			value.RawValue,
+			value.SourceLabel,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save product attribute value: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
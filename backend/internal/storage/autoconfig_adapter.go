@@ -1,14 +1,17 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/solomonczyk/izborator/internal/autoconfig"
+	"github.com/solomonczyk/izborator/internal/candidates"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
 )
 
 // nonAlphanumericRegex регулярное выражение для удаления неалфавитных символов
@@ -26,177 +29,318 @@ func NewAutoconfigAdapter(pg *Postgres) autoconfig.Storage {
 	}
 }
 
-// GetClassifiedCandidates получает кандидатов со статусом "classified" для авто-конфигурации
-// Исключает кандидатов с >= 3 неудачными попытками конфигурации
-func (a *autoconfigAdapter) GetClassifiedCandidates(limit int) ([]autoconfig.Candidate, error) {
-	query := `
-		SELECT ps.id, ps.domain, ps.metadata
-		FROM potential_shops ps
-		LEFT JOIN (
-			SELECT potential_shop_id, COUNT(*) as failed_count
-			FROM shop_config_attempts
-			WHERE status = 'failed'
-			GROUP BY potential_shop_id
-		) attempts ON ps.id = attempts.potential_shop_id
-		WHERE ps.status = 'classified'
-		  AND (attempts.failed_count IS NULL OR attempts.failed_count < 3)
-		ORDER BY ps.confidence_score DESC, ps.discovered_at ASC
-		LIMIT $1
-	`
+// staleClaimTimeout кандидат, застрявший в configuring дольше этого времени (упавший процесс),
+// считается неудачной попыткой и может быть взят снова
+const staleClaimTimeout = time.Hour
+
+// ClaimCandidates забирает кандидатов в работу: classified и неудачные с попытками меньше candidates.MaxAttempts
+// переводятся в configuring. SKIP LOCKED не даёт параллельным процессам взять одного кандидата
+func (a *autoconfigAdapter) ClaimCandidates(limit int) ([]autoconfig.Candidate, error) {
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := failStaleCandidates(ctx, tx); err != nil {
+		return nil, err
+	}
 
-	rows, err := a.pg.DB().Query(a.GetContext(), query, limit)
+	rows, err := tx.Query(ctx, `
+		SELECT id, domain, COALESCE(metadata->>'site_type', ''), COALESCE(metadata->'product_urls'->>0, '')
+		FROM potential_shops
+		WHERE status = 'classified'
+		   OR (status = 'failed' AND attempts < $2)
+		ORDER BY status = 'failed', confidence_score DESC, discovered_at ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit, candidates.MaxAttempts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query candidates: %w", err)
 	}
-	defer rows.Close()
 
-	var candidates []autoconfig.Candidate
+	var claimed []autoconfig.Candidate
 	for rows.Next() {
 		var c autoconfig.Candidate
-		var metadataJSON []byte
-		if err := rows.Scan(&c.ID, &c.Domain, &metadataJSON); err != nil {
+		if err := rows.Scan(&c.ID, &c.Domain, &c.SiteType, &c.SampleURL); err != nil {
+			rows.Close()
 			return nil, fmt.Errorf("failed to scan candidate: %w", err)
 		}
-		
-		// Извлекаем site_type из metadata
-		if metadataJSON != nil {
-			var metadata map[string]interface{}
-			if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
-				if siteType, ok := metadata["site_type"].(string); ok {
-					c.SiteType = siteType
-				}
-			}
+		claimed = append(claimed, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating candidates: %w", err)
+	}
+
+	for _, c := range claimed {
+		if _, err := transitionCandidate(ctx, tx, c.ID, candidates.StatusConfiguring, "", candidates.ActorAutoconfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return claimed, nil
+}
+
+// failStaleCandidates переводит застрявших в configuring кандидатов в failed
+func failStaleCandidates(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, `
+		SELECT id
+		FROM potential_shops
+		WHERE status = 'configuring' AND state_changed_at < NOW() - make_interval(secs => $1)
+		FOR UPDATE SKIP LOCKED
+	`, staleClaimTimeout.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to query stale candidates: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to scan stale candidates: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := failCandidate(ctx, tx, id, "configuration timed out"); err != nil {
+			return err
 		}
-		
-		candidates = append(candidates, c)
 	}
+	return nil
+}
 
-	return candidates, rows.Err()
+// failCandidate переводит кандидата в failed, увеличивает счётчик попыток и сохраняет причину
+func failCandidate(ctx context.Context, tx pgx.Tx, id, reason string) error {
+	if _, err := transitionCandidate(ctx, tx, id, candidates.StatusFailed, reason, candidates.ActorAutoconfig); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE potential_shops
+		SET attempts = attempts + 1,
+		    last_error = $2
+		WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to update candidate attempts: %w", err)
+	}
+	return nil
 }
 
-// MarkAsConfigured создает магазин в таблице shops и обновляет статус в potential_shops
+// MarkAsConfigured сохраняет проверенные селекторы кандидата и переводит его в configured.
+// Магазин создаётся после одобрения администратором (candidates.Service.Approve)
 func (a *autoconfigAdapter) MarkAsConfigured(id string, config autoconfig.ShopConfig) error {
-	// Начинаем транзакцию
-	tx, err := a.pg.DB().Begin(a.GetContext())
+	ctx := a.GetContext()
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	selectorsJSON, err := json.Marshal(config.Selectors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal selectors: %w", err)
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
-		_ = tx.Rollback(a.GetContext())
+		_ = tx.Rollback(ctx)
 	}()
 
-	// Получаем данные кандидата
-	var domain string
-	var metadataJSON []byte
-	err = tx.QueryRow(a.GetContext(), `
-		SELECT domain, metadata
-		FROM potential_shops
+	if _, err := transitionCandidate(ctx, tx, id, candidates.StatusConfigured, "selectors validated", candidates.ActorAutoconfig); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE potential_shops
+		SET config = $2,
+		    last_error = NULL
 		WHERE id = $1
-	`, id).Scan(&domain, &metadataJSON)
+	`, id, configJSON)
 	if err != nil {
-		return fmt.Errorf("failed to get candidate: %w", err)
+		return fmt.Errorf("failed to save candidate config: %w", err)
 	}
 
-	// Извлекаем название магазина из метаданных или используем домен
-	shopName := domain
-	if metadataJSON != nil {
-		var metadata map[string]interface{}
-		if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
-			if title, ok := metadata["title"].(string); ok && title != "" {
-				shopName = title
-			}
-		}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop_config_attempts (potential_shop_id, ai_response, validation_result, status, created_at)
+		VALUES ($1, $2, $3, 'success', NOW())
+	`, id, selectorsJSON, json.RawMessage(`{"validated": true}`))
+	if err != nil {
+		return fmt.Errorf("failed to save config attempt: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MarkAsFailed переводит кандидата в failed и сохраняет причину ошибки.
+// До candidates.MaxAttempts попыток кандидат будет взят повторно
+func (a *autoconfigAdapter) MarkAsFailed(id string, reason string) error {
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := failCandidate(ctx, tx, id, reason); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop_config_attempts (potential_shop_id, status, error_message, created_at)
+		VALUES ($1, 'failed', $2, NOW())
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to save config attempt: %w", err)
 	}
 
-	// Генерируем ID для магазина (используем UUID)
-	shopID := uuid.New().String()
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// maxReconfigAttempts после стольких неудачных попыток магазин переводится в failed и ждёт ручной правки
+const maxReconfigAttempts = 3
+
+// GetDegradedShops получает магазины, помеченные детектором деградации селекторов.
+// В качестве страницы для генерации берётся последний спарсенный товар магазина.
+// Магазины, закреплённые на версии селекторов, пропускаются
+func (a *autoconfigAdapter) GetDegradedShops(limit int) ([]autoconfig.DegradedShop, error) {
+	query := `
+		SELECT s.id, s.base_url, COALESCE(rp.url, ''), COALESCE(ps.metadata->>'site_type', ''), h.degraded_fields
+		FROM shop_selector_health h
+		JOIN shops s ON s.id = h.shop_id
+		LEFT JOIN LATERAL (
+			SELECT url FROM raw_products
+			WHERE shop_id = s.id AND url IS NOT NULL AND url <> ''
+			ORDER BY scraped_at DESC
+			LIMIT 1
+		) rp ON TRUE
+		LEFT JOIN potential_shops ps
+			ON ps.domain = regexp_replace(regexp_replace(s.base_url, '^https?://', ''), '/.*$', '')
+		WHERE h.status = 'degraded'
+		  AND h.reconfig_attempts < $2
+		  AND NOT s.selectors_pinned
+		ORDER BY h.degraded_at ASC NULLS LAST
+		LIMIT $1
+	`
 
-	// Генерируем code из name (как в миграции 0002)
-	// Удаляем все символы кроме букв и цифр, приводим к нижнему регистру
-	shopCode := strings.ToLower(nonAlphanumericRegex.ReplaceAllString(shopName, ""))
-	// Если code пустой (только спецсимволы в названии), используем домен
-	if shopCode == "" {
-		shopCode = strings.ToLower(nonAlphanumericRegex.ReplaceAllString(domain, ""))
-		// Удаляем точку из домена для code
-		shopCode = strings.ReplaceAll(shopCode, ".", "")
+	rows, err := a.pg.DB().Query(a.GetContext(), query, limit, maxReconfigAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query degraded shops: %w", err)
 	}
+	defer rows.Close()
 
-	// Формируем base_url
-	baseURL := domain
-	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
-		baseURL = "https://" + baseURL
+	var shops []autoconfig.DegradedShop
+	for rows.Next() {
+		var shop autoconfig.DegradedShop
+		if err := rows.Scan(&shop.ShopID, &shop.Domain, &shop.SampleURL, &shop.SiteType, &shop.DegradedFields); err != nil {
+			return nil, fmt.Errorf("failed to scan degraded shop: %w", err)
+		}
+		shops = append(shops, shop)
 	}
 
-	// Сериализуем селекторы
+	return shops, rows.Err()
+}
+
+// ApplyReconfiguration сохраняет новые селекторы магазина как версию и активирует её;
+// прежняя версия остаётся в истории для отката, окно детектора начинается заново
+func (a *autoconfigAdapter) ApplyReconfiguration(shopID string, config autoconfig.ShopConfig) error {
 	selectorsJSON, err := json.Marshal(config.Selectors)
 	if err != nil {
 		return fmt.Errorf("failed to marshal selectors: %w", err)
 	}
 
-	// Создаем магазин в таблице shops
-	_, err = tx.Exec(a.GetContext(), `
-		INSERT INTO shops (id, name, code, base_url, selectors, rate_limit, is_active, is_auto_configured, ai_config_model, discovery_source, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
-	`, shopID, shopName, shopCode, baseURL, selectorsJSON, 1, true, true, "gpt-4o-mini", "google_search")
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to insert shop: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
-	// Обновляем статус в potential_shops
-	_, err = tx.Exec(a.GetContext(), `
-		UPDATE potential_shops
-		SET status = 'configured',
-		    updated_at = NOW()
-		WHERE id = $1
-	`, id)
+	version := newAutoconfigVersion(shopID, config, "reconfiguration after selector degradation")
+	created, err := createSelectorVersion(ctx, tx, version)
 	if err != nil {
-		return fmt.Errorf("failed to update potential_shop status: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("shop %s not found", shopID)
+		}
+		return err
+	}
+	// Совпадающие селекторы новую версию не создают, но окно детектора всё равно начинается заново
+	if !created {
+		if err := activateSelectorVersion(ctx, tx, shopID, version.Version); err != nil {
+			return err
+		}
 	}
 
-	// Сохраняем попытку конфигурации в shop_config_attempts
-	// Игнорируем ошибку, так как это не критично для основной операции
-	_, _ = tx.Exec(a.GetContext(), `
-		INSERT INTO shop_config_attempts (potential_shop_id, shop_id, ai_response, validation_result, status, created_at)
-		VALUES ($1, $2, $3, $4, 'success', NOW())
-	`, id, shopID, selectorsJSON, json.RawMessage(`{"validated": true}`))
+	_, err = tx.Exec(ctx, `UPDATE shops SET is_auto_configured = TRUE WHERE id = $1`, shopID)
+	if err != nil {
+		return fmt.Errorf("failed to update shop: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop_config_attempts (shop_id, ai_response, validation_result, status, created_at)
+		VALUES ($1, $2, $3, 'success', NOW())
+	`, shopID, selectorsJSON, json.RawMessage(`{"validated": true, "reconfiguration": true}`))
+	if err != nil {
+		return fmt.Errorf("failed to save reconfiguration attempt: %w", err)
+	}
 
-	// Коммитим транзакцию
-	if err := tx.Commit(a.GetContext()); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-// MarkAsFailed обновляет статус кандидата на "rejected" и сохраняет причину ошибки
-func (a *autoconfigAdapter) MarkAsFailed(id string, reason string) error {
-	// Обновляем статус и метаданные с ошибкой
-	// Используем явное приведение типов для jsonb_build_object
-	query := `
-		UPDATE potential_shops
-		SET status = 'rejected',
-		    metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('autoconfig_error', $2::text, 'autoconfig_failed_at', $3::text),
-		    updated_at = NOW()
-		WHERE id = $1
-	`
+// newAutoconfigVersion версия селекторов от авто-конфигурации; без источника считается сгенерированной AI
+func newAutoconfigVersion(shopID string, config autoconfig.ShopConfig, note string) *selectorversions.Version {
+	source := config.Source
+	if source == "" {
+		source = selectorversions.SourceAI
+	}
+	return &selectorversions.Version{
+		ShopID:    shopID,
+		Selectors: config.Selectors,
+		Source:    source,
+		Author:    config.Author,
+		Note:      note,
+	}
+}
 
-	_, err := a.pg.DB().Exec(a.GetContext(), query, id, reason, time.Now().Format(time.RFC3339))
+// MarkReconfigurationFailed увеличивает счётчик неудачных попыток и сохраняет причину
+func (a *autoconfigAdapter) MarkReconfigurationFailed(shopID string, reason string) error {
+	_, err := a.pg.DB().Exec(a.GetContext(), `
+		UPDATE shop_selector_health
+		SET reconfig_attempts = reconfig_attempts + 1,
+		    last_error = $2,
+		    status = CASE WHEN reconfig_attempts + 1 >= $3 THEN 'failed' ELSE status END,
+		    updated_at = NOW()
+		WHERE shop_id = $1
+	`, shopID, reason, maxReconfigAttempts)
 	if err != nil {
-		return fmt.Errorf("failed to mark as failed: %w", err)
+		return fmt.Errorf("failed to mark reconfiguration as failed: %w", err)
 	}
 
-	// Сохраняем попытку конфигурации в shop_config_attempts
-	// Игнорируем ошибку INSERT - основной статус уже обновлен, попытка - это дополнительная информация
+	// Попытка - дополнительная информация, статус уже обновлён
 	_, insertErr := a.pg.DB().Exec(a.GetContext(), `
-		INSERT INTO shop_config_attempts (potential_shop_id, status, error_message, created_at)
+		INSERT INTO shop_config_attempts (shop_id, status, error_message, created_at)
 		VALUES ($1, 'failed', $2, NOW())
-	`, id, reason)
+	`, shopID, reason)
 	if insertErr != nil {
-		// Возвращаем ошибку, но она будет залогирована в service.go
-		// Основной статус уже обновлен, поэтому это не критично
 		return fmt.Errorf("failed to save attempt (status updated): %w", insertErr)
 	}
 
 	return nil
 }
-
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/brands"
+)
+
+// BrandsAdapter адаптер справочника брендов и написаний брендов в товарах
+type BrandsAdapter struct {
+	*BaseAdapter
+}
+
+// NewBrandsAdapter создаёт новый адаптер справочника брендов
+func NewBrandsAdapter(pg *Postgres) brands.Storage {
+	return &BrandsAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+// ListBrands возвращает все бренды с синонимами и названием производителя
+func (a *BrandsAdapter) ListBrands(ctx context.Context) ([]*brands.Brand, error) {
+	query := `
+		SELECT b.id::text, b.name, b.slug, b.logo_url, b.manufacturer_id::text,
+		       COALESCE(m.name, ''),
+		       COALESCE(
+		           (SELECT array_agg(ba.alias ORDER BY ba.alias) FROM brand_aliases ba WHERE ba.brand_id = b.id),
+		           '{}'
+		       ),
+		       b.created_at, b.updated_at
+		FROM brands b
+		LEFT JOIN brands m ON m.id = b.manufacturer_id
+		ORDER BY LOWER(b.name)
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query brands: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]*brands.Brand, 0)
+	for rows.Next() {
+		var brand brands.Brand
+		if err := rows.Scan(
+			&brand.ID,
+			&brand.Name,
+			&brand.Slug,
+			&brand.LogoURL,
+			&brand.ManufacturerID,
+			&brand.Manufacturer,
+			&brand.Aliases,
+			&brand.CreatedAt,
+			&brand.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan brand: %w", err)
+		}
+		list = append(list, &brand)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brands: %w", err)
+	}
+
+	return list, nil
+}
+
+// SaveBrand создаёт бренд (пустой ID) или обновляет существующий
+func (a *BrandsAdapter) SaveBrand(ctx context.Context, brand *brands.Brand) error {
+	if brand.ID == "" {
+		err := a.pg.DB().QueryRow(ctx, `
+			INSERT INTO brands (name, slug, logo_url, manufacturer_id)
+			VALUES ($1, $2, $3, $4::uuid)
+			RETURNING id::text, created_at, updated_at
+		`, brand.Name, brand.Slug, brand.LogoURL, brand.ManufacturerID).Scan(&brand.ID, &brand.CreatedAt, &brand.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create brand: %w", err)
+		}
+		return nil
+	}
+
+	brandUUID, err := a.ParseUUID(brand.ID)
+	if err != nil {
+		return brands.ErrBrandNotFound
+	}
+	err = a.pg.DB().QueryRow(ctx, `
+		UPDATE brands SET
+			name            = $2,
+			slug            = $3,
+			logo_url        = $4,
+			manufacturer_id = $5::uuid,
+			updated_at      = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, brandUUID, brand.Name, brand.Slug, brand.LogoURL, brand.ManufacturerID).Scan(&brand.CreatedAt, &brand.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return brands.ErrBrandNotFound
+		}
+		return fmt.Errorf("failed to update brand: %w", err)
+	}
+	return nil
+}
+
+// DeleteBrand удаляет бренд вместе с синонимами
+func (a *BrandsAdapter) DeleteBrand(ctx context.Context, id string) error {
+	brandUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return brands.ErrBrandNotFound
+	}
+
+	tag, err := a.pg.DB().Exec(ctx, `DELETE FROM brands WHERE id = $1`, brandUUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete brand: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return brands.ErrBrandNotFound
+	}
+	return nil
+}
+
+// SaveAlias привязывает написание к бренду; повторная привязка обновляет написание
+func (a *BrandsAdapter) SaveAlias(ctx context.Context, brandID, aliasKey, alias string) error {
+	_, err := a.pg.DB().Exec(ctx, `
+		INSERT INTO brand_aliases (alias_key, alias, brand_id)
+		VALUES ($1, $2, $3::uuid)
+		ON CONFLICT (alias_key) DO UPDATE SET
+			alias    = EXCLUDED.alias,
+			brand_id = EXCLUDED.brand_id
+	`, aliasKey, alias, brandID)
+	if err != nil {
+		return fmt.Errorf("failed to save brand alias: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlias удаляет синоним по ключу написания
+func (a *BrandsAdapter) DeleteAlias(ctx context.Context, aliasKey string) error {
+	tag, err := a.pg.DB().Exec(ctx, `DELETE FROM brand_aliases WHERE alias_key = $1`, aliasKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete brand alias: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return brands.ErrAliasNotFound
+	}
+	return nil
+}
+
+// ListBrandUsage возвращает написания брендов в товарах с числом товаров
+func (a *BrandsAdapter) ListBrandUsage(ctx context.Context) ([]*brands.Usage, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT TRIM(brand), COUNT(*)
+		FROM products
+		WHERE brand IS NOT NULL AND TRIM(brand) <> ''
+		GROUP BY TRIM(brand)
+		ORDER BY COUNT(*) DESC, TRIM(brand)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query brand usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make([]*brands.Usage, 0)
+	for rows.Next() {
+		var u brands.Usage
+		if err := rows.Scan(&u.Brand, &u.Products); err != nil {
+			return nil, fmt.Errorf("failed to scan brand usage: %w", err)
+		}
+		usage = append(usage, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brand usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// RenameProductBrands заменяет написания from на каноническое название to в товарах;
+// updated_at обновляется, чтобы документы попали в инкрементальную индексацию
+func (a *BrandsAdapter) RenameProductBrands(ctx context.Context, from []string, to string) (int64, error) {
+	if len(from) == 0 {
+		return 0, nil
+	}
+
+	tag, err := a.pg.DB().Exec(ctx, `
+		UPDATE products
+		SET brand = $2, updated_at = NOW()
+		WHERE TRIM(brand) = ANY($1::text[])
+		  AND brand <> $2
+	`, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename product brands: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
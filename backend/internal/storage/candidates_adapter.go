@@ -0,0 +1,380 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/autoconfig"
+	"github.com/solomonczyk/izborator/internal/candidates"
+	"github.com/solomonczyk/izborator/internal/scraper"
+)
+
+// CandidatesAdapter адаптер для конвейера кандидатов в магазины
+type CandidatesAdapter struct {
+	*BaseAdapter
+}
+
+// NewCandidatesAdapter создаёт новый адаптер кандидатов
+func NewCandidatesAdapter(pg *Postgres) candidates.Storage {
+	return &CandidatesAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+const candidateColumns = `
+	id, domain, COALESCE(source, ''), status, COALESCE(confidence_score, 0), COALESCE(metadata->>'site_type', ''),
+	attempts, COALESCE(last_error, ''), config, COALESCE(shop_id, ''), metadata,
+	COALESCE(discovered_at, created_at), state_changed_at
+`
+
+// ListCandidates возвращает страницу кандидатов, недавно изменённые первыми
+func (a *CandidatesAdapter) ListCandidates(ctx context.Context, filter candidates.ListFilter) ([]*candidates.Candidate, int, error) {
+	var total int
+	err := a.pg.DB().QueryRow(ctx, `
+		SELECT COUNT(*) FROM potential_shops WHERE ($1::VARCHAR = '' OR status = $1::VARCHAR)
+	`, string(filter.Status)).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count candidates: %w", err)
+	}
+
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT `+candidateColumns+`
+		FROM potential_shops
+		WHERE ($1::VARCHAR = '' OR status = $1::VARCHAR)
+		ORDER BY state_changed_at DESC, domain
+		LIMIT $2 OFFSET $3
+	`, string(filter.Status), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*candidates.Candidate
+	for rows.Next() {
+		candidate, err := scanCandidate(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating candidates: %w", err)
+	}
+	return items, total, nil
+}
+
+// GetCandidate возвращает кандидата по ID
+func (a *CandidatesAdapter) GetCandidate(ctx context.Context, id string) (*candidates.Candidate, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, candidates.ErrCandidateNotFound
+	}
+
+	row := a.pg.DB().QueryRow(ctx, `
+		SELECT `+candidateColumns+`
+		FROM potential_shops
+		WHERE id = $1
+	`, id)
+	candidate, err := scanCandidate(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, candidates.ErrCandidateNotFound
+	}
+	return candidate, err
+}
+
+// ListTransitions возвращает историю переходов кандидата, старые первыми
+func (a *CandidatesAdapter) ListTransitions(ctx context.Context, id string) ([]*candidates.Transition, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT from_status, to_status, COALESCE(reason, ''), actor, created_at
+		FROM potential_shop_transitions
+		WHERE potential_shop_id = $1
+		ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candidate transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*candidates.Transition
+	for rows.Next() {
+		var t candidates.Transition
+		var from *string
+		var to string
+		if err := rows.Scan(&from, &to, &t.Reason, &t.Actor, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan candidate transition: %w", err)
+		}
+		if from != nil {
+			status := candidates.Status(*from)
+			t.From = &status
+		}
+		t.To = candidates.Status(to)
+		history = append(history, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating candidate transitions: %w", err)
+	}
+	return history, nil
+}
+
+// Transition переводит кандидата в статус to; возврат в classified сбрасывает попытки
+func (a *CandidatesAdapter) Transition(ctx context.Context, id string, to candidates.Status, reason, actor string) error {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := transitionCandidate(ctx, tx, id, to, reason, actor); err != nil {
+		return err
+	}
+
+	if to == candidates.StatusClassified {
+		_, err = tx.Exec(ctx, `
+			UPDATE potential_shops SET attempts = 0, last_error = NULL WHERE id = $1
+		`, id)
+		if err != nil {
+			return fmt.Errorf("failed to reset candidate attempts: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Approve создаёт неактивный магазин из кандидата с первой версией селекторов
+func (a *CandidatesAdapter) Approve(ctx context.Context, id, actor, note string) (string, error) {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := transitionCandidate(ctx, tx, id, candidates.StatusApproved, note, actor); err != nil {
+		return "", err
+	}
+
+	var domain, source string
+	var metadataJSON, configJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT domain, COALESCE(source, ''), metadata, config
+		FROM potential_shops
+		WHERE id = $1
+	`, id).Scan(&domain, &source, &metadataJSON, &configJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to get candidate: %w", err)
+	}
+
+	var config autoconfig.ShopConfig
+	if len(configJSON) > 0 {
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return "", fmt.Errorf("failed to unmarshal candidate config: %w", err)
+		}
+	}
+	if len(config.Selectors) == 0 {
+		return "", candidates.ErrNoConfig
+	}
+
+	shopID, err := createCandidateShop(ctx, tx, domain, source, metadataJSON, config)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE potential_shops SET shop_id = $2 WHERE id = $1`, id, shopID)
+	if err != nil {
+		return "", fmt.Errorf("failed to link candidate to shop: %w", err)
+	}
+
+	// Успешная попытка авто-конфигурации теперь относится к созданному магазину
+	_, err = tx.Exec(ctx, `
+		UPDATE shop_config_attempts
+		SET shop_id = $2
+		WHERE potential_shop_id = $1 AND status = 'success' AND shop_id IS NULL
+	`, id, shopID)
+	if err != nil {
+		return "", fmt.Errorf("failed to link config attempts to shop: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return shopID, nil
+}
+
+// GoLive активирует магазин кандидата
+func (a *CandidatesAdapter) GoLive(ctx context.Context, id, actor, note string) error {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := transitionCandidate(ctx, tx, id, candidates.StatusLive, note, actor); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE shops s
+		SET is_active = TRUE, updated_at = NOW()
+		FROM potential_shops ps
+		WHERE ps.id = $1 AND s.id = ps.shop_id
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to activate shop: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("candidate %s has no shop to activate", id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// transitionCandidate переводит заблокированного кандидата в статус to и записывает переход в историю.
+// Общий для discovery, классификатора, авто-конфигурации и административного API
+func transitionCandidate(ctx context.Context, tx pgx.Tx, id string, to candidates.Status, reason, actor string) (candidates.Status, error) {
+	var current string
+	err := tx.QueryRow(ctx, `SELECT status FROM potential_shops WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", candidates.ErrCandidateNotFound
+		}
+		return "", fmt.Errorf("failed to lock candidate: %w", err)
+	}
+
+	from := candidates.Status(current)
+	if err := candidates.CheckTransition(from, to); err != nil {
+		return from, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE potential_shops
+		SET status = $2::VARCHAR,
+		    state_changed_at = NOW(),
+		    classified_at = CASE WHEN $2::VARCHAR = 'classified' THEN COALESCE(classified_at, NOW()) ELSE classified_at END
+		WHERE id = $1
+	`, id, string(to))
+	if err != nil {
+		return from, fmt.Errorf("failed to update candidate status: %w", err)
+	}
+
+	if err := recordCandidateTransition(ctx, tx, id, &from, to, reason, actor); err != nil {
+		return from, err
+	}
+	return from, nil
+}
+
+// recordCandidateTransition добавляет запись в историю переходов кандидата
+func recordCandidateTransition(ctx context.Context, tx pgx.Tx, id string, from *candidates.Status, to candidates.Status, reason, actor string) error {
+	var fromStatus *string
+	if from != nil {
+		status := string(*from)
+		fromStatus = &status
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO potential_shop_transitions (potential_shop_id, from_status, to_status, reason, actor)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, id, fromStatus, string(to), reason, actor)
+	if err != nil {
+		return fmt.Errorf("failed to record candidate transition: %w", err)
+	}
+	return nil
+}
+
+// createCandidateShop создаёт неактивный магазин с селекторами кандидата и первой версией селекторов
+func createCandidateShop(ctx context.Context, tx pgx.Tx, domain, source string, metadataJSON []byte, config autoconfig.ShopConfig) (string, error) {
+	// Название магазина и тип сайта из метаданных; по умолчанию домен и ecommerce
+	shopName := domain
+	siteType := scraper.SiteTypeEcommerce
+	if metadataJSON != nil {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal(metadataJSON, &metadata); err == nil {
+			if title, ok := metadata["title"].(string); ok && title != "" {
+				shopName = title
+			}
+			if metadata["site_type"] == scraper.SiteTypeServiceProvider {
+				siteType = scraper.SiteTypeServiceProvider
+			}
+		}
+	}
+
+	shopID := uuid.New().String()
+
+	// code из названия (как в миграции 0002); если в названии только спецсимволы - из домена
+	shopCode := strings.ToLower(nonAlphanumericRegex.ReplaceAllString(shopName, ""))
+	if shopCode == "" {
+		shopCode = strings.ToLower(nonAlphanumericRegex.ReplaceAllString(domain, ""))
+	}
+
+	baseURL := domain
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+
+	selectorsJSON, err := json.Marshal(config.Selectors)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal selectors: %w", err)
+	}
+
+	// Магазин неактивен до перевода кандидата в live
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shops (id, name, code, base_url, selectors, rate_limit, is_active, is_auto_configured, ai_config_model, discovery_source, site_type, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 1, FALSE, TRUE, NULLIF($6, ''), NULLIF($7, ''), $8, NOW(), NOW())
+	`, shopID, shopName, shopCode, baseURL, selectorsJSON, config.Author, source, siteType)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert shop: %w", err)
+	}
+
+	if _, err := createSelectorVersion(ctx, tx, newAutoconfigVersion(shopID, config, "initial auto-configuration")); err != nil {
+		return "", fmt.Errorf("failed to save selector version: %w", err)
+	}
+	return shopID, nil
+}
+
+// scanCandidate читает строку potential_shops в кандидата
+func scanCandidate(row pgx.Row) (*candidates.Candidate, error) {
+	var c candidates.Candidate
+	var status string
+	var configJSON, metadataJSON []byte
+	err := row.Scan(
+		&c.ID, &c.Domain, &c.Source, &status, &c.ConfidenceScore, &c.SiteType,
+		&c.Attempts, &c.LastError, &configJSON, &c.ShopID, &metadataJSON,
+		&c.DiscoveredAt, &c.StateChangedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan candidate: %w", err)
+	}
+	c.Status = candidates.Status(status)
+
+	if len(configJSON) > 0 {
+		var config autoconfig.ShopConfig
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal candidate config: %w", err)
+		}
+		c.Config = &candidates.Config{Selectors: config.Selectors, Source: string(config.Source), Author: config.Author}
+	}
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &c.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal candidate metadata: %w", err)
+		}
+	}
+	return &c, nil
+}
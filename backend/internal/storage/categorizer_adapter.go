@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/categorizer"
+)
+
+// CategorizerAdapter адаптер справочника категорий, сопоставлений категорий магазинов и категорий товаров
+type CategorizerAdapter struct {
+	*BaseAdapter
+}
+
+// NewCategorizerAdapter создаёт новый адаптер определения категорий
+func NewCategorizerAdapter(pg *Postgres) categorizer.Storage {
+	return &CategorizerAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+// LoadCatalog возвращает активные категории с активными типами товаров, глубокие уровни первыми
+func (a *CategorizerAdapter) LoadCatalog(ctx context.Context) ([]*categorizer.CatalogCategory, error) {
+	query := `
+		SELECT c.id::text, c.parent_id::text, c.code, c.slug, c.name_sr, c.level,
+		       COALESCE(
+		           array_agg(pt.id::text ORDER BY pt.code) FILTER (WHERE pt.id IS NOT NULL),
+		           '{}'
+		       )
+		FROM categories c
+		LEFT JOIN category_product_types cpt ON cpt.category_id = c.id
+		LEFT JOIN product_types pt ON pt.id = cpt.product_type_id AND pt.is_active = TRUE
+		WHERE c.is_active = TRUE
+		GROUP BY c.id, c.parent_id, c.code, c.slug, c.name_sr, c.level, c.sort_order
+		ORDER BY c.level DESC, c.sort_order, c.code
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	catalog := make([]*categorizer.CatalogCategory, 0)
+	for rows.Next() {
+		var category categorizer.CatalogCategory
+		if err := rows.Scan(
+			&category.ID,
+			&category.ParentID,
+			&category.Code,
+			&category.Slug,
+			&category.NameSr,
+			&category.Level,
+			&category.ProductTypeIDs,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		catalog = append(catalog, &category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+
+	return catalog, nil
+}
+
+const shopMappingColumns = `
+	id::text, shop_id, source_category, category_id::text, product_type_id::text,
+	confidence, created_at, updated_at
+`
+
+// ListShopMappings возвращает сопоставления магазина; пустой shopID - всех магазинов
+func (a *CategorizerAdapter) ListShopMappings(ctx context.Context, shopID string) ([]*categorizer.ShopMapping, error) {
+	query := `SELECT ` + shopMappingColumns + `
+		FROM shop_category_mappings
+		WHERE ($1 = '' OR shop_id = $1)
+		ORDER BY shop_id, source_category
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shop category mappings: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := make([]*categorizer.ShopMapping, 0)
+	for rows.Next() {
+		mapping, err := scanShopMapping(rows)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shop category mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// SaveShopMapping создаёт или обновляет сопоставление по (shop_id, source_category)
+func (a *CategorizerAdapter) SaveShopMapping(ctx context.Context, mapping *categorizer.ShopMapping) error {
+	query := `
+		INSERT INTO shop_category_mappings (shop_id, source_category, category_id, product_type_id, confidence)
+		VALUES ($1, $2, $3::uuid, $4::uuid, $5)
+		ON CONFLICT (shop_id, source_category) DO UPDATE SET
+			category_id     = EXCLUDED.category_id,
+			product_type_id = EXCLUDED.product_type_id,
+			confidence      = EXCLUDED.confidence,
+			updated_at      = NOW()
+		RETURNING id::text, created_at, updated_at
+	`
+
+	err := a.pg.DB().QueryRow(ctx, query,
+		mapping.ShopID,
+		mapping.SourceCategory,
+		mapping.CategoryID,
+		mapping.ProductTypeID,
+		mapping.Confidence,
+	).Scan(&mapping.ID, &mapping.CreatedAt, &mapping.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save shop category mapping: %w", err)
+	}
+	return nil
+}
+
+// DeleteShopMapping удаляет сопоставление
+func (a *CategorizerAdapter) DeleteShopMapping(ctx context.Context, id string) error {
+	mappingUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return categorizer.ErrMappingNotFound
+	}
+
+	tag, err := a.pg.DB().Exec(ctx, `DELETE FROM shop_category_mappings WHERE id = $1`, mappingUUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete shop category mapping: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return categorizer.ErrMappingNotFound
+	}
+	return nil
+}
+
+// AssignCategory записывает категорию товара, если её нет или она назначена автоматически
+// с меньшей уверенностью (category_source NULL - категория задана вручную).
+// Возвращает true, если категория или тип товара изменились
+func (a *CategorizerAdapter) AssignCategory(ctx context.Context, productID string, result *categorizer.Result) (bool, error) {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return false, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	query := `
+		WITH prev AS (
+			SELECT id, category_id, product_type_id
+			FROM products
+			WHERE id = $1
+			FOR UPDATE
+		)
+		UPDATE products p SET
+			category_id         = $2::uuid,
+			product_type_id     = $3::uuid,
+			category_source     = $4,
+			category_confidence = $5,
+			updated_at          = NOW()
+		FROM prev
+		WHERE p.id = prev.id
+		  AND (
+		      prev.category_id IS NULL
+		      OR (p.category_source IS NOT NULL AND COALESCE(p.category_confidence, 0) < $5)
+		  )
+		RETURNING prev.category_id IS DISTINCT FROM p.category_id
+		       OR prev.product_type_id IS DISTINCT FROM p.product_type_id
+	`
+
+	var changed bool
+	err = a.pg.DB().QueryRow(ctx, query,
+		productUUID,
+		result.CategoryID,
+		result.ProductTypeID,
+		string(result.Source),
+		result.Confidence,
+	).Scan(&changed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to assign product category: %w", err)
+	}
+	return changed, nil
+}
+
+// ListUncategorized возвращает товары без категории с ID больше afterID;
+// магазин и категория магазина берутся из последнего привязанного сырого товара
+func (a *CategorizerAdapter) ListUncategorized(ctx context.Context, afterID string, limit int) ([]*categorizer.Product, error) {
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+
+	query := `
+		SELECT p.id::text, COALESCE(rp.shop_id, ''),
+		       COALESCE(NULLIF(rp.category, ''), p.category, ''), p.name
+		FROM products p
+		LEFT JOIN LATERAL (
+			SELECT r.shop_id, r.category
+			FROM raw_products r
+			WHERE r.product_id = p.id
+			ORDER BY r.created_at DESC
+			LIMIT 1
+		) rp ON TRUE
+		WHERE p.category_id IS NULL
+		  AND p.id > $1::uuid
+		ORDER BY p.id
+		LIMIT $2
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uncategorized products: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*categorizer.Product, 0, limit)
+	for rows.Next() {
+		var item categorizer.Product
+		if err := rows.Scan(&item.ID, &item.ShopID, &item.Category, &item.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan uncategorized product: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating uncategorized products: %w", err)
+	}
+
+	return items, nil
+}
+
+// scanShopMapping читает сопоставление категории магазина
+func scanShopMapping(row pgx.Row) (*categorizer.ShopMapping, error) {
+	var mapping categorizer.ShopMapping
+	if err := row.Scan(
+		&mapping.ID,
+		&mapping.ShopID,
+		&mapping.SourceCategory,
+		&mapping.CategoryID,
+		&mapping.ProductTypeID,
+		&mapping.Confidence,
+		&mapping.CreatedAt,
+		&mapping.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan shop category mapping: %w", err)
+	}
+	return &mapping, nil
+}
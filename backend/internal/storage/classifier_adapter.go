@@ -2,10 +2,12 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/candidates"
 	"github.com/solomonczyk/izborator/internal/classifier"
 )
 
@@ -21,14 +23,16 @@ func NewClassifierAdapter(pg *Postgres) classifier.Storage {
 	}
 }
 
-// SavePotentialShop сохраняет кандидата на магазин
+// SavePotentialShop сохраняет кандидата на магазин; новый кандидат получает первую запись истории переходов.
+// У известного домена дополняются метаданные, источник и статус остаются прежними
 func (a *classifierAdapter) SavePotentialShop(shop *classifier.PotentialShop) error {
 	query := `
 		INSERT INTO potential_shops (id, domain, source, status, confidence_score, discovered_at, metadata)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (domain) DO UPDATE SET
-			source = EXCLUDED.source,
+			metadata = COALESCE(potential_shops.metadata, '{}'::jsonb) || COALESCE(EXCLUDED.metadata, '{}'::jsonb),
 			updated_at = NOW()
+		RETURNING id, (xmax = 0) AS inserted
 	`
 
 	var metadataJSON []byte
@@ -51,17 +55,46 @@ func (a *classifierAdapter) SavePotentialShop(shop *classifier.PotentialShop) er
 		discoveredAt = time.Now()
 	}
 
-	_, err := a.pg.DB().Exec(a.GetContext(), query,
+	status := candidates.Status(shop.Status)
+	if status == "" {
+		status = candidates.StatusDiscovered
+	}
+
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var id string
+	var inserted bool
+	err = tx.QueryRow(ctx, query,
 		shop.ID,
 		shop.Domain,
 		shop.Source,
-		shop.Status,
+		string(status),
 		shop.ConfidenceScore,
 		discoveredAt,
 		metadataJSON,
-	)
+	).Scan(&id, &inserted)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if inserted {
+		reason := shop.StatusReason
+		if reason == "" && shop.Source != "" {
+			reason = "found by " + shop.Source
+		}
+		if err := recordCandidateTransition(ctx, tx, id, nil, status, reason, candidates.ActorDiscovery); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 // GetPotentialShopByDomain получает кандидата по домену
@@ -160,20 +193,10 @@ func (a *classifierAdapter) ListPotentialShopsByStatus(status string, limit int)
 	return shops, rows.Err()
 }
 
-// UpdatePotentialShop обновляет кандидата
+// UpdatePotentialShop обновляет оценку и метаданные кандидата; смена статуса проходит через конвейер кандидатов
+// и записывается в историю переходов.
 // Используем поиск по domain вместо id, так как domain уникален и является строкой
-// Явно указываем тип параметра, чтобы избежать ошибки "inconsistent types deduced for parameter" (42P08)
 func (a *classifierAdapter) UpdatePotentialShop(shop *classifier.PotentialShop) error {
-	query := `
-		UPDATE potential_shops
-		SET status = $2::VARCHAR,
-		    confidence_score = $3::FLOAT,
-		    classified_at = CASE WHEN $2::VARCHAR IN ('classified', 'configured') THEN COALESCE(classified_at, NOW()) ELSE classified_at END,
-		    metadata = COALESCE($4::jsonb, metadata),
-		    updated_at = NOW()
-		WHERE domain = $1::VARCHAR
-	`
-
 	// Проверяем, что domain не пустой
 	if shop.Domain == "" {
 		return fmt.Errorf("shop.Domain is empty for id=%s", shop.ID)
@@ -192,37 +215,40 @@ func (a *classifierAdapter) UpdatePotentialShop(shop *classifier.PotentialShop)
 		metadataJSON = []byte("{}")
 	}
 
-	// Выполняем запрос
-	// $1 = shop.Domain (string) - используется в WHERE
-	// $2 = shop.Status (string)
-	// $3 = shop.ConfidenceScore (float)
-	// $4 = metadataJSON (jsonb)
-	result, err := a.pg.DB().Exec(a.GetContext(), query,
-		shop.Domain,
-		shop.Status,
-		shop.ConfidenceScore,
-		metadataJSON,
-	)
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
+	// Явно указываем типы параметров, чтобы избежать ошибки "inconsistent types deduced for parameter" (42P08)
+	var id, current string
+	err = tx.QueryRow(ctx, `
+		UPDATE potential_shops
+		SET confidence_score = $2::FLOAT,
+		    metadata = COALESCE($3::jsonb, metadata),
+		    updated_at = NOW()
+		WHERE domain = $1::VARCHAR
+		RETURNING id, status
+	`, shop.Domain, shop.ConfidenceScore, metadataJSON).Scan(&id, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no rows updated for potential_shop (domain=%s) - record does not exist", shop.Domain)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update potential_shop (domain=%s, status=%s): %w", shop.Domain, shop.Status, err)
+		return fmt.Errorf("failed to update potential_shop (domain=%s): %w", shop.Domain, err)
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		// Проверяем, существует ли запись с таким domain
-		var exists bool
-		checkQuery := `SELECT EXISTS(SELECT 1 FROM potential_shops WHERE domain = $1)`
-		err := a.pg.DB().QueryRow(a.GetContext(), checkQuery, shop.Domain).Scan(&exists)
-		if err != nil {
-			return fmt.Errorf("no rows updated for potential_shop (domain=%s) - failed to check existence: %w", shop.Domain, err)
-		}
-		if !exists {
-			return fmt.Errorf("no rows updated for potential_shop (domain=%s) - record does not exist", shop.Domain)
+	if shop.Status != "" && shop.Status != current {
+		if _, err := transitionCandidate(ctx, tx, id, candidates.Status(shop.Status), shop.StatusReason, candidates.ActorClassifier); err != nil {
+			return fmt.Errorf("failed to update potential_shop status (domain=%s, status=%s): %w", shop.Domain, shop.Status, err)
 		}
-		return fmt.Errorf("no rows updated for potential_shop (domain=%s) - record exists but update failed", shop.Domain)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
-
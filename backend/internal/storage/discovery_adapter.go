@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/discovery"
+)
+
+// discoveryAdapter реализация discovery.KnownShops
+type discoveryAdapter struct {
+	*BaseAdapter
+}
+
+// NewDiscoveryAdapter создаёт новый адаптер для discovery
+func NewDiscoveryAdapter(pg *Postgres) discovery.KnownShops {
+	return &discoveryAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+// ListShopURLs возвращает base_url всех магазинов, включая неактивные
+func (a *discoveryAdapter) ListShopURLs(ctx context.Context) ([]string, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT base_url
+		FROM shops
+		WHERE base_url IS NOT NULL AND base_url <> ''
+		ORDER BY base_url
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shop urls: %w", err)
+	}
+	urls, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan shop urls: %w", err)
+	}
+	return urls, nil
+}
@@ -0,0 +1,533 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/solomonczyk/izborator/internal/indexing"
+)
+
+// ProductsIndexName имя индекса товаров в Meilisearch
+const ProductsIndexName = "products"
+
+// IndexingAdapter адаптер для синхронизации товаров из PostgreSQL в Meilisearch
+type IndexingAdapter struct {
+	*BaseAdapter
+	meili *Meilisearch
+}
+
+// NewIndexingAdapter создаёт новый адаптер индексации
+func NewIndexingAdapter(pg *Postgres, meili *Meilisearch) indexing.Storage {
+	return &IndexingAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+		meili:       meili,
+	}
+}
+
+// productDocumentQuery собирает документ товара вместе с агрегатами по ценам
+// одним запросом (без отдельного запроса магазинов на каждый товар).
+// Цены разных валют не сравнимы, поэтому min/max и цена за единицу считаются только
+// в основной валюте товара - как products.primaryCurrency на карточке товара:
+// больше всего предложений в наличии (без наличия - всех предложений), при равенстве меньший код
+const productDocumentQuery = `
+	SELECT
+		p.id::text, p.name, p.description, p.brand, p.category, p.category_id::text,
+		p.image_url, p.specs, p.type, p.created_at, p.updated_at,
+		COALESCE(agg.shop_names, '{}'), COALESCE(agg.shops_count, 0),
+		agg.min_price, agg.max_price, cur.currency, agg.min_unit_price, agg.unit, av.attrs
+	FROM products p
+	LEFT JOIN LATERAL (
+		SELECT pp.currency
+		FROM product_prices pp
+		WHERE pp.product_id = p.id
+		GROUP BY pp.currency
+		ORDER BY
+			COUNT(*) FILTER (WHERE pp.in_stock) DESC,
+			CASE WHEN SUM(COUNT(*) FILTER (WHERE pp.in_stock)) OVER () = 0 THEN COUNT(*) END DESC NULLS LAST,
+			pp.currency
+		LIMIT 1
+	) cur ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT
+			array_agg(DISTINCT s.name) FILTER (WHERE s.name IS NOT NULL AND s.name <> '') AS shop_names,
+			COUNT(DISTINCT pp.shop_id) AS shops_count,
+			(MIN(pp.price) FILTER (WHERE pp.currency = cur.currency))::float8 AS min_price,
+			(MAX(pp.price) FILTER (WHERE pp.currency = cur.currency))::float8 AS max_price,
+			(MIN(pp.unit_price) FILTER (WHERE pp.currency = cur.currency))::float8 AS min_unit_price,
+			(array_agg(pp.pack_unit ORDER BY pp.unit_price)
+				FILTER (WHERE pp.unit_price IS NOT NULL AND pp.currency = cur.currency))[1] AS unit
+		FROM product_prices pp
+		LEFT JOIN shops s ON s.id = pp.shop_id
+		WHERE pp.product_id = p.id
+	) agg ON TRUE
+	LEFT JOIN LATERAL (
+		-- Строковые значения в нижнем регистре: фильтры attrs.<code> не зависят от регистра
+		SELECT jsonb_object_agg(
+			LOWER(a.code),
+			COALESCE(to_jsonb(pav.value_number), to_jsonb(LOWER(pav.value_text)), to_jsonb(pav.value_bool))
+		) AS attrs
+		FROM product_attribute_values pav
+		JOIN attributes a ON a.id = pav.attribute_id
+		WHERE pav.product_id = p.id
+	) av ON TRUE
+`
+
+// LoadDocuments собирает документы для указанных товаров
+func (a *IndexingAdapter) LoadDocuments(ctx context.Context, productIDs []string) ([]*indexing.Document, error) {
+	ids := make([]uuid.UUID, 0, len(productIDs))
+	for _, id := range productIDs {
+		parsed, err := a.ParseUUID(id)
+		if err != nil {
+			// Невалидный ID не может быть в БД - документ будет удалён из индекса
+			continue
+		}
+		ids = append(ids, parsed)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := a.pg.DB().Query(ctx, productDocumentQuery+` WHERE p.id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	return scanProductDocuments(rows)
+}
+
+// LoadChangedDocuments собирает документы товаров, изменённых после since
+func (a *IndexingAdapter) LoadChangedDocuments(ctx context.Context, since time.Time, afterID string, limit int) ([]*indexing.Document, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if !since.IsZero() {
+		args = append(args, since)
+		conditions = append(conditions, fmt.Sprintf(`(
+			p.updated_at > $%[1]d
+			OR EXISTS (
+				SELECT 1 FROM product_prices pp
+				WHERE pp.product_id = p.id AND pp.updated_at > $%[1]d
+			)
+			OR EXISTS (
+				SELECT 1 FROM product_attribute_values pav
+				WHERE pav.product_id = p.id AND pav.updated_at > $%[1]d
+			)
+		)`, len(args)))
+	}
+
+	if afterID != "" {
+		parsed, err := a.ParseUUID(afterID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		args = append(args, parsed)
+		conditions = append(conditions, fmt.Sprintf("p.id > $%d", len(args)))
+	}
+
+	query := productDocumentQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY p.id LIMIT $%d", len(args))
+
+	rows, err := a.pg.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed products: %w", err)
+	}
+	return scanProductDocuments(rows)
+}
+
+// scanProductDocuments читает строки productDocumentQuery
+func scanProductDocuments(rows pgx.Rows) ([]*indexing.Document, error) {
+	defer rows.Close()
+
+	var docs []*indexing.Document
+	for rows.Next() {
+		var (
+			doc                                           indexing.Document
+			description, brand, category, imageURL, pType *string
+			specsJSON, attrsJSON                          []byte
+			createdAt, updatedAt                          *time.Time
+			currency, unit                                *string
+		)
+
+		if err := rows.Scan(
+			&doc.ID,
+			&doc.Name,
+			&description,
+			&brand,
+			&category,
+			&doc.CategoryID,
+			&imageURL,
+			&specsJSON,
+			&pType,
+			&createdAt,
+			&updatedAt,
+			&doc.ShopNames,
+			&doc.ShopsCount,
+			&doc.MinPrice,
+			&doc.MaxPrice,
+			&currency,
+			&doc.UnitPrice,
+			&unit,
+			&attrsJSON,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+
+		if description != nil {
+			doc.Description = *description
+		}
+		if brand != nil {
+			doc.Brand = *brand
+		}
+		if category != nil {
+			doc.Category = *category
+		}
+		if imageURL != nil {
+			doc.ImageURL = *imageURL
+		}
+		if currency != nil {
+			doc.Currency = *currency
+		}
+		if unit != nil {
+			doc.Unit = *unit
+		}
+		// По умолчанию "good" для обратной совместимости
+		doc.Type = "good"
+		if pType != nil && *pType != "" {
+			doc.Type = *pType
+		}
+		if createdAt != nil {
+			doc.CreatedAt = createdAt.Format(time.RFC3339)
+		}
+		if updatedAt != nil {
+			doc.UpdatedAt = updatedAt.Format(time.RFC3339)
+		}
+		if len(specsJSON) > 0 {
+			var specs map[string]interface{}
+			if err := json.Unmarshal(specsJSON, &specs); err == nil {
+				doc.Specs = specs
+			}
+		}
+		if len(attrsJSON) > 0 {
+			var attrs map[string]interface{}
+			if err := json.Unmarshal(attrsJSON, &attrs); err == nil {
+				doc.Attrs = attrs
+			}
+		}
+
+		docs = append(docs, &doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	return docs, nil
+}
+
+// UpsertDocuments добавляет или заменяет документы в рабочем индексе
+func (a *IndexingAdapter) UpsertDocuments(ctx context.Context, docs []*indexing.Document) error {
+	return a.UpsertDocumentsTo(ctx, ProductsIndexName, docs)
+}
+
+// UpsertDocumentsTo добавляет или заменяет документы в индексе и ждёт завершения задачи Meilisearch
+func (a *IndexingAdapter) UpsertDocumentsTo(ctx context.Context, indexName string, docs []*indexing.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	index, err := a.indexByName(indexName)
+	if err != nil {
+		return err
+	}
+
+	task, err := index.AddDocuments(docs, "id")
+	if err != nil {
+		return fmt.Errorf("failed to add documents: %w", err)
+	}
+	return waitForMeiliTask(ctx, index, task)
+}
+
+// DeleteDocuments удаляет документы из индекса
+func (a *IndexingAdapter) DeleteDocuments(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	index, err := a.index()
+	if err != nil {
+		return err
+	}
+
+	task, err := index.DeleteDocuments(ids)
+	if err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return waitForMeiliTask(ctx, index, task)
+}
+
+// DeleteAllDocuments очищает индекс
+func (a *IndexingAdapter) DeleteAllDocuments(ctx context.Context) error {
+	index, err := a.index()
+	if err != nil {
+		return err
+	}
+
+	task, err := index.DeleteAllDocuments()
+	if err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return waitForMeiliTask(ctx, index, task)
+}
+
+// ListDeletedProducts возвращает товары, удалённые после since
+func (a *IndexingAdapter) ListDeletedProducts(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := a.pg.DB().Query(ctx,
+		`SELECT DISTINCT product_id::text FROM product_deletions WHERE deleted_at > $1`, since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted products: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted product: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted products: %w", err)
+	}
+	return ids, nil
+}
+
+// PruneDeletedProducts очищает журнал удалений до before
+func (a *IndexingAdapter) PruneDeletedProducts(ctx context.Context, before time.Time) error {
+	if _, err := a.pg.DB().Exec(ctx, `DELETE FROM product_deletions WHERE deleted_at <= $1`, before); err != nil {
+		return fmt.Errorf("failed to prune deleted products: %w", err)
+	}
+	return nil
+}
+
+// TryLockRebuild берёт advisory lock пересборки индекса
+func (a *IndexingAdapter) TryLockRebuild(ctx context.Context) (func(), bool, error) {
+	return a.pg.tryAdvisoryLock(ctx, indexRebuildLock)
+}
+
+// CurrentTime возвращает текущее время PostgreSQL
+func (a *IndexingAdapter) CurrentTime(ctx context.Context) (time.Time, error) {
+	var now time.Time
+	if err := a.pg.DB().QueryRow(ctx, `SELECT NOW()`).Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get database time: %w", err)
+	}
+	return now, nil
+}
+
+// GetCheckpoint возвращает watermark синхронизации
+func (a *IndexingAdapter) GetCheckpoint(ctx context.Context, name string) (time.Time, error) {
+	var watermark time.Time
+	err := a.pg.DB().QueryRow(ctx,
+		`SELECT watermark FROM search_sync_checkpoints WHERE name = $1`, name,
+	).Scan(&watermark)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get checkpoint: %w", err)
+	}
+	return watermark, nil
+}
+
+// SaveCheckpoint сохраняет watermark синхронизации
+func (a *IndexingAdapter) SaveCheckpoint(ctx context.Context, name string, watermark time.Time) error {
+	query := `
+		INSERT INTO search_sync_checkpoints (name, watermark, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			watermark = EXCLUDED.watermark,
+			updated_at = NOW()
+	`
+	if _, err := a.pg.DB().Exec(ctx, query, name, watermark); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// CountProducts возвращает число товаров в PostgreSQL
+func (a *IndexingAdapter) CountProducts(ctx context.Context) (int64, error) {
+	var count int64
+	if err := a.pg.DB().QueryRow(ctx, `SELECT COUNT(*) FROM products`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return count, nil
+}
+
+// CreateIndex создаёт пустой индекс товаров и применяет к нему настройки поиска
+func (a *IndexingAdapter) CreateIndex(ctx context.Context, indexName string) error {
+	if a.meili == nil {
+		return fmt.Errorf("meilisearch is not available")
+	}
+	client := a.meili.Client()
+
+	task, err := client.CreateIndex(&meilisearch.IndexConfig{Uid: indexName, PrimaryKey: "id"})
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", indexName, err)
+	}
+	index := client.Index(indexName)
+	if err := waitForMeiliTask(ctx, index, task); err != nil {
+		return err
+	}
+
+	task, err = index.UpdateSettings(ProductsIndexSettings())
+	if err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+	return waitForMeiliTask(ctx, index, task)
+}
+
+// CountDocuments возвращает число документов в индексе
+func (a *IndexingAdapter) CountDocuments(ctx context.Context, indexName string) (int64, error) {
+	index, err := a.indexByName(indexName)
+	if err != nil {
+		return 0, err
+	}
+	stats, err := index.GetStats()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get index stats: %w", err)
+	}
+	return stats.NumberOfDocuments, nil
+}
+
+// SwapWithLive атомарно меняет местами indexName и рабочий индекс
+// Если рабочего индекса ещё нет, он создаётся пустым (swap требует оба индекса)
+func (a *IndexingAdapter) SwapWithLive(ctx context.Context, indexName string) error {
+	if a.meili == nil {
+		return fmt.Errorf("meilisearch is not available")
+	}
+	client := a.meili.Client()
+
+	if _, err := client.GetIndex(ProductsIndexName); err != nil {
+		var meiliErr *meilisearch.Error
+		if !errors.As(err, &meiliErr) || meiliErr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to get live index: %w", err)
+		}
+		if err := a.CreateIndex(ctx, ProductsIndexName); err != nil {
+			return err
+		}
+	}
+
+	task, err := client.SwapIndexes([]meilisearch.SwapIndexesParams{
+		{Indexes: []string{ProductsIndexName, indexName}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to swap indexes: %w", err)
+	}
+	return waitForMeiliTask(ctx, client.Index(ProductsIndexName), task)
+}
+
+// DeleteIndex удаляет индекс целиком
+func (a *IndexingAdapter) DeleteIndex(ctx context.Context, indexName string) error {
+	if a.meili == nil {
+		return fmt.Errorf("meilisearch is not available")
+	}
+	client := a.meili.Client()
+
+	task, err := client.DeleteIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("failed to delete index %s: %w", indexName, err)
+	}
+	return waitForMeiliTask(ctx, client.Index(indexName), task)
+}
+
+func (a *IndexingAdapter) index() (*meilisearch.Index, error) {
+	return a.indexByName(ProductsIndexName)
+}
+
+func (a *IndexingAdapter) indexByName(name string) (*meilisearch.Index, error) {
+	if a.meili == nil {
+		return nil, fmt.Errorf("meilisearch is not available")
+	}
+	return a.meili.Client().Index(name), nil
+}
+
+// ProductsIndexSettings настройки поиска индекса товаров
+// Используются и для рабочего индекса (indexer -setup), и для нового индекса при пересборке
+func ProductsIndexSettings() *meilisearch.Settings {
+	return &meilisearch.Settings{
+		// Поисковые поля
+		SearchableAttributes: []string{
+			"name",
+			"description",
+			"brand",
+			"category",
+		},
+		// Фильтруемые поля
+		FilterableAttributes: []string{
+			"brand",
+			"category",
+			"category_id",
+			"type", // Фильтр по типу: good/service
+			"shop_names",
+			"shops_count",
+			"min_price",
+			"max_price",
+			"min_unit_price",
+			"unit",
+			"created_at",
+			"updated_at",
+			"attrs", // attrs.<code> - атрибуты справочника
+		},
+		// Сортируемые поля
+		SortableAttributes: []string{
+			"name",
+			"brand",
+			"category",
+			"shops_count",
+			"min_price",
+			"max_price",
+			"min_unit_price",
+			"unit", // unit_price_* сортирует внутри единицы: kg, l, pcs
+			"created_at",
+			"updated_at",
+			"attrs",
+		},
+		RankingRules: []string{
+			"words",
+			"typo",
+			"proximity",
+			"attribute",
+			"sort",
+			"exactness",
+		},
+		StopWords: []string{}, // Можно добавить стоп-слова для сербского языка
+		Synonyms:  map[string][]string{},
+	}
+}
+
+// waitForMeiliTask ждёт завершения задачи Meilisearch
+func waitForMeiliTask(ctx context.Context, index *meilisearch.Index, task *meilisearch.TaskInfo) error {
+	result, err := index.WaitForTask(task.TaskUID, meilisearch.WaitParams{
+		Context:  ctx,
+		Interval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for task %d: %w", task.TaskUID, err)
+	}
+	if result.Status == meilisearch.TaskStatusFailed {
+		return fmt.Errorf("meilisearch task %d failed: %s", task.TaskUID, result.Error.Message)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/solomonczyk/izborator/internal/matching"
 )
@@ -216,6 +217,8 @@ func (a *MatchingAdapter) GetProductByID(id string) (*matching.Product, error) {
 }
 
 // SaveMatch сохраняет результат сопоставления
+// Проверенные модератором сопоставления не перезаписываются.
+// С ShopID сохраняется предварительная привязка предложения магазина к MatchedID
 func (a *MatchingAdapter) SaveMatch(match *matching.ProductMatch) error {
 	productUUID, err := a.ParseUUID(match.ProductID)
 	if err != nil {
@@ -228,12 +231,13 @@ func (a *MatchingAdapter) SaveMatch(match *matching.ProductMatch) error {
 	}
 
 	query := `
-		INSERT INTO product_matches (product_id, matched_id, similarity, confidence, matched_at)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (product_id, matched_id) DO UPDATE SET
+		INSERT INTO product_matches (product_id, matched_id, similarity, confidence, matched_at, shop_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		ON CONFLICT (product_id, matched_id, (COALESCE(shop_id, ''))) DO UPDATE SET
 			similarity = EXCLUDED.similarity,
 			confidence = EXCLUDED.confidence,
 			matched_at = EXCLUDED.matched_at
+		WHERE product_matches.status = 'pending'
 	`
 
 	if match.MatchedAt.IsZero() {
@@ -250,6 +254,7 @@ func (a *MatchingAdapter) SaveMatch(match *matching.ProductMatch) error {
 		match.Similarity,
 		match.Confidence,
 		match.MatchedAt,
+		match.ShopID,
 	)
 
 	if err != nil {
@@ -268,7 +273,7 @@ func (a *MatchingAdapter) GetMatches(productID string) ([]*matching.ProductMatch
 
 	// Оптимизированный запрос: использует индекс idx_product_matches_similarity
 	query := `
-		SELECT product_id, matched_id, similarity, confidence, matched_at
+		SELECT product_id, matched_id, similarity, confidence, matched_at, COALESCE(shop_id, '')
 		FROM product_matches
 		WHERE product_id = $1
 		ORDER BY similarity DESC, matched_at DESC
@@ -293,6 +298,7 @@ func (a *MatchingAdapter) GetMatches(productID string) ([]*matching.ProductMatch
 			&match.Similarity,
 			&match.Confidence,
 			&matchedAt,
+			&match.ShopID,
 		)
 
 		if err != nil {
@@ -310,3 +316,162 @@ func (a *MatchingAdapter) GetMatches(productID string) ([]*matching.ProductMatch
 	return matches, nil
 }
 
+
+// identifierColumns раскладывает идентификаторы по колонкам для unnest
+func identifierColumns(ids []matching.Identifier) (types, scopes, values []string) {
+	types = make([]string, 0, len(ids))
+	scopes = make([]string, 0, len(ids))
+	values = make([]string, 0, len(ids))
+	for _, id := range ids {
+		types = append(types, string(id.Type))
+		scopes = append(scopes, id.Scope)
+		values = append(values, id.Value)
+	}
+	return types, scopes, values
+}
+
+// FindProductsByIdentifiers ищет товары с любым из идентификаторов
+func (a *MatchingAdapter) FindProductsByIdentifiers(ids []matching.Identifier) ([]*matching.IdentifierOwner, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	types, scopes, values := identifierColumns(ids)
+
+	query := `
+		SELECT pi.product_id::text, pi.id_type, pi.scope, pi.value
+		FROM product_identifiers pi
+		JOIN unnest($1::text[], $2::text[], $3::text[]) AS q(id_type, scope, value)
+			ON pi.id_type = q.id_type AND pi.scope = q.scope AND pi.value = q.value
+		ORDER BY pi.created_at
+	`
+
+	rows, err := a.pg.DB().Query(a.GetContext(), query, types, scopes, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products by identifiers: %w", err)
+	}
+	defer rows.Close()
+
+	var owners []*matching.IdentifierOwner
+	for rows.Next() {
+		var owner matching.IdentifierOwner
+		var idType string
+		if err := rows.Scan(&owner.ProductID, &idType, &owner.Identifier.Scope, &owner.Identifier.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan identifier owner: %w", err)
+		}
+		owner.Identifier.Type = matching.IdentifierType(idType)
+		owners = append(owners, &owner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating identifier owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+// GetIdentifiers возвращает идентификаторы товаров
+func (a *MatchingAdapter) GetIdentifiers(productIDs []string) (map[string][]matching.Identifier, error) {
+	result := make(map[string][]matching.Identifier, len(productIDs))
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	uuids := make([]uuid.UUID, 0, len(productIDs))
+	for _, id := range productIDs {
+		parsed, err := a.ParseUUID(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product ID: %w", err)
+		}
+		uuids = append(uuids, parsed)
+	}
+
+	query := `
+		SELECT product_id::text, id_type, scope, value
+		FROM product_identifiers
+		WHERE product_id = ANY($1)
+	`
+
+	rows, err := a.pg.DB().Query(a.GetContext(), query, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identifiers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, idType string
+		var id matching.Identifier
+		if err := rows.Scan(&productID, &idType, &id.Scope, &id.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan identifier: %w", err)
+		}
+		id.Type = matching.IdentifierType(idType)
+		result[productID] = append(result[productID], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating identifiers: %w", err)
+	}
+
+	return result, nil
+}
+
+// SaveIdentifiers привязывает идентификаторы к товару
+func (a *MatchingAdapter) SaveIdentifiers(productID string, ids []matching.Identifier) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+	types, scopes, values := identifierColumns(ids)
+
+	query := `
+		INSERT INTO product_identifiers (product_id, id_type, scope, value)
+		SELECT $1, t.id_type, t.scope, t.value
+		FROM unnest($2::text[], $3::text[], $4::text[]) AS t(id_type, scope, value)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := a.pg.DB().Exec(a.GetContext(), query, productUUID, types, scopes, values); err != nil {
+		return fmt.Errorf("failed to save identifiers: %w", err)
+	}
+
+	return nil
+}
+
+// SaveIdentifierConflict сохраняет конфликт, объединяя список товаров с уже известным
+func (a *MatchingAdapter) SaveIdentifierConflict(conflict *matching.IdentifierConflict) error {
+	uuids := make([]uuid.UUID, 0, len(conflict.ProductIDs))
+	for _, id := range conflict.ProductIDs {
+		parsed, err := a.ParseUUID(id)
+		if err != nil {
+			return fmt.Errorf("invalid product ID: %w", err)
+		}
+		uuids = append(uuids, parsed)
+	}
+
+	if conflict.DetectedAt.IsZero() {
+		conflict.DetectedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO identifier_conflicts (id_type, scope, value, product_ids, detected_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (id_type, scope, value) DO UPDATE SET
+			product_ids = ARRAY(
+				SELECT DISTINCT unnest(identifier_conflicts.product_ids || EXCLUDED.product_ids)
+			),
+			updated_at = NOW()
+	`
+
+	_, err := a.pg.DB().Exec(a.GetContext(), query,
+		string(conflict.Identifier.Type),
+		conflict.Identifier.Scope,
+		conflict.Identifier.Value,
+		uuids,
+		conflict.DetectedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save identifier conflict: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,628 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/matchreview"
+)
+
+// MatchReviewAdapter адаптер очереди проверки сопоставлений и слияния/разделения товаров
+type MatchReviewAdapter struct {
+	*BaseAdapter
+}
+
+// NewMatchReviewAdapter создаёт новый адаптер проверки сопоставлений
+func NewMatchReviewAdapter(pg *Postgres) matchreview.Storage {
+	return &MatchReviewAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+const reviewMatchColumns = `
+	pm.id, pm.product_id, p.name, pm.matched_id, m.name, pm.similarity, pm.confidence,
+	COALESCE(pm.shop_id, ''), pm.status, pm.matched_at, pm.reviewed_by, pm.reviewed_at, pm.review_note
+`
+
+// ListPending возвращает непроверенные сопоставления medium/low и их общее число
+func (a *MatchReviewAdapter) ListPending(ctx context.Context, filter matchreview.ListFilter) ([]*matchreview.Match, int, error) {
+	where := `
+		FROM product_matches pm
+		JOIN products p ON p.id = pm.product_id
+		JOIN products m ON m.id = pm.matched_id
+		WHERE pm.status = 'pending'
+		  AND pm.confidence IN ('medium', 'low')
+		  AND ($1 = '' OR pm.confidence = $1)
+	`
+
+	var total int
+	if err := a.pg.DB().QueryRow(ctx, `SELECT COUNT(*) `+where, filter.Confidence).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count pending matches: %w", err)
+	}
+
+	query := `SELECT ` + reviewMatchColumns + where + `
+		ORDER BY pm.similarity DESC, pm.matched_at
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := a.pg.DB().Query(ctx, query, filter.Confidence, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list pending matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := make([]*matchreview.Match, 0)
+	for rows.Next() {
+		match, err := scanReviewMatch(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan match: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, total, rows.Err()
+}
+
+// GetMatch получает сопоставление по ID
+func (a *MatchReviewAdapter) GetMatch(ctx context.Context, id string) (*matchreview.Match, error) {
+	matchUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return nil, matchreview.ErrMatchNotFound
+	}
+
+	query := `SELECT ` + reviewMatchColumns + `
+		FROM product_matches pm
+		JOIN products p ON p.id = pm.product_id
+		JOIN products m ON m.id = pm.matched_id
+		WHERE pm.id = $1
+	`
+	match, err := scanReviewMatch(a.pg.DB().QueryRow(ctx, query, matchUUID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, matchreview.ErrMatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get match: %w", err)
+	}
+
+	return match, nil
+}
+
+// RejectMatch помечает сопоставление отклонённым и пишет запись журнала
+func (a *MatchReviewAdapter) RejectMatch(ctx context.Context, id string, entry *matchreview.AuditEntry) error {
+	matchUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return matchreview.ErrMatchNotFound
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := tx.Exec(ctx, `
+		UPDATE product_matches
+		SET status = 'rejected',
+		    reviewed_by = $2,
+		    reviewed_at = NOW(),
+		    review_note = NULLIF($3, '')
+		WHERE id = $1
+		  AND status = 'pending'
+	`, matchUUID, entry.Actor, entry.Note)
+	if err != nil {
+		return fmt.Errorf("failed to reject match: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return matchreview.ErrMatchNotPending
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MergeProducts сливает sourceID в targetID в одной транзакции.
+// Если у обоих товаров есть цена одного магазина, остаётся более свежая.
+func (a *MatchReviewAdapter) MergeProducts(ctx context.Context, sourceID, targetID string, entry *matchreview.AuditEntry) (*matchreview.MergeResult, error) {
+	sourceUUID, err := a.ParseUUID(sourceID)
+	if err != nil {
+		return nil, matchreview.ErrProductNotFound
+	}
+	targetUUID, err := a.ParseUUID(targetID)
+	if err != nil {
+		return nil, matchreview.ErrProductNotFound
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Сопоставление могли проверить параллельно - проверяем статус под блокировкой
+	if entry.MatchID != nil {
+		var status string
+		err := tx.QueryRow(ctx, `SELECT status FROM product_matches WHERE id = $1 FOR UPDATE`, *entry.MatchID).Scan(&status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, matchreview.ErrMatchNotFound
+			}
+			return nil, fmt.Errorf("failed to lock match: %w", err)
+		}
+		if matchreview.Status(status) != matchreview.StatusPending {
+			return nil, matchreview.ErrMatchNotPending
+		}
+	}
+
+	var locked int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT id FROM products WHERE id IN ($1, $2) ORDER BY id FOR UPDATE
+		) locked
+	`, sourceUUID, targetUUID).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock products: %w", err)
+	}
+	if locked != 2 {
+		return nil, matchreview.ErrProductNotFound
+	}
+
+	result := &matchreview.MergeResult{SourceID: sourceID, TargetID: targetID}
+
+	// Цены одного магазина: (product_id, shop_id) уникальны, удаляем более старую
+	dropped, err := tx.Exec(ctx, `
+		DELETE FROM product_prices src
+		USING product_prices dst
+		WHERE src.product_id = $1
+		  AND dst.product_id = $2
+		  AND dst.shop_id = src.shop_id
+		  AND dst.updated_at >= src.updated_at
+	`, sourceUUID, targetUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to drop outdated source prices: %w", err)
+	}
+	result.DroppedPrices = int(dropped.RowsAffected())
+
+	dropped, err = tx.Exec(ctx, `
+		DELETE FROM product_prices dst
+		USING product_prices src
+		WHERE dst.product_id = $2
+		  AND src.product_id = $1
+		  AND src.shop_id = dst.shop_id
+	`, sourceUUID, targetUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to drop outdated target prices: %w", err)
+	}
+	result.DroppedPrices += int(dropped.RowsAffected())
+
+	moved, err := tx.Exec(ctx, `UPDATE product_prices SET product_id = $2 WHERE product_id = $1`, sourceUUID, targetUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move prices: %w", err)
+	}
+	result.MovedPrices = int(moved.RowsAffected())
+
+	moved, err = tx.Exec(ctx, `UPDATE raw_products SET product_id = $2 WHERE product_id = $1`, sourceUUID, targetUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move raw products: %w", err)
+	}
+	result.MovedRawProducts = int(moved.RowsAffected())
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO product_identifiers (product_id, id_type, scope, value, created_at)
+		SELECT $2, id_type, scope, value, created_at
+		FROM product_identifiers
+		WHERE product_id = $1
+		ON CONFLICT DO NOTHING
+	`, sourceUUID, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to copy identifiers: %w", err)
+	}
+
+	// Конфликт идентификатора между слитыми товарами разрешён
+	if _, err := tx.Exec(ctx, `
+		UPDATE identifier_conflicts
+		SET product_ids = array_append(array_remove(array_remove(product_ids, $1), $2), $2),
+		    updated_at = NOW()
+		WHERE $1 = ANY(product_ids)
+	`, sourceUUID, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to update identifier conflicts: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM identifier_conflicts WHERE cardinality(product_ids) < 2`); err != nil {
+		return nil, fmt.Errorf("failed to delete resolved identifier conflicts: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE price_alert_subscriptions SET product_id = $2 WHERE product_id = $1`, sourceUUID, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to move price alerts: %w", err)
+	}
+
+	// История цен: сырые точки переносятся, агрегаты одного интервала объединяются
+	if _, err := tx.Exec(ctx, `UPDATE price_history SET product_id = $2 WHERE product_id = $1`, sourceUUID, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to move price history: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO price_history_rollups AS r (
+			product_id, shop_id, granularity, bucket_start,
+			min_price, max_price, sum_price, close_price, currency, in_stock, points, updated_at
+		)
+		SELECT $2, shop_id, granularity, bucket_start,
+		       min_price, max_price, sum_price, close_price, currency, in_stock, points, updated_at
+		FROM price_history_rollups
+		WHERE product_id = $1
+		ON CONFLICT (product_id, shop_id, granularity, bucket_start) DO UPDATE SET
+			min_price = LEAST(r.min_price, EXCLUDED.min_price),
+			max_price = GREATEST(r.max_price, EXCLUDED.max_price),
+			sum_price = r.sum_price + EXCLUDED.sum_price,
+			close_price = CASE WHEN EXCLUDED.updated_at > r.updated_at THEN EXCLUDED.close_price ELSE r.close_price END,
+			currency = CASE WHEN EXCLUDED.updated_at > r.updated_at THEN EXCLUDED.currency ELSE r.currency END,
+			in_stock = CASE WHEN EXCLUDED.updated_at > r.updated_at THEN EXCLUDED.in_stock ELSE r.in_stock END,
+			points = r.points + EXCLUDED.points,
+			updated_at = GREATEST(r.updated_at, EXCLUDED.updated_at)
+	`, sourceUUID, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to merge price history rollups: %w", err)
+	}
+
+	entry.Details["moved_prices"] = result.MovedPrices
+	entry.Details["dropped_prices"] = result.DroppedPrices
+	entry.Details["moved_raw_products"] = result.MovedRawProducts
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	// Сопоставления и идентификаторы источника удаляются каскадно
+	if _, err := tx.Exec(ctx, `DELETE FROM products WHERE id = $1`, sourceUUID); err != nil {
+		return nil, fmt.Errorf("failed to delete merged product: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE products SET updated_at = NOW() WHERE id = $1`, targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to touch target product: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return result, nil
+}
+
+// ConfirmMatch закрепляет предварительную привязку предложения: сопоставление удаляется,
+// решение остаётся в журнале
+func (a *MatchReviewAdapter) ConfirmMatch(ctx context.Context, id string, entry *matchreview.AuditEntry) error {
+	matchUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return matchreview.ErrMatchNotFound
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := tx.Exec(ctx, `
+		DELETE FROM product_matches
+		WHERE id = $1
+		  AND status = 'pending'
+		  AND shop_id IS NOT NULL
+	`, matchUUID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm match: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return matchreview.ErrMatchNotPending
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DetachOffer отклоняет предварительную привязку в одной транзакции: предложение магазина
+// выделяется в копию товара, сопоставление помечается отклонённым. Если других предложений
+// у товара нет, выделять нечего - товар и так состоит только из этого предложения
+func (a *MatchReviewAdapter) DetachOffer(ctx context.Context, id string, entry *matchreview.AuditEntry) (*matchreview.SplitResult, error) {
+	matchUUID, err := a.ParseUUID(id)
+	if err != nil {
+		return nil, matchreview.ErrMatchNotFound
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var (
+		status    string
+		productID uuid.UUID
+		shopID    *string
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT status, matched_id, shop_id FROM product_matches WHERE id = $1 FOR UPDATE
+	`, matchUUID).Scan(&status, &productID, &shopID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, matchreview.ErrMatchNotFound
+		}
+		return nil, fmt.Errorf("failed to lock match: %w", err)
+	}
+	if matchreview.Status(status) != matchreview.StatusPending || shopID == nil {
+		return nil, matchreview.ErrMatchNotPending
+	}
+
+	req := &matchreview.SplitRequest{ProductID: productID.String(), ShopIDs: []string{*shopID}}
+	result, err := splitOffers(ctx, tx, productID, req)
+	switch {
+	case errors.Is(err, matchreview.ErrInvalidSplit):
+		result = &matchreview.SplitResult{ProductID: req.ProductID}
+	case err != nil:
+		return nil, err
+	default:
+		entry.Details["new_product_id"] = result.NewProductID
+		entry.Details["moved_prices"] = result.MovedPrices
+		entry.Details["moved_raw_products"] = result.MovedRawProducts
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE product_matches
+		SET status = 'rejected',
+		    reviewed_by = $2,
+		    reviewed_at = NOW(),
+		    review_note = NULLIF($3, '')
+		WHERE id = $1
+	`, matchUUID, entry.Actor, entry.Note); err != nil {
+		return nil, fmt.Errorf("failed to reject match: %w", err)
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit detach: %w", err)
+	}
+
+	return result, nil
+}
+
+// SplitProduct копирует товар и переносит в копию цены, сырые данные и SKU магазинов req.ShopIDs
+func (a *MatchReviewAdapter) SplitProduct(ctx context.Context, req *matchreview.SplitRequest, entry *matchreview.AuditEntry) (*matchreview.SplitResult, error) {
+	productUUID, err := a.ParseUUID(req.ProductID)
+	if err != nil {
+		return nil, matchreview.ErrProductNotFound
+	}
+
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	result, err := splitOffers(ctx, tx, productUUID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	newID := result.NewProductID
+	entry.RelatedProductID = &newID
+	entry.Details["moved_prices"] = result.MovedPrices
+	entry.Details["moved_raw_products"] = result.MovedRawProducts
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit split: %w", err)
+	}
+
+	return result, nil
+}
+
+// splitOffers выполняет разделение в транзакции tx: копирует товар и переносит в копию
+// предложения магазинов req.ShopIDs. ErrInvalidSplit возвращается до первого изменения
+func splitOffers(ctx context.Context, tx pgx.Tx, productUUID uuid.UUID, req *matchreview.SplitRequest) (*matchreview.SplitResult, error) {
+	var lockedID uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productUUID).Scan(&lockedID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, matchreview.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	var total, selected int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE shop_id = ANY($2))
+		FROM product_prices
+		WHERE product_id = $1
+	`, productUUID, req.ShopIDs).Scan(&total, &selected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count offers: %w", err)
+	}
+	if selected == 0 || selected == total {
+		return nil, matchreview.ErrInvalidSplit
+	}
+
+	var newUUID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO products (
+			name, description, brand, category, image_url, specs,
+			category_id, product_type_id, type, service_metadata, is_deliverable, is_onsite
+		)
+		SELECT COALESCE(NULLIF($2, ''), name), description, brand, category, image_url, specs,
+		       category_id, product_type_id, type, service_metadata, is_deliverable, is_onsite
+		FROM products
+		WHERE id = $1
+		RETURNING id
+	`, productUUID, req.Name).Scan(&newUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create split product: %w", err)
+	}
+
+	result := &matchreview.SplitResult{ProductID: productUUID.String(), NewProductID: newUUID.String()}
+
+	moved, err := tx.Exec(ctx, `
+		UPDATE product_prices SET product_id = $2 WHERE product_id = $1 AND shop_id = ANY($3)
+	`, productUUID, newUUID, req.ShopIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move prices: %w", err)
+	}
+	result.MovedPrices = int(moved.RowsAffected())
+
+	moved, err = tx.Exec(ctx, `
+		UPDATE raw_products SET product_id = $2 WHERE product_id = $1 AND shop_id = ANY($3)
+	`, productUUID, newUUID, req.ShopIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move raw products: %w", err)
+	}
+	result.MovedRawProducts = int(moved.RowsAffected())
+
+	// История цен магазина уходит вместе с его предложением
+	if _, err := tx.Exec(ctx, `
+		UPDATE price_history SET product_id = $2 WHERE product_id = $1 AND shop_id = ANY($3)
+	`, productUUID, newUUID, req.ShopIDs); err != nil {
+		return nil, fmt.Errorf("failed to move price history: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE price_history_rollups SET product_id = $2 WHERE product_id = $1 AND shop_id = ANY($3)
+	`, productUUID, newUUID, req.ShopIDs); err != nil {
+		return nil, fmt.Errorf("failed to move price history rollups: %w", err)
+	}
+
+	// SKU магазина относится к его предложению; GTIN/MPN остаются у исходного товара
+	if _, err := tx.Exec(ctx, `
+		UPDATE product_identifiers SET product_id = $2
+		WHERE product_id = $1 AND id_type = 'sku' AND scope = ANY($3)
+	`, productUUID, newUUID, req.ShopIDs); err != nil {
+		return nil, fmt.Errorf("failed to move shop identifiers: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE products SET updated_at = NOW() WHERE id = $1`, productUUID); err != nil {
+		return nil, fmt.Errorf("failed to touch product: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListAudit возвращает журнал решений по товару
+func (a *MatchReviewAdapter) ListAudit(ctx context.Context, productID string, limit int) ([]*matchreview.AuditEntry, error) {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return nil, matchreview.ErrProductNotFound
+	}
+
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT id, action, match_id, product_id, related_product_id, actor, COALESCE(note, ''), details, created_at
+		FROM match_audit_log
+		WHERE product_id = $1 OR related_product_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, productUUID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*matchreview.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry       matchreview.AuditEntry
+			action      string
+			detailsJSON []byte
+		)
+		if err := rows.Scan(
+			&entry.ID,
+			&action,
+			&entry.MatchID,
+			&entry.ProductID,
+			&entry.RelatedProductID,
+			&entry.Actor,
+			&entry.Note,
+			&detailsJSON,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Action = matchreview.Action(action)
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &entry.Details); err != nil {
+				return nil, fmt.Errorf("failed to decode audit details: %w", err)
+			}
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// insertAuditEntry пишет запись журнала в транзакции операции
+func insertAuditEntry(ctx context.Context, tx pgx.Tx, entry *matchreview.AuditEntry) error {
+	var detailsJSON []byte
+	if len(entry.Details) > 0 {
+		var err error
+		detailsJSON, err = json.Marshal(entry.Details)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit details: %w", err)
+		}
+	}
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO match_audit_log (action, match_id, product_id, related_product_id, actor, note, details)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		RETURNING id, created_at
+	`,
+		string(entry.Action),
+		entry.MatchID,
+		entry.ProductID,
+		entry.RelatedProductID,
+		entry.Actor,
+		entry.Note,
+		detailsJSON,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func scanReviewMatch(row pgx.Row) (*matchreview.Match, error) {
+	var (
+		match  matchreview.Match
+		status string
+	)
+	err := row.Scan(
+		&match.ID,
+		&match.ProductID,
+		&match.ProductName,
+		&match.MatchedID,
+		&match.MatchedName,
+		&match.Similarity,
+		&match.Confidence,
+		&match.ShopID,
+		&status,
+		&match.MatchedAt,
+		&match.ReviewedBy,
+		&match.ReviewedAt,
+		&match.ReviewNote,
+	)
+	if err != nil {
+		return nil, err
+	}
+	match.Status = matchreview.Status(status)
+	return &match, nil
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/solomonczyk/izborator/internal/pricehistory"
 )
 
+// priceHistoryCleanupBatch количество точек, удаляемых за один запрос очистки
+const priceHistoryCleanupBatch = 10000
+
 // PriceHistoryAdapter адаптер для работы с историей цен через PostgreSQL
 type PriceHistoryAdapter struct {
 	*BaseAdapter
@@ -19,17 +22,95 @@ func NewPriceHistoryAdapter(pg *Postgres) pricehistory.Storage {
 	}
 }
 
-// SavePrice сохраняет точку цены
-// В текущей реализации используем product_prices, где updated_at показывает время обновления
-// Для полноценной истории нужно будет создать отдельную таблицу price_history
-func (a *PriceHistoryAdapter) SavePrice(point *pricehistory.PricePoint) error {
-	// Пока что сохранение происходит через ProductsAdapter.SavePrice
-	// Здесь можно добавить логику для сохранения в отдельную таблицу истории
-	return nil
+// SavePrice добавляет точку в price_history, если цена, валюта или наличие отличаются
+// от последней точки магазина, и в том же запросе обновляет дневной и недельный агрегаты.
+// Записи одной пары товар-магазин сериализуются advisory lock транзакции: без него два
+// параллельных запроса не видят точек друг друга и оба записывают одно изменение.
+// Интервалы агрегатов считаются по UTC, независимо от часового пояса сессии
+func (a *PriceHistoryAdapter) SavePrice(point *pricehistory.PricePoint) (bool, error) {
+	productUUID, err := a.ParseUUID(point.ProductID)
+	if err != nil {
+		return false, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	timestamp := point.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	query := `
+		WITH last AS (
+			SELECT price, currency, in_stock
+			FROM price_history
+			WHERE product_id = $1::uuid AND shop_id = $2::varchar
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		), inserted AS (
+			INSERT INTO price_history (product_id, shop_id, price, currency, in_stock, recorded_at)
+			SELECT $1::uuid, $2::varchar, ROUND($3::numeric, 2), $4::varchar, $5::boolean, $6::timestamptz
+			WHERE NOT EXISTS (
+				SELECT 1 FROM last
+				WHERE last.price = ROUND($3::numeric, 2)
+				  AND last.currency = $4::varchar
+				  AND last.in_stock = $5::boolean
+			)
+			RETURNING product_id, shop_id, price, currency, in_stock, recorded_at
+		)
+		INSERT INTO price_history_rollups AS r (
+			product_id, shop_id, granularity, bucket_start,
+			min_price, max_price, sum_price, close_price, currency, in_stock, points, updated_at
+		)
+		SELECT i.product_id, i.shop_id, g.granularity,
+		       CASE WHEN g.granularity = 'day' THEN (i.recorded_at AT TIME ZONE 'UTC')::date
+		            ELSE date_trunc('week', i.recorded_at AT TIME ZONE 'UTC')::date END,
+		       i.price, i.price, i.price, i.price, i.currency, i.in_stock, 1, i.recorded_at
+		FROM inserted i
+		CROSS JOIN (VALUES ('day'), ('week')) AS g(granularity)
+		ON CONFLICT (product_id, shop_id, granularity, bucket_start) DO UPDATE SET
+			min_price = LEAST(r.min_price, EXCLUDED.min_price),
+			max_price = GREATEST(r.max_price, EXCLUDED.max_price),
+			sum_price = r.sum_price + EXCLUDED.sum_price,
+			close_price = EXCLUDED.close_price,
+			currency = EXCLUDED.currency,
+			in_stock = EXCLUDED.in_stock,
+			points = r.points + 1,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	currency := point.Currency
+	if currency == "" {
+		currency = "RSD"
+	}
+
+	ctx := a.GetContext()
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Запрос ниже выполняется после получения блокировки и видит точку, записанную её прежним владельцем
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2::text || '/' || $3::text))`,
+		priceHistoryWriteLock, productUUID.String(), point.ShopID); err != nil {
+		return false, fmt.Errorf("failed to lock price history: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, query,
+		productUUID, point.ShopID, point.Price, currency, point.InStock, timestamp)
+	if err != nil {
+		return false, fmt.Errorf("failed to save price point: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit price point: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
 }
 
-// GetHistory получает историю цен за период из product_prices
-// Используем updated_at как timestamp изменения цены
+// GetHistory получает сырые точки изменений цены за период
 func (a *PriceHistoryAdapter) GetHistory(productID string, from, to time.Time) ([]*pricehistory.PricePoint, error) {
 	productUUID, err := a.ParseUUID(productID)
 	if err != nil {
@@ -37,12 +118,12 @@ func (a *PriceHistoryAdapter) GetHistory(productID string, from, to time.Time) (
 	}
 
 	query := `
-		SELECT shop_id, price, currency, updated_at
-		FROM product_prices
+		SELECT shop_id, price, currency, in_stock, recorded_at
+		FROM price_history
 		WHERE product_id = $1
-		  AND updated_at >= $2
-		  AND updated_at <= $3
-		ORDER BY updated_at ASC, shop_id
+		  AND recorded_at >= $2
+		  AND recorded_at <= $3
+		ORDER BY recorded_at ASC, shop_id
 	`
 
 	rows, err := a.pg.DB().Query(a.GetContext(), query, productUUID, from, to)
@@ -54,20 +135,19 @@ func (a *PriceHistoryAdapter) GetHistory(productID string, from, to time.Time) (
 	var points []*pricehistory.PricePoint
 	for rows.Next() {
 		var point pricehistory.PricePoint
-		var timestamp time.Time
 
 		err := rows.Scan(
 			&point.ShopID,
 			&point.Price,
 			&point.Currency,
-			&timestamp,
+			&point.InStock,
+			&point.Timestamp,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan price point: %w", err)
 		}
 
 		point.ProductID = productID
-		point.Timestamp = timestamp
 		points = append(points, &point)
 	}
 
@@ -79,8 +159,10 @@ func (a *PriceHistoryAdapter) GetHistory(productID string, from, to time.Time) (
 }
 
 // GetPriceChart получает данные для графика цен
-// Группирует цены по магазинам и возвращает структуру для отображения графика
-func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, shopIDs []string) (*pricehistory.PriceChart, error) {
+// Короткие периоды строятся по сырым точкам, длинные - по агрегатам.
+// Для каждого магазина добавляется стартовая точка на начало периода с последней известной ценой,
+// чтобы график не начинался с пустоты, если цена долго не менялась
+func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, granularity pricehistory.Granularity, shopIDs []string) (*pricehistory.PriceChart, error) {
 	productUUID, err := a.ParseUUID(productID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid product ID: %w", err)
@@ -102,22 +184,59 @@ func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, sho
 		from = now.AddDate(0, 0, -30) // По умолчанию 30 дней
 	}
 
-	// Строим запрос с shop_name
-	query := `
-		SELECT pp.shop_id, pp.price, pp.currency, pp.updated_at, pp.shop_name
-		FROM product_prices pp
-		WHERE pp.product_id = $1
-		  AND pp.updated_at >= $2
-	`
 	args := []interface{}{productUUID, from}
 
 	// Фильтр по магазинам (если указаны)
+	shopFilter := ""
 	if len(shopIDs) > 0 {
-		query += " AND pp.shop_id = ANY($3)"
+		shopFilter = " AND shop_id = ANY($3)"
 		args = append(args, shopIDs)
 	}
 
-	query += " ORDER BY pp.updated_at ASC, pp.shop_id"
+	var query string
+	switch granularity {
+	case pricehistory.GranularityDay, pricehistory.GranularityWeek:
+		args = append(args, string(granularity))
+		granularityArg := fmt.Sprintf("$%d", len(args))
+		query = `
+			SELECT p.shop_id, p.price, p.min_price, p.max_price, p.currency, p.in_stock, p.ts, COALESCE(s.name, p.shop_id)
+			FROM (
+				(
+					SELECT DISTINCT ON (shop_id)
+					       shop_id, close_price AS price, close_price AS min_price, close_price AS max_price,
+					       currency, in_stock, $2::timestamptz AS ts
+					FROM price_history_rollups
+					WHERE product_id = $1 AND granularity = ` + granularityArg + ` AND bucket_start < ($2::timestamptz AT TIME ZONE 'UTC')::date` + shopFilter + `
+					ORDER BY shop_id, bucket_start DESC
+				)
+				UNION ALL
+				SELECT shop_id, close_price, min_price, max_price, currency, in_stock, bucket_start::timestamp AT TIME ZONE 'UTC'
+				FROM price_history_rollups
+				WHERE product_id = $1 AND granularity = ` + granularityArg + ` AND bucket_start >= ($2::timestamptz AT TIME ZONE 'UTC')::date` + shopFilter + `
+			) p
+			LEFT JOIN shops s ON s.id = p.shop_id
+			ORDER BY p.ts ASC, p.shop_id
+		`
+	default:
+		granularity = pricehistory.GranularityRaw
+		query = `
+			SELECT p.shop_id, p.price, p.price, p.price, p.currency, p.in_stock, p.ts, COALESCE(s.name, p.shop_id)
+			FROM (
+				(
+					SELECT DISTINCT ON (shop_id) shop_id, price, currency, in_stock, $2::timestamptz AS ts
+					FROM price_history
+					WHERE product_id = $1 AND recorded_at < $2` + shopFilter + `
+					ORDER BY shop_id, recorded_at DESC, id DESC
+				)
+				UNION ALL
+				SELECT shop_id, price, currency, in_stock, recorded_at
+				FROM price_history
+				WHERE product_id = $1 AND recorded_at >= $2` + shopFilter + `
+			) p
+			LEFT JOIN shops s ON s.id = p.shop_id
+			ORDER BY p.ts ASC, p.shop_id
+		`
+	}
 
 	rows, err := a.pg.DB().Query(a.GetContext(), query, args...)
 	if err != nil {
@@ -131,14 +250,16 @@ func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, sho
 
 	for rows.Next() {
 		var point pricehistory.PricePoint
-		var timestamp time.Time
 		var shopName string
 
 		err := rows.Scan(
 			&point.ShopID,
 			&point.Price,
+			&point.MinPrice,
+			&point.MaxPrice,
 			&point.Currency,
-			&timestamp,
+			&point.InStock,
+			&point.Timestamp,
 			&shopName,
 		)
 		if err != nil {
@@ -146,7 +267,6 @@ func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, sho
 		}
 
 		point.ProductID = productID
-		point.Timestamp = timestamp
 		shops[point.ShopID] = append(shops[point.ShopID], &point)
 		shopNames[point.ShopID] = shopName
 	}
@@ -156,19 +276,53 @@ func (a *PriceHistoryAdapter) GetPriceChart(productID string, period string, sho
 	}
 
 	return &pricehistory.PriceChart{
-		ProductID: productID,
-		Shops:     shops,
-		ShopNames: shopNames,
-		Period:    period,
-		From:      from,
-		To:        now,
+		ProductID:   productID,
+		Shops:       shops,
+		ShopNames:   shopNames,
+		Period:      period,
+		Granularity: granularity,
+		From:        from,
+		To:          now,
 	}, nil
 }
 
-// CleanupOldData удаляет старые данные
-// В текущей реализации не требуется, т.к. используем product_prices
-func (a *PriceHistoryAdapter) CleanupOldData(before time.Time) error {
-	// TODO: реализовать очистку старых данных из отдельной таблицы истории
-	return nil
+// TryLockCleanup берёт advisory lock очистки истории цен
+func (a *PriceHistoryAdapter) TryLockCleanup() (func(), bool, error) {
+	return a.pg.tryAdvisoryLock(a.GetContext(), priceHistoryCleanupLock)
 }
 
+// CleanupOldData удаляет сырые точки старше before пачками.
+// Последняя точка каждого магазина сохраняется - по ней определяется изменение цены
+// и строится стартовая точка графика. Агрегаты не удаляются
+func (a *PriceHistoryAdapter) CleanupOldData(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM price_history
+		WHERE id IN (
+			SELECT ph.id
+			FROM price_history ph
+			WHERE ph.recorded_at < $1
+			  AND EXISTS (
+				SELECT 1 FROM price_history newer
+				WHERE newer.product_id = ph.product_id
+				  AND newer.shop_id = ph.shop_id
+				  AND (newer.recorded_at > ph.recorded_at
+				       OR (newer.recorded_at = ph.recorded_at AND newer.id > ph.id))
+			  )
+			LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		result, err := a.pg.DB().Exec(a.GetContext(), query, before, priceHistoryCleanupBatch)
+		if err != nil {
+			return total, fmt.Errorf("failed to cleanup price history: %w", err)
+		}
+
+		deleted := result.RowsAffected()
+		total += deleted
+		if deleted < priceHistoryCleanupBatch {
+			return total, nil
+		}
+	}
+}
@@ -96,7 +96,7 @@ func (a *ProcessorAdapter) SaveProduct(product *products.Product) error {
 	return nil
 }
 
-// SavePrice сохраняет цену товара в product_prices
+// SavePrice сохраняет цену товара в product_prices вместе с фасовкой и ценой за единицу
 func (a *ProcessorAdapter) SavePrice(price *products.ProductPrice) error {
 	productUUID, err := a.ParseUUID(price.ProductID)
 	if err != nil {
@@ -104,18 +104,24 @@ func (a *ProcessorAdapter) SavePrice(price *products.ProductPrice) error {
 	}
 
 	query := `
-		INSERT INTO product_prices (product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO product_prices (product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at,
+		                            pack_count, pack_amount, pack_unit, unit_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (product_id, shop_id) DO UPDATE SET
 			shop_name = EXCLUDED.shop_name,
 			price = EXCLUDED.price,
 			currency = EXCLUDED.currency,
 			url = EXCLUDED.url,
 			in_stock = EXCLUDED.in_stock,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			pack_count = EXCLUDED.pack_count,
+			pack_amount = EXCLUDED.pack_amount,
+			pack_unit = EXCLUDED.pack_unit,
+			unit_price = EXCLUDED.unit_price
 	`
 
 	price.UpdatedAt = time.Now()
+	packCount, packAmount, packUnit := packColumns(price.Pack)
 
 	_, err = a.pg.DB().Exec(a.GetContext(), query,
 		productUUID,
@@ -126,6 +132,10 @@ func (a *ProcessorAdapter) SavePrice(price *products.ProductPrice) error {
 		price.URL,
 		price.InStock,
 		price.UpdatedAt,
+		packCount,
+		packAmount,
+		packUnit,
+		price.UnitPrice,
 	)
 
 	if err != nil {
@@ -135,77 +145,53 @@ func (a *ProcessorAdapter) SavePrice(price *products.ProductPrice) error {
 	return nil
 }
 
+// HasShopOffer проверяет, есть ли у товара цена магазина
+func (a *ProcessorAdapter) HasShopOffer(productID, shopID string) (bool, error) {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return false, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	var exists bool
+	err = a.pg.DB().QueryRow(a.GetContext(), `
+		SELECT EXISTS (SELECT 1 FROM product_prices WHERE product_id = $1 AND shop_id = $2)
+	`, productUUID, shopID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check shop offer: %w", err)
+	}
+
+	return exists, nil
+}
+
 // IndexProduct индексирует товар в Meilisearch
+// Документ собирается так же, как при синхронизации (см. IndexingAdapter)
 func (a *ProcessorAdapter) IndexProduct(product *products.Product) error {
 	if a.meili == nil {
 		// Meilisearch недоступен - не критично, просто пропускаем индексацию
 		return nil
 	}
 
-	// Получаем названия магазинов для этого товара
-	query := `
-		SELECT DISTINCT s.name 
-		FROM product_prices pp
-		JOIN shops s ON pp.shop_id = s.id
-		WHERE pp.product_id = $1
-	`
-
-	rows, err := a.pg.DB().Query(a.GetContext(), query, product.ID)
+	productUUID, err := a.ParseUUID(product.ID)
 	if err != nil {
-		// Не прерываем индексацию, просто будет без имен
-	} else {
-		defer rows.Close()
-	}
-
-	var shopNames []string
-	if rows != nil {
-		for rows.Next() {
-			var name string
-			if err := rows.Scan(&name); err == nil && name != "" {
-				shopNames = append(shopNames, name)
-			}
-		}
+		return fmt.Errorf("invalid product ID: %w", err)
 	}
 
-	type MeiliDoc struct {
-		ID          string            `json:"id"`
-		Name        string            `json:"name"`
-		Brand       string            `json:"brand"`
-		Category    string            `json:"category"`
-		CategoryID  *string           `json:"category_id,omitempty"`
-		Description string            `json:"description,omitempty"`
-		ImageURL    string            `json:"image_url,omitempty"`
-		Specs       map[string]string `json:"specs,omitempty"`
-		Type        string            `json:"type"` // "good" | "service"
-		ShopNames   []string          `json:"shop_names,omitempty"`
-		ShopsCount  int               `json:"shops_count,omitempty"`
+	rows, err := a.pg.DB().Query(a.GetContext(), productDocumentQuery+` WHERE p.id = $1`, productUUID)
+	if err != nil {
+		return fmt.Errorf("failed to query product: %w", err)
 	}
-
-	// Определяем тип продукта
-	productType := string(product.Type)
-	if productType == "" {
-		productType = "good" // По умолчанию "good" для обратной совместимости
+	docs, err := scanProductDocuments(rows)
+	if err != nil {
+		return err
 	}
-
-	doc := MeiliDoc{
-		ID:          product.ID,
-		Name:        product.Name,
-		Brand:       product.Brand,
-		Category:    product.Category,
-		CategoryID:  product.CategoryID,
-		Description: product.Description,
-		ImageURL:    product.ImageURL,
-		Specs:       product.Specs,
-		Type:        productType,
-		ShopNames:   shopNames,
-		ShopsCount:  len(shopNames),
+	if len(docs) == 0 {
+		return fmt.Errorf("product %s not found", product.ID)
 	}
 
-	_, err = a.meili.Client().Index("products").AddDocuments([]MeiliDoc{doc})
+	_, err = a.meili.Client().Index(ProductsIndexName).AddDocuments(docs, "id")
 	if err != nil {
 		return fmt.Errorf("failed to index product in Meilisearch: %w", err)
 	}
 
 	return nil
 }
-
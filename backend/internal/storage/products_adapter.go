@@ -444,7 +444,8 @@ func (a *ProductsAdapter) GetProductPrices(productID string) ([]*products.Produc
 	}
 
 	query := `
-		SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at
+		SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at,
+		       pack_count, pack_amount::float8, pack_unit, unit_price::float8
 		FROM product_prices
 		WHERE product_id = $1
 		ORDER BY price ASC, updated_at DESC
@@ -461,6 +462,9 @@ func (a *ProductsAdapter) GetProductPrices(productID string) ([]*products.Produc
 	for rows.Next() {
 		var price products.ProductPrice
 		var updatedAt time.Time
+		var packCount *int
+		var packAmount *float64
+		var packUnit *string
 
 		err := rows.Scan(
 			&price.ProductID,
@@ -471,6 +475,10 @@ func (a *ProductsAdapter) GetProductPrices(productID string) ([]*products.Produc
 			&price.URL,
 			&price.InStock,
 			&updatedAt,
+			&packCount,
+			&packAmount,
+			&packUnit,
+			&price.UnitPrice,
 		)
 
 		if err != nil {
@@ -478,6 +486,7 @@ func (a *ProductsAdapter) GetProductPrices(productID string) ([]*products.Produc
 		}
 
 		price.UpdatedAt = updatedAt
+		price.Pack = scanPack(packCount, packAmount, packUnit)
 
 		// Логируем для отладки shop_name
 		if a.logger != nil && price.ShopName == "" {
@@ -512,14 +521,16 @@ func (a *ProductsAdapter) GetProductPricesByCity(productID string, cityID string
 	// Оптимизированный запрос: использует UNION для эффективного использования индексов
 	query := `
 		(
-			SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at
+			SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at,
+			       pack_count, pack_amount::float8, pack_unit, unit_price::float8
 			FROM product_prices
 			WHERE product_id = $1 AND city_id = $2
 			ORDER BY price ASC, updated_at DESC
 		)
 		UNION ALL
 		(
-			SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at
+			SELECT product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at,
+			       pack_count, pack_amount::float8, pack_unit, unit_price::float8
 			FROM product_prices
 			WHERE product_id = $1 AND city_id IS NULL
 			ORDER BY price ASC, updated_at DESC
@@ -538,6 +549,9 @@ func (a *ProductsAdapter) GetProductPricesByCity(productID string, cityID string
 	for rows.Next() {
 		var price products.ProductPrice
 		var updatedAt time.Time
+		var packCount *int
+		var packAmount *float64
+		var packUnit *string
 
 		err := rows.Scan(
 			&price.ProductID,
@@ -548,6 +562,10 @@ func (a *ProductsAdapter) GetProductPricesByCity(productID string, cityID string
 			&price.URL,
 			&price.InStock,
 			&updatedAt,
+			&packCount,
+			&packAmount,
+			&packUnit,
+			&price.UnitPrice,
 		)
 
 		if err != nil {
@@ -555,6 +573,7 @@ func (a *ProductsAdapter) GetProductPricesByCity(productID string, cityID string
 		}
 
 		price.UpdatedAt = updatedAt
+		price.Pack = scanPack(packCount, packAmount, packUnit)
 		prices = append(prices, &price)
 	}
 
@@ -565,6 +584,77 @@ func (a *ProductsAdapter) GetProductPricesByCity(productID string, cityID string
 	return prices, nil
 }
 
+// GetOffers получает предложения товара вместе с условиями доставки магазинов
+func (a *ProductsAdapter) GetOffers(ctx context.Context, productID string, cityID *string) ([]*products.Offer, error) {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	var cityUUID *uuid.UUID
+	if cityID != nil {
+		parsed, err := a.ParseUUID(*cityID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid city ID: %w", err)
+		}
+		cityUUID = &parsed
+	}
+
+	query := `
+		SELECT pp.product_id, pp.shop_id, pp.shop_name, pp.price, COALESCE(pp.currency, 'RSD'),
+		       COALESCE(pp.url, ''), COALESCE(pp.in_stock, true), pp.updated_at, pp.city_id,
+		       s.delivery_fee, s.free_delivery_from,
+		       pp.pack_count, pp.pack_amount::float8, pp.pack_unit, pp.unit_price::float8
+		FROM product_prices pp
+		JOIN shops s ON s.id = pp.shop_id
+		WHERE pp.product_id = $1
+		  AND ($2::uuid IS NULL OR pp.city_id = $2 OR pp.city_id IS NULL)
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, productUUID, cityUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product offers: %w", err)
+	}
+	defer rows.Close()
+
+	offers := make([]*products.Offer, 0)
+	for rows.Next() {
+		var (
+			offer      products.Offer
+			packCount  *int
+			packAmount *float64
+			packUnit   *string
+		)
+		if err := rows.Scan(
+			&offer.ProductID,
+			&offer.ShopID,
+			&offer.ShopName,
+			&offer.Price,
+			&offer.Currency,
+			&offer.URL,
+			&offer.InStock,
+			&offer.UpdatedAt,
+			&offer.CityID,
+			&offer.DeliveryFee,
+			&offer.FreeDeliveryFrom,
+			&packCount,
+			&packAmount,
+			&packUnit,
+			&offer.UnitPrice,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan offer: %w", err)
+		}
+		offer.Pack = scanPack(packCount, packAmount, packUnit)
+		offers = append(offers, &offer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating offers: %w", err)
+	}
+
+	return offers, nil
+}
+
 // Browse возвращает каталог товаров с фильтрами
 func (a *ProductsAdapter) Browse(ctx context.Context, params products.BrowseParams) (*products.BrowseResult, error) {
 	// Используем Meilisearch для поиска с фильтрами (основной метод)
@@ -673,21 +763,42 @@ func (a *ProductsAdapter) browseViaMeilisearch(ctx context.Context, params produ
 	if params.Brand != "" {
 		filters = append(filters, fmt.Sprintf("brand = \"%s\"", params.Brand))
 	}
+
+	// Фильтры по атрибутам справочника (поля attrs.<code> документа)
+	filters = append(filters, meiliAttributeFilters(params.Attributes)...)
 	
-	// Пока shop_id и price фильтры пропускаем, т.к. эти поля могут быть не в индексе
-	// Их можно добавить позже, когда обновим индекс
+	// Цены в индексе агрегированы по всем городам, поэтому при фильтре по городу
+	// диапазон цен проверяется только по ценам из PostgreSQL (ниже)
+	if params.CityID == nil {
+		if params.MinPrice != nil {
+			filters = append(filters, fmt.Sprintf("max_price >= %f", *params.MinPrice))
+		}
+		if params.MaxPrice != nil {
+			filters = append(filters, fmt.Sprintf("min_price <= %f", *params.MaxPrice))
+		}
+	}
 
 	if len(filters) > 0 {
 		searchReq.Filter = filters
 	}
 
 	// Сортировка
+	if params.SortAttribute != nil {
+		direction := "asc"
+		if params.SortAttribute.Desc {
+			direction = "desc"
+		}
+		searchReq.Sort = []string{"attrs." + params.SortAttribute.Code + ":" + direction}
+	}
 	switch params.Sort {
 	case "price_asc":
-		// Пока сортировка по цене не работает без min_price в индексе
-		// Можно добавить позже
+		searchReq.Sort = []string{"min_price:asc"}
 	case "price_desc":
-		// Пока сортировка по цене не работает без min_price в индексе
+		searchReq.Sort = []string{"min_price:desc"}
+	case "unit_price_asc":
+		searchReq.Sort = []string{"unit:asc", "min_unit_price:asc"}
+	case "unit_price_desc":
+		searchReq.Sort = []string{"unit:asc", "min_unit_price:desc"}
 	case "newest":
 		searchReq.Sort = []string{"created_at:desc"}
 	case "name_asc":
@@ -826,6 +937,7 @@ func (a *ProductsAdapter) browseViaMeilisearch(ctx context.Context, params produ
 			browseProduct.MinPrice = minPrice
 			browseProduct.MaxPrice = maxPrice
 			browseProduct.Currency = currency
+			browseProduct.UnitPrice, browseProduct.Unit = products.MinUnitPrice(prices)
 
 			// Применяем фильтры по цене и shop_id (если указаны)
 			// Товар должен попадать в диапазон: min_price >= MinPrice и max_price <= MaxPrice
@@ -872,6 +984,10 @@ func (a *ProductsAdapter) browseViaMeilisearch(ctx context.Context, params produ
 				}
 			}
 		}
+	case "unit_price_asc":
+		products.SortByUnitPrice(items, false)
+	case "unit_price_desc":
+		products.SortByUnitPrice(items, true)
 	case "name_asc":
 		// Сортируем по названию (A-Z)
 		for i := 0; i < len(items)-1; i++ {
@@ -986,17 +1102,32 @@ func (a *ProductsAdapter) browseViaPostgres(ctx context.Context, params products
 			argIndex++
 		}
 		
+		// Фильтры по атрибутам справочника
+		attrConditions, attrArgs := attributeFilterConditions(params.Attributes, "products.id", argIndex)
+		for _, condition := range attrConditions {
+			querySQL += " AND " + condition
+		}
+		args = append(args, attrArgs...)
+		argIndex += len(attrArgs)
+
 		// Фильтр по brand (если нужен)
 		// Фильтр по shop_id будет применен позже через product_prices
 		
 		// Сортировка
-		switch params.Sort {
-		case "price_asc", "price_desc":
+		switch {
+		case params.SortAttribute != nil:
+			orderBy, orderArgs := attributeOrderBy(params.SortAttribute, "products.id", argIndex)
+			querySQL += " ORDER BY " + orderBy + ", name ASC"
+			args = append(args, orderArgs...)
+			argIndex += len(orderArgs)
+		case params.Sort == "price_asc", params.Sort == "price_desc":
 			// Сортировка по цене требует JOIN с product_prices, делаем по имени
 			querySQL += " ORDER BY name ASC"
-		case "newest":
+		case params.Sort == "unit_price_asc", params.Sort == "unit_price_desc":
+			querySQL += " ORDER BY " + unitPriceOrderBy("products.id", params.Sort == "unit_price_desc") + ", name ASC"
+		case params.Sort == "newest":
 			querySQL += " ORDER BY created_at DESC"
-		case "name_asc":
+		case params.Sort == "name_asc":
 			querySQL += " ORDER BY name ASC"
 		default:
 			querySQL += " ORDER BY name ASC"
@@ -1087,9 +1218,26 @@ func (a *ProductsAdapter) browseViaPostgres(ctx context.Context, params products
 		}
 	}
 
+	// Для результатов поиска фильтры по атрибутам проверяются отдельным запросом
+	var attributeMatches map[string]bool
+	if params.Query != "" && len(params.Attributes) > 0 {
+		ids := make([]string, len(productsList))
+		for i, p := range productsList {
+			ids[i] = p.ID
+		}
+		attributeMatches, err = a.filterProductIDsByAttributes(ctx, ids, params.Attributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Преобразуем в BrowseProduct
 	items := make([]products.BrowseProduct, 0, len(productsList))
 	for _, p := range productsList {
+		if attributeMatches != nil && !attributeMatches[p.ID] {
+			continue
+		}
+
 		// Фильтр по списку category_id (родитель + дочерние категории)
 		if len(params.CategoryIDs) > 0 {
 			found := false
@@ -1265,6 +1413,7 @@ func (a *ProductsAdapter) browseViaPostgres(ctx context.Context, params products
 			browseProduct.MinPrice = minPrice
 			browseProduct.MaxPrice = maxPrice
 			browseProduct.Currency = currency
+			browseProduct.UnitPrice, browseProduct.Unit = products.MinUnitPrice(prices)
 
 			// Применяем фильтры по цене и shop_id
 			if params.MinPrice != nil && browseProduct.MaxPrice < *params.MinPrice {
@@ -1331,6 +1480,10 @@ func (a *ProductsAdapter) browseViaPostgres(ctx context.Context, params products
 				}
 			}
 		}
+	case "unit_price_asc":
+		products.SortByUnitPrice(items, false)
+	case "unit_price_desc":
+		products.SortByUnitPrice(items, true)
 	case "name_asc":
 		// Сортируем по названию (A-Z)
 		for i := 0; i < len(items)-1; i++ {
@@ -1351,6 +1504,13 @@ func (a *ProductsAdapter) browseViaPostgres(ctx context.Context, params products
 		}
 	}
 
+	// Результаты поиска упорядочиваются по атрибуту здесь (без запроса ORDER BY не применялся)
+	if params.SortAttribute != nil && params.Query != "" {
+		if err := a.sortByAttribute(ctx, items, params.SortAttribute); err != nil {
+			return nil, err
+		}
+	}
+
 	// Сохраняем total ДО пагинации
 	totalCount := len(items)
 
@@ -1384,18 +1544,24 @@ func (a *ProductsAdapter) SaveProductPrice(price *products.ProductPrice) error {
 	}
 
 	query := `
-		INSERT INTO product_prices (product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO product_prices (product_id, shop_id, shop_name, price, currency, url, in_stock, updated_at,
+		                            pack_count, pack_amount, pack_unit, unit_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (product_id, shop_id) DO UPDATE SET
 			shop_name = EXCLUDED.shop_name,
 			price = EXCLUDED.price,
 			currency = EXCLUDED.currency,
 			url = EXCLUDED.url,
 			in_stock = EXCLUDED.in_stock,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			pack_count = EXCLUDED.pack_count,
+			pack_amount = EXCLUDED.pack_amount,
+			pack_unit = EXCLUDED.pack_unit,
+			unit_price = EXCLUDED.unit_price
 	`
 
 	price.UpdatedAt = time.Now()
+	packCount, packAmount, packUnit := packColumns(price.Pack)
 
 	_, err = a.pg.DB().Exec(a.GetContext(), query,
 		productUUID,
@@ -1406,6 +1572,10 @@ func (a *ProductsAdapter) SaveProductPrice(price *products.ProductPrice) error {
 		price.URL,
 		price.InStock,
 		price.UpdatedAt,
+		packCount,
+		packAmount,
+		packUnit,
+		price.UnitPrice,
 	)
 
 	if err != nil {
@@ -1414,55 +1584,3 @@ func (a *ProductsAdapter) SaveProductPrice(price *products.ProductPrice) error {
 
 	return nil
 }
-
-// GetURLsForRescrape возвращает список URL и ID магазинов для товаров,
-// цена которых не обновлялась дольше указанного времени
-func (a *ProductsAdapter) GetURLsForRescrape(ctx context.Context, olderThan time.Duration, limit int) ([]products.RescrapeItem, error) {
-	// Выбираем цены, обновленные давно, но у активных магазинов
-	// Используем DISTINCT ON для получения уникальных пар (url, shop_id) с самой старой датой
-	query := `
-		SELECT url, shop_id
-		FROM (
-			SELECT DISTINCT ON (pp.url, pp.shop_id) 
-				pp.url, 
-				pp.shop_id, 
-				pp.updated_at
-			FROM product_prices pp
-			JOIN shops s ON pp.shop_id = s.id
-			WHERE s.is_active = true
-			  AND pp.url IS NOT NULL
-			  AND pp.url != ''
-			  AND pp.updated_at < $1
-			ORDER BY pp.url, pp.shop_id, pp.updated_at ASC
-		) AS distinct_prices
-		ORDER BY updated_at ASC
-		LIMIT $2
-	`
-
-	threshold := time.Now().Add(-olderThan)
-
-	rows, err := a.pg.DB().Query(ctx, query, threshold, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query URLs for rescrape: %w", err)
-	}
-	defer rows.Close()
-
-	var results []products.RescrapeItem
-
-	for rows.Next() {
-		var item products.RescrapeItem
-		if err := rows.Scan(&item.URL, &item.ShopID); err != nil {
-			a.logger.Warn("Failed to scan rescrape item", map[string]interface{}{"error": err.Error()})
-			continue
-		}
-		results = append(results, item)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rescrape items: %w", err)
-	}
-
-	return results, nil
-}
-
-
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solomonczyk/izborator/internal/products"
+)
+
+// attributeFilterConditions условия SQL для фильтров по атрибутам; productIDExpr - колонка ID товара в запросе.
+// Возвращает условия и аргументы, нумерация плейсхолдеров начинается с argIndex
+func attributeFilterConditions(filters []products.AttributeFilter, productIDExpr string, argIndex int) ([]string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	next := func(value interface{}) string {
+		args = append(args, value)
+		placeholder := fmt.Sprintf("$%d", argIndex)
+		argIndex++
+		return placeholder
+	}
+
+	for _, filter := range filters {
+		clauses := []string{
+			"pav.product_id = " + productIDExpr,
+			"pav.attribute_id = " + next(filter.AttributeID) + "::uuid",
+		}
+		if filter.Min != nil {
+			clauses = append(clauses, "pav.value_number >= "+next(*filter.Min))
+		}
+		if filter.Max != nil {
+			clauses = append(clauses, "pav.value_number <= "+next(*filter.Max))
+		}
+		if len(filter.Values) > 0 {
+			values := make([]string, len(filter.Values))
+			for i, value := range filter.Values {
+				values[i] = strings.ToLower(value)
+			}
+			clauses = append(clauses, "LOWER(pav.value_text) = ANY("+next(values)+"::text[])")
+		}
+		if filter.Bool != nil {
+			clauses = append(clauses, "pav.value_bool = "+next(*filter.Bool))
+		}
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM product_attribute_values pav WHERE "+strings.Join(clauses, " AND ")+")")
+	}
+
+	return conditions, args
+}
+
+// attributeOrderBy выражение ORDER BY по значению атрибута: числа, затем текст; товары без значения в конце
+func attributeOrderBy(sortAttr *products.AttributeSort, productIDExpr string, argIndex int) (string, []interface{}) {
+	direction := "ASC"
+	if sortAttr.Desc {
+		direction = "DESC"
+	}
+	placeholder := fmt.Sprintf("$%d", argIndex)
+	value := func(column string) string {
+		return fmt.Sprintf(
+			"(SELECT %s FROM product_attribute_values pav WHERE pav.product_id = %s AND pav.attribute_id = %s::uuid) %s NULLS LAST",
+			column, productIDExpr, placeholder, direction,
+		)
+	}
+	return value("pav.value_number") + ", " + value("LOWER(pav.value_text)"), []interface{}{sortAttr.AttributeID}
+}
+
+// filterProductIDsByAttributes оставляет из productIDs товары, подходящие под все фильтры
+func (a *ProductsAdapter) filterProductIDsByAttributes(ctx context.Context, productIDs []string, filters []products.AttributeFilter) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	if len(productIDs) == 0 {
+		return matched, nil
+	}
+
+	conditions, args := attributeFilterConditions(filters, "p.id", 2)
+	query := `SELECT p.id::text FROM products p WHERE p.id = ANY($1::uuid[])`
+	if len(conditions) > 0 {
+		query += " AND " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := a.pg.DB().Query(ctx, query, append([]interface{}{productIDs}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter products by attributes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		matched[id] = true
+	}
+	return matched, rows.Err()
+}
+
+// sortByAttribute упорядочивает товары по значению атрибута (для поиска через PostgreSQL, где ORDER BY не применяется)
+func (a *ProductsAdapter) sortByAttribute(ctx context.Context, items []products.BrowseProduct, sortAttr *products.AttributeSort) error {
+	if len(items) < 2 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT product_id::text, value_number, LOWER(value_text)
+		FROM product_attribute_values
+		WHERE attribute_id = $1::uuid AND product_id = ANY($2::uuid[])
+	`, sortAttr.AttributeID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load attribute sort values: %w", err)
+	}
+	defer rows.Close()
+
+	type sortKey struct {
+		number *float64
+		text   *string
+	}
+	keys := make(map[string]sortKey)
+	for rows.Next() {
+		var (
+			id  string
+			key sortKey
+		)
+		if err := rows.Scan(&id, &key.number, &key.text); err != nil {
+			return fmt.Errorf("failed to scan attribute sort value: %w", err)
+		}
+		keys[id] = key
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating attribute sort values: %w", err)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		ki, iok := keys[items[i].ID]
+		kj, jok := keys[items[j].ID]
+		if !iok || !jok {
+			// Товары без значения - в конце
+			return iok && !jok
+		}
+		switch {
+		case ki.number != nil && kj.number != nil:
+			if sortAttr.Desc {
+				return *ki.number > *kj.number
+			}
+			return *ki.number < *kj.number
+		case ki.text != nil && kj.text != nil:
+			if sortAttr.Desc {
+				return *ki.text > *kj.text
+			}
+			return *ki.text < *kj.text
+		}
+		return false
+	})
+	return nil
+}
+
+// AttributeFacets считает значения фильтруемых атрибутов типов товаров категорий
+// среди товаров этих категорий
+func (a *ProductsAdapter) AttributeFacets(ctx context.Context, categoryIDs []string) ([]*products.AttributeFacet, error) {
+	if ctx == nil {
+		ctx = a.GetContext()
+	}
+
+	query := `
+		WITH scoped AS (
+			SELECT a.id, a.code, a.name_sr, a.data_type, a.unit_sr, a.is_sortable,
+			       MIN(pta.sort_order) AS sort_order
+			FROM category_product_types cpt
+			JOIN product_type_attributes pta ON pta.product_type_id = cpt.product_type_id
+			JOIN attributes a ON a.id = pta.attribute_id AND a.is_filterable = TRUE
+			WHERE cpt.category_id = ANY($1::uuid[])
+			GROUP BY a.id, a.code, a.name_sr, a.data_type, a.unit_sr, a.is_sortable
+		)
+		SELECT s.code, s.name_sr, s.data_type, COALESCE(s.unit_sr, ''), s.is_sortable,
+		       pav.value_number, MIN(pav.value_text), pav.value_bool,
+		       COUNT(DISTINCT pav.product_id)
+		FROM scoped s
+		JOIN product_attribute_values pav ON pav.attribute_id = s.id
+		JOIN products p ON p.id = pav.product_id AND p.category_id = ANY($1::uuid[])
+		GROUP BY s.code, s.name_sr, s.data_type, s.unit_sr, s.is_sortable, s.sort_order,
+		         pav.value_number, LOWER(pav.value_text), pav.value_bool
+		ORDER BY s.sort_order, s.code, pav.value_number, LOWER(pav.value_text), pav.value_bool
+	`
+
+	rows, err := a.pg.DB().Query(ctx, query, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attribute facets: %w", err)
+	}
+	defer rows.Close()
+
+	facets := make([]*products.AttributeFacet, 0)
+	var current *products.AttributeFacet
+	for rows.Next() {
+		var (
+			facet  products.AttributeFacet
+			number *float64
+			text   *string
+			flag   *bool
+			count  int
+		)
+		if err := rows.Scan(&facet.Code, &facet.Name, &facet.DataType, &facet.Unit, &facet.IsSortable,
+			&number, &text, &flag, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute facet: %w", err)
+		}
+
+		code := strings.ToLower(facet.Code)
+		if current == nil || current.Code != code {
+			facet.Code = code
+			facet.Values = []products.AttributeFacetValue{}
+			current = &facet
+			facets = append(facets, current)
+		}
+
+		var value string
+		switch {
+		case number != nil:
+			value = strconv.FormatFloat(*number, 'f', -1, 64)
+			if current.Min == nil || *number < *current.Min {
+				current.Min = number
+			}
+			if current.Max == nil || *number > *current.Max {
+				current.Max = number
+			}
+		case text != nil:
+			value = *text
+		case flag != nil:
+			value = strconv.FormatBool(*flag)
+		default:
+			continue
+		}
+		current.Values = append(current.Values, products.AttributeFacetValue{Value: value, Count: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attribute facets: %w", err)
+	}
+
+	return facets, nil
+}
+
+// meiliAttributeFilters фильтры Meilisearch по полям attrs.<code> документа товара
+func meiliAttributeFilters(filters []products.AttributeFilter) []string {
+	var result []string
+	for _, filter := range filters {
+		field := "attrs." + filter.Code
+		if filter.Min != nil {
+			result = append(result, fmt.Sprintf("%s >= %s", field, strconv.FormatFloat(*filter.Min, 'f', -1, 64)))
+		}
+		if filter.Max != nil {
+			result = append(result, fmt.Sprintf("%s <= %s", field, strconv.FormatFloat(*filter.Max, 'f', -1, 64)))
+		}
+		if len(filter.Values) > 0 {
+			values := make([]string, len(filter.Values))
+			for i, value := range filter.Values {
+				values[i] = meiliQuote(strings.ToLower(value))
+			}
+			result = append(result, fmt.Sprintf("%s IN [%s]", field, strings.Join(values, ", ")))
+		}
+		if filter.Bool != nil {
+			result = append(result, fmt.Sprintf("%s = %t", field, *filter.Bool))
+		}
+	}
+	return result
+}
+
+// meiliQuote строка в кавычках для фильтра Meilisearch
+func meiliQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
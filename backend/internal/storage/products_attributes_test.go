@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/solomonczyk/izborator/internal/products"
+)
+
+func TestAttributeFilterConditions(t *testing.T) {
+	low, high := 8.0, 16.0
+	filters := []products.AttributeFilter{
+		{AttributeID: "ram-id", Code: "ram", Min: &low, Max: &high},
+		{AttributeID: "color-id", Code: "color", Values: []string{"Black"}},
+	}
+
+	conditions, args := attributeFilterConditions(filters, "products.id", 3)
+	if len(conditions) != 2 || len(args) != 5 {
+		t.Fatalf("unexpected conditions %v args %v", conditions, args)
+	}
+	if !strings.Contains(conditions[0], "pav.attribute_id = $3::uuid") ||
+		!strings.Contains(conditions[0], "pav.value_number >= $4") ||
+		!strings.Contains(conditions[0], "pav.value_number <= $5") {
+		t.Errorf("unexpected range condition %s", conditions[0])
+	}
+	if !strings.Contains(conditions[1], "LOWER(pav.value_text) = ANY($7::text[])") {
+		t.Errorf("unexpected values condition %s", conditions[1])
+	}
+	if values, ok := args[4].([]string); !ok || values[0] != "black" {
+		t.Errorf("values must be lowercased, got %v", args[4])
+	}
+}
+
+func TestMeiliAttributeFilters(t *testing.T) {
+	low, flag := 8.0, true
+	filters := meiliAttributeFilters([]products.AttributeFilter{
+		{Code: "ram", Min: &low},
+		{Code: "color", Values: []string{"Black", `say "hi"`}},
+		{Code: "nfc", Bool: &flag},
+	})
+
+	want := []string{
+		"attrs.ram >= 8",
+		`attrs.color IN ["black", "say \"hi\""]`,
+		"attrs.nfc = true",
+	}
+	if len(filters) != len(want) {
+		t.Fatalf("expected %d filters, got %v", len(want), filters)
+	}
+	for i := range want {
+		if filters[i] != want[i] {
+			t.Errorf("filter %d: got %s, want %s", i, filters[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"github.com/solomonczyk/izborator/internal/products"
+)
+
+// packColumns значения колонок pack_count, pack_amount, pack_unit (NULL без фасовки)
+func packColumns(pack *products.PackSize) (*int, *float64, *string) {
+	if pack == nil {
+		return nil, nil, nil
+	}
+	return &pack.Count, &pack.Amount, &pack.Unit
+}
+
+// scanPack собирает фасовку из прочитанных колонок pack_count, pack_amount, pack_unit
+func scanPack(count *int, amount *float64, unit *string) *products.PackSize {
+	if count == nil || amount == nil || unit == nil {
+		return nil
+	}
+	return &products.PackSize{Count: *count, Amount: *amount, Unit: *unit}
+}
+
+// unitPriceOrderBy выражение сортировки товаров по минимальной цене за единицу внутри групп
+// kg, l, pcs (единица самого дешёвого предложения); товары без фасовки - в конце в обоих направлениях
+func unitPriceOrderBy(productIDExpr string, desc bool) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	return "(SELECT pp.pack_unit FROM product_prices pp WHERE pp.product_id = " + productIDExpr +
+		" AND pp.unit_price IS NOT NULL ORDER BY pp.unit_price LIMIT 1) ASC NULLS LAST, " +
+		"(SELECT MIN(pp.unit_price) FROM product_prices pp WHERE pp.product_id = " + productIDExpr + ") " +
+		direction + " NULLS LAST"
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/rescrape"
+)
+
+// rescrapeDispatchLock ключ advisory lock: адреса раздаёт только один процесс одновременно
+const rescrapeDispatchLock = 72410020
+
+// RescrapeAdapter адаптер расписания повторного парсинга
+type RescrapeAdapter struct {
+	*BaseAdapter
+}
+
+// NewRescrapeAdapter создаёт новый адаптер расписания повторного парсинга
+func NewRescrapeAdapter(pg *Postgres) rescrape.Storage {
+	return &RescrapeAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+// SeedURLs добавляет в расписание адреса цен, которых там ещё нет
+func (a *RescrapeAdapter) SeedURLs(ctx context.Context, firstVisit time.Duration) (int, error) {
+	query := `
+		INSERT INTO scrape_schedule (shop_id, url, next_visit_at, last_visited_at)
+		SELECT pp.shop_id, pp.url,
+		       COALESCE(MAX(pp.updated_at), NOW()) + make_interval(secs => $1),
+		       MAX(pp.updated_at)
+		FROM product_prices pp
+		WHERE pp.url IS NOT NULL AND pp.url <> ''
+		  AND NOT EXISTS (
+			SELECT 1 FROM scrape_schedule t WHERE t.shop_id = pp.shop_id AND t.url = pp.url
+		  )
+		GROUP BY pp.shop_id, pp.url
+		ON CONFLICT (shop_id, url) DO NOTHING
+	`
+
+	tag, err := a.pg.DB().Exec(ctx, query, firstVisit.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to seed scrape schedule: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ScheduleURLs добавляет адреса магазина в расписание к немедленной раздаче
+// и переносит на сейчас уже известные адреса с более поздним посещением (кроме отправленных)
+func (a *RescrapeAdapter) ScheduleURLs(ctx context.Context, shopID string, urls []string) (int, error) {
+	query := `
+		INSERT INTO scrape_schedule (shop_id, url, next_visit_at)
+		SELECT $1, u.url, NOW()
+		FROM (SELECT DISTINCT unnest($2::text[]) AS url) u
+		WHERE u.url <> ''
+		ON CONFLICT (shop_id, url)
+		DO UPDATE SET next_visit_at = EXCLUDED.next_visit_at
+		WHERE scrape_schedule.next_visit_at > EXCLUDED.next_visit_at
+		  AND scrape_schedule.dispatched_at IS NULL
+	`
+
+	tag, err := a.pg.DB().Exec(ctx, query, shopID, urls)
+	if err != nil {
+		return 0, fmt.Errorf("failed to schedule urls: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ClaimDue забирает созревшие адреса в пределах суточного бюджета магазинов и откладывает их на lease.
+// Порядок: приоритет, умноженный на просрочку в интервалах, - долго ждущие адреса не голодают.
+// Отправленный адрес (dispatched_at не сброшен Reschedule) раздаётся снова только через lease
+// и бюджет магазина повторно не расходует: страница за это посещение ещё не запрошена
+func (a *RescrapeAdapter) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*rescrape.Job, error) {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, rescrapeDispatchLock).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to acquire dispatch lock: %w", err)
+	}
+	if !locked {
+		// Адреса сейчас раздаёт другой воркер
+		return nil, nil
+	}
+
+	query := `
+		WITH usage AS (
+			SELECT shop_id, dispatched
+			FROM shop_scrape_budget_usage
+			WHERE day = CURRENT_DATE
+		), due AS (
+			SELECT t.shop_id, t.url,
+			       t.priority * (1 + EXTRACT(EPOCH FROM NOW() - t.next_visit_at) / GREATEST(t.interval_seconds, 3600)) AS score,
+			       s.scrape_budget - COALESCE(u.dispatched, 0) AS remaining,
+			       t.priority, t.next_visit_at, t.dispatched_at IS NULL AS fresh
+			FROM scrape_schedule t
+			JOIN shops s ON s.id = t.shop_id AND s.is_active = TRUE
+			LEFT JOIN usage u ON u.shop_id = t.shop_id
+			WHERE t.next_visit_at <= NOW()
+			  AND (t.dispatched_at IS NULL OR t.dispatched_at <= NOW() - make_interval(secs => $2))
+		), ranked AS (
+			SELECT due.*, ROW_NUMBER() OVER (PARTITION BY shop_id ORDER BY score DESC, next_visit_at) AS rn
+			FROM due
+		)
+		SELECT shop_id, url, priority, fresh
+		FROM ranked
+		WHERE rn <= remaining
+		ORDER BY score DESC, next_visit_at
+		LIMIT $1
+	`
+
+	// NOW() постоянно в пределах транзакции: это же значение запишется в dispatched_at
+	// и станет отметкой раздачи, по которой IsDispatched узнаёт текущее задание
+	var dispatchedAt time.Time
+	if err := tx.QueryRow(ctx, `SELECT NOW()`).Scan(&dispatchedAt); err != nil {
+		return nil, fmt.Errorf("failed to get dispatch time: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due urls: %w", err)
+	}
+
+	var jobs []*rescrape.Job
+	var shopIDs, urls, chargedShopIDs []string
+	for rows.Next() {
+		job := &rescrape.Job{DispatchedAt: dispatchedAt}
+		var fresh bool
+		if err := rows.Scan(&job.ShopID, &job.URL, &job.Priority, &fresh); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan due url: %w", err)
+		}
+		jobs = append(jobs, job)
+		shopIDs = append(shopIDs, job.ShopID)
+		urls = append(urls, job.URL)
+		if fresh {
+			chargedShopIDs = append(chargedShopIDs, job.ShopID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due urls: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE scrape_schedule t
+		SET next_visit_at = NOW() + make_interval(secs => $3),
+		    dispatched_at = NOW()
+		FROM unnest($1::text[], $2::text[]) AS c(shop_id, url)
+		WHERE t.shop_id = c.shop_id AND t.url = c.url
+	`, shopIDs, urls, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease due urls: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop_scrape_budget_usage (shop_id, day, dispatched)
+		SELECT shop_id, CURRENT_DATE, COUNT(*)
+		FROM unnest($1::text[]) AS c(shop_id)
+		GROUP BY shop_id
+		ON CONFLICT (shop_id, day)
+		DO UPDATE SET dispatched = shop_scrape_budget_usage.dispatched + EXCLUDED.dispatched
+	`, chargedShopIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shop budget usage: %w", err)
+	}
+
+	// Счётчики прошлых дней больше не нужны
+	if _, err := tx.Exec(ctx, `DELETE FROM shop_scrape_budget_usage WHERE day < CURRENT_DATE - 7`); err != nil {
+		return nil, fmt.Errorf("failed to prune shop budget usage: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetSignals собирает признаки адреса: изменения цены, подписки, предложения, наличие и бюджет магазина.
+// Число адресов магазина считается отдельно (CountShopURLs) и кэшируется планировщиком
+func (a *RescrapeAdapter) GetSignals(ctx context.Context, shopID, url string, volatilityWindow time.Duration) (*rescrape.Signals, error) {
+	query := `
+		SELECT
+			COALESCE(t.failures, 0),
+			s.scrape_budget,
+			COALESCE(p.in_stock, TRUE),
+			(SELECT COUNT(*) FROM price_history ph
+			 WHERE ph.product_id = p.product_id AND ph.shop_id = s.id
+			   AND ph.recorded_at > NOW() - make_interval(secs => $3)),
+			(SELECT COUNT(*) FROM price_alert_subscriptions pa
+			 WHERE pa.product_id = p.product_id AND pa.is_active = TRUE),
+			(SELECT COUNT(DISTINCT o.shop_id) FROM product_prices o WHERE o.product_id = p.product_id)
+		FROM shops s
+		LEFT JOIN scrape_schedule t ON t.shop_id = s.id AND t.url = $2
+		LEFT JOIN LATERAL (
+			SELECT pp.product_id, pp.in_stock
+			FROM product_prices pp
+			WHERE pp.shop_id = s.id AND pp.url = $2
+			ORDER BY pp.updated_at DESC
+			LIMIT 1
+		) p ON TRUE
+		WHERE s.id = $1
+	`
+
+	var signals rescrape.Signals
+	err := a.pg.DB().QueryRow(ctx, query, shopID, url, volatilityWindow.Seconds()).Scan(
+		&signals.Failures,
+		&signals.ShopBudget,
+		&signals.InStock,
+		&signals.PriceChanges,
+		&signals.Alerts,
+		&signals.Offers,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, rescrape.ErrShopNotFound
+		}
+		return nil, fmt.Errorf("failed to get rescrape signals: %w", err)
+	}
+
+	return &signals, nil
+}
+
+// CountShopURLs возвращает число адресов в расписании по магазинам
+func (a *RescrapeAdapter) CountShopURLs(ctx context.Context) (map[string]int, error) {
+	rows, err := a.pg.DB().Query(ctx, `SELECT shop_id, COUNT(*) FROM scrape_schedule GROUP BY shop_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count shop urls: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var shopID string
+		var count int
+		if err := rows.Scan(&shopID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan shop url count: %w", err)
+		}
+		counts[shopID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shop url counts: %w", err)
+	}
+	return counts, nil
+}
+
+// IsDispatched проверяет, что адрес отправлен на парсинг этим заданием и ещё не выполнен:
+// после повторной раздачи dispatched_at меняется и прежние экземпляры задания не совпадают
+func (a *RescrapeAdapter) IsDispatched(ctx context.Context, job *rescrape.Job) (bool, error) {
+	var dispatched bool
+	err := a.pg.DB().QueryRow(ctx, `
+		SELECT COALESCE(dispatched_at = $3, FALSE) FROM scrape_schedule WHERE shop_id = $1 AND url = $2
+	`, job.ShopID, job.URL, job.DispatchedAt).Scan(&dispatched)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check dispatched url: %w", err)
+	}
+	return dispatched, nil
+}
+
+// Reschedule сохраняет время следующего посещения и результат текущего; адрес снова доступен для раздачи.
+// Адрес, уже розданный заново после lease, не трогается: его перепланирует задание новой раздачи
+func (a *RescrapeAdapter) Reschedule(ctx context.Context, job *rescrape.Job, plan rescrape.Plan, failures int, lastError string) error {
+	query := `
+		INSERT INTO scrape_schedule (
+			shop_id, url, next_visit_at, priority, interval_seconds,
+			failures, last_error, last_visited_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NOW())
+		ON CONFLICT (shop_id, url)
+		DO UPDATE SET
+			next_visit_at    = EXCLUDED.next_visit_at,
+			priority         = EXCLUDED.priority,
+			interval_seconds = EXCLUDED.interval_seconds,
+			failures         = EXCLUDED.failures,
+			last_error       = EXCLUDED.last_error,
+			last_visited_at  = EXCLUDED.last_visited_at,
+			dispatched_at    = NULL
+		WHERE scrape_schedule.dispatched_at IS NULL OR scrape_schedule.dispatched_at = $8
+	`
+
+	_, err := a.pg.DB().Exec(ctx, query,
+		job.ShopID,
+		job.URL,
+		plan.NextVisitAt,
+		plan.Priority,
+		int(plan.Interval.Seconds()),
+		failures,
+		lastError,
+		job.DispatchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule url: %w", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -117,20 +118,22 @@ func (a *ScraperAdapter) SaveRawProduct(data *scraper.RawProduct) error {
 	return nil
 }
 
-// GetShopConfig получает конфигурацию магазина
+// GetShopConfig получает конфигурацию магазина с селекторами активной версии
 func (a *ScraperAdapter) GetShopConfig(shopID string) (*scraper.ShopConfig, error) {
 	query := `
 		SELECT 
-			id,
-			name,
-			base_url,
-			selectors,
-			rate_limit,
-			is_active,
-			COALESCE(retry_limit, 3) AS retry_limit,
-			COALESCE(retry_backoff_ms, 3000) AS retry_backoff_ms
-		FROM shops
-		WHERE id = $1
+			s.id,
+			s.name,
+			s.base_url,
+			COALESCE(v.selectors, s.selectors) AS selectors,
+			s.rate_limit,
+			s.is_active,
+			COALESCE(s.retry_limit, 3) AS retry_limit,
+			COALESCE(s.retry_backoff_ms, 3000) AS retry_backoff_ms,
+			s.site_type
+		FROM shops s
+		LEFT JOIN shop_selector_versions v ON v.shop_id = s.id AND v.version = s.active_selector_version
+		WHERE s.id = $1
 	`
 
 	var config scraper.ShopConfig
@@ -146,6 +149,7 @@ func (a *ScraperAdapter) GetShopConfig(shopID string) (*scraper.ShopConfig, erro
 		&isActive,
 		&config.RetryLimit,
 		&config.RetryBackoffMs,
+		&config.SiteType,
 	)
 
 	config.Enabled = isActive
@@ -173,16 +177,18 @@ func (a *ScraperAdapter) GetShopConfig(shopID string) (*scraper.ShopConfig, erro
 func (a *ScraperAdapter) ListShops() ([]*scraper.ShopConfig, error) {
 	query := `
 		SELECT 
-			id,
-			name,
-			base_url,
-			selectors,
-			rate_limit,
-			is_active,
-			COALESCE(retry_limit, 3) AS retry_limit,
-			COALESCE(retry_backoff_ms, 3000) AS retry_backoff_ms
-		FROM shops
-		ORDER BY name
+			s.id,
+			s.name,
+			s.base_url,
+			COALESCE(v.selectors, s.selectors) AS selectors,
+			s.rate_limit,
+			s.is_active,
+			COALESCE(s.retry_limit, 3) AS retry_limit,
+			COALESCE(s.retry_backoff_ms, 3000) AS retry_backoff_ms,
+			s.site_type
+		FROM shops s
+		LEFT JOIN shop_selector_versions v ON v.shop_id = s.id AND v.version = s.active_selector_version
+		ORDER BY s.name
 	`
 
 	rows, err := a.pg.DB().Query(a.GetContext(), query)
@@ -207,6 +213,7 @@ func (a *ScraperAdapter) ListShops() ([]*scraper.ShopConfig, error) {
 			&isActive,
 			&shop.RetryLimit,
 			&shop.RetryBackoffMs,
+			&shop.SiteType,
 		)
 
 		shop.Enabled = isActive
@@ -397,3 +404,98 @@ func (a *ScraperAdapter) MarkRawProductAsProcessed(shopID, externalID string) er
 	return nil
 }
 
+// GetLinkedProductID возвращает товар, к которому привязан сырой товар; пусто - не привязан
+func (a *ScraperAdapter) GetLinkedProductID(shopID, externalID string) (string, error) {
+	query := `
+		SELECT COALESCE(product_id::text, '')
+		FROM raw_products
+		WHERE shop_id = $1
+		  AND external_id = $2
+	`
+
+	var productID string
+	err := a.pg.DB().QueryRow(a.GetContext(), query, shopID, externalID).Scan(&productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get raw product link: %w", err)
+	}
+
+	return productID, nil
+}
+
+// LinkRawProduct связывает сырой товар с каноническим товаром
+func (a *ScraperAdapter) LinkRawProduct(shopID, externalID, productID string) error {
+	productUUID, err := a.ParseUUID(productID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	query := `
+		UPDATE raw_products
+		SET product_id = $3
+		WHERE shop_id = $1
+		  AND external_id = $2
+	`
+
+	if _, err := a.pg.DB().Exec(a.GetContext(), query, shopID, externalID, productUUID); err != nil {
+		return fmt.Errorf("failed to link raw product: %w", err)
+	}
+
+	return nil
+}
+
+
+// GetCatalogLastMods возвращает lastmod уже разобранных адресов sitemap магазина
+func (a *ScraperAdapter) GetCatalogLastMods(shopID string) (map[string]time.Time, error) {
+	query := `
+		SELECT url, COALESCE(lastmod, 'epoch'::timestamptz)
+		FROM shop_catalog_urls
+		WHERE shop_id = $1
+	`
+
+	rows, err := a.pg.DB().Query(a.GetContext(), query, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog urls: %w", err)
+	}
+	defer rows.Close()
+
+	lastMods := make(map[string]time.Time)
+	for rows.Next() {
+		var url string
+		var lastMod time.Time
+		if err := rows.Scan(&url, &lastMod); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog url: %w", err)
+		}
+		lastMods[url] = lastMod
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate catalog urls: %w", err)
+	}
+
+	return lastMods, nil
+}
+
+// SaveCatalogLastMod запоминает lastmod разобранного адреса sitemap
+func (a *ScraperAdapter) SaveCatalogLastMod(shopID, url string, lastMod time.Time) error {
+	var lastModValue *time.Time
+	if !lastMod.IsZero() {
+		lastModValue = &lastMod
+	}
+
+	query := `
+		INSERT INTO shop_catalog_urls (shop_id, url, lastmod, scraped_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (shop_id, url)
+		DO UPDATE SET
+			lastmod    = EXCLUDED.lastmod,
+			scraped_at = EXCLUDED.scraped_at
+	`
+
+	if _, err := a.pg.DB().Exec(a.GetContext(), query, shopID, url, lastModValue); err != nil {
+		return fmt.Errorf("failed to save catalog url: %w", err)
+	}
+
+	return nil
+}
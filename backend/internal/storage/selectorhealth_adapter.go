@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/selectorhealth"
+)
+
+// SelectorHealthAdapter адаптер для мониторинга селекторов магазинов
+type SelectorHealthAdapter struct {
+	*BaseAdapter
+}
+
+// NewSelectorHealthAdapter создаёт новый адаптер мониторинга селекторов
+func NewSelectorHealthAdapter(pg *Postgres) selectorhealth.Storage {
+	return &SelectorHealthAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+const selectorHealthColumns = `
+	s.id, s.name, COALESCE(h.status, 'healthy'), COALESCE(h.field_stats, '{}'::jsonb),
+	COALESCE(h.degraded_fields, '{}'), COALESCE(h.reason, ''),
+	EXISTS (
+		SELECT 1 FROM shop_selector_versions v
+		WHERE v.shop_id = s.id AND v.activated_at IS NOT NULL
+		  AND v.version IS DISTINCT FROM s.active_selector_version
+	),
+	COALESCE(h.reconfig_attempts, 0), COALESCE(h.last_error, ''), h.degraded_at, h.reconfigured_at,
+	COALESCE(h.updated_at, NOW())
+`
+
+// SaveObservation сохраняет результат парсинга и удаляет записи магазина за пределами окна
+func (a *SelectorHealthAdapter) SaveObservation(ctx context.Context, obs *selectorhealth.Observation, keep int) error {
+	outcomesJSON, err := json.Marshal(obs.Outcomes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outcomes: %w", err)
+	}
+
+	_, err = a.pg.DB().Exec(ctx, `
+		INSERT INTO selector_health_samples (shop_id, url, outcomes, observed_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4)
+	`, obs.ShopID, obs.URL, outcomesJSON, obs.ObservedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert selector observation: %w", err)
+	}
+
+	if keep <= 0 {
+		return nil
+	}
+	_, err = a.pg.DB().Exec(ctx, `
+		DELETE FROM selector_health_samples
+		WHERE shop_id = $1
+		  AND id <= (
+			SELECT id FROM selector_health_samples
+			WHERE shop_id = $1
+			ORDER BY id DESC
+			OFFSET $2 LIMIT 1
+		  )
+	`, obs.ShopID, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune selector observations: %w", err)
+	}
+	return nil
+}
+
+// RecentObservations возвращает последние результаты селекторов магазина
+func (a *SelectorHealthAdapter) RecentObservations(ctx context.Context, shopID string, since *time.Time, limit int) ([]*selectorhealth.Observation, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT COALESCE(url, ''), outcomes, observed_at
+		FROM selector_health_samples
+		WHERE shop_id = $1
+		  AND ($2::timestamptz IS NULL OR observed_at > $2::timestamptz)
+		ORDER BY id DESC
+		LIMIT $3
+	`, shopID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query selector observations: %w", err)
+	}
+	defer rows.Close()
+
+	var observations []*selectorhealth.Observation
+	for rows.Next() {
+		obs := &selectorhealth.Observation{ShopID: shopID}
+		var outcomesJSON []byte
+		if err := rows.Scan(&obs.URL, &outcomesJSON, &obs.ObservedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan selector observation: %w", err)
+		}
+		if err := json.Unmarshal(outcomesJSON, &obs.Outcomes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outcomes: %w", err)
+		}
+		observations = append(observations, obs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating selector observations: %w", err)
+	}
+	return observations, nil
+}
+
+// GetHealth получает состояние селекторов магазина
+func (a *SelectorHealthAdapter) GetHealth(ctx context.Context, shopID string) (*selectorhealth.ShopHealth, error) {
+	row := a.pg.DB().QueryRow(ctx, `
+		SELECT `+selectorHealthColumns+`
+		FROM shops s
+		LEFT JOIN shop_selector_health h ON h.shop_id = s.id
+		WHERE s.id = $1
+	`, shopID)
+
+	health, err := scanSelectorHealth(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, selectorhealth.ErrShopNotFound
+		}
+		return nil, err
+	}
+	return health, nil
+}
+
+// SaveHealth сохраняет статистику и статус селекторов.
+// При переходе в degraded фиксируется время и сбрасывается счётчик попыток авто-конфигурации
+func (a *SelectorHealthAdapter) SaveHealth(ctx context.Context, health *selectorhealth.ShopHealth) error {
+	fieldsJSON, err := json.Marshal(health.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal field stats: %w", err)
+	}
+	degradedFields := health.DegradedFields
+	if degradedFields == nil {
+		degradedFields = []string{}
+	}
+
+	_, err = a.pg.DB().Exec(ctx, `
+		INSERT INTO shop_selector_health AS h (shop_id, status, field_stats, degraded_fields, reason, degraded_at, updated_at)
+		VALUES ($1, $2::varchar, $3, $4, NULLIF($5, ''), CASE WHEN $2::varchar = 'degraded' THEN NOW() END, NOW())
+		ON CONFLICT (shop_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			field_stats = EXCLUDED.field_stats,
+			degraded_fields = EXCLUDED.degraded_fields,
+			reason = EXCLUDED.reason,
+			degraded_at = CASE
+				WHEN EXCLUDED.status = 'degraded' AND h.status <> 'degraded' THEN NOW()
+				ELSE h.degraded_at
+			END,
+			reconfig_attempts = CASE
+				WHEN EXCLUDED.status = 'degraded' AND h.status <> 'degraded' THEN 0
+				ELSE h.reconfig_attempts
+			END,
+			updated_at = NOW()
+	`, health.ShopID, string(health.Status), fieldsJSON, degradedFields, health.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to save selector health: %w", err)
+	}
+	return nil
+}
+
+// ListHealth возвращает состояние селекторов магазинов, по которым есть данные
+func (a *SelectorHealthAdapter) ListHealth(ctx context.Context, status selectorhealth.Status) ([]*selectorhealth.ShopHealth, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT `+selectorHealthColumns+`
+		FROM shop_selector_health h
+		JOIN shops s ON s.id = h.shop_id
+		WHERE ($1 = '' OR h.status = $1)
+		ORDER BY (h.status = 'healthy'), h.degraded_at DESC NULLS LAST, s.name
+	`, string(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query selector health: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*selectorhealth.ShopHealth
+	for rows.Next() {
+		health, err := scanSelectorHealth(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, health)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating selector health: %w", err)
+	}
+	return items, nil
+}
+
+// RollbackSelectors активирует версию селекторов, действовавшую до текущей,
+// поэтому откат можно отменить повторным откатом
+func (a *SelectorHealthAdapter) RollbackSelectors(ctx context.Context, shopID string) error {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := lockShopSelectors(ctx, tx, shopID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return selectorhealth.ErrShopNotFound
+		}
+		return err
+	}
+
+	var previous int
+	if err := tx.QueryRow(ctx, previousSelectorVersionQuery, shopID).Scan(&previous); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return selectorhealth.ErrNoPreviousConfig
+		}
+		return fmt.Errorf("failed to get previous selector version: %w", err)
+	}
+
+	if err := activateSelectorVersion(ctx, tx, shopID, previous); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit rollback: %w", err)
+	}
+	return nil
+}
+
+// scanSelectorHealth сканирует строку состояния селекторов
+func scanSelectorHealth(row pgx.Row) (*selectorhealth.ShopHealth, error) {
+	var health selectorhealth.ShopHealth
+	var status string
+	var fieldsJSON []byte
+
+	err := row.Scan(
+		&health.ShopID,
+		&health.ShopName,
+		&status,
+		&fieldsJSON,
+		&health.DegradedFields,
+		&health.Reason,
+		&health.HasPreviousConfig,
+		&health.ReconfigAttempts,
+		&health.LastError,
+		&health.DegradedAt,
+		&health.ReconfiguredAt,
+		&health.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan selector health: %w", err)
+	}
+
+	health.Status = selectorhealth.Status(status)
+	if err := json.Unmarshal(fieldsJSON, &health.Fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal field stats: %w", err)
+	}
+	return &health, nil
+}
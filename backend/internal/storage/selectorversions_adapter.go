@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/solomonczyk/izborator/internal/selectorversions"
+)
+
+// SelectorVersionsAdapter адаптер для версий селекторов магазинов
+type SelectorVersionsAdapter struct {
+	*BaseAdapter
+}
+
+// NewSelectorVersionsAdapter создаёт новый адаптер версий селекторов
+func NewSelectorVersionsAdapter(pg *Postgres) selectorversions.Storage {
+	return &SelectorVersionsAdapter{
+		BaseAdapter: NewBaseAdapter(pg, nil),
+	}
+}
+
+const selectorVersionColumns = `
+	v.shop_id, v.version, v.selectors, v.source, COALESCE(v.author, ''), COALESCE(v.note, ''),
+	v.version IS NOT DISTINCT FROM s.active_selector_version, v.created_at, v.activated_at
+`
+
+// GetState возвращает активную версию и признак закрепления
+func (a *SelectorVersionsAdapter) GetState(ctx context.Context, shopID string) (*selectorversions.State, error) {
+	state := &selectorversions.State{ShopID: shopID}
+	err := a.pg.DB().QueryRow(ctx, `
+		SELECT COALESCE(active_selector_version, 0), selectors_pinned
+		FROM shops
+		WHERE id = $1
+	`, shopID).Scan(&state.ActiveVersion, &state.Pinned)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, selectorversions.ErrShopNotFound
+		}
+		return nil, fmt.Errorf("failed to get selector state: %w", err)
+	}
+	return state, nil
+}
+
+// ListVersions возвращает версии магазина, новые первыми
+func (a *SelectorVersionsAdapter) ListVersions(ctx context.Context, shopID string) ([]*selectorversions.Version, error) {
+	rows, err := a.pg.DB().Query(ctx, `
+		SELECT `+selectorVersionColumns+`
+		FROM shop_selector_versions v
+		JOIN shops s ON s.id = v.shop_id
+		WHERE v.shop_id = $1
+		ORDER BY v.version DESC
+	`, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query selector versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*selectorversions.Version
+	for rows.Next() {
+		version, err := scanSelectorVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating selector versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetVersion возвращает версию магазина по номеру
+func (a *SelectorVersionsAdapter) GetVersion(ctx context.Context, shopID string, version int) (*selectorversions.Version, error) {
+	row := a.pg.DB().QueryRow(ctx, `
+		SELECT `+selectorVersionColumns+`
+		FROM shop_selector_versions v
+		JOIN shops s ON s.id = v.shop_id
+		WHERE v.shop_id = $1 AND v.version = $2
+	`, shopID, version)
+
+	v, err := scanSelectorVersion(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, selectorversions.ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// CreateVersion сохраняет версию и активирует её, если магазин не закреплён
+func (a *SelectorVersionsAdapter) CreateVersion(ctx context.Context, version *selectorversions.Version) (bool, error) {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	created, err := createSelectorVersion(ctx, tx, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, selectorversions.ErrShopNotFound
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit selector version: %w", err)
+	}
+	return created, nil
+}
+
+// ActivateVersion делает версию активной; pin закрепляет магазин на ней
+func (a *SelectorVersionsAdapter) ActivateVersion(ctx context.Context, shopID string, version int, pin bool) error {
+	tx, err := a.pg.DB().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := lockShopSelectors(ctx, tx, shopID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return selectorversions.ErrShopNotFound
+		}
+		return err
+	}
+
+	if err := activateSelectorVersion(ctx, tx, shopID, version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return selectorversions.ErrVersionNotFound
+		}
+		return err
+	}
+
+	if pin {
+		if _, err := tx.Exec(ctx, `UPDATE shops SET selectors_pinned = TRUE WHERE id = $1`, shopID); err != nil {
+			return fmt.Errorf("failed to pin selectors: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit selector activation: %w", err)
+	}
+	return nil
+}
+
+// SetPinned закрепляет магазин на активной версии или снимает закрепление
+func (a *SelectorVersionsAdapter) SetPinned(ctx context.Context, shopID string, pinned bool) error {
+	result, err := a.pg.DB().Exec(ctx, `
+		UPDATE shops SET selectors_pinned = $2, updated_at = NOW() WHERE id = $1
+	`, shopID, pinned)
+	if err != nil {
+		return fmt.Errorf("failed to update selector pin: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return selectorversions.ErrShopNotFound
+	}
+	return nil
+}
+
+// PreviousVersion возвращает версию, активную до текущей
+func (a *SelectorVersionsAdapter) PreviousVersion(ctx context.Context, shopID string) (int, error) {
+	if _, err := a.GetState(ctx, shopID); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := a.pg.DB().QueryRow(ctx, previousSelectorVersionQuery, shopID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, selectorversions.ErrNoPreviousVersion
+		}
+		return 0, fmt.Errorf("failed to get previous selector version: %w", err)
+	}
+	return version, nil
+}
+
+// lockShopSelectors блокирует строку магазина до конца транзакции и возвращает признак закрепления.
+// Блокировка упорядочивает выдачу номеров версий
+func lockShopSelectors(ctx context.Context, tx pgx.Tx, shopID string) (bool, error) {
+	var pinned bool
+	err := tx.QueryRow(ctx, `SELECT selectors_pinned FROM shops WHERE id = $1 FOR UPDATE`, shopID).Scan(&pinned)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, err
+		}
+		return false, fmt.Errorf("failed to lock shop: %w", err)
+	}
+	return pinned, nil
+}
+
+// createSelectorVersion сохраняет версию в транзакции и активирует её, если магазин не закреплён
+// или изменение ручное. Совпадающие с активной версией селекторы новую версию не создают:
+// version заполняется активной версией и возвращается false
+func createSelectorVersion(ctx context.Context, tx pgx.Tx, version *selectorversions.Version) (bool, error) {
+	selectorsJSON, err := json.Marshal(version.Selectors)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal selectors: %w", err)
+	}
+
+	pinned, err := lockShopSelectors(ctx, tx, version.ShopID)
+	if err != nil {
+		return false, err
+	}
+
+	var active int
+	err = tx.QueryRow(ctx, `
+		SELECT v.version
+		FROM shops s
+		JOIN shop_selector_versions v ON v.shop_id = s.id AND v.version = s.active_selector_version
+		WHERE s.id = $1 AND v.selectors = $2::jsonb
+	`, version.ShopID, selectorsJSON).Scan(&active)
+	if err == nil {
+		version.Version = active
+		version.Active = true
+		return false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to compare with active selectors: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shop_selector_versions (shop_id, version, selectors, source, author, note)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, NULLIF($4, ''), NULLIF($5, '')
+		FROM shop_selector_versions
+		WHERE shop_id = $1
+		RETURNING version, created_at
+	`, version.ShopID, selectorsJSON, string(version.Source), version.Author, version.Note).Scan(&version.Version, &version.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert selector version: %w", err)
+	}
+
+	version.Active = version.Source == selectorversions.SourceManual || !pinned
+	if !version.Active {
+		return true, nil
+	}
+	if err := activateSelectorVersion(ctx, tx, version.ShopID, version.Version); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// activateSelectorVersion делает версию активной и копирует её селекторы в shops.selectors.
+// Окно детектора деградации начинается заново: промахи прежних селекторов не относятся к новым
+func activateSelectorVersion(ctx context.Context, tx pgx.Tx, shopID string, version int) error {
+	var selectorsJSON []byte
+	err := tx.QueryRow(ctx, `
+		UPDATE shop_selector_versions
+		SET activated_at = NOW()
+		WHERE shop_id = $1 AND version = $2
+		RETURNING selectors
+	`, shopID, version).Scan(&selectorsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to activate selector version: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shops
+		SET selectors = $3,
+		    active_selector_version = $2,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, shopID, version, selectorsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update shop selectors: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop_selector_health (shop_id, status, reconfigured_at, updated_at)
+		VALUES ($1, 'healthy', NOW(), NOW())
+		ON CONFLICT (shop_id) DO UPDATE SET
+			status = 'healthy',
+			degraded_fields = '{}',
+			reason = NULL,
+			last_error = NULL,
+			reconfig_attempts = 0,
+			reconfigured_at = NOW(),
+			updated_at = NOW()
+	`, shopID)
+	if err != nil {
+		return fmt.Errorf("failed to reset selector health: %w", err)
+	}
+	return nil
+}
+
+// previousSelectorVersionQuery последняя активировавшаяся версия магазина, кроме текущей
+const previousSelectorVersionQuery = `
+	SELECT v.version
+	FROM shop_selector_versions v
+	JOIN shops s ON s.id = v.shop_id
+	WHERE v.shop_id = $1
+	  AND v.activated_at IS NOT NULL
+	  AND v.version IS DISTINCT FROM s.active_selector_version
+	ORDER BY v.activated_at DESC, v.version DESC
+	LIMIT 1
+`
+
+// scanSelectorVersion сканирует строку версии селекторов
+func scanSelectorVersion(row pgx.Row) (*selectorversions.Version, error) {
+	var version selectorversions.Version
+	var source string
+	var selectorsJSON []byte
+
+	err := row.Scan(
+		&version.ShopID,
+		&version.Version,
+		&selectorsJSON,
+		&source,
+		&version.Author,
+		&version.Note,
+		&version.Active,
+		&version.CreatedAt,
+		&version.ActivatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan selector version: %w", err)
+	}
+
+	version.Source = selectorversions.Source(source)
+	if err := json.Unmarshal(selectorsJSON, &version.Selectors); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal selectors: %w", err)
+	}
+	return &version, nil
+}
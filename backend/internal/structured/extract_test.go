@@ -0,0 +1,258 @@
+package structured
+
+import (
+	"testing"
+)
+
+func extract(t *testing.T, html string) *Product {
+	t.Helper()
+	p, err := Extract([]byte(html), "https://shop.example/catalog/phone-1")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	return p
+}
+
+func TestExtract_JSONLDGraphAggregateOffer(t *testing.T) {
+	p := extract(t, `<html><head>
+<script type="application/ld+json">
+{"@context":"https://schema.org","@graph":[
+  {"@type":"WebSite","name":"Shop"},
+  {"@type":"BreadcrumbList","itemListElement":[]},
+  {"@type":"Product","name":"Samsung Galaxy A55 &amp; case","sku":"SM-A556",
+   "gtin13":"4006381333931","mpn":"A556B","brand":{"@type":"Brand","name":"Samsung"},
+   "category":["Telefoni","Mobilni telefoni"],
+   "image":[{"@type":"ImageObject","url":"/img/a55.jpg"},"https://cdn.example/a55-2.jpg"],
+   "offers":{"@type":"AggregateOffer","lowPrice":"42999","highPrice":"45999","priceCurrency":"rsd",
+     "offers":[{"@type":"Offer","price":45999,"availability":"https://schema.org/OutOfStock"},
+               {"@type":"Offer","price":42999,"availability":"https://schema.org/InStock"}]}}
+]}
+</script></head><body></body></html>`)
+
+	if p == nil {
+		t.Fatal("expected product, got nil")
+	}
+	if p.Name != "Samsung Galaxy A55 & case" {
+		t.Errorf("unexpected name %q", p.Name)
+	}
+	if p.Brand != "Samsung" || p.SKU != "SM-A556" || p.MPN != "A556B" || p.GTIN != "4006381333931" {
+		t.Errorf("unexpected identity fields: %+v", p)
+	}
+	if p.Category != "Telefoni > Mobilni telefoni" {
+		t.Errorf("unexpected category %q", p.Category)
+	}
+	if p.Price != 42999 || p.LowPrice != 42999 || p.HighPrice != 45999 || p.Currency != "RSD" {
+		t.Errorf("unexpected price: price=%v low=%v high=%v currency=%q", p.Price, p.LowPrice, p.HighPrice, p.Currency)
+	}
+	if p.Availability != AvailabilityInStock {
+		t.Errorf("expected in_stock, got %q", p.Availability)
+	}
+	if len(p.Images) != 2 || p.Images[0] != "https://shop.example/img/a55.jpg" {
+		t.Errorf("unexpected images %v", p.Images)
+	}
+	if p.Origin[FieldPrice] != SourceJSONLD {
+		t.Errorf("expected price from json-ld, got %q", p.Origin[FieldPrice])
+	}
+}
+
+func TestExtract_JSONLDOffersArrayAndPriceSpecification(t *testing.T) {
+	p := extract(t, `<script type="application/ld+json">[
+  {"@type":"Organization","name":"Shop"},
+  {"@type":["Product","Thing"],"name":"Laptop","brand":"Dell",
+   "offers":[{"@type":"Offer","priceSpecification":{"price":"99999.90","priceCurrency":"RSD"},
+              "availability":"PreOrder"}]}
+]</script>`)
+
+	if p == nil || p.Name != "Laptop" || p.Brand != "Dell" {
+		t.Fatalf("unexpected product %+v", p)
+	}
+	if p.Price != 99999.90 || p.Currency != "RSD" {
+		t.Errorf("unexpected price %v %q", p.Price, p.Currency)
+	}
+	if p.Availability != AvailabilityPreOrder {
+		t.Errorf("expected pre_order, got %q", p.Availability)
+	}
+}
+
+func TestExtract_JSONLDInvalidIgnored(t *testing.T) {
+	p := extract(t, `<script type="application/ld+json">{"@type":"Product", broken</script>
+<title>Page</title>`)
+	if p != nil {
+		t.Fatalf("expected nil product, got %+v", p)
+	}
+}
+
+func TestExtract_Microdata(t *testing.T) {
+	p := extract(t, `<div itemscope itemtype="https://schema.org/Product">
+  <h1 itemprop="name">  Bosch   bušilica </h1>
+  <img itemprop="image" src="/img/drill.jpg">
+  <span itemprop="brand" itemscope itemtype="https://schema.org/Brand"><span itemprop="name">Bosch</span></span>
+  <meta itemprop="gtin13" content="5901234123457">
+  <div itemprop="review" itemscope itemtype="https://schema.org/Review">
+    <span itemprop="name">Odlična</span>
+  </div>
+  <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+    <span itemprop="price">12.999,00 RSD</span>
+    <meta itemprop="priceCurrency" content="RSD">
+    <link itemprop="availability" href="https://schema.org/OutOfStock">
+  </div>
+</div>`)
+
+	if p == nil {
+		t.Fatal("expected product, got nil")
+	}
+	if p.Name != "Bosch bušilica" || p.Brand != "Bosch" || p.GTIN != "5901234123457" {
+		t.Errorf("unexpected fields: %+v", p)
+	}
+	if p.Price != 12999 || p.Currency != "RSD" {
+		t.Errorf("unexpected price %v %q", p.Price, p.Currency)
+	}
+	if p.Availability != AvailabilityOutOfStock {
+		t.Errorf("expected out_of_stock, got %q", p.Availability)
+	}
+	if len(p.Images) != 1 || p.Images[0] != "https://shop.example/img/drill.jpg" {
+		t.Errorf("unexpected images %v", p.Images)
+	}
+	if p.Origin[FieldName] != SourceMicrodata {
+		t.Errorf("expected name from microdata, got %q", p.Origin[FieldName])
+	}
+}
+
+func TestExtract_MicrodataThousandsContent(t *testing.T) {
+	p := extract(t, `<div itemscope itemtype="https://schema.org/Product">
+  <h1 itemprop="name">Usisivač Gorenje</h1>
+  <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+    <meta itemprop="price" content="12.999">
+    <meta itemprop="priceCurrency" content="RSD">
+  </div>
+</div>`)
+
+	if p == nil || p.Price != 12999 {
+		t.Fatalf("expected content=\"12.999\" to parse as 12999, got %+v", p)
+	}
+}
+
+func TestExtract_RDFa(t *testing.T) {
+	p := extract(t, `<div vocab="https://schema.org/" typeof="Product">
+  <span property="name">Frižider Gorenje</span>
+  <span property="sku">RK6192</span>
+  <div property="offers" typeof="Offer">
+    <span property="price" content="54990">54.990 din</span>
+    <span property="priceCurrency" content="RSD"></span>
+  </div>
+</div>`)
+
+	if p == nil || p.Name != "Frižider Gorenje" || p.SKU != "RK6192" {
+		t.Fatalf("unexpected product %+v", p)
+	}
+	if p.Price != 54990 || p.Currency != "RSD" {
+		t.Errorf("unexpected price %v %q", p.Price, p.Currency)
+	}
+	if p.Origin[FieldPrice] != SourceRDFa {
+		t.Errorf("expected price from rdfa, got %q", p.Origin[FieldPrice])
+	}
+}
+
+func TestExtract_OpenGraph(t *testing.T) {
+	p := extract(t, `<head>
+<meta property="og:type" content="product">
+<meta property="og:title" content="TV LG 55">
+<meta property="og:image" content="https://cdn.example/tv.jpg">
+<meta property="og:price:amount" content="64999">
+<meta property="og:price:currency" content="RSD">
+<meta property="product:availability" content="in stock">
+</head>`)
+
+	if p == nil || p.Name != "TV LG 55" || p.Price != 64999 || p.Currency != "RSD" {
+		t.Fatalf("unexpected product %+v", p)
+	}
+	if p.Availability != AvailabilityInStock {
+		t.Errorf("expected in_stock, got %q", p.Availability)
+	}
+	if p.Origin[FieldName] != SourceOpenGraph {
+		t.Errorf("expected name from opengraph, got %q", p.Origin[FieldName])
+	}
+}
+
+func TestExtract_OpenGraphWithoutProductIgnored(t *testing.T) {
+	p := extract(t, `<meta property="og:type" content="website"><meta property="og:title" content="Home">`)
+	if p != nil {
+		t.Fatalf("expected nil product, got %+v", p)
+	}
+}
+
+func TestExtract_SourcePriority(t *testing.T) {
+	p := extract(t, `<head>
+<meta property="og:type" content="product">
+<meta property="og:title" content="OG title | Shop">
+<meta property="og:description" content="OG description">
+<meta property="og:price:amount" content="100">
+<script type="application/ld+json">{"@type":"Product","name":"JSON-LD name","offers":{"price":"90","priceCurrency":"EUR"}}</script>
+</head>`)
+
+	if p.Name != "JSON-LD name" || p.Price != 90 || p.Currency != "EUR" {
+		t.Errorf("expected json-ld values to win, got %+v", p)
+	}
+	if p.Description != "OG description" || p.Origin[FieldDescription] != SourceOpenGraph {
+		t.Errorf("expected description filled from opengraph, got %q (%q)", p.Description, p.Origin[FieldDescription])
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := map[string]float64{
+		"1299.00":         1299,
+		"12.999,00 RSD":   12999,
+		"12.999 din":      12999,
+		"12.999":          12999,
+		"1.299":           1299,
+		"1299":            1299,
+		"1299.5":          1299.5,
+		"1,299.50":        1299.5,
+		"1 299 RSD":       1299,
+		"12,5 EUR":        12.5,
+		"":                0,
+		"po dogovoru":     0,
+		"-5":              0,
+		"999999999999.00": 0,
+	}
+	for raw, want := range tests {
+		if got := parsePrice(raw); got != want {
+			t.Errorf("parsePrice(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestJSONPrice(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  float64
+	}{
+		{12.999, 12.999},
+		{map[string]interface{}{"@value": 12.999}, 12.999},
+		{[]interface{}{"", 1299.5}, 1299.5},
+		{"12.999", 12999},
+		{"1299.00", 1299},
+	}
+	for _, tt := range tests {
+		if got := jsonPrice(tt.value); got != tt.want {
+			t.Errorf("jsonPrice(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeGTIN(t *testing.T) {
+	tests := map[string]string{
+		"4006381333931":    "4006381333931",
+		"400 6381 333931":  "4006381333931",
+		"4006381333932":    "", // неверная контрольная цифра
+		"96385074":         "96385074",
+		"012345678905":     "012345678905",
+		"12345":            "",
+		"ABC4006381333931": "",
+	}
+	for raw, want := range tests {
+		if got := NormalizeGTIN(raw); got != want {
+			t.Errorf("NormalizeGTIN(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package structured
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// vocabulary описывает атрибуты разметки сущностей в HTML.
+// Microdata и RDFa устроены одинаково: элемент-сущность с типом и свойства внутри него.
+type vocabulary struct {
+	scope    string // селектор элемента-сущности
+	typeAttr string // атрибут с типом сущности
+	propAttr string // атрибут с именем свойства
+}
+
+var (
+	microdata = vocabulary{scope: "[itemscope]", typeAttr: "itemtype", propAttr: "itemprop"}
+	rdfa      = vocabulary{scope: "[typeof]", typeAttr: "typeof", propAttr: "property"}
+)
+
+// extractItems ищет сущность-товар в разметке Microdata или RDFa
+func extractItems(doc *goquery.Document, v vocabulary) *Product {
+	var best *Product
+	doc.Find(v.scope).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if !v.hasType(s, productTypes) {
+			return true
+		}
+		p := v.product(s)
+		if best == nil || (!best.HasPrice() && p.HasPrice()) {
+			best = p
+		}
+		return !best.HasPrice()
+	})
+	return best
+}
+
+func (v vocabulary) hasType(s *goquery.Selection, types map[string]bool) bool {
+	for _, t := range strings.Fields(s.AttrOr(v.typeAttr, "")) {
+		if types[typeName(t)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (v vocabulary) isScope(s *goquery.Selection) bool {
+	return s.Is(v.scope)
+}
+
+// props собирает свойства, принадлежащие именно этой сущности (без вложенных)
+func (v vocabulary) props(scope *goquery.Selection) map[string][]*goquery.Selection {
+	props := make(map[string][]*goquery.Selection)
+	root := scope.Get(0)
+	scope.Find("[" + v.propAttr + "]").Each(func(_ int, el *goquery.Selection) {
+		owner := el.ParentsFiltered(v.scope).First()
+		if owner.Length() == 0 || owner.Get(0) != root {
+			return
+		}
+		for _, name := range strings.Fields(el.AttrOr(v.propAttr, "")) {
+			name = typeName(name)
+			props[name] = append(props[name], el)
+		}
+	})
+	return props
+}
+
+func (v vocabulary) product(scope *goquery.Selection) *Product {
+	props := v.props(scope)
+	p := &Product{
+		Name:        v.text(props["name"]),
+		Description: v.text(props["description"]),
+		SKU:         v.text(props["sku"]),
+		MPN:         v.text(props["mpn"]),
+		Brand:       v.name(props["brand"]),
+		Category:    v.text(props["category"]),
+	}
+	if p.Brand == "" {
+		p.Brand = v.name(props["manufacturer"])
+	}
+	for _, key := range gtinKeys {
+		if gtin := NormalizeGTIN(v.text(props[key])); gtin != "" {
+			p.GTIN = gtin
+			break
+		}
+	}
+	for _, el := range props["image"] {
+		if v.isScope(el) {
+			nested := v.props(el)
+			if link := v.link(nested["url"]); link != "" {
+				p.Images = append(p.Images, link)
+			} else if link := v.link(nested["contentUrl"]); link != "" {
+				p.Images = append(p.Images, link)
+			}
+			continue
+		}
+		if link := linkValue(el); link != "" {
+			p.Images = append(p.Images, link)
+		}
+	}
+
+	// Цена в самом товаре (встречается на витринах без Offer) и во вложенных offers
+	var summary offerSummary
+	v.addOffer(&summary, props)
+	summary.apply(p)
+	return p
+}
+
+// addOffer учитывает Offer или AggregateOffer вместе с вложенными предложениями
+func (v vocabulary) addOffer(summary *offerSummary, props map[string][]*goquery.Selection) {
+	currency := v.text(props["priceCurrency"])
+	summary.addPrice(parsePrice(v.text(props["price"])), currency)
+	summary.addPrice(parsePrice(v.text(props["lowPrice"])), currency)
+	summary.addPrice(parsePrice(v.text(props["highPrice"])), currency)
+	summary.addAvailability(parseAvailability(v.link(props["availability"])))
+	for _, el := range props["priceSpecification"] {
+		if v.isScope(el) {
+			spec := v.props(el)
+			specCurrency := v.text(spec["priceCurrency"])
+			if specCurrency == "" {
+				specCurrency = currency
+			}
+			summary.addPrice(parsePrice(v.text(spec["price"])), specCurrency)
+		}
+	}
+	for _, el := range props["offers"] {
+		if v.isScope(el) {
+			v.addOffer(summary, v.props(el))
+		}
+	}
+}
+
+// text первое непустое текстовое значение свойства
+func (v vocabulary) text(elements []*goquery.Selection) string {
+	for _, el := range elements {
+		if value := textValue(el); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// link первое непустое значение-ссылка (availability, image)
+func (v vocabulary) link(elements []*goquery.Selection) string {
+	for _, el := range elements {
+		if value := linkValue(el); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// name значение Brand: вложенная сущность с name или текст
+func (v vocabulary) name(elements []*goquery.Selection) string {
+	for _, el := range elements {
+		if v.isScope(el) {
+			if name := v.text(v.props(el)["name"]); name != "" {
+				return name
+			}
+			continue
+		}
+		if value := textValue(el); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// textValue значение свойства: content (meta и машинные значения) или текст элемента
+func textValue(el *goquery.Selection) string {
+	if content, ok := el.Attr("content"); ok {
+		return cleanText(content)
+	}
+	switch goquery.NodeName(el) {
+	case "meta":
+		return ""
+	case "data", "meter":
+		if value, ok := el.Attr("value"); ok {
+			return cleanText(value)
+		}
+	case "time":
+		if value, ok := el.Attr("datetime"); ok {
+			return cleanText(value)
+		}
+	}
+	return cleanText(el.Text())
+}
+
+// linkValue значение свойства-ссылки: href, src, resource или content
+func linkValue(el *goquery.Selection) string {
+	for _, attr := range []string{"content", "href", "src", "data-src", "resource"} {
+		if value, ok := el.Attr(attr); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return cleanText(el.Text())
+}
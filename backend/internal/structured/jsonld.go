@@ -0,0 +1,253 @@
+package structured
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxJSONLDDepth ограничение глубины обхода JSON-LD
+const maxJSONLDDepth = 10
+
+// gtinKeys свойства schema.org с GTIN в порядке предпочтения
+var gtinKeys = []string{"gtin13", "gtin", "gtin14", "gtin12", "gtin8"}
+
+// extractJSONLD ищет Product во всех блоках JSON-LD страницы, включая @graph
+// и вложенные сущности (WebPage.mainEntity и т.п.)
+func extractJSONLD(doc *goquery.Document) *Product {
+	var best *Product
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(cleanJSONLD(s.Text())), &data); err != nil {
+			return
+		}
+
+		var nodes []map[string]interface{}
+		collectProductNodes(data, &nodes, 0)
+		for _, node := range nodes {
+			p := productFromJSONLD(node)
+			if best == nil || (!best.HasPrice() && p.HasPrice()) {
+				best = p
+			}
+		}
+	})
+	return best
+}
+
+// cleanJSONLD снимает обёртки, которые встречаются внутри script
+func cleanJSONLD(text string) string {
+	text = strings.TrimSpace(text)
+	for _, wrapper := range []string{"<!--", "-->", "//<![CDATA[", "//]]>", "<![CDATA[", "]]>"} {
+		text = strings.ReplaceAll(text, wrapper, "")
+	}
+	return strings.TrimSpace(text)
+}
+
+// collectProductNodes рекурсивно собирает объекты с @type товара.
+// Внутрь самого товара не спускается (offers.itemOffered, isSimilarTo).
+func collectProductNodes(value interface{}, out *[]map[string]interface{}, depth int) {
+	if depth > maxJSONLDDepth {
+		return
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			collectProductNodes(item, out, depth+1)
+		}
+	case map[string]interface{}:
+		if hasJSONLDType(v, productTypes) {
+			*out = append(*out, v)
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectProductNodes(v[key], out, depth+1)
+		}
+	}
+}
+
+func hasJSONLDType(node map[string]interface{}, types map[string]bool) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return types[typeName(t)]
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && types[typeName(s)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func productFromJSONLD(node map[string]interface{}) *Product {
+	p := &Product{
+		Name:        jsonText(node["name"]),
+		Description: jsonText(node["description"]),
+		SKU:         jsonText(node["sku"]),
+		MPN:         jsonText(node["mpn"]),
+		Brand:       jsonName(node["brand"]),
+		Category:    jsonCategory(node["category"]),
+		Images:      jsonImages(node["image"]),
+	}
+	if p.Brand == "" {
+		p.Brand = jsonName(node["manufacturer"])
+	}
+	for _, key := range gtinKeys {
+		if gtin := NormalizeGTIN(jsonText(node[key])); gtin != "" {
+			p.GTIN = gtin
+			break
+		}
+	}
+
+	var summary offerSummary
+	addJSONLDOffers(&summary, node["offers"], 0)
+	summary.apply(p)
+	return p
+}
+
+// addJSONLDOffers учитывает Offer, массив предложений и AggregateOffer
+// (lowPrice/highPrice и вложенные offers)
+func addJSONLDOffers(summary *offerSummary, value interface{}, depth int) {
+	if depth > maxJSONLDDepth {
+		return
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			addJSONLDOffers(summary, item, depth+1)
+		}
+	case map[string]interface{}:
+		currency := jsonText(v["priceCurrency"])
+		price := jsonPrice(v["price"])
+		if price == 0 {
+			if spec, ok := firstJSONObject(v["priceSpecification"]); ok {
+				price = jsonPrice(spec["price"])
+				if currency == "" {
+					currency = jsonText(spec["priceCurrency"])
+				}
+			}
+		}
+		summary.addPrice(price, currency)
+		summary.addPrice(jsonPrice(v["lowPrice"]), currency)
+		summary.addPrice(jsonPrice(v["highPrice"]), currency)
+		summary.addAvailability(parseAvailability(jsonText(v["availability"])))
+		addJSONLDOffers(summary, v["offers"], depth+1)
+	}
+}
+
+// jsonText приводит значение JSON-LD к строке: строка, число, {"@value"}, первый элемент массива
+func jsonText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return cleanText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		if inner, ok := v["@value"]; ok {
+			return jsonText(inner)
+		}
+		return jsonText(v["@id"])
+	case []interface{}:
+		for _, item := range v {
+			if text := jsonText(item); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// jsonName значение Brand/Organization: строка или объект с name
+func jsonName(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return jsonText(v["name"])
+	case []interface{}:
+		for _, item := range v {
+			if name := jsonName(item); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+	return jsonText(value)
+}
+
+// jsonCategory строка, путь из массива ("Телефоны > Смартфоны") или объект с name
+func jsonCategory(value interface{}) string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return jsonName(value)
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		if name := jsonName(item); name != "" {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// jsonImages строка, массив строк или ImageObject (url/contentUrl)
+func jsonImages(value interface{}) []string {
+	var images []string
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			images = append(images, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			images = append(images, jsonImages(item)...)
+		}
+	case map[string]interface{}:
+		if link := jsonText(v["url"]); link != "" {
+			images = append(images, link)
+		} else if link := jsonText(v["contentUrl"]); link != "" {
+			images = append(images, link)
+		}
+	}
+	return images
+}
+
+// jsonPrice число JSON-LD берётся как есть (в т.ч. {"@value": 12.999}), строка разбирается как текст витрины
+func jsonPrice(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return validPrice(v)
+	case map[string]interface{}:
+		if inner, ok := v["@value"]; ok {
+			return jsonPrice(inner)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if price := jsonPrice(item); price > 0 {
+				return price
+			}
+		}
+		return 0
+	}
+	return parsePrice(jsonText(value))
+}
+
+func firstJSONObject(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				return m, true
+			}
+		}
+	}
+	return nil, false
+}
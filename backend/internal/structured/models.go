@@ -0,0 +1,62 @@
+package structured
+
+// Source источник структурированных данных на странице
+type Source string
+
+const (
+	SourceJSONLD    Source = "json-ld"
+	SourceMicrodata Source = "microdata"
+	SourceRDFa      Source = "rdfa"
+	SourceOpenGraph Source = "opengraph"
+)
+
+// Availability нормализованное наличие товара (schema.org / OpenGraph)
+type Availability string
+
+const (
+	AvailabilityUnknown    Availability = ""
+	AvailabilityInStock    Availability = "in_stock"
+	AvailabilityOutOfStock Availability = "out_of_stock"
+	AvailabilityPreOrder   Availability = "pre_order"
+)
+
+// Поля Product, для которых запоминается источник (Product.Origin)
+const (
+	FieldName         = "name"
+	FieldDescription  = "description"
+	FieldBrand        = "brand"
+	FieldCategory     = "category"
+	FieldSKU          = "sku"
+	FieldGTIN         = "gtin"
+	FieldMPN          = "mpn"
+	FieldImages       = "images"
+	FieldPrice        = "price"
+	FieldAvailability = "availability"
+)
+
+// Product товар, извлечённый из разметки страницы
+type Product struct {
+	Name        string
+	Description string
+	Brand       string
+	Category    string
+	SKU         string
+	GTIN        string // gtin13/gtin14/gtin12/gtin8 с проверенной контрольной цифрой
+	MPN         string
+	Images      []string
+
+	Price     float64 // цена предложения; при нескольких предложениях - минимальная
+	LowPrice  float64 // диапазон цен по всем предложениям (AggregateOffer.lowPrice)
+	HighPrice float64 // AggregateOffer.highPrice
+	Currency  string
+
+	Availability Availability
+
+	// Origin источник каждого заполненного поля (ключи - Field*)
+	Origin map[string]Source
+}
+
+// HasPrice есть ли у товара цена
+func (p *Product) HasPrice() bool {
+	return p != nil && p.Price > 0
+}
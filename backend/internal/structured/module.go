@@ -0,0 +1,105 @@
+// Package structured извлекает данные о товаре из семантической разметки страницы:
+// JSON-LD, Microdata, RDFa (schema.org) и OpenGraph.
+package structured
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Extract извлекает товар из HTML страницы.
+// Возвращает nil без ошибки, если разметки товара на странице нет.
+func Extract(html []byte, pageURL string) (*Product, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+	return ExtractDocument(doc, pageURL), nil
+}
+
+// ExtractDocument извлекает товар из уже разобранного документа.
+// Источники применяются по приоритету JSON-LD > Microdata > RDFa > OpenGraph:
+// каждый следующий только дополняет незаполненные поля.
+func ExtractDocument(doc *goquery.Document, pageURL string) *Product {
+	result := &Product{Origin: make(map[string]Source)}
+	result.merge(extractJSONLD(doc), SourceJSONLD)
+	result.merge(extractItems(doc, microdata), SourceMicrodata)
+	result.merge(extractItems(doc, rdfa), SourceRDFa)
+	result.merge(extractOpenGraph(doc), SourceOpenGraph)
+
+	if len(result.Origin) == 0 {
+		return nil
+	}
+
+	base, err := url.Parse(pageURL)
+	if err == nil {
+		result.Images = resolveURLs(base, result.Images)
+	}
+	return result
+}
+
+// merge дополняет пустые поля p значениями из other
+func (p *Product) merge(other *Product, source Source) {
+	if other == nil {
+		return
+	}
+
+	mergeString(p, &p.Name, other.Name, FieldName, source)
+	mergeString(p, &p.Description, other.Description, FieldDescription, source)
+	mergeString(p, &p.Brand, other.Brand, FieldBrand, source)
+	mergeString(p, &p.Category, other.Category, FieldCategory, source)
+	mergeString(p, &p.SKU, other.SKU, FieldSKU, source)
+	mergeString(p, &p.GTIN, other.GTIN, FieldGTIN, source)
+	mergeString(p, &p.MPN, other.MPN, FieldMPN, source)
+
+	if len(p.Images) == 0 && len(other.Images) > 0 {
+		p.Images = other.Images
+		p.Origin[FieldImages] = source
+	}
+
+	// Цена, диапазон и валюта берутся из одного источника
+	if p.Price == 0 && other.Price > 0 {
+		p.Price = other.Price
+		p.LowPrice = other.LowPrice
+		p.HighPrice = other.HighPrice
+		if other.Currency != "" {
+			p.Currency = other.Currency
+		}
+		p.Origin[FieldPrice] = source
+	} else if p.Price > 0 && p.Currency == "" {
+		p.Currency = other.Currency
+	}
+
+	if p.Availability == AvailabilityUnknown && other.Availability != AvailabilityUnknown {
+		p.Availability = other.Availability
+		p.Origin[FieldAvailability] = source
+	}
+}
+
+func mergeString(p *Product, dst *string, value, field string, source Source) {
+	if *dst == "" && value != "" {
+		*dst = value
+		p.Origin[field] = source
+	}
+}
+
+// resolveURLs делает ссылки абсолютными и убирает дубликаты
+func resolveURLs(base *url.URL, links []string) []string {
+	seen := make(map[string]bool, len(links))
+	result := make([]string, 0, len(links))
+	for _, link := range links {
+		ref, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		abs := base.ResolveReference(ref).String()
+		if !seen[abs] {
+			seen[abs] = true
+			result = append(result, abs)
+		}
+	}
+	return result
+}
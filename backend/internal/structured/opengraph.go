@@ -0,0 +1,67 @@
+package structured
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ogProductTypes значения og:type страницы товара
+var ogProductTypes = map[string]bool{
+	"product":       true,
+	"og:product":    true,
+	"product.item":  true,
+	"product.group": true,
+}
+
+// extractOpenGraph читает meta-теги og:* и product:* (в том числе og:price:amount).
+// og:title и og:description есть почти на любой странице, поэтому товар
+// возвращается только для og:type=product или при наличии цены.
+func extractOpenGraph(doc *goquery.Document) *Product {
+	meta := make(map[string][]string)
+	doc.Find("meta[property], meta[name]").Each(func(_ int, s *goquery.Selection) {
+		key := s.AttrOr("property", "")
+		if key == "" {
+			key = s.AttrOr("name", "")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if content := strings.TrimSpace(s.AttrOr("content", "")); key != "" && content != "" {
+			meta[key] = append(meta[key], content)
+		}
+	})
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if values := meta[key]; len(values) > 0 {
+				return cleanText(values[0])
+			}
+		}
+		return ""
+	}
+
+	var summary offerSummary
+	summary.addPrice(
+		parsePrice(first("product:price:amount", "og:price:amount", "product:sale_price:amount")),
+		first("product:price:currency", "og:price:currency", "product:sale_price:currency"),
+	)
+	summary.addAvailability(parseAvailability(first("product:availability", "og:availability")))
+
+	if !ogProductTypes[strings.ToLower(first("og:type"))] && summary.low == 0 {
+		return nil
+	}
+
+	p := &Product{
+		Name:        first("og:title"),
+		Description: first("og:description"),
+		Brand:       first("product:brand", "og:brand"),
+		Category:    first("product:category"),
+		SKU:         first("product:retailer_item_id"),
+		GTIN:        NormalizeGTIN(first("product:gtin", "product:ean", "product:upc")),
+		MPN:         first("product:mfr_part_no"),
+	}
+	for _, key := range []string{"og:image", "og:image:secure_url", "og:image:url"} {
+		p.Images = append(p.Images, meta[key]...)
+	}
+	summary.apply(p)
+	return p
+}
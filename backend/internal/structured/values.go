@@ -0,0 +1,207 @@
+package structured
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxPrice верхняя граница правдоподобной цены (как и в селекторном парсере)
+const maxPrice = 10000000
+
+// machinePriceRegex цена в машинном формате без разночтений: целое или ровно два знака после точки.
+// "12.999" сюда не попадает - на сербских витринах это 12 999, а не 12,999
+var machinePriceRegex = regexp.MustCompile(`^[-+]?\d+(?:\.\d{2})?$`)
+
+// productTypes типы schema.org, которые считаются товаром
+var productTypes = map[string]bool{
+	"Product":           true,
+	"IndividualProduct": true,
+	"ProductModel":      true,
+	"SomeProducts":      true,
+}
+
+// typeName убирает префикс словаря: "http://schema.org/Product", "schema:Product" -> "Product"
+func typeName(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.LastIndexAny(value, "/:#"); i >= 0 {
+		value = value[i+1:]
+	}
+	return value
+}
+
+// cleanText раскрывает HTML-сущности и схлопывает пробелы
+func cleanText(value string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(value)), " ")
+}
+
+// parsePrice разбирает цену из текста: однозначное машинное значение ("1299.00", "1299"),
+// иначе как текст витрины ("12.999,00 RSD", "12.999", "1 299 din").
+// Числа из JSON-LD разбирает jsonPrice без этой функции
+func parsePrice(raw string) float64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if machinePriceRegex.MatchString(raw) {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0
+		}
+		return validPrice(value)
+	}
+
+	var digits strings.Builder
+	for _, r := range raw {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	if number == "" {
+		return 0
+	}
+
+	lastDot := strings.LastIndex(number, ".")
+	lastComma := strings.LastIndex(number, ",")
+	decimal := byte(0)
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Оба разделителя: десятичный - последний
+		if lastDot > lastComma {
+			decimal = '.'
+		} else {
+			decimal = ','
+		}
+	case lastDot >= 0 || lastComma >= 0:
+		sep := byte('.')
+		idx := lastDot
+		if lastComma >= 0 {
+			sep, idx = ',', lastComma
+		}
+		// Один разделитель: "12.999" - тысячи, "12,5" - дробная часть
+		if strings.Count(number, string(sep)) == 1 && len(number)-idx-1 != 3 {
+			decimal = sep
+		}
+	}
+
+	intPart, fracPart := number, ""
+	if decimal != 0 {
+		idx := strings.LastIndexByte(number, decimal)
+		intPart, fracPart = number[:idx], number[idx+1:]
+	}
+	intPart = strings.NewReplacer(".", "", ",", "").Replace(intPart)
+	normalized := intPart
+	if fracPart != "" {
+		normalized += "." + fracPart
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0
+	}
+	return validPrice(value)
+}
+
+func validPrice(value float64) float64 {
+	if value <= 0 || value >= maxPrice {
+		return 0
+	}
+	return value
+}
+
+// parseAvailability нормализует schema.org ItemAvailability и значения OpenGraph
+func parseAvailability(raw string) Availability {
+	value := strings.ToLower(typeName(raw))
+	value = strings.NewReplacer(" ", "", "_", "", "-", "").Replace(value)
+	switch value {
+	case "instock", "limitedavailability", "instoreonly", "onlineonly", "available":
+		return AvailabilityInStock
+	case "outofstock", "soldout", "discontinued", "oos", "unavailable":
+		return AvailabilityOutOfStock
+	case "preorder", "presale", "backorder", "availablefororder":
+		return AvailabilityPreOrder
+	}
+	return AvailabilityUnknown
+}
+
+// NormalizeGTIN оставляет в GTIN/EAN/UPC только цифры и проверяет длину
+// и контрольную цифру. Для некорректного кода возвращает пустую строку.
+func NormalizeGTIN(raw string) string {
+	var digits strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		} else if r != ' ' && r != '-' {
+			return ""
+		}
+	}
+	code := digits.String()
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return ""
+	}
+
+	// Контрольная цифра GS1: веса 3 и 1 справа налево, начиная с предпоследней
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	if (10-sum%10)%10 != int(code[len(code)-1]-'0') {
+		return ""
+	}
+	return code
+}
+
+// offerSummary сводит несколько предложений в цену, диапазон и наличие
+type offerSummary struct {
+	low          float64
+	high         float64
+	currency     string
+	availability Availability
+}
+
+func (s *offerSummary) addPrice(price float64, currency string) {
+	if price <= 0 {
+		return
+	}
+	if s.low == 0 || price < s.low {
+		s.low = price
+		if currency != "" {
+			s.currency = currency
+		}
+	}
+	if price > s.high {
+		s.high = price
+	}
+	if s.currency == "" {
+		s.currency = currency
+	}
+}
+
+// addAvailability: "в наличии" у любого предложения важнее остальных статусов
+func (s *offerSummary) addAvailability(a Availability) {
+	switch {
+	case a == AvailabilityUnknown:
+	case a == AvailabilityInStock, s.availability == AvailabilityUnknown:
+		s.availability = a
+	case a == AvailabilityPreOrder && s.availability == AvailabilityOutOfStock:
+		s.availability = a
+	}
+}
+
+func (s *offerSummary) apply(p *Product) {
+	if s.low > 0 {
+		p.Price = s.low
+		p.LowPrice = s.low
+		p.HighPrice = s.high
+		p.Currency = strings.ToUpper(s.currency)
+	}
+	p.Availability = s.availability
+}
@@ -0,0 +1,5 @@
+-- 0017_price_alerts.down.sql
+-- Откат миграции подписок на снижение цены
+
+DROP TABLE IF EXISTS price_alert_notifications;
+DROP TABLE IF EXISTS price_alert_subscriptions;
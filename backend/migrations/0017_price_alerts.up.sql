@@ -0,0 +1,72 @@
+-- 0017_price_alerts.up.sql
+-- Подписки пользователей на снижение цены товара
+
+------------------------------------------------------------
+-- 1. Таблица price_alert_subscriptions
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS price_alert_subscriptions (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_key           VARCHAR(64) NOT NULL,                -- SHA-256 (hex) токена владельца
+    product_id          UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    city_id             UUID NULL REFERENCES cities(id) ON DELETE CASCADE,
+    target_price        DECIMAL(10, 2) NOT NULL CHECK (target_price > 0),
+    currency            VARCHAR(10) NOT NULL DEFAULT 'RSD',
+    channel             VARCHAR(20) NOT NULL CHECK (channel IN ('webhook', 'email')),
+    target              VARCHAR(500) NOT NULL,               -- URL вебхука или email
+    is_active           BOOLEAN NOT NULL DEFAULT TRUE,
+    last_notified_price DECIMAL(10, 2) NULL,
+    last_notified_at    TIMESTAMPTZ NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_product_active
+    ON price_alert_subscriptions(product_id)
+    WHERE is_active = TRUE;
+CREATE INDEX IF NOT EXISTS idx_price_alerts_owner_key ON price_alert_subscriptions(owner_key);
+
+COMMENT ON COLUMN price_alert_subscriptions.owner_key IS 'SHA-256 (hex) токена владельца подписки';
+
+------------------------------------------------------------
+-- 2. Триггер обновления updated_at
+------------------------------------------------------------
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_trigger WHERE tgname = 'set_price_alert_subscriptions_updated_at'
+    ) THEN
+        CREATE TRIGGER set_price_alert_subscriptions_updated_at
+        BEFORE UPDATE ON price_alert_subscriptions
+        FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+    END IF;
+END;
+$$;
+
+------------------------------------------------------------
+-- 3. Таблица price_alert_notifications
+-- Сработавшие уведомления: сохраняются при обработке цены,
+-- доставляются воркером отдельно с повторами
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS price_alert_notifications (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES price_alert_subscriptions(id) ON DELETE CASCADE,
+    product_id      UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    shop_id         VARCHAR(255) NOT NULL,
+    shop_name       VARCHAR(255) NOT NULL DEFAULT '',
+    url             VARCHAR(500) NOT NULL DEFAULT '',
+    price           DECIMAL(10, 2) NOT NULL,
+    target_price    DECIMAL(10, 2) NOT NULL,
+    currency        VARCHAR(10) NOT NULL,
+    channel         VARCHAR(20) NOT NULL,
+    target          VARCHAR(500) NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    triggered_at    TIMESTAMPTZ NOT NULL,
+    sent_at         TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alert_notifications_pending
+    ON price_alert_notifications(next_attempt_at)
+    WHERE status = 'pending';
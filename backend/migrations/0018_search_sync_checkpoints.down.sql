@@ -0,0 +1,8 @@
+-- 0018_search_sync_checkpoints.down.sql
+-- Откат таблицы checkpoint'ов синхронизации поискового индекса
+
+DROP TRIGGER IF EXISTS log_products_deletion ON products;
+DROP FUNCTION IF EXISTS log_product_deletion();
+DROP TABLE IF EXISTS product_deletions;
+DROP INDEX IF EXISTS idx_product_prices_product_updated_at;
+DROP TABLE IF EXISTS search_sync_checkpoints;
@@ -0,0 +1,41 @@
+-- 0018_search_sync_checkpoints.up.sql
+-- Watermark инкрементальной синхронизации поискового индекса и журнал удалённых товаров
+
+CREATE TABLE IF NOT EXISTS search_sync_checkpoints (
+    name        VARCHAR(100) PRIMARY KEY,          -- например, 'products'
+    watermark   TIMESTAMPTZ NOT NULL,              -- NOW() БД на момент начала синхронизации
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Поиск товаров с изменёнными ценами (product_prices.updated_at > watermark)
+CREATE INDEX IF NOT EXISTS idx_product_prices_product_updated_at
+    ON product_prices (updated_at, product_id);
+
+-- Журнал удалённых товаров: синхронизация и пересборка индекса убирают из индекса товары,
+-- удалённые или слитые после watermark или во время заливки
+CREATE TABLE IF NOT EXISTS product_deletions (
+    product_id  UUID NOT NULL,
+    deleted_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_deletions_deleted_at ON product_deletions(deleted_at);
+
+CREATE OR REPLACE FUNCTION log_product_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO product_deletions (product_id) VALUES (OLD.id);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_trigger WHERE tgname = 'log_products_deletion'
+    ) THEN
+        CREATE TRIGGER log_products_deletion
+        AFTER DELETE ON products
+        FOR EACH ROW EXECUTE FUNCTION log_product_deletion();
+    END IF;
+END;
+$$;
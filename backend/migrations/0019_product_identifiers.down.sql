@@ -0,0 +1,5 @@
+-- 0019_product_identifiers.down.sql
+-- Откат идентификаторов товаров
+
+DROP TABLE IF EXISTS identifier_conflicts;
+DROP TABLE IF EXISTS product_identifiers;
@@ -0,0 +1,32 @@
+-- 0019_product_identifiers.up.sql
+-- Идентификаторы товаров (GTIN/EAN, MPN, SKU) для точного сопоставления
+
+------------------------------------------------------------
+-- 1. Таблица product_identifiers
+------------------------------------------------------------
+-- scope: '' для GTIN, нормализованный бренд для MPN, shop_id для SKU
+CREATE TABLE IF NOT EXISTS product_identifiers (
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    id_type     VARCHAR(10) NOT NULL CHECK (id_type IN ('gtin', 'mpn', 'sku')),
+    scope       VARCHAR(255) NOT NULL DEFAULT '',
+    value       VARCHAR(100) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, id_type, scope, value)
+);
+
+-- Точный поиск товара по идентификатору (быстрый путь matching)
+CREATE INDEX IF NOT EXISTS idx_product_identifiers_lookup
+    ON product_identifiers (id_type, scope, value);
+
+------------------------------------------------------------
+-- 2. Конфликты: один идентификатор у нескольких товаров
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS identifier_conflicts (
+    id_type      VARCHAR(10) NOT NULL,
+    scope        VARCHAR(255) NOT NULL DEFAULT '',
+    value        VARCHAR(100) NOT NULL,
+    product_ids  UUID[] NOT NULL,
+    detected_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id_type, scope, value)
+);
@@ -0,0 +1,21 @@
+-- 0020_match_review.down.sql
+-- Откат очереди проверки сопоставлений
+
+DROP TABLE IF EXISTS match_audit_log;
+
+DROP INDEX IF EXISTS idx_raw_products_product_id;
+ALTER TABLE raw_products DROP COLUMN IF EXISTS product_id;
+
+DROP INDEX IF EXISTS idx_product_matches_pending;
+
+DELETE FROM product_matches WHERE shop_id IS NOT NULL;
+DROP INDEX IF EXISTS uq_product_matches_pair;
+ALTER TABLE product_matches
+    ADD CONSTRAINT product_matches_product_id_matched_id_key UNIQUE (product_id, matched_id);
+
+ALTER TABLE product_matches
+    DROP COLUMN IF EXISTS shop_id,
+    DROP COLUMN IF EXISTS review_note,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,59 @@
+-- 0020_match_review.up.sql
+-- Очередь ручной проверки сопоставлений, ссылки сырых данных на товары и журнал решений
+
+------------------------------------------------------------
+-- 1. Статус проверки сопоставления
+------------------------------------------------------------
+-- Одобренное сопоставление сливает товары: строка удаляется вместе с дубликатом,
+-- решение остаётся в match_audit_log. Поэтому хранятся только pending и rejected.
+ALTER TABLE product_matches
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'rejected')),
+    ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS review_note TEXT;
+
+-- Предварительное сопоставление: предложение со средней уверенностью сразу привязывается
+-- к найденному товару (без дубликата), а на проверку попадает пара "товар - магазин".
+-- shop_id заполнен - предложение магазина временно привязано к matched_id (product_id = matched_id);
+-- одобрение закрепляет привязку, отклонение выделяет предложение в отдельный товар
+ALTER TABLE product_matches
+    ADD COLUMN IF NOT EXISTS shop_id VARCHAR(255) NULL REFERENCES shops(id) ON DELETE CASCADE;
+
+-- У одного товара может быть несколько предварительных привязок разных магазинов
+ALTER TABLE product_matches
+    DROP CONSTRAINT IF EXISTS product_matches_product_id_matched_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_product_matches_pair
+    ON product_matches (product_id, matched_id, COALESCE(shop_id, ''));
+
+CREATE INDEX IF NOT EXISTS idx_product_matches_pending
+    ON product_matches (similarity DESC, matched_at)
+    WHERE status = 'pending';
+
+------------------------------------------------------------
+-- 2. Связь сырого товара с каноническим
+------------------------------------------------------------
+ALTER TABLE raw_products
+    ADD COLUMN IF NOT EXISTS product_id UUID NULL REFERENCES products(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_raw_products_product_id ON raw_products(product_id);
+
+------------------------------------------------------------
+-- 3. Журнал решений модераторов
+------------------------------------------------------------
+-- Без внешних ключей: записи должны пережить удаление слитых товаров
+CREATE TABLE IF NOT EXISTS match_audit_log (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action              VARCHAR(20) NOT NULL CHECK (action IN ('approve', 'reject', 'merge', 'split')),
+    match_id            UUID,
+    product_id          UUID NOT NULL,
+    related_product_id  UUID,
+    actor               VARCHAR(255) NOT NULL,
+    note                TEXT,
+    details             JSONB,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_audit_log_product_id ON match_audit_log(product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_match_audit_log_related_product_id ON match_audit_log(related_product_id, created_at DESC);
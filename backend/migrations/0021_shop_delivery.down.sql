@@ -0,0 +1,6 @@
+-- 0021_shop_delivery.down.sql
+-- Откат условий доставки магазина
+
+ALTER TABLE shops
+    DROP COLUMN IF EXISTS free_delivery_from,
+    DROP COLUMN IF EXISTS delivery_fee;
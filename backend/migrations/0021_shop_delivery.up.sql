@@ -0,0 +1,9 @@
+-- 0021_shop_delivery.up.sql
+-- Условия доставки магазина для ранжирования предложений по итоговой цене
+
+ALTER TABLE shops
+    ADD COLUMN IF NOT EXISTS delivery_fee DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0),
+    ADD COLUMN IF NOT EXISTS free_delivery_from DECIMAL(10, 2) NULL CHECK (free_delivery_from >= 0);
+
+COMMENT ON COLUMN shops.delivery_fee IS 'Стоимость доставки в валюте цен магазина';
+COMMENT ON COLUMN shops.free_delivery_from IS 'Сумма заказа, начиная с которой доставка бесплатна (NULL - всегда платная)';
@@ -0,0 +1,5 @@
+-- 0022_price_history.down.sql
+-- Откат журнала изменений цен
+
+DROP TABLE IF EXISTS price_history_rollups;
+DROP TABLE IF EXISTS price_history;
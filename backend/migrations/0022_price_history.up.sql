@@ -0,0 +1,64 @@
+-- 0022_price_history.up.sql
+-- Журнал изменений цен (append-only) и агрегаты по дням/неделям для длинных периодов
+
+------------------------------------------------------------
+-- 1. Сырые точки: пишутся только при изменении цены, валюты или наличия
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS price_history (
+    id           BIGSERIAL PRIMARY KEY,
+    product_id   UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    shop_id      VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    price        DECIMAL(10, 2) NOT NULL,
+    currency     VARCHAR(10) NOT NULL DEFAULT 'RSD',
+    in_stock     BOOLEAN NOT NULL DEFAULT TRUE,
+    recorded_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Последняя точка магазина и график товара за период
+CREATE INDEX IF NOT EXISTS idx_price_history_product_shop_time
+    ON price_history (product_id, shop_id, recorded_at DESC);
+
+-- Очистка по сроку хранения
+CREATE INDEX IF NOT EXISTS idx_price_history_recorded_at ON price_history (recorded_at);
+
+------------------------------------------------------------
+-- 2. Агрегаты: обновляются при каждой записи точки и переживают очистку
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS price_history_rollups (
+    product_id    UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    shop_id       VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    granularity   VARCHAR(10) NOT NULL CHECK (granularity IN ('day', 'week')),
+    bucket_start  DATE NOT NULL,            -- начало дня/недели по UTC
+    min_price     DECIMAL(10, 2) NOT NULL,
+    max_price     DECIMAL(10, 2) NOT NULL,
+    sum_price     DECIMAL(14, 2) NOT NULL,
+    close_price   DECIMAL(10, 2) NOT NULL, -- последняя цена в интервале
+    currency      VARCHAR(10) NOT NULL DEFAULT 'RSD',
+    in_stock      BOOLEAN NOT NULL DEFAULT TRUE,
+    points        INTEGER NOT NULL DEFAULT 1,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, shop_id, granularity, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_rollups_chart
+    ON price_history_rollups (product_id, granularity, bucket_start);
+
+------------------------------------------------------------
+-- 3. Начальные точки из текущих цен
+------------------------------------------------------------
+INSERT INTO price_history (product_id, shop_id, price, currency, in_stock, recorded_at)
+SELECT product_id, shop_id, price, COALESCE(currency, 'RSD'), COALESCE(in_stock, TRUE), COALESCE(updated_at, NOW())
+FROM product_prices
+WHERE NOT EXISTS (SELECT 1 FROM price_history);
+
+INSERT INTO price_history_rollups (
+    product_id, shop_id, granularity, bucket_start,
+    min_price, max_price, sum_price, close_price, currency, in_stock, points, updated_at
+)
+SELECT ph.product_id, ph.shop_id, g.granularity,
+       CASE WHEN g.granularity = 'day' THEN (ph.recorded_at AT TIME ZONE 'UTC')::date
+            ELSE date_trunc('week', ph.recorded_at AT TIME ZONE 'UTC')::date END,
+       ph.price, ph.price, ph.price, ph.price, ph.currency, ph.in_stock, 1, ph.recorded_at
+FROM price_history ph
+CROSS JOIN (VALUES ('day'), ('week')) AS g(granularity)
+ON CONFLICT (product_id, shop_id, granularity, bucket_start) DO NOTHING;
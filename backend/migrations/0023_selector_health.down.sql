@@ -0,0 +1,5 @@
+-- 0023_selector_health.down.sql
+-- Откат мониторинга селекторов
+
+DROP TABLE IF EXISTS shop_selector_health;
+DROP TABLE IF EXISTS selector_health_samples;
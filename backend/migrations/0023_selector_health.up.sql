@@ -0,0 +1,37 @@
+-- 0023_selector_health.up.sql
+-- Мониторинг срабатывания селекторов магазинов и очередь повторной авто-конфигурации
+
+------------------------------------------------------------
+-- 1. Результаты селекторов по каждому парсингу (скользящее окно на магазин)
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS selector_health_samples (
+    id           BIGSERIAL PRIMARY KEY,
+    shop_id      VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    url          VARCHAR(1000),
+    outcomes     JSONB NOT NULL,  -- {"name": true, "price": false, ...}
+    observed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_selector_health_samples_shop
+    ON selector_health_samples (shop_id, id DESC);
+
+------------------------------------------------------------
+-- 2. Состояние селекторов магазина
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS shop_selector_health (
+    shop_id             VARCHAR(255) PRIMARY KEY REFERENCES shops(id) ON DELETE CASCADE,
+    status              VARCHAR(20) NOT NULL DEFAULT 'healthy'
+                        CHECK (status IN ('healthy', 'degraded', 'failed')),
+    field_stats         JSONB NOT NULL DEFAULT '{}'::jsonb,
+    degraded_fields     TEXT[] NOT NULL DEFAULT '{}',
+    reason              TEXT,
+    reconfig_attempts   INTEGER NOT NULL DEFAULT 0,
+    last_error          TEXT,
+    degraded_at         TIMESTAMPTZ,
+    reconfigured_at     TIMESTAMPTZ,  -- окно оценки начинается заново с этого момента
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shop_selector_health_degraded
+    ON shop_selector_health (degraded_at)
+    WHERE status = 'degraded';
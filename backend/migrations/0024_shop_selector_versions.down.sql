@@ -0,0 +1,8 @@
+-- 0024_shop_selector_versions.down.sql
+-- Откат версий селекторов
+
+ALTER TABLE shops
+    DROP COLUMN IF EXISTS selectors_pinned,
+    DROP COLUMN IF EXISTS active_selector_version;
+
+DROP TABLE IF EXISTS shop_selector_versions;
@@ -0,0 +1,54 @@
+-- 0024_shop_selector_versions.up.sql
+-- Версии селекторов магазинов: история изменений с автором и источником, закрепление и откат
+
+------------------------------------------------------------
+-- 1. Версии селекторов
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS shop_selector_versions (
+    id            BIGSERIAL PRIMARY KEY,
+    shop_id       VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    version       INTEGER NOT NULL,
+    selectors     JSONB NOT NULL DEFAULT '{}'::jsonb,
+    source        VARCHAR(20) NOT NULL
+                  CHECK (source IN ('manual', 'autoconfig', 'ai')),
+    author        VARCHAR(255),  -- пользователь, генератор или модель AI
+    note          TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activated_at  TIMESTAMPTZ,   -- последняя активация; по нему определяется версия для отката
+    UNIQUE (shop_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shop_selector_versions_activated
+    ON shop_selector_versions (shop_id, activated_at DESC)
+    WHERE activated_at IS NOT NULL;
+
+------------------------------------------------------------
+-- 2. Активная версия магазина; shops.selectors остаётся её копией
+------------------------------------------------------------
+ALTER TABLE shops
+    ADD COLUMN IF NOT EXISTS active_selector_version INTEGER,
+    ADD COLUMN IF NOT EXISTS selectors_pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+------------------------------------------------------------
+-- 3. Текущие селекторы магазинов - первая версия
+------------------------------------------------------------
+INSERT INTO shop_selector_versions (shop_id, version, selectors, source, author, note, created_at, activated_at)
+SELECT s.id, 1,
+       COALESCE(s.selectors, '{}'::jsonb),
+       CASE WHEN COALESCE(s.is_auto_configured, FALSE) THEN 'ai' ELSE 'manual' END,
+       CASE WHEN COALESCE(s.is_auto_configured, FALSE) THEN COALESCE(s.ai_config_model, 'migration') ELSE 'migration' END,
+       'initial version',
+       COALESCE(h.reconfigured_at, s.updated_at, NOW()),
+       COALESCE(h.reconfigured_at, s.updated_at, NOW())
+FROM shops s
+LEFT JOIN shop_selector_health h ON h.shop_id = s.id
+ON CONFLICT (shop_id, version) DO NOTHING;
+
+UPDATE shops s
+SET active_selector_version = v.version
+FROM (
+    SELECT shop_id, MAX(version) AS version
+    FROM shop_selector_versions
+    GROUP BY shop_id
+) v
+WHERE v.shop_id = s.id;
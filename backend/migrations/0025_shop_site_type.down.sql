@@ -0,0 +1,4 @@
+-- 0025_shop_site_type.down.sql
+-- Откат типа сайта магазина
+
+ALTER TABLE shops DROP COLUMN IF EXISTS site_type;
@@ -0,0 +1,13 @@
+-- 0025_shop_site_type.up.sql
+-- Тип сайта магазина: прайс-листы услуг (service_provider) парсятся построчно, каждая строка - отдельная услуга
+
+ALTER TABLE shops
+    ADD COLUMN IF NOT EXISTS site_type VARCHAR(30) NOT NULL DEFAULT 'ecommerce'
+        CHECK (site_type IN ('ecommerce', 'service_provider'));
+
+-- Магазины, созданные авто-конфигурацией, получают тип из классификации кандидата
+UPDATE shops s
+SET site_type = 'service_provider'
+FROM potential_shops ps
+WHERE ps.domain = regexp_replace(regexp_replace(s.base_url, '^https?://', ''), '/.*$', '')
+  AND ps.metadata->>'site_type' = 'service_provider';
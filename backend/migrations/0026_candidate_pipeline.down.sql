@@ -0,0 +1,26 @@
+-- 0026_candidate_pipeline.down.sql
+-- Откат конвейера кандидатов: возврат к прежним статусам potential_shops
+
+DROP TABLE IF EXISTS potential_shop_transitions;
+
+ALTER TABLE potential_shops DROP CONSTRAINT IF EXISTS potential_shops_status_check;
+ALTER TABLE potential_shops ALTER COLUMN status DROP NOT NULL;
+ALTER TABLE potential_shops ALTER COLUMN status SET DEFAULT 'new';
+
+UPDATE potential_shops SET status = 'new' WHERE status = 'discovered';
+UPDATE potential_shops
+SET status = 'pending_review',
+    metadata = metadata - 'needs_review'
+WHERE status = 'classified' AND (metadata->>'needs_review')::boolean;
+UPDATE potential_shops SET status = 'classified' WHERE status = 'configuring';
+UPDATE potential_shops SET status = 'rejected' WHERE status = 'failed';
+UPDATE potential_shops SET status = 'configured' WHERE status IN ('approved', 'live');
+
+DROP INDEX IF EXISTS idx_potential_shops_status_changed;
+
+ALTER TABLE potential_shops
+    DROP COLUMN IF EXISTS state_changed_at,
+    DROP COLUMN IF EXISTS shop_id,
+    DROP COLUMN IF EXISTS config,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,82 @@
+-- 0026_candidate_pipeline.up.sql
+-- Конвейер кандидатов в магазины: явные статусы potential_shops, история переходов, счётчик попыток авто-конфигурации
+--   discovered → classified → configuring → configured | failed → approved | rejected → live
+
+------------------------------------------------------------
+-- 1. Новые поля кандидата
+------------------------------------------------------------
+ALTER TABLE potential_shops
+    ADD COLUMN IF NOT EXISTS attempts         INTEGER NOT NULL DEFAULT 0,  -- неудачные попытки авто-конфигурации
+    ADD COLUMN IF NOT EXISTS last_error       TEXT,
+    ADD COLUMN IF NOT EXISTS config           JSONB,                       -- селекторы, найденные авто-конфигурацией
+    ADD COLUMN IF NOT EXISTS shop_id          VARCHAR(255) REFERENCES shops(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS state_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+------------------------------------------------------------
+-- 2. Перенос прежних статусов
+------------------------------------------------------------
+UPDATE potential_shops SET status = 'discovered' WHERE status IS NULL OR status = 'new';
+
+-- Сомнительные сайты продолжают путь по конвейеру с пометкой для администратора:
+-- магазин всё равно создаётся только после его одобрения (configured → approved)
+UPDATE potential_shops
+SET status = 'classified',
+    metadata = COALESCE(metadata, '{}'::jsonb) || '{"needs_review": true}'::jsonb
+WHERE status = 'pending_review';
+
+-- Отклонённые авто-конфигурацией считаются неудачной конфигурацией
+UPDATE potential_shops
+SET status = 'failed',
+    last_error = metadata->>'autoconfig_error'
+WHERE status = 'rejected'
+  AND metadata ? 'autoconfig_error';
+
+UPDATE potential_shops ps
+SET attempts = a.failed_count
+FROM (
+    SELECT potential_shop_id, COUNT(*) AS failed_count
+    FROM shop_config_attempts
+    WHERE status = 'failed' AND potential_shop_id IS NOT NULL
+    GROUP BY potential_shop_id
+) a
+WHERE a.potential_shop_id = ps.id;
+
+-- Раньше авто-конфигурация сразу создавала активный магазин
+UPDATE potential_shops ps
+SET status = 'live',
+    shop_id = s.id,
+    config = jsonb_build_object('selectors', s.selectors, 'author', s.ai_config_model)
+FROM shops s
+WHERE ps.status IN ('configured', 'active')
+  AND ps.domain = regexp_replace(regexp_replace(s.base_url, '^https?://', ''), '/.*$', '');
+
+UPDATE potential_shops SET status = 'configured' WHERE status = 'active';
+
+ALTER TABLE potential_shops ALTER COLUMN status SET DEFAULT 'discovered';
+ALTER TABLE potential_shops ALTER COLUMN status SET NOT NULL;
+ALTER TABLE potential_shops
+    ADD CONSTRAINT potential_shops_status_check
+        CHECK (status IN ('discovered', 'classified', 'configuring', 'configured', 'failed', 'approved', 'rejected', 'live'));
+
+CREATE INDEX IF NOT EXISTS idx_potential_shops_status_changed ON potential_shops(status, state_changed_at);
+
+------------------------------------------------------------
+-- 3. История переходов
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS potential_shop_transitions (
+    id                BIGSERIAL PRIMARY KEY,
+    potential_shop_id UUID NOT NULL REFERENCES potential_shops(id) ON DELETE CASCADE,
+    from_status       VARCHAR(20),  -- NULL для только что найденного кандидата
+    to_status         VARCHAR(20) NOT NULL,
+    reason            TEXT,
+    actor             VARCHAR(255) NOT NULL,  -- discovery, classifier, autoconfig или администратор
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_potential_shop_transitions_shop
+    ON potential_shop_transitions (potential_shop_id, created_at);
+
+-- Текущее состояние существующих кандидатов - первая запись истории
+INSERT INTO potential_shop_transitions (potential_shop_id, from_status, to_status, reason, actor, created_at)
+SELECT id, NULL, status, 'migrated from legacy status', 'migration', NOW()
+FROM potential_shops;
@@ -0,0 +1,4 @@
+-- 0027_shop_catalog_urls.down.sql
+-- Откат адресов товаров из sitemap
+
+DROP TABLE IF EXISTS shop_catalog_urls;
@@ -0,0 +1,10 @@
+-- 0027_shop_catalog_urls.up.sql
+-- Адреса товаров из sitemap магазинов: lastmod последнего разбора, чтобы повторно парсить только изменившиеся страницы
+
+CREATE TABLE IF NOT EXISTS shop_catalog_urls (
+    shop_id     VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    url         TEXT NOT NULL,
+    lastmod     TIMESTAMPTZ,                       -- NULL, если sitemap не указывает lastmod
+    scraped_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (shop_id, url)
+);
@@ -0,0 +1,7 @@
+-- 0028_scrape_schedule.down.sql
+-- Откат расписания повторного парсинга
+
+DROP TABLE IF EXISTS shop_scrape_budget_usage;
+DROP TABLE IF EXISTS scrape_schedule;
+
+ALTER TABLE shops DROP COLUMN IF EXISTS scrape_budget;
@@ -0,0 +1,46 @@
+-- 0028_scrape_schedule.up.sql
+-- Расписание повторного парсинга: время следующего посещения каждого адреса и суточный бюджет магазинов
+
+------------------------------------------------------------
+-- 1. Бюджет магазина: сколько страниц в сутки можно запросить повторно
+------------------------------------------------------------
+ALTER TABLE shops
+    ADD COLUMN IF NOT EXISTS scrape_budget INTEGER NOT NULL DEFAULT 2000 CHECK (scrape_budget >= 0);
+
+------------------------------------------------------------
+-- 2. Расписание адресов
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS scrape_schedule (
+    shop_id           VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    url               TEXT NOT NULL,
+    next_visit_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    priority          DOUBLE PRECISION NOT NULL DEFAULT 1,
+    interval_seconds  INTEGER NOT NULL DEFAULT 0,        -- последний рассчитанный интервал
+    failures          INTEGER NOT NULL DEFAULT 0,        -- неудачных парсингов подряд
+    last_error        TEXT,
+    last_visited_at   TIMESTAMPTZ,
+    dispatched_at     TIMESTAMPTZ,                       -- когда задание отправлено в очередь
+    PRIMARY KEY (shop_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_schedule_next_visit ON scrape_schedule (next_visit_at);
+
+------------------------------------------------------------
+-- 3. Расход бюджета магазинов по дням
+------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS shop_scrape_budget_usage (
+    shop_id     VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    day         DATE NOT NULL,
+    dispatched  INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (shop_id, day)
+);
+
+------------------------------------------------------------
+-- 4. Начальное расписание из текущих цен: прежний интервал 6 часов от последнего обновления
+------------------------------------------------------------
+INSERT INTO scrape_schedule (shop_id, url, next_visit_at, last_visited_at)
+SELECT shop_id, url, COALESCE(MAX(updated_at), NOW()) + INTERVAL '6 hours', MAX(updated_at)
+FROM product_prices
+WHERE url IS NOT NULL AND url <> ''
+GROUP BY shop_id, url
+ON CONFLICT (shop_id, url) DO NOTHING;
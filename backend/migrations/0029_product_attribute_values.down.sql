@@ -0,0 +1,5 @@
+-- 0029_product_attribute_values.down.sql
+-- Откат типизированных значений характеристик
+
+DROP TABLE IF EXISTS product_attribute_values;
+DROP TABLE IF EXISTS attribute_aliases;
@@ -0,0 +1,29 @@
+-- 0029_product_attribute_values.up.sql
+-- Типизированные значения характеристик товаров и названия характеристик у магазинов ("Radna memorija" -> RAM)
+
+CREATE TABLE IF NOT EXISTS attribute_aliases (
+    alias         TEXT PRIMARY KEY,                 -- нормализованное название: нижний регистр, латиница без диакритики
+    attribute_id  UUID NOT NULL REFERENCES attributes(id) ON DELETE CASCADE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS product_attribute_values (
+    product_id    UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    attribute_id  UUID NOT NULL REFERENCES attributes(id) ON DELETE CASCADE,
+    value_text    TEXT,                             -- string/enum
+    value_number  DOUBLE PRECISION,                 -- int/float в единице атрибута (attributes.unit_sr)
+    value_bool    BOOLEAN,
+    unit          TEXT,
+    raw_value     TEXT NOT NULL,                    -- значение как у магазина
+    source_label  TEXT,                             -- название характеристики у магазина
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, attribute_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_attribute_values_number
+    ON product_attribute_values (attribute_id, value_number)
+    WHERE value_number IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_attribute_values_text
+    ON product_attribute_values (attribute_id, value_text)
+    WHERE value_text IS NOT NULL;
@@ -0,0 +1,10 @@
+-- 0030_product_categorization.down.sql
+-- Откат автоматического определения категорий
+
+DROP INDEX IF EXISTS idx_products_uncategorized;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS category_confidence,
+    DROP COLUMN IF EXISTS category_source;
+
+DROP TABLE IF EXISTS shop_category_mappings;
@@ -0,0 +1,23 @@
+-- 0030_product_categorization.up.sql
+-- Автоматическое определение категории и типа товара: сопоставления категорий магазинов и уверенность решения
+
+CREATE TABLE IF NOT EXISTS shop_category_mappings (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    shop_id          VARCHAR(255) NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    source_category  TEXT NOT NULL,                    -- нормализованный путь категории магазина: "tv audio video > televizori"
+    category_id      UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    product_type_id  UUID NULL REFERENCES product_types(id) ON DELETE SET NULL,
+    confidence       REAL NOT NULL DEFAULT 1.0 CHECK (confidence >= 0 AND confidence <= 1),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (shop_id, source_category)
+);
+
+-- Источник и уверенность автоматически назначенной категории; NULL - категория задана вручную
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS category_source TEXT NULL,
+    ADD COLUMN IF NOT EXISTS category_confidence REAL NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_uncategorized
+    ON products (id)
+    WHERE category_id IS NULL;
@@ -0,0 +1,5 @@
+-- 0031_brands.down.sql
+-- Откат справочника брендов
+
+DROP TABLE IF EXISTS brand_aliases;
+DROP TABLE IF EXISTS brands;
@@ -0,0 +1,53 @@
+-- 0031_brands.up.sql
+-- Справочник брендов: каноническое название, синонимы у магазинов, логотип и производитель-владелец
+
+CREATE TABLE IF NOT EXISTS brands (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name             TEXT NOT NULL UNIQUE,           -- каноническое написание: "HP", "LG", "New Balance"
+    slug             TEXT NOT NULL UNIQUE,
+    logo_url         TEXT NULL,
+    manufacturer_id  UUID NULL REFERENCES brands(id) ON DELETE SET NULL, -- владелец бренда: Redmi -> Xiaomi
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS brand_aliases (
+    alias_key   TEXT PRIMARY KEY,                    -- ключ написания: нижний регистр, латиница без диакритики, без пробелов и знаков
+    alias       TEXT NOT NULL,                       -- написание как у магазина
+    brand_id    UUID NOT NULL REFERENCES brands(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_brand_aliases_brand_id ON brand_aliases(brand_id);
+
+-- Стартовый справочник (ранее - встроенные списки в matching и processor)
+INSERT INTO brands (name, slug)
+VALUES
+    ('Apple', 'apple'), ('Samsung', 'samsung'), ('Xiaomi', 'xiaomi'), ('Huawei', 'huawei'),
+    ('Motorola', 'motorola'), ('LG', 'lg'), ('Sony', 'sony'), ('Nokia', 'nokia'),
+    ('OnePlus', 'oneplus'), ('OPPO', 'oppo'), ('vivo', 'vivo'), ('realme', 'realme'),
+    ('Honor', 'honor'), ('HP', 'hp'), ('Lenovo', 'lenovo'), ('ASUS', 'asus'), ('Acer', 'acer'),
+    ('Dell', 'dell'), ('MSI', 'msi'), ('TCL', 'tcl'), ('Hisense', 'hisense'), ('Philips', 'philips'),
+    ('Nike', 'nike'), ('adidas', 'adidas'), ('PUMA', 'puma'), ('New Balance', 'new-balance'),
+    ('Imlek', 'imlek'), ('Dukat', 'dukat')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO brands (name, slug, manufacturer_id)
+SELECT v.name, v.slug, m.id
+FROM (VALUES ('Redmi', 'redmi', 'Xiaomi'), ('POCO', 'poco', 'Xiaomi'), ('Beats', 'beats', 'Apple')) AS v(name, slug, manufacturer)
+JOIN brands m ON m.name = v.manufacturer
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO brand_aliases (alias_key, alias, brand_id)
+SELECT v.alias_key, v.alias, b.id
+FROM (VALUES
+    ('hewlettpackard', 'Hewlett-Packard', 'HP'),
+    ('lgelectronics', 'LG Electronics', 'LG'),
+    ('samsungelectronics', 'Samsung Electronics', 'Samsung'),
+    ('xiaomimi', 'Xiaomi Mi', 'Xiaomi'),
+    ('mi', 'Mi', 'Xiaomi'),
+    ('asustek', 'ASUSTeK', 'ASUS'),
+    ('nb', 'NB', 'New Balance')
+) AS v(alias_key, alias, brand)
+JOIN brands b ON b.name = v.brand
+ON CONFLICT (alias_key) DO NOTHING;
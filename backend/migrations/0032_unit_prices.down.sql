@@ -0,0 +1,10 @@
+-- 0032_unit_prices.down.sql
+-- Откат фасовки предложений и цены за единицу
+
+DROP INDEX IF EXISTS idx_product_prices_unit_price;
+
+ALTER TABLE product_prices
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS pack_unit,
+    DROP COLUMN IF EXISTS pack_amount,
+    DROP COLUMN IF EXISTS pack_count;
@@ -0,0 +1,16 @@
+-- 0032_unit_prices.up.sql
+-- Фасовка предложений и цена за единицу (1 кг, 1 л, 1 шт): сравнение 1 л и 6x0.5 л
+
+ALTER TABLE product_prices
+    ADD COLUMN IF NOT EXISTS pack_count  INTEGER CHECK (pack_count > 0),
+    ADD COLUMN IF NOT EXISTS pack_amount NUMERIC(12, 4) CHECK (pack_amount > 0),
+    ADD COLUMN IF NOT EXISTS pack_unit   VARCHAR(8) CHECK (pack_unit IN ('kg', 'l', 'pcs')),
+    ADD COLUMN IF NOT EXISTS unit_price  NUMERIC(12, 2);
+
+COMMENT ON COLUMN product_prices.pack_count IS 'Число упаковок в мультипаке (6 для 6x0.5 l)';
+COMMENT ON COLUMN product_prices.pack_amount IS 'Количество в одной упаковке в базовой единице pack_unit';
+COMMENT ON COLUMN product_prices.unit_price IS 'Цена за 1 кг, 1 л или 1 шт';
+
+CREATE INDEX IF NOT EXISTS idx_product_prices_unit_price
+    ON product_prices(product_id, unit_price)
+    WHERE unit_price IS NOT NULL;
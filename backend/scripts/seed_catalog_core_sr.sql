@@ -62,6 +62,7 @@ VALUES
     ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb104', 'CPU', 'Procesor', 'string', NULL, TRUE, FALSE),
     ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb105', 'GPU', 'Grafička kartica', 'string', NULL, TRUE, FALSE),
     ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb106', 'OS', 'Operativni sistem', 'string', NULL, TRUE, FALSE),
+    ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb107', 'BATTERY_CAPACITY', 'Kapacitet baterije', 'int', 'mAh', TRUE, TRUE),
     -- Для молока
     ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb201', 'FAT_PERCENT', 'Procenat masti', 'float', '%', TRUE, TRUE),
     ('bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb202', 'VOLUME_L', 'Zapremina', 'float', 'l', TRUE, TRUE),
@@ -84,7 +85,8 @@ VALUES
     ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb101', TRUE, 20),
     ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb102', TRUE, 30),
     ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb103', FALSE, 40),
-    ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb106', FALSE, 50)
+    ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb106', FALSE, 50),
+    ('aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaa1', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbb107', FALSE, 60)
 ON CONFLICT DO NOTHING;
 
 -- LAPTOP